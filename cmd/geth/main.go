@@ -100,6 +100,8 @@ var (
 		utils.BlobPoolDataCapFlag,
 		utils.BlobPoolPriceBumpFlag,
 		utils.SyncModeFlag,
+		utils.SyncImportGasFlag,
+		utils.SyncProgressIntervalFlag,
 		utils.TriesVerifyModeFlag,
 		// utils.SyncTargetFlag,
 		utils.ExitWhenSyncedFlag,
@@ -108,6 +110,7 @@ var (
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
 		utils.BlockHistoryFlag,
+		utils.BlockAccessListsFlag,
 		utils.StateHistoryFlag,
 		utils.PathDBSyncFlag,
 		utils.JournalFileFlag,
@@ -132,6 +135,8 @@ var (
 		// utils.CacheNoPrefetchFlag,
 		utils.CacheEnableSharedStorageFlag,
 		utils.CachePreimagesFlag,
+		utils.CacheHeaderWarmupFlag,
+		utils.CacheCodeJournalFlag,
 		utils.MultiDataBaseFlag,
 		utils.PruneAncientDataFlag, // deprecated
 		utils.CacheLogSizeFlag,
@@ -168,6 +173,8 @@ var (
 		utils.VMEnableDebugFlag,
 		utils.VMTraceFlag,
 		utils.VMTraceJsonConfigFlag,
+		utils.VMParallelWorkersFlag,
+		utils.VMStaticCallMemoFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
 		utils.NoCompactionFlag,
@@ -28,6 +28,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -97,6 +98,11 @@ var (
 		Value:    flags.DirectoryString(node.DefaultDataDir()),
 		Category: flags.EthCategory,
 	}
+	DataDirLockTimeoutFlag = &cli.DurationFlag{
+		Name:     "datadir.locktimeout",
+		Usage:    "Time to wait for another process to release the data directory lock (0 = fail immediately)",
+		Category: flags.EthCategory,
+	}
 	MultiDataBaseFlag = &cli.BoolFlag{
 		Name: "multidatabase",
 		Usage: "Enable a separated state database, it will be created subdirectory called state, " +
@@ -129,11 +135,33 @@ var (
 		Value:    node.DefaultConfig.DBEngine,
 		Category: flags.EthCategory,
 	}
+	DBKeyspacesFlag = &cli.BoolFlag{
+		Name:     "db.keyspaces",
+		Usage:    "Partition the data of a new pebble database into separately managed keyspaces",
+		Category: flags.EthCategory,
+	}
 	AncientFlag = &flags.DirectoryFlag{
 		Name:     "datadir.ancient",
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	AncientRemoteFlag = &cli.StringFlag{
+		Name:     "datadir.ancient.remote",
+		Usage:    "S3 compatible URL (endpoint/bucket/prefix) to offload sealed ancient files into, credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY",
+		Category: flags.EthCategory,
+	}
+	AncientRemoteRegionFlag = &cli.StringFlag{
+		Name:     "datadir.ancient.remote.region",
+		Usage:    "Region of the object store the ancient files are offloaded into",
+		Value:    "auto",
+		Category: flags.EthCategory,
+	}
+	AncientRemoteCacheFlag = &cli.Uint64Flag{
+		Name:     "datadir.ancient.remote.cache",
+		Usage:    "Size of offloaded ancient files retained locally (in megabytes)",
+		Value:    4096,
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -344,6 +372,18 @@ var (
 		Value:    ethconfig.Defaults.SyncMode.String(),
 		Category: flags.StateCategory,
 	}
+	SyncImportGasFlag = &cli.Uint64Flag{
+		Name:     "syncmode.importgas",
+		Usage:    "Gas budget of the batches the downloaded blocks are imported in (0 = split by count only)",
+		Value:    ethconfig.Defaults.ImportBatchGas,
+		Category: flags.StateCategory,
+	}
+	SyncProgressIntervalFlag = &cli.Uint64Flag{
+		Name:     "syncmode.progressinterval",
+		Usage:    "Number of imported blocks between the import progress events (0 = disabled)",
+		Value:    ethconfig.Defaults.ImportProgressInterval,
+		Category: flags.StateCategory,
+	}
 	GCModeFlag = &cli.StringFlag{
 		Name:     "gcmode",
 		Usage:    `Blockchain garbage collection mode, only relevant in state.scheme=hash ("full", "archive")`,
@@ -385,6 +425,11 @@ var (
 		Value:    ethconfig.Defaults.BlockHistory,
 		Category: flags.BlockHistoryCategory,
 	}
+	BlockAccessListsFlag = &cli.BoolFlag{
+		Name:     "history.accesslists",
+		Usage:    "Produce and store the access lists of the imported blocks",
+		Category: flags.BlockHistoryCategory,
+	}
 	// Beacon client light sync settings
 	BeaconApiFlag = &cli.StringSliceFlag{
 		Name:     "beacon.api",
@@ -580,6 +625,16 @@ var (
 		Usage:    "Enable recording the SHA3/keccak preimages of trie keys",
 		Category: flags.PerfCategory,
 	}
+	CacheHeaderWarmupFlag = &cli.Uint64Flag{
+		Name:     "cache.headerwarmup",
+		Usage:    "Number of recent headers preloaded into the caches on startup (0 = disabled)",
+		Category: flags.PerfCategory,
+	}
+	CacheCodeJournalFlag = &cli.BoolFlag{
+		Name:     "cache.codejournal",
+		Usage:    "Persist the hot contract codes and their analyses across restarts",
+		Category: flags.PerfCategory,
+	}
 	CacheLogSizeFlag = &cli.IntFlag{
 		Name:     "cache.blocklogs",
 		Usage:    "Size (in number of blocks) of the log cache for filtering",
@@ -681,6 +736,16 @@ var (
 		Value:    "{}",
 		Category: flags.VMCategory,
 	}
+	VMParallelWorkersFlag = &cli.IntFlag{
+		Name:     "vm.parallel",
+		Usage:    "Number of workers speculatively executing block transactions in parallel (0 = serial)",
+		Category: flags.VMCategory,
+	}
+	VMStaticCallMemoFlag = &cli.IntFlag{
+		Name:     "vm.staticcallmemo",
+		Usage:    "Number of pure static call results memoized per block (0 = disabled)",
+		Category: flags.VMCategory,
+	}
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
 		Name:     "rpc.gascap",
@@ -1254,14 +1319,44 @@ var (
 	// DatabaseFlags is the flag group of all database flags.
 	DatabaseFlags = []cli.Flag{
 		DataDirFlag,
+		DataDirLockTimeoutFlag,
 		AncientFlag,
+		AncientRemoteFlag,
+		AncientRemoteRegionFlag,
+		AncientRemoteCacheFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
+		DBKeyspacesFlag,
 		StateSchemeFlag,
 		HttpHeaderFlag,
 	}
 )
 
+// makeRemoteAncient creates the configuration of offloading the sealed ancient
+// files into the S3 compatible object store requested on the command line.
+func makeRemoteAncient(ctx *cli.Context) *rawdb.RemoteFreezerConfig {
+	target, err := url.Parse(ctx.String(AncientRemoteFlag.Name))
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		Fatalf("Invalid --%s URL: %q", AncientRemoteFlag.Name, ctx.String(AncientRemoteFlag.Name))
+	}
+	bucket, prefix, _ := strings.Cut(strings.Trim(target.Path, "/"), "/")
+	store, err := rawdb.NewS3Store(rawdb.S3Config{
+		Endpoint:  target.Scheme + "://" + target.Host,
+		Region:    ctx.String(AncientRemoteRegionFlag.Name),
+		Bucket:    bucket,
+		AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+	})
+	if err != nil {
+		Fatalf("Failed to set up the ancient object store: %v", err)
+	}
+	return &rawdb.RemoteFreezerConfig{
+		Store:     store,
+		Prefix:    prefix,
+		CacheSize: ctx.Uint64(AncientRemoteCacheFlag.Name) * 1024 * 1024,
+	}
+}
+
 // MakeDataDir retrieves the currently requested data directory, terminating
 // if none (or the empty string) is specified. If the node is starting a testnet,
 // then a subdirectory of the specified datadir will be used.
@@ -1747,6 +1842,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
 		cfg.DBEngine = dbEngine
 	}
+	if ctx.IsSet(DBKeyspacesFlag.Name) {
+		cfg.DBKeyspaces = ctx.Bool(DBKeyspacesFlag.Name)
+	}
+	if ctx.IsSet(AncientRemoteFlag.Name) {
+		cfg.DBRemoteAncient = makeRemoteAncient(ctx)
+	}
 	// deprecation notice for log debug flags (TODO: find a more appropriate place to put these?)
 	if ctx.IsSet(LogBacktraceAtFlag.Name) {
 		log.Warn("log.backtrace flag is deprecated")
@@ -1783,6 +1884,9 @@ func SetDataDir(ctx *cli.Context, cfg *node.Config) {
 	case ctx.Bool(DeveloperFlag.Name):
 		cfg.DataDir = "" // unless explicitly requested, use memory databases
 	}
+	if ctx.IsSet(DataDirLockTimeoutFlag.Name) {
+		cfg.DataDirLockTimeout = ctx.Duration(DataDirLockTimeoutFlag.Name)
+	}
 }
 
 func setVoteJournalDir(ctx *cli.Context, cfg *node.Config) {
@@ -2026,6 +2130,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			Fatalf("invalid --syncmode flag: %v", err)
 		}
 	}
+	if ctx.IsSet(SyncImportGasFlag.Name) {
+		cfg.ImportBatchGas = ctx.Uint64(SyncImportGasFlag.Name)
+	}
+	if ctx.IsSet(SyncProgressIntervalFlag.Name) {
+		cfg.ImportProgressInterval = ctx.Uint64(SyncProgressIntervalFlag.Name)
+	}
 	if ctx.IsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.Uint64(NetworkIdFlag.Name)
 	}
@@ -2058,6 +2168,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.IsSet(CacheHeaderWarmupFlag.Name) {
+		cfg.HeaderCacheWarmup = ctx.Uint64(CacheHeaderWarmupFlag.Name)
+	}
+	if ctx.IsSet(CacheCodeJournalFlag.Name) {
+		cfg.CodeCacheJournal = ctx.Bool(CacheCodeJournalFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.Bool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -2091,6 +2207,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.BlockHistory = params.FullImmutabilityThreshold
 		}
 	}
+	if ctx.IsSet(BlockAccessListsFlag.Name) {
+		cfg.BlockAccessLists = ctx.Bool(BlockAccessListsFlag.Name)
+	}
 	if ctx.IsSet(PathDBSyncFlag.Name) {
 		cfg.PathSyncFlush = true
 	}
@@ -2164,6 +2283,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMParallelWorkersFlag.Name) {
+		cfg.ParallelWorkers = ctx.Int(VMParallelWorkersFlag.Name)
+	}
+	if ctx.IsSet(VMStaticCallMemoFlag.Name) {
+		cfg.StaticCallMemo = ctx.Int(VMStaticCallMemoFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -142,6 +142,20 @@ func (c *BasicLRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
 	return key, item.value, true
 }
 
+// Resize changes the capacity of the cache, evicting the least recently used
+// items if it shrinks. Returns the number of evicted items.
+func (c *BasicLRU[K, V]) Resize(capacity int) (evicted int) {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	for c.Len() > capacity {
+		c.RemoveOldest()
+		evicted++
+	}
+	c.cap = capacity
+	return evicted
+}
+
 // Keys returns all keys in the cache.
 func (c *BasicLRU[K, V]) Keys() []K {
 	keys := make([]K, 0, len(c.items))
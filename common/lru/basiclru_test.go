@@ -184,6 +184,30 @@ func TestBasicLRUPeek(t *testing.T) {
 	}
 }
 
+// Test that Resize evicts the least recently used items when shrinking.
+func TestBasicLRUResize(t *testing.T) {
+	cache := NewBasicLRU[int, int](4)
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+	cache.Get(0)
+	if evicted := cache.Resize(2); evicted != 2 {
+		t.Fatalf("wrong number of evicted items: have %d, want 2", evicted)
+	}
+	if !cache.Contains(0) || !cache.Contains(3) || cache.Len() != 2 {
+		t.Fatalf("wrong items retained: %v", cache.Keys())
+	}
+	cache.Add(4, 4)
+	if cache.Contains(3) || cache.Len() != 2 {
+		t.Fatalf("shrunk capacity not honoured: %v", cache.Keys())
+	}
+	cache.Resize(3)
+	cache.Add(5, 5)
+	if cache.Len() != 3 {
+		t.Fatalf("grown capacity not honoured: %v", cache.Keys())
+	}
+}
+
 func BenchmarkLRU(b *testing.B) {
 	var (
 		capacity = 1000
@@ -86,6 +86,15 @@ func (c *Cache[K, V]) Remove(key K) bool {
 	return c.cache.Remove(key)
 }
 
+// Resize changes the capacity of the cache, evicting the least recently used
+// items if it shrinks. Returns the number of evicted items.
+func (c *Cache[K, V]) Resize(capacity int) (evicted int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cache.Resize(capacity)
+}
+
 // Keys returns all keys of items currently in the LRU.
 func (c *Cache[K, V]) Keys() []K {
 	c.mu.Lock()
@@ -427,7 +427,7 @@ func (beacon *Beacon) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 	header.Root = state.IntermediateRoot(true)
 
 	// Assemble the final block.
-	block := types.NewBlock(header, body, receipts, trie.NewStackTrie(nil))
+	block := types.NewBlock(header, body, receipts, trie.NewRootHasher(chain.Config()))
 
 	// Create the block witness and attach to block.
 	// This step needs to happen as late as possible to catch all access events.
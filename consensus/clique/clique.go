@@ -19,6 +19,7 @@ package clique
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -182,9 +183,10 @@ type Clique struct {
 
 	proposals map[common.Address]bool // Current list of proposals we are pushing
 
-	signer common.Address // Ethereum address of the signing key
-	signFn SignerFn       // Signer function to authorize hashes with
-	lock   sync.RWMutex   // Protects the signer and proposals fields
+	signer       common.Address         // Ethereum address of the signing key
+	signFn       SignerFn               // Signer function to authorize hashes with
+	headerSigner consensus.HeaderSigner // External signer used instead of signFn, if set
+	lock         sync.RWMutex           // Protects the signer and proposals fields
 
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
@@ -357,7 +359,7 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 		if header.BaseFee != nil {
 			return fmt.Errorf("invalid baseFee before fork: have %d, want <nil>", header.BaseFee)
 		}
-		if err := misc.VerifyGaslimit(parent.GasLimit, header.GasLimit); err != nil {
+		if err := misc.VerifyHeaderGaslimit(chain.Config(), parent.GasLimit, header); err != nil {
 			return err
 		}
 	} else if err := eip1559.VerifyEIP1559Header(chain.Config(), parent, header); err != nil {
@@ -465,6 +467,22 @@ func (c *Clique) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	return snap, err
 }
 
+// ValidatorsAt implements consensus.ValidatorSetReader, returning the signers
+// authorized after the given header.
+func (c *Clique) ValidatorsAt(chain consensus.ChainHeaderReader, header *types.Header) ([]common.Address, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// RecoverSigner implements consensus.SignerRecoverer, returning the signer of
+// the header and caching it for the seal checks.
+func (c *Clique) RecoverSigner(header *types.Header) (common.Address, error) {
+	return ecrecover(header, c.signatures)
+}
+
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (c *Clique) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
@@ -611,7 +629,7 @@ func (c *Clique) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
 	// Assemble and return the final block for sealing.
-	return types.NewBlock(header, &types.Body{Transactions: body.Transactions}, receipts, trie.NewStackTrie(nil)), receipts, nil
+	return types.NewBlock(header, &types.Body{Transactions: body.Transactions}, receipts, trie.NewRootHasher(chain.Config())), receipts, nil
 }
 
 // Authorize injects a private key into the consensus engine to mint new blocks
@@ -624,6 +642,15 @@ func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// SetHeaderSigner delegates signing the sealed headers to an external signer,
+// instead of the signer function passed to Authorize.
+func (c *Clique) SetHeaderSigner(signer consensus.HeaderSigner) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.headerSigner = signer
+}
+
 func (c *Clique) Delay(chain consensus.ChainReader, header *types.Header, leftOver *time.Duration) *time.Duration {
 	return nil
 }
@@ -644,7 +671,7 @@ func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 	}
 	// Don't hold the signer fields for the entire sealing procedure
 	c.lock.RLock()
-	signer, signFn := c.signer, c.signFn
+	signer, signFn, headerSigner := c.signer, c.signFn, c.headerSigner
 	c.lock.RUnlock()
 
 	// Bail out if we're unauthorized to sign a block
@@ -674,7 +701,19 @@ func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 		log.Trace("Out-of-turn signing requested", "wiggle", common.PrettyDuration(wiggle))
 	}
 	// Sign all the things!
-	sighash, err := signFn(accounts.Account{Address: signer}, accounts.MimetypeClique, CliqueRLP(header))
+	var sighash []byte
+	if headerSigner != nil {
+		sighash, err = headerSigner.SignHeader(context.Background(), &consensus.HeaderSignRequest{
+			Signer:   signer,
+			Hash:     SealHash(header),
+			Number:   number,
+			ChainID:  chain.Config().ChainID,
+			MimeType: accounts.MimetypeClique,
+			Message:  CliqueRLP(header),
+		})
+	} else {
+		sighash, err = signFn(accounts.Account{Address: signer}, accounts.MimetypeClique, CliqueRLP(header))
+	}
 	if err != nil {
 		return err
 	}
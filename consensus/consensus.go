@@ -164,3 +164,63 @@ type PoSA interface {
 	IsActiveValidatorAt(chain ChainHeaderReader, header *types.Header, checkVoteKeyFn func(bLSPublicKey *types.BLSPublicKey) bool) bool
 	NextProposalBlock(chain ChainHeaderReader, header *types.Header, proposer common.Address) (uint64, uint64, error)
 }
+
+// BatchSealVerifier verifies the seals of a batch of headers in one go. It allows
+// the signature checks of long header chains to be offloaded to dedicated
+// verifiers, e.g. aggregate BLS verification or GPU accelerated secp256k1.
+type BatchSealVerifier interface {
+	// VerifySeals checks the seals of a batch of headers ordered by number, but
+	// not necessarily contiguous, returning one result per header in the order
+	// of the input slice.
+	VerifySeals(chain ChainHeaderReader, headers []*types.Header) []error
+}
+
+// SealDeferringEngine is implemented by consensus engines able to verify headers
+// without recovering their signers, leaving the signature checks to a
+// BatchSealVerifier. Only engines whose headers name their signer can do so.
+type SealDeferringEngine interface {
+	Engine
+
+	// VerifyHeadersWithoutSeals is similar to VerifyHeaders, but takes the
+	// headers to be signed by the signer they name instead of recovering it.
+	// All the rules depending on the signer are still checked.
+	VerifyHeadersWithoutSeals(chain ChainHeaderReader, headers []*types.Header) (chan<- struct{}, <-chan error)
+}
+
+// SealSamplingEngine is implemented by consensus engines able to verify a batch
+// of headers while recovering only selected signers, used to spot check trusted
+// header ranges.
+type SealSamplingEngine interface {
+	Engine
+
+	// VerifyHeadersSampled is similar to VerifyHeaders, but only recovers the
+	// signers of the headers flagged in seals, taking the others to be signed
+	// by the signer they name. All the rules depending on the signer, e.g. the
+	// difficulty, are still checked.
+	VerifyHeadersSampled(chain ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error)
+}
+
+// ValidatorSetReader is implemented by consensus engines with a known set of
+// block producers, e.g. the signers of clique or the validators of parlia.
+type ValidatorSetReader interface {
+	// ValidatorsAt returns the validator set in effect after the given header,
+	// sorted by address.
+	ValidatorsAt(chain ChainHeaderReader, header *types.Header) ([]common.Address, error)
+}
+
+// SignerRecoverer is implemented by consensus engines sealing headers with
+// recoverable signatures. Recovered signers are cached by the engine, allowing
+// them to be recovered concurrently ahead of the header verification.
+type SignerRecoverer interface {
+	// RecoverSigner returns the address of the account which sealed the header.
+	RecoverSigner(header *types.Header) (common.Address, error)
+}
+
+// ExtraDataValidator checks the extra-data of headers against the layout rules
+// of a chain, on top of the checks of the consensus engine, e.g. the vanity and
+// signature layout of a signed extra-data or size limits changing with forks.
+type ExtraDataValidator interface {
+	// ValidateExtra returns an error if the extra-data of the header violates
+	// the rules of the chain.
+	ValidateExtra(config *params.ChainConfig, header *types.Header) error
+}
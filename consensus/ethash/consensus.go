@@ -258,7 +258,7 @@ func (ethash *Ethash) verifyHeader(chain consensus.ChainHeaderReader, header, pa
 		if header.BaseFee != nil {
 			return fmt.Errorf("invalid baseFee before fork: have %d, expected 'nil'", header.BaseFee)
 		}
-		if err := misc.VerifyGaslimit(parent.GasLimit, header.GasLimit); err != nil {
+		if err := misc.VerifyHeaderGaslimit(chain.Config(), parent.GasLimit, header); err != nil {
 			return err
 		}
 	} else if err := eip1559.VerifyEIP1559Header(chain.Config(), parent, header); err != nil {
@@ -534,7 +534,7 @@ func (ethash *Ethash) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
 	// Header seems complete, assemble into a block and return
-	return types.NewBlock(header, &types.Body{Transactions: body.Transactions, Uncles: body.Uncles}, receipts, trie.NewStackTrie(nil)), receipts, nil
+	return types.NewBlock(header, &types.Body{Transactions: body.Transactions, Uncles: body.Uncles}, receipts, trie.NewRootHasher(chain.Config())), receipts, nil
 }
 
 func (ethash *Ethash) Delay(_ consensus.ChainReader, _ *types.Header, _ *time.Duration) *time.Duration {
@@ -580,32 +580,60 @@ func (ethash *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 	return hash
 }
 
-// accumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
-func accumulateRewards(config *params.ChainConfig, stateDB vm.StateDB, header *types.Header, uncles []*types.Header) {
-	// Select the correct block reward based on chain progression
-	blockReward := FrontierBlockReward
-	if config.IsByzantium(header.Number) {
-		blockReward = ByzantiumBlockReward
+// blockReward returns the static block reward of the given block based on chain
+// progression and the configured reward schedule.
+func blockReward(config *params.ChainConfig, number *big.Int) *uint256.Int {
+	if scheduled := config.BlockReward(number); scheduled != nil {
+		// Rewards beyond 256 bits are rejected by the config checks, clamp them
+		// for configs that skipped the checks
+		reward, overflow := uint256.FromBig(scheduled)
+		if overflow {
+			return new(uint256.Int).SetAllOne()
+		}
+		return reward
 	}
-	if config.IsConstantinople(header.Number) {
-		blockReward = ConstantinopleBlockReward
+	reward := FrontierBlockReward
+	if config.IsByzantium(number) {
+		reward = ByzantiumBlockReward
 	}
-	// Accumulate the rewards for the miner and any included uncles
-	reward := new(uint256.Int).Set(blockReward)
-	r := new(uint256.Int)
-	hNum, _ := uint256.FromBig(header.Number)
-	for _, uncle := range uncles {
+	if config.IsConstantinople(number) {
+		reward = ConstantinopleBlockReward
+	}
+	return reward
+}
+
+// UncleRewards returns the reward credited to the coinbase of each of the given
+// uncles, along with the reward credited to the coinbase of the including block
+// for each uncle included.
+func UncleRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) ([]*uint256.Int, *uint256.Int) {
+	var (
+		reward  = blockReward(config, header.Number)
+		hNum, _ = uint256.FromBig(header.Number)
+		rewards = make([]*uint256.Int, len(uncles))
+	)
+	for i, uncle := range uncles {
 		uNum, _ := uint256.FromBig(uncle.Number)
-		r.AddUint64(uNum, 8)
+		r := new(uint256.Int).AddUint64(uNum, 8)
 		r.Sub(r, hNum)
-		r.Mul(r, blockReward)
+		r.Mul(r, reward)
 		r.Rsh(r, 3)
-		stateDB.AddBalance(uncle.Coinbase, r, tracing.BalanceIncreaseRewardMineUncle)
+		rewards[i] = r
+	}
+	return rewards, new(uint256.Int).Rsh(reward, 5)
+}
 
-		r.Rsh(blockReward, 5)
-		reward.Add(reward, r)
+// accumulateRewards credits the coinbase of the given block with the mining
+// reward. The total reward consists of the static block reward and rewards for
+// included uncles. The coinbase of each uncle block is also rewarded.
+func accumulateRewards(config *params.ChainConfig, stateDB vm.StateDB, header *types.Header, uncles []*types.Header) {
+	// Accumulate the rewards for the miner and any included uncles
+	var (
+		reward                  = new(uint256.Int).Set(blockReward(config, header.Number))
+		uncleRewards, inclusion = UncleRewards(config, header, uncles)
+	)
+	for i, uncle := range uncles {
+		stateDB.AddBalance(uncle.Coinbase, uncleRewards[i], tracing.BalanceIncreaseRewardMineUncle)
+		reward.Add(reward, inclusion)
 	}
 	stateDB.AddBalance(header.Coinbase, reward, tracing.BalanceIncreaseRewardMineBlock)
 }
@@ -0,0 +1,138 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// errSignerMismatch is returned if an external signer produced a signature that
+// doesn't recover to the requested signer.
+var errSignerMismatch = errors.New("signature from unexpected signer")
+
+var (
+	externalSignRequestMeter = metrics.NewRegisteredMeter("consensus/signer/requests", nil)
+	externalSignFailureMeter = metrics.NewRegisteredMeter("consensus/signer/failures", nil)
+	externalSignTimer        = metrics.NewRegisteredTimer("consensus/signer/duration", nil)
+)
+
+// HeaderSignRequest is the context of a header signature delegated to a signer
+// holding the sealing key outside of the node.
+type HeaderSignRequest struct {
+	Signer   common.Address // Account expected to sign the header
+	Hash     common.Hash    // Seal hash of the header, the digest to sign
+	Number   uint64         // Number of the header
+	ChainID  *big.Int       // Chain the header belongs to
+	MimeType string         // Content type of the message, e.g. accounts.MimetypeParlia
+	Message  []byte         // Encoded header the seal hash is computed from
+}
+
+// HeaderSigner is a signing service sealing headers on behalf of the node, such
+// as a remote HSM. It returns the 65 byte [R || S || V] signature of the seal
+// hash, with V being 0 or 1.
+type HeaderSigner interface {
+	SignHeader(ctx context.Context, req *HeaderSignRequest) ([]byte, error)
+}
+
+// ExternalSignerConfig configures the delivery of requests to an external signer.
+type ExternalSignerConfig struct {
+	Retries int           // Number of times a failed request is retried
+	Backoff time.Duration // Delay before the first retry, doubled on each subsequent one
+	Timeout time.Duration // Timeout of a single request, no timeout if zero
+}
+
+// ExternalSigner is a HeaderSigner relaying the requests to another one, retrying
+// failed requests, validating the returned signatures and logging every request
+// for auditing.
+type ExternalSigner struct {
+	signer HeaderSigner
+	config ExternalSignerConfig
+}
+
+// NewExternalSigner wraps a signing service with retries and audit logging.
+func NewExternalSigner(signer HeaderSigner, config ExternalSignerConfig) *ExternalSigner {
+	return &ExternalSigner{signer: signer, config: config}
+}
+
+// SignHeader implements HeaderSigner, requesting a signature until it succeeds,
+// the retries are exhausted or the context is cancelled.
+func (s *ExternalSigner) SignHeader(ctx context.Context, req *HeaderSignRequest) ([]byte, error) {
+	var (
+		start   = time.Now()
+		backoff = s.config.Backoff
+		err     error
+	)
+	defer externalSignTimer.UpdateSince(start)
+
+	for attempt := 0; ; attempt++ {
+		var sig []byte
+		sig, err = s.request(ctx, req)
+		if err == nil {
+			log.Info("Header signed externally", "number", req.Number, "hash", req.Hash, "signer", req.Signer, "chainid", req.ChainID, "attempt", attempt+1, "elapsed", common.PrettyDuration(time.Since(start)))
+			return sig, nil
+		}
+		externalSignFailureMeter.Mark(1)
+		log.Warn("External header signing failed", "number", req.Number, "hash", req.Hash, "signer", req.Signer, "chainid", req.ChainID, "attempt", attempt+1, "err", err)
+
+		// Invalid signatures are not transient, don't bother retrying
+		if errors.Is(err, errSignerMismatch) || attempt >= s.config.Retries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("external signer failed after %v: %w", common.PrettyDuration(time.Since(start)), err)
+}
+
+// request sends a single signing request and validates the returned signature.
+func (s *ExternalSigner) request(ctx context.Context, req *HeaderSignRequest) ([]byte, error) {
+	externalSignRequestMeter.Mark(1)
+
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+	sig, err := s.signer.SignHeader(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	pubkey, err := crypto.SigToPub(req.Hash.Bytes(), sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != req.Signer {
+		return nil, fmt.Errorf("%w: have %s, want %s", errSignerMismatch, signer, req.Signer)
+	}
+	return sig, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// flakySigner is a HeaderSigner failing a number of requests before signing.
+type flakySigner struct {
+	key      *ecdsa.PrivateKey
+	failures int
+	requests int
+}
+
+func (s *flakySigner) SignHeader(ctx context.Context, req *HeaderSignRequest) ([]byte, error) {
+	s.requests++
+	if s.requests <= s.failures {
+		return nil, errors.New("signer unavailable")
+	}
+	return crypto.Sign(req.Hash.Bytes(), s.key)
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	req := &HeaderSignRequest{
+		Signer: crypto.PubkeyToAddress(key.PublicKey),
+		Hash:   common.Hash{0x01},
+		Number: 1,
+	}
+	config := ExternalSignerConfig{Retries: 2, Backoff: time.Millisecond}
+
+	// Transient failures are retried
+	backend := &flakySigner{key: key, failures: 2}
+	sig, err := NewExternalSigner(backend, config).SignHeader(context.Background(), req)
+	if err != nil {
+		t.Fatalf("failed to sign header: %v", err)
+	}
+	if pubkey, err := crypto.SigToPub(req.Hash.Bytes(), sig); err != nil || crypto.PubkeyToAddress(*pubkey) != req.Signer {
+		t.Fatalf("invalid signature returned: %v", err)
+	}
+	// Failures beyond the retry allowance are reported
+	backend = &flakySigner{key: key, failures: 3}
+	if _, err := NewExternalSigner(backend, config).SignHeader(context.Background(), req); err == nil {
+		t.Fatal("signature returned after exhausting retries")
+	}
+	if backend.requests != 3 {
+		t.Errorf("request count mismatch: have %d, want 3", backend.requests)
+	}
+	// Signatures of another key are rejected without retrying
+	other, _ := crypto.GenerateKey()
+	backend = &flakySigner{key: other}
+	if _, err := NewExternalSigner(backend, config).SignHeader(context.Background(), req); !errors.Is(err, errSignerMismatch) {
+		t.Fatalf("signature of unexpected signer accepted: %v", err)
+	}
+	if backend.requests != 1 {
+		t.Errorf("mismatching signature retried: %d requests", backend.requests)
+	}
+}
@@ -37,7 +37,7 @@ func VerifyEIP1559Header(config *params.ChainConfig, parent, header *types.Heade
 		if !config.IsLondon(parent.Number) {
 			parentGasLimit = parent.GasLimit * config.ElasticityMultiplier()
 		}
-		if err := misc.VerifyGaslimit(parentGasLimit, header.GasLimit); err != nil {
+		if err := misc.VerifyHeaderGaslimit(config, parentGasLimit, header); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,71 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package misc
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ExtraDataLimit is a step of the extra-data size limits, applying from the
+// given block onwards until the next step.
+type ExtraDataLimit struct {
+	Block   uint64 // Number of the first block the limit applies to
+	MaxSize int    // Maximum size of the extra-data in bytes
+}
+
+// ExtraDataPolicy is a consensus.ExtraDataValidator enforcing a size limit on
+// the extra-data changing at fork blocks, and a layout consisting of a fixed
+// size vanity prefix and signature suffix.
+type ExtraDataPolicy struct {
+	Limits []ExtraDataLimit // Size limits in ascending block order, unlimited before the first
+	Vanity int              // Size of the required vanity prefix
+	Seal   int              // Size of the required signature suffix
+}
+
+// ValidateExtra implements consensus.ExtraDataValidator.
+func (p *ExtraDataPolicy) ValidateExtra(config *params.ChainConfig, header *types.Header) error {
+	size := len(header.Extra)
+	if limit, ok := p.limit(header.Number.Uint64()); ok && size > limit {
+		return fmt.Errorf("extra-data too long: %d > %d", size, limit)
+	}
+	if size < p.Vanity {
+		return fmt.Errorf("extra-data %d byte vanity prefix missing: have %d bytes", p.Vanity, size)
+	}
+	if size < p.Vanity+p.Seal {
+		return fmt.Errorf("extra-data %d byte signature suffix missing: have %d bytes", p.Seal, size)
+	}
+	return nil
+}
+
+// limit returns the size limit in effect at the given block, or false if the
+// extra-data is unlimited.
+func (p *ExtraDataPolicy) limit(number uint64) (int, bool) {
+	var (
+		limit int
+		found bool
+	)
+	for _, step := range p.Limits {
+		if step.Block > number {
+			break
+		}
+		limit, found = step.MaxSize, true
+	}
+	return limit, found
+}
@@ -19,6 +19,7 @@ package misc
 import (
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -39,3 +40,27 @@ func VerifyGaslimit(parentGasLimit, headerGasLimit uint64) error {
 	}
 	return nil
 }
+
+// VerifyScheduledGaslimit verifies the header gas limit against the gas limit
+// schedule of the chain config. It returns false if the schedule doesn't cover
+// the header, in which case the regular bound checks apply.
+func VerifyScheduledGaslimit(config *params.ChainConfig, header *types.Header) (bool, error) {
+	limit, ok := config.ScheduledGasLimit(header.Number)
+	if !ok {
+		return false, nil
+	}
+	if header.GasLimit != limit {
+		return true, fmt.Errorf("invalid gas limit: have %d, want %d by schedule", header.GasLimit, limit)
+	}
+	return true, nil
+}
+
+// VerifyHeaderGaslimit verifies the header gas limit against the gas limit
+// schedule if it covers the header, and according to the increase/decrease in
+// relation to the parent gas limit otherwise.
+func VerifyHeaderGaslimit(config *params.ChainConfig, parentGasLimit uint64, header *types.Header) error {
+	if scheduled, err := VerifyScheduledGaslimit(config, header); scheduled {
+		return err
+	}
+	return VerifyGaslimit(parentGasLimit, header.GasLimit)
+}
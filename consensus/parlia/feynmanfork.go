@@ -114,15 +114,12 @@ func (p *Parlia) updateValidatorSetV2(state vm.StateDB, header *types.Header, ch
 	eValidators, eVotingPowers, eVoteAddrs := getTopValidatorsByVotingPower(validatorItems, maxElectedValidators)
 
 	// 3. update validator set to system contract
-	method := "updateValidatorSetV2"
-	data, err := p.validatorSetABI.Pack(method, eValidators, eVotingPowers, eVoteAddrs)
+	msg, err := core.NewValidatorSetUpdateMessage(header.Coinbase, eValidators, eVotingPowers, eVoteAddrs)
 	if err != nil {
 		log.Error("Unable to pack tx for updateValidatorSetV2", "error", err)
 		return err
 	}
 
-	// get system message
-	msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, common.Big0)
 	// apply message
 	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining, tracer)
 }
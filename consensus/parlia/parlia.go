@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	cmath "github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
@@ -248,9 +249,10 @@ type Parlia struct {
 
 	signer types.Signer
 
-	val      common.Address // Ethereum address of the signing key
-	signFn   SignerFn       // Signer function to authorize hashes with
-	signTxFn SignerTxFn
+	val          common.Address // Ethereum address of the signing key
+	signFn       SignerFn       // Signer function to authorize hashes with
+	signTxFn     SignerTxFn
+	headerSigner consensus.HeaderSigner // External signer used instead of signFn for headers, if set
 
 	lock sync.RWMutex // Protects the signer fields
 
@@ -258,7 +260,6 @@ type Parlia struct {
 	VotePool                   consensus.VotePool
 	validatorSetABIBeforeLuban abi.ABI
 	validatorSetABI            abi.ABI
-	slashABI                   abi.ABI
 	stakeHubABI                abi.ABI
 
 	// The fields below are for testing only
@@ -284,10 +285,6 @@ func New(
 	if err != nil {
 		panic(err)
 	}
-	sABI, err := abi.JSON(strings.NewReader(slashABI))
-	if err != nil {
-		panic(err)
-	}
 	stABI, err := abi.JSON(strings.NewReader(stakeABI))
 	if err != nil {
 		panic(err)
@@ -303,7 +300,6 @@ func New(
 		signatures:                 lru.NewCache[common.Hash, common.Address](inMemorySignatures),
 		validatorSetABIBeforeLuban: vABIBeforeLuban,
 		validatorSetABI:            vABI,
-		slashABI:                   sABI,
 		stakeHubABI:                stABI,
 		signer:                     types.LatestSigner(chainConfig),
 	}
@@ -344,19 +340,54 @@ func (p *Parlia) ConsensusAddress() common.Address {
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
 func (p *Parlia) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header) error {
-	return p.verifyHeader(chain, header, nil)
+	return p.verifyHeader(chain, header, nil, true)
 }
 
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
 // method returns a quit channel to abort the operations and a results channel to
 // retrieve the async verifications (the order is that of the input slice).
 func (p *Parlia) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header) (chan<- struct{}, <-chan error) {
+	return p.verifyHeaders(chain, headers, nil)
+}
+
+// VerifyHeadersWithoutSeals implements consensus.SealDeferringEngine, verifying
+// a batch of headers as if signed by their coinbase. The batch seal verifier has
+// to check that the coinbase did sign each header.
+func (p *Parlia) VerifyHeadersWithoutSeals(chain consensus.ChainHeaderReader, headers []*types.Header) (chan<- struct{}, <-chan error) {
+	return p.verifyHeaders(chain, headers, make([]bool, len(headers)))
+}
+
+// VerifyHeadersSampled implements consensus.SealSamplingEngine, verifying a
+// batch of headers but only recovering the signers of the flagged ones. The
+// other headers are taken to be signed by their coinbase.
+func (p *Parlia) VerifyHeadersSampled(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	return p.verifyHeaders(chain, headers, seals)
+}
+
+// verifyHeaders runs the async batch verification, recovering the signers of the
+// headers flagged in seals, or all of them if nil.
+func (p *Parlia) verifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
 	abort := make(chan struct{})
 	results := make(chan error, len(headers))
 
+	var unsealed map[common.Hash]struct{}
+	if seals != nil {
+		unsealed = make(map[common.Hash]struct{})
+		for i, header := range headers {
+			if !seals[i] {
+				unsealed[header.Hash()] = struct{}{}
+			}
+		}
+	}
 	gopool.Submit(func() {
 		for i, header := range headers {
-			err := p.verifyHeader(chain, header, headers[:i])
+			// Resolve the parent snapshot without recovering the unchecked signers
+			// up front, the header checks pick it up from the cache. Any error is
+			// reported by the header checks themselves.
+			if len(unsealed) > 0 && i > 0 && header.Number != nil && header.Number.Sign() > 0 {
+				p.snapshotUnsealed(chain, header.Number.Uint64()-1, header.ParentHash, headers[:i], unsealed)
+			}
+			err := p.verifyHeader(chain, header, headers[:i], seals == nil || seals[i])
 
 			select {
 			case <-abort:
@@ -551,8 +582,9 @@ func (p *Parlia) verifyVoteAttestation(chain consensus.ChainHeaderReader, header
 // verifyHeader checks whether a header conforms to the consensus rules.The
 // caller may optionally pass in a batch of parents (ascending order) to avoid
 // looking those up from the database. This is useful for concurrently verifying
-// a batch of new headers.
-func (p *Parlia) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+// a batch of new headers. If seal is unset, the header is taken to be signed by
+// its coinbase instead of recovering the signer.
+func (p *Parlia) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) error {
 	if header.Number == nil {
 		return errUnknownBlock
 	}
@@ -665,14 +697,14 @@ func (p *Parlia) verifyHeader(chain consensus.ChainHeaderReader, header *types.H
 	}
 
 	// All basic checks passed, verify cascading fields
-	return p.verifyCascadingFields(chain, header, parents)
+	return p.verifyCascadingFields(chain, header, parents, seal)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
-func (p *Parlia) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+func (p *Parlia) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) error {
 	// The genesis block is the always valid dead-end
 	number := header.Number.Uint64()
 	if number == 0 {
@@ -704,19 +736,23 @@ func (p *Parlia) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
 	}
 
-	// Verify that the gas limit remains within allowed bounds
-	diff := int64(parent.GasLimit) - int64(header.GasLimit)
-	if diff < 0 {
-		diff *= -1
-	}
-	gasLimitBoundDivisor := gasLimitBoundDivisorBeforeLorentz
-	if p.chainConfig.IsLorentz(header.Number, header.Time) {
-		gasLimitBoundDivisor = params.GasLimitBoundDivisor
-	}
-	limit := parent.GasLimit / gasLimitBoundDivisor
+	// Verify that the gas limit follows the schedule or remains within allowed bounds
+	if scheduled, err := misc.VerifyScheduledGaslimit(p.chainConfig, header); err != nil {
+		return err
+	} else if !scheduled {
+		diff := int64(parent.GasLimit) - int64(header.GasLimit)
+		if diff < 0 {
+			diff *= -1
+		}
+		gasLimitBoundDivisor := gasLimitBoundDivisorBeforeLorentz
+		if p.chainConfig.IsLorentz(header.Number, header.Time) {
+			gasLimitBoundDivisor = params.GasLimitBoundDivisor
+		}
+		limit := parent.GasLimit / gasLimitBoundDivisor
 
-	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
-		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit-1)
+		if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
+			return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit-1)
+		}
 	}
 
 	// Verify vote attestation for fast finality.
@@ -730,7 +766,7 @@ func (p *Parlia) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	}
 
 	// All basic checks passed, verify the seal and return
-	return p.verifySeal(chain, header, parents)
+	return p.verifySeal(chain, header, parents, seal)
 }
 
 // snapshot retrieves the authorization snapshot at a given point in time.
@@ -738,6 +774,12 @@ func (p *Parlia) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 // the block with `number` and `hash` is just the last element of `parents`,
 // unlike other interfaces such as verifyCascadingFields, `parents` are real parents
 func (p *Parlia) snapshot(chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	return p.snapshotUnsealed(chain, number, hash, parents, nil)
+}
+
+// snapshotUnsealed is like snapshot, but takes the headers in unsealed to be
+// signed by their coinbase instead of recovering their signers.
+func (p *Parlia) snapshotUnsealed(chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.Header, unsealed map[common.Hash]struct{}) (*Snapshot, error) {
 	// Search for a snapshot in memory or on disk for checkpoints
 	var (
 		headers []*types.Header
@@ -870,7 +912,7 @@ func (p *Parlia) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
 
-	snap, err := snap.apply(headers, chain, parents, p.chainConfig)
+	snap, err := snap.apply(headers, chain, parents, p.chainConfig, unsealed)
 	if err != nil {
 		return nil, err
 	}
@@ -886,6 +928,22 @@ func (p *Parlia) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	return snap, err
 }
 
+// ValidatorsAt implements consensus.ValidatorSetReader, returning the validators
+// in effect after the given header.
+func (p *Parlia) ValidatorsAt(chain consensus.ChainHeaderReader, header *types.Header) ([]common.Address, error) {
+	snap, err := p.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.validators(), nil
+}
+
+// RecoverSigner implements consensus.SignerRecoverer, returning the validator
+// which sealed the header and caching it for the seal checks.
+func (p *Parlia) RecoverSigner(header *types.Header) (common.Address, error) {
+	return ecrecover(header, p.signatures, p.chainConfig.ChainID)
+}
+
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (p *Parlia) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
@@ -902,14 +960,15 @@ func (p *Parlia) VerifyRequests(header *types.Header, Requests [][]byte) error {
 // VerifySeal implements consensus.Engine, checking whether the signature contained
 // in the header satisfies the consensus protocol requirements.
 func (p *Parlia) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
-	return p.verifySeal(chain, header, nil)
+	return p.verifySeal(chain, header, nil, true)
 }
 
 // verifySeal checks whether the signature contained in the header satisfies the
 // consensus protocol requirements. The method accepts an optional list of parent
 // headers that aren't yet part of the local blockchain to generate the snapshots
-// from.
-func (p *Parlia) verifySeal(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+// from. If seal is unset, the header is taken to be signed by its coinbase, all
+// the checks depending on the signer are still done.
+func (p *Parlia) verifySeal(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) error {
 	// Verifying the genesis block is not supported
 	number := header.Number.Uint64()
 	if number == 0 {
@@ -922,9 +981,12 @@ func (p *Parlia) verifySeal(chain consensus.ChainHeaderReader, header *types.Hea
 	}
 
 	// Resolve the authorization key and check against validators
-	signer, err := ecrecover(header, p.signatures, p.chainConfig.ChainID)
-	if err != nil {
-		return err
+	signer := header.Coinbase
+	if seal {
+		signer, err = ecrecover(header, p.signatures, p.chainConfig.ChainID)
+		if err != nil {
+			return err
+		}
 	}
 
 	if signer != header.Coinbase {
@@ -1293,24 +1355,12 @@ func (p *Parlia) distributeFinalityReward(chain consensus.ChainHeaderReader, sta
 		}
 	}
 
-	validators := make([]common.Address, 0, len(accumulatedWeights))
-	weights := make([]*big.Int, 0, len(accumulatedWeights))
-	for val := range accumulatedWeights {
-		validators = append(validators, val)
-	}
-	sort.Sort(validatorsAscending(validators))
-	for _, val := range validators {
-		weights = append(weights, big.NewInt(int64(accumulatedWeights[val])))
-	}
-
 	// generate system transaction
-	method := "distributeFinalityReward"
-	data, err := p.validatorSetABI.Pack(method, validators, weights)
+	msg, err := core.NewFinalityRewardMessage(header.Coinbase, accumulatedWeights)
 	if err != nil {
 		log.Error("Unable to pack tx for distributeFinalityReward", "error", err)
 		return err
 	}
-	msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, common.Big0)
 	return p.applyTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining, tracer)
 }
 
@@ -1566,7 +1616,7 @@ func (p *Parlia) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 		wg.Done()
 	}()
 	go func() {
-		blk = types.NewBlock(header, body, receipts, trie.NewStackTrie(nil))
+		blk = types.NewBlock(header, body, receipts, trie.NewRootHasher(p.chainConfig))
 		wg.Done()
 	}()
 	wg.Wait()
@@ -1644,6 +1694,42 @@ func (p *Parlia) Authorize(val common.Address, signFn SignerFn, signTxFn SignerT
 	p.signTxFn = signTxFn
 }
 
+// SetHeaderSigner delegates signing the sealed headers to an external signer,
+// instead of the signer function passed to Authorize. Transactions are still
+// signed by the transaction signer function.
+func (p *Parlia) SetHeaderSigner(signer consensus.HeaderSigner) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.headerSigner = signer
+}
+
+// signHeader signs the seal hash of a header, through the external signer if
+// one is set. The request is cancelled if the stop channel is closed.
+func (p *Parlia) signHeader(val common.Address, signFn SignerFn, signer consensus.HeaderSigner, header *types.Header, stop <-chan struct{}) ([]byte, error) {
+	if signer == nil {
+		return signFn(accounts.Account{Address: val}, accounts.MimetypeParlia, ParliaRLP(header, p.chainConfig.ChainID))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return signer.SignHeader(ctx, &consensus.HeaderSignRequest{
+		Signer:   val,
+		Hash:     types.SealHash(header, p.chainConfig.ChainID),
+		Number:   header.Number.Uint64(),
+		ChainID:  p.chainConfig.ChainID,
+		MimeType: accounts.MimetypeParlia,
+		Message:  ParliaRLP(header, p.chainConfig.ChainID),
+	})
+}
+
 // Argument leftOver is the time reserved for block finalize(calculate root, distribute income...)
 func (p *Parlia) Delay(chain consensus.ChainReader, header *types.Header, leftOver *time.Duration) *time.Duration {
 	number := header.Number.Uint64()
@@ -1686,7 +1772,7 @@ func (p *Parlia) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 	}
 	// Don't hold the val fields for the entire sealing procedure
 	p.lock.RLock()
-	val, signFn := p.val, p.signFn
+	val, signFn, headerSigner := p.val, p.signFn, p.headerSigner
 	p.lock.RUnlock()
 
 	snap, err := p.snapshot(chain, number-1, header.ParentHash, nil)
@@ -1727,7 +1813,7 @@ func (p *Parlia) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 		}
 
 		// Sign all the things!
-		sig, err := signFn(accounts.Account{Address: val}, accounts.MimetypeParlia, ParliaRLP(header, p.chainConfig.ChainID))
+		sig, err := p.signHeader(val, signFn, headerSigner, header, stop)
 		if err != nil {
 			log.Error("Sign for the block header failed when sealing", "err", err)
 			return
@@ -1949,7 +2035,7 @@ func (p *Parlia) distributeIncoming(val common.Address, state vm.StateDB, header
 		rewards := new(uint256.Int)
 		rewards = rewards.Rsh(balance, systemRewardPercent)
 		if rewards.Cmp(common.U2560) > 0 {
-			state.SetBalance(consensus.SystemAddress, balance.Sub(balance, rewards), tracing.BalanceChangeUnspecified)
+			state.SetBalance(consensus.SystemAddress, new(uint256.Int).Sub(balance, rewards), tracing.BalanceChangeUnspecified)
 			state.AddBalance(coinbase, rewards, tracing.BalanceChangeUnspecified)
 			err := p.distributeToSystem(rewards.ToBig(), state, header, chain, txs, receipts, receivedTxs, usedGas, mining, tracer)
 			if err != nil {
@@ -1973,19 +2059,12 @@ func (p *Parlia) distributeIncoming(val common.Address, state vm.StateDB, header
 // slash spoiled validators
 func (p *Parlia) slash(spoiledVal common.Address, state vm.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool, tracer *tracing.Hooks) error {
-	// method
-	method := "slash"
-
-	// get packed data
-	data, err := p.slashABI.Pack(method,
-		spoiledVal,
-	)
+	// get system message
+	msg, err := core.NewSlashMessage(header.Coinbase, spoiledVal)
 	if err != nil {
 		log.Error("Unable to pack tx for slash", "error", err)
 		return err
 	}
-	// get system message
-	msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.SlashContract), data, common.Big0)
 	// apply message
 	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining, tracer)
 }
@@ -2026,7 +2105,7 @@ func (p *Parlia) initContract(state vm.StateDB, header *types.Header, chain core
 func (p *Parlia) distributeToSystem(amount *big.Int, state vm.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool, tracer *tracing.Hooks) error {
 	// get system message
-	msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.SystemRewardContract), nil, amount)
+	msg := core.NewSystemRewardMessage(header.Coinbase, amount)
 	// apply message
 	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining, tracer)
 }
@@ -2035,33 +2114,19 @@ func (p *Parlia) distributeToSystem(amount *big.Int, state vm.StateDB, header *t
 func (p *Parlia) distributeToValidator(amount *big.Int, validator common.Address,
 	state vm.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool, tracer *tracing.Hooks) error {
-	// method
-	method := "deposit"
-
-	// get packed data
-	data, err := p.validatorSetABI.Pack(method,
-		validator,
-	)
+	// get system message
+	msg, err := core.NewDepositMessage(header.Coinbase, validator, amount)
 	if err != nil {
 		log.Error("Unable to pack tx for deposit", "error", err)
 		return err
 	}
-	// get system message
-	msg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, amount)
 	// apply message
 	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining, tracer)
 }
 
 // get system message
 func (p *Parlia) getSystemMessage(from, toAddress common.Address, data []byte, value *big.Int) *core.Message {
-	return &core.Message{
-		From:     from,
-		GasLimit: math.MaxUint64 / 2,
-		GasPrice: big.NewInt(0),
-		Value:    value,
-		To:       &toAddress,
-		Data:     data,
-	}
+	return core.NewSystemMessage(from, toAddress, data, value)
 }
 
 func (p *Parlia) applyTransaction(
@@ -2074,7 +2139,7 @@ func (p *Parlia) applyTransaction(
 	tracer *tracing.Hooks,
 ) (applyErr error) {
 	nonce := state.GetNonce(msg.From)
-	expectedTx := types.NewTransaction(nonce, *msg.To, msg.Value, msg.GasLimit, msg.GasPrice, msg.Data)
+	expectedTx := core.NewSystemTransaction(nonce, msg)
 	expectedHash := p.signer.Hash(expectedTx)
 
 	if msg.From == p.val && mining {
@@ -275,7 +275,7 @@ func (s *Snapshot) getFinalizedNumber() uint64 {
 	return 0
 }
 
-func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderReader, parents []*types.Header, chainConfig *params.ChainConfig) (*Snapshot, error) {
+func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderReader, parents []*types.Header, chainConfig *params.ChainConfig, unsealed map[common.Hash]struct{}) (*Snapshot, error) {
 	// Allow passing in no headers for cleaner code
 	if len(headers) == 0 {
 		return s, nil
@@ -307,10 +307,14 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 		if limit := snap.versionHistoryCheckLen(); number >= limit {
 			delete(snap.RecentForkHashes, number-limit)
 		}
-		// Resolve the authorization key and check against signers
-		validator, err := ecrecover(header, s.sigCache, chainConfig.ChainID)
-		if err != nil {
-			return nil, err
+		// Resolve the authorization key and check against signers, taking the
+		// coinbase for the signer of the headers with unchecked seals
+		validator := header.Coinbase
+		if _, ok := unsealed[header.Hash()]; !ok {
+			var err error
+			if validator, err = ecrecover(header, s.sigCache, chainConfig.ChainID); err != nil {
+				return nil, err
+			}
 		}
 		if _, ok := snap.Validators[validator]; !ok {
 			return nil, errUnauthorizedValidator(validator.String())
@@ -2,12 +2,16 @@ package parlia
 
 import (
 	"bytes"
+	"math/big"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestValidatorSetSort(t *testing.T) {
@@ -21,3 +25,36 @@ func TestValidatorSetSort(t *testing.T) {
 		assert.True(t, bytes.Compare(validators[i][:], validators[i+1][:]) < 0)
 	}
 }
+
+func TestSnapshotApplyUnsealed(t *testing.T) {
+	var (
+		validator = common.Address{0x01}
+		config    = &params.ChainConfig{ChainID: big.NewInt(1), Parlia: &params.ParliaConfig{}}
+		snap      = newSnapshot(config.Parlia, lru.NewCache[common.Hash, common.Address](16), 0, common.Hash{0xaa}, []common.Address{validator}, nil, nil)
+		header    = &types.Header{
+			Number:     big.NewInt(1),
+			ParentHash: snap.Hash,
+			Coinbase:   validator,
+			Difficulty: diffInTurn,
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+	)
+	// The header carries no valid signature, so recovering its signer fails
+	if _, err := snap.apply([]*types.Header{header}, nil, nil, config, nil); err == nil {
+		t.Fatal("unsigned header applied")
+	}
+	// Headers with unchecked seals are taken to be signed by their coinbase
+	unsealed := map[common.Hash]struct{}{header.Hash(): {}}
+	applied, err := snap.apply([]*types.Header{header}, nil, nil, config, unsealed)
+	if err != nil {
+		t.Fatalf("failed to apply unsealed header: %v", err)
+	}
+	assert.Equal(t, validator, applied.Recents[1])
+
+	// The signer dependent rules are still enforced
+	header.Coinbase = common.Address{0x02}
+	unsealed = map[common.Hash]struct{}{header.Hash(): {}}
+	if _, err := snap.apply([]*types.Header{header}, nil, nil, config, unsealed); err == nil {
+		t.Fatal("header of unauthorized validator applied")
+	}
+}
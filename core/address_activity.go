@@ -0,0 +1,221 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// EnableAddressActivityIndex returns a BlockChainOption which maintains bitmaps
+// of the canonical blocks every address was active in, either as a transaction
+// sender, a transaction recipient or a log emitter. Only blocks becoming
+// canonical after enabling the index are covered, there is no backfilling of
+// older blocks.
+func EnableAddressActivityIndex() BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.activityIndex = true
+		return bc, nil
+	}
+}
+
+// FirstSeen returns the number of the first indexed canonical block the address
+// was active in.
+func (bc *BlockChain) FirstSeen(addr common.Address) (uint64, bool) {
+	var (
+		head   = bc.CurrentBlock().Number.Uint64()
+		first  uint64
+		exists bool
+	)
+	rawdb.IterateAddressActivity(bc.db, addr, 0, func(number uint64) bool {
+		if number <= head {
+			first, exists = number, true
+		}
+		return false
+	})
+	return first, exists
+}
+
+// LastActive returns the number of the last indexed canonical block the address
+// was active in.
+func (bc *BlockChain) LastActive(addr common.Address) (uint64, bool) {
+	var (
+		head   = bc.CurrentBlock().Number.Uint64()
+		last   uint64
+		exists bool
+	)
+	rawdb.IterateAddressActivity(bc.db, addr, 0, func(number uint64) bool {
+		if number > head {
+			return false
+		}
+		last, exists = number, true
+		return true
+	})
+	return last, exists
+}
+
+// AddressActivity returns the numbers of the indexed canonical blocks within the
+// given inclusive range the address was active in, allowing to only re-execute
+// the blocks relevant to it.
+func (bc *BlockChain) AddressActivity(addr common.Address, from uint64, to uint64) []uint64 {
+	// Cut off any activity of blocks above the head, which are in the process
+	// of being written or rewound.
+	to = min(to, bc.CurrentBlock().Number.Uint64())
+
+	var numbers []uint64
+	rawdb.IterateAddressActivity(bc.db, addr, from, func(number uint64) bool {
+		if number > to {
+			return false
+		}
+		numbers = append(numbers, number)
+		return true
+	})
+	return numbers
+}
+
+// activeAddresses collects the unique addresses active in a block.
+func (bc *BlockChain) activeAddresses(block *types.Block) []common.Address {
+	var (
+		signer = types.MakeSigner(bc.chainConfig, block.Number(), block.Time())
+		seen   = make(map[common.Address]struct{})
+		addrs  []common.Address
+	)
+	add := func(addr common.Address) {
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = struct{}{}
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, tx := range block.Transactions() {
+		if from, err := types.Sender(signer, tx); err == nil {
+			add(from)
+		}
+		if to := tx.To(); to != nil {
+			add(*to)
+		}
+	}
+	for _, receipt := range bc.GetReceiptsByHash(block.Hash()) {
+		for _, log := range receipt.Logs {
+			add(log.Address)
+		}
+	}
+	return addrs
+}
+
+// activityChunk identifies an activity bitmap.
+type activityChunk struct {
+	addr  common.Address
+	chunk uint64
+}
+
+// addressActivityUpdate accumulates the activity changes of several blocks in
+// memory, so they can be written through a single batch. Marking and unmarking
+// the blocks is idempotent.
+type addressActivityUpdate struct {
+	bc      *BlockChain
+	bitmaps map[activityChunk]*rawdb.ActivityBitmap // Updated bitmaps
+}
+
+// newAddressActivityUpdate creates an empty activity update, or nil if the
+// index is disabled.
+func (bc *BlockChain) newAddressActivityUpdate() *addressActivityUpdate {
+	if !bc.activityIndex {
+		return nil
+	}
+	return &addressActivityUpdate{
+		bc:      bc,
+		bitmaps: make(map[activityChunk]*rawdb.ActivityBitmap),
+	}
+}
+
+// read retrieves an activity bitmap, including the pending changes.
+func (u *addressActivityUpdate) read(addr common.Address, chunk uint64) *rawdb.ActivityBitmap {
+	if bitmap, ok := u.bitmaps[activityChunk{addr, chunk}]; ok {
+		return bitmap
+	}
+	bitmap := rawdb.ReadAddressActivity(u.bc.db, addr, chunk)
+	if bitmap == nil {
+		bitmap = new(rawdb.ActivityBitmap)
+	}
+	return bitmap
+}
+
+// apply marks the addresses active in a block which became canonical.
+func (u *addressActivityUpdate) apply(block *types.Block) {
+	if u == nil {
+		return
+	}
+	var (
+		chunk  = block.NumberU64() >> rawdb.ActivityChunkBits
+		offset = uint16(block.NumberU64())
+	)
+	for _, addr := range u.bc.activeAddresses(block) {
+		bitmap := u.read(addr, chunk)
+		if bitmap.Add(offset) {
+			u.bitmaps[activityChunk{addr, chunk}] = bitmap
+		}
+	}
+}
+
+// revert unmarks the addresses active in a block which left the canonical
+// chain. Addresses not marked, e.g. as the block predates the index, are left
+// alone.
+func (u *addressActivityUpdate) revert(block *types.Block) {
+	if u == nil {
+		return
+	}
+	var (
+		chunk  = block.NumberU64() >> rawdb.ActivityChunkBits
+		offset = uint16(block.NumberU64())
+	)
+	for _, addr := range u.bc.activeAddresses(block) {
+		bitmap := u.read(addr, chunk)
+		if bitmap.Remove(offset) {
+			u.bitmaps[activityChunk{addr, chunk}] = bitmap
+		}
+	}
+}
+
+// write flushes the accumulated changes into the database.
+func (u *addressActivityUpdate) write(db ethdb.KeyValueWriter) {
+	if u == nil {
+		return
+	}
+	for id, bitmap := range u.bitmaps {
+		rawdb.WriteAddressActivity(db, id.addr, id.chunk, bitmap)
+	}
+}
+
+// writeAddressActivity marks the addresses active in a block which became
+// canonical in the activity bitmaps.
+func (bc *BlockChain) writeAddressActivity(db ethdb.KeyValueWriter, block *types.Block) {
+	update := bc.newAddressActivityUpdate()
+	update.apply(block)
+	update.write(db)
+}
+
+// revertAddressActivity unmarks the addresses active in the blocks which were
+// reorged out of the canonical chain from the activity bitmaps.
+func (bc *BlockChain) revertAddressActivity(db ethdb.KeyValueWriter, blocks []*types.Block) {
+	update := bc.newAddressActivityUpdate()
+	for _, block := range blocks {
+		update.revert(block)
+	}
+	update.write(db)
+}
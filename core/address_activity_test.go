@@ -0,0 +1,126 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the blocks addresses were active in are indexed for senders,
+// recipients and log emitters, and unindexed when reorged out.
+func TestAddressActivityIndex(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		caller  = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		emitter = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		other   = common.HexToAddress("0x000000000000000000000000000000000000cccc")
+
+		// caller forwards the call to emitter, which emits an anonymous log
+		callerCode  = append(append(common.FromHex("0x60006000600060006000"), append([]byte{0x73}, emitter.Bytes()...)...), common.FromHex("0x5af100")...)
+		emitterCode = common.FromHex("0x60006000a000")
+
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				addr:    {Balance: big.NewInt(params.Ether)},
+				caller:  {Code: callerCode},
+				emitter: {Code: emitterCode},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		if i == 0 || i == 2 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), caller, common.Big0, 100000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	_, fork, _ := GenerateChainWithGenesis(genesis, engine, 5, func(i int, b *BlockGen) {
+		b.SetExtra([]byte("fork"))
+		if i == 1 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), other, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableAddressActivityIndex())
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for _, a := range []common.Address{addr, caller, emitter} {
+		if have, want := chain.AddressActivity(a, 0, 10), []uint64{1, 3}; !slices.Equal(have, want) {
+			t.Errorf("activity of %x mismatch: have %v, want %v", a, have, want)
+		}
+		if first, ok := chain.FirstSeen(a); !ok || first != 1 {
+			t.Errorf("first seen of %x mismatch: have %d (%v), want 1", a, first, ok)
+		}
+		if last, ok := chain.LastActive(a); !ok || last != 3 {
+			t.Errorf("last active of %x mismatch: have %d (%v), want 3", a, last, ok)
+		}
+	}
+	if have := chain.AddressActivity(addr, 2, 10); !slices.Equal(have, []uint64{3}) {
+		t.Errorf("ranged activity mismatch: have %v, want [3]", have)
+	}
+	if _, ok := chain.FirstSeen(other); ok {
+		t.Errorf("inactive address indexed")
+	}
+	// Reorg the active blocks out and check the index follows the new chain
+	if n, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork block %d: %v", n, err)
+	}
+	if chain.CurrentBlock().Hash() != fork[len(fork)-1].Hash() {
+		t.Fatalf("fork not canonical")
+	}
+	if have := chain.AddressActivity(addr, 0, 10); !slices.Equal(have, []uint64{2}) {
+		t.Errorf("sender activity mismatch after reorg: have %v, want [2]", have)
+	}
+	if have := chain.AddressActivity(other, 0, 10); !slices.Equal(have, []uint64{2}) {
+		t.Errorf("recipient activity mismatch after reorg: have %v, want [2]", have)
+	}
+	for _, a := range []common.Address{caller, emitter} {
+		if bitmap := rawdb.ReadAddressActivity(db, a, 0); bitmap != nil {
+			t.Errorf("activity of %x not dropped on reorg: %v", a, bitmap.Offsets())
+		}
+	}
+	// Rewind the head and check the activity of the rewound blocks is dropped
+	if err := chain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind the chain: %v", err)
+	}
+	for _, a := range []common.Address{addr, other} {
+		if bitmap := rawdb.ReadAddressActivity(db, a, 0); bitmap != nil {
+			t.Errorf("activity of %x not dropped on rewind: %v", a, bitmap.Offsets())
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// addressFilterGas is the gas allowance of reading the filtered addresses
+	// from the system contract.
+	addressFilterGas = 50_000_000
+
+	// addressFilterCacheLimit is the number of filter lists kept in memory.
+	addressFilterCacheLimit = 16
+
+	// addressSourceCacheLimit is the number of blocks whose last epoch boundary
+	// is kept in memory.
+	addressSourceCacheLimit = 1024
+)
+
+// addressFilterABI contains the system contract method listing the filtered
+// addresses.
+var addressFilterABI = mustParseABI(`[
+	{"type":"function","name":"filteredAddresses","inputs":[],"outputs":[{"name":"","type":"address[]"}],"stateMutability":"view"}
+]`)
+
+// addressSet is a set of filtered addresses.
+type addressSet map[common.Address]struct{}
+
+// CheckTransactionAddresses returns ErrAddressFiltered if the transaction is
+// sent from or to an address filtered in the block with the given header.
+func (bc *BlockChain) CheckTransactionAddresses(header *types.Header, tx *types.Transaction) error {
+	filter, err := bc.addressFilter(header.Number.Uint64(), header.ParentHash)
+	if err != nil || len(filter) == 0 {
+		return err
+	}
+	return filter.check(types.MakeSigner(bc.chainConfig, header.Number, header.Time), tx)
+}
+
+// CheckPendingTransactionAddresses returns ErrAddressFiltered if the
+// transaction is sent from or to an address filtered in the block following
+// the current head.
+func (bc *BlockChain) CheckPendingTransactionAddresses(tx *types.Transaction) error {
+	head := bc.CurrentBlock()
+	number := new(big.Int).Add(head.Number, common.Big1)
+
+	filter, err := bc.addressFilter(number.Uint64(), head.Hash())
+	if err != nil || len(filter) == 0 {
+		return err
+	}
+	// The next block is not timestamped yet, assume it's sealed right now
+	timestamp := uint64(time.Now().Unix())
+	if timestamp <= head.Time {
+		timestamp = head.Time + 1
+	}
+	return filter.check(types.MakeSigner(bc.chainConfig, number, timestamp), tx)
+}
+
+// checkBlockAddresses verifies that no transaction of the block is sent from
+// or to a filtered address.
+func (bc *BlockChain) checkBlockAddresses(block *types.Block) error {
+	filter, err := bc.addressFilter(block.NumberU64(), block.ParentHash())
+	if err != nil || len(filter) == 0 {
+		return err
+	}
+	signer := types.MakeSigner(bc.chainConfig, block.Number(), block.Time())
+	for i, tx := range block.Transactions() {
+		if err := filter.check(signer, tx); err != nil {
+			return fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+	}
+	return nil
+}
+
+// check returns ErrAddressFiltered if the transaction is sent from or to a
+// filtered address.
+func (s addressSet) check(signer types.Signer, tx *types.Transaction) error {
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	if _, ok := s[from]; ok {
+		return fmt.Errorf("%w: sender %v", ErrAddressFiltered, from)
+	}
+	if to := tx.To(); to != nil {
+		if _, ok := s[*to]; ok {
+			return fmt.Errorf("%w: recipient %v", ErrAddressFiltered, *to)
+		}
+	}
+	return nil
+}
+
+// addressFilter returns the filtered addresses enforced on the block with the
+// given number and parent, or nil if the filter is not enforced on it. The list
+// is the one saved when the last epoch boundary before the block was imported.
+// Boundaries which were not executed locally, e.g. the genesis or the blocks
+// below a snap sync pivot, fall back to reading it from their state. If the list
+// can't be read, the failure is logged and no address is filtered, rather than
+// halting the chain on a broken filter contract.
+func (bc *BlockChain) addressFilter(number uint64, parentHash common.Hash) (addressSet, error) {
+	if number == 0 || !bc.chainConfig.IsAddressFilterEnforced(new(big.Int).SetUint64(number)) {
+		return nil, nil
+	}
+	source, err := bc.addressFilterSource(parentHash, number-1)
+	if err != nil {
+		return nil, err
+	}
+	hash := source.Hash()
+	if filter, ok := bc.addressFilters.Get(hash); ok {
+		return filter, nil
+	}
+	addrs, ok := rawdb.ReadAddressFilter(bc.db, hash)
+	if !ok {
+		statedb, err := bc.StateAt(source.Root)
+		if err != nil {
+			// Don't cache the missing list, the state may become available later
+			log.Warn("Address filter neither saved nor available from state", "number", source.Number, "hash", hash, "err", err)
+			return nil, nil
+		}
+		addrs = bc.readAddressFilter(source, statedb)
+	}
+	filter := make(addressSet, len(addrs))
+	for _, addr := range addrs {
+		filter[addr] = struct{}{}
+	}
+	bc.addressFilters.Add(hash, filter)
+	return filter, nil
+}
+
+// addressFilterSource returns the last epoch boundary at or before the block
+// with the given hash and number, whose state lists the addresses filtered in
+// the following epoch. The boundaries are cached for the blocks walked, so that
+// subsequent lookups stop at the first block seen before.
+func (bc *BlockChain) addressFilterSource(hash common.Hash, number uint64) (*types.Header, error) {
+	var (
+		epoch  = bc.chainConfig.AddressFilter.Epoch
+		walked []common.Hash
+	)
+	for number%epoch != 0 {
+		if source, ok := bc.addressSources.Get(hash); ok {
+			hash = source
+			break
+		}
+		header := bc.GetHeader(hash, number)
+		if header == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		walked = append(walked, hash)
+		hash, number = header.ParentHash, number-1
+	}
+	source := bc.GetHeaderByHash(hash)
+	if source == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	for _, block := range walked {
+		bc.addressSources.Add(block, hash)
+	}
+	return source, nil
+}
+
+// saveAddressFilter saves the filtered addresses listed in the post-state of
+// an imported epoch boundary block, if the list is enforced on the blocks of
+// the following epoch. Validating these blocks then doesn't need the state of
+// the boundary, which may be pruned by then.
+func (bc *BlockChain) saveAddressFilter(header *types.Header, statedb *state.StateDB) {
+	config := bc.chainConfig.AddressFilter
+	if config == nil || header.Number.Uint64()%config.Epoch != 0 {
+		return
+	}
+	if !bc.chainConfig.IsAddressFilterEnforced(new(big.Int).Add(header.Number, new(big.Int).SetUint64(config.Epoch))) {
+		return
+	}
+	// Read the list from a copy, the static call touches the contract account
+	rawdb.WriteAddressFilter(bc.db, header.Hash(), bc.readAddressFilter(header, statedb.Copy()))
+}
+
+// readAddressFilter reads the filtered addresses from the system contract in
+// the given state of a block. The list is empty until the contract is deployed,
+// or if it fails to return a valid list.
+func (bc *BlockChain) readAddressFilter(header *types.Header, statedb *state.StateDB) []common.Address {
+	contract := bc.chainConfig.AddressFilter.Contract
+	if statedb.GetCodeSize(contract) == 0 {
+		return nil
+	}
+	data, _ := addressFilterABI.Pack("filteredAddresses")
+	evm := vm.NewEVM(NewEVMBlockContext(header, bc, nil), statedb, bc.chainConfig, vm.Config{})
+	ret, _, err := evm.StaticCall(vm.AccountRef(common.Address{}), contract, data, addressFilterGas)
+	if err != nil {
+		log.Error("Failed to read address filter, filtering none", "number", header.Number, "hash", header.Hash(), "err", err)
+		return nil
+	}
+	var addrs []common.Address
+	if err := addressFilterABI.UnpackIntoInterface(&addrs, "filteredAddresses", ret); err != nil {
+		log.Error("Failed to decode address filter, filtering none", "number", header.Number, "hash", header.Hash(), "err", err)
+		return nil
+	}
+	return addrs
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newAddressFilterChain creates a chain whose filter contract lists the given
+// address, with the filter enforced from the given block, and generates blocks
+// sending a transfer to the recipient in each.
+func newAddressFilterChain(t *testing.T, filtered, recipient common.Address, enforced int64, n int) (*BlockChain, []*types.Block, *ecdsa.PrivateKey) {
+	var (
+		engine   = ethash.NewFaker()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0x000000000000000000000000000000000000f11e")
+		config   = *params.AllEthashProtocolChanges
+	)
+	config.AddressFilter = &params.AddressFilterConfig{
+		Block:    big.NewInt(enforced),
+		Contract: contract,
+		Epoch:    4,
+	}
+	// The contract returns the ABI encoding of [filtered]
+	code := append(common.FromHex("602060005260016020527f"), common.LeftPadBytes(filtered.Bytes(), 32)...)
+	code = append(code, common.FromHex("60405260606000f3")...)
+
+	gspec := &Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			addr:     {Balance: big.NewInt(params.Ether)},
+			contract: {Code: code},
+		},
+	}
+	signer := types.LatestSigner(&config)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, n, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), recipient, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	return chain, blocks, key
+}
+
+// Tests that blocks containing transactions to filtered addresses are rejected
+// once the filter is enforced.
+func TestAddressFilterBlocks(t *testing.T) {
+	var (
+		filtered = common.HexToAddress("0xbad")
+		allowed  = common.HexToAddress("0x600d")
+	)
+	chain, blocks, _ := newAddressFilterChain(t, filtered, allowed, 1, 6)
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	chain, blocks, _ = newAddressFilterChain(t, filtered, filtered, 3, 6)
+	defer chain.Stop()
+	n, err := chain.InsertChain(blocks)
+	if !errors.Is(err, ErrAddressFiltered) {
+		t.Fatalf("filtered transaction not rejected: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("rejected block mismatch: have %d, want %d", blocks[n].NumberU64(), 3)
+	}
+}
+
+// Tests that the transaction pool facing check uses the list enforced on the
+// next block.
+func TestAddressFilterPending(t *testing.T) {
+	var (
+		filtered = common.HexToAddress("0xbad")
+		allowed  = common.HexToAddress("0x600d")
+	)
+	chain, _, key := newAddressFilterChain(t, filtered, allowed, 1, 0)
+	defer chain.Stop()
+
+	signer := types.LatestSigner(chain.Config())
+	bad, _ := types.SignTx(types.NewTransaction(0, filtered, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee), nil), signer, key)
+	if err := chain.CheckPendingTransactionAddresses(bad); !errors.Is(err, ErrAddressFiltered) {
+		t.Fatalf("filtered recipient not rejected: %v", err)
+	}
+	good, _ := types.SignTx(types.NewTransaction(0, allowed, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee), nil), signer, key)
+	if err := chain.CheckPendingTransactionAddresses(good); err != nil {
+		t.Fatalf("allowed recipient rejected: %v", err)
+	}
+	// Checks are read-only, the list read from the state isn't persisted
+	if _, ok := rawdb.ReadAddressFilter(chain.db, chain.Genesis().Hash()); ok {
+		t.Fatalf("address filter persisted by a check")
+	}
+}
+
+// Tests that the list of an epoch boundary is saved when the boundary block is
+// imported, so validating the next epoch doesn't need the boundary state.
+func TestAddressFilterSavedOnImport(t *testing.T) {
+	var (
+		filtered = common.HexToAddress("0xbad")
+		allowed  = common.HexToAddress("0x600d")
+	)
+	chain, blocks, _ := newAddressFilterChain(t, filtered, allowed, 1, 8)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks[:4]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	addrs, ok := rawdb.ReadAddressFilter(chain.db, blocks[3].Hash())
+	if !ok || len(addrs) != 1 || addrs[0] != filtered {
+		t.Fatalf("boundary address filter not saved on import: %v", addrs)
+	}
+	// Blocks not at a boundary don't save a list
+	if _, ok := rawdb.ReadAddressFilter(chain.db, blocks[2].Hash()); ok {
+		t.Fatalf("address filter saved for non-boundary block")
+	}
+	// The saved list is enforced without consulting the state of the boundary
+	rawdb.WriteAddressFilter(chain.db, blocks[3].Hash(), []common.Address{allowed})
+	chain.addressFilters.Purge()
+
+	n, err := chain.InsertChain(blocks[4:])
+	if !errors.Is(err, ErrAddressFiltered) || n != 0 {
+		t.Fatalf("saved address filter not enforced: block %d, err %v", n, err)
+	}
+}
+
+// Tests that the epoch boundaries of the blocks walked are cached, so looking up
+// the list of a later block in the same epoch doesn't walk the headers again.
+func TestAddressFilterSource(t *testing.T) {
+	chain, blocks, _ := newAddressFilterChain(t, common.HexToAddress("0xbad"), common.HexToAddress("0x600d"), 1, 7)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.addressSources.Purge()
+
+	source, err := chain.addressFilterSource(blocks[6].Hash(), 7)
+	if err != nil {
+		t.Fatalf("failed to find epoch boundary: %v", err)
+	}
+	if source.Hash() != blocks[3].Hash() {
+		t.Fatalf("epoch boundary mismatch: have #%d, want #4", source.Number)
+	}
+	for _, block := range blocks[4:] {
+		if hash, ok := chain.addressSources.Get(block.Hash()); !ok || hash != blocks[3].Hash() {
+			t.Errorf("epoch boundary of block #%d not cached", block.NumberU64())
+		}
+	}
+	// Boundaries are their own source
+	if source, err = chain.addressFilterSource(blocks[3].Hash(), 4); err != nil || source.Hash() != blocks[3].Hash() {
+		t.Fatalf("epoch boundary mismatch: have %v, err %v", source, err)
+	}
+}
+
+// Tests that a filter contract failing to return a valid list filters nothing,
+// instead of halting block processing.
+func TestAddressFilterBroken(t *testing.T) {
+	chain, _, _ := newAddressFilterChain(t, common.HexToAddress("0xbad"), common.HexToAddress("0x600d"), 1, 0)
+	defer chain.Stop()
+
+	contract := chain.Config().AddressFilter.Contract
+	for _, code := range [][]byte{
+		common.FromHex("0x60006000fd"), // Reverts
+		common.FromHex("0x60016000f3"), // Returns garbage
+		common.FromHex("0x5b600056"),   // Runs out of gas
+	} {
+		statedb, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		statedb.SetCode(contract, code)
+		if addrs := chain.readAddressFilter(chain.CurrentHeader(), statedb); addrs != nil {
+			t.Errorf("code %x: broken filter returned addresses: %v", code, addrs)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var batchCallMeter = metrics.NewRegisteredMeter("chain/batchcall/calls", nil)
+
+// BatchCallResult is the outcome of a single call of a batch. Err is set if the
+// call could not be executed, reverts are reported through Result.
+type BatchCallResult struct {
+	Result *ExecutionResult
+	Err    error
+}
+
+// BatchCall executes the given read-only calls concurrently on top of the
+// state of the given header. The state view is pinned once and every worker
+// operates on a copy of it sharing the storage pool, so the slots loaded by
+// one call are warm for all the others. State changes of a call are reverted
+// before the next one runs, calls never observe each other's effects.
+//
+// The messages are expected to be constructed like eth_call ones, i.e. with
+// nonce checks skipped. Calls not started before the context is cancelled
+// fail with the context error.
+func (bc *BlockChain) BatchCall(ctx context.Context, header *types.Header, calls []*Message) ([]*BatchCallResult, error) {
+	statedb, err := state.NewWithSharedPool(header.Root, bc.stateDatabase(header.Root))
+	if err != nil {
+		return nil, err
+	}
+	statedb.EnableSharedStorage(true)
+
+	var (
+		results = make([]*BatchCallResult, len(calls))
+		tasks   = make(chan int, len(calls))
+		workers = min(runtime.NumCPU(), len(calls))
+		pend    sync.WaitGroup
+	)
+	for i := range calls {
+		tasks <- i
+	}
+	close(tasks)
+
+	for i := 0; i < workers; i++ {
+		// Copy in the caller thread, the base state is not thread safe
+		statedb := statedb.Copy()
+
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+
+			evm := vm.NewEVM(NewEVMBlockContext(header, bc, nil), statedb, bc.chainConfig, vm.Config{NoBaseFee: true})
+			for index := range tasks {
+				if err := ctx.Err(); err != nil {
+					results[index] = &BatchCallResult{Err: err}
+					continue
+				}
+				snapshot := statedb.Snapshot()
+				result, err := ApplyMessage(evm, calls[index], new(GasPool).AddGas(math.MaxUint64))
+				statedb.RevertToSnapshot(snapshot)
+
+				results[index] = &BatchCallResult{Result: result, Err: err}
+			}
+		}()
+	}
+	pend.Wait()
+	batchCallMeter.Mark(int64(len(calls)))
+	return results, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that batched calls are executed against the pinned state in isolation
+// from each other.
+func TestBatchCall(t *testing.T) {
+	var (
+		caller   = common.Address{0xaa}
+		contract = common.Address{0xcc}
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				// Increment slot 0 and return the new value
+				contract: {
+					Code: []byte{
+						byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+						byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+						byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+					},
+					Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(0xff))},
+				},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	calls := make([]*Message, 64)
+	for i := range calls {
+		calls[i] = &Message{
+			From:            caller,
+			To:              &contract,
+			Value:           new(big.Int),
+			GasLimit:        100000,
+			GasPrice:        new(big.Int),
+			GasFeeCap:       new(big.Int),
+			GasTipCap:       new(big.Int),
+			SkipNonceChecks: true,
+		}
+	}
+	results, err := chain.BatchCall(context.Background(), chain.CurrentBlock(), calls)
+	if err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	want := common.BigToHash(big.NewInt(0x100))
+	for i, res := range results {
+		if res.Err != nil || res.Result.Failed() {
+			t.Fatalf("call %d failed: %v %v", i, res.Err, res.Result.Err)
+		}
+		if have := common.BytesToHash(res.Result.ReturnData); have != want {
+			t.Errorf("call %d: result mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	// Cancelled batches don't execute the calls
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err = chain.BatchCall(ctx, chain.CurrentBlock(), calls)
+	if err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	for i, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("call %d: error mismatch: have %v, want %v", i, res.Err, context.Canceled)
+		}
+	}
+}
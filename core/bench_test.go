@@ -355,3 +355,85 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 		db.Close()
 	}
 }
+
+func BenchmarkReorg_depth1_touched0(b *testing.B)    { benchReorg(b, 1, 0) }
+func BenchmarkReorg_depth1_touched100(b *testing.B)  { benchReorg(b, 1, 100) }
+func BenchmarkReorg_depth16_touched0(b *testing.B)   { benchReorg(b, 16, 0) }
+func BenchmarkReorg_depth16_touched100(b *testing.B) { benchReorg(b, 16, 100) }
+func BenchmarkReorg_depth64_touched0(b *testing.B)   { benchReorg(b, 64, 0) }
+func BenchmarkReorg_depth64_touched100(b *testing.B) { benchReorg(b, 64, 100) }
+
+// genTouchedState returns a block generator that funds the given number of new
+// accounts in each block, salted to keep the accounts of sibling chains apart.
+func genTouchedState(touched int, salt uint64) func(int, *BlockGen) {
+	return func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{byte(salt)})
+		for j := 0; j < touched; j++ {
+			to := common.BigToAddress(new(big.Int).SetUint64(salt<<32 | uint64(i*touched+j)))
+			tx, err := types.SignNewTx(benchRootKey, gen.Signer(), &types.LegacyTx{
+				Nonce:    gen.TxNonce(benchRootAddr),
+				To:       &to,
+				Value:    big.NewInt(1),
+				Gas:      params.TxGas,
+				GasPrice: gen.header.BaseFee,
+			})
+			if err != nil {
+				panic(err)
+			}
+			gen.AddTx(tx)
+		}
+	}
+}
+
+// makeReorgChains generates a canonical chain and a heavier fork replacing its
+// last depth blocks, each block touching the given number of accounts. It's the
+// parametrised variant of getLongAndShortChains.
+func makeReorgChains(depth, touched int) (*Genesis, []*types.Block, []*types.Block) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	genDb, ancestors, _ := GenerateChainWithGenesis(genesis, engine, 4, genTouchedState(touched, 1))
+	parent := ancestors[len(ancestors)-1]
+
+	canon, _ := GenerateChain(genesis.Config, parent, engine, genDb, depth, genTouchedState(touched, 2))
+	fork, _ := GenerateChain(genesis.Config, parent, engine, genDb, depth+1, func(i int, gen *BlockGen) {
+		genTouchedState(touched, 3)(i, gen)
+		gen.OffsetTime(-9) // Keep the difficulty high to outweigh the canonical chain
+	})
+	return genesis, append(ancestors, canon...), fork
+}
+
+// benchReorg measures the cost of switching to a fork replacing the last depth
+// canonical blocks, including the execution of the fork.
+func benchReorg(b *testing.B, depth, touched int) {
+	genesis, canon, fork := makeReorgChains(depth, touched)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			b.Fatalf("failed to create blockchain: %v", err)
+		}
+		if n, err := chain.InsertChain(canon); err != nil {
+			b.Fatalf("failed to insert canonical block %d: %v", n, err)
+		}
+		b.StartTimer()
+
+		if n, err := chain.InsertChain(fork); err != nil {
+			b.Fatalf("failed to insert fork block %d: %v", n, err)
+		}
+		b.StopTimer()
+		if head := chain.CurrentBlock().Hash(); head != fork[len(fork)-1].Hash() {
+			b.Fatalf("reorg not performed: head %x, want %x", head, fork[len(fork)-1].Hash())
+		}
+		chain.Stop()
+		b.StartTimer()
+	}
+}
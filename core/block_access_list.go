@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	errMissingAccessList  = errors.New("block access list not available")
+	errAccessListMismatch = errors.New("block access list mismatch")
+
+	accessListMismatchMeter = metrics.NewRegisteredMeter("chain/accesslist/mismatch", nil)
+)
+
+// EnableBlockAccessLists returns a BlockChainOption which produces the access
+// list of every block executed during import, and stores it along with the
+// block. If a block is executed again, e.g. after a rewind, its new access list
+// is checked against the stored one. A mismatch is reported, but doesn't fail
+// the import of the otherwise valid block: the new list replaces the stored one.
+//
+// Blocks produced locally are not covered, as the miner's state also includes
+// the accesses of the transactions left out of the block.
+func EnableBlockAccessLists() BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.accessLists = true
+		return bc, nil
+	}
+}
+
+// newBlockAccessList builds the access list of a block from the state accessed
+// by its execution.
+func newBlockAccessList(statedb *state.StateDB) types.BlockAccessList {
+	accessed := statedb.AccessedState()
+
+	list := make(types.BlockAccessList, 0, len(accessed))
+	for addr, slots := range accessed {
+		// Accounts destructed and recreated in the block are collected twice
+		slices.SortFunc(slots, common.Hash.Cmp)
+		list = append(list, types.AccountAccess{Address: addr, StorageKeys: slices.Compact(slots)})
+	}
+	slices.SortFunc(list, func(a, b types.AccountAccess) int { return a.Address.Cmp(b.Address) })
+	return list
+}
+
+// writeBlockAccessList stores the access list produced by executing the block.
+// If a different list is already stored for the block, the mismatch is logged
+// and the stored list overwritten, as the block itself passed validation.
+func (bc *BlockChain) writeBlockAccessList(block *types.Block, list types.BlockAccessList) {
+	if stored := rawdb.ReadBlockAccessList(bc.db, block.Hash(), block.NumberU64()); stored != nil {
+		have, want := list.Commitment(block.Hash()), stored.Commitment(block.Hash())
+		if have == want {
+			return
+		}
+		log.Error("Block access list mismatch, replacing stored one", "number", block.Number(), "hash", block.Hash(), "have", have, "stored", want)
+		accessListMismatchMeter.Mark(1)
+	}
+	rawdb.WriteBlockAccessList(bc.db, block.Hash(), block.NumberU64(), list)
+}
+
+// GetBlockAccessList retrieves the access list produced by executing a block,
+// or nil if the block wasn't executed with access lists enabled.
+func (bc *BlockChain) GetBlockAccessList(hash common.Hash) types.BlockAccessList {
+	number := bc.hc.GetBlockNumber(hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadBlockAccessList(bc.db, hash, *number)
+}
+
+// VerifyBlockAccessList checks an access list obtained elsewhere for a block
+// against the commitment of the one produced by executing it.
+func (bc *BlockChain) VerifyBlockAccessList(hash common.Hash, list types.BlockAccessList) error {
+	stored := bc.GetBlockAccessList(hash)
+	if stored == nil {
+		return errMissingAccessList
+	}
+	if have, want := list.Commitment(hash), stored.Commitment(hash); have != want {
+		return fmt.Errorf("%w: have %x, want %x", errAccessListMismatch, have, want)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the access lists of the imported blocks are produced identically
+// by serial and parallel execution, and that they can be verified.
+func TestBlockAccessList(t *testing.T) {
+	var (
+		// Copies storage slot 0 into slot 1
+		code    = common.FromHex("0x60005460015500")
+		keys    = make([]*ecdsa.PrivateKey, parallelMinTxs)
+		targets = make([]common.Address, parallelMinTxs)
+		alloc   = make(types.GenesisAlloc)
+		signer  = types.LatestSigner(params.TestChainConfig)
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		targets[i] = common.BigToAddress(big.NewInt(int64(0xc000 + i)))
+
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = types.Account{Balance: big.NewInt(params.Ether)}
+		alloc[targets[i]] = types.Account{Code: code, Storage: map[common.Hash]common.Hash{{}: {0x1}}}
+	}
+	genesis := &Genesis{Config: params.TestChainConfig, Alloc: alloc, BaseFee: big.NewInt(params.InitialBaseFee)}
+
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 1, func(i int, b *BlockGen) {
+		for j, key := range keys {
+			tx, _ := types.SignTx(types.NewTransaction(0, targets[j], nil, 100_000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	produce := func(workers int) types.BlockAccessList {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{ParallelWorkers: workers}, nil, nil, EnableBlockAccessLists())
+		if err != nil {
+			t.Fatalf("failed to create blockchain: %v", err)
+		}
+		defer chain.Stop()
+
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("failed to insert block %d: %v", n, err)
+		}
+		list := chain.GetBlockAccessList(blocks[0].Hash())
+		if list == nil {
+			t.Fatal("access list not produced")
+		}
+		if err := chain.VerifyBlockAccessList(blocks[0].Hash(), list); err != nil {
+			t.Fatalf("failed to verify access list: %v", err)
+		}
+		if err := chain.VerifyBlockAccessList(blocks[0].Hash(), list[1:]); !errors.Is(err, errAccessListMismatch) {
+			t.Fatalf("tampered access list error mismatch: have %v, want %v", err, errAccessListMismatch)
+		}
+		if err := chain.VerifyBlockAccessList(chain.Genesis().Hash(), list); !errors.Is(err, errMissingAccessList) {
+			t.Fatalf("missing access list error mismatch: have %v, want %v", err, errMissingAccessList)
+		}
+		// Executing the block again into a different list replaces the stored one
+		chain.writeBlockAccessList(blocks[0], list[1:])
+		if stored := chain.GetBlockAccessList(blocks[0].Hash()); stored.Hash() != list[1:].Hash() {
+			t.Fatalf("stored access list not replaced: have %x, want %x", stored.Hash(), list[1:].Hash())
+		}
+		return list
+	}
+	serial := produce(0)
+	for _, target := range targets {
+		want := types.AccountAccess{Address: target, StorageKeys: []common.Hash{{}, common.BigToHash(common.Big1)}}
+		var found bool
+		for _, access := range serial {
+			if access.Address == target {
+				found = reflect.DeepEqual(access, want)
+				break
+			}
+		}
+		if !found {
+			t.Errorf("access of %x missing or mismatching, want %v", target, want)
+		}
+	}
+	if serial.Commitment(blocks[0].Hash()) == serial.Commitment(blocks[0].ParentHash()) {
+		t.Error("access list commitment not bound to the block")
+	}
+	if parallel := produce(len(keys)); parallel.Hash() != serial.Hash() {
+		t.Fatalf("parallel access list mismatch: have %v, want %v", parallel, serial)
+	}
+}
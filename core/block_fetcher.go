@@ -0,0 +1,152 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// maxFetchedBlocks is the maximum number of missing block bodies retrieved to
+// switch the head to a block only known by its header.
+const maxFetchedBlocks = 1024
+
+var errNoBlockFetcher = errors.New("no block fetcher registered")
+
+// BlockFetcher retrieves the blocks of the given contiguous headers, ordered by
+// number, from elsewhere, e.g. the network. It may return the blocks of a prefix
+// of the headers only, but at least one of them unless failing.
+type BlockFetcher func(headers []*types.Header) ([]*types.Block, error)
+
+// RegisterBlockFetcher registers the fetcher used to retrieve the bodies of the
+// blocks only known by their headers, replacing the previous one.
+func (bc *BlockChain) RegisterBlockFetcher(fetcher BlockFetcher) {
+	bc.blockFetcher.Store(&fetcher)
+}
+
+// SetCanonicalHash is the variant of SetCanonical for a block which may only be
+// known by its header. The missing bodies of the block and its ancestors are
+// retrieved through the registered block fetcher first, and the head state is
+// regenerated by SetCanonical if needed.
+func (bc *BlockChain) SetCanonicalHash(hash common.Hash) (common.Hash, error) {
+	block, err := bc.FetchBlock(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return bc.SetCanonical(block)
+}
+
+// FetchBlock retrieves a block by hash, fetching its body and the ones of its
+// ancestors through the registered block fetcher if only their headers are
+// known locally.
+func (bc *BlockChain) FetchBlock(hash common.Hash) (*types.Block, error) {
+	header := bc.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, fmt.Errorf("unknown header %x", hash)
+	}
+	// Collect the headers missing their bodies, the genesis block always exists
+	var missing []*types.Header
+	for h := header; !bc.HasBlock(h.Hash(), h.Number.Uint64()); {
+		if len(missing) == maxFetchedBlocks {
+			return nil, fmt.Errorf("too many missing bodies, more than %d", maxFetchedBlocks)
+		}
+		missing = append(missing, h)
+		if h = bc.GetHeader(h.ParentHash, h.Number.Uint64()-1); h == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+	}
+	if len(missing) > 0 {
+		for i, j := 0, len(missing)-1; i < j; i, j = i+1, j-1 {
+			missing[i], missing[j] = missing[j], missing[i]
+		}
+		if err := bc.fetchBlocks(missing); err != nil {
+			return nil, err
+		}
+		log.Info("Retrieved missing block bodies", "count", len(missing), "number", header.Number, "hash", hash)
+	}
+	block := bc.GetBlock(hash, header.Number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block %x not available", hash)
+	}
+	return block, nil
+}
+
+// fetchBlocks retrieves the blocks of the given headers, ordered by number,
+// through the registered block fetcher, and stores them without state.
+func (bc *BlockChain) fetchBlocks(headers []*types.Header) error {
+	fetch := bc.blockFetcher.Load()
+	if fetch == nil {
+		return errNoBlockFetcher
+	}
+	for len(headers) > 0 {
+		blocks, err := (*fetch)(headers)
+		if err != nil {
+			return err
+		}
+		if len(blocks) == 0 || len(blocks) > len(headers) {
+			return fmt.Errorf("invalid fetch result: %d blocks for %d headers", len(blocks), len(headers))
+		}
+		for i, block := range blocks {
+			if err := verifyFetchedBlock(bc.chainConfig, headers[i], block); err != nil {
+				return err
+			}
+			if err := IsDataAvailable(bc, block); err != nil {
+				return fmt.Errorf("fetched block %d unavailable: %w", block.Number(), err)
+			}
+			td := bc.GetTd(block.Hash(), block.NumberU64())
+			if td == nil {
+				return consensus.ErrUnknownAncestor
+			}
+			if err := bc.writeBlockWithoutState(block, td); err != nil {
+				return err
+			}
+		}
+		headers = headers[len(blocks):]
+	}
+	return nil
+}
+
+// verifyFetchedBlock checks that the body of a fetched block matches its header.
+func verifyFetchedBlock(config *params.ChainConfig, header *types.Header, block *types.Block) error {
+	if block.Hash() != header.Hash() {
+		return fmt.Errorf("fetched block mismatch: have %x, want %x", block.Hash(), header.Hash())
+	}
+	if hash := types.DeriveSha(block.Transactions(), trie.NewRootHasher(config)); hash != header.TxHash {
+		return fmt.Errorf("fetched block %d transaction root mismatch: have %x, want %x", header.Number, hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("fetched block %d uncle root mismatch: have %x, want %x", header.Number, hash, header.UncleHash)
+	}
+	switch {
+	case header.WithdrawalsHash == nil && block.Withdrawals() != nil:
+		return fmt.Errorf("fetched block %d has unexpected withdrawals", header.Number)
+	case header.WithdrawalsHash != nil && block.Withdrawals() == nil:
+		return fmt.Errorf("fetched block %d is missing withdrawals", header.Number)
+	case header.WithdrawalsHash != nil:
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewRootHasher(config)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("fetched block %d withdrawals root mismatch: have %x, want %x", header.Number, hash, *header.WithdrawalsHash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the head can be switched to a block only known by its header, with
+// the missing bodies retrieved through the registered fetcher.
+func TestSetCanonicalHash(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.LatestSigner(params.TestChainConfig)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 5, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	head := blocks[len(blocks)-1].Hash()
+	if _, err := chain.SetCanonicalHash(head); !errors.Is(err, errNoBlockFetcher) {
+		t.Fatalf("switch without fetcher error mismatch: have %v, want %v", err, errNoBlockFetcher)
+	}
+	// Serve the bodies two at a time, tampering with them if requested
+	var tamper bool
+	chain.RegisterBlockFetcher(func(headers []*types.Header) ([]*types.Block, error) {
+		var served []*types.Block
+		for _, header := range headers[:min(2, len(headers))] {
+			block := blocks[header.Number.Uint64()-1]
+			if tamper {
+				block = types.NewBlockWithHeader(header).WithBody(types.Body{Withdrawals: block.Withdrawals()})
+			}
+			served = append(served, block)
+		}
+		return served, nil
+	})
+	tamper = true
+	if _, err := chain.SetCanonicalHash(head); err == nil {
+		t.Fatal("tampered bodies accepted")
+	}
+	tamper = false
+	if _, err := chain.SetCanonicalHash(head); err != nil {
+		t.Fatalf("failed to switch head: %v", err)
+	}
+	if have := chain.CurrentBlock().Hash(); have != head {
+		t.Fatalf("head mismatch: have %x, want %x", have, head)
+	}
+	if !chain.HasState(blocks[len(blocks)-1].Root()) {
+		t.Fatal("head state not regenerated")
+	}
+}
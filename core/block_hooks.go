@@ -0,0 +1,63 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockHooks are the callbacks invoked around the execution of every block
+// imported by InsertChainWithHook. They're run synchronously on the import
+// path with the chain lock held, so they must not call back into the chain
+// mutators. Blocks dropped by a later reorg are announced by the ReorgEvent, not
+// here.
+type BlockHooks struct {
+	// PreBlock is invoked before executing the block, with the state of its
+	// parent. An error aborts the import before the block is executed.
+	PreBlock func(block *types.Block, statedb *state.StateDB) error
+
+	// PostBlock is invoked after the block and its state are committed, with
+	// the post state and the receipts of the block. Side blocks are reported
+	// too, the block is canonical if it's the current block by then. An error
+	// aborts the import of the remaining blocks, the block itself is kept.
+	PostBlock func(block *types.Block, statedb *state.StateDB, receipts types.Receipts) error
+}
+
+// preBlock invokes the PreBlock hook, if any.
+func (h *BlockHooks) preBlock(block *types.Block, statedb *state.StateDB) error {
+	if h == nil || h.PreBlock == nil {
+		return nil
+	}
+	if err := h.PreBlock(block, statedb); err != nil {
+		return fmt.Errorf("pre-block hook of block %d failed: %w", block.NumberU64(), err)
+	}
+	return nil
+}
+
+// postBlock invokes the PostBlock hook, if any.
+func (h *BlockHooks) postBlock(block *types.Block, statedb *state.StateDB, receipts types.Receipts) error {
+	if h == nil || h.PostBlock == nil {
+		return nil
+	}
+	if err := h.PostBlock(block, statedb, receipts); err != nil {
+		return fmt.Errorf("post-block hook of block %d failed: %w", block.NumberU64(), err)
+	}
+	return nil
+}
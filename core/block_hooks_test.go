@@ -0,0 +1,97 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the block hooks are invoked around every imported block with the
+// pre and post states, and that their failures abort the import.
+func TestInsertChainWithHook(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.Address{0xaa}
+		signer  = types.LatestSigner(params.TestChainConfig)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), to, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Index the balance of the recipient after every block
+	var (
+		pre      []uint64
+		balances []uint64
+		failPost = errors.New("post failure")
+	)
+	hooks := &BlockHooks{
+		PreBlock: func(block *types.Block, statedb *state.StateDB) error {
+			pre = append(pre, statedb.GetBalance(to).Uint64())
+			return nil
+		},
+		PostBlock: func(block *types.Block, statedb *state.StateDB, receipts types.Receipts) error {
+			if len(receipts) != 1 || receipts[0].Status != types.ReceiptStatusSuccessful {
+				t.Errorf("block %d: unexpected receipts %v", block.NumberU64(), receipts)
+			}
+			balances = append(balances, statedb.GetBalance(to).Uint64())
+			if block.NumberU64() == 2 {
+				return failPost
+			}
+			return nil
+		},
+	}
+	if n, err := chain.InsertChainWithHook(blocks, hooks); !errors.Is(err, failPost) || n != 1 {
+		t.Fatalf("post-block failure mismatch: have %d/%v, want 1/%v", n, err, failPost)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 2 {
+		t.Fatalf("head mismatch after post-block failure: have %d, want 2", head)
+	}
+	hooks.PostBlock = nil
+	if n, err := chain.InsertChainWithHook(blocks[2:], hooks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if want := []uint64{0, 1, 2}; !slices.Equal(pre, want) {
+		t.Fatalf("pre-block balances mismatch: have %v, want %v", pre, want)
+	}
+	if want := []uint64{1, 2}; !slices.Equal(balances, want) {
+		t.Fatalf("post-block balances mismatch: have %v, want %v", balances, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import "errors"
+
+var (
+	errNilValidator  = errors.New("nil block validator")
+	errNilProcessor  = errors.New("nil block processor")
+	errNilPrefetcher = errors.New("nil state prefetcher")
+)
+
+// WithValidator returns a BlockChainOption which replaces the block validator
+// of the chain with the one built by the given constructor. The constructor is
+// invoked with the fully initialised chain, whose default validator is still
+// accessible through Validator, so it can be wrapped rather than replaced.
+func WithValidator(build func(bc *BlockChain) Validator) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		validator := build(bc)
+		if validator == nil {
+			return nil, errNilValidator
+		}
+		bc.validator = validator
+		return bc, nil
+	}
+}
+
+// WithProcessor returns a BlockChainOption which replaces the block processor
+// of the chain with the one built by the given constructor. The default
+// processor is accessible through Processor while the constructor runs.
+func WithProcessor(build func(bc *BlockChain) Processor) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		processor := build(bc)
+		if processor == nil {
+			return nil, errNilProcessor
+		}
+		bc.processor = processor
+		return bc, nil
+	}
+}
+
+// WithPrefetcher returns a BlockChainOption which replaces the state prefetcher
+// warming the caches ahead of block processing with the one built by the given
+// constructor. The default prefetcher is accessible through Prefetcher while
+// the constructor runs.
+func WithPrefetcher(build func(bc *BlockChain) Prefetcher) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		prefetcher := build(bc)
+		if prefetcher == nil {
+			return nil, errNilPrefetcher
+		}
+		bc.prefetcher = prefetcher
+		return bc, nil
+	}
+}
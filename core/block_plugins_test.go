@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var errForbiddenExtra = errors.New("forbidden extra data")
+
+// extraValidator wraps a validator, additionally rejecting blocks carrying a
+// forbidden extra data.
+type extraValidator struct {
+	Validator
+	forbidden []byte
+}
+
+func (v *extraValidator) ValidateBody(block *types.Block) error {
+	if bytes.Equal(block.Extra(), v.forbidden) {
+		return errForbiddenExtra
+	}
+	return v.Validator.ValidateBody(block)
+}
+
+// countingProcessor wraps a processor, counting the processed blocks.
+type countingProcessor struct {
+	Processor
+	processed int
+}
+
+func (p *countingProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (*ProcessResult, error) {
+	p.processed++
+	return p.Processor.Process(block, statedb, cfg)
+}
+
+// Tests that custom validators and processors are injected into the chain.
+func TestBlockPlugins(t *testing.T) {
+	var (
+		engine    = ethash.NewFaker()
+		genesis   = &Genesis{Config: params.TestChainConfig}
+		processor *countingProcessor
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		if i == 2 {
+			b.SetExtra([]byte("forbidden"))
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil,
+		WithValidator(func(bc *BlockChain) Validator {
+			return &extraValidator{Validator: bc.Validator(), forbidden: []byte("forbidden")}
+		}),
+		WithProcessor(func(bc *BlockChain) Processor {
+			processor = &countingProcessor{Processor: bc.Processor()}
+			return processor
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); n != 2 || !errors.Is(err, errForbiddenExtra) {
+		t.Fatalf("forbidden block accepted: index %d, err %v", n, err)
+	}
+	if processor.processed != 2 {
+		t.Errorf("processed block count mismatch: have %d, want 2", processor.processed)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 2 {
+		t.Errorf("head mismatch: have %d, want 2", head)
+	}
+	// Constructors failing to build a plugin are rejected
+	_, err = NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil,
+		WithPrefetcher(func(bc *BlockChain) Prefetcher { return nil }))
+	if !errors.Is(err, errNilPrefetcher) {
+		t.Errorf("nil prefetcher accepted: %v", err)
+	}
+}
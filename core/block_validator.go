@@ -66,7 +66,7 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 
 	validateFuns := []func() error{
 		func() error {
-			if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+			if hash := types.DeriveSha(block.Transactions(), trie.NewRootHasher(v.config)); hash != header.TxHash {
 				return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 			}
 			return nil
@@ -78,7 +78,7 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 				if block.Withdrawals() == nil {
 					return errors.New("missing withdrawals in block body")
 				}
-				if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+				if hash := types.DeriveSha(block.Withdrawals(), trie.NewRootHasher(v.config)); hash != *header.WithdrawalsHash {
 					return fmt.Errorf("withdrawals root hash mismatch (header value %x, calculated %x)", *header.WithdrawalsHash, hash)
 				}
 			} else if block.Withdrawals() != nil { // Withdrawals turn into empty from nil when BlockBody has Sidecars
@@ -135,7 +135,9 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 			return r
 		}
 	}
-	return nil
+	// The filtered addresses are read from the ancestor states, so check them
+	// only once the parent state is known to be present.
+	return v.bc.checkBlockAddresses(block)
 }
 
 // ValidateState validates the various changes that happen after a state transition,
@@ -164,7 +166,7 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	if !stateless {
 		validateFuns = append(validateFuns, func() error {
 			// The receipt Trie's root (R = (Tr [[H1, R1], ... [Hn, Rn]]))
-			receiptSha := types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
+			receiptSha := types.DeriveSha(res.Receipts, trie.NewRootHasher(v.config))
 			if receiptSha != header.ReceiptHash {
 				return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
 			}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests that simple header verification works, for both good and bad blocks.
@@ -265,3 +266,49 @@ func TestCalcGasLimit(t *testing.T) {
 		}
 	}
 }
+
+// Tests that chains configured with a custom root hasher derive the list roots
+// of produced blocks with it, and validate imported blocks against it.
+func TestCustomRootHasher(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		config  = *params.TestChainConfig
+		genesis = &Genesis{
+			Config:  &config,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(&config)
+	)
+	config.RootHasher = params.RootHasherBinary
+
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		for j := 0; j < 3; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	for i, block := range blocks {
+		if have, want := block.TxHash(), types.DeriveSha(block.Transactions(), trie.NewBinaryHasher()); have != want {
+			t.Fatalf("block %d: transaction root mismatch: have %x, want %x", i, have, want)
+		}
+		if have, want := block.ReceiptHash(), types.DeriveSha(receipts[i], trie.NewBinaryHasher()); have != want {
+			t.Fatalf("block %d: receipt root mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	// Chains with unknown root hashers must be rejected
+	config.RootHasher = "unknown"
+	if _, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil); err == nil {
+		t.Fatal("chain with unknown root hasher created")
+	}
+}
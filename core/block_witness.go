@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	errInvalidWitnessLimit = errors.New("witness limit must be positive")
+
+	witnessRecordMeter = metrics.NewRegisteredMeter("chain/witness/record", nil)
+)
+
+// BlockWitness is the execution witness of an imported block: the trie nodes,
+// codes and ancestor headers needed to execute it statelessly, along with the
+// accounts and storage slots it accessed.
+type BlockWitness struct {
+	Number  uint64
+	Hash    common.Hash
+	Witness *stateless.Witness
+
+	Accounts []common.Address                 // Accessed accounts, sorted
+	Storage  map[common.Address][]common.Hash // Accessed storage slots per account, sorted
+}
+
+// WithWitnessRecording returns a BlockChainOption which records the execution
+// witness of every block processed during import, retaining the given number
+// of most recent ones for retrieval via GetBlockWitness. Witnesses are large,
+// so the limit should be kept small.
+func WithWitnessRecording(limit int) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if limit <= 0 {
+			return nil, errInvalidWitnessLimit
+		}
+		bc.witnesses = lru.NewCache[common.Hash, *BlockWitness](limit)
+		return bc, nil
+	}
+}
+
+// recordWitness stores the witness collected while processing the block.
+func (bc *BlockChain) recordWitness(block *types.Block, statedb *state.StateDB) {
+	witness := statedb.Witness()
+	if witness == nil {
+		return // Pre-Byzantium blocks are processed without witnesses
+	}
+	accessed := statedb.AccessedState()
+
+	storage := make(map[common.Address][]common.Hash)
+	for addr, slots := range accessed {
+		if len(slots) > 0 {
+			slices.SortFunc(slots, func(a, b common.Hash) int { return a.Cmp(b) })
+			storage[addr] = slots
+		}
+	}
+	accounts := slices.SortedFunc(maps.Keys(accessed), func(a, b common.Address) int { return a.Cmp(b) })
+
+	bc.witnesses.Add(block.Hash(), &BlockWitness{
+		Number:   block.NumberU64(),
+		Hash:     block.Hash(),
+		Witness:  witness,
+		Accounts: accounts,
+		Storage:  storage,
+	})
+	witnessRecordMeter.Mark(1)
+}
+
+// GetBlockWitness returns the recorded execution witness of a block, or nil if
+// witness recording is disabled or the block's witness is not retained.
+func (bc *BlockChain) GetBlockWitness(hash common.Hash) *BlockWitness {
+	if bc.witnesses == nil {
+		return nil
+	}
+	witness, _ := bc.witnesses.Get(hash)
+	return witness
+}
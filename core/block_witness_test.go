@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that witnesses are recorded for every imported block, contain the
+// accessed state and suffice to execute the block statelessly.
+func TestBlockWitnessRecording(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xcc}
+		slot     = common.Hash{0x01}
+		engine   = ethash.NewFaker()
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// PUSH32 slot SLOAD POP
+				contract: {
+					Code:    append(append([]byte{byte(vm.PUSH32)}, slot[:]...), byte(vm.SLOAD), byte(vm.POP)),
+					Storage: map[common.Hash]common.Hash{slot: {0xff}},
+				},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), contract, big.NewInt(1), 50000, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, WithWitnessRecording(2))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if witness := chain.GetBlockWitness(blocks[0].Hash()); witness != nil {
+		t.Errorf("evicted witness retained: block %d", witness.Number)
+	}
+	for _, block := range blocks[1:] {
+		witness := chain.GetBlockWitness(block.Hash())
+		if witness == nil {
+			t.Fatalf("witness missing for block %d", block.NumberU64())
+		}
+		if witness.Number != block.NumberU64() || witness.Hash != block.Hash() {
+			t.Errorf("witness block mismatch: have %d %x", witness.Number, witness.Hash)
+		}
+		if parent := witness.Witness.Headers[0]; parent.Hash() != block.ParentHash() {
+			t.Errorf("witness parent mismatch: have %x, want %x", parent.Hash(), block.ParentHash())
+		}
+		for _, addr := range []common.Address{sender, contract} {
+			if !slices.Contains(witness.Accounts, addr) {
+				t.Errorf("block %d: accessed account %x missing", block.NumberU64(), addr)
+			}
+		}
+		if slots := witness.Storage[contract]; !slices.Equal(slots, []common.Hash{slot}) {
+			t.Errorf("block %d: accessed slots mismatch: have %v, want %v", block.NumberU64(), slots, []common.Hash{slot})
+		}
+		// Cross-validate the block against the witness alone
+		context := block.Header()
+		context.Root, context.ReceiptHash = common.Hash{}, common.Hash{}
+
+		root, receiptRoot, err := ExecuteStateless(params.TestChainConfig, vm.Config{}, types.NewBlockWithHeader(context).WithBody(*block.Body()), witness.Witness)
+		if err != nil {
+			t.Fatalf("block %d: stateless execution failed: %v", block.NumberU64(), err)
+		}
+		if root != block.Root() || receiptRoot != block.ReceiptHash() {
+			t.Errorf("block %d: stateless roots mismatch: have %x %x, want %x %x", block.NumberU64(), root, receiptRoot, block.Root(), block.ReceiptHash())
+		}
+	}
+	// Invalid limits are rejected
+	_, err = NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, WithWitnessRecording(0))
+	if !errors.Is(err, errInvalidWitnessLimit) {
+		t.Errorf("invalid witness limit accepted: %v", err)
+	}
+}
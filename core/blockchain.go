@@ -18,6 +18,8 @@
 package core
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"io"
@@ -52,6 +54,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/ethereum/go-ethereum/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/triedb/pathdb"
@@ -96,14 +99,24 @@ var (
 	blockExecutionTimer       = metrics.NewRegisteredTimer("chain/execution", nil)
 	blockWriteTimer           = metrics.NewRegisteredTimer("chain/write", nil)
 
+	blockCallDepthHist     = metrics.NewRegisteredHistogram("chain/execution/calldepth", nil, metrics.NewExpDecaySample(1028, 0.015))
+	blockMemoryHist        = metrics.NewRegisteredHistogram("chain/execution/memory", nil, metrics.NewExpDecaySample(1028, 0.015))
+	blockReturnDataHist    = metrics.NewRegisteredHistogram("chain/execution/returndata", nil, metrics.NewExpDecaySample(1028, 0.015))
+	blockMaxReturnDataHist = metrics.NewRegisteredHistogram("chain/execution/returndata/max", nil, metrics.NewExpDecaySample(1028, 0.015))
+
 	blockReorgMeter     = metrics.NewRegisteredMeter("chain/reorg/executes", nil)
 	blockReorgAddMeter  = metrics.NewRegisteredMeter("chain/reorg/add", nil)
 	blockReorgDropMeter = metrics.NewRegisteredMeter("chain/reorg/drop", nil)
 
+	stateArchiveMeter = metrics.NewRegisteredMeter("chain/state/archived", nil)
+
 	blockRecvTimeDiffGauge = metrics.NewRegisteredGauge("chain/block/recvtimediff", nil)
 
 	errInsertionInterrupted = errors.New("insertion is interrupted")
 	errChainStopped         = errors.New("blockchain is stopped")
+	errChainPaused          = errors.New("blockchain is already paused")
+	errChainNotPaused       = errors.New("blockchain is not paused")
+	errSidecarRetention     = errors.New("blob sidecar retention below the availability window")
 	errInvalidOldChain      = errors.New("invalid old chain")
 	errInvalidNewChain      = errors.New("invalid new chain")
 )
@@ -115,6 +128,9 @@ const (
 	sidecarsCacheLimit  = 1024
 	txLookupCacheLimit  = 1024
 	maxFutureBlocks     = 256
+	maxCacheLimit       = 1 << 20 // Maximum number of entries of the configurable caches
+	minTriesInMemory    = 2       // The head and its parent are committed on shutdown
+	maxTriesInMemory    = 1 << 16
 	maxTimeFutureBlocks = 30
 	maxBeyondBlocks     = 2048
 	prefetchTxNumber    = 100
@@ -159,16 +175,26 @@ type CacheConfig struct {
 	EnableSharedStorage bool          // Whether to enable shared storage in statedb, improve execute stage performance ~6%.
 	TrieCleanLimit      int           // Memory allowance (MB) to use for caching trie nodes in memory
 	TrieCleanNoPrefetch bool          // Whether to disable heuristic state prefetching for followup blocks
+	PrefetchThreads     int           // Number of threads prefetching the state of imported blocks, 0 for the default
 	TrieDirtyLimit      int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
-	TriesInMemory       uint64        // How many tries keeps in memory
+	TriesInMemory       uint64        // How many tries keeps in memory, 0 for the default
+	BodyCacheLimit      int           // Number of recent block bodies cached, 0 for the default
+	BlockCacheLimit     int           // Number of recent blocks cached, 0 for the default
+	ReceiptsCacheLimit  int           // Number of recent block receipts cached, 0 for the default
 	NoTries             bool          // Insecure settings. Do not have any tries in databases if enabled.
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
+	ReceiptRetention    uint64        // Number of blocks from head whose receipts and log index are retained, 0 to keep all
+	SidecarRetention    uint64        // Number of blocks from head whose blob sidecars are retained, 0 to keep all
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 	PathSyncFlush       bool          // Whether sync flush the trienodebuffer of pathdb to disk.
+	StrictCommit        bool          // Whether to flush the tries of each block before processing the next one (hash scheme only)
+	ArchiveInterval     uint64        // Interval of blocks whose full state is persisted and never pruned, 0 to disable (hash scheme only)
+	CodeCacheJournal    string        // Directory persisting the hot contract codes and analyses across restarts, empty to disable
+	HeaderCacheWarmup   uint64        // Number of recent headers preloaded into the caches on startup, 0 to disable
 	JournalFilePath     string
 	JournalFile         bool
 
@@ -176,6 +202,19 @@ type CacheConfig struct {
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
 
+// CacheReconfig contains the subset of the cache configuration which can be
+// adjusted on a live chain. Nil fields are left unchanged.
+type CacheReconfig struct {
+	TrieCleanLimit      *int           // Memory allowance (MB) to use for caching trie nodes in memory, the cache is emptied
+	TrieDirtyLimit      *int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk (hash scheme only)
+	TrieTimeLimit       *time.Duration // Time limit after which to flush the current in-memory trie to disk
+	TrieCleanNoPrefetch *bool          // Whether to disable heuristic state prefetching for followup blocks
+	SnapshotLimit       *int           // Memory allowance (MB) to use for caching snapshot entries in memory, the cache is emptied
+	BodyCacheLimit      *int           // Number of recent block bodies cached
+	BlockCacheLimit     *int           // Number of recent blocks cached
+	ReceiptsCacheLimit  *int           // Number of recent block receipts cached
+}
+
 // triedbConfig derives the configures for trie database.
 func (c *CacheConfig) triedbConfig(isVerkle bool) *triedb.Config {
 	config := &triedb.Config{
@@ -202,6 +241,42 @@ func (c *CacheConfig) triedbConfig(isVerkle bool) *triedb.Config {
 	return config
 }
 
+// sanitize returns a copy of the cache configuration with the unset operational
+// limits defaulted and the others clamped within safe bounds.
+func (c *CacheConfig) sanitize() *CacheConfig {
+	config := *c
+	switch {
+	case config.TriesInMemory == 0:
+		config.TriesInMemory = state.TriesInMemory
+	case config.TriesInMemory < minTriesInMemory:
+		log.Warn("Sanitizing tries in memory", "provided", config.TriesInMemory, "updated", minTriesInMemory)
+		config.TriesInMemory = minTriesInMemory
+	case config.TriesInMemory > maxTriesInMemory:
+		log.Warn("Sanitizing tries in memory", "provided", config.TriesInMemory, "updated", maxTriesInMemory)
+		config.TriesInMemory = maxTriesInMemory
+	}
+	config.BodyCacheLimit = sanitizeCacheLimit("body", config.BodyCacheLimit, bodyCacheLimit)
+	config.BlockCacheLimit = sanitizeCacheLimit("block", config.BlockCacheLimit, blockCacheLimit)
+	config.ReceiptsCacheLimit = sanitizeCacheLimit("receipts", config.ReceiptsCacheLimit, receiptsCacheLimit)
+	return &config
+}
+
+// sanitizeCacheLimit defaults an unset cache size, or clamps it within safe
+// bounds.
+func sanitizeCacheLimit(name string, limit int, fallback int) int {
+	switch {
+	case limit == 0:
+		return fallback
+	case limit < 0:
+		log.Warn("Sanitizing cache limit", "cache", name, "provided", limit, "updated", fallback)
+		return fallback
+	case limit > maxCacheLimit:
+		log.Warn("Sanitizing cache limit", "cache", name, "provided", limit, "updated", maxCacheLimit)
+		return maxCacheLimit
+	}
+	return limit
+}
+
 // defaultCacheConfig are the default caching values if none are specified by the
 // user (also used during testing).
 var defaultCacheConfig = &CacheConfig{
@@ -267,14 +342,27 @@ type BlockChain struct {
 
 	db            ethdb.Database                   // Low level persistent database to store final content in
 	snaps         *snapshot.Tree                   // Snapshot tree for fast trie leaf access
+	snapRecovery  atomic.Bool                      // Whether an automatic snapshot rebuild is being watched
 	triegc        *prque.Prque[int64, common.Hash] // Priority queue mapping block numbers to tries to gc
-	gcproc        time.Duration                    // Accumulates canonical block processing for trie dumping
+	gcproc        atomic.Int64                     // Accumulates canonical block processing for trie dumping
 	lastWrite     uint64                           // Last block when the state was flushed
+	commitAsync   bool                             // Whether trie commits are pipelined with the next block's processing
+	commitPending chan error                       // Result of the in-flight trie commit, nil if none
 	flushInterval atomic.Int64                     // Time interval (processing time) after which to flush a state
+	dirtyLimit    atomic.Int64                     // Memory limit (MB) of dirty trie nodes, live adjustable copy of the cache config
+	noPrefetch    atomic.Bool                      // Whether state prefetching is disabled, live adjustable copy of the cache config
+	cleanLimit    atomic.Int64                     // Memory allowance (MB) of clean trie nodes, live adjustable copy of the cache config
+	snapshotLimit atomic.Int64                     // Memory allowance (MB) of snapshot entries, live adjustable copy of the cache config
+	bodyLimit     atomic.Int64                     // Number of cached block bodies, live adjustable copy of the cache config
+	blockLimit    atomic.Int64                     // Number of cached blocks, live adjustable copy of the cache config
+	receiptsLimit atomic.Int64                     // Number of cached block receipts, live adjustable copy of the cache config
 	triedb        *triedb.Database                 // The database handler for maintaining trie nodes.
 	statedb       *state.CachingDB                 // State database to reuse between imports (contains state cache)
+	verkleTriedb  *triedb.Database                 // Trie database of the verkle overlays, nil unless transitioning
+	verkleStatedb *state.CachingDB                 // State database of the verkle transition, nil unless transitioning
 	triesInMemory uint64
-	txIndexer     *txIndexer // Transaction indexer, might be nil if not enabled
+	txIndexer     *txIndexer     // Transaction indexer, might be nil if not enabled
+	tasks         *taskScheduler // Supervisor of the background goroutines
 
 	hc                       *HeaderChain
 	rmLogsFeed               event.Feed
@@ -282,9 +370,19 @@ type BlockChain struct {
 	chainHeadFeed            event.Feed
 	chainBlockFeed           event.Feed
 	logsFeed                 event.Feed
+	decodedLogsFeed          event.Feed
 	blockProcFeed            event.Feed
 	finalizedHeaderFeed      event.Feed
 	highestVerifiedBlockFeed event.Feed
+	preCommitFeed            event.Feed
+	reorgDumpFeed            event.Feed
+	reorgFeed                event.Feed
+	futureBlockFeed          event.Feed
+	snapHealthFeed           event.Feed
+	snapGenFeed              event.Feed
+	blobSidecarsFeed         event.Feed
+	progressFeed             event.Feed
+	equivocationFeed         event.Feed
 	scope                    event.SubscriptionScope
 	genesisBlock             *types.Block
 
@@ -297,13 +395,18 @@ type BlockChain struct {
 	currentBlock          atomic.Pointer[types.Header] // Current head of the chain
 	currentSnapBlock      atomic.Pointer[types.Header] // Current head of snap-sync
 	currentFinalBlock     atomic.Pointer[types.Header] // Latest (consensus) finalized block
+	currentSafeBlock      atomic.Pointer[types.Header] // Latest (consensus) safe block
 	chasingHead           atomic.Pointer[types.Header]
+	canonicalSeq          atomic.Uint64 // Bumped around canonical chain rewrites, odd while one is in progress
 
 	bodyCache       *lru.Cache[common.Hash, *types.Body]
 	bodyRLPCache    *lru.Cache[common.Hash, rlp.RawValue]
 	receiptsCache   *lru.Cache[common.Hash, []*types.Receipt]
+	logsCache       *lru.Cache[common.Hash, [][]*types.Log]
 	blockCache      *lru.Cache[common.Hash, *types.Block]
 	blockStatsCache *lru.Cache[common.Hash, *BlockStats]
+	addressFilters  *lru.Cache[common.Hash, addressSet]
+	addressSources  *lru.Cache[common.Hash, common.Hash]
 
 	txLookupLock  sync.RWMutex
 	txLookupCache *lru.Cache[common.Hash, txLookup]
@@ -311,12 +414,23 @@ type BlockChain struct {
 
 	// future blocks are blocks added for later processing
 	futureBlocks *lru.Cache[common.Hash, *types.Block]
+	futureConfig FutureBlockConfig
+
+	witnesses *lru.Cache[common.Hash, *BlockWitness] // Recorded block witnesses, nil if disabled
+	regen     *stateRegenerator                      // Regenerator of the pruned historical states
+
+	codeJournal *codeJournal // Contract code cache persisted across restarts, nil if disabled
 
 	wg            sync.WaitGroup
 	dbWg          sync.WaitGroup
 	quit          chan struct{} // shutdown signal, closed in Stop.
 	stopping      atomic.Bool   // false if chain is running, true when stopped
 	procInterrupt atomic.Bool   // interrupt signaler for block processing
+	stopOnce      sync.Once     // ensures the persisting shutdown only runs once
+	stopped       chan struct{} // closed when the persisting shutdown finished
+	stopPhase     atomic.Value  // shutdown subsystem currently flushing (string)
+	pauseLock     sync.Mutex    // guards the paused flag and the pause transitions
+	paused        bool          // whether the chain mutex is held by Pause
 
 	engine     consensus.Engine
 	prefetcher Prefetcher
@@ -327,18 +441,44 @@ type BlockChain struct {
 
 	// monitor
 	doubleSignMonitor *monitor.DoubleSignMonitor
+	reorgDumper       *reorgDumper       // Post-mortem dumper for deep reorgs, nil if disabled
+	checkpointConfig  *CheckpointConfig  // Periodic state checkpoint export, nil if disabled
+	supplyAuditConfig *SupplyAuditConfig // Periodic native supply audits, nil if disabled
+	lastSupplyAudit   atomic.Pointer[SupplyAudit]
+	readLimiter       *readLimiter       // Rate limiter of the expensive context aware reads, nil if disabled
+	attestKey         *ecdsa.PrivateKey  // Key signing the segment verification attestations, nil if disabled
+	uncleIndex        bool               // Whether to index the canonical uncles by miner
+	contractStats     bool               // Whether to track the storage and code sizes of the contracts
+	accessLists       bool               // Whether to produce the access lists of the imported blocks
+	activityIndex     bool               // Whether to index the blocks every address was active in
+	reorgHooks        []reorgHook        // Callbacks invoked after chain reorganisations
+	insertHooks       []InsertReportHook // Callbacks invoked with the phase timings of the imported blocks
+	sidecarWrites     time.Duration      // Time spent writing the sidecars of the last block written, guarded by chainmu
+	blockHooks        *BlockHooks        // Callbacks of the running InsertChainWithHook, guarded by chainmu
+	progress          importProgress     // Rolling throughput of the block imports
+	progressEvery     uint64             // Number of blocks between import progress events, 0 if disabled
+	revertReasonLimit int                // Maximum stored revert data per failed transaction, 0 if disabled
+	logSchemas        logSchemaRegistry  // Event ABIs of the contracts whose logs are decoded
 	logger            *tracing.Hooks
+
+	lastError    atomic.Pointer[healthError]  // Last block import failure, reported by the health status
+	blockFetcher atomic.Pointer[BlockFetcher] // Retriever of the bodies missing for a head switch, nil if unregistered
 }
 
 // NewBlockChain returns a fully initialised block chain using information
-// available in the database. It initialises the default Ethereum Validator and
-// Processor.
+// available in the database. It initialises the default Ethereum Validator,
+// Processor and Prefetcher, which can be replaced using the WithValidator,
+// WithProcessor and WithPrefetcher options.
 func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis, overrides *ChainOverrides, engine consensus.Engine,
 	vmConfig vm.Config, shouldPreserve func(block *types.Header) bool, txLookupLimit *uint64,
 	options ...BlockChainOption) (*BlockChain, error) {
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
+	cacheConfig = cacheConfig.sanitize()
+	if cacheConfig.SidecarRetention != 0 && cacheConfig.SidecarRetention < params.MinBlocksForBlobRequests {
+		return nil, fmt.Errorf("%w: %d < %d", errSidecarRetention, cacheConfig.SidecarRetention, params.MinBlocksForBlobRequests)
+	}
 	if cacheConfig.StateScheme == rawdb.HashScheme && cacheConfig.TriesInMemory != 128 {
 		log.Warn("TriesInMemory isn't the default value (128), you need specify the same TriesInMemory when pruning data",
 			"triesInMemory", cacheConfig.TriesInMemory, "scheme", cacheConfig.StateScheme)
@@ -360,6 +500,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		return nil, err
 	}
 	systemcontracts.GenesisHash = genesisHash
+	if err := trie.ValidateRootHasher(chainConfig); err != nil {
+		return nil, err
+	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 	/*
 		log.Info("")
@@ -378,29 +521,50 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		triedb:          triedb,
 		triegc:          prque.New[int64, common.Hash](nil),
 		quit:            make(chan struct{}),
+		stopped:         make(chan struct{}),
 		triesInMemory:   cacheConfig.TriesInMemory,
 		chainmu:         syncx.NewClosableMutex(),
-		bodyCache:       lru.NewCache[common.Hash, *types.Body](bodyCacheLimit),
-		bodyRLPCache:    lru.NewCache[common.Hash, rlp.RawValue](bodyCacheLimit),
-		receiptsCache:   lru.NewCache[common.Hash, []*types.Receipt](receiptsCacheLimit),
+		bodyCache:       lru.NewCache[common.Hash, *types.Body](cacheConfig.BodyCacheLimit),
+		bodyRLPCache:    lru.NewCache[common.Hash, rlp.RawValue](cacheConfig.BodyCacheLimit),
+		receiptsCache:   lru.NewCache[common.Hash, []*types.Receipt](cacheConfig.ReceiptsCacheLimit),
+		logsCache:       lru.NewCache[common.Hash, [][]*types.Log](logsCacheLimit),
 		sidecarsCache:   lru.NewCache[common.Hash, types.BlobSidecars](sidecarsCacheLimit),
-		blockCache:      lru.NewCache[common.Hash, *types.Block](blockCacheLimit),
-		blockStatsCache: lru.NewCache[common.Hash, *BlockStats](blockCacheLimit),
+		blockCache:      lru.NewCache[common.Hash, *types.Block](cacheConfig.BlockCacheLimit),
+		blockStatsCache: lru.NewCache[common.Hash, *BlockStats](cacheConfig.BlockCacheLimit),
+		addressFilters:  lru.NewCache[common.Hash, addressSet](addressFilterCacheLimit),
+		addressSources:  lru.NewCache[common.Hash, common.Hash](addressSourceCacheLimit),
 		txLookupCache:   lru.NewCache[common.Hash, txLookup](txLookupCacheLimit),
 		futureBlocks:    lru.NewCache[common.Hash, *types.Block](maxFutureBlocks),
+		futureConfig:    DefaultFutureBlockConfig,
 		engine:          engine,
 		vmConfig:        vmConfig,
 		logger:          vmConfig.Tracer,
 	}
+	bc.tasks = newTaskScheduler(bc.quit, defaultTaskSlots)
+	bc.regen = newStateRegenerator(bc)
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
 	if err != nil {
 		return nil, err
 	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
+	bc.dirtyLimit.Store(int64(cacheConfig.TrieDirtyLimit))
+	bc.noPrefetch.Store(cacheConfig.TrieCleanNoPrefetch)
+	bc.cleanLimit.Store(int64(cacheConfig.TrieCleanLimit))
+	bc.snapshotLimit.Store(int64(cacheConfig.SnapshotLimit))
+	bc.bodyLimit.Store(int64(cacheConfig.BodyCacheLimit))
+	bc.blockLimit.Store(int64(cacheConfig.BlockCacheLimit))
+	bc.receiptsLimit.Store(int64(cacheConfig.ReceiptsCacheLimit))
+	if cacheConfig.ArchiveInterval > 0 && cacheConfig.StateScheme != rawdb.HashScheme {
+		log.Warn("State archive interval is only supported by the hash scheme", "interval", cacheConfig.ArchiveInterval)
+	}
 	bc.forker = NewForkChoice(bc, shouldPreserve)
 	bc.statedb = state.NewDatabase(bc.triedb, nil)
 	bc.validator = NewBlockValidator(chainConfig, bc)
-	bc.prefetcher = NewStatePrefetcher(chainConfig, bc.hc)
+	prefetcher := NewStatePrefetcher(chainConfig, bc.hc)
+	if cacheConfig.PrefetchThreads > 0 {
+		prefetcher.threads = cacheConfig.PrefetchThreads
+	}
+	bc.prefetcher = prefetcher
 	bc.processor = NewStateProcessor(chainConfig, bc.hc)
 
 	bc.genesisBlock = bc.GetBlockByNumber(0)
@@ -553,6 +717,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		// Re-initialize the state database with snapshot
 		bc.statedb = state.NewDatabase(bc.triedb, bc.snaps)
 	}
+	bc.statedb.SetInterrupt(bc.quit)
+	if err := bc.setupVerkleTransition(); err != nil {
+		return nil, err
+	}
+	bc.setupCodeJournal()
 	// do options before start any routine
 	for _, option := range options {
 		bc, err = option(bc)
@@ -561,12 +730,30 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		}
 	}
 	// Start future block processor.
-	bc.wg.Add(1)
-	go bc.updateFutureBlocks()
+	bc.tasks.spawn("futureblocks", TaskHigh, RestartOnPanic, bc.updateFutureBlocks)
 
 	if bc.doubleSignMonitor != nil {
-		bc.wg.Add(1)
-		go bc.startDoubleSignMonitor()
+		bc.tasks.spawn("doublesign", TaskHigh, RestartOnPanic, bc.startDoubleSignMonitor)
+	}
+	if bc.cacheConfig.ReceiptRetention > 0 {
+		bc.tasks.spawn("receiptpruner", TaskLow, RestartOnPanic, bc.receiptPruneLoop)
+	}
+	if bc.cacheConfig.SidecarRetention > 0 {
+		bc.tasks.spawn("sidecarpruner", TaskLow, RestartOnPanic, bc.sidecarPruneLoop)
+	}
+	if bc.snaps != nil {
+		bc.tasks.spawn("snapprogress", TaskLow, RestartOnPanic, bc.watchSnapshotGeneration)
+	}
+	if bc.checkpointConfig != nil {
+		bc.tasks.spawn("checkpoint", TaskLow, RestartOnPanic, bc.checkpointLoop)
+	}
+	if bc.supplyAuditConfig != nil {
+		bc.tasks.spawn("supplyaudit", TaskLow, RestartOnPanic, bc.supplyAuditLoop)
+	}
+	if bc.cacheConfig.HeaderCacheWarmup > 0 {
+		bc.tasks.spawn("headerwarmup", TaskLow, RestartNever, func(quit <-chan struct{}) {
+			bc.hc.warmCaches(bc.cacheConfig.HeaderCacheWarmup, quit)
+		})
 	}
 
 	// Rewind the chain in case of an incompatible config upgrade.
@@ -708,6 +895,18 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	// Restore the last known finalized and safe blocks
+	if hash := rawdb.ReadFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil {
+			bc.currentFinalBlock.Store(header)
+		}
+	}
+	if hash := rawdb.ReadSafeBlockHash(bc.db); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil {
+			bc.currentSafeBlock.Store(header)
+		}
+	}
+
 	// Issue a status log for the user
 	var (
 		currentSnapBlock = bc.CurrentSnapBlock()
@@ -945,14 +1144,41 @@ func (bc *BlockChain) rewindHead(head *types.Header, root common.Hash) (*types.H
 	return bc.rewindHashHead(head, root)
 }
 
-// SetFinalized sets the finalized block.
+// SetFinalized sets the finalized block and persists it. Subscribers are
+// notified through a FinalizedHeaderEvent if the finalized block changes.
 // This function differs slightly from Ethereum; we fine-tune it through the outer-layer setting finalizedBlockGauge.
 func (bc *BlockChain) SetFinalized(header *types.Header) {
-	bc.currentFinalBlock.Store(header)
+	if bc.setFinalized(header) && header != nil {
+		bc.finalizedHeaderFeed.Send(FinalizedHeaderEvent{header})
+	}
+}
+
+// setFinalized sets the finalized block and persists it without notifying the
+// subscribers, returning whether the finalized block changed. Block imports
+// announce the finalized headers along with their chain head events instead.
+func (bc *BlockChain) setFinalized(header *types.Header) bool {
+	prev := bc.currentFinalBlock.Swap(header)
+	if header == nil {
+		if prev == nil {
+			return false
+		}
+		rawdb.WriteFinalizedBlockHash(bc.db, common.Hash{})
+		return true
+	}
+	if prev != nil && prev.Hash() == header.Hash() {
+		return false
+	}
+	rawdb.WriteFinalizedBlockHash(bc.db, header.Hash())
+	return true
+}
+
+// SetSafe sets the safe block and persists it.
+func (bc *BlockChain) SetSafe(header *types.Header) {
+	bc.currentSafeBlock.Store(header)
 	if header != nil {
-		rawdb.WriteFinalizedBlockHash(bc.db, header.Hash())
+		rawdb.WriteSafeBlockHash(bc.db, header.Hash())
 	} else {
-		rawdb.WriteFinalizedBlockHash(bc.db, common.Hash{})
+		rawdb.WriteSafeBlockHash(bc.db, common.Hash{})
 	}
 }
 
@@ -974,6 +1200,9 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 	}
 	defer bc.chainmu.Unlock()
 
+	bc.canonicalSeq.Add(1)
+	defer bc.canonicalSeq.Add(1)
+
 	var (
 		// Track the block number of the requested root hash
 		rootNumber uint64 // (no root == always 0)
@@ -1053,8 +1282,20 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 		}
 		return headHeader, wipe // Only force wipe if full synced
 	}
-	// Rewind the header chain, deleting all block bodies until then
+	// Rewind the header chain, deleting all block bodies until then. The chain
+	// statistics of the rewound canonical blocks are reverted too, otherwise
+	// re-importing them would count them twice.
+	var (
+		statsUpdate    = bc.newContractStatsUpdate()
+		activityUpdate = bc.newAddressActivityUpdate()
+	)
 	delFn := func(db ethdb.KeyValueWriter, hash common.Hash, num uint64) {
+		statsUpdate.revert(hash, num)
+		if activityUpdate != nil && rawdb.ReadCanonicalHash(bc.db, num) == hash {
+			if block := bc.GetBlock(hash, num); block != nil {
+				activityUpdate.revert(block)
+			}
+		}
 		// Ignore the error here since light client won't hit this path
 		frozen, _ := bc.db.Ancients()
 		if num+1 <= frozen {
@@ -1072,6 +1313,9 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 			rawdb.DeleteBlobSidecars(db, hash, num)
 			rawdb.DeleteReceipts(db, hash, num)
 		}
+		// Revert reasons are kept in the key-value store even for frozen blocks
+		rawdb.DeleteRevertReasons(db, hash, num)
+
 		// Todo(rjl493456442) txlookup, bloombits, etc
 	}
 	// If SetHead was only called as a chain reparation method, try to skip
@@ -1091,10 +1335,19 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 			bc.hc.SetHead(head, updateFn, delFn)
 		}
 	}
+	if statsUpdate != nil || activityUpdate != nil {
+		batch := bc.db.NewBatch()
+		statsUpdate.write(batch)
+		activityUpdate.write(batch)
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to revert chain statistics", "err", err)
+		}
+	}
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
 	bc.receiptsCache.Purge()
+	bc.logsCache.Purge()
 	bc.sidecarsCache.Purge()
 	bc.blockCache.Purge()
 	bc.blockStatsCache.Purge()
@@ -1105,6 +1358,10 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 		log.Error("SetHead invalidated finalized block")
 		bc.SetFinalized(nil)
 	}
+	if safe := bc.CurrentSafeBlock(); safe != nil && head < safe.Number.Uint64() {
+		log.Warn("SetHead invalidated safe block")
+		bc.SetSafe(nil)
+	}
 
 	return rootNumber, bc.loadLastState()
 }
@@ -1259,6 +1516,9 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 
 		batch := bc.db.NewBatch()
 		rawdb.WriteTxLookupEntriesByBlock(batch, block)
+		bc.writeUncleIndex(batch, block)
+		bc.writeContractStats(batch, block)
+		bc.writeAddressActivity(batch, block)
 
 		// Flush the whole batch into the disk, exit the node if failed
 		if err := batch.Write(); err != nil {
@@ -1295,6 +1555,9 @@ func (bc *BlockChain) stopWithoutSaving() {
 	// Unsubscribe all subscriptions registered from blockchain.
 	bc.scope.Close()
 
+	// Release the chain mutex if held by a maintenance pause.
+	bc.Resume()
+
 	// Signal shutdown to all goroutines.
 	close(bc.quit)
 	bc.StopInsert()
@@ -1307,16 +1570,67 @@ func (bc *BlockChain) stopWithoutSaving() {
 	// returned.
 	bc.chainmu.Close()
 	bc.wg.Wait()
+	bc.tasks.wait()
 }
 
 // Stop stops the blockchain service. If any imports are currently in progress
 // it will abort them using the procInterrupt.
 func (bc *BlockChain) Stop() {
+	bc.StopWithContext(context.Background())
+}
+
+// StopWithContext stops the blockchain service like Stop, but returns early if
+// the context is cancelled before all the data is persisted. In that case, the
+// error names the subsystem still flushing, which keeps running in the background
+// until done: the database must not be closed before a repeated call returned
+// without error. Progress is reported periodically while the shutdown is pending.
+func (bc *BlockChain) StopWithContext(ctx context.Context) error {
+	go bc.stopOnce.Do(bc.stop)
+
+	var (
+		start  = time.Now()
+		report = time.NewTicker(8 * time.Second)
+	)
+	defer report.Stop()
+
+	for {
+		select {
+		case <-bc.stopped:
+			return nil
+		case <-report.C:
+			log.Info("Blockchain shutdown in progress", "phase", bc.ShutdownPhase(), "elapsed", common.PrettyDuration(time.Since(start)))
+		case <-ctx.Done():
+			phase := bc.ShutdownPhase()
+			log.Error("Blockchain shutdown deadline exceeded", "phase", phase, "elapsed", common.PrettyDuration(time.Since(start)))
+			return fmt.Errorf("%w: %s: %v", ErrShutdownDeadline, phase, ctx.Err())
+		}
+	}
+}
+
+// ShutdownPhase returns the subsystem the blockchain shutdown is currently busy
+// with, or an empty string if no shutdown is in progress.
+func (bc *BlockChain) ShutdownPhase() string {
+	phase, _ := bc.stopPhase.Load().(string)
+	return phase
+}
+
+// setShutdownPhase records the subsystem the shutdown started flushing.
+func (bc *BlockChain) setShutdownPhase(phase string) {
+	log.Debug("Blockchain shutdown phase", "phase", phase)
+	bc.stopPhase.Store(phase)
+}
+
+// stop runs the blockchain shutdown, persisting all the in-memory data.
+func (bc *BlockChain) stop() {
+	defer close(bc.stopped)
+
+	bc.setShutdownPhase("chain operations")
 	bc.stopWithoutSaving()
 
 	// Ensure that the entirety of the state snapshot is journaled to disk.
 	var snapBase common.Hash
 	if bc.snaps != nil {
+		bc.setShutdownPhase("snapshot journal")
 		var err error
 		if snapBase, err = bc.snaps.Journal(bc.CurrentBlock().Root); err != nil {
 			log.Error("Failed to journal state snapshot", "err", err)
@@ -1325,8 +1639,10 @@ func (bc *BlockChain) Stop() {
 	}
 	if !bc.NoTries() {
 		if bc.triedb.Scheme() == rawdb.PathScheme {
+			bc.setShutdownPhase("trie journal")
+
 			// Ensure that the in-memory trie nodes are journaled to disk properly.
-			if err := bc.triedb.Journal(bc.CurrentBlock().Root); err != nil {
+			if err := bc.triedb.Journal(bc.merkleRoot(bc.CurrentBlock().Root)); err != nil {
 				log.Info("Failed to journal in-memory trie nodes", "err", err)
 			}
 		} else {
@@ -1334,11 +1650,13 @@ func (bc *BlockChain) Stop() {
 			// We're writing three different states to catch different restart scenarios:
 			//  - HEAD:     So we don't need to reprocess any blocks in the general case
 			//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
-			//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
+			//  - HEAD-(TriesInMemory-1): So we have a hard limit on the number of blocks reexecuted
 			if !bc.cacheConfig.TrieDirtyDisabled {
+				bc.setShutdownPhase("trie commit")
+
 				triedb := bc.triedb
 				var once sync.Once
-				for _, offset := range []uint64{0, 1, state.TriesInMemory - 1} {
+				for _, offset := range []uint64{0, 1, bc.triesInMemory - 1} {
 					if number := bc.CurrentBlock().Number.Uint64(); number > offset {
 						recent := bc.GetBlockByNumber(number - offset)
 						log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
@@ -1372,15 +1690,129 @@ func (bc *BlockChain) Stop() {
 	}
 	// Allow tracers to clean-up and release resources.
 	if bc.logger != nil && bc.logger.OnClose != nil {
+		bc.setShutdownPhase("tracer close")
 		bc.logger.OnClose()
 	}
+	// Make sure all the chain data moved into the freezer hits the disk.
+	if frozen, err := bc.db.Ancients(); err == nil && frozen > 0 {
+		bc.setShutdownPhase("freezer sync")
+		if err := bc.db.SyncAncient(); err != nil {
+			log.Error("Failed to sync ancient store", "err", err)
+		}
+	}
 	// Close the trie database, release all the held resources as the last step.
+	bc.setShutdownPhase("trie database close")
+	bc.stopVerkleTransition()
+	bc.stopCodeJournal()
 	if err := bc.triedb.Close(); err != nil {
 		log.Error("Failed to close trie database", "err", err)
 	}
+	bc.stopPhase.Store("")
 	log.Info("Blockchain stopped")
 }
 
+// Pause quiesces the blockchain for a maintenance window. It waits for any
+// running chain modification to finish, then blocks all further imports, head
+// changes and rewinds until Resume is called, while the read APIs stay live.
+// The background writers (the freezer, the transaction indexer and the snapshot
+// generator) are suspended too. Before returning, the head state is flushed to disk so the database is in a
+// consistent state for snapshots or integrity checks. If the context expires
+// before the chain could be quiesced, the pause is abandoned.
+func (bc *BlockChain) Pause(ctx context.Context) error {
+	bc.pauseLock.Lock()
+	defer bc.pauseLock.Unlock()
+
+	if bc.paused {
+		return errChainPaused
+	}
+	if bc.stopping.Load() {
+		return errChainStopped
+	}
+	locked := make(chan bool, 1)
+	go func() { locked <- bc.chainmu.TryLock() }()
+
+	select {
+	case ok := <-locked:
+		if !ok {
+			return errChainStopped
+		}
+	case <-ctx.Done():
+		// Release the mutex in the background if it's acquired eventually
+		go func() {
+			if <-locked {
+				bc.chainmu.Unlock()
+			}
+		}()
+		return ctx.Err()
+	}
+	bc.db.PauseFreezing()
+	if bc.txIndexer != nil {
+		bc.txIndexer.suspend()
+	}
+	if bc.snaps != nil {
+		bc.snaps.PauseGeneration()
+	}
+	bc.flushPaused()
+	bc.paused = true
+
+	head := bc.CurrentBlock()
+	log.Info("Blockchain paused", "number", head.Number, "hash", head.Hash(), "root", head.Root)
+	return nil
+}
+
+// Resume lifts a maintenance pause, allowing chain modifications again.
+func (bc *BlockChain) Resume() error {
+	bc.pauseLock.Lock()
+	defer bc.pauseLock.Unlock()
+
+	if !bc.paused {
+		return errChainNotPaused
+	}
+	bc.paused = false
+	if bc.snaps != nil {
+		bc.snaps.ResumeGeneration()
+	}
+	if bc.txIndexer != nil {
+		bc.txIndexer.unsuspend()
+	}
+	bc.db.ResumeFreezing()
+	bc.chainmu.Unlock()
+
+	log.Info("Blockchain resumed")
+	return nil
+}
+
+// Paused reports whether the blockchain is paused for maintenance.
+func (bc *BlockChain) Paused() bool {
+	bc.pauseLock.Lock()
+	defer bc.pauseLock.Unlock()
+
+	return bc.paused
+}
+
+// flushPaused persists the data held in memory which is needed to reopen the
+// database at the current head. The caller must hold the chain mutex.
+func (bc *BlockChain) flushPaused() {
+	// Wait for the asynchronous head index writes to land
+	bc.dbWg.Wait()
+
+	// Under the hash scheme, the recent states live in memory only. Commit the
+	// head state, the path scheme keeps a consistent disk layer on its own.
+	if !bc.NoTries() && bc.triedb.Scheme() == rawdb.HashScheme && !bc.cacheConfig.TrieDirtyDisabled {
+		head := bc.CurrentBlock()
+		if err := bc.triedb.Commit(head.Root, true); err != nil {
+			log.Error("Failed to commit head state trie", "err", err)
+		} else {
+			rawdb.WriteSafePointBlockNumber(bc.db, head.Number.Uint64())
+		}
+	}
+	if frozen, err := bc.db.Ancients(); err == nil && frozen > 0 {
+		if err := bc.db.SyncAncient(); err != nil {
+			log.Error("Failed to sync ancient store", "err", err)
+		}
+	}
+}
+
 // StopInsert interrupts all insertion methods, causing them to return
 // errInsertionInterrupted as soon as possible. Insertion is permanently disabled after
 // calling this method.
@@ -1394,20 +1826,17 @@ func (bc *BlockChain) insertStopped() bool {
 }
 
 func (bc *BlockChain) procFutureBlocks() {
-	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
-	for _, hash := range bc.futureBlocks.Keys() {
-		if block, exist := bc.futureBlocks.Peek(hash); exist {
-			blocks = append(blocks, block)
-		}
-	}
-	if len(blocks) > 0 {
-		slices.SortFunc(blocks, func(a, b *types.Block) int {
-			return a.Number().Cmp(b.Number())
-		})
-		// Insert one by one as chain insertion needs contiguous ancestry between blocks
-		for i := range blocks {
-			bc.InsertChain(blocks[i : i+1])
+	// Drip the blocks whose time has come into the chain, one by one as chain
+	// insertion needs contiguous ancestry between blocks. Blocks still ahead
+	// of the local clock stay queued.
+	now := uint64(time.Now().Unix())
+	for _, block := range bc.FutureBlocks() {
+		if block.Time() > now {
+			continue
 		}
+		log.Debug("Future block eligible, importing", "number", block.Number(), "hash", block.Hash())
+		bc.futureBlockFeed.Send(FutureBlockEvent{Block: block})
+		bc.InsertChain(types.Blocks{block})
 	}
 }
 
@@ -1495,6 +1924,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			rawdb.WriteHeadFastBlockHash(bc.db, head.Hash())
 			bc.currentSnapBlock.Store(head.Header())
 			headFastBlockGauge.Update(int64(head.NumberU64()))
+
+			// Connect any out of order segments the new head reached
+			bc.stitchReceiptRanges()
 			return true
 		}
 		return false
@@ -1728,6 +2160,18 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Make sure no inconsistent state is leaked during insertion
 	externTd := new(big.Int).Add(block.Difficulty(), ptd)
 
+	// Carry the total supply over if it was tracked for the parent
+	var supply *big.Int
+	if psupply := rawdb.ReadTotalSupply(bc.db, block.ParentHash(), block.NumberU64()-1); psupply != nil {
+		supply = psupply.Add(psupply, statedb.SupplyDelta())
+	}
+	// Accumulate the fee totals onto the parent's, if indexed
+	burnt, blob := rawdb.ReadFeeTotals(bc.db, block.ParentHash(), block.NumberU64()-1)
+	if burnt != nil && blob != nil {
+		blockBurnt, blockBlob := BlockFees(bc.chainConfig, block.Header())
+		burnt, blob = burnt.Add(burnt, blockBurnt), blob.Add(blob, blockBlob)
+	}
+
 	// Irrelevant of the canonical status, write the block itself to the database.
 	//
 	// Note all the components of block(td, hash->number map, header, body, receipts)
@@ -1740,9 +2184,18 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 		rawdb.WriteBlock(blockBatch, block)
 		rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
+		if supply != nil {
+			rawdb.WriteTotalSupply(blockBatch, block.Hash(), block.NumberU64(), supply)
+		}
+		if burnt != nil && blob != nil {
+			rawdb.WriteFeeTotals(blockBatch, block.Hash(), block.NumberU64(), burnt, blob)
+		}
 		// if cancun is enabled, here need to write sidecars too
+		bc.sidecarWrites = 0
 		if bc.chainConfig.IsCancun(block.Number(), block.Time()) {
+			start := time.Now()
 			rawdb.WriteBlobSidecars(blockBatch, block.Hash(), block.NumberU64(), block.Sidecars())
+			bc.sidecarWrites = time.Since(start)
 		}
 		if bc.db.HasSeparateStateStore() {
 			rawdb.WritePreimages(bc.db.GetStateStore(), statedb.Preimages())
@@ -1766,38 +2219,63 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err != nil {
 		return err
 	}
+	if bc.contractStats {
+		rawdb.WriteContractChanges(bc.db, block.Hash(), block.NumberU64(), statedb.ContractChanges())
+	}
+	if husks := statedb.Husks(); len(husks) > 0 {
+		rawdb.WriteHusks(bc.db, block.Hash(), block.NumberU64(), husks)
+		huskMeter.Mark(int64(len(husks)))
+	}
 
 	// If node is running in path mode, skip explicit gc operation
 	// which is unnecessary in this mode.
 	if bc.triedb.Scheme() == rawdb.PathScheme {
 		return nil
 	}
+	return bc.scheduleCommit(block, root)
+}
+
+// commitTries flushes or garbage collects the tries in the trie database after
+// the state of the given block was committed into it.
+func (bc *BlockChain) commitTries(block *types.Block, root common.Hash) error {
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.TrieDirtyDisabled {
 		return bc.triedb.Commit(root, false)
 	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	// Full but not archive node, do proper garbage collection
 	bc.triedb.Reference(block.Root(), common.Hash{}) // metadata reference to keep trie alive
 	bc.triegc.Push(block.Root(), -int64(block.NumberU64()))
 
-	// Flush limits are not considered for the first TriesInMemory blocks.
+	// Persist the full state of the archive checkpoints, the garbage collector
+	// only drops dirty nodes so they are never pruned afterwards.
 	current := block.NumberU64()
-	if current <= state.TriesInMemory {
+	if interval := bc.cacheConfig.ArchiveInterval; interval > 0 && current%interval == 0 {
+		if err := bc.triedb.Commit(root, false); err != nil {
+			return err
+		}
+		stateArchiveMeter.Mark(1)
+	}
+	// Flush limits are not considered for the first TriesInMemory blocks.
+	if current <= bc.triesInMemory {
 		return nil
 	}
 	// If we exceeded our memory allowance, flush matured singleton nodes to disk
 	var (
 		_, nodes, _, imgs = bc.triedb.Size()
-		limit             = common.StorageSize(bc.cacheConfig.TrieDirtyLimit) * 1024 * 1024
+		limit             = common.StorageSize(bc.dirtyLimit.Load()) * 1024 * 1024
 	)
 	if nodes > limit || imgs > 4*1024*1024 {
 		bc.triedb.Cap(limit - ethdb.IdealBatchSize)
 	}
 	// Find the next state trie we need to commit
-	chosen := current - state.TriesInMemory
+	chosen := current - bc.triesInMemory
 	flushInterval := time.Duration(bc.flushInterval.Load())
+	gcproc := time.Duration(bc.gcproc.Load())
 	// If we exceeded out time allowance, flush an entire trie to disk
-	if bc.gcproc > flushInterval {
+	if gcproc > flushInterval {
 		canWrite := true
 		if posa, ok := bc.engine.(consensus.PoSA); ok {
 			if !posa.EnoughDistance(bc, block.Header()) {
@@ -1813,14 +2291,14 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 			} else {
 				// If we're exceeding limits but haven't reached a large enough memory gap,
 				// warn the user that the system is becoming unstable.
-				if chosen < bc.lastWrite+state.TriesInMemory && bc.gcproc >= 2*flushInterval {
-					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/float64(state.TriesInMemory))
+				if chosen < bc.lastWrite+bc.triesInMemory && gcproc >= 2*flushInterval {
+					log.Info("State in memory for too long, committing", "time", gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/float64(bc.triesInMemory))
 				}
 				// Flush an entire trie and restart the counters
 				bc.triedb.Commit(header.Root, true)
 				rawdb.WriteSafePointBlockNumber(bc.db, chosen)
 				bc.lastWrite = chosen
-				bc.gcproc = 0
+				bc.gcproc.Store(0)
 			}
 		}
 	}
@@ -1896,7 +2374,10 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	if status == CanonStatTy {
 		bc.chainFeed.Send(ChainEvent{Header: block.Header()})
 		if len(logs) > 0 {
-			bc.logsFeed.Send(logs)
+			bc.sendLogs(logs)
+		}
+		if sidecars := block.Sidecars(); len(sidecars) > 0 {
+			bc.blobSidecarsFeed.Send(BlobSidecarsEvent{Header: block.Header(), Sidecars: sidecars})
 		}
 		// In theory, we should fire a ChainHeadEvent when we inject
 		// a canonical block, but sometimes we can insert a batch of
@@ -1906,7 +2387,7 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 		var finalizedHeader *types.Header
 		if posa, ok := bc.Engine().(consensus.PoSA); ok {
 			if finalizedHeader = posa.GetFinalizedHeader(bc, block.Header()); finalizedHeader != nil {
-				bc.SetFinalized(finalizedHeader)
+				bc.setFinalized(finalizedHeader)
 			}
 		}
 		if sealedBlockSender != nil {
@@ -1926,7 +2407,7 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 // TODO after the transition, the future block shouldn't be kept. Because
 // it's not checked in the Geth side anymore.
 func (bc *BlockChain) addFutureBlock(block *types.Block) error {
-	max := uint64(time.Now().Unix() + maxTimeFutureBlocks)
+	max := uint64(time.Now().Add(bc.futureConfig.MaxSkew).Unix())
 	if block.Time() > max {
 		return fmt.Errorf("future block timestamp %v > allowed %v", block.Time(), max)
 	}
@@ -1943,6 +2424,14 @@ func (bc *BlockChain) addFutureBlock(block *types.Block) error {
 // the index number of the failing block as well an error describing what went
 // wrong. After insertion is done, all accumulated events will be fired.
 func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
+	return bc.InsertChainWithHook(chain, nil)
+}
+
+// InsertChainWithHook is InsertChain invoking the given hooks around every block
+// executed by the import, including the ones re-executed to recover a missing
+// ancestor state, e.g. to maintain external indexes along with the chain. The
+// hooks may be nil.
+func (bc *BlockChain) InsertChainWithHook(chain types.Blocks, hooks *BlockHooks) (int, error) {
 	// Sanity check that we have something meaningful to import
 	if len(chain) == 0 {
 		return 0, nil
@@ -1971,6 +2460,9 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 	}
 	defer bc.chainmu.Unlock()
 
+	bc.blockHooks = hooks
+	defer func() { bc.blockHooks = nil }()
+
 	_, n, err := bc.insertChain(chain, true, false) // No witness collection for mass inserts (would get super large)
 	return n, err
 }
@@ -1983,7 +2475,7 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 // racey behaviour. If a sidechain import is in progress, and the historic state
 // is imported, but then new canon-head is added before the actual sidechain
 // completes, then the historic state could be pruned again
-func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness bool) (*stateless.Witness, int, error) {
+func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness bool) (_ *stateless.Witness, n int, err error) {
 	// If the chain is terminating, don't even bother starting up.
 	if bc.insertStopped() {
 		return nil, 0, nil
@@ -2011,6 +2503,26 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			}
 		}
 	}()
+	// Overlap the trie commits with the processing of the next blocks, waiting
+	// for the last one before announcing the new head
+	if bc.startCommitPipeline() {
+		defer func() {
+			if stopErr := bc.stopCommitPipeline(); err == nil {
+				err = stopErr
+			}
+			// Report failed commits against their own block, not the one being
+			// imported when the failure surfaced
+			var cerr *commitError
+			if errors.As(err, &cerr) {
+				for i, block := range chain {
+					if block.Hash() == cerr.block.Hash() {
+						n = i
+						break
+					}
+				}
+			}
+		}()
+	}
 
 	// check block data available first
 	if bc.chainConfig.Parlia != nil {
@@ -2025,7 +2537,14 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 		bc.GetBlockStats(block.Hash()).StartImportBlockTime.Store(time.Now().UnixMilli())
 		headers[i] = block.Header()
 	}
-	abort, results := bc.engine.VerifyHeaders(bc, headers)
+	anchored, err := bc.hc.checkAnchored(headers)
+	if err != nil {
+		return nil, anchored, err
+	}
+	verified := headers[max(anchored-1, 0):]
+	abort, results := bc.engine.VerifyHeaders(bc, verified)
+	abort, results = bc.hc.validateExtra(verified, abort, results)
+	abort, results = skipAnchored(anchored, len(headers), abort, results)
 	defer close(abort)
 
 	// Peek the error for the first block to decide the directing import logic
@@ -2175,6 +2694,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			lastCanon = block
 			continue
 		}
+		// Record the evidence if the validator already sealed another block here
+		bc.checkEquivocation(block.Header())
+
 		// Retrieve the parent block and it's state to execute on top
 		start := time.Now()
 		parent := it.previous()
@@ -2182,12 +2704,18 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
 
-		statedb, err := state.NewWithSharedPool(parent.Root, bc.statedb)
+		if err := bc.startVerkleTransition(parent, block.Header()); err != nil {
+			return nil, it.index, err
+		}
+		statedb, err := state.NewWithSharedPool(parent.Root, bc.stateDatabase(parent.Root))
 		if err != nil {
 			return nil, it.index, err
 		}
 		statedb.EnableSharedStorage(bc.cacheConfig.EnableSharedStorage)
 		statedb.SetNeedBadSharedStorage(bc.chainConfig.NeedBadSharedStorage(block.Number()))
+		if bc.accessLists {
+			statedb.RequireCompleteAccesses()
+		}
 		bc.updateHighestVerifiedHeader(block.Header())
 
 		// If we are past Byzantium, enable prefetching to pull in trie node paths
@@ -2197,7 +2725,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			// Generate witnesses either if we're self-testing, or if it's the
 			// only block being inserted. A bit crude, but witnesses are huge,
 			// so we refuse to make an entire chain of them.
-			if bc.vmConfig.StatelessSelfValidation || (makeWitness && len(chain) == 1) {
+			if bc.vmConfig.StatelessSelfValidation || (makeWitness && len(chain) == 1) || bc.witnesses != nil {
 				witness, err = stateless.NewWitness(block.Header(), bc)
 				if err != nil {
 					return nil, it.index, err
@@ -2208,7 +2736,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 
 		interruptCh := make(chan struct{})
 		// For diff sync, it may fallback to full sync, so we still do prefetch
-		if !bc.cacheConfig.TrieCleanNoPrefetch && len(block.Transactions()) >= prefetchTxNumber {
+		if !bc.noPrefetch.Load() && len(block.Transactions()) >= prefetchTxNumber {
 			// do Prefetch in a separate goroutine to avoid blocking the critical path
 			// 1.do state prefetch for snapshot cache
 			throwaway := statedb.CopyDoPrefetch()
@@ -2222,6 +2750,10 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			// trie prefetcher is thread safe now, ok to prefetch in a separate routine
 			go throwaway.TriePrefetchInAdvance(block, signer)
 		}
+		// Warm the state of the next block in the batch while this one executes
+		if !bc.noPrefetch.Load() && it.index+1 < len(chain) && len(chain[it.index+1].Transactions()) > 0 {
+			go bc.prefetcher.PrefetchNext(chain[it.index+1], statedb.CopyDoPrefetch(), interruptCh)
+		}
 
 		// The traced section of block import.
 		res, err := bc.processBlock(block, statedb, start, setHead, interruptCh)
@@ -2233,6 +2765,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 		// Report the import stats before returning the various results
 		stats.processed++
 		stats.usedGas += res.usedGas
+		bc.recordImport(block.NumberU64(), res.usedGas)
 
 		var snapDiffItems, snapBufItems common.StorageSize
 		if bc.snaps != nil {
@@ -2244,7 +2777,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 		if !setHead {
 			// After merge we expect few side chains. Simply count
 			// all blocks the CL gives us for GC processing time
-			bc.gcproc += res.procTime
+			bc.gcproc.Add(int64(res.procTime))
 			return witness, it.index, nil // Direct block insertion of a single block
 		}
 		switch res.status {
@@ -2257,7 +2790,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 			lastCanon = block
 
 			// Only count canonical blocks for GC processing time
-			bc.gcproc += res.procTime
+			bc.gcproc.Add(int64(res.procTime))
 
 		case SideStatTy:
 			log.Debug("Inserted forked block", "number", block.Number(), "hash", block.Hash(),
@@ -2338,6 +2871,20 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 		}()
 	}
 
+	// Track whether the snapshot covers the parent, to detect a failed extension
+	var snapCovered bool
+	if parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1); parent != nil {
+		snapCovered = bc.snapshotCovered(parent.Root)
+	}
+	// Recover the senders not cached yet to keep them out of the execution time
+	stime := bc.recoverSenders(block)
+
+	if err := bc.blockHooks.preBlock(block, statedb); err != nil {
+		close(interruptCh)
+		statedb.StopPrefetcher()
+		return nil, err
+	}
+
 	// Process block using the parent state as reference point
 	pstart := time.Now()
 	res, err := bc.processor.Process(block, statedb, bc.vmConfig)
@@ -2358,6 +2905,16 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	}
 	vtime := time.Since(vstart)
 
+	// Save the address filter listed at an epoch boundary for the next epoch
+	bc.saveAddressFilter(block.Header(), statedb)
+
+	// Record the witness of the block if requested
+	if bc.witnesses != nil {
+		bc.recordWitness(block, statedb)
+	}
+	if bc.accessLists {
+		bc.writeBlockAccessList(block, newBlockAccessList(statedb))
+	}
 	// If witnesses was generated and stateless self-validation requested, do
 	// that now. Self validation should *never* run in production, it's more of
 	// a tight integration to enable running *all* consensus tests through the
@@ -2409,6 +2966,16 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	blockValidationTimer.Update(vtime - (triehash + trieUpdate))                      // The time spent on block validation
 	blockCrossValidationTimer.Update(xvtime)                                          // The time spent on stateless cross validation
 
+	blockCallDepthHist.Update(int64(res.Stats.MaxCallDepth))
+	blockMemoryHist.Update(int64(res.Stats.MaxMemory))
+	blockReturnDataHist.Update(int64(res.Stats.ReturnData))
+	blockMaxReturnDataHist.Update(int64(res.Stats.MaxReturnData))
+
+	// The block is valid, let the networking layer announce it while committing
+	bc.preCommitFeed.Send(PreCommitBlockEvent{Block: block})
+
+	bc.writeRevertReasons(block, res.Reverts)
+
 	// Write the block to the chain and get the status.
 	var (
 		wstart = time.Now()
@@ -2423,6 +2990,8 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	if err != nil {
 		return nil, err
 	}
+	bc.checkSnapshot(block, snapCovered)
+
 	// Update the metrics touched during block commit
 	if metrics.EnabledExpensive() {
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
@@ -2435,6 +3004,23 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	blockInsertTxSizeGauge.Update(int64(len(block.Transactions())))
 	blockInsertGasUsedGauge.Update(int64(block.GasUsed()))
 
+	if err := bc.blockHooks.postBlock(block, statedb, res.Receipts); err != nil {
+		return nil, err
+	}
+	bc.reportInsert(&InsertReport{
+		Number:    block.NumberU64(),
+		Hash:      block.Hash(),
+		Txs:       len(block.Transactions()),
+		GasUsed:   block.GasUsed(),
+		Senders:   stime,
+		Execution: ptime,
+		TrieHash:  vtime,
+		Snapshot:  statedb.SnapshotCommits,
+		Commit:    time.Since(wstart) - statedb.SnapshotCommits,
+		Sidecars:  bc.sidecarWrites,
+		Total:     time.Since(start),
+	})
+
 	return &blockProcessingResult{usedGas: res.GasUsed, procTime: proctime, status: status}, nil
 }
 
@@ -2657,7 +3243,11 @@ func (bc *BlockChain) collectLogs(b *types.Block, removed bool) []*types.Log {
 // Note the new head block won't be processed here, callers need to handle it
 // externally.
 func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error {
+	bc.canonicalSeq.Add(1)
+	defer bc.canonicalSeq.Add(1)
+
 	var (
+		start       = time.Now()
 		newChain    []*types.Header
 		oldChain    []*types.Header
 		commonBlock *types.Header
@@ -2738,6 +3328,14 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error
 
 		deletedLogs []*types.Log
 		rebirthLogs []*types.Log
+
+		dump        = bc.reorgDumper.shouldDump(len(oldChain)) && len(newChain) > 0
+		removedLogs []*types.Log
+
+		droppedBlocks []*types.Block
+		oldBlocks     []*types.Block
+		addedTxs      []*types.Transaction
+		addedSidecars []BlobSidecarsEvent
 	)
 	// Deleted log emission on the API uses forward order, which is borked, but
 	// we'll leave it in for legacy reasons.
@@ -2771,14 +3369,32 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error
 		for _, tx := range block.Transactions() {
 			deletedTxs = append(deletedTxs, tx.Hash())
 		}
+		oldBlocks = append(oldBlocks, block)
+		if bc.uncleIndex && len(block.Uncles()) > 0 {
+			droppedBlocks = append(droppedBlocks, block)
+		}
 		// Collect deleted logs and emit them for new integrations
 		if logs := bc.collectLogs(block, true); len(logs) > 0 {
 			// Emit revertals latest first, older then
 			slices.Reverse(logs)
+			if dump {
+				removedLogs = append(removedLogs, logs...)
+			}
 
 			// TODO(karalabe): Hook into the reverse emission part
 		}
 	}
+	// Revert the contract statistics and address activity of the old blocks in
+	// one go, before the new blocks accumulate onto them. Reverting is tracked
+	// per block, so redoing it after a crash doesn't count anything twice.
+	if bc.contractStats || bc.activityIndex {
+		revertBatch := bc.db.NewBatch()
+		bc.revertContractStats(revertBatch, oldBlocks)
+		bc.revertAddressActivity(revertBatch, oldBlocks)
+		if err := revertBatch.Write(); err != nil {
+			log.Crit("Failed to revert chain statistics", "err", err)
+		}
+	}
 	// Apply new blocks in forward order
 	for i := len(newChain) - 1; i >= 1; i-- {
 		// Collect all the included transactions
@@ -2789,19 +3405,25 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error
 		for _, tx := range block.Transactions() {
 			rebirthTxs = append(rebirthTxs, tx.Hash())
 		}
+		addedTxs = append(addedTxs, block.Transactions()...)
 		// Collect inserted logs and emit them
 		if logs := bc.collectLogs(block, false); len(logs) > 0 {
 			rebirthLogs = append(rebirthLogs, logs...)
 		}
 		if len(rebirthLogs) > 512 {
-			bc.logsFeed.Send(rebirthLogs)
+			bc.sendLogs(rebirthLogs)
 			rebirthLogs = nil
 		}
+		// Collect the sidecars of the newly canonical blocks, the new head's
+		// are announced by the caller
+		if sidecars := bc.GetSidecarsByHash(block.Hash()); len(sidecars) > 0 {
+			addedSidecars = append(addedSidecars, BlobSidecarsEvent{Header: block.Header(), Sidecars: sidecars})
+		}
 		// Update the head block
 		bc.writeHeadBlock(block)
 	}
 	if len(rebirthLogs) > 0 {
-		bc.logsFeed.Send(rebirthLogs)
+		bc.sendLogs(rebirthLogs)
 	}
 	// Delete useless indexes right now which includes the non-canonical
 	// transaction indexes, canonical chain indexes which above the head.
@@ -2812,6 +3434,9 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error
 	for _, tx := range types.HashDifference(deletedTxs, rebirthTxs) {
 		rawdb.DeleteTxLookupEntry(indexesBatch, tx)
 	}
+	for _, block := range droppedBlocks {
+		bc.deleteUncleIndex(indexesBatch, block)
+	}
 	// Delete all hash markers that are not part of the new canonical chain.
 	// Because the reorg function does not handle new chain head, all hash
 	// markers greater than or equal to new chain head should be deleted.
@@ -2838,6 +3463,59 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Header) error
 	// Release the tx-lookup lock after mutation.
 	bc.txLookupLock.Unlock()
 
+	for _, ev := range addedSidecars {
+		bc.blobSidecarsFeed.Send(ev)
+	}
+
+	missingTxs := types.HashDifference(deletedTxs, rebirthTxs)
+
+	// Verify the transaction indexes of the rewritten range in the background
+	if len(oldChain) > 0 {
+		check := &txIndexCheck{from: commonBlock.Number.Uint64() + 1, to: oldChain[0].Number.Uint64(), dropped: deletedTxs}
+		if len(newChain) > 0 && newChain[0].Number.Uint64() > check.to {
+			check.to = newChain[0].Number.Uint64()
+		}
+		bc.scheduleTxIndexCheck(check)
+	}
+	// Persist a post-mortem of deep reorgs for offline analysis
+	if dump {
+		bc.dumpReorg(commonBlock, oldChain, newChain, missingTxs, removedLogs)
+	}
+	if len(oldChain) > 0 && len(newChain) > 0 {
+		// The new head is written by the caller, but its transactions are
+		// part of the diff nonetheless
+		head := bc.GetBlock(newChain[0].Hash(), newChain[0].Number.Uint64())
+		if head == nil {
+			return errInvalidNewChain
+		}
+		addedTxs = append(addedTxs, head.Transactions()...)
+
+		var removedTxs []*types.Transaction
+		for i := len(oldBlocks) - 1; i >= 0; i-- {
+			removedTxs = append(removedTxs, oldBlocks[i].Transactions()...)
+		}
+
+		// Announce the chains ordered from the common ancestor upwards
+		dropped, added := slices.Clone(oldChain), slices.Clone(newChain)
+		slices.Reverse(dropped)
+		slices.Reverse(added)
+		bc.reorgFeed.Send(ReorgEvent{
+			CommonAncestor: commonBlock,
+			OldChain:       dropped,
+			NewChain:       added,
+			RemovedTxs:     removedTxs,
+			AddedTxs:       addedTxs,
+		})
+		bc.reportReorg(&ReorgStats{
+			CommonNumber:  commonBlock.Number.Uint64(),
+			CommonHash:    commonBlock.Hash(),
+			Depth:         len(oldChain),
+			Width:         len(newChain),
+			DroppedTxs:    len(deletedTxs),
+			ReinjectedTxs: len(missingTxs),
+			Elapsed:       time.Since(start),
+		})
+	}
 	return nil
 }
 
@@ -2885,7 +3563,10 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	logs := bc.collectLogs(head, false)
 	bc.chainFeed.Send(ChainEvent{Header: head.Header()})
 	if len(logs) > 0 {
-		bc.logsFeed.Send(logs)
+		bc.sendLogs(logs)
+	}
+	if sidecars := bc.GetSidecarsByHash(head.Hash()); len(sidecars) > 0 {
+		bc.blobSidecarsFeed.Send(BlobSidecarsEvent{Header: head.Header(), Sidecars: sidecars})
 	}
 	bc.chainHeadFeed.Send(ChainHeadEvent{Header: head.Header()})
 
@@ -2902,27 +3583,25 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	return head.Hash(), nil
 }
 
-func (bc *BlockChain) updateFutureBlocks() {
-	futureTimer := time.NewTicker(5 * time.Second)
+func (bc *BlockChain) updateFutureBlocks(quit <-chan struct{}) {
+	futureTimer := time.NewTicker(bc.futureConfig.Interval)
 	defer futureTimer.Stop()
-	defer bc.wg.Done()
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
-		case <-bc.quit:
+		case <-quit:
 			return
 		}
 	}
 }
 
-func (bc *BlockChain) startDoubleSignMonitor() {
+func (bc *BlockChain) startDoubleSignMonitor(quit <-chan struct{}) {
 	eventChan := make(chan ChainHeadEvent, monitor.MaxCacheHeader)
 	sub := bc.SubscribeChainHeadEvent(eventChan)
 	defer func() {
 		sub.Unsubscribe()
 		close(eventChan)
-		bc.wg.Done()
 	}()
 
 	for {
@@ -2931,7 +3610,7 @@ func (bc *BlockChain) startDoubleSignMonitor() {
 			if bc.doubleSignMonitor != nil {
 				bc.doubleSignMonitor.Verify(event.Header)
 			}
-		case <-bc.quit:
+		case <-quit:
 			return
 		}
 	}
@@ -2985,6 +3664,7 @@ func (bc *BlockChain) reportBlock(block *types.Block, res *ProcessResult, err er
 	if res != nil {
 		receipts = res.Receipts
 	}
+	bc.lastError.Store(&healthError{block: block.NumberU64(), err: err, time: time.Now()})
 	rawdb.WriteBadBlock(bc.db, block)
 	log.Error(summarizeBadBlock(block, receipts, bc.Config(), err))
 }
@@ -3028,6 +3708,17 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header) (int, error) {
 	if len(chain) == 0 {
 		return 0, nil
 	}
+	// Insert the headers linked up to the sync anchor first, as the parents of the
+	// following ones have to be known to verify them
+	if anchored, err := bc.hc.checkAnchored(chain); err != nil {
+		return anchored, err
+	} else if anchored > 0 && anchored < len(chain) {
+		if n, err := bc.InsertHeaderChain(chain[:anchored]); err != nil {
+			return n, err
+		}
+		n, err := bc.InsertHeaderChain(chain[anchored:])
+		return anchored + n, err
+	}
 	start := time.Now()
 	if i, err := bc.hc.ValidateHeaderChain(chain); err != nil {
 		return i, err
@@ -3037,6 +3728,10 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header) (int, error) {
 		return 0, errChainStopped
 	}
 	defer bc.chainmu.Unlock()
+
+	bc.canonicalSeq.Add(1)
+	defer bc.canonicalSeq.Add(1)
+
 	_, err := bc.hc.InsertHeaderChain(chain, start, bc.forker)
 	return 0, err
 }
@@ -3072,6 +3767,134 @@ func (bc *BlockChain) GetTrieFlushInterval() time.Duration {
 	return time.Duration(bc.flushInterval.Load())
 }
 
+// Reconfigure adjusts the live adjustable subset of the cache configuration
+// without a restart. The changes are applied between block imports, a lowered
+// dirty trie limit is enforced right away. Resized trie and snapshot caches are
+// replaced by empty ones, while the block caches only evict their oldest items
+// when shrunk.
+func (bc *BlockChain) Reconfigure(update CacheReconfig) error {
+	if update.TrieCleanLimit != nil && *update.TrieCleanLimit < 0 {
+		return fmt.Errorf("invalid clean trie limit: %d", *update.TrieCleanLimit)
+	}
+	if update.TrieDirtyLimit != nil && *update.TrieDirtyLimit < 0 {
+		return fmt.Errorf("invalid dirty trie limit: %d", *update.TrieDirtyLimit)
+	}
+	if update.TrieTimeLimit != nil && *update.TrieTimeLimit < 0 {
+		return fmt.Errorf("invalid trie time limit: %v", *update.TrieTimeLimit)
+	}
+	if update.SnapshotLimit != nil {
+		if bc.snaps == nil {
+			return errors.New("snapshots disabled")
+		}
+		if *update.SnapshotLimit <= 0 {
+			return fmt.Errorf("invalid snapshot limit: %d", *update.SnapshotLimit)
+		}
+	}
+	for name, limit := range map[string]*int{"body": update.BodyCacheLimit, "block": update.BlockCacheLimit, "receipts": update.ReceiptsCacheLimit} {
+		if limit != nil && *limit <= 0 {
+			return fmt.Errorf("invalid %s cache limit: %d", name, *limit)
+		}
+	}
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	var logctx []interface{}
+	if update.TrieCleanLimit != nil {
+		limit := *update.TrieCleanLimit
+		if err := bc.triedb.ResizeCleanCache(limit * 1024 * 1024); err != nil {
+			return err
+		}
+		bc.cleanLimit.Store(int64(limit))
+		logctx = append(logctx, "clean", limit)
+	}
+	if update.TrieDirtyLimit != nil {
+		limit := *update.TrieDirtyLimit
+		if bc.triedb.Scheme() == rawdb.HashScheme && !bc.cacheConfig.TrieDirtyDisabled {
+			if _, nodes, _, _ := bc.triedb.Size(); nodes > common.StorageSize(limit)*1024*1024 {
+				if err := bc.triedb.Cap(common.StorageSize(limit)*1024*1024 - ethdb.IdealBatchSize); err != nil {
+					return err
+				}
+			}
+		}
+		bc.dirtyLimit.Store(int64(limit))
+		logctx = append(logctx, "dirty", limit)
+	}
+	if update.TrieTimeLimit != nil {
+		bc.flushInterval.Store(int64(*update.TrieTimeLimit))
+		logctx = append(logctx, "flush", *update.TrieTimeLimit)
+	}
+	if update.TrieCleanNoPrefetch != nil {
+		bc.noPrefetch.Store(*update.TrieCleanNoPrefetch)
+		logctx = append(logctx, "noprefetch", *update.TrieCleanNoPrefetch)
+	}
+	if update.SnapshotLimit != nil {
+		bc.snaps.ResizeCache(*update.SnapshotLimit)
+		bc.snapshotLimit.Store(int64(*update.SnapshotLimit))
+		logctx = append(logctx, "snapshot", *update.SnapshotLimit)
+	}
+	if update.BodyCacheLimit != nil {
+		bc.bodyCache.Resize(*update.BodyCacheLimit)
+		bc.bodyRLPCache.Resize(*update.BodyCacheLimit)
+		bc.bodyLimit.Store(int64(*update.BodyCacheLimit))
+		logctx = append(logctx, "bodies", *update.BodyCacheLimit)
+	}
+	if update.BlockCacheLimit != nil {
+		bc.blockCache.Resize(*update.BlockCacheLimit)
+		bc.blockStatsCache.Resize(*update.BlockCacheLimit)
+		bc.blockLimit.Store(int64(*update.BlockCacheLimit))
+		logctx = append(logctx, "blocks", *update.BlockCacheLimit)
+	}
+	if update.ReceiptsCacheLimit != nil {
+		bc.receiptsCache.Resize(*update.ReceiptsCacheLimit)
+		bc.receiptsLimit.Store(int64(*update.ReceiptsCacheLimit))
+		logctx = append(logctx, "receipts", *update.ReceiptsCacheLimit)
+	}
+	if len(logctx) > 0 {
+		log.Info("Reconfigured chain caches", logctx...)
+	}
+	return nil
+}
+
+// CacheConfig returns the cache configuration currently in effect, including
+// any live adjustments made via Reconfigure.
+func (bc *BlockChain) CacheConfig() CacheConfig {
+	config := *bc.cacheConfig
+	config.TrieDirtyLimit = int(bc.dirtyLimit.Load())
+	config.TrieTimeLimit = time.Duration(bc.flushInterval.Load())
+	config.TrieCleanNoPrefetch = bc.noPrefetch.Load()
+	config.TrieCleanLimit = int(bc.cleanLimit.Load())
+	config.SnapshotLimit = int(bc.snapshotLimit.Load())
+	config.BodyCacheLimit = int(bc.bodyLimit.Load())
+	config.BlockCacheLimit = int(bc.blockLimit.Load())
+	config.ReceiptsCacheLimit = int(bc.receiptsLimit.Load())
+	return config
+}
+
+// ChainLimits are the operational limits of the chain in effect.
+type ChainLimits struct {
+	TriesInMemory      uint64        // Number of recent tries kept in memory
+	BodyCacheLimit     int           // Number of recent block bodies cached
+	BlockCacheLimit    int           // Number of recent blocks cached
+	ReceiptsCacheLimit int           // Number of recent block receipts cached
+	FutureBlocksLimit  int           // Maximum number of queued future blocks
+	FutureBlocksSkew   time.Duration // Maximum time a queued block may be ahead of the local clock
+}
+
+// Limits returns the operational limits of the chain in effect, after the
+// configured values were sanitized.
+func (bc *BlockChain) Limits() ChainLimits {
+	return ChainLimits{
+		TriesInMemory:      bc.triesInMemory,
+		BodyCacheLimit:     int(bc.bodyLimit.Load()),
+		BlockCacheLimit:    int(bc.blockLimit.Load()),
+		ReceiptsCacheLimit: int(bc.receiptsLimit.Load()),
+		FutureBlocksLimit:  bc.futureConfig.Limit,
+		FutureBlocksSkew:   bc.futureConfig.MaxSkew,
+	}
+}
+
 func (bc *BlockChain) GetBlockStats(hash common.Hash) *BlockStats {
 	if v, ok := bc.blockStatsCache.Get(hash); ok {
 		return v
@@ -3081,6 +3904,25 @@ func (bc *BlockChain) GetBlockStats(hash common.Hash) *BlockStats {
 	return n
 }
 
+// RepairHeaderNumbers verifies the hash->number index of the canonical chain
+// against the headers in the database, fixing any inconsistency if repair is
+// set. Block imports are blocked while the check is running.
+func (bc *BlockChain) RepairHeaderNumbers(repair bool) (*rawdb.HeaderNumberReport, error) {
+	if !bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	report, err := rawdb.RepairHeaderNumbers(bc.db, bc.CurrentHeader().Number.Uint64(), repair)
+	if err != nil {
+		return nil, err
+	}
+	if repair && !report.Healthy() {
+		bc.hc.numberCache.Purge()
+	}
+	return report, nil
+}
+
 // PruneBlockHistory prune block history
 func (bc *BlockChain) PruneBlockHistory(blockHistory uint64) error {
 	// if the node try to keep entire chain blocks, just skip
@@ -53,21 +53,24 @@ func (bc *BlockChain) CurrentSnapBlock() *types.Header {
 }
 
 // CurrentFinalBlock retrieves the current finalized block of the canonical
-// chain. The block is retrieved from the blockchain's internal cache.
+// chain. With fast finality the block is derived from the votes, otherwise it
+// is the one last set via SetFinalized.
 func (bc *BlockChain) CurrentFinalBlock() *types.Header {
 	if p, ok := bc.engine.(consensus.PoSA); ok {
 		currentHeader := bc.CurrentHeader()
 		if currentHeader == nil {
 			return nil
 		}
-		return p.GetFinalizedHeader(bc, currentHeader)
+		if finalized := p.GetFinalizedHeader(bc, currentHeader); finalized != nil {
+			return finalized
+		}
 	}
-
-	return nil
+	return bc.currentFinalBlock.Load()
 }
 
-// CurrentSafeBlock retrieves the current safe block of the canonical
-// chain. The block is retrieved from the blockchain's internal cache.
+// CurrentSafeBlock retrieves the current safe block of the canonical chain.
+// With fast finality the block is the latest justified one, otherwise it is
+// the one last set via SetSafe.
 func (bc *BlockChain) CurrentSafeBlock() *types.Header {
 	if p, ok := bc.engine.(consensus.PoSA); ok {
 		currentHeader := bc.CurrentHeader()
@@ -76,10 +79,12 @@ func (bc *BlockChain) CurrentSafeBlock() *types.Header {
 		}
 		_, justifiedBlockHash, err := p.GetJustifiedNumberAndHash(bc, []*types.Header{currentHeader})
 		if err == nil {
-			return bc.GetHeaderByHash(justifiedBlockHash)
+			if justified := bc.GetHeaderByHash(justifiedBlockHash); justified != nil {
+				return justified
+			}
 		}
 	}
-	return nil
+	return bc.currentSafeBlock.Load()
 }
 
 // HasHeader checks if a block header is present in the database or not, caching
@@ -242,8 +247,10 @@ func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*type
 // GetReceiptsByHash retrieves the receipts for all transactions in a given block.
 func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if receipts, ok := bc.receiptsCache.Get(hash); ok {
+		receiptsCacheHitMeter.Mark(1)
 		return receipts
 	}
+	receiptsCacheMissMeter.Mark(1)
 	number := rawdb.ReadHeaderNumber(bc.db, hash)
 	if number == nil {
 		return nil
@@ -360,6 +367,18 @@ func (bc *BlockChain) GetTd(hash common.Hash, number uint64) *big.Int {
 	return bc.hc.GetTd(hash, number)
 }
 
+// TotalSupply retrieves the total native supply after the canonical block with
+// the given number: the genesis allocations plus all balance issued by rewards
+// and mints, minus all balance burnt. Nil is returned if the supply was not
+// tracked, e.g. for blocks imported without execution.
+func (bc *BlockChain) TotalSupply(number uint64) *big.Int {
+	hash := bc.GetCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadTotalSupply(bc.db, hash, number)
+}
+
 // HasState checks if state trie is fully present in the database or not.
 func (bc *BlockChain) HasState(hash common.Hash) bool {
 	if bc.NoTries() {
@@ -374,7 +393,7 @@ func (bc *BlockChain) HasState(hash common.Hash) bool {
 		}
 		return found
 	}
-	_, err := bc.statedb.OpenTrie(hash)
+	_, err := bc.stateDatabase(hash).OpenTrie(hash)
 	return err == nil
 }
 
@@ -416,7 +435,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	stateDb, err := state.NewWithSharedPool(root, bc.statedb)
+	stateDb, err := state.NewWithSharedPool(root, bc.stateDatabase(root))
 	if err != nil {
 		return nil, err
 	}
@@ -454,6 +473,11 @@ func (bc *BlockChain) Processor() Processor {
 	return bc.processor
 }
 
+// Prefetcher returns the current state prefetcher.
+func (bc *BlockChain) Prefetcher() Prefetcher {
+	return bc.prefetcher
+}
+
 // StateCache returns the caching database underpinning the blockchain instance.
 func (bc *BlockChain) StateCache() state.Database {
 	return bc.statedb
@@ -487,6 +511,15 @@ func (bc *BlockChain) TrieDB() *triedb.Database {
 	return bc.triedb
 }
 
+// StateArchiveInterval returns the interval of blocks whose full state is kept
+// on disk, or 0 if only the recent states are retained.
+func (bc *BlockChain) StateArchiveInterval() uint64 {
+	if bc.cacheConfig.TrieDirtyDisabled || bc.triedb.Scheme() != rawdb.HashScheme {
+		return 0
+	}
+	return bc.cacheConfig.ArchiveInterval
+}
+
 // HeaderChain returns the underlying header chain.
 func (bc *BlockChain) HeaderChain() *HeaderChain {
 	return bc.hc
@@ -512,6 +545,26 @@ func (bc *BlockChain) SubscribeHighestVerifiedHeaderEvent(ch chan<- HighestVerif
 	return bc.scope.Track(bc.highestVerifiedBlockFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
+// SubscribePreCommitBlockEvent registers a subscription of PreCommitBlockEvent.
+func (bc *BlockChain) SubscribePreCommitBlockEvent(ch chan<- PreCommitBlockEvent) event.Subscription {
+	return bc.scope.Track(bc.preCommitFeed.Subscribe(ch))
+}
+
+// SubscribeReorgDumpEvent registers a subscription of ReorgDumpEvent.
+func (bc *BlockChain) SubscribeReorgDumpEvent(ch chan<- ReorgDumpEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgDumpFeed.Subscribe(ch))
+}
+
+// SubscribeBlobSidecarsEvent registers a subscription of BlobSidecarsEvent.
+func (bc *BlockChain) SubscribeBlobSidecarsEvent(ch chan<- BlobSidecarsEvent) event.Subscription {
+	return bc.scope.Track(bc.blobSidecarsFeed.Subscribe(ch))
+}
+
 // SubscribeChainBlockEvent registers a subscription of ChainBlockEvent.
 func (bc *BlockChain) SubscribeChainBlockEvent(ch chan<- ChainHeadEvent) event.Subscription {
 	return bc.scope.Track(bc.chainBlockFeed.Subscribe(ch))
@@ -0,0 +1,352 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Differential tests running identical randomized workloads of insertions,
+// reorgs, rewinds and crashes against the hash and path state schemes, and
+// comparing everything the chain exposes.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	schemeTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	schemeTestAddr    = crypto.PubkeyToAddress(schemeTestKey.PublicKey)
+	schemeTestMaxHead = 100 // Stay below the in-memory trie window, where the schemes flush differently
+)
+
+// schemeChain is a chain backed by a persistent database using one of the
+// state schemes.
+type schemeChain struct {
+	scheme  string
+	datadir string
+	db      ethdb.Database
+	chain   *BlockChain
+}
+
+// schemeHarness drives identical workloads against a chain of each scheme and
+// compares their observable behavior. The canonical chain expected after every
+// operation is tracked independently of both.
+type schemeHarness struct {
+	t      *testing.T
+	rand   *rand.Rand
+	gspec  *Genesis
+	engine *ethash.Ethash
+	genDb  ethdb.Database // Database holding the state of all generated blocks
+
+	chains   []*schemeChain
+	canon    []*types.Block          // Expected canonical chain, indexed by number
+	fresh    int                     // First block inserted since the last rewind or crash
+	accounts map[common.Address]bool // Accounts touched by the workload
+	forks    int                     // Number of forks generated, to tell their blocks apart
+}
+
+func newSchemeHarness(t *testing.T, seed int64) *schemeHarness {
+	h := &schemeHarness{
+		t:    t,
+		rand: rand.New(rand.NewSource(seed)),
+		gspec: &Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.AllEthashProtocolChanges,
+			Alloc:   types.GenesisAlloc{schemeTestAddr: {Balance: big.NewInt(params.Ether)}},
+		},
+		engine:   ethash.NewFaker(),
+		accounts: map[common.Address]bool{schemeTestAddr: true},
+	}
+	h.genDb, _, _ = GenerateChainWithGenesis(h.gspec, h.engine, 0, nil)
+	h.canon = []*types.Block{h.gspec.ToBlock()}
+	h.fresh = 1
+
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		sc := &schemeChain{scheme: scheme, datadir: t.TempDir()}
+		h.open(sc)
+		h.chains = append(h.chains, sc)
+	}
+	return h
+}
+
+// cacheConfig returns the chain configuration of the given scheme.
+func (h *schemeHarness) cacheConfig(scheme string) *CacheConfig {
+	return &CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		StateScheme:    scheme,
+		TriesInMemory:  128,
+	}
+}
+
+// open opens the database and the chain of the given scheme.
+func (h *schemeHarness) open(sc *schemeChain) {
+	pdb, err := pebble.New(sc.datadir, 0, 0, "", false)
+	if err != nil {
+		h.t.Fatalf("%s: failed to open key-value database: %v", sc.scheme, err)
+	}
+	sc.db, err = rawdb.NewDatabaseWithFreezer(pdb, filepath.Join(sc.datadir, "ancient"), "", false, false, false)
+	if err != nil {
+		h.t.Fatalf("%s: failed to open freezer database: %v", sc.scheme, err)
+	}
+	if err := sc.db.SetupFreezerEnv(&ethdb.FreezerEnv{
+		ChainCfg:         h.gspec.Config,
+		BlobExtraReserve: params.DefaultExtraReserveForBlobRequests,
+	}, 0); err != nil {
+		h.t.Fatalf("%s: failed to set up freezer: %v", sc.scheme, err)
+	}
+	sc.chain, err = NewBlockChain(sc.db, h.cacheConfig(sc.scheme), h.gspec, nil, h.engine, vm.Config{}, nil, nil)
+	if err != nil {
+		h.t.Fatalf("%s: failed to create chain: %v", sc.scheme, err)
+	}
+}
+
+// close shuts down all chains cleanly.
+func (h *schemeHarness) close() {
+	for _, sc := range h.chains {
+		sc.chain.Stop()
+		sc.db.Close()
+	}
+}
+
+// generate creates n blocks on top of the given expected canonical block.
+func (h *schemeHarness) generate(parent *types.Block, n int, coinbase common.Address) []*types.Block {
+	signer := types.LatestSigner(h.gspec.Config)
+	txs := make([]int, n)
+	for i := range txs {
+		txs[i] = h.rand.Intn(4)
+	}
+	recipients := make([]common.Address, 0, 4*n)
+	for i := 0; i < 4*n; i++ {
+		recipients = append(recipients, common.Address{0xaa, byte(h.rand.Intn(16))})
+	}
+	blocks, _ := GenerateChain(h.gspec.Config, parent, h.engine, h.genDb, n, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		for j := 0; j < txs[i]; j++ {
+			to := recipients[4*i+j]
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(schemeTestAddr), to, big.NewInt(int64(1+j)), params.TxGas, b.header.BaseFee, nil), signer, schemeTestKey)
+			b.AddTx(tx)
+			h.accounts[to] = true
+		}
+	})
+	h.accounts[coinbase] = true
+	return blocks
+}
+
+// insert imports the blocks into all chains.
+func (h *schemeHarness) insert(op string, blocks []*types.Block) {
+	for _, sc := range h.chains {
+		if n, err := sc.chain.InsertChain(blocks); err != nil {
+			h.t.Fatalf("%s: %s: failed to insert block %d: %v", op, sc.scheme, blocks[n].NumberU64(), err)
+		}
+	}
+}
+
+// extend appends new blocks to the canonical chain.
+func (h *schemeHarness) extend() string {
+	n := 1 + h.rand.Intn(8)
+	blocks := h.generate(h.canon[len(h.canon)-1], n, common.Address{0xcc})
+	h.insert("extend", blocks)
+	h.canon = append(h.canon, blocks...)
+	return fmt.Sprintf("extend by %d", n)
+}
+
+// reorg replaces the last blocks of the canonical chain by a longer fork.
+func (h *schemeHarness) reorg() string {
+	head := len(h.canon) - 1
+	depth := h.rand.Intn(min(head, 16) + 1)
+	h.forks++
+
+	fork := h.generate(h.canon[head-depth], depth+1+h.rand.Intn(3), common.Address{0xfe, byte(h.forks >> 8), byte(h.forks)})
+	h.insert("reorg", fork)
+	h.canon = append(h.canon[:head-depth+1], fork...)
+	h.fresh = min(h.fresh, head-depth+1)
+	return fmt.Sprintf("reorg of depth %d by %d blocks", depth, len(fork))
+}
+
+// setHead rewinds all chains to a recent block.
+func (h *schemeHarness) setHead() string {
+	head := len(h.canon) - 1
+	target := head - h.rand.Intn(min(head, 16)+1)
+	for _, sc := range h.chains {
+		if err := sc.chain.SetHead(uint64(target)); err != nil {
+			h.t.Fatalf("sethead: %s: failed to rewind to %d: %v", sc.scheme, target, err)
+		}
+	}
+	h.canon = h.canon[:target+1]
+	h.fresh = target + 1
+	return fmt.Sprintf("sethead to %d", target)
+}
+
+// crash persists the state of a recently inserted block, then kills and
+// restarts all chains without flushing anything else.
+func (h *schemeHarness) crash() string {
+	head := len(h.canon) - 1
+	commit := -1
+	if h.fresh <= head {
+		commit = h.fresh + h.rand.Intn(head-h.fresh+1)
+	}
+	for _, sc := range h.chains {
+		if commit >= 0 {
+			if err := sc.chain.triedb.Commit(h.canon[commit].Root(), false); err != nil {
+				h.t.Fatalf("crash: %s: failed to commit block %d: %v", sc.scheme, commit, err)
+			}
+		}
+		sc.chain.triedb.Close()
+		sc.db.Close()
+		sc.chain.stopWithoutSaving()
+		h.open(sc)
+	}
+	h.fresh = len(h.canon)
+	return fmt.Sprintf("crash after committing %d", commit)
+}
+
+// resync reimports the expected canonical blocks each chain lacks the state of,
+// which both schemes may legitimately have dropped after a rewind or crash.
+func (h *schemeHarness) resync(op string) {
+	tip := h.canon[len(h.canon)-1]
+	for _, sc := range h.chains {
+		if head := sc.chain.CurrentHeader(); head.Hash() != tip.Hash() {
+			h.t.Fatalf("%s: %s: header head mismatch: have %d [%x], want %d [%x]", op, sc.scheme, head.Number, head.Hash(), tip.Number(), tip.Hash())
+		}
+		head := sc.chain.CurrentBlock()
+		if head.Hash() != h.canon[head.Number.Uint64()].Hash() {
+			h.t.Fatalf("%s: %s: head block %d [%x] not canonical", op, sc.scheme, head.Number, head.Hash())
+		}
+		if !sc.chain.HasState(head.Root) {
+			h.t.Fatalf("%s: %s: state of head block %d missing", op, sc.scheme, head.Number)
+		}
+		if number := head.Number.Uint64(); number < tip.NumberU64() {
+			if n, err := sc.chain.InsertChain(h.canon[number+1:]); err != nil {
+				h.t.Fatalf("%s: %s: failed to reimport block %d: %v", op, sc.scheme, number+1+uint64(n), err)
+			}
+		}
+	}
+}
+
+// compare checks that all chains expose the expected canonical chain and the
+// same state, receipts and getter results.
+func (h *schemeHarness) compare(op string) {
+	var (
+		tip  = h.canon[len(h.canon)-1]
+		base = h.chains[0]
+	)
+	for _, sc := range h.chains {
+		if head := sc.chain.CurrentBlock(); head.Hash() != tip.Hash() {
+			h.t.Fatalf("%s: %s: head block mismatch: have %d [%x], want %d [%x]", op, sc.scheme, head.Number, head.Hash(), tip.Number(), tip.Hash())
+		}
+		for number, block := range h.canon {
+			if hash := sc.chain.GetCanonicalHash(uint64(number)); hash != block.Hash() {
+				h.t.Fatalf("%s: %s: canonical hash %d mismatch: have %x, want %x", op, sc.scheme, number, hash, block.Hash())
+			}
+			if sc.chain.GetBlockByNumber(uint64(number)) == nil {
+				h.t.Fatalf("%s: %s: canonical block %d missing", op, sc.scheme, number)
+			}
+		}
+		if sc.chain.GetCanonicalHash(tip.NumberU64()+1) != (common.Hash{}) {
+			h.t.Fatalf("%s: %s: canonical block beyond the head", op, sc.scheme)
+		}
+		if sc == base {
+			continue
+		}
+		for _, block := range []*types.Block{tip, h.canon[max(len(h.canon)-8, 0)]} {
+			if have, want := len(sc.chain.GetReceiptsByHash(block.Hash())), len(base.chain.GetReceiptsByHash(block.Hash())); have != want {
+				h.t.Fatalf("%s: %s: receipt count of block %d mismatch: have %d, %s has %d", op, sc.scheme, block.Number(), have, base.scheme, want)
+			}
+		}
+		have, err := sc.chain.StateAt(tip.Root())
+		if err != nil {
+			h.t.Fatalf("%s: %s: head state unavailable: %v", op, sc.scheme, err)
+		}
+		want, err := base.chain.StateAt(tip.Root())
+		if err != nil {
+			h.t.Fatalf("%s: %s: head state unavailable: %v", op, base.scheme, err)
+		}
+		for addr := range h.accounts {
+			if have.GetBalance(addr).Cmp(want.GetBalance(addr)) != 0 || have.GetNonce(addr) != want.GetNonce(addr) {
+				h.t.Fatalf("%s: %s: account %x mismatch: have %v/%d, %s has %v/%d", op, sc.scheme, addr,
+					have.GetBalance(addr), have.GetNonce(addr), base.scheme, want.GetBalance(addr), want.GetNonce(addr))
+			}
+		}
+	}
+}
+
+// run executes the given number of random operations, comparing the chains
+// after each.
+func (h *schemeHarness) run(steps int) {
+	for i := 0; i < steps; i++ {
+		var op string
+		switch r := h.rand.Intn(10); {
+		case len(h.canon) < 4 || r < 4:
+			op = h.extend()
+		case r < 6:
+			op = h.reorg()
+		case r < 8:
+			op = h.setHead()
+		default:
+			op = h.crash()
+		}
+		op = fmt.Sprintf("step %d (%s)", i, op)
+		h.resync(op)
+		h.compare(op)
+
+		// Keep the chain short enough for both schemes to hold it in memory
+		if len(h.canon) > schemeTestMaxHead {
+			h.setHead()
+			h.resync(op)
+		}
+	}
+}
+
+// Tests that the hash and path schemes behave identically under randomized
+// workloads of deep reorgs, rewinds and crashes.
+func TestSchemeDifferential(t *testing.T) {
+	seeds, steps := 8, 24
+	if testing.Short() {
+		seeds, steps = 2, 12
+	}
+	for seed := 0; seed < seeds; seed++ {
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			h := newSchemeHarness(t, int64(seed))
+			defer h.close()
+			h.run(steps)
+		})
+	}
+}
+
+func FuzzSchemeDifferential(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(1))
+	f.Fuzz(func(t *testing.T, seed int64) {
+		h := newSchemeHarness(t, seed)
+		defer h.close()
+		h.run(16)
+	})
+}
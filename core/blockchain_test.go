@@ -18,6 +18,7 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -4487,3 +4488,610 @@ func TestEIP7702(t *testing.T) {
 		t.Fatalf("addr2 storage wrong: expected %d, got %d", fortyTwo, actual)
 	}
 }
+
+// Tests that a shutdown exceeding its deadline reports the subsystem still
+// flushing, and that the shutdown completes in the background afterwards.
+func TestStopWithDeadline(t *testing.T) {
+	release := make(chan struct{})
+	tracer := &tracing.Hooks{OnClose: func() { <-release }}
+
+	genesis := &Genesis{Config: params.TestChainConfig}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{Tracer: tracer}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = chain.StopWithContext(ctx)
+	if !errors.Is(err, ErrShutdownDeadline) {
+		t.Fatalf("shutdown error mismatch: have %v, want %v", err, ErrShutdownDeadline)
+	}
+	if phase := chain.ShutdownPhase(); phase != "tracer close" {
+		t.Fatalf("shutdown phase mismatch: have %q, want %q", phase, "tracer close")
+	}
+	close(release)
+
+	// A repeated stop must wait for the pending shutdown instead of rerunning it
+	if err := chain.StopWithContext(context.Background()); err != nil {
+		t.Fatalf("failed to finish shutdown: %v", err)
+	}
+	if phase := chain.ShutdownPhase(); phase != "" {
+		t.Fatalf("shutdown phase not cleared: %q", phase)
+	}
+}
+
+// Tests that the live adjustable cache settings can be reconfigured on a running
+// chain and are honoured by subsequent imports.
+func TestReconfigureCache(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2*state.TriesInMemory, nil)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		limit    = 0
+		interval = time.Hour
+		prefetch = true
+		clean    = 1
+		snapshot = 1
+		entries  = 2
+	)
+	update := CacheReconfig{
+		TrieCleanLimit:      &clean,
+		TrieDirtyLimit:      &limit,
+		TrieTimeLimit:       &interval,
+		TrieCleanNoPrefetch: &prefetch,
+		SnapshotLimit:       &snapshot,
+		BodyCacheLimit:      &entries,
+		BlockCacheLimit:     &entries,
+		ReceiptsCacheLimit:  &entries,
+	}
+	if err := chain.Reconfigure(update); err != nil {
+		t.Fatalf("failed to reconfigure chain: %v", err)
+	}
+	config := chain.CacheConfig()
+	if config.TrieDirtyLimit != limit || config.TrieTimeLimit != interval || config.TrieCleanNoPrefetch != prefetch {
+		t.Fatalf("cache config not updated: %+v", config)
+	}
+	if config.TrieCleanLimit != clean || config.SnapshotLimit != snapshot {
+		t.Fatalf("cache sizes not updated: %+v", config)
+	}
+	if limits := chain.Limits(); limits.BodyCacheLimit != entries || limits.BlockCacheLimit != entries || limits.ReceiptsCacheLimit != entries {
+		t.Fatalf("cache limits not updated: %+v", limits)
+	}
+	if defaultCacheConfig.TrieDirtyLimit == limit {
+		t.Fatalf("shared default cache config modified")
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// With a zero dirty limit, all the matured trie nodes must have been flushed
+	if _, nodes, _, _ := chain.triedb.Size(); nodes > ethdb.IdealBatchSize {
+		t.Errorf("dirty trie nodes not capped: %v", nodes)
+	}
+	for _, block := range blocks {
+		chain.GetBlock(block.Hash(), block.NumberU64())
+		chain.GetBody(block.Hash())
+	}
+	if n := chain.blockCache.Len(); n != entries {
+		t.Errorf("block cache size mismatch: have %d, want %d", n, entries)
+	}
+	if n := chain.bodyCache.Len(); n != entries {
+		t.Errorf("body cache size mismatch: have %d, want %d", n, entries)
+	}
+	invalid := -1
+	if err := chain.Reconfigure(CacheReconfig{TrieDirtyLimit: &invalid}); err == nil {
+		t.Errorf("negative dirty limit accepted")
+	}
+	if err := chain.Reconfigure(CacheReconfig{BodyCacheLimit: &limit}); err == nil {
+		t.Errorf("zero body cache limit accepted")
+	}
+	// The clean trie cache of the path scheme can be disabled and resized too
+	pathChain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.PathScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer pathChain.Stop()
+
+	if err := pathChain.Reconfigure(CacheReconfig{TrieCleanLimit: &limit}); err != nil {
+		t.Fatalf("failed to disable clean cache: %v", err)
+	}
+	if n, err := pathChain.InsertChain(blocks[:len(blocks)/2]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if err := pathChain.Reconfigure(CacheReconfig{TrieCleanLimit: &clean}); err != nil {
+		t.Fatalf("failed to resize clean cache: %v", err)
+	}
+	if n, err := pathChain.InsertChain(blocks[len(blocks)/2:]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+}
+
+// Tests that the operational limits of the cache config are defaulted when
+// unset and clamped when out of bounds, without modifying the given config.
+func TestCacheConfigLimits(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig}
+
+	config := &CacheConfig{
+		TrieCleanLimit:     16,
+		TrieTimeLimit:      5 * time.Minute,
+		StateScheme:        rawdb.HashScheme,
+		TriesInMemory:      1,
+		BodyCacheLimit:     -1,
+		ReceiptsCacheLimit: maxCacheLimit + 1,
+	}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	want := ChainLimits{
+		TriesInMemory:      minTriesInMemory,
+		BodyCacheLimit:     bodyCacheLimit,
+		BlockCacheLimit:    blockCacheLimit,
+		ReceiptsCacheLimit: maxCacheLimit,
+		FutureBlocksLimit:  DefaultFutureBlockConfig.Limit,
+		FutureBlocksSkew:   DefaultFutureBlockConfig.MaxSkew,
+	}
+	if have := chain.Limits(); have != want {
+		t.Fatalf("limits mismatch: have %+v, want %+v", have, want)
+	}
+	if config.TriesInMemory != 1 || config.BodyCacheLimit != -1 {
+		t.Fatalf("given cache config modified: %+v", config)
+	}
+	// Unset limits fall back to the defaults
+	chain, err = NewBlockChain(rawdb.NewMemoryDatabase(), &CacheConfig{StateScheme: rawdb.HashScheme}, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if have := chain.Limits(); have.TriesInMemory != state.TriesInMemory || have.ReceiptsCacheLimit != receiptsCacheLimit {
+		t.Fatalf("default limits mismatch: %+v", have)
+	}
+}
+
+// Tests that pausing the chain blocks imports until resumed, while leaving the
+// head state readable from disk.
+func TestPauseResume(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, nil)
+
+	limit := uint64(0)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, &limit)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if err := chain.Pause(context.Background()); err != nil {
+		t.Fatalf("failed to pause chain: %v", err)
+	}
+	if err := chain.Pause(context.Background()); !errors.Is(err, errChainPaused) {
+		t.Fatalf("repeated pause error mismatch: have %v, want %v", err, errChainPaused)
+	}
+	// The head state must have been flushed to disk
+	if ok, _ := chain.db.Has(blocks[1].Root().Bytes()); !ok {
+		t.Errorf("head state not flushed on pause")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := chain.InsertChain(blocks[2:])
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("import finished while paused: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 2 {
+		t.Fatalf("head changed while paused: %d", head)
+	}
+	// The suspended indexer still reports its progress
+	if _, err := chain.TxIndexProgress(); err != nil {
+		t.Fatalf("failed to retrieve indexing progress while paused: %v", err)
+	}
+	if err := chain.Resume(); err != nil {
+		t.Fatalf("failed to resume chain: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("failed to import after resume: %v", err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 4 {
+		t.Fatalf("head mismatch after resume: have %d, want 4", head)
+	}
+	// The resumed indexer catches up with the new head
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if progress, _ := chain.TxIndexProgress(); progress.Done() && rawdb.ReadTxIndexTail(chain.db) != nil {
+			break
+		}
+		if time.Since(start) > 3*time.Second {
+			t.Fatal("transaction indexing not resumed")
+		}
+	}
+	if err := chain.Resume(); !errors.Is(err, errChainNotPaused) {
+		t.Fatalf("repeated resume error mismatch: have %v, want %v", err, errChainNotPaused)
+	}
+}
+
+// TestPascalPrecompiles tests that the BLS12-381 and P-256 verification
+// precompiles are activated by the fork flags of the Parlia networks.
+func TestPascalPrecompiles(t *testing.T) {
+	// Haber already ships P-256, BLS12-381 is added by Pascal
+	t.Run("haber", func(t *testing.T) { testPascalPrecompiles(t, false) })
+	t.Run("pascal", func(t *testing.T) { testPascalPrecompiles(t, true) })
+}
+
+func testPascalPrecompiles(t *testing.T, pascal bool) {
+	var (
+		aa     = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		engine = ethash.NewFaker()
+
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		funds  = new(big.Int).Mul(common.Big1, big.NewInt(params.Ether))
+		config = *params.AllEthashProtocolChanges
+		gspec  = &Genesis{
+			Config: &config,
+			Alloc: types.GenesisAlloc{
+				addr: {Balance: funds},
+				// The address 0xAAAA verifies the P-256 signature in the calldata,
+				// storing the result in slot 0, then calls the BLS12-381 G1 addition
+				// with empty input, storing the success flag in slot 1.
+				aa: {
+					Code: common.FromHex("60a06000600037" + // calldatacopy(0, 0, 160)
+						"602061010060a06000610100" + "5afa50" + // staticcall(gas, 0x100, 0, 160, 0x100, 32)
+						"61010051600055" + // sstore(0, mload(0x100))
+						"6000600060006000600b61fffffa600155" + // sstore(1, staticcall(0xffff, 0x0b, 0, 0, 0, 0))
+						"00"),
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+		// First vector of testdata/precompiles/p256Verify.json
+		input = common.FromHex("bb5a52f42f9c9261ed4361f59422a1e30036e7c32b270c8807a419feca6050232ba3a8be6b94d5ec80a6d9d1190a436effe50d85a1eee859b8cc6af9bd5c2e184cd60b855d442f5b3c7b11eb6c4e0ae7525fe710fab9aa7c77a67f79e6fadd762927b10512bae3eddcfe467828128bad2903269919f7086069c8c4df6c732838c7787964eaac00e5921fb1498a60f4606766b3d9685001558d1a974e7341513e")
+	)
+	config.HaberTime = u64(0)
+	if pascal {
+		config.PascalTime = u64(0)
+	}
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     0,
+			To:        &aa,
+			Gas:       500000,
+			GasFeeCap: newGwei(5),
+			GasTipCap: big.NewInt(2),
+			Data:      input,
+		}), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	receipts := chain.GetReceiptsByHash(blocks[0].Hash())
+	if len(receipts) != 1 || receipts[0].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("precompile caller failed")
+	}
+	state, _ := chain.State()
+	if have := state.GetState(aa, common.Hash{}); have != common.BigToHash(common.Big1) {
+		t.Errorf("p256 verification mismatch: have %x, want 1", have)
+	}
+	// A precompile rejects the invalid input, an empty account accepts it
+	want := common.BigToHash(common.Big1)
+	if pascal {
+		want = common.Hash{}
+	}
+	if have := state.GetState(aa, common.BigToHash(common.Big1)); have != want {
+		t.Errorf("bls12-381 call result mismatch: have %x, want %x", have, want)
+	}
+	rules := config.Rules(blocks[0].Number(), true, blocks[0].Time())
+	if _, ok := vm.ActivePrecompiledContracts(rules)[common.BytesToAddress([]byte{0x0b})]; ok != pascal {
+		t.Errorf("bls12-381 activation mismatch: have %v, want %v", ok, pascal)
+	}
+}
+
+// Tests that the total supply tracks the genesis allocations, the block rewards
+// and the burnt base fees of the chain.
+func TestTotalSupply(t *testing.T) {
+	var (
+		engine    = ethash.NewFaker()
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		coinbase  = common.HexToAddress("0x000000000000000000000000000000000000cccc")
+		gspec     = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), recipient, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if supply := chain.TotalSupply(0); supply == nil || supply.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("genesis supply mismatch: have %v, want %v", supply, params.Ether)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		statedb, err := chain.StateAt(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: state unavailable: %v", block.NumberU64(), err)
+		}
+		want := new(big.Int)
+		for _, account := range []common.Address{addr, recipient, coinbase} {
+			want.Add(want, statedb.GetBalance(account).ToBig())
+		}
+		if have := chain.TotalSupply(block.NumberU64()); have == nil || have.Cmp(want) != 0 {
+			t.Errorf("block %d: supply mismatch: have %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+	if supply := chain.TotalSupply(uint64(len(blocks) + 1)); supply != nil {
+		t.Errorf("supply of unknown block: have %v, want nil", supply)
+	}
+}
+
+// Tests that the finalized and safe blocks are persisted across restarts,
+// announced on change and reset when rewinding below them.
+func TestFinalizedAndSafeTracking(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {})
+
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	events := make(chan FinalizedHeaderEvent, 2)
+	sub := chain.SubscribeFinalizedHeaderEvent(events)
+	defer sub.Unsubscribe()
+
+	chain.SetFinalized(blocks[1].Header())
+	chain.SetFinalized(blocks[1].Header())
+	chain.SetSafe(blocks[2].Header())
+
+	select {
+	case ev := <-events:
+		if ev.Header.Hash() != blocks[1].Hash() {
+			t.Errorf("finalized event mismatch: have %x, want %x", ev.Header.Hash(), blocks[1].Hash())
+		}
+	default:
+		t.Fatal("finalized event not announced")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unchanged finalized block announced: %d", ev.Header.Number)
+	default:
+	}
+	chain.Stop()
+
+	// Reopen the chain and check the markers were restored
+	chain, err = NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentFinalBlock(); head == nil || head.Hash() != blocks[1].Hash() {
+		t.Fatalf("finalized block not restored: %v", head)
+	}
+	if head := chain.CurrentSafeBlock(); head == nil || head.Hash() != blocks[2].Hash() {
+		t.Fatalf("safe block not restored: %v", head)
+	}
+	// Rewind below both markers and check they are dropped
+	if err := chain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentFinalBlock(); head != nil {
+		t.Errorf("finalized block not reset: %d", head.Number)
+	}
+	if head := chain.CurrentSafeBlock(); head != nil {
+		t.Errorf("safe block not reset: %d", head.Number)
+	}
+	if hash := rawdb.ReadSafeBlockHash(db); hash != (common.Hash{}) {
+		t.Errorf("safe block marker not cleared: %x", hash)
+	}
+}
+
+func TestBlobSidecarsEvent(t *testing.T) {
+	testKey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+
+	config := params.ParliaTestChainConfig
+	gspec := &Genesis{
+		Config: config,
+		Alloc:  types.GenesisAlloc{testAddr: {Balance: new(big.Int).SetUint64(10 * params.Ether)}},
+	}
+	engine := &mockParlia{}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan BlobSidecarsEvent, 2)
+	sub := chain.SubscribeBlobSidecarsEvent(events)
+	defer sub.Unsubscribe()
+
+	signer := types.LatestSigner(config)
+	_, bs, _ := GenerateChainWithGenesis(gspec, engine, 2, func(i int, gen *BlockGen) {
+		if i == 0 {
+			return
+		}
+		tx, sidecar := makeMockTx(config, signer, testKey, gen.TxNonce(testAddr), gen.BaseFee().Uint64(), eip4844.CalcBlobFee(config, gen.HeadBlock()).Uint64(), true)
+		gen.AddTxWithChain(chain, tx)
+		gen.AddBlobSidecar(&types.BlobSidecar{
+			BlobTxSidecar: *sidecar,
+			TxIndex:       0,
+			TxHash:        tx.Hash(),
+		})
+	})
+	if _, err := chain.InsertChain(bs); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Only the block carrying sidecars is announced
+	select {
+	case ev := <-events:
+		if ev.Header.Hash() != bs[1].Hash() {
+			t.Fatalf("announced block mismatch: have %x, want %x", ev.Header.Hash(), bs[1].Hash())
+		}
+		if len(ev.Sidecars) != 1 || ev.Sidecars[0].TxHash != bs[1].Transactions()[0].Hash() {
+			t.Fatalf("announced sidecars mismatch: %v", ev.Sidecars)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("sidecars not announced")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected sidecars announced for block %d", ev.Header.Number)
+	default:
+	}
+	if sidecars := chain.GetSidecarsByHash(bs[1].Hash()); len(sidecars) != 1 {
+		t.Fatalf("stored sidecars mismatch: have %d, want 1", len(sidecars))
+	}
+}
+
+// Tests that a reorg announces the sidecars of every newly canonical block.
+func TestBlobSidecarsEventReorg(t *testing.T) {
+	testKey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+
+	config := params.ParliaTestChainConfig
+	gspec := &Genesis{
+		Config: config,
+		Alloc:  types.GenesisAlloc{testAddr: {Balance: new(big.Int).SetUint64(10 * params.Ether)}},
+	}
+	engine := &mockParlia{}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	signer := types.LatestSigner(config)
+	_, canon, _ := GenerateChainWithGenesis(gspec, engine, 3, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	_, fork, _ := GenerateChainWithGenesis(gspec, engine, 3, func(i int, gen *BlockGen) {
+		tx, sidecar := makeMockTx(config, signer, testKey, gen.TxNonce(testAddr), gen.BaseFee().Uint64(), eip4844.CalcBlobFee(config, gen.HeadBlock()).Uint64(), true)
+		gen.AddTxWithChain(chain, tx)
+		gen.AddBlobSidecar(&types.BlobSidecar{
+			BlobTxSidecar: *sidecar,
+			TxIndex:       0,
+			TxHash:        tx.Hash(),
+		})
+	})
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for _, block := range fork {
+		if _, err := chain.InsertBlockWithoutSetHead(block, false); err != nil {
+			t.Fatalf("failed to insert fork block %d: %v", block.NumberU64(), err)
+		}
+	}
+	events := make(chan BlobSidecarsEvent, len(fork)+1)
+	sub := chain.SubscribeBlobSidecarsEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.SetCanonical(fork[len(fork)-1]); err != nil {
+		t.Fatalf("failed to set canonical head: %v", err)
+	}
+	for _, block := range fork {
+		select {
+		case ev := <-events:
+			if ev.Header.Hash() != block.Hash() {
+				t.Fatalf("announced block mismatch: have %d %x, want %d %x", ev.Header.Number, ev.Header.Hash(), block.NumberU64(), block.Hash())
+			}
+			if len(ev.Sidecars) != 1 || ev.Sidecars[0].TxHash != block.Transactions()[0].Hash() {
+				t.Fatalf("announced sidecars mismatch: %v", ev.Sidecars)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("sidecars of block %d not announced", block.NumberU64())
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected sidecars announced for block %d", ev.Header.Number)
+	default:
+	}
+}
+
+// Tests that the full state of every archive checkpoint is persisted, while the
+// states in between are only kept in memory.
+func TestStateArchiveInterval(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.ArchiveInterval = 4
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if have := chain.StateArchiveInterval(); have != 4 {
+		t.Fatalf("archive interval mismatch: have %d, want 4", have)
+	}
+	for _, block := range blocks {
+		want := block.NumberU64()%4 == 0
+		if have := rawdb.HasLegacyTrieNode(db, block.Root()); have != want {
+			t.Errorf("block %d state persistence mismatch: have %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReadView is an immutable view of the canonical chain pinned to a specific
+// head. All number based accessors of the view resolve against the ancestry
+// of the pinned head, so a caller issuing multiple reads never observes a mix
+// of the old and new canonical chain, even if a reorg happens concurrently.
+type ReadView struct {
+	bc   *BlockChain
+	head *types.Header
+
+	lock   sync.Mutex
+	hashes map[uint64]common.Hash // Resolved ancestors of the pinned head
+}
+
+// ReadView returns a read view pinned to the current head block.
+func (bc *BlockChain) ReadView() *ReadView {
+	return bc.ReadViewAt(bc.CurrentBlock())
+}
+
+// ReadViewAt returns a read view pinned to the given header, which doesn't
+// need to be canonical.
+func (bc *BlockChain) ReadViewAt(head *types.Header) *ReadView {
+	return &ReadView{
+		bc:     bc,
+		head:   head,
+		hashes: map[uint64]common.Hash{head.Number.Uint64(): head.Hash()},
+	}
+}
+
+// Head returns the header the view is pinned to.
+func (v *ReadView) Head() *types.Header {
+	return v.head
+}
+
+// Stale reports whether the pinned head is no longer part of the canonical
+// chain. A stale view remains fully usable, it just doesn't reflect the
+// latest canonical chain anymore.
+func (v *ReadView) Stale() bool {
+	return rawdb.ReadCanonicalHash(v.bc.db, v.head.Number.Uint64()) != v.head.Hash()
+}
+
+// GetCanonicalHash returns the hash of the ancestor of the pinned head at the
+// given height, or an empty hash if it's above the head or unavailable.
+func (v *ReadView) GetCanonicalHash(number uint64) common.Hash {
+	head := v.head.Number.Uint64()
+	if number > head {
+		return common.Hash{}
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if hash, ok := v.hashes[number]; ok {
+		return hash
+	}
+	// Try the fast path first: if the canonical chain wasn't mutated during
+	// the lookup and it still contains the pinned head, then the canonical
+	// hash at the requested height is an ancestor of the head.
+	if seq := v.bc.canonicalSeq.Load(); seq%2 == 0 {
+		hash := rawdb.ReadCanonicalHash(v.bc.db, number)
+		headHash := rawdb.ReadCanonicalHash(v.bc.db, head)
+		if v.bc.canonicalSeq.Load() == seq && headHash == v.head.Hash() && hash != (common.Hash{}) {
+			v.hashes[number] = hash
+			return hash
+		}
+	}
+	// The canonical chain moved away from the pinned head (or is being moved),
+	// walk the parent links down from the closest known descendant instead.
+	var (
+		from = head
+		hash = v.head.Hash()
+	)
+	for n, h := range v.hashes {
+		if n > number && n < from {
+			from, hash = n, h
+		}
+	}
+	for ; from > number; from-- {
+		header := v.bc.GetHeader(hash, from)
+		if header == nil {
+			return common.Hash{}
+		}
+		hash = header.ParentHash
+		v.hashes[from-1] = hash
+	}
+	return hash
+}
+
+// GetHeaderByNumber retrieves the ancestor header of the pinned head at the
+// given height.
+func (v *ReadView) GetHeaderByNumber(number uint64) *types.Header {
+	hash := v.GetCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return v.bc.GetHeader(hash, number)
+}
+
+// GetBlockByNumber retrieves the ancestor block of the pinned head at the
+// given height.
+func (v *ReadView) GetBlockByNumber(number uint64) *types.Block {
+	hash := v.GetCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return v.bc.GetBlock(hash, number)
+}
+
+// GetReceiptsByNumber retrieves the receipts of the ancestor block of the
+// pinned head at the given height.
+func (v *ReadView) GetReceiptsByNumber(number uint64) types.Receipts {
+	hash := v.GetCanonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return v.bc.GetReceiptsByHash(hash)
+}
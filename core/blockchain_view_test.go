@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a read view keeps serving the chain it was pinned to after the
+// canonical chain is reorged away underneath it.
+func TestReadViewAcrossReorg(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	_, oldBlocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	_, newBlocks, _ := GenerateChainWithGenesis(genesis, engine, 6, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+	})
+	if _, err := chain.InsertChain(oldBlocks); err != nil {
+		t.Fatalf("failed to insert old chain: %v", err)
+	}
+	view := chain.ReadView()
+	if view.Stale() {
+		t.Fatal("fresh view reported stale")
+	}
+	// Resolve one height before the reorg to exercise both lookup paths
+	if block := view.GetBlockByNumber(2); block == nil || block.Hash() != oldBlocks[1].Hash() {
+		t.Fatalf("pre-reorg block mismatch")
+	}
+	if _, err := chain.InsertChain(newBlocks); err != nil {
+		t.Fatalf("failed to insert new chain: %v", err)
+	}
+	if !view.Stale() {
+		t.Fatal("reorged view not reported stale")
+	}
+	for i, block := range oldBlocks {
+		number := uint64(i + 1)
+		if have := view.GetBlockByNumber(number); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("block %d: view served non-pinned block", number)
+		}
+		if have := view.GetHeaderByNumber(number); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("header %d: view served non-pinned header", number)
+		}
+	}
+	if view.GetBlockByNumber(5) != nil {
+		t.Errorf("view served block above its head")
+	}
+	fresh := chain.ReadView()
+	for i, block := range newBlocks {
+		number := uint64(i + 1)
+		if have := fresh.GetBlockByNumber(number); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("block %d: fresh view served non-canonical block", number)
+		}
+	}
+}
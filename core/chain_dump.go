@@ -0,0 +1,111 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ChainDumpVersion is the schema version of the chain dump records. It is
+// bumped on any change of the record layout other than adding optional fields.
+const ChainDumpVersion = 1
+
+// DumpOptions configures the contents of a chain dump.
+type DumpOptions struct {
+	Receipts bool // Whether to include the receipts of the blocks
+
+	// Trace, if set, is called for every block and its result is included as
+	// the traces of the block. It allows dumping the output of any tracer,
+	// which the core package cannot depend on.
+	Trace func(block *types.Block) (json.RawMessage, error)
+}
+
+// DumpRecord is the record of a block in a chain dump, one per line.
+type DumpRecord struct {
+	Version      int                  `json:"version"`
+	Number       uint64               `json:"number"`
+	Hash         common.Hash          `json:"hash"`
+	Header       *types.Header        `json:"header"`
+	Transactions []*types.Transaction `json:"transactions"`
+	Uncles       []*types.Header      `json:"uncles"`
+	Withdrawals  types.Withdrawals    `json:"withdrawals,omitempty"`
+	Receipts     types.Receipts       `json:"receipts,omitempty"`
+	Traces       json.RawMessage      `json:"traces,omitempty"`
+}
+
+// DumpRange streams the canonical blocks in the inclusive range [from, to] to
+// the writer as newline delimited JSON, one DumpRecord per block.
+func (bc *BlockChain) DumpRange(w io.Writer, from, to uint64, options *DumpOptions) error {
+	if from > to {
+		return fmt.Errorf("invalid dump range [%d, %d]", from, to)
+	}
+	if options == nil {
+		options = new(DumpOptions)
+	}
+	var (
+		buf = bufio.NewWriter(w)
+		enc = json.NewEncoder(buf)
+	)
+	for number := from; number <= to; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("block %d unavailable", number)
+		}
+		record := &DumpRecord{
+			Version:      ChainDumpVersion,
+			Number:       number,
+			Hash:         block.Hash(),
+			Header:       block.Header(),
+			Transactions: block.Transactions(),
+			Uncles:       block.Uncles(),
+			Withdrawals:  block.Withdrawals(),
+		}
+		// Keep empty lists as such instead of nulls, for a stable schema
+		if record.Transactions == nil {
+			record.Transactions = []*types.Transaction{}
+		}
+		if record.Uncles == nil {
+			record.Uncles = []*types.Header{}
+		}
+		if options.Receipts {
+			record.Receipts = bc.GetReceiptsByHash(block.Hash())
+			if record.Receipts == nil && len(block.Transactions()) > 0 {
+				return fmt.Errorf("receipts of block %d unavailable", number)
+			}
+		}
+		if options.Trace != nil {
+			traces, err := options.Trace(block)
+			if err != nil {
+				return fmt.Errorf("failed to trace block %d: %w", number, err)
+			}
+			record.Traces = traces
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+		if number == to {
+			break // Avoid overflowing at the end of the uint64 range
+		}
+	}
+	return buf.Flush()
+}
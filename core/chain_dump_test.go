@@ -0,0 +1,113 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a chain range is dumped as one JSON record per line.
+func TestDumpRange(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 3, func(i int, b *BlockGen) {
+		if i == 1 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	var out bytes.Buffer
+	options := &DumpOptions{
+		Receipts: true,
+		Trace: func(block *types.Block) (json.RawMessage, error) {
+			return json.RawMessage(fmt.Sprintf(`{"txs":%d}`, len(block.Transactions()))), nil
+		},
+	}
+	if err := chain.DumpRange(&out, 1, 3, options); err != nil {
+		t.Fatalf("failed to dump range: %v", err)
+	}
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(nil, 1<<20)
+
+	var records []map[string]json.RawMessage
+	for scanner.Scan() {
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid record line %d: %v", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("record count mismatch: have %d, want 3", len(records))
+	}
+	for i, record := range records {
+		var hash common.Hash
+		json.Unmarshal(record["hash"], &hash)
+		if hash != blocks[i].Hash() {
+			t.Errorf("record %d: hash mismatch: have %x, want %x", i, hash, blocks[i].Hash())
+		}
+		if string(record["version"]) != "1" {
+			t.Errorf("record %d: schema version mismatch: have %s", i, record["version"])
+		}
+		if string(record["uncles"]) != "[]" {
+			t.Errorf("record %d: uncles not an empty list: %s", i, record["uncles"])
+		}
+	}
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(records[1]["receipts"], &receipts); err != nil || len(receipts) != 1 {
+		t.Fatalf("receipts mismatch: have %s (%v)", records[1]["receipts"], err)
+	}
+	if receipts[0].TxHash != blocks[1].Transactions()[0].Hash() {
+		t.Errorf("receipt transaction mismatch")
+	}
+	if string(records[1]["traces"]) != `{"txs":1}` {
+		t.Errorf("traces mismatch: have %s", records[1]["traces"])
+	}
+	if err := chain.DumpRange(&out, 3, 4, nil); err == nil {
+		t.Errorf("range beyond the head dumped")
+	}
+}
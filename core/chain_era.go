@@ -0,0 +1,349 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// EraChecksumsFile is the name of the file listing the sha256 checksums of the
+// era1 files of a directory, one per line in epoch order.
+const EraChecksumsFile = "checksums.txt"
+
+// EraFile describes an era1 archive written by ExportEra.
+type EraFile struct {
+	Epoch       uint64
+	Path        string
+	Accumulator common.Hash // Root of the header records accumulator
+	Checksum    common.Hash // Sha256 of the file
+}
+
+// EraCheckpoints maps epochs to their trusted accumulator roots. Imported era1
+// files of the listed epochs must match them.
+type EraCheckpoints map[uint64]common.Hash
+
+// eraNetwork returns the network name used in the era1 file names of the chain.
+func (bc *BlockChain) eraNetwork() string {
+	if name, ok := params.NetworkNames[bc.chainConfig.ChainID.String()]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ExportEra exports the canonical blocks in the inclusive range [first, last]
+// with their receipts and total difficulties as era1 archives of up to 8192
+// blocks each, along with their checksums. The range must start at an epoch
+// boundary and only cover proof-of-work history, as era1 files carry the total
+// difficulties of the blocks.
+func (bc *BlockChain) ExportEra(dir string, first, last uint64) ([]EraFile, error) {
+	size := uint64(era.MaxEra1Size)
+	if first%size != 0 {
+		return nil, fmt.Errorf("export start %d not at an epoch boundary", first)
+	}
+	if first > last {
+		return nil, fmt.Errorf("invalid export range [%d, %d]", first, last)
+	}
+	if head := bc.CurrentBlock().Number.Uint64(); last > head {
+		return nil, fmt.Errorf("export range [%d, %d] beyond head %d", first, last, head)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var (
+		files    []EraFile
+		start    = time.Now()
+		reported = time.Now()
+	)
+	for from := first; from <= last; from += size {
+		file, err := bc.exportEraFile(dir, from, min(from+size-1, last))
+		if err != nil {
+			return files, err
+		}
+		files = append(files, *file)
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting era files", "epoch", file.Epoch, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	checksums := make([]string, len(files))
+	for i, file := range files {
+		checksums[i] = file.Checksum.Hex()
+	}
+	if err := os.WriteFile(filepath.Join(dir, EraChecksumsFile), []byte(strings.Join(checksums, "\n")), 0644); err != nil {
+		return files, err
+	}
+	log.Info("Exported era files", "files", len(files), "first", first, "last", last, "elapsed", common.PrettyDuration(time.Since(start)))
+	return files, nil
+}
+
+// exportEraFile writes the blocks [from, to] into a single era1 file.
+func (bc *BlockChain) exportEraFile(dir string, from, to uint64) (*EraFile, error) {
+	epoch := from / uint64(era.MaxEra1Size)
+
+	f, err := os.CreateTemp(dir, fmt.Sprintf("%s-%05d-*.tmp", bc.eraNetwork(), epoch))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name()) // No-op once renamed
+	}()
+	var (
+		buf     = bufio.NewWriter(f)
+		builder = era.NewBuilder(buf)
+	)
+	for number := from; number <= to; number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		block := rawdb.ReadBlock(bc.db, hash, number)
+		if block == nil {
+			return nil, fmt.Errorf("block %d unavailable", number)
+		}
+		if number > 0 && block.Difficulty().Sign() == 0 {
+			return nil, fmt.Errorf("block %d is not a proof-of-work block", number)
+		}
+		receipts := rawdb.ReadReceipts(bc.db, hash, number, block.Time(), bc.chainConfig)
+		if receipts == nil {
+			return nil, fmt.Errorf("receipts of block %d unavailable", number)
+		}
+		td := rawdb.ReadTd(bc.db, hash, number)
+		if td == nil {
+			return nil, fmt.Errorf("total difficulty of block %d unavailable", number)
+		}
+		if err := builder.Add(block, receipts, td); err != nil {
+			return nil, err
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, era.Filename(bc.eraNetwork(), int(epoch), root))
+	if err := os.Rename(f.Name(), path); err != nil {
+		return nil, err
+	}
+	return &EraFile{
+		Epoch:       epoch,
+		Path:        path,
+		Accumulator: root,
+		Checksum:    common.BytesToHash(hasher.Sum(nil)),
+	}, nil
+}
+
+// ImportEra backfills the chain history from the era1 archives of the network
+// in dir, written into the ancient store. The files are checked against the
+// checksums listed along them, and every file is verified against its own
+// accumulator, the checkpoints and the chain it extends before any of its
+// blocks are written. The headers of epochs without a checkpoint are verified
+// by the consensus engine. Blocks already present in the chain are skipped, so an
+// interrupted import can be resumed. The number of imported blocks is returned.
+func (bc *BlockChain) ImportEra(dir string, checkpoints EraCheckpoints) (int, error) {
+	entries, err := era.ReadDir(dir, bc.eraNetwork())
+	if err != nil {
+		return 0, err
+	}
+	blob, err := os.ReadFile(filepath.Join(dir, EraChecksumsFile))
+	if err != nil {
+		return 0, err
+	}
+	checksums := strings.Fields(string(blob))
+	if len(checksums) != len(entries) {
+		return 0, fmt.Errorf("checksum count mismatch: have %d, want %d", len(checksums), len(entries))
+	}
+	var (
+		imported int
+		start    = time.Now()
+	)
+	for i, name := range entries {
+		n, err := bc.importEraFile(filepath.Join(dir, name), uint64(i), common.HexToHash(checksums[i]), checkpoints)
+		if err != nil {
+			return imported, fmt.Errorf("%s: %w", name, err)
+		}
+		imported += n
+		if n > 0 {
+			log.Info("Imported era file", "epoch", i, "blocks", n, "head", bc.CurrentSnapBlock().Number, "elapsed", common.PrettyDuration(time.Since(start)))
+		}
+	}
+	return imported, nil
+}
+
+// importEraFile verifies and imports the blocks of a single era1 file.
+func (bc *BlockChain) importEraFile(path string, epoch uint64, checksum common.Hash, checkpoints EraCheckpoints) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return 0, err
+	}
+	if have := common.BytesToHash(hasher.Sum(nil)); have != checksum {
+		return 0, fmt.Errorf("checksum mismatch: have %x, want %x", have, checksum)
+	}
+	e, err := era.From(f)
+	if err != nil {
+		return 0, err
+	}
+	if have, want := e.Start(), epoch*uint64(era.MaxEra1Size); have != want {
+		return 0, fmt.Errorf("epoch start mismatch: have %d, want %d", have, want)
+	}
+	// Read and verify the entire file before touching the database
+	var (
+		blocks   types.Blocks
+		receipts []types.Receipts
+		hashes   []common.Hash
+		tds      []*big.Int
+	)
+	it, err := era.NewIterator(e)
+	if err != nil {
+		return 0, err
+	}
+	for it.Next() {
+		block, blockReceipts, err := it.BlockAndReceipts()
+		if err != nil {
+			return 0, fmt.Errorf("block %d: %w", it.Number(), err)
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil {
+			return 0, fmt.Errorf("block %d: %w", it.Number(), err)
+		}
+		if err := verifyEraBlock(bc.chainConfig, block, blockReceipts); err != nil {
+			return 0, fmt.Errorf("block %d: %w", it.Number(), err)
+		}
+		if n := len(tds); n > 0 {
+			if block.ParentHash() != hashes[n-1] {
+				return 0, fmt.Errorf("block %d: parent hash mismatch", block.NumberU64())
+			}
+			if want := new(big.Int).Add(tds[n-1], block.Difficulty()); td.Cmp(want) != 0 {
+				return 0, fmt.Errorf("block %d: total difficulty mismatch: have %v, want %v", block.NumberU64(), td, want)
+			}
+		}
+		blocks = append(blocks, block)
+		receipts = append(receipts, blockReceipts)
+		hashes = append(hashes, block.Hash())
+		tds = append(tds, td)
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	root, err := era.ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return 0, err
+	}
+	if have, err := e.Accumulator(); err != nil {
+		return 0, err
+	} else if have != root {
+		return 0, fmt.Errorf("accumulator mismatch: have %x, computed %x", have, root)
+	}
+	want, trusted := checkpoints[epoch]
+	if trusted && want != root {
+		return 0, fmt.Errorf("accumulator %x does not match checkpoint %x", root, want)
+	}
+	// Skip the blocks already present, the remainder must extend the chain
+	head := bc.CurrentSnapBlock()
+	for len(blocks) > 0 && blocks[0].NumberU64() <= head.Number.Uint64() {
+		if canonical := bc.GetCanonicalHash(blocks[0].NumberU64()); canonical != blocks[0].Hash() {
+			return 0, fmt.Errorf("block %d: hash %x conflicts with canonical %x", blocks[0].NumberU64(), blocks[0].Hash(), canonical)
+		}
+		blocks, receipts, tds = blocks[1:], receipts[1:], tds[1:]
+	}
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+	if blocks[0].ParentHash() != head.Hash() {
+		return 0, fmt.Errorf("block %d does not extend the chain head %d", blocks[0].NumberU64(), head.Number)
+	}
+	if ptd := bc.GetTd(head.Hash(), head.Number.Uint64()); ptd == nil || new(big.Int).Add(ptd, blocks[0].Difficulty()).Cmp(tds[0]) != 0 {
+		return 0, fmt.Errorf("block %d: total difficulty does not extend the chain", blocks[0].NumberU64())
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	// The headers of checkpointed epochs are trusted through the accumulator,
+	// those of the others must pass the regular header verification
+	if !trusted {
+		if i, err := bc.hc.ValidateHeaderChain(headers); err != nil {
+			return 0, fmt.Errorf("block %d: %w", headers[i].Number, err)
+		}
+	}
+	if !bc.chainmu.TryLock() {
+		return 0, errChainStopped
+	}
+	status, err := bc.hc.InsertHeaderChain(headers, time.Now(), bc.forker)
+	bc.chainmu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if status != CanonStatTy {
+		return 0, fmt.Errorf("headers not canonical: %v", status)
+	}
+	if _, err := bc.InsertReceiptChain(blocks, receipts, math.MaxUint64); err != nil {
+		return 0, err
+	}
+	return len(blocks), nil
+}
+
+// verifyEraBlock checks that the body and receipts of an archived block match
+// its header.
+func verifyEraBlock(config *params.ChainConfig, block *types.Block, receipts types.Receipts) error {
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(block.Transactions()))
+	}
+	if hash := types.DeriveSha(block.Transactions(), trie.NewRootHasher(config)); hash != block.TxHash() {
+		return fmt.Errorf("transaction root mismatch: have %x, want %x", hash, block.TxHash())
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+		return fmt.Errorf("uncle root mismatch: have %x, want %x", hash, block.UncleHash())
+	}
+	if hash := types.DeriveSha(receipts, trie.NewRootHasher(config)); hash != block.ReceiptHash() {
+		return fmt.Errorf("receipt root mismatch: have %x, want %x", hash, block.ReceiptHash())
+	}
+	return nil
+}
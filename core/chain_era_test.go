@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that history exported as era1 files backfills a fresh chain, that files
+// not matching the checkpoints are rejected, and that the headers of epochs
+// without a checkpoint are verified.
+func TestEraExportImport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		dir    = t.TempDir()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 6, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	source, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	defer source.Stop()
+	if _, err := source.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if _, err := source.ExportEra(dir, 1, 6); err == nil {
+		t.Error("unaligned export accepted")
+	}
+	files, err := source.ExportEra(dir, 0, 6)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if len(files) != 1 || files[0].Epoch != 0 {
+		t.Fatalf("exported files mismatch: %+v", files)
+	}
+	newTarget := func(engine consensus.Engine) *BlockChain {
+		db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false, false, false)
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create target chain: %v", err)
+		}
+		return chain
+	}
+	// Files conflicting with the checkpoints must not be imported
+	target := newTarget(engine)
+	if _, err := target.ImportEra(dir, EraCheckpoints{0: common.Hash{0x01}}); err == nil {
+		t.Error("checkpoint mismatch accepted")
+	}
+	if head := target.CurrentSnapBlock().Number.Uint64(); head != 0 {
+		t.Errorf("rejected file imported up to %d", head)
+	}
+	target.Stop()
+
+	// Headers without a checkpoint must pass the engine verification, those of
+	// checkpointed epochs are trusted
+	target = newTarget(ethash.NewFakeFailer(3))
+	if _, err := target.ImportEra(dir, nil); err == nil {
+		t.Error("unverifiable headers accepted")
+	}
+	if head := target.CurrentSnapBlock().Number.Uint64(); head != 0 {
+		t.Errorf("rejected file imported up to %d", head)
+	}
+	target.Stop()
+
+	target = newTarget(ethash.NewFakeFailer(3))
+	defer target.Stop()
+
+	n, err := target.ImportEra(dir, EraCheckpoints{0: files[0].Accumulator})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if n != len(blocks) {
+		t.Errorf("imported block count mismatch: have %d, want %d", n, len(blocks))
+	}
+	if head := target.CurrentSnapBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Errorf("snap head mismatch: have %d", head.Number)
+	}
+	for _, block := range blocks {
+		receipts := target.GetReceiptsByHash(block.Hash())
+		if len(receipts) != 1 || receipts[0].TxHash != block.Transactions()[0].Hash() {
+			t.Errorf("receipts of block %d mismatch", block.NumberU64())
+		}
+	}
+	// Importing again is a no-op
+	if n, err := target.ImportEra(dir, nil); err != nil || n != 0 {
+		t.Errorf("repeated import: have %d blocks, err %v", n, err)
+	}
+}
@@ -267,6 +267,9 @@ func (b *BlockGen) AddUncle(h *types.Header) {
 			h.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
 		}
 	}
+	if limit, ok := b.cm.config.ScheduledGasLimit(h.Number); ok {
+		h.GasLimit = limit
+	}
 	b.uncles = append(b.uncles, h)
 }
 
@@ -633,6 +636,9 @@ func (cm *chainMaker) makeHeader(parent *types.Block, state *state.StateDB, engi
 			header.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
 		}
 	}
+	if limit, ok := cm.config.ScheduledGasLimit(header.Number); ok {
+		header.GasLimit = limit
+	}
 	if cm.config.IsCancun(header.Number, header.Time) {
 		excessBlobGas := eip4844.CalcExcessBlobGas(cm.config, parentHeader, time)
 		header.ExcessBlobGas = &excessBlobGas
@@ -252,3 +252,49 @@ func ExampleGenerateChain() {
 	// balance of addr2: 10000
 	// balance of addr3: 19687500000000001000
 }
+
+func TestGenerateChainGasLimitSchedule(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		config = *params.AllEthashProtocolChanges
+		gspec  = &Genesis{Config: &config, GasLimit: params.GenesisGasLimit}
+	)
+	config.GasLimitSchedule = &params.GasLimitScheduleConfig{
+		Block:           big.NewInt(1),
+		InitialGasLimit: 10_000_000,
+		IncreaseRate:    1_000_000,
+		GasLimitCap:     12_500_000,
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 5, nil)
+
+	want := []uint64{10_000_000, 11_000_000, 12_000_000, 12_500_000, 12_500_000}
+	for i, block := range blocks {
+		if block.GasLimit() != want[i] {
+			t.Errorf("block %d: gas limit mismatch: have %d, want %d", block.NumberU64(), block.GasLimit(), want[i])
+		}
+	}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	// A chain following a different schedule must reject the blocks
+	other := config
+	other.GasLimitSchedule = &params.GasLimitScheduleConfig{
+		Block:           big.NewInt(1),
+		InitialGasLimit: 10_000_000,
+		IncreaseRate:    2_000_000,
+		GasLimitCap:     12_500_000,
+	}
+	chain, err = NewBlockChain(rawdb.NewMemoryDatabase(), nil, &Genesis{Config: &other, GasLimit: params.GenesisGasLimit}, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err == nil || n != 1 {
+		t.Fatalf("schedule violation not detected: index %d, err %v", n, err)
+	}
+}
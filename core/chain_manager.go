@@ -0,0 +1,181 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	errChainExists     = errors.New("chain already exists")
+	errChainUnknown    = errors.New("unknown chain")
+	errChainDBInUse    = errors.New("database used by another chain")
+	errManagerShutdown = errors.New("chain manager closed")
+)
+
+// WithCodeCache returns a BlockChainOption which makes the chain share the given
+// contract code cache, instead of keeping its own.
+func WithCodeCache(cache *state.CodeCache) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.statedb.SetCodeCache(cache)
+		return bc, nil
+	}
+}
+
+// ChainSpec is the definition of a chain hosted by a ChainManager.
+type ChainSpec struct {
+	DB          ethdb.Database // Database of the chain, owned by the manager
+	StateDB     ethdb.Database // Optional separate database of the state trie, owned by the manager
+	CacheConfig *CacheConfig
+	Genesis     *Genesis
+	Overrides   *ChainOverrides
+	Engine      consensus.Engine
+	VMConfig    vm.Config
+	Options     []BlockChainOption
+}
+
+// managedChain is a chain hosted by a ChainManager.
+type managedChain struct {
+	chain *BlockChain
+	db    ethdb.Database
+}
+
+// ChainManager hosts several independent chains in one process, each with its
+// own genesis, configuration and database. Contract codes are cached once for
+// all the chains, as are transaction senders, whose recovery is process wide
+// already. Everything else, notably the state and trie caches, is kept per
+// chain, as sharing it would let one chain observe another's state.
+type ChainManager struct {
+	codes  *state.CodeCache
+	chains map[string]*managedChain
+	closed bool
+	lock   sync.RWMutex
+}
+
+// NewChainManager creates a manager hosting no chains.
+func NewChainManager() *ChainManager {
+	return &ChainManager{
+		codes:  state.NewCodeCache(),
+		chains: make(map[string]*managedChain),
+	}
+}
+
+// Start creates the chain defined by the spec and hosts it under the given
+// name. The manager takes ownership of the database of the chain, closing it
+// when the chain is stopped.
+func (m *ChainManager) Start(name string, spec *ChainSpec) (*BlockChain, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return nil, errManagerShutdown
+	}
+	if _, ok := m.chains[name]; ok {
+		return nil, fmt.Errorf("%w: %s", errChainExists, name)
+	}
+	for other, hosted := range m.chains {
+		if hosted.db == spec.DB || (spec.StateDB != nil && hosted.db.GetStateStore() == spec.StateDB) {
+			return nil, fmt.Errorf("%w: %s", errChainDBInUse, other)
+		}
+	}
+	if spec.StateDB != nil {
+		if err := rawdb.AttachStateStore(spec.DB, spec.StateDB); err != nil {
+			return nil, err
+		}
+	}
+	options := append(slices.Clone(spec.Options), WithCodeCache(m.codes))
+	chain, err := NewBlockChain(spec.DB, spec.CacheConfig, spec.Genesis, spec.Overrides, spec.Engine, spec.VMConfig, nil, nil, options...)
+	if err != nil {
+		return nil, err
+	}
+	m.chains[name] = &managedChain{chain: chain, db: spec.DB}
+	log.Info("Started hosted chain", "name", name, "chainid", chain.Config().ChainID, "head", chain.CurrentBlock().Number)
+	return chain, nil
+}
+
+// Chain returns the hosted chain with the given name, or nil if there is none.
+func (m *ChainManager) Chain(name string) *BlockChain {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if hosted, ok := m.chains[name]; ok {
+		return hosted.chain
+	}
+	return nil
+}
+
+// Chains returns the names of the hosted chains in alphabetical order.
+func (m *ChainManager) Chains() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	names := make([]string, 0, len(m.chains))
+	for name := range m.chains {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Stop stops the hosted chain with the given name and closes its database.
+func (m *ChainManager) Stop(name string) error {
+	m.lock.Lock()
+	hosted, ok := m.chains[name]
+	delete(m.chains, name)
+	m.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", errChainUnknown, name)
+	}
+	return m.stop(name, hosted)
+}
+
+// Close stops all the hosted chains and prevents starting new ones.
+func (m *ChainManager) Close() error {
+	m.lock.Lock()
+	chains := m.chains
+	m.chains, m.closed = make(map[string]*managedChain), true
+	m.lock.Unlock()
+
+	var errs []error
+	for name, hosted := range chains {
+		if err := m.stop(name, hosted); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stop shuts a chain down, which was already removed from the hosted set.
+func (m *ChainManager) stop(name string, hosted *managedChain) error {
+	hosted.chain.Stop()
+	if err := hosted.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database of chain %s: %w", name, err)
+	}
+	log.Info("Stopped hosted chain", "name", name)
+	return nil
+}
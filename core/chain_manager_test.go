@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests the lifecycle of the chains hosted by a chain manager.
+func TestChainManager(t *testing.T) {
+	newSpec := func(chainID int64) *ChainSpec {
+		config := *params.TestChainConfig
+		config.ChainID = big.NewInt(chainID)
+		return &ChainSpec{
+			DB:      rawdb.NewMemoryDatabase(),
+			Genesis: &Genesis{Config: &config, ExtraData: []byte{byte(chainID)}},
+			Engine:  ethash.NewFaker(),
+		}
+	}
+	manager := NewChainManager()
+	defer manager.Close()
+
+	first, second := newSpec(1), newSpec(2)
+	a, err := manager.Start("a", first)
+	if err != nil {
+		t.Fatalf("failed to start first chain: %v", err)
+	}
+	if _, err := manager.Start("b", second); err != nil {
+		t.Fatalf("failed to start second chain: %v", err)
+	}
+	if _, err := manager.Start("a", newSpec(3)); !errors.Is(err, errChainExists) {
+		t.Errorf("duplicate name accepted: %v", err)
+	}
+	if _, err := manager.Start("c", &ChainSpec{DB: first.DB, Genesis: first.Genesis, Engine: first.Engine}); !errors.Is(err, errChainDBInUse) {
+		t.Errorf("shared database accepted: %v", err)
+	}
+	if names := manager.Chains(); !slices.Equal(names, []string{"a", "b"}) {
+		t.Errorf("hosted chains mismatch: have %v", names)
+	}
+	if manager.Chain("a") != a || a.Config().ChainID.Int64() != 1 {
+		t.Errorf("hosted chain mismatch")
+	}
+	if manager.Chain("b").Genesis().Hash() == a.Genesis().Hash() {
+		t.Errorf("hosted chains share the genesis")
+	}
+	// Blocks of one chain must not leak into the other
+	_, blocks, _ := GenerateChainWithGenesis(first.Genesis, first.Engine, 2, nil)
+	if _, err := a.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if head := manager.Chain("b").CurrentBlock().Number.Uint64(); head != 0 {
+		t.Errorf("second chain advanced to %d", head)
+	}
+	if err := manager.Stop("a"); err != nil {
+		t.Fatalf("failed to stop chain: %v", err)
+	}
+	if err := manager.Stop("a"); !errors.Is(err, errChainUnknown) {
+		t.Errorf("stopped chain stopped again: %v", err)
+	}
+	if err := manager.Close(); err != nil {
+		t.Fatalf("failed to close manager: %v", err)
+	}
+	if _, err := manager.Start("d", newSpec(4)); !errors.Is(err, errManagerShutdown) {
+		t.Errorf("chain started on closed manager: %v", err)
+	}
+}
+
+// Tests that hosted chains keep their state in the separate state database if
+// one is configured.
+func TestChainManagerStateStore(t *testing.T) {
+	spec := &ChainSpec{
+		DB:      rawdb.NewMemoryDatabase(),
+		StateDB: rawdb.NewMemoryDatabase(),
+		Genesis: &Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{{0x01}: {Balance: big.NewInt(1)}}},
+		Engine:  ethash.NewFaker(),
+	}
+	manager := NewChainManager()
+	defer manager.Close()
+
+	chain, err := manager.Start("a", spec)
+	if err != nil {
+		t.Fatalf("failed to start chain: %v", err)
+	}
+	root := chain.Genesis().Root()
+	if !rawdb.HasLegacyTrieNode(spec.StateDB, root) {
+		t.Error("genesis state missing from the state database")
+	}
+	if rawdb.HasLegacyTrieNode(spec.DB, root) {
+		t.Error("genesis state written into the chain database")
+	}
+	if _, err := manager.Start("b", &ChainSpec{DB: rawdb.NewMemoryDatabase(), StateDB: spec.StateDB, Genesis: spec.Genesis, Engine: spec.Engine}); !errors.Is(err, errChainDBInUse) {
+		t.Errorf("shared state database accepted: %v", err)
+	}
+}
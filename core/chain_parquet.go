@@ -0,0 +1,302 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/parquet"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ParquetSchemaVersion is the version of the parquet export schema, stored in
+// the metadata of every exported file. Columns are only ever appended to the
+// tables, never removed or retyped, so files of older versions remain readable
+// along newer ones by engines merging the schemas of a dataset.
+const ParquetSchemaVersion = 1
+
+// DefaultParquetPartitionSize is the default number of blocks per exported
+// file.
+const DefaultParquetPartitionSize = 100_000
+
+// Exported tables and their columns.
+var (
+	parquetBlockColumns = []parquet.Column{
+		{Name: "number", Type: parquet.Int64},
+		{Name: "hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "parent_hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "timestamp", Type: parquet.Int64},
+		{Name: "miner", Type: parquet.FixedLenByteArray, Length: 20},
+		{Name: "state_root", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "gas_limit", Type: parquet.Int64},
+		{Name: "gas_used", Type: parquet.Int64},
+		{Name: "base_fee", Type: parquet.ByteArray, UTF8: true, Optional: true},
+		{Name: "transaction_count", Type: parquet.Int32},
+		{Name: "size", Type: parquet.Int64},
+	}
+	parquetTransactionColumns = []parquet.Column{
+		{Name: "block_number", Type: parquet.Int64},
+		{Name: "block_hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "transaction_index", Type: parquet.Int32},
+		{Name: "hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "type", Type: parquet.Int32},
+		{Name: "from", Type: parquet.FixedLenByteArray, Length: 20},
+		{Name: "to", Type: parquet.FixedLenByteArray, Length: 20, Optional: true},
+		{Name: "nonce", Type: parquet.Int64},
+		{Name: "value", Type: parquet.ByteArray, UTF8: true},
+		{Name: "gas", Type: parquet.Int64},
+		{Name: "gas_price", Type: parquet.ByteArray, UTF8: true},
+		{Name: "input", Type: parquet.ByteArray},
+		{Name: "status", Type: parquet.Int32},
+		{Name: "gas_used", Type: parquet.Int64},
+		{Name: "cumulative_gas_used", Type: parquet.Int64},
+		{Name: "effective_gas_price", Type: parquet.ByteArray, UTF8: true, Optional: true},
+		{Name: "contract_address", Type: parquet.FixedLenByteArray, Length: 20, Optional: true},
+	}
+	parquetLogColumns = []parquet.Column{
+		{Name: "block_number", Type: parquet.Int64},
+		{Name: "block_hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "transaction_index", Type: parquet.Int32},
+		{Name: "transaction_hash", Type: parquet.FixedLenByteArray, Length: 32},
+		{Name: "log_index", Type: parquet.Int32},
+		{Name: "address", Type: parquet.FixedLenByteArray, Length: 20},
+		{Name: "topic0", Type: parquet.FixedLenByteArray, Length: 32, Optional: true},
+		{Name: "topic1", Type: parquet.FixedLenByteArray, Length: 32, Optional: true},
+		{Name: "topic2", Type: parquet.FixedLenByteArray, Length: 32, Optional: true},
+		{Name: "topic3", Type: parquet.FixedLenByteArray, Length: 32, Optional: true},
+		{Name: "data", Type: parquet.ByteArray},
+	}
+)
+
+// ParquetExport is the summary of a parquet export.
+type ParquetExport struct {
+	Partitions   int   // Number of partitions written
+	Blocks       int64 // Number of block rows written
+	Transactions int64 // Number of transaction rows written
+	Logs         int64 // Number of log rows written
+}
+
+// ExportParquet exports the canonical blocks in the inclusive range [from, to]
+// with their transactions and logs as parquet files, for loading into data
+// warehouses. The data is read straight from the database and the freezer.
+//
+// Every table is written into its own directory below dir, partitioned into
+// files of partitionSize blocks each, aligned to multiples of the size:
+//
+//	blocks/<first>-<last>.parquet
+//	transactions/<first>-<last>.parquet
+//	logs/<first>-<last>.parquet
+//
+// Files are written atomically, replacing any previous export of the same
+// partition.
+func (bc *BlockChain) ExportParquet(dir string, from, to uint64, partitionSize uint64) (*ParquetExport, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid export range [%d, %d]", from, to)
+	}
+	if head := bc.CurrentBlock().Number.Uint64(); to > head {
+		return nil, fmt.Errorf("export range [%d, %d] beyond head %d", from, to, head)
+	}
+	if partitionSize == 0 {
+		partitionSize = DefaultParquetPartitionSize
+	}
+	for _, table := range []string{"blocks", "transactions", "logs"} {
+		if err := os.MkdirAll(filepath.Join(dir, table), 0755); err != nil {
+			return nil, err
+		}
+	}
+	export := new(ParquetExport)
+	for first := from; ; {
+		last := first - first%partitionSize + partitionSize - 1
+		if last > to || last < first {
+			last = to
+		}
+		if err := bc.exportParquetPartition(dir, first, last, export); err != nil {
+			return export, err
+		}
+		export.Partitions++
+		log.Info("Exported parquet partition", "first", first, "last", last,
+			"blocks", export.Blocks, "txs", export.Transactions, "logs", export.Logs)
+
+		if last == to {
+			return export, nil
+		}
+		first = last + 1
+	}
+}
+
+// parquetTable is a table file being written.
+type parquetTable struct {
+	file   *os.File
+	buf    *bufio.Writer
+	writer *parquet.Writer
+	path   string
+}
+
+func newParquetTable(path string, columns []parquet.Column, first, last uint64) (*parquetTable, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	writer, err := parquet.NewWriter(buf, columns, map[string]string{
+		"schema_version": strconv.Itoa(ParquetSchemaVersion),
+		"first_block":    strconv.FormatUint(first, 10),
+		"last_block":     strconv.FormatUint(last, 10),
+	})
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &parquetTable{file: file, buf: buf, writer: writer, path: path}, nil
+}
+
+// commit finishes the file and moves it into place.
+func (t *parquetTable) commit() error {
+	if err := t.writer.Close(); err != nil {
+		return err
+	}
+	if err := t.buf.Flush(); err != nil {
+		return err
+	}
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+	if err := t.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(t.file.Name(), t.path)
+}
+
+// discard removes the unfinished file.
+func (t *parquetTable) discard() {
+	t.file.Close()
+	os.Remove(t.file.Name())
+}
+
+// exportParquetPartition writes the tables of the blocks in [first, last].
+func (bc *BlockChain) exportParquetPartition(dir string, first, last uint64, export *ParquetExport) (err error) {
+	var (
+		name   = fmt.Sprintf("%012d-%012d.parquet", first, last)
+		tables = make([]*parquetTable, 0, 3)
+	)
+	defer func() {
+		if err != nil {
+			for _, table := range tables {
+				table.discard()
+			}
+		}
+	}()
+	for _, spec := range []struct {
+		table   string
+		columns []parquet.Column
+	}{
+		{"blocks", parquetBlockColumns},
+		{"transactions", parquetTransactionColumns},
+		{"logs", parquetLogColumns},
+	} {
+		table, err := newParquetTable(filepath.Join(dir, spec.table, name), spec.columns, first, last)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	blocks, txs, logs := tables[0].writer, tables[1].writer, tables[2].writer
+
+	for number := first; ; number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		block := rawdb.ReadBlock(bc.db, hash, number)
+		if block == nil {
+			return fmt.Errorf("block %d unavailable", number)
+		}
+		receipts := rawdb.ReadReceipts(bc.db, hash, number, block.Time(), bc.chainConfig)
+		if len(receipts) != len(block.Transactions()) {
+			return fmt.Errorf("receipts of block %d unavailable", number)
+		}
+		if err := blocks.Write(
+			number, hash.Bytes(), block.ParentHash().Bytes(), block.Time(), block.Coinbase().Bytes(),
+			block.Root().Bytes(), block.GasLimit(), block.GasUsed(), optionalDecimal(block.BaseFee()),
+			int32(len(block.Transactions())), block.Size(),
+		); err != nil {
+			return err
+		}
+		signer := types.MakeSigner(bc.chainConfig, block.Number(), block.Time())
+		for i, tx := range block.Transactions() {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return fmt.Errorf("block %d tx %d: %w", number, i, err)
+			}
+			receipt := receipts[i]
+
+			var to, contract any
+			if tx.To() != nil {
+				to = tx.To().Bytes()
+			} else {
+				contract = receipt.ContractAddress.Bytes()
+			}
+			if err := txs.Write(
+				number, hash.Bytes(), int32(i), tx.Hash().Bytes(), int32(tx.Type()), from.Bytes(), to,
+				tx.Nonce(), tx.Value().String(), tx.Gas(), tx.GasPrice().String(), tx.Data(),
+				int32(receipt.Status), receipt.GasUsed, receipt.CumulativeGasUsed,
+				optionalDecimal(receipt.EffectiveGasPrice), contract,
+			); err != nil {
+				return err
+			}
+			for _, l := range receipt.Logs {
+				topics := make([]any, 4)
+				for j := 0; j < len(l.Topics) && j < len(topics); j++ {
+					topics[j] = l.Topics[j].Bytes()
+				}
+				if err := logs.Write(
+					number, hash.Bytes(), int32(i), tx.Hash().Bytes(), int32(l.Index), l.Address.Bytes(),
+					topics[0], topics[1], topics[2], topics[3], l.Data,
+				); err != nil {
+					return err
+				}
+			}
+		}
+		if number == last {
+			break
+		}
+	}
+	export.Blocks += blocks.Rows()
+	export.Transactions += txs.Rows()
+	export.Logs += logs.Rows()
+
+	for _, table := range tables {
+		if err := table.commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// optionalDecimal returns the decimal representation of the number, or nil if
+// the number is not set.
+func optionalDecimal(n *big.Int) any {
+	if n == nil {
+		return nil
+	}
+	return n.String()
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that chain ranges are exported into partitioned parquet tables.
+func TestExportParquet(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+
+		// Contract emitting an anonymous log with one topic
+		logger = common.Address{0xdd}
+		gspec  = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc: types.GenesisAlloc{
+				addr:   {Balance: big.NewInt(params.Ether)},
+				logger: {Code: common.FromHex("0x600160006000a100")},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, func(i int, b *BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), logger, nil, 50000, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	dir := t.TempDir()
+	export, err := chain.ExportParquet(dir, 1, 4, 2)
+	if err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	if want := (ParquetExport{Partitions: 3, Blocks: 4, Transactions: 6, Logs: 6}); *export != want {
+		t.Errorf("export summary mismatch: have %+v, want %+v", *export, want)
+	}
+	for _, table := range []string{"blocks", "transactions", "logs"} {
+		for _, name := range []string{"000000000001-000000000001", "000000000002-000000000003", "000000000004-000000000004"} {
+			blob, err := os.ReadFile(filepath.Join(dir, table, name+".parquet"))
+			if err != nil {
+				t.Fatalf("table %s: partition %s missing: %v", table, name, err)
+			}
+			if !bytes.HasPrefix(blob, []byte("PAR1")) || !bytes.HasSuffix(blob, []byte("PAR1")) {
+				t.Errorf("table %s: partition %s not a parquet file", table, name)
+			}
+		}
+		if temps, _ := filepath.Glob(filepath.Join(dir, table, "*.tmp")); len(temps) > 0 {
+			t.Errorf("table %s: temporary files left: %v", table, temps)
+		}
+	}
+	if _, err := chain.ExportParquet(dir, 3, 2, 0); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	if _, err := chain.ExportParquet(dir, 1, 5, 0); err == nil {
+		t.Errorf("range beyond the head accepted")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// replayReexecLimit is the maximum number of blocks re-executed to reconstruct
+// the parent state of a replayed range, if it's not available.
+const replayReexecLimit = 1024
+
+// errReplayRange is returned if the block range to replay is invalid.
+var errReplayRange = errors.New("invalid replay range")
+
+// Replay re-executes the canonical blocks in the range [from, to] on top of the
+// state of the parent of from, streaming the execution through the given hooks.
+// If the parent state is not available, it is reconstructed by re-executing the
+// blocks from the closest ancestor with state, up to replayReexecLimit blocks.
+// Block bodies are read from the freezer if already frozen.
+//
+// Every replayed block is validated against its header, the replay stops at the
+// first mismatch. Receipts missing from the database are rewritten once their
+// block is validated. The live state is never modified, the replay executes on
+// an in-memory state only.
+func (bc *BlockChain) Replay(from, to uint64, hooks *tracing.Hooks) error {
+	if from == 0 || from > to {
+		return fmt.Errorf("%w: [%d, %d]", errReplayRange, from, to)
+	}
+	if head := bc.CurrentBlock().Number.Uint64(); to > head {
+		return fmt.Errorf("%w: %d beyond head %d", errReplayRange, to, head)
+	}
+	statedb, err := bc.replayState(from - 1)
+	if err != nil {
+		return err
+	}
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		restored int
+	)
+	for number := from; number <= to; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("block #%d not found", number)
+		}
+		if hooks != nil && hooks.OnBlockStart != nil {
+			hooks.OnBlockStart(tracing.BlockEvent{
+				Block:     block,
+				TD:        bc.GetTd(block.ParentHash(), number-1),
+				Finalized: bc.CurrentFinalBlock(),
+				Safe:      bc.CurrentSafeBlock(),
+			})
+		}
+		res, err := bc.replayBlock(block, statedb, hooks)
+		if hooks != nil && hooks.OnBlockEnd != nil {
+			hooks.OnBlockEnd(err)
+		}
+		if err != nil {
+			return err
+		}
+		if rawdb.ReadRawReceipts(bc.db, block.Hash(), number) == nil {
+			rawdb.WriteReceipts(bc.db, block.Hash(), number, res.Receipts)
+			restored++
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Replaying blocks", "number", number, "target", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Replayed blocks", "from", from, "to", to, "restored", restored, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// replayBlock executes a block on top of the given state and validates the
+// outcome against the header.
+func (bc *BlockChain) replayBlock(block *types.Block, statedb *state.StateDB, hooks *tracing.Hooks) (*ProcessResult, error) {
+	res, err := bc.processor.Process(block, statedb, vm.Config{Tracer: hooks})
+	if err != nil {
+		return nil, fmt.Errorf("processing block %d failed: %w", block.NumberU64(), err)
+	}
+	if err := bc.validator.ValidateState(block, statedb, res, false); err != nil {
+		return nil, fmt.Errorf("block %d diverged on replay: %w", block.NumberU64(), err)
+	}
+	return res, nil
+}
+
+// replayState returns the state after the given canonical block, reconstructing
+// it from the closest ancestor with state available if needed.
+func (bc *BlockChain) replayState(number uint64) (*state.StateDB, error) {
+	var (
+		base    = number
+		statedb *state.StateDB
+	)
+	for {
+		header := bc.GetHeaderByNumber(base)
+		if header == nil {
+			return nil, fmt.Errorf("block #%d not found", base)
+		}
+		var err error
+		if statedb, err = bc.StateAt(header.Root); err == nil {
+			break
+		}
+		if base == 0 || number-base >= replayReexecLimit {
+			return nil, fmt.Errorf("state of block #%d unavailable (reexec=%d)", number, replayReexecLimit)
+		}
+		base--
+	}
+	if base < number {
+		log.Info("Reconstructing replay state", "base", base, "target", number)
+	}
+	for current := base + 1; current <= number; current++ {
+		block := bc.GetBlockByNumber(current)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", current)
+		}
+		if _, err := bc.replayBlock(block, statedb, nil); err != nil {
+			return nil, err
+		}
+	}
+	return statedb, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that replaying a block range streams the execution through the hooks
+// and restores the receipts missing from the database.
+func TestBlockchainReplay(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Drop the receipts of a block and replay a range including it
+	victim := blocks[4]
+	want := rawdb.ReadRawReceipts(db, victim.Hash(), victim.NumberU64())
+	rawdb.DeleteReceipts(db, victim.Hash(), victim.NumberU64())
+
+	var (
+		started, ended int
+		receipts       []*types.Receipt
+	)
+	hooks := &tracing.Hooks{
+		OnBlockStart: func(tracing.BlockEvent) { started++ },
+		OnBlockEnd: func(err error) {
+			if err != nil {
+				t.Errorf("block replay failed: %v", err)
+			}
+			ended++
+		},
+		OnTxEnd: func(receipt *types.Receipt, err error) {
+			if err != nil {
+				t.Errorf("transaction replay failed: %v", err)
+			}
+			receipts = append(receipts, receipt)
+		},
+	}
+	if err := chain.Replay(3, 6, hooks); err != nil {
+		t.Fatalf("failed to replay blocks: %v", err)
+	}
+	if started != 4 || ended != 4 {
+		t.Errorf("block hooks mismatch: started %d, ended %d, want 4", started, ended)
+	}
+	if len(receipts) != 4 {
+		t.Fatalf("receipt count mismatch: have %d, want 4", len(receipts))
+	}
+	for i, receipt := range receipts {
+		if have, want := receipt.BlockNumber.Uint64(), uint64(3+i); have != want {
+			t.Errorf("receipt %d: block number mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	have := rawdb.ReadRawReceipts(db, victim.Hash(), victim.NumberU64())
+	if len(have) != len(want) || have[0].CumulativeGasUsed != want[0].CumulativeGasUsed || have[0].Status != want[0].Status {
+		t.Fatalf("receipts not restored: have %v, want %v", have, want)
+	}
+	// Invalid ranges are rejected
+	for _, r := range [][2]uint64{{0, 2}, {5, 4}, {7, 9}} {
+		if err := chain.Replay(r[0], r[1], nil); !errors.Is(err, errReplayRange) {
+			t.Errorf("range %v: error mismatch: have %v, want %v", r, err, errReplayRange)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	codeJournalCodes    = "codes"    // Directory of the persisted contract codes
+	codeJournalAnalyses = "analyses" // Directory of the persisted code analyses
+
+	codeJournalCodesSize    = 128 * 1024 * 1024 // Memory allowance of the persisted contract codes
+	codeJournalAnalysesSize = 32 * 1024 * 1024  // Memory allowance of the persisted code analyses
+)
+
+// codeJournal is a side cache of the contract codes and their jump destination
+// analyses, saved to disk on shutdown and loaded on startup. A node restarting
+// at the head thus finds the hot contracts in memory, instead of reading them
+// one by one from the database while processing the first blocks.
+//
+// The caches are keyed by code hash, so they can't go stale and are safe to be
+// shared by any chain using the same directory. As the files may be corrupted,
+// each entry is verified when first loaded from them: the codes against their
+// hash and the analyses against their checksum.
+type codeJournal struct {
+	dir      string
+	codes    *fastcache.Cache
+	analyses *fastcache.Cache
+}
+
+// openCodeJournal loads the code journal from the given directory, starting
+// empty if there's none or it's unreadable.
+func openCodeJournal(dir string) *codeJournal {
+	start := time.Now()
+	journal := &codeJournal{
+		dir:      dir,
+		codes:    fastcache.LoadFromFileOrNew(filepath.Join(dir, codeJournalCodes), codeJournalCodesSize),
+		analyses: fastcache.LoadFromFileOrNew(filepath.Join(dir, codeJournalAnalyses), codeJournalAnalysesSize),
+	}
+	var stats fastcache.Stats
+	journal.codes.UpdateStats(&stats)
+	log.Info("Loaded code cache journal", "dir", dir, "codes", stats.EntriesCount, "elapsed", common.PrettyDuration(time.Since(start)))
+	return journal
+}
+
+// setupCodeJournal loads the code journal configured for the chain, and puts
+// it behind the code caches of the state databases and the EVM.
+func (bc *BlockChain) setupCodeJournal() {
+	if bc.cacheConfig.CodeCacheJournal == "" {
+		return
+	}
+	bc.codeJournal = openCodeJournal(bc.cacheConfig.CodeCacheJournal)
+	bc.statedb.SetCodeStore(bc.codeJournal.codes)
+	if bc.verkleStatedb != nil {
+		bc.verkleStatedb.SetCodeStore(bc.codeJournal.codes)
+	}
+	bc.vmConfig.CodeAnalysisStore = bc.codeJournal.analyses
+}
+
+// stopCodeJournal saves the code journal of the chain to disk and releases it.
+func (bc *BlockChain) stopCodeJournal() {
+	journal := bc.codeJournal
+	if journal == nil {
+		return
+	}
+
+	start := time.Now()
+	for name, cache := range map[string]*fastcache.Cache{codeJournalCodes: journal.codes, codeJournalAnalyses: journal.analyses} {
+		if err := cache.SaveToFileConcurrent(filepath.Join(journal.dir, name), runtime.GOMAXPROCS(0)); err != nil {
+			log.Warn("Failed to save code cache journal", "dir", journal.dir, "cache", name, "err", err)
+		}
+	}
+	log.Info("Saved code cache journal", "dir", journal.dir, "elapsed", common.PrettyDuration(time.Since(start)))
+}
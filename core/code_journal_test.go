@@ -0,0 +1,114 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the contract codes read by a chain are persisted on shutdown and
+// served from the journal after a restart.
+func TestCodeJournal(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		contract = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		code     = common.FromHex("0x6001600055")
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{contract: {Code: code}},
+		}
+		db     = rawdb.NewMemoryDatabase()
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	config.CodeCacheJournal = dir
+
+	chain, err := NewBlockChain(db, config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Fatalf("code mismatch: have %x, want %x", have, code)
+	}
+	chain.Stop()
+
+	// Drop the code from the database and check it's served from the journal
+	rawdb.DeleteCode(db, crypto.Keccak256Hash(code))
+
+	chain, err = NewBlockChain(db, config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	statedb, err = chain.State()
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Fatalf("journaled code mismatch: have %x, want %x", have, code)
+	}
+}
+
+// Tests that journaled codes not matching their hash are ignored.
+func TestCodeJournalCorrupted(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		code     = common.FromHex("0x6001600055")
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{contract: {Code: code}},
+		}
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	config.CodeCacheJournal = t.TempDir()
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.GetVMConfig().CodeAnalysisStore != chain.codeJournal.analyses {
+		t.Fatalf("code analysis store not attached to the chain")
+	}
+	hash := crypto.Keccak256Hash(code)
+	chain.codeJournal.codes.Set(hash[:], common.FromHex("0x6002600055"))
+
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Fatalf("code mismatch: have %x, want %x", have, code)
+	}
+	if chain.codeJournal.codes.Has(hash[:]) && !bytes.Equal(chain.codeJournal.codes.Get(nil, hash[:]), code) {
+		t.Fatalf("corrupted code not dropped from the journal")
+	}
+}
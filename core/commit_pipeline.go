@@ -0,0 +1,115 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	commitPipelinedMeter = metrics.NewRegisteredMeter("chain/commit/pipelined", nil)
+	commitWaitTimer      = metrics.NewRegisteredTimer("chain/commit/wait", nil)
+)
+
+// The trie commit pipeline overlaps the trie database maintenance of imported
+// blocks, i.e. referencing, capping, flushing and garbage collecting the tries,
+// with the processing of the next block. The state of a block is committed
+// into the trie database before the block is written, so it's readable by the
+// next block and by anyone following the head, but the head may be updated
+// before its tries are flushed. Nodes requiring the tries of every head to be
+// persisted first disable the pipeline with CacheConfig.StrictCommit.
+//
+// The pipeline only runs within a chain insertion, the last trie commit being
+// waited for before the insertion returns. A failed commit aborts the insertion
+// and is reported against the block it belongs to. All the pipeline's state is
+// protected by the chain mutex.
+
+// startCommitPipeline enables pipelining the trie commits of imported blocks,
+// returning false if it's disabled or already running.
+func (bc *BlockChain) startCommitPipeline() bool {
+	if bc.cacheConfig.StrictCommit || bc.commitAsync {
+		return false
+	}
+	bc.commitAsync = true
+	return true
+}
+
+// commitError is returned if the pipelined trie commit of a block failed. The
+// failure surfaces while importing a later block or when the pipeline stops,
+// so the error names the block it belongs to.
+type commitError struct {
+	block *types.Block
+	err   error
+}
+
+// Error implements error.
+func (e *commitError) Error() string {
+	return fmt.Sprintf("failed to commit tries of block #%d [%x..]: %v", e.block.NumberU64(), e.block.Hash().Bytes()[:4], e.err)
+}
+
+// Unwrap returns the underlying commit failure.
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// stopCommitPipeline waits for the in-flight trie commit and disables the
+// pipelining of trie commits, returning the error of the commit if it failed.
+func (bc *BlockChain) stopCommitPipeline() error {
+	err := bc.waitCommit()
+	bc.commitAsync = false
+	return err
+}
+
+// waitCommit blocks until the in-flight trie commit finishes, returning its
+// error if it failed.
+func (bc *BlockChain) waitCommit() error {
+	if bc.commitPending == nil {
+		return nil
+	}
+	start := time.Now()
+	err := <-bc.commitPending
+	bc.commitPending = nil
+	commitWaitTimer.UpdateSince(start)
+	return err
+}
+
+// scheduleCommit runs the trie maintenance of the given block after the one of
+// its predecessor, in the background if the commit pipeline is running.
+func (bc *BlockChain) scheduleCommit(block *types.Block, root common.Hash) error {
+	if err := bc.waitCommit(); err != nil {
+		return err
+	}
+	if !bc.commitAsync {
+		return bc.commitTries(block, root)
+	}
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if cerr := bc.commitTries(block, root); cerr != nil {
+			err = &commitError{block: block, err: cerr}
+		}
+		done <- err
+	}()
+	bc.commitPending = done
+	commitPipelinedMeter.Mark(1)
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Tests that pipelined trie commits leave the trie database in the same state
+// as strict ones once the chain insertion returns.
+func TestCommitPipeline(t *testing.T) {
+	t.Run("gc", func(t *testing.T) { testCommitPipeline(t, false) })
+	t.Run("archive", func(t *testing.T) { testCommitPipeline(t, true) })
+}
+
+func testCommitPipeline(t *testing.T, archive bool) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(state.TriesInMemory)+32, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	newChain := func(strict bool) *BlockChain {
+		config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		config.TrieDirtyDisabled = archive
+		config.StrictCommit = strict
+
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create blockchain: %v", err)
+		}
+		// Import in a few batches to cover the pipeline restarting
+		for i := 0; i < len(blocks); i += 50 {
+			end := min(i+50, len(blocks))
+			if _, err := chain.InsertChain(blocks[i:end]); err != nil {
+				t.Fatalf("failed to import blocks %d-%d: %v", i, end, err)
+			}
+		}
+		return chain
+	}
+	strict, pipelined := newChain(true), newChain(false)
+	defer strict.Stop()
+	defer pipelined.Stop()
+
+	if pipelined.commitAsync || pipelined.commitPending != nil {
+		t.Fatal("commit pipeline still running after insertion")
+	}
+	if have, want := pipelined.CurrentBlock().Hash(), blocks[len(blocks)-1].Hash(); have != want {
+		t.Fatalf("head mismatch: have %x, want %x", have, want)
+	}
+	if _, err := pipelined.StateAt(pipelined.CurrentBlock().Root); err != nil {
+		t.Fatalf("head state unavailable: %v", err)
+	}
+	if have, want := pipelined.triegc.Size(), strict.triegc.Size(); have != want {
+		t.Fatalf("tries awaiting gc mismatch: have %d, want %d", have, want)
+	}
+	if have, want := pipelined.lastWrite, strict.lastWrite; have != want {
+		t.Fatalf("last flushed block mismatch: have %d, want %d", have, want)
+	}
+	// Archive nodes must have flushed the state of every block
+	if archive {
+		for _, block := range blocks {
+			if !rawdb.HasLegacyTrieNode(pipelined.db, block.Root()) {
+				t.Fatalf("state of block #%d not flushed", block.Number())
+			}
+		}
+	}
+}
+
+var errTrieWrite = errors.New("trie write failed")
+
+// trieFailingStore is a key-value store failing to write the trie nodes of the
+// hash scheme, i.e. the entries keyed by a bare hash.
+type trieFailingStore struct {
+	ethdb.KeyValueStore
+}
+
+func (s *trieFailingStore) NewBatch() ethdb.Batch {
+	return &trieFailingBatch{Batch: s.KeyValueStore.NewBatch()}
+}
+
+func (s *trieFailingStore) NewBatchWithSize(size int) ethdb.Batch {
+	return &trieFailingBatch{Batch: s.KeyValueStore.NewBatchWithSize(size)}
+}
+
+type trieFailingBatch struct {
+	ethdb.Batch
+	nodes bool
+}
+
+func (b *trieFailingBatch) Put(key, value []byte) error {
+	b.nodes = b.nodes || len(key) == common.HashLength
+	return b.Batch.Put(key, value)
+}
+
+func (b *trieFailingBatch) Write() error {
+	if b.nodes {
+		return errTrieWrite
+	}
+	return b.Batch.Write()
+}
+
+// Tests that a failed pipelined trie commit aborts the chain insertion and is
+// reported against the block it belongs to, even if it surfaces while the next
+// block is imported.
+func TestCommitPipelineFailure(t *testing.T) {
+	var (
+		genesis = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		engine  = ethash.NewFaker()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	for _, count := range []int{1, len(blocks)} {
+		db := rawdb.NewMemoryDatabase()
+		if _, err := genesis.Commit(db, triedb.NewDatabase(db, triedb.HashDefaults)); err != nil {
+			t.Fatalf("failed to commit genesis: %v", err)
+		}
+		config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		config.TrieDirtyDisabled = true
+
+		chain, err := NewBlockChain(rawdb.NewDatabase(&trieFailingStore{db}), config, genesis, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create blockchain: %v", err)
+		}
+		n, err := chain.InsertChain(blocks[:count])
+		if !errors.Is(err, errTrieWrite) {
+			t.Errorf("%d blocks: insertion error mismatch: have %v, want %v", count, err, errTrieWrite)
+		}
+		if n != 0 {
+			t.Errorf("%d blocks: failed block index mismatch: have %d, want 0", count, n)
+		}
+		if chain.commitAsync || chain.commitPending != nil {
+			t.Errorf("%d blocks: commit pipeline still running after insertion", count)
+		}
+		chain.Stop()
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ContractStatsOrder is the ordering of the contracts returned by TopContracts.
+type ContractStatsOrder int
+
+const (
+	BySlots      ContractStatsOrder = iota // Net number of storage slots created
+	ByCodeSize                             // Current size of the code
+	ByGrowthRate                           // Storage slots created per block
+)
+
+// EnableContractStats returns a BlockChainOption which tracks the storage slot
+// counts and code sizes of the contracts changed by the canonical blocks. Only
+// blocks executed after enabling the tracking are covered, the slot counts of
+// older contracts are relative to the state at that point.
+func EnableContractStats() BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.contractStats = true
+		return bc, nil
+	}
+}
+
+// ContractStats retrieves the tracked statistics of a contract, or nil if no
+// tracked block changed it.
+func (bc *BlockChain) ContractStats(addr common.Address) *rawdb.ContractStats {
+	return rawdb.ReadContractStats(bc.db, addr)
+}
+
+// TopContracts returns the statistics of the n largest tracked contracts, in
+// descending order of the given metric.
+func (bc *BlockChain) TopContracts(n int, order ContractStatsOrder) []*rawdb.ContractStats {
+	if n <= 0 {
+		return nil
+	}
+	compare := func(a, b *rawdb.ContractStats) int {
+		var c int
+		switch order {
+		case ByCodeSize:
+			c = cmp.Compare(b.CodeSize, a.CodeSize)
+		case ByGrowthRate:
+			c = cmp.Compare(b.GrowthRate(), a.GrowthRate())
+		default:
+			c = cmp.Compare(b.Slots(), a.Slots())
+		}
+		if c != 0 {
+			return c
+		}
+		return a.Address.Cmp(b.Address)
+	}
+	// Keep the top n sorted while iterating, avoiding loading all the stats
+	// into memory at once.
+	top := make([]*rawdb.ContractStats, 0, n+1)
+	rawdb.IterateContractStats(bc.db, func(stats *rawdb.ContractStats) {
+		if len(top) == n && compare(stats, top[n-1]) >= 0 {
+			return
+		}
+		pos, _ := slices.BinarySearchFunc(top, stats, compare)
+		top = slices.Insert(top, pos, stats)
+		if len(top) > n {
+			top = top[:n]
+		}
+	})
+	return top
+}
+
+// contractStatsUpdate accumulates the statistics changes of several blocks in
+// memory, so they can be written through a single batch. Every block is tracked
+// by a marker written along, making applying and reverting it idempotent.
+type contractStatsUpdate struct {
+	bc      *BlockChain
+	stats   map[common.Address]*rawdb.ContractStats // Updated statistics, nil if deleted
+	applied map[common.Hash]bool                    // Updated block markers
+	numbers map[common.Hash]uint64                  // Numbers of the blocks with updated markers
+}
+
+// newContractStatsUpdate creates an empty statistics update, or nil if the
+// tracking is disabled.
+func (bc *BlockChain) newContractStatsUpdate() *contractStatsUpdate {
+	if !bc.contractStats {
+		return nil
+	}
+	return &contractStatsUpdate{
+		bc:      bc,
+		stats:   make(map[common.Address]*rawdb.ContractStats),
+		applied: make(map[common.Hash]bool),
+		numbers: make(map[common.Hash]uint64),
+	}
+}
+
+// read retrieves the statistics of a contract, including the pending changes.
+func (u *contractStatsUpdate) read(addr common.Address) *rawdb.ContractStats {
+	if stats, ok := u.stats[addr]; ok {
+		return stats
+	}
+	return rawdb.ReadContractStats(u.bc.db, addr)
+}
+
+// isApplied reports whether the changes of a block are in the statistics,
+// including the pending changes.
+func (u *contractStatsUpdate) isApplied(hash common.Hash, number uint64) bool {
+	if applied, ok := u.applied[hash]; ok {
+		return applied
+	}
+	return rawdb.HasContractChangesApplied(u.bc.db, hash, number)
+}
+
+// apply accumulates the contract changes of a block which became canonical,
+// unless they were accumulated already.
+func (u *contractStatsUpdate) apply(block *types.Block) {
+	if u == nil || u.isApplied(block.Hash(), block.NumberU64()) {
+		return
+	}
+	for _, change := range rawdb.ReadContractChanges(u.bc.db, block.Hash(), block.NumberU64()) {
+		stats := u.read(change.Address)
+		if stats == nil {
+			stats = &rawdb.ContractStats{Address: change.Address, FirstBlock: block.NumberU64()}
+		}
+		stats.SlotsCreated += change.SlotsCreated
+		stats.SlotsDeleted += change.SlotsDeleted
+		if change.CodeChanged {
+			stats.CodeSize = change.CodeSize
+		}
+		stats.LastBlock = block.NumberU64()
+		u.stats[change.Address] = stats
+	}
+	u.applied[block.Hash()], u.numbers[block.Hash()] = true, block.NumberU64()
+}
+
+// revert subtracts the contract changes of a block which left the canonical
+// chain, if they were accumulated before. The block range of the statistics
+// is not rewound.
+func (u *contractStatsUpdate) revert(hash common.Hash, number uint64) {
+	if u == nil || !u.isApplied(hash, number) {
+		return
+	}
+	for _, change := range rawdb.ReadContractChanges(u.bc.db, hash, number) {
+		stats := u.read(change.Address)
+		if stats == nil || stats.SlotsCreated < change.SlotsCreated || stats.SlotsDeleted < change.SlotsDeleted {
+			log.Warn("Inconsistent contract statistics", "addr", change.Address, "block", number)
+			continue
+		}
+		stats.SlotsCreated -= change.SlotsCreated
+		stats.SlotsDeleted -= change.SlotsDeleted
+		if change.CodeChanged {
+			stats.CodeSize = change.PrevCodeSize
+		}
+		if stats.SlotsCreated == 0 && stats.SlotsDeleted == 0 && stats.CodeSize == 0 {
+			stats = nil
+		}
+		u.stats[change.Address] = stats
+	}
+	u.applied[hash], u.numbers[hash] = false, number
+}
+
+// write flushes the accumulated changes into the database.
+func (u *contractStatsUpdate) write(db ethdb.KeyValueWriter) {
+	if u == nil {
+		return
+	}
+	for addr, stats := range u.stats {
+		if stats == nil {
+			rawdb.DeleteContractStats(db, addr)
+		} else {
+			rawdb.WriteContractStats(db, stats)
+		}
+	}
+	for hash, applied := range u.applied {
+		if applied {
+			rawdb.WriteContractChangesApplied(db, hash, u.numbers[hash])
+		} else {
+			rawdb.DeleteContractChangesApplied(db, hash, u.numbers[hash])
+		}
+	}
+}
+
+// writeContractStats accumulates the contract changes of a block which became
+// canonical into the contract statistics.
+func (bc *BlockChain) writeContractStats(db ethdb.KeyValueWriter, block *types.Block) {
+	update := bc.newContractStatsUpdate()
+	update.apply(block)
+	update.write(db)
+}
+
+// revertContractStats subtracts the contract changes of the blocks which were
+// reorged out of the canonical chain from the contract statistics.
+func (bc *BlockChain) revertContractStats(db ethdb.KeyValueWriter, blocks []*types.Block) {
+	update := bc.newContractStatsUpdate()
+	for _, block := range blocks {
+		update.revert(block.Hash(), block.NumberU64())
+	}
+	update.write(db)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the contract statistics follow the canonical chain, including
+// across reorgs, and that the largest contracts are ranked correctly.
+func TestContractStats(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+
+		// Deploys a 7 byte contract storing 1 into the slot given as calldata
+		initcode  = hexutil.MustDecode("0x666001600035550060005260076019f3")
+		contractA = crypto.CreateAddress(addr, 0)
+		contractB = crypto.CreateAddress(addr, 1)
+	)
+
+	store := func(b *BlockGen, contract common.Address, slot byte) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), contract, big.NewInt(0), 100000, b.header.BaseFee, common.LeftPadBytes([]byte{slot}, 32)), signer, key)
+		b.AddTx(tx)
+	}
+	genDb, blocks, _ := GenerateChainWithGenesis(gspec, engine, 3, func(i int, b *BlockGen) {
+		switch i {
+		case 0:
+			for j := 0; j < 2; j++ {
+				tx, _ := types.SignTx(types.NewContractCreation(b.TxNonce(addr), big.NewInt(0), 100000, b.header.BaseFee, initcode), signer, key)
+				b.AddTx(tx)
+			}
+		case 1:
+			store(b, contractA, 1)
+			store(b, contractA, 2)
+			store(b, contractA, 3)
+			store(b, contractB, 1)
+		case 2:
+			store(b, contractA, 4)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil, EnableContractStats())
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	check := func(contract common.Address, slots int64, first, last uint64) {
+		t.Helper()
+		stats := chain.ContractStats(contract)
+		if stats == nil {
+			t.Fatalf("contract %x: statistics missing", contract)
+		}
+		if stats.Slots() != slots || stats.CodeSize != 7 || stats.FirstBlock != first || stats.LastBlock != last {
+			t.Errorf("contract %x: statistics mismatch: have %d slots, %d code bytes, blocks %d-%d, want %d slots, 7 code bytes, blocks %d-%d",
+				contract, stats.Slots(), stats.CodeSize, stats.FirstBlock, stats.LastBlock, slots, first, last)
+		}
+	}
+	check(contractA, 4, 1, 3)
+	check(contractB, 1, 1, 2)
+
+	if rate := chain.ContractStats(contractA).GrowthRate(); rate != 4.0/3 {
+		t.Errorf("growth rate mismatch: have %v, want %v", rate, 4.0/3)
+	}
+	if top := chain.TopContracts(1, BySlots); len(top) != 1 || top[0].Address != contractA {
+		t.Errorf("top contract mismatch: have %v", top)
+	}
+	if top := chain.TopContracts(5, ByCodeSize); len(top) != 2 {
+		t.Errorf("top contract count mismatch: have %d, want 2", len(top))
+	}
+	// Reorg to a longer fork storing into the second contract only
+	fork, _ := GenerateChain(gspec.Config, blocks[0], engine, genDb, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		switch i {
+		case 0:
+			store(b, contractB, 10)
+			store(b, contractB, 11)
+		case 1:
+			store(b, contractB, 12)
+		}
+	})
+	if n, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("block %d: failed to insert fork: %v", n, err)
+	}
+	check(contractA, 0, 1, 3)
+	check(contractB, 3, 1, 3)
+
+	top := chain.TopContracts(2, BySlots)
+	if len(top) != 2 || top[0].Address != contractB || top[1].Address != contractA {
+		t.Errorf("top contracts mismatch after reorg: have %v", top)
+	}
+	// Reverting the reorged out blocks again must not change anything
+	chain.revertContractStats(chain.db, blocks[1:])
+	check(contractA, 0, 1, 3)
+
+	// Rewind the head and reimport the fork, which must not be counted twice
+	if err := chain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind the chain: %v", err)
+	}
+	check(contractB, 0, 1, 3)
+	if n, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("block %d: failed to reimport fork: %v", n, err)
+	}
+	check(contractA, 0, 1, 3)
+	check(contractB, 3, 1, 3)
+}
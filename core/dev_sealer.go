@@ -0,0 +1,324 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// errDevMinerStopped is returned if a block is requested from a stopped
+	// development miner.
+	errDevMinerStopped = errors.New("dev miner stopped")
+
+	// errDevNothingToSeal is returned if a block was requested on the arrival
+	// of transactions, but none of them could be included.
+	errDevNothingToSeal = errors.New("no transactions to seal")
+)
+
+// DevSealer is a consensus engine for development networks. It inherits all the
+// header and block rules of its base engine, but seals blocks instantly without
+// any proof or signature.
+type DevSealer struct {
+	consensus.Engine
+}
+
+// NewDevSealer creates a development sealer on top of the given base engine. If
+// no base engine is given, a fake ethash engine is used.
+func NewDevSealer(base consensus.Engine) *DevSealer {
+	if base == nil {
+		base = ethash.NewFaker()
+	}
+	return &DevSealer{Engine: base}
+}
+
+// Seal implements consensus.Engine, returning the block as is.
+func (s *DevSealer) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	go func() {
+		select {
+		case results <- block:
+		case <-stop:
+		}
+	}()
+	return nil
+}
+
+// DevTxSource is the transaction source of the development miner, usually an
+// adapter around the transaction pool.
+type DevTxSource interface {
+	// Pending retrieves the currently executable transactions, grouped by sender
+	// and ordered by nonce.
+	Pending() map[common.Address][]*types.Transaction
+
+	// SubscribeTransactions subscribes to new transaction events.
+	SubscribeTransactions(ch chan<- NewTxsEvent, reorgs bool) event.Subscription
+}
+
+// DevMinerConfig contains the settings of the development miner.
+type DevMinerConfig struct {
+	Coinbase common.Address // Address to credit block rewards to
+	Period   time.Duration  // Interval to seal (possibly empty) blocks at, 0 = only on transactions
+}
+
+// DevMiner is a block production driver for development networks. It seals a
+// new block on top of the chain head whenever new transactions arrive, and at
+// a fixed period if configured, removing the need for the miner package.
+//
+// Blocks are built deterministically: transactions are ordered by sender address
+// and nonce, and timestamps only depend on the parent and the wall clock.
+type DevMiner struct {
+	chain  *BlockChain
+	source DevTxSource
+	config DevMinerConfig
+
+	lock sync.Mutex // Serializes block production
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDevMiner creates a development miner sealing blocks onto the given chain.
+// The chain's engine is used to finalize and seal the blocks, which should be
+// a DevSealer unless the embedder provides sealing of its own.
+func NewDevMiner(chain *BlockChain, source DevTxSource, config DevMinerConfig) *DevMiner {
+	return &DevMiner{
+		chain:  chain,
+		source: source,
+		config: config,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start launches the background sealing loop.
+func (m *DevMiner) Start() {
+	txs := make(chan NewTxsEvent, 16)
+	sub := m.source.SubscribeTransactions(txs, true)
+
+	m.wg.Add(1)
+	go m.loop(txs, sub)
+}
+
+// Stop terminates the background sealing loop and waits for any block being
+// sealed to be imported.
+func (m *DevMiner) Stop() {
+	select {
+	case <-m.quit:
+	default:
+		close(m.quit)
+	}
+	m.wg.Wait()
+}
+
+// Seal builds, seals and imports a block with all the pending transactions,
+// even if there are none.
+func (m *DevMiner) Seal() (*types.Block, error) {
+	return m.seal(true)
+}
+
+// loop is the background sealing loop, building blocks on new transactions and
+// timer ticks.
+func (m *DevMiner) loop(txs chan NewTxsEvent, sub event.Subscription) {
+	defer m.wg.Done()
+	defer sub.Unsubscribe()
+
+	var tick <-chan time.Time
+	if m.config.Period > 0 {
+		ticker := time.NewTicker(m.config.Period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-txs:
+			// Keep sealing until the pending transactions are exhausted, which
+			// might take multiple blocks if the gas limit is reached.
+			for {
+				block, err := m.seal(false)
+				if err != nil {
+					if !errors.Is(err, errDevNothingToSeal) {
+						log.Warn("Failed to seal dev block", "err", err)
+					}
+					break
+				}
+				if len(m.source.Pending()) == 0 {
+					break
+				}
+				log.Debug("Dev block sealed with transactions left", "number", block.NumberU64())
+			}
+		case <-tick:
+			if _, err := m.seal(true); err != nil {
+				log.Warn("Failed to seal dev block", "err", err)
+			}
+		case <-sub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// seal builds a block on top of the current head containing as many pending
+// transactions as fit and imports it into the chain.
+func (m *DevMiner) seal(allowEmpty bool) (*types.Block, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	select {
+	case <-m.quit:
+		return nil, errDevMinerStopped
+	default:
+	}
+	var (
+		chain  = m.chain
+		config = chain.Config()
+		engine = chain.Engine()
+		parent = chain.CurrentBlock()
+	)
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := max(parent.Time+1, uint64(time.Now().Unix()))
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   m.config.Coinbase,
+		GasLimit:   CalcGasLimit(parent.GasLimit, parent.GasLimit),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       timestamp,
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent)
+		if !config.IsLondon(parent.Number) {
+			parentGasLimit := parent.GasLimit * config.ElasticityMultiplier()
+			header.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
+		}
+	}
+	if limit, ok := config.ScheduledGasLimit(header.Number); ok {
+		header.GasLimit = limit
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		excessBlobGas := eip4844.CalcExcessBlobGas(config, parent, header.Time)
+		header.ExcessBlobGas = &excessBlobGas
+		header.BlobGasUsed = new(uint64)
+		header.ParentBeaconRoot = new(common.Hash)
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	vmenv := vm.NewEVM(NewEVMBlockContext(header, chain, &header.Coinbase), statedb, config, vm.Config{})
+	if header.ParentBeaconRoot != nil {
+		ProcessBeaconBlockRoot(*header.ParentBeaconRoot, vmenv)
+	}
+	if config.IsPrague(header.Number, header.Time) {
+		ProcessParentBlockHash(header.ParentHash, vmenv)
+	}
+	// Include the pending transactions in a deterministic order, dropping the
+	// rest of a sender's transactions on the first failure.
+	pending := m.source.Pending()
+	senders := make([]common.Address, 0, len(pending))
+	for sender := range pending {
+		senders = append(senders, sender)
+	}
+	slices.SortFunc(senders, func(a, b common.Address) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	var (
+		gp       = new(GasPool).AddGas(header.GasLimit)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	for _, sender := range senders {
+		for _, tx := range pending[sender] {
+			if tx.Type() == types.BlobTxType || tx.Gas() > gp.Gas() {
+				break
+			}
+			var (
+				snap = statedb.Snapshot()
+				gas  = gp.Gas()
+			)
+			statedb.SetTxContext(tx.Hash(), len(txs))
+			receipt, err := ApplyTransaction(vmenv, gp, statedb, header, tx, &header.GasUsed, NewReceiptBloomGenerator())
+			if err != nil {
+				log.Debug("Skipping dev transaction", "hash", tx.Hash(), "sender", sender, "err", err)
+				statedb.RevertToSnapshot(snap)
+				gp.SetGas(gas)
+				break
+			}
+			txs = append(txs, tx)
+			receipts = append(receipts, receipt)
+		}
+	}
+	if len(txs) == 0 && !allowEmpty {
+		return nil, errDevNothingToSeal
+	}
+	if config.IsPrague(header.Number, header.Time) && config.Parlia == nil {
+		requests := [][]byte{}
+		var logs []*types.Log
+		for _, receipt := range receipts {
+			logs = append(logs, receipt.Logs...)
+		}
+		if err := ParseDepositLogs(&requests, logs, config); err != nil {
+			return nil, err
+		}
+		ProcessWithdrawalQueue(&requests, vmenv)
+		ProcessConsolidationQueue(&requests, vmenv)
+		reqHash := types.CalcRequestsHash(requests)
+		header.RequestsHash = &reqHash
+	}
+	body := &types.Body{Transactions: txs}
+	if header.EmptyWithdrawalsHash() {
+		body.Withdrawals = make([]*types.Withdrawal, 0)
+	}
+	block, _, err := engine.FinalizeAndAssemble(chain, header, statedb, body, receipts, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Seal the block and import it, waiting on the engine for the result
+	var (
+		results = make(chan *types.Block, 1)
+		stop    = make(chan struct{})
+	)
+	defer close(stop)
+
+	if err := engine.Seal(chain, block, results, stop); err != nil {
+		return nil, err
+	}
+	select {
+	case block = <-results:
+	case <-m.quit:
+		return nil, errDevMinerStopped
+	}
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		return nil, err
+	}
+	log.Info("Sealed dev block", "number", block.NumberU64(), "hash", block.Hash(), "txs", len(txs), "gas", block.GasUsed())
+	return block, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testDevTxSource is a minimal transaction source dropping the transactions
+// already included in the chain.
+type testDevTxSource struct {
+	chain *BlockChain
+	txs   map[common.Address][]*types.Transaction
+	feed  event.Feed
+	lock  sync.Mutex
+}
+
+func (s *testDevTxSource) add(sender common.Address, tx *types.Transaction) {
+	s.lock.Lock()
+	s.txs[sender] = append(s.txs[sender], tx)
+	s.lock.Unlock()
+
+	s.feed.Send(NewTxsEvent{Txs: []*types.Transaction{tx}})
+}
+
+func (s *testDevTxSource) Pending() map[common.Address][]*types.Transaction {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	statedb, err := s.chain.State()
+	if err != nil {
+		return nil
+	}
+	pending := make(map[common.Address][]*types.Transaction)
+	for sender, txs := range s.txs {
+		for _, tx := range txs {
+			if tx.Nonce() < statedb.GetNonce(sender) {
+				continue
+			}
+			pending[sender] = append(pending[sender], tx)
+		}
+	}
+	return pending
+}
+
+func (s *testDevTxSource) SubscribeTransactions(ch chan<- NewTxsEvent, reorgs bool) event.Subscription {
+	return s.feed.Subscribe(ch)
+}
+
+// Tests that the dev miner seals blocks on transaction arrival and on request.
+func TestDevMiner(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		config  = params.TestChainConfig
+		signer  = types.LatestSigner(config)
+		genesis = &Genesis{
+			Config: config,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, NewDevSealer(nil), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	source := &testDevTxSource{chain: chain, txs: make(map[common.Address][]*types.Transaction)}
+	miner := NewDevMiner(chain, source, DevMinerConfig{Coinbase: common.Address{0xc0}})
+
+	// Requested blocks are sealed even if empty
+	block, err := miner.Seal()
+	if err != nil {
+		t.Fatalf("failed to seal empty block: %v", err)
+	}
+	if block.NumberU64() != 1 || chain.CurrentBlock().Hash() != block.Hash() {
+		t.Fatalf("empty block not imported: have #%d, head #%d", block.NumberU64(), chain.CurrentBlock().Number)
+	}
+	// Transactions trigger sealing automatically
+	miner.Start()
+	defer miner.Stop()
+
+	heads := make(chan ChainHeadEvent, 16)
+	sub := chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee*2), nil), signer, key)
+		source.add(addr, tx)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-heads:
+			statedb, _ := chain.State()
+			if statedb.GetNonce(addr) == 3 {
+				if balance := statedb.GetBalance(common.Address{0xaa}); balance.Uint64() != 3 {
+					t.Fatalf("recipient balance mismatch: have %v, want 3", balance)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatalf("transactions not sealed, head #%d", chain.CurrentBlock().Number)
+		}
+	}
+}
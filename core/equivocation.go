@@ -0,0 +1,97 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxEquivocationRange is the maximum number of heights the recorded
+// equivocation evidence can be retrieved for at once.
+const maxEquivocationRange = 100_000
+
+var equivocationMeter = metrics.NewRegisteredMeter("chain/equivocations", nil)
+
+// EquivocationEvent is posted when a validator is found sealing two different
+// headers at the same height, e.g. for slashing tooling to submit the evidence.
+type EquivocationEvent struct {
+	Evidence *types.Equivocation
+}
+
+// SubscribeEquivocationEvent registers a subscription of EquivocationEvent.
+func (bc *BlockChain) SubscribeEquivocationEvent(ch chan<- EquivocationEvent) event.Subscription {
+	return bc.scope.Track(bc.equivocationFeed.Subscribe(ch))
+}
+
+// checkEquivocation looks for a header already known at the height of the given
+// verified one, sealed by the same validator on top of the same parent, and if
+// found, records the evidence and announces it. Only the first equivocation of
+// a validator at a height is recorded.
+//
+// The check is limited to the PoSA engines, whose headers are sealed by their
+// coinbase, as verified by the engine.
+func (bc *BlockChain) checkEquivocation(header *types.Header) {
+	if _, ok := bc.engine.(consensus.PoSA); !ok {
+		return
+	}
+	var (
+		number = header.Number.Uint64()
+		hash   = header.Hash()
+	)
+	for _, other := range rawdb.ReadAllHashes(bc.db, number) {
+		if other == hash {
+			continue
+		}
+		sibling := bc.GetHeader(other, number)
+		if sibling == nil || sibling.Coinbase != header.Coinbase || sibling.ParentHash != header.ParentHash {
+			continue
+		}
+		if rawdb.ReadEquivocation(bc.db, number, header.Coinbase) != nil {
+			return
+		}
+		evidence := &types.Equivocation{
+			Signer:  header.Coinbase,
+			Header1: types.CopyHeader(sibling),
+			Header2: types.CopyHeader(header),
+		}
+		rawdb.WriteEquivocation(bc.db, evidence)
+		equivocationMeter.Mark(1)
+
+		log.Warn("Validator equivocation detected", "number", number, "signer", header.Coinbase, "first", other, "second", hash)
+		bc.equivocationFeed.Send(EquivocationEvent{Evidence: evidence})
+		return
+	}
+}
+
+// GetEquivocations retrieves the equivocation evidence recorded for the heights
+// within the given range, ordered by height.
+func (bc *BlockChain) GetEquivocations(from, to uint64) ([]*types.Equivocation, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range: %d > %d", from, to)
+	}
+	if to-from >= maxEquivocationRange {
+		return nil, fmt.Errorf("range too large: %d heights, max %d", to-from+1, maxEquivocationRange)
+	}
+	return rawdb.ReadEquivocations(bc.db, from, to), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// fakePoSA is an ethash faker posing as a PoSA engine, without any of the PoSA
+// specific rules.
+type fakePoSA struct {
+	consensus.Engine
+}
+
+var _ consensus.PoSA = fakePoSA{}
+
+func (fakePoSA) IsSystemTransaction(*types.Transaction, *types.Header) (bool, error) {
+	return false, nil
+}
+func (fakePoSA) IsSystemContract(*common.Address) bool                             { return false }
+func (fakePoSA) EnoughDistance(consensus.ChainReader, *types.Header) bool          { return true }
+func (fakePoSA) IsLocalBlock(*types.Header) bool                                   { return false }
+func (fakePoSA) VerifyVote(consensus.ChainHeaderReader, *types.VoteEnvelope) error { return nil }
+func (fakePoSA) GetJustifiedNumberAndHash(consensus.ChainHeaderReader, []*types.Header) (uint64, common.Hash, error) {
+	return 0, common.Hash{}, nil
+}
+func (fakePoSA) GetFinalizedHeader(consensus.ChainHeaderReader, *types.Header) *types.Header {
+	return nil
+}
+func (fakePoSA) IsActiveValidatorAt(consensus.ChainHeaderReader, *types.Header, func(*types.BLSPublicKey) bool) bool {
+	return true
+}
+func (fakePoSA) NextProposalBlock(consensus.ChainHeaderReader, *types.Header, common.Address) (uint64, uint64, error) {
+	return 0, 0, nil
+}
+
+// Tests that two headers sealed by the same validator at the same height are
+// recorded as equivocation evidence and announced once.
+func TestEquivocation(t *testing.T) {
+	var (
+		genesis   = &Genesis{Config: params.TestChainConfig}
+		validator = common.Address{0xaa}
+		engine    = fakePoSA{ethash.NewFaker()}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan EquivocationEvent, 2)
+	sub := chain.SubscribeEquivocationEvent(events)
+	defer sub.Unsubscribe()
+
+	parent := chain.Genesis().Header()
+	sealed := func(coinbase common.Address, extra string) *types.Header {
+		header := &types.Header{ParentHash: parent.Hash(), Number: common.Big1, Coinbase: coinbase, Difficulty: common.Big1, Extra: []byte(extra)}
+		rawdb.WriteHeader(chain.db, header)
+		return header
+	}
+	first := sealed(validator, "first")
+	sealed(common.Address{0xbb}, "other validator")
+
+	chain.checkEquivocation(first)
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected equivocation: %+v", ev.Evidence)
+	default:
+	}
+	second := sealed(validator, "second")
+	chain.checkEquivocation(second)
+
+	select {
+	case ev := <-events:
+		if ev.Evidence.Signer != validator || ev.Evidence.Header1.Hash() != first.Hash() || ev.Evidence.Header2.Hash() != second.Hash() {
+			t.Fatalf("evidence mismatch: %+v", ev.Evidence)
+		}
+	default:
+		t.Fatal("equivocation not announced")
+	}
+	chain.checkEquivocation(sealed(validator, "third"))
+	select {
+	case ev := <-events:
+		t.Fatalf("equivocation announced twice: %+v", ev.Evidence)
+	default:
+	}
+	evidences, err := chain.GetEquivocations(0, 10)
+	if err != nil {
+		t.Fatalf("failed to retrieve evidence: %v", err)
+	}
+	if len(evidences) != 1 || evidences[0].Header2.Hash() != second.Hash() {
+		t.Fatalf("recorded evidence mismatch: %v", evidences)
+	}
+}
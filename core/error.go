@@ -36,6 +36,10 @@ var (
 
 	// ErrCurrentBlockNotFound is returned when current block not found.
 	ErrCurrentBlockNotFound = errors.New("current block not found")
+
+	// ErrShutdownDeadline is returned when the blockchain shutdown is still
+	// persisting data after the deadline given to StopWithContext.
+	ErrShutdownDeadline = errors.New("blockchain shutdown deadline exceeded")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
@@ -113,6 +117,10 @@ var (
 	// ErrSenderNoEOA is returned if the sender of a transaction is a contract.
 	ErrSenderNoEOA = errors.New("sender not an eoa")
 
+	// ErrAddressFiltered is returned if a transaction is sent from or to an
+	// address filtered by the chain.
+	ErrAddressFiltered = errors.New("address filtered")
+
 	// -- EIP-4844 errors --
 
 	// ErrBlobFeeCapTooLow is returned if the transaction fee cap is less than the
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -26,6 +27,18 @@ type NewTxsEvent struct{ Txs []*types.Transaction }
 // ReannoTxsEvent is posted when a batch of local pending transactions exceed a specified duration.
 type ReannoTxsEvent struct{ Txs []*types.Transaction }
 
+// ReinjectTxsEvent is posted when the transaction pool processed the
+// transactions dropped from the canonical chain by a reorg. Reinjected lists
+// the transactions returned to the pool, in reinjection order: by position in
+// the dropped chain, oldest block first, then by nonce. Dropped lists the ones
+// neither reinjected nor included in the new chain, in the same order.
+type ReinjectTxsEvent struct {
+	OldHead    *types.Header
+	NewHead    *types.Header
+	Reinjected []common.Hash
+	Dropped    []common.Hash
+}
+
 // NewSealedBlockEvent is posted when a block has been sealed.
 type NewSealedBlockEvent struct{ Block *types.Block }
 
@@ -50,3 +63,40 @@ type ChainHeadEvent struct {
 }
 
 type HighestVerifiedBlockEvent struct{ Header *types.Header }
+
+// PreCommitBlockEvent is posted when an imported block has passed all of its
+// validation, before it and its state are written to the database. It allows
+// the block to be announced while the commit is still running, but gives no
+// guarantee beyond validity: the commit may still fail, in which case the block
+// is dropped, and the block may never become canonical. Subscribers must not
+// expect the block to be retrievable from the chain yet.
+//
+// The event is sent synchronously on the import path, subscribers should use
+// buffered channels.
+type PreCommitBlockEvent struct{ Block *types.Block }
+
+// BlobSidecarsEvent is posted when a block carrying blob sidecars becomes
+// canonical, either as the new head or as part of a reorg.
+type BlobSidecarsEvent struct {
+	Header   *types.Header
+	Sidecars types.BlobSidecars
+}
+
+// ReorgEvent is posted when the canonical chain is reorganised, carrying the
+// full diff to roll back and replay. Both chains are ordered from the common
+// ancestor upwards, the last block of NewChain being the new head. The event
+// is sent before the new head is set, ChainHeadEvent follows once it is.
+type ReorgEvent struct {
+	CommonAncestor *types.Header
+	OldChain       []*types.Header      // Canonical blocks dropped
+	NewChain       []*types.Header      // Blocks becoming canonical
+	RemovedTxs     []*types.Transaction // Transactions of the dropped blocks, in chain order
+	AddedTxs       []*types.Transaction // Transactions of the added blocks, in chain order
+}
+
+// ReorgDumpEvent is posted when a post-mortem artifact of a deep reorg has
+// been written to disk.
+type ReorgDumpEvent struct {
+	Path     string
+	Artifact *ReorgArtifact
+}
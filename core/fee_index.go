@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlockFees returns the base fee burnt by the given block and the blob fee paid
+// by its transactions. The blob fee is burnt as well, except on Parlia chains
+// where it is rewarded to the validators.
+func BlockFees(config *params.ChainConfig, header *types.Header) (burnt *big.Int, blob *big.Int) {
+	burnt, blob = new(big.Int), new(big.Int)
+	if header.BaseFee != nil {
+		burnt.Mul(header.BaseFee, new(big.Int).SetUint64(header.GasUsed))
+	}
+	if header.ExcessBlobGas != nil && header.BlobGasUsed != nil {
+		blob.Mul(eip4844.CalcBlobFee(config, header), new(big.Int).SetUint64(*header.BlobGasUsed))
+	}
+	return burnt, blob
+}
+
+// FeeTotals returns the base fees burnt and the blob fees paid by the canonical
+// blocks in the inclusive range [from, to]. The sums are derived from the
+// cumulative totals indexed for every executed block.
+func (bc *BlockChain) FeeTotals(from, to uint64) (burnt *big.Int, blob *big.Int, err error) {
+	if from > to {
+		return nil, nil, fmt.Errorf("invalid fee range [%d, %d]", from, to)
+	}
+	burnt, blob, err = bc.cumulativeFees(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from == 0 {
+		return burnt, blob, nil
+	}
+	prevBurnt, prevBlob, err := bc.cumulativeFees(from - 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return burnt.Sub(burnt, prevBurnt), blob.Sub(blob, prevBlob), nil
+}
+
+// cumulativeFees returns the fee totals of all blocks up to and including the
+// canonical block with the given number.
+func (bc *BlockChain) cumulativeFees(number uint64) (*big.Int, *big.Int, error) {
+	burnt, blob := rawdb.ReadFeeTotals(bc.db, bc.GetCanonicalHash(number), number)
+	if burnt == nil || blob == nil {
+		return nil, nil, fmt.Errorf("fee totals of block %d unavailable", number)
+	}
+	return burnt, blob, nil
+}
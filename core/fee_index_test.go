@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the fee index answers range sums of the burnt base fees.
+func TestFeeTotals(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, func(i int, b *BlockGen) {
+		for j := 0; j <= i; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	burnt := func(from, to int) *big.Int {
+		sum := new(big.Int)
+		for _, block := range blocks[from-1 : to] {
+			sum.Add(sum, new(big.Int).Mul(block.BaseFee(), new(big.Int).SetUint64(block.GasUsed())))
+		}
+		return sum
+	}
+	for _, r := range [][2]int{{1, 4}, {2, 2}, {2, 4}} {
+		have, blob, err := chain.FeeTotals(uint64(r[0]), uint64(r[1]))
+		if err != nil {
+			t.Fatalf("range %v: failed to sum fees: %v", r, err)
+		}
+		if want := burnt(r[0], r[1]); have.Cmp(want) != 0 || have.Sign() == 0 {
+			t.Errorf("range %v: burnt fee mismatch: have %v, want %v", r, have, want)
+		}
+		if blob.Sign() != 0 {
+			t.Errorf("range %v: blob fee mismatch: have %v, want 0", r, blob)
+		}
+	}
+	if have, _, err := chain.FeeTotals(0, 4); err != nil || have.Cmp(burnt(1, 4)) != 0 {
+		t.Errorf("range from genesis mismatch: have %v (%v), want %v", have, err, burnt(1, 4))
+	}
+	if _, _, err := chain.FeeTotals(3, 2); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	if _, _, err := chain.FeeTotals(1, 5); err == nil {
+		t.Errorf("range beyond the head accepted")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// FutureBlockConfig contains the settings of the queue holding blocks which
+// are ahead of the local clock, or descend from such blocks.
+type FutureBlockConfig struct {
+	MaxSkew  time.Duration // Maximum time a block may be ahead of the local clock to be queued
+	Limit    int           // Maximum number of queued blocks, the least recently added are evicted
+	Interval time.Duration // Time between attempts to insert the queued blocks
+}
+
+// DefaultFutureBlockConfig is the future block queue configuration used if
+// none is set.
+var DefaultFutureBlockConfig = FutureBlockConfig{
+	MaxSkew:  maxTimeFutureBlocks * time.Second,
+	Limit:    maxFutureBlocks,
+	Interval: 5 * time.Second,
+}
+
+// WithFutureBlocks returns a BlockChainOption which configures the queue of
+// future blocks.
+func WithFutureBlocks(config FutureBlockConfig) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if config.MaxSkew < 0 {
+			return nil, fmt.Errorf("invalid future block skew %v", config.MaxSkew)
+		}
+		if config.Limit <= 0 {
+			return nil, fmt.Errorf("invalid future block limit %d", config.Limit)
+		}
+		if config.Interval <= 0 {
+			return nil, fmt.Errorf("invalid future block interval %v", config.Interval)
+		}
+		bc.futureConfig = config
+		bc.futureBlocks = lru.NewCache[common.Hash, *types.Block](config.Limit)
+		return bc, nil
+	}
+}
+
+// FutureBlockEvent is posted when a queued future block becomes eligible and
+// is about to be inserted.
+type FutureBlockEvent struct{ Block *types.Block }
+
+// SubscribeFutureBlockEvent registers a subscription of FutureBlockEvent.
+func (bc *BlockChain) SubscribeFutureBlockEvent(ch chan<- FutureBlockEvent) event.Subscription {
+	return bc.scope.Track(bc.futureBlockFeed.Subscribe(ch))
+}
+
+// FutureBlocks returns the blocks waiting in the future queue, ordered by
+// number.
+func (bc *BlockChain) FutureBlocks() []*types.Block {
+	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
+	for _, hash := range bc.futureBlocks.Keys() {
+		if block, exist := bc.futureBlocks.Peek(hash); exist {
+			blocks = append(blocks, block)
+		}
+	}
+	slices.SortFunc(blocks, func(a, b *types.Block) int {
+		return a.Number().Cmp(b.Number())
+	})
+	return blocks
+}
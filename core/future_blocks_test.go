@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that blocks ahead of the clock are queued within the configured skew,
+// and rejected beyond it.
+func TestFutureBlockSkew(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig, Timestamp: uint64(time.Now().Unix())}
+		config  = FutureBlockConfig{MaxSkew: time.Minute, Limit: 8, Interval: time.Hour}
+	)
+	_, near, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		b.OffsetTime(30)
+	})
+	_, far, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		b.OffsetTime(300)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, WithFutureBlocks(config))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(near); err != nil {
+		t.Fatalf("failed to queue near future block: %v", err)
+	}
+	if _, err := chain.InsertChain(far); err == nil {
+		t.Fatal("far future block accepted")
+	}
+	queued := chain.FutureBlocks()
+	if len(queued) != 1 || queued[0].Hash() != near[0].Hash() {
+		t.Fatalf("queued blocks mismatch: have %d", len(queued))
+	}
+	// The block is not yet eligible and must stay queued
+	chain.procFutureBlocks()
+	if head := chain.CurrentBlock().Number.Uint64(); head != 0 {
+		t.Errorf("future block imported early")
+	}
+	if _, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil,
+		WithFutureBlocks(FutureBlockConfig{MaxSkew: time.Minute, Interval: time.Second})); err == nil {
+		t.Error("zero queue limit accepted")
+	}
+}
+
+// Tests that queued blocks are announced and inserted once eligible.
+func TestFutureBlockDrip(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 3, nil)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan FutureBlockEvent, len(blocks))
+	sub := chain.SubscribeFutureBlockEvent(events)
+	defer sub.Unsubscribe()
+
+	// Queue the blocks out of order, they must be dripped in by number
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := chain.addFutureBlock(blocks[i]); err != nil {
+			t.Fatalf("failed to queue block %d: %v", i, err)
+		}
+	}
+	chain.procFutureBlocks()
+
+	for i, block := range blocks {
+		select {
+		case ev := <-events:
+			if ev.Block.Hash() != block.Hash() {
+				t.Errorf("event %d mismatch: have %d, want %d", i, ev.Block.NumberU64(), block.NumberU64())
+			}
+		default:
+			t.Fatalf("event %d missing", i)
+		}
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Errorf("head mismatch: have %x, want %x", head, blocks[len(blocks)-1].Hash())
+	}
+	if queued := chain.FutureBlocks(); len(queued) != 0 {
+		t.Errorf("inserted blocks still queued: %d", len(queued))
+	}
+}
@@ -204,6 +204,17 @@ func flushAlloc(ga *types.GenesisAlloc, triedb *triedb.Database) (common.Hash, e
 	return root, nil
 }
 
+// allocSupply returns the total native supply of the genesis allocations.
+func allocSupply(ga types.GenesisAlloc) *big.Int {
+	supply := new(big.Int)
+	for _, account := range ga {
+		if account.Balance != nil {
+			supply.Add(supply, account.Balance)
+		}
+	}
+	return supply
+}
+
 func getGenesisState(db ethdb.Database, blockhash common.Hash) (alloc types.GenesisAlloc, err error) {
 	blob := rawdb.ReadGenesisStateSpec(db, blockhash)
 	if len(blob) != 0 {
@@ -363,11 +374,14 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *triedb.Database, g
 		if err := overrides.apply(genesis.Config); err != nil {
 			return nil, common.Hash{}, nil, err
 		}
-
-		if hash := genesis.ToBlock().Hash(); hash != ghash {
+		block, err := genesis.toBlock()
+		if err != nil {
+			return nil, common.Hash{}, nil, err
+		}
+		if hash := block.Hash(); hash != ghash {
 			return nil, common.Hash{}, nil, &GenesisMismatchError{ghash, hash}
 		}
-		block, err := genesis.Commit(db, triedb)
+		block, err = genesis.Commit(db, triedb)
 		if err != nil {
 			return nil, common.Hash{}, nil, err
 		}
@@ -380,8 +394,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *triedb.Database, g
 		if err := overrides.apply(genesis.Config); err != nil {
 			return nil, common.Hash{}, nil, err
 		}
-
-		if hash := genesis.ToBlock().Hash(); hash != ghash {
+		block, err := genesis.toBlock()
+		if err != nil {
+			return nil, common.Hash{}, nil, err
+		}
+		if hash := block.Hash(); hash != ghash {
 			return nil, common.Hash{}, nil, &GenesisMismatchError{ghash, hash}
 		}
 	}
@@ -441,8 +458,14 @@ func LoadChainConfig(db ethdb.Database, genesis *Genesis) (*params.ChainConfig,
 		// config is missing(initialize the empty leveldb with an
 		// external ancient chain segment), ensure the provided genesis
 		// is matched.
-		if stored != (common.Hash{}) && genesis.ToBlock().Hash() != stored {
-			return nil, common.Hash{}, &GenesisMismatchError{stored, genesis.ToBlock().Hash()}
+		if stored != (common.Hash{}) {
+			block, err := genesis.toBlock()
+			if err != nil {
+				return nil, common.Hash{}, err
+			}
+			if hash := block.Hash(); hash != stored {
+				return nil, common.Hash{}, &GenesisMismatchError{stored, hash}
+			}
 		}
 		return genesis.Config, stored, nil
 	}
@@ -471,13 +494,29 @@ func (g *Genesis) IsVerkle() bool {
 	return g.Config.IsVerkleGenesis()
 }
 
-// ToBlock returns the genesis block according to genesis specification.
+// ToBlock returns the genesis block according to genesis specification. It
+// panics if the specification is invalid, e.g. a contract constructor fails,
+// so genesis specifications from untrusted sources must be checked first.
 func (g *Genesis) ToBlock() *types.Block {
-	root, err := hashAlloc(&g.Alloc, g.IsVerkle())
+	block, err := g.toBlock()
 	if err != nil {
 		panic(err)
 	}
-	return g.toBlockWithRoot(root)
+	return block
+}
+
+// toBlock returns the genesis block according to genesis specification, or an
+// error if the specification is invalid.
+func (g *Genesis) toBlock() (*types.Block, error) {
+	alloc, err := g.resolveAlloc()
+	if err != nil {
+		return nil, err
+	}
+	root, err := hashAlloc(&alloc, g.IsVerkle())
+	if err != nil {
+		return nil, err
+	}
+	return g.toBlockWithRoot(root), nil
 }
 
 // toBlockWithRoot constructs the genesis block with the given genesis state root.
@@ -572,20 +611,27 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *triedb.Database) (*types.Blo
 	if config.Clique != nil && len(g.ExtraData) < 32+crypto.SignatureLength {
 		return nil, errors.New("can't start clique chain without signers")
 	}
-	// flush the data to disk and compute the state root
-	root, err := flushAlloc(&g.Alloc, triedb)
+	// Execute any contract constructors, flush the data to disk and compute
+	// the state root
+	alloc, err := g.resolveAlloc()
+	if err != nil {
+		return nil, err
+	}
+	root, err := flushAlloc(&alloc, triedb)
 	if err != nil {
 		return nil, err
 	}
 	block := g.toBlockWithRoot(root)
 
-	// Marshal the genesis state specification and persist.
-	blob, err := json.Marshal(g.Alloc)
+	// Marshal the deployed genesis state specification and persist.
+	blob, err := json.Marshal(alloc)
 	if err != nil {
 		return nil, err
 	}
 	rawdb.WriteGenesisStateSpec(db, block.Hash(), blob)
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty())
+	rawdb.WriteTotalSupply(db, block.Hash(), block.NumberU64(), allocSupply(alloc))
+	rawdb.WriteFeeTotals(db, block.Hash(), block.NumberU64(), new(big.Int), new(big.Int))
 	rawdb.WriteBlock(db, block)
 	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
 	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
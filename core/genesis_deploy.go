@@ -0,0 +1,270 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// genesisConstructorGas is the gas allowance granted to each genesis contract
+// constructor. It's deliberately generous, genesis allocations are trusted.
+const genesisConstructorGas = 1_000_000_000
+
+// GenesisAllocBuilder assembles a genesis allocation programmatically, allowing
+// contracts to be specified via their creation code instead of precomputed
+// runtime code and storage.
+type GenesisAllocBuilder struct {
+	alloc types.GenesisAlloc
+}
+
+// NewGenesisAllocBuilder creates an empty genesis allocation builder.
+func NewGenesisAllocBuilder() *GenesisAllocBuilder {
+	return &GenesisAllocBuilder{alloc: make(types.GenesisAlloc)}
+}
+
+// Fund credits the given balance to an account.
+func (b *GenesisAllocBuilder) Fund(addr common.Address, balance *big.Int) *GenesisAllocBuilder {
+	account := b.alloc[addr]
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	account.Balance = new(big.Int).Add(account.Balance, balance)
+	b.alloc[addr] = account
+	return b
+}
+
+// Account sets a fully specified account, overriding any previous entry.
+func (b *GenesisAllocBuilder) Account(addr common.Address, account types.Account) *GenesisAllocBuilder {
+	b.alloc[addr] = account
+	return b
+}
+
+// Deploy schedules a contract to be deployed at the given address by running
+// its creation code with the ABI encoded constructor arguments appended. Any
+// balance already assigned to the address is retained.
+func (b *GenesisAllocBuilder) Deploy(addr common.Address, code []byte, args []byte) *GenesisAllocBuilder {
+	account := b.alloc[addr]
+	account.Code = nil
+	account.Constructor = common.CopyBytes(code)
+	account.ConstructorArgs = common.CopyBytes(args)
+	b.alloc[addr] = account
+	return b
+}
+
+// Alloc returns the assembled genesis allocation, with the contract constructors
+// still pending. They are executed when the genesis block is created.
+func (b *GenesisAllocBuilder) Alloc() types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc, len(b.alloc))
+	for addr, account := range b.alloc {
+		alloc[addr] = account
+	}
+	return alloc
+}
+
+// Build executes the pending contract constructors against the given genesis
+// and returns the resulting allocation containing runtime code and storage only.
+func (b *GenesisAllocBuilder) Build(genesis *Genesis) (types.GenesisAlloc, error) {
+	g := *genesis
+	g.Alloc = b.Alloc()
+	return g.resolveAlloc()
+}
+
+// genesisStateDB wraps a state database to track the accounts and storage slots
+// modified by genesis constructors, as the results need to be exported back into
+// a plain genesis allocation.
+type genesisStateDB struct {
+	*state.StateDB
+	slots map[common.Address]map[common.Hash]struct{}
+}
+
+// touch marks an account as modified during constructor execution.
+func (db *genesisStateDB) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := db.slots[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		db.slots[addr] = slots
+	}
+	return slots
+}
+
+// CreateAccount implements vm.StateDB, tracking contracts created by constructors.
+func (db *genesisStateDB) CreateAccount(addr common.Address) {
+	db.touch(addr)
+	db.StateDB.CreateAccount(addr)
+}
+
+// SetCode implements vm.StateDB, tracking the accounts with modified code.
+func (db *genesisStateDB) SetCode(addr common.Address, code []byte) []byte {
+	db.touch(addr)
+	return db.StateDB.SetCode(addr, code)
+}
+
+// SetState implements vm.StateDB, tracking the modified storage slots.
+func (db *genesisStateDB) SetState(addr common.Address, key, value common.Hash) common.Hash {
+	db.touch(addr)[key] = struct{}{}
+	return db.StateDB.SetState(addr, key, value)
+}
+
+// resolveAlloc executes the constructors specified in the genesis allocation in
+// a throwaway EVM and returns an allocation with the constructors replaced by
+// the deployed runtime code and the storage they initialized. If there are no
+// constructors, the original allocation is returned as is.
+func (g *Genesis) resolveAlloc() (types.GenesisAlloc, error) {
+	var pending []common.Address
+	for addr, account := range g.Alloc {
+		if len(account.Constructor) > 0 {
+			if len(account.Code) > 0 {
+				return nil, fmt.Errorf("genesis account %x has both code and constructor", addr)
+			}
+			pending = append(pending, addr)
+		}
+	}
+	if len(pending) == 0 {
+		return g.Alloc, nil
+	}
+	// Constructors may interact with each other, execute them in a deterministic
+	// order on top of all the plain accounts.
+	slices.SortFunc(pending, func(a, b common.Address) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	sdb, err := state.New(types.EmptyRootHash, state.NewDatabase(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil), nil))
+	if err != nil {
+		return nil, err
+	}
+	statedb := &genesisStateDB{StateDB: sdb, slots: make(map[common.Address]map[common.Hash]struct{})}
+	for addr, account := range g.Alloc {
+		if account.Balance != nil {
+			statedb.AddBalance(addr, uint256.MustFromBig(account.Balance), tracing.BalanceIncreaseGenesisBalance)
+		}
+		statedb.StateDB.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce, tracing.NonceChangeGenesis)
+		for key, value := range account.Storage {
+			statedb.StateDB.SetState(addr, key, value)
+		}
+	}
+	config := g.Config
+	if config == nil {
+		config = params.AllEthashProtocolChanges
+	}
+	var (
+		context = g.constructorContext()
+		rules   = config.Rules(context.BlockNumber, context.Random != nil, context.Time)
+		evm     = vm.NewEVM(context, statedb, config, vm.Config{})
+	)
+	for _, addr := range pending {
+		account := g.Alloc[addr]
+
+		// Apply the same restrictions to the creation code and the deployed code
+		// as a regular contract creation would at the genesis block.
+		initcode := append(common.CopyBytes(account.Constructor), account.ConstructorArgs...)
+		if rules.IsShanghai && len(initcode) > params.MaxInitCodeSize {
+			return nil, fmt.Errorf("genesis constructor of %x: %w", addr, ErrMaxInitCodeSizeExceeded)
+		}
+		// Run the creation code in place of the runtime code, the returned blob
+		// is the code to be deployed.
+		statedb.StateDB.SetCode(addr, initcode)
+		code, _, err := evm.Call(vm.AccountRef(common.Address{}), addr, nil, genesisConstructorGas, common.U2560)
+		if err != nil {
+			return nil, fmt.Errorf("genesis constructor of %x failed: %w", addr, err)
+		}
+		if rules.IsEIP158 && len(code) > params.MaxCodeSize {
+			return nil, fmt.Errorf("genesis constructor of %x: %w", addr, vm.ErrMaxCodeSizeExceeded)
+		}
+		if rules.IsLondon && len(code) > 0 && code[0] == 0xEF {
+			return nil, fmt.Errorf("genesis constructor of %x: %w", addr, vm.ErrInvalidCode)
+		}
+		statedb.SetCode(addr, code)
+		if rules.IsEIP158 && account.Nonce == 0 {
+			statedb.SetNonce(addr, 1, tracing.NonceChangeNewContract)
+		}
+	}
+	// Export the post-deployment state of all the allocated and touched accounts
+	alloc := make(types.GenesisAlloc, len(g.Alloc))
+	for addr, account := range g.Alloc {
+		slots := statedb.touch(addr)
+		for key := range account.Storage {
+			slots[key] = struct{}{}
+		}
+	}
+	for addr, slots := range statedb.slots {
+		account := types.Account{
+			Code:       statedb.GetCode(addr),
+			Nonce:      statedb.GetNonce(addr),
+			Balance:    statedb.GetBalance(addr).ToBig(),
+			PrivateKey: g.Alloc[addr].PrivateKey,
+		}
+		for key := range slots {
+			if value := statedb.GetState(addr, key); value != (common.Hash{}) {
+				if account.Storage == nil {
+					account.Storage = make(map[common.Hash]common.Hash)
+				}
+				account.Storage[key] = value
+			}
+		}
+		if len(account.Code) == 0 {
+			account.Code = nil
+		}
+		if _, ok := g.Alloc[addr]; !ok && statedb.Empty(addr) {
+			continue // Touched by a reverted call, don't materialize
+		}
+		alloc[addr] = account
+	}
+	return alloc, nil
+}
+
+// constructorContext returns the block context genesis constructors are run in,
+// mirroring the header fields of the genesis block itself.
+func (g *Genesis) constructorContext() vm.BlockContext {
+	head := g.toBlockWithRoot(types.EmptyRootHash).Header()
+
+	ctx := vm.BlockContext{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Coinbase:    head.Coinbase,
+		GasLimit:    head.GasLimit,
+		BlockNumber: new(big.Int).Set(head.Number),
+		Time:        head.Time,
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int),
+		BlobBaseFee: new(big.Int),
+	}
+	if head.Difficulty != nil {
+		ctx.Difficulty.Set(head.Difficulty)
+	}
+	if head.BaseFee != nil {
+		ctx.BaseFee.Set(head.BaseFee)
+	}
+	if ctx.Difficulty.Sign() == 0 {
+		random := head.MixDigest
+		ctx.Random = &random
+	}
+	return ctx
+}
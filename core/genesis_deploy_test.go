@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Tests that contract constructors in the genesis allocation are executed and
+// the resulting runtime code and storage committed.
+func TestGenesisConstructors(t *testing.T) {
+	var (
+		// Constructor storing its 32 byte argument into slot 0 and deploying a
+		// runtime returning the content of slot 0.
+		initcode = hexutil.MustDecode("0x60206024600039600051600055600b6019600039600b6000f360005460005260206000f3")
+		runtime  = hexutil.MustDecode("0x60005460005260206000f3")
+		value    = common.HexToHash("0xcafebabe")
+		contract = common.HexToAddress("0xc0de")
+		funded   = common.HexToAddress("0xf00d")
+	)
+
+	builder := NewGenesisAllocBuilder().
+		Fund(funded, big.NewInt(params.Ether)).
+		Fund(contract, big.NewInt(1)).
+		Deploy(contract, initcode, value[:])
+
+	genesis := &Genesis{Config: params.TestChainConfig, Alloc: builder.Alloc()}
+	db := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(db, nil)
+	block, err := genesis.Commit(db, tdb)
+	if err != nil {
+		t.Fatalf("failed to commit genesis: %v", err)
+	}
+	if root := genesis.ToBlock().Root(); root != block.Root() {
+		t.Fatalf("genesis root mismatch: hashed %x, committed %x", root, block.Root())
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(tdb, nil))
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	if code := statedb.GetCode(contract); !bytes.Equal(code, runtime) {
+		t.Errorf("deployed code mismatch: have %x, want %x", code, runtime)
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != value {
+		t.Errorf("constructor storage mismatch: have %x, want %x", have, value)
+	}
+	if nonce := statedb.GetNonce(contract); nonce != 1 {
+		t.Errorf("contract nonce mismatch: have %d, want 1", nonce)
+	}
+	if balance := statedb.GetBalance(contract); balance.Uint64() != 1 {
+		t.Errorf("contract balance mismatch: have %v, want 1", balance)
+	}
+	if balance := statedb.GetBalance(funded); balance.ToBig().Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Errorf("funded balance mismatch: have %v, want %v", balance, params.Ether)
+	}
+	// The builder must produce the same state without any pending constructors
+	alloc, err := builder.Build(genesis)
+	if err != nil {
+		t.Fatalf("failed to build allocation: %v", err)
+	}
+	if len(alloc[contract].Constructor) != 0 || !bytes.Equal(alloc[contract].Code, runtime) {
+		t.Errorf("built allocation not deployed: %+v", alloc[contract])
+	}
+	if root := (&Genesis{Config: params.TestChainConfig, Alloc: alloc}).ToBlock().Root(); root != block.Root() {
+		t.Errorf("built allocation root mismatch: have %x, want %x", root, block.Root())
+	}
+}
+
+// Tests that invalid genesis constructors are rejected with an error, subject to
+// the same restrictions as a regular contract creation.
+func TestGenesisConstructorsInvalid(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0xc0de")
+		runtime  = hexutil.MustDecode("0x60005460005260206000f3")
+	)
+	tests := []struct {
+		name    string
+		account types.Account
+		err     error
+	}{
+		{
+			name:    "reverting constructor",
+			account: types.Account{Constructor: hexutil.MustDecode("0x60006000fd")},
+			err:     vm.ErrExecutionReverted,
+		},
+		{
+			name:    "code and constructor",
+			account: types.Account{Code: runtime, Constructor: hexutil.MustDecode("0x00")},
+		},
+		{
+			name:    "oversized initcode",
+			account: types.Account{Constructor: make([]byte, params.MaxInitCodeSize+1)},
+			err:     ErrMaxInitCodeSizeExceeded,
+		},
+		{
+			// Constructor deploying the single byte 0xef
+			name:    "0xef prefixed code",
+			account: types.Account{Constructor: hexutil.MustDecode("0x60ef60005360016000f3")},
+			err:     vm.ErrInvalidCode,
+		},
+	}
+	for _, tt := range tests {
+		genesis := &Genesis{Config: params.MergedTestChainConfig, Alloc: types.GenesisAlloc{contract: tt.account}}
+
+		// Both writing a fresh genesis and checking it against a stored one must
+		// fail gracefully.
+		db := rawdb.NewMemoryDatabase()
+		_, _, _, err := SetupGenesisBlock(db, triedb.NewDatabase(db, nil), genesis)
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: fresh genesis error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+		db = rawdb.NewMemoryDatabase()
+		(&Genesis{Config: params.MergedTestChainConfig}).MustCommit(db, triedb.NewDatabase(db, nil))
+		_, _, _, err = SetupGenesisBlock(db, triedb.NewDatabase(db, nil), genesis)
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: stored genesis error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
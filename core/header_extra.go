@@ -0,0 +1,68 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EnableExtraDataValidator returns a BlockChainOption which checks the extra-data
+// of every verified header with the given validator, after the consensus engine
+// accepted it.
+func EnableExtraDataValidator(validator consensus.ExtraDataValidator) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.hc.SetExtraDataValidator(validator)
+		return bc, nil
+	}
+}
+
+// SetExtraDataValidator sets the validator to check the extra-data of verified
+// headers with, or removes it if nil.
+func (hc *HeaderChain) SetExtraDataValidator(validator consensus.ExtraDataValidator) {
+	hc.extraValidator = validator
+}
+
+// validateExtra wraps the results of the engine's verification of headers,
+// additionally checking the extra-data of the headers accepted by the engine.
+// The returned abort channel must be closed by the caller, as with the engine.
+func (hc *HeaderChain) validateExtra(headers []*types.Header, abort chan<- struct{}, results <-chan error) (chan<- struct{}, <-chan error) {
+	if hc.extraValidator == nil {
+		return abort, results
+	}
+	var (
+		quit    = make(chan struct{})
+		checked = make(chan error, len(headers))
+	)
+	go func() {
+		defer close(abort)
+
+		for _, header := range headers {
+			select {
+			case <-quit:
+				return
+			case err := <-results:
+				if err == nil {
+					err = hc.extraValidator.ValidateExtra(hc.config, header)
+				}
+				checked <- err
+			}
+		}
+		<-quit
+	}()
+	return quit, checked
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests the checks of the extra-data policy.
+func TestExtraDataPolicy(t *testing.T) {
+	policy := &misc.ExtraDataPolicy{
+		Limits: []misc.ExtraDataLimit{{Block: 10, MaxSize: 100}, {Block: 20, MaxSize: 50}},
+		Vanity: 8,
+		Seal:   16,
+	}
+	tests := []struct {
+		number uint64
+		size   int
+		valid  bool
+	}{
+		{1, 200, true},  // unlimited before the first step
+		{10, 100, true}, // at the limit of the first step
+		{10, 101, false},
+		{19, 100, true},
+		{20, 100, false}, // limit lowered by the second step
+		{20, 24, true},   // vanity and seal present
+		{20, 23, false},  // seal incomplete
+		{20, 7, false},   // vanity incomplete
+	}
+	for i, tt := range tests {
+		header := &types.Header{Number: new(big.Int).SetUint64(tt.number), Extra: make([]byte, tt.size)}
+		if err := policy.ValidateExtra(params.TestChainConfig, header); (err == nil) != tt.valid {
+			t.Errorf("test %d: block #%d with %d bytes: have err %v, want valid %v", i, tt.number, tt.size, err, tt.valid)
+		}
+	}
+}
+
+// Tests that the extra-data validator is consulted when verifying both header
+// and block chains.
+func TestExtraDataValidator(t *testing.T) {
+	var (
+		genesis      = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		_, blocks, _ = GenerateChainWithGenesis(genesis, ethash.NewFaker(), 8, func(i int, b *BlockGen) {
+			b.SetExtra(make([]byte, 16))
+		})
+		policy = &misc.ExtraDataPolicy{Limits: []misc.ExtraDataLimit{{Block: 0, MaxSize: 32}, {Block: 5, MaxSize: 8}}}
+	)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	newChain := func() *BlockChain {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil, EnableExtraDataValidator(policy))
+		if err != nil {
+			t.Fatalf("failed to create blockchain: %v", err)
+		}
+		return chain
+	}
+	// Headers from the lowered limit onwards must be rejected
+	chain := newChain()
+	defer chain.Stop()
+
+	if n, err := chain.InsertHeaderChain(headers); err == nil || n != 4 {
+		t.Fatalf("oversized header extra-data not detected: index %d, err %v", n, err)
+	}
+	if _, err := chain.InsertHeaderChain(headers[:4]); err != nil {
+		t.Fatalf("failed to insert valid headers: %v", err)
+	}
+	// Blocks from the lowered limit onwards must be rejected
+	chain = newChain()
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err == nil || n != 4 {
+		t.Fatalf("oversized block extra-data not detected: index %d, err %v", n, err)
+	}
+	if have, want := chain.CurrentBlock().Hash(), blocks[3].Hash(); have != want {
+		t.Fatalf("head mismatch: have %x, want %x", have, want)
+	}
+	// Without a validator, the engine accepts the extra-data
+	plain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer plain.Stop()
+
+	if _, err := plain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+}
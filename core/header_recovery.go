@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var headerRecoverMeter = metrics.NewRegisteredMeter("chain/headers/recovered", nil)
+
+// EnableParallelHeaderVerification returns a BlockChainOption which recovers
+// the seal signers of header chains on the given number of workers, or one per
+// CPU if zero, while the engine verifies the header fields and ancestry. It
+// only takes effect if the engine seals headers with recoverable signatures.
+func EnableParallelHeaderVerification(workers int) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.hc.SetVerifyWorkers(workers)
+		return bc, nil
+	}
+}
+
+// SetVerifyWorkers sets the number of workers recovering seal signers ahead of
+// the header verification, or one per CPU if zero. Negative values disable the
+// parallel recovery.
+func (hc *HeaderChain) SetVerifyWorkers(workers int) {
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > 1 {
+		if _, ok := hc.engine.(consensus.SignerRecoverer); !ok {
+			log.Warn("Consensus engine can't recover seal signers, ignoring verification workers")
+			return
+		}
+	}
+	hc.verifyWorkers = workers
+}
+
+// recoverSigners starts recovering the signers of the headers flagged in seals
+// (or all if nil) in chain order, caching them in the engine. Failures are left
+// to the engine to report during verification. The returned wait group is done
+// once all headers were processed or abort was closed.
+func (hc *HeaderChain) recoverSigners(recoverer consensus.SignerRecoverer, chain []*types.Header, seals []bool, abort <-chan struct{}) *sync.WaitGroup {
+	var (
+		pend    = new(sync.WaitGroup)
+		next    atomic.Int64
+		workers = hc.verifyWorkers
+	)
+	if workers > len(chain) {
+		workers = len(chain)
+	}
+	for w := 0; w < workers; w++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+
+			for i := int(next.Add(1) - 1); i < len(chain); i = int(next.Add(1) - 1) {
+				select {
+				case <-abort:
+					return
+				default:
+				}
+				if seals != nil && !seals[i] {
+					continue
+				}
+				recoverer.RecoverSigner(chain[i])
+				headerRecoverMeter.Mark(1)
+			}
+		}()
+	}
+	return pend
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// signerRecoveringFaker is a fake engine recording the headers whose signers
+// were recovered.
+type signerRecoveringFaker struct {
+	consensus.Engine
+
+	lock      sync.Mutex
+	recovered map[uint64]int
+}
+
+func (e *signerRecoveringFaker) RecoverSigner(header *types.Header) (common.Address, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.recovered[header.Number.Uint64()]++
+	return header.Coinbase, nil
+}
+
+// Tests that the signers of header chains are recovered by the worker pool,
+// each flagged header exactly once, and that header chains import fine with
+// the parallel recovery enabled.
+func TestParallelHeaderVerification(t *testing.T) {
+	var (
+		genesis    = &Genesis{Config: params.TestChainConfig, BaseFee: common.Big1}
+		_, headers = makeHeaderChainWithGenesis(genesis, 192, ethash.NewFaker(), 1)
+		engine     = &signerRecoveringFaker{Engine: ethash.NewFaker(), recovered: make(map[uint64]int)}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableParallelHeaderVerification(4))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Recover a sampled subset of the seals and ensure nothing else is touched
+	seals := make([]bool, len(headers))
+	for i := range seals {
+		seals[i] = i%3 == 0
+	}
+	chain.hc.recoverSigners(engine, headers, seals, make(chan struct{})).Wait()
+	for i, header := range headers {
+		want := 0
+		if seals[i] {
+			want = 1
+		}
+		if have := engine.recovered[header.Number.Uint64()]; have != want {
+			t.Fatalf("header #%d: recovered %d times, want %d", header.Number, have, want)
+		}
+	}
+	// An aborted recovery must not touch any headers
+	engine.recovered = make(map[uint64]int)
+	abort := make(chan struct{})
+	close(abort)
+	chain.hc.recoverSigners(engine, headers, nil, abort).Wait()
+	if len(engine.recovered) != 0 {
+		t.Fatalf("aborted recovery processed %d headers", len(engine.recovered))
+	}
+	// Headers verified with the recovery running alongside import fine
+	if _, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	if head := chain.CurrentHeader().Number.Uint64(); head != uint64(len(headers)) {
+		t.Fatalf("head mismatch: have #%d, want #%d", head, len(headers))
+	}
+}
+
+// Tests that the verification workers are ignored if the engine can't recover
+// seal signers.
+func TestParallelHeaderVerificationUnsupported(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig, BaseFee: common.Big1}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil, EnableParallelHeaderVerification(4))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.hc.verifyWorkers != 0 {
+		t.Fatalf("verification workers enabled for unsupported engine: %d", chain.hc.verifyWorkers)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SealCheckPolicy configures spot checking of header seals during bulk imports
+// of trusted historical ranges. Within the trusted range only the seals of every
+// Frequency-th header and the first and last header of each batch are verified,
+// all other header rules are checked in full.
+type SealCheckPolicy struct {
+	Frequency uint64 // Verify the seal of every Nth header, 0 or 1 verifies all
+	Trusted   uint64 // Block number below which batches are spot checked
+}
+
+// SpotCheckSeals returns a BlockChainOption which spot checks the seals of header
+// batches below the trusted block number. The ranges imported this way are
+// recorded in the database, see SealCheckRanges.
+//
+// Spot checking requires the consensus engine to be able to verify selected
+// seals only, or seal checks to be delegated to a batch verifier.
+func SpotCheckSeals(policy SealCheckPolicy) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if policy.Frequency < 2 {
+			return bc, nil
+		}
+		_, sampling := bc.engine.(consensus.SealSamplingEngine)
+		_, deferring := bc.engine.(consensus.SealDeferringEngine)
+		if !sampling && !deferring {
+			log.Warn("Consensus engine can't spot check seals, verifying all", "frequency", policy.Frequency)
+			return bc, nil
+		}
+		bc.hc.sealPolicy = policy
+		log.Info("Spot checking header seals", "frequency", policy.Frequency, "trusted", policy.Trusted)
+		return bc, nil
+	}
+}
+
+// SealCheckRanges returns the header ranges which were imported with their seals
+// spot checked, ordered by block number.
+func (bc *BlockChain) SealCheckRanges() []rawdb.SealCheckRange {
+	return rawdb.ReadSealCheckRanges(bc.db)
+}
+
+// sealChecks returns the headers of a contiguous batch whose seals should be
+// verified, or nil if all of them should be.
+func (hc *HeaderChain) sealChecks(chain []*types.Header) []bool {
+	policy := hc.sealPolicy
+	if policy.Frequency < 2 || len(chain) == 0 || chain[len(chain)-1].Number.Uint64() >= policy.Trusted {
+		return nil
+	}
+	// Make sure the configured engine and verifier can actually skip seals
+	_, deferring := hc.engine.(consensus.SealDeferringEngine)
+	if _, ok := hc.engine.(consensus.SealSamplingEngine); !ok && (hc.sealVerifier == nil || !deferring) {
+		return nil
+	}
+	seals := make([]bool, len(chain))
+	for i, header := range chain {
+		seals[i] = header.Number.Uint64()%policy.Frequency == 0
+	}
+	seals[0], seals[len(chain)-1] = true, true
+	return seals
+}
+
+// recordSealChecks records a spot checked header batch in the database, merging
+// it into any adjacent or overlapping range checked with the same frequency.
+func (hc *HeaderChain) recordSealChecks(chain []*types.Header) {
+	hc.sealLock.Lock()
+	defer hc.sealLock.Unlock()
+
+	var (
+		first  = chain[0].Number.Uint64()
+		last   = chain[len(chain)-1].Number.Uint64()
+		freq   = hc.sealPolicy.Frequency
+		ranges = rawdb.ReadSealCheckRanges(hc.chainDb)
+		merged []rawdb.SealCheckRange
+	)
+	for _, r := range ranges {
+		if r.Frequency == freq && r.First <= last+1 && first <= r.Last+1 {
+			first, last = min(first, r.First), max(last, r.Last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	merged = append(merged, rawdb.SealCheckRange{First: first, Last: last, Frequency: freq})
+	slices.SortFunc(merged, func(a, b rawdb.SealCheckRange) int {
+		return cmp.Compare(a.First, b.First)
+	})
+	rawdb.WriteSealCheckRanges(hc.chainDb, merged)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// sealSamplingFaker is a fake engine recording the seals it was asked to check.
+type sealSamplingFaker struct {
+	consensus.Engine
+	sampled [][]uint64
+}
+
+func (e *sealSamplingFaker) VerifyHeadersSampled(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	var numbers []uint64
+	for i, header := range headers {
+		if seals[i] {
+			numbers = append(numbers, header.Number.Uint64())
+		}
+	}
+	e.sampled = append(e.sampled, numbers)
+	return e.Engine.VerifyHeaders(chain, headers)
+}
+
+// Tests that header seals in trusted ranges are spot checked according to the
+// configured policy and that the checked ranges are recorded.
+func TestSealSpotChecks(t *testing.T) {
+	var (
+		genesis    = &Genesis{Config: params.TestChainConfig, BaseFee: common.Big1}
+		_, headers = makeHeaderChainWithGenesis(genesis, 24, ethash.NewFaker(), 1)
+		engine     = &sealSamplingFaker{Engine: ethash.NewFaker()}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, SpotCheckSeals(SealCheckPolicy{Frequency: 4, Trusted: 20}))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Import the headers in batches, the last one reaching beyond the trusted range
+	for _, batch := range [][]*types.Header{headers[:10], headers[10:16], headers[16:]} {
+		if _, err := chain.InsertHeaderChain(batch); err != nil {
+			t.Fatalf("failed to insert headers: %v", err)
+		}
+	}
+	want := [][]uint64{{1, 4, 8, 10}, {11, 12, 16}}
+	if !reflect.DeepEqual(engine.sampled, want) {
+		t.Errorf("sampled seals mismatch: have %v, want %v", engine.sampled, want)
+	}
+	ranges := []rawdb.SealCheckRange{{First: 1, Last: 16, Frequency: 4}}
+	if have := chain.SealCheckRanges(); !reflect.DeepEqual(have, ranges) {
+		t.Errorf("seal check ranges mismatch: have %v, want %v", have, ranges)
+	}
+}
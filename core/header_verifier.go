@@ -0,0 +1,113 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// EnableBatchSealVerifier returns a BlockChainOption which delegates the seal
+// checks of header chains to the given batch verifier. It only takes effect if
+// the consensus engine can verify headers without their seals, otherwise the
+// engine keeps verifying the seals itself.
+func EnableBatchSealVerifier(verifier consensus.BatchSealVerifier) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.hc.SetBatchSealVerifier(verifier)
+		return bc, nil
+	}
+}
+
+// SetBatchSealVerifier sets the batch verifier to delegate the seal checks of
+// header chains to, or removes it if nil.
+func (hc *HeaderChain) SetBatchSealVerifier(verifier consensus.BatchSealVerifier) {
+	if verifier != nil {
+		if _, ok := hc.engine.(consensus.SealDeferringEngine); !ok {
+			log.Warn("Consensus engine can't defer seal checks, ignoring batch verifier")
+			return
+		}
+	}
+	hc.sealVerifier = verifier
+}
+
+// validateHeadersDeferred verifies a contiguous header chain with the engine
+// checking everything but the seals and the batch verifier checking the seals
+// flagged in seals (or all if nil) concurrently. The index and error of the
+// first invalid header is returned.
+func (hc *HeaderChain) validateHeadersDeferred(chain []*types.Header, engine consensus.SealDeferringEngine, seals []bool) (int, error) {
+	var (
+		sealed  = chain
+		indices []int
+	)
+	if seals != nil {
+		sealed = nil
+		for i, header := range chain {
+			if seals[i] {
+				sealed = append(sealed, header)
+				indices = append(indices, i)
+			}
+		}
+	}
+	results := make(chan []error, 1)
+	go func() {
+		results <- hc.sealVerifier.VerifySeals(hc, sealed)
+	}()
+	abort, checks := engine.VerifyHeadersWithoutSeals(hc, chain)
+	abort, checks = hc.validateExtra(chain, abort, checks)
+	defer close(abort)
+
+	var (
+		failed = len(chain)
+		err    error
+	)
+	for i := range chain {
+		if hc.procInterrupt() {
+			log.Debug("Premature abort during headers verification")
+			return 0, errors.New("aborted")
+		}
+		if err = <-checks; err != nil {
+			failed = i
+			break
+		}
+	}
+	// Headers are only valid if their seals check out too, report whichever
+	// failure comes first in the chain.
+	errs := <-results
+	if len(errs) != len(sealed) {
+		return 0, fmt.Errorf("batch seal verifier returned %d results for %d headers", len(errs), len(sealed))
+	}
+	for i, sealErr := range errs {
+		index := i
+		if indices != nil {
+			index = indices[i]
+		}
+		if index >= failed {
+			break
+		}
+		if sealErr != nil {
+			return index, sealErr
+		}
+	}
+	if err != nil {
+		return failed, err
+	}
+	return 0, nil
+}
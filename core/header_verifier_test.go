@@ -0,0 +1,97 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// sealDeferringFaker is a fake engine which can skip seal checks, counting the
+// batches verified that way.
+type sealDeferringFaker struct {
+	consensus.Engine
+	deferred atomic.Int32
+}
+
+func (e *sealDeferringFaker) VerifyHeadersWithoutSeals(chain consensus.ChainHeaderReader, headers []*types.Header) (chan<- struct{}, <-chan error) {
+	e.deferred.Add(1)
+	return e.Engine.VerifyHeaders(chain, headers)
+}
+
+// testSealVerifier is a batch seal verifier rejecting a single header number.
+type testSealVerifier struct {
+	reject  uint64
+	batches int
+	headers int
+}
+
+func (v *testSealVerifier) VerifySeals(chain consensus.ChainHeaderReader, headers []*types.Header) []error {
+	v.batches++
+	v.headers += len(headers)
+
+	errs := make([]error, len(headers))
+	for i, header := range headers {
+		if header.Number.Uint64() == v.reject {
+			errs[i] = errors.New("bad seal")
+		}
+	}
+	return errs
+}
+
+// Tests that header chain seal checks are delegated to a batch verifier if the
+// engine supports deferring them.
+func TestBatchSealVerifier(t *testing.T) {
+	var (
+		genesis    = &Genesis{Config: params.TestChainConfig, BaseFee: common.Big1}
+		_, headers = makeHeaderChainWithGenesis(genesis, 16, ethash.NewFaker(), 1)
+		engine     = &sealDeferringFaker{Engine: ethash.NewFaker()}
+		verifier   = &testSealVerifier{reject: 10}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableBatchSealVerifier(verifier))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	// The batch containing the rejected seal must fail at its index
+	if n, err := chain.InsertHeaderChain(headers); err == nil || n != 9 {
+		t.Fatalf("bad seal not detected: index %d, err %v", n, err)
+	}
+	if verifier.batches != 1 || verifier.headers != len(headers) || engine.deferred.Load() != 1 {
+		t.Fatalf("seal checks not delegated: batches %d, headers %d, deferred %d", verifier.batches, verifier.headers, engine.deferred.Load())
+	}
+	if head := chain.CurrentHeader().Number.Uint64(); head != 0 {
+		t.Fatalf("invalid headers imported: head #%d", head)
+	}
+	// Headers before the bad seal import fine
+	if _, err := chain.InsertHeaderChain(headers[:9]); err != nil {
+		t.Fatalf("failed to insert valid headers: %v", err)
+	}
+	if head := chain.CurrentHeader().Number.Uint64(); head != 9 {
+		t.Fatalf("head mismatch: have #%d, want #9", head)
+	}
+}
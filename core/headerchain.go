@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -68,8 +69,14 @@ type HeaderChain struct {
 	tdCache     *lru.Cache[common.Hash, *big.Int] // most recent total difficulties
 	numberCache *lru.Cache[common.Hash, uint64]   // most recent block numbers
 
-	procInterrupt func() bool
-	engine        consensus.Engine
+	procInterrupt  func() bool
+	engine         consensus.Engine
+	sealVerifier   consensus.BatchSealVerifier  // Optional verifier to offload seal checks to
+	sealPolicy     SealCheckPolicy              // Seal spot checking policy for trusted ranges
+	sealLock       sync.Mutex                   // Lock protecting the seal check records
+	verifyWorkers  int                          // Number of workers recovering seal signers ahead of verification
+	extraValidator consensus.ExtraDataValidator // Optional validator of the extra-data layout of headers
+	anchor         *SyncAnchor                  // Optional trusted checkpoint below which headers aren't verified
 }
 
 // NewHeaderChain creates a new HeaderChain structure. ProcInterrupt points
@@ -337,8 +344,61 @@ func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header) (int, error) {
 				parentHash.Bytes()[:4], i, chain[i].Number, hash.Bytes()[:4], chain[i].ParentHash[:4])
 		}
 	}
+	// Headers linked up to the sync anchor are trusted, only check them to link up.
+	// The ones following them need their parents known to be verified.
+	anchored, err := hc.checkAnchored(chain)
+	if err != nil {
+		return anchored, err
+	}
+	if anchored == len(chain) {
+		return 0, nil
+	}
+	chain = chain[anchored:]
+
+	// Spot check the seals of trusted ranges if configured, recording what was
+	// skipped after successful verification
+	seals := hc.sealChecks(chain)
+	if index, err := hc.verifyHeaderChain(chain, seals); err != nil {
+		return anchored + index, err
+	}
+	if seals != nil {
+		hc.recordSealChecks(chain)
+	}
+	return 0, nil
+}
+
+// verifyHeaderChain verifies a contiguous header chain, only checking the seals
+// flagged in seals, or all of them if nil.
+func (hc *HeaderChain) verifyHeaderChain(chain []*types.Header, seals []bool) (int, error) {
+	// If seal checks are delegated, run them alongside the engine's verifier
+	if hc.sealVerifier != nil {
+		if engine, ok := hc.engine.(consensus.SealDeferringEngine); ok {
+			return hc.validateHeadersDeferred(chain, engine, seals)
+		}
+	}
+	// Recover the signers concurrently, so the engine's sequential checks only
+	// hit its signature cache
+	if hc.verifyWorkers > 1 {
+		if recoverer, ok := hc.engine.(consensus.SignerRecoverer); ok {
+			stop := make(chan struct{})
+			pend := hc.recoverSigners(recoverer, chain, seals, stop)
+			defer func() {
+				close(stop)
+				pend.Wait()
+			}()
+		}
+	}
 	// Start the parallel verifier
-	abort, results := hc.engine.VerifyHeaders(hc, chain)
+	var (
+		abort   chan<- struct{}
+		results <-chan error
+	)
+	if engine, ok := hc.engine.(consensus.SealSamplingEngine); ok && seals != nil {
+		abort, results = engine.VerifyHeadersSampled(hc, chain, seals)
+	} else {
+		abort, results = hc.engine.VerifyHeaders(hc, chain)
+	}
+	abort, results = hc.validateExtra(chain, abort, results)
 	defer close(abort)
 
 	// Iterate over the headers and ensure they all check out
@@ -509,6 +569,46 @@ func (hc *HeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
 	return hc.GetHeader(hash, *number)
 }
 
+// warmCaches preloads the headers, block numbers and total difficulties of up to
+// the given number of most recent canonical blocks into the caches, reading the
+// headers in one batch from the database and freezer. This spares the queries
+// of the first minutes after a restart from all hitting the disk. The count is
+// capped to the capacity of the header cache.
+func (hc *HeaderChain) warmCaches(count uint64, quit <-chan struct{}) {
+	var (
+		start  = time.Now()
+		head   = hc.CurrentHeader().Number.Uint64()
+		warmed int
+	)
+	headers := rawdb.ReadHeaderRange(hc.chainDb, head, min(count, headerCacheLimit))
+
+	// Headers are read head first, insert them from the oldest so the most recent
+	// ones are the last to be evicted
+	for i := len(headers) - 1; i >= 0; i-- {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(headers[i], header); err != nil {
+			log.Warn("Failed to decode header for cache warmup", "err", err)
+			return
+		}
+		var (
+			hash   = header.Hash()
+			number = header.Number.Uint64()
+		)
+		hc.headerCache.Add(hash, header)
+		hc.numberCache.Add(hash, number)
+		if td := rawdb.ReadTd(hc.chainDb, hash, number); td != nil {
+			hc.tdCache.Add(hash, td)
+		}
+		warmed++
+	}
+	log.Info("Warmed up header caches", "headers", warmed, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 // HasHeader checks if a block header is present in the database or not.
 // In theory, if header is present in the database, all relative components
 // like td and hash->number should be present too.
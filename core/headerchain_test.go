@@ -114,3 +114,44 @@ func TestHeaderInsertion(t *testing.T) {
 	// And B becomes even longer
 	testInsert(t, hc, chainB[107:128], CanonStatTy, nil, forker)
 }
+
+// Tests that the cache warmup preloads the most recent canonical headers, and
+// only those.
+func TestHeaderChainWarmCaches(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{BaseFee: big.NewInt(params.InitialBaseFee), Config: params.AllEthashProtocolChanges}
+	)
+	gspec.Commit(db, triedb.NewDatabase(db, nil))
+	hc, err := NewHeaderChain(db, gspec.Config, ethash.NewFaker(), func() bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, headers := makeHeaderChainWithGenesis(gspec, 64, ethash.NewFaker(), 10)
+	testInsert(t, hc, headers, CanonStatTy, nil, NewForkChoice(hc, nil))
+	rawdb.WriteHeadBlockHash(db, headers[63].Hash())
+
+	// Reopen the header chain with cold caches and warm them up
+	hc, err = NewHeaderChain(db, gspec.Config, ethash.NewFaker(), func() bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.headerCache.Purge()
+	hc.numberCache.Purge()
+	hc.tdCache.Purge()
+	hc.warmCaches(16, nil)
+
+	for i, header := range headers {
+		hash := header.Hash()
+		want := i >= len(headers)-16
+		if have := hc.headerCache.Contains(hash); have != want {
+			t.Errorf("header %d cached mismatch: have %v, want %v", header.Number, have, want)
+		}
+		if have := hc.numberCache.Contains(hash); have != want {
+			t.Errorf("number %d cached mismatch: have %v, want %v", header.Number, have, want)
+		}
+		if have := hc.tdCache.Contains(hash); have != want {
+			t.Errorf("td %d cached mismatch: have %v, want %v", header.Number, have, want)
+		}
+	}
+}
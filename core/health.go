@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// healthError is a block import failure, retained for the health status.
+type healthError struct {
+	block uint64
+	err   error
+	time  time.Time
+}
+
+// HealthStatus is a snapshot of the health of the chain, flat and numeric where
+// possible so it maps directly onto liveness and readiness probes and metric
+// exporters.
+type HealthStatus struct {
+	HeadNumber uint64        `json:"headNumber"` // Number of the current head block
+	HeadHash   common.Hash   `json:"headHash"`   // Hash of the current head block
+	HeadTime   uint64        `json:"headTime"`   // Timestamp of the current head block
+	HeadLag    time.Duration `json:"headLag"`    // Wall clock time elapsed since the head timestamp
+
+	SnapSyncing  bool   `json:"snapSyncing"`  // Whether the snap sync head is ahead of the full head
+	SnapBlock    uint64 `json:"snapBlock"`    // Number of the snap sync head
+	TxIndexing   bool   `json:"txIndexing"`   // Whether the transaction indexer is still catching up
+	TxIndexLeft  uint64 `json:"txIndexLeft"`  // Number of blocks not yet transaction indexed
+	FreezerItems uint64 `json:"freezerItems"` // Number of blocks moved into the freezer, 0 if there is none
+	FreezerTail  uint64 `json:"freezerTail"`  // Number of the first block retained in the freezer
+	SnapshotGen  bool   `json:"snapshotGen"`  // Whether the state snapshot is being generated
+
+	LastError      string    `json:"lastError,omitempty"`      // Last block import failure
+	LastErrorBlock uint64    `json:"lastErrorBlock,omitempty"` // Number of the block failing to import
+	LastErrorTime  time.Time `json:"lastErrorTime,omitempty"`  // Time of the last block import failure
+}
+
+// Live reports whether the chain is running, having imported a block within the
+// given time.
+func (s *HealthStatus) Live(maxLag time.Duration) bool {
+	return s.HeadLag <= maxLag
+}
+
+// Ready reports whether the chain is live and fully synced, able to serve the
+// current state.
+func (s *HealthStatus) Ready(maxLag time.Duration) bool {
+	return s.Live(maxLag) && !s.SnapSyncing && !s.SnapshotGen
+}
+
+// HealthStatus aggregates the health of the chain into a structured status.
+func (bc *BlockChain) HealthStatus() *HealthStatus {
+	var (
+		head   = bc.CurrentBlock()
+		snap   = bc.CurrentSnapBlock()
+		status = &HealthStatus{
+			HeadNumber: head.Number.Uint64(),
+			HeadHash:   head.Hash(),
+			HeadTime:   head.Time,
+			HeadLag:    time.Since(time.Unix(int64(head.Time), 0)),
+			SnapBlock:  snap.Number.Uint64(),
+		}
+	)
+	status.SnapSyncing = status.SnapBlock > status.HeadNumber
+
+	if progress, err := bc.TxIndexProgress(); err == nil {
+		status.TxIndexing = !progress.Done()
+		status.TxIndexLeft = progress.Remaining
+	}
+	if frozen, err := bc.db.Ancients(); err == nil {
+		status.FreezerItems = frozen
+		status.FreezerTail, _ = bc.db.Tail()
+	}
+	if bc.snaps != nil {
+		status.SnapshotGen = bc.snaps.Generating()
+	}
+	if last := bc.lastError.Load(); last != nil && last.err != nil {
+		status.LastError = last.err.Error()
+		status.LastErrorBlock = last.block
+		status.LastErrorTime = last.time
+	}
+	return status
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the health status reflects the chain head and import failures.
+func TestHealthStatus(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		gspec  = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, nil)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	status := chain.HealthStatus()
+	if status.HeadNumber != 4 || status.HeadHash != blocks[3].Hash() || status.HeadTime != blocks[3].Time() {
+		t.Errorf("head mismatch: have #%d [%x], want #4 [%x]", status.HeadNumber, status.HeadHash, blocks[3].Hash())
+	}
+	if status.SnapSyncing {
+		t.Errorf("full synced chain reported snap syncing")
+	}
+	if status.LastError != "" {
+		t.Errorf("unexpected error reported: %v", status.LastError)
+	}
+	// Generated blocks are stamped far in the past, the chain is stale
+	if status.Live(time.Hour) || status.Ready(time.Hour) {
+		t.Errorf("stale chain reported live")
+	}
+	if !status.Live(status.HeadLag) {
+		t.Errorf("chain within the lag reported dead")
+	}
+	chain.reportBlock(blocks[2], nil, errors.New("invalid merkle root"))
+	if status := chain.HealthStatus(); status.LastError != "invalid merkle root" || status.LastErrorBlock != 3 || status.LastErrorTime.IsZero() {
+		t.Errorf("import failure mismatch: have %q at #%d", status.LastError, status.LastErrorBlock)
+	}
+}
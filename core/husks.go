@@ -0,0 +1,83 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxHusksRange is the maximum number of blocks scanned by a husks query.
+const maxHusksRange = 100_000
+
+var huskMeter = metrics.NewRegisteredMeter("chain/husks", nil)
+
+// Husk is a contract which survived a SELFDESTRUCT under the EIP-6780 rules and
+// is left in the state without balance, keeping its code, nonce and storage.
+// Removing husks would change the state transition, so they're only reported.
+type Husk struct {
+	Address  common.Address // Address of the contract
+	Number   uint64         // Number of the last block leaving the contract as a husk
+	Nonce    uint64         // Current nonce of the contract
+	CodeSize int            // Current size of the contract code
+}
+
+// Husks returns the contracts left as husks by the canonical blocks in the given
+// range, which are still husks in the current state, sorted by address.
+func (bc *BlockChain) Husks(from, to uint64) ([]*Husk, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to-from >= maxHusksRange {
+		return nil, fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", from, to, maxHusksRange)
+	}
+	if head := bc.CurrentBlock().Number.Uint64(); to > head {
+		to = head
+	}
+	last := make(map[common.Address]uint64)
+	for number := from; number <= to; number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		for _, addr := range rawdb.ReadHusks(bc.db, hash, number) {
+			last[addr] = number
+		}
+	}
+	if len(last) == 0 {
+		return nil, nil
+	}
+	statedb, err := bc.State()
+	if err != nil {
+		return nil, err
+	}
+	var husks []*Husk
+	for addr, number := range last {
+		if !statedb.Exist(addr) || !statedb.GetBalance(addr).IsZero() {
+			continue
+		}
+		husks = append(husks, &Husk{
+			Address:  addr,
+			Number:   number,
+			Nonce:    statedb.GetNonce(addr),
+			CodeSize: statedb.GetCodeSize(addr),
+		})
+	}
+	slices.SortFunc(husks, func(a, b *Husk) int { return a.Address.Cmp(b.Address) })
+	return husks, nil
+}
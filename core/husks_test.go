@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the contracts surviving a SELFDESTRUCT without balance are tracked
+// per block, and only reported while they're still husks.
+func TestHusks(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.MergedTestChainConfig
+		signer = types.LatestSigner(&config)
+		engine = beacon.NewFaker()
+
+		// Selfdestructs to the caller if called without value, stops otherwise
+		code  = common.FromHex("0x3460065733ff5b00")
+		husk1 = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		husk2 = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+	)
+	genesis := &Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			addr:  {Balance: big.NewInt(params.Ether)},
+			husk1: {Balance: big.NewInt(params.Ether), Nonce: 1, Code: code},
+			husk2: {Balance: big.NewInt(params.Ether), Nonce: 1, Code: code},
+		},
+	}
+	call := func(b *BlockGen, to common.Address, value int64) {
+		b.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     b.TxNonce(addr),
+			To:        &to,
+			Gas:       100_000,
+			GasFeeCap: newGwei(5),
+			GasTipCap: big.NewInt(2),
+			Value:     big.NewInt(value),
+		}))
+	}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		switch i {
+		case 0:
+			call(b, husk1, 0)
+			call(b, husk2, 0)
+		case 1:
+			call(b, husk1, 1) // refund the first husk
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if have, want := rawdb.ReadHusks(chain.db, blocks[0].Hash(), 1), []common.Address{husk1, husk2}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("tracked husks mismatch: have %v, want %v", have, want)
+	}
+	if have := rawdb.ReadHusks(chain.db, blocks[1].Hash(), 2); len(have) != 0 {
+		t.Fatalf("husks tracked for the refunding block: %v", have)
+	}
+	husks, err := chain.Husks(0, 2)
+	if err != nil {
+		t.Fatalf("failed to retrieve husks: %v", err)
+	}
+	want := []*Husk{{Address: husk2, Number: 1, Nonce: 1, CodeSize: len(code)}}
+	if !reflect.DeepEqual(husks, want) {
+		t.Fatalf("reported husks mismatch: have %v, want %v", husks, want)
+	}
+	if _, err := chain.Husks(0, maxHusksRange); err == nil {
+		t.Fatal("oversized range accepted")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	// importProgressWindow is the span of the recent imports the throughput is
+	// measured over.
+	importProgressWindow = time.Minute
+
+	// importProgressSamples is the maximum number of imports retained within
+	// the window, bounding the tracker during fast imports.
+	importProgressSamples = 4096
+)
+
+// ImportProgress is the rolling throughput of the block imports, along with the
+// estimated time to reach a target block at that pace.
+type ImportProgress struct {
+	Head            uint64        // Number of the last block imported
+	Target          uint64        // Number of the block the estimate is for
+	BlocksPerSecond float64       // Blocks imported per second over the recent window
+	GasPerSecond    float64       // Gas processed per second over the recent window
+	MgasPerSecond   float64       // Million gas processed per second over the recent window
+	ETA             time.Duration // Estimated time to reach the target, zero if reached or unknown
+}
+
+// ImportProgressEvent is posted every configured number of blocks imported.
+type ImportProgressEvent struct {
+	Progress *ImportProgress
+}
+
+// WithImportProgressEvents returns a BlockChainOption which posts an
+// ImportProgressEvent whenever the number of an imported block is a multiple of
+// every, e.g. to feed sync dashboards without scraping the logs.
+func WithImportProgressEvents(every uint64) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.progressEvery = every
+		return bc, nil
+	}
+}
+
+// importSample is a single block import tracked by the progress estimator.
+type importSample struct {
+	time   time.Time
+	number uint64
+	gas    uint64
+}
+
+// importProgress tracks the block imports within the recent window.
+type importProgress struct {
+	lock    sync.Mutex
+	samples []importSample // Imports within the window, oldest first
+}
+
+// record tracks a block import, dropping the ones fallen out of the window.
+func (p *importProgress) record(number, gas uint64, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.samples = append(p.samples, importSample{time: now, number: number, gas: gas})
+
+	var drop int
+	for drop < len(p.samples)-2 && (now.Sub(p.samples[drop].time) > importProgressWindow || len(p.samples)-drop > importProgressSamples) {
+		drop++
+	}
+	p.samples = append(p.samples[:0], p.samples[drop:]...)
+}
+
+// estimate computes the throughput over the window and the time to reach the
+// given target at that pace.
+func (p *importProgress) estimate(target uint64) *ImportProgress {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	progress := &ImportProgress{Target: target}
+	if len(p.samples) == 0 {
+		return progress
+	}
+	last := p.samples[len(p.samples)-1]
+	progress.Head = last.number
+
+	// The first sample only marks the start of the window
+	elapsed := last.time.Sub(p.samples[0].time).Seconds()
+	if len(p.samples) < 2 || elapsed <= 0 {
+		return progress
+	}
+	var gas uint64
+	for _, sample := range p.samples[1:] {
+		gas += sample.gas
+	}
+	progress.BlocksPerSecond = float64(len(p.samples)-1) / elapsed
+	progress.GasPerSecond = float64(gas) / elapsed
+	progress.MgasPerSecond = progress.GasPerSecond / 1_000_000
+
+	if target > last.number {
+		progress.ETA = time.Duration(float64(target-last.number) / progress.BlocksPerSecond * float64(time.Second))
+	}
+	return progress
+}
+
+// ImportProgress returns the rolling throughput of the block imports and the
+// estimated time to import the given target block. A zero target estimates
+// for the best head known, either from the header chain or the peers.
+func (bc *BlockChain) ImportProgress(target uint64) *ImportProgress {
+	if target == 0 {
+		target = bc.CurrentHeader().Number.Uint64()
+		if chasing := bc.ChasingHead(); chasing != nil && chasing.Number.Uint64() > target {
+			target = chasing.Number.Uint64()
+		}
+	}
+	return bc.progress.estimate(target)
+}
+
+// SubscribeImportProgressEvent registers a subscription of ImportProgressEvent.
+func (bc *BlockChain) SubscribeImportProgressEvent(ch chan<- ImportProgressEvent) event.Subscription {
+	return bc.scope.Track(bc.progressFeed.Subscribe(ch))
+}
+
+// recordImport tracks an imported block in the progress estimator, posting a
+// progress event at the configured intervals.
+func (bc *BlockChain) recordImport(number, gas uint64) {
+	bc.progress.record(number, gas, time.Now())
+
+	if bc.progressEvery > 0 && number%bc.progressEvery == 0 {
+		bc.progressFeed.Send(ImportProgressEvent{Progress: bc.ImportProgress(0)})
+	}
+}
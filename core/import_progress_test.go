@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the import throughput is measured over the recent window only, and
+// that the time to reach the target is estimated from it.
+func TestImportProgressEstimate(t *testing.T) {
+	var (
+		p     importProgress
+		start = time.Unix(1_000_000, 0)
+	)
+	if have := p.estimate(100); have.Head != 0 || have.ETA != 0 {
+		t.Fatalf("estimate without imports: %+v", have)
+	}
+	// Slow imports falling out of the window, then two blocks per second
+	for i := uint64(1); i <= 10; i++ {
+		p.record(i, 1_000_000, start.Add(time.Duration(i)*time.Minute))
+	}
+	base := start.Add(10 * time.Minute)
+	for i := uint64(1); i <= 20; i++ {
+		p.record(10+i, 3_000_000, base.Add(time.Duration(i)*500*time.Millisecond))
+	}
+	have := p.estimate(50)
+	if have.Head != 30 || have.Target != 50 {
+		t.Fatalf("head/target mismatch: have %d/%d, want 30/50", have.Head, have.Target)
+	}
+	if have.BlocksPerSecond != 2 || have.MgasPerSecond != 6 {
+		t.Fatalf("throughput mismatch: have %v blocks/s %v mgas/s, want 2 blocks/s 6 mgas/s", have.BlocksPerSecond, have.MgasPerSecond)
+	}
+	if have.ETA != 10*time.Second {
+		t.Fatalf("eta mismatch: have %v, want %v", have.ETA, 10*time.Second)
+	}
+	if have := p.estimate(20); have.ETA != 0 {
+		t.Fatalf("eta of a reached target: %v", have.ETA)
+	}
+}
+
+// Tests that the import progress events are posted at the configured intervals.
+func TestImportProgressEvents(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 10, nil)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil, WithImportProgressEvents(4))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan ImportProgressEvent, 10)
+	sub := chain.SubscribeImportProgressEvent(events)
+	defer sub.Unsubscribe()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for _, want := range []uint64{4, 8} {
+		select {
+		case ev := <-events:
+			if ev.Progress.Head != want {
+				t.Fatalf("progress head mismatch: have %d, want %d", ev.Progress.Head, want)
+			}
+		default:
+			t.Fatalf("missing progress event at block %d", want)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected progress event: %+v", ev.Progress)
+	default:
+	}
+	if have := chain.ImportProgress(0); have.Head != 10 || have.Target != 10 {
+		t.Fatalf("progress mismatch: have %d/%d, want 10/10", have.Head, have.Target)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	importSendersTimer   = metrics.NewRegisteredTimer("chain/import/senders", nil)
+	importExecutionTimer = metrics.NewRegisteredTimer("chain/import/execution", nil)
+	importTrieHashTimer  = metrics.NewRegisteredTimer("chain/import/triehash", nil)
+	importSnapshotTimer  = metrics.NewRegisteredTimer("chain/import/snapshot", nil)
+	importCommitTimer    = metrics.NewRegisteredTimer("chain/import/commit", nil)
+	importSidecarsTimer  = metrics.NewRegisteredTimer("chain/import/sidecars", nil)
+)
+
+// InsertReport breaks the time spent importing a block down by phase.
+type InsertReport struct {
+	Number  uint64      // Number of the imported block
+	Hash    common.Hash // Hash of the imported block
+	Txs     int         // Transactions contained in the block
+	GasUsed uint64      // Gas used by the block
+
+	Senders   time.Duration // Recovering the senders missed by the background recovery
+	Execution time.Duration // Executing the transactions, including the state reads
+	TrieHash  time.Duration // Validating the post state, mostly updating and hashing the tries
+	Snapshot  time.Duration // Updating the snapshot with the state changes
+	Commit    time.Duration // Committing the state and writing the block, excluding the snapshot
+	Sidecars  time.Duration // Writing the blob sidecars, concurrently with the commit
+	Total     time.Duration // Importing the block, from the start of its processing
+}
+
+// InsertReportHook is a callback invoked after every block imported. It's run
+// synchronously on the import path, so any slow work should be handed off.
+type InsertReportHook func(report *InsertReport)
+
+// WithInsertReports returns a BlockChainOption which invokes hook with the
+// phase timings of every block imported, e.g. to diagnose slow imports.
+func WithInsertReports(hook InsertReportHook) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.insertHooks = append(bc.insertHooks, hook)
+		return bc, nil
+	}
+}
+
+// recoverSenders recovers the senders of the transactions in the block which
+// weren't cached yet by the background recovery of the import batch.
+func (bc *BlockChain) recoverSenders(block *types.Block) time.Duration {
+	start := time.Now()
+	signer := types.MakeSigner(bc.chainConfig, block.Number(), block.Time())
+	for _, tx := range block.Transactions() {
+		types.Sender(signer, tx) // Invalid signatures are reported by the processing
+	}
+	return time.Since(start)
+}
+
+// reportInsert records the phase timings of an imported block and invokes the
+// hooks.
+func (bc *BlockChain) reportInsert(report *InsertReport) {
+	importSendersTimer.Update(report.Senders)
+	importExecutionTimer.Update(report.Execution)
+	importTrieHashTimer.Update(report.TrieHash)
+	importSnapshotTimer.Update(report.Snapshot)
+	importCommitTimer.Update(report.Commit)
+	importSidecarsTimer.Update(report.Sidecars)
+
+	for _, hook := range bc.insertHooks {
+		hook(report)
+	}
+}
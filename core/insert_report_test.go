@@ -0,0 +1,74 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the phase timings of every imported block are reported.
+func TestInsertReports(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.LatestSigner(params.TestChainConfig)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	var reports []*InsertReport
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil, WithInsertReports(func(report *InsertReport) {
+		reports = append(reports, report)
+	}))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if len(reports) != len(blocks) {
+		t.Fatalf("report count mismatch: have %d, want %d", len(reports), len(blocks))
+	}
+	for i, report := range reports {
+		if report.Number != blocks[i].NumberU64() || report.Hash != blocks[i].Hash() {
+			t.Errorf("report %d: block mismatch: have %d/%x, want %d/%x", i, report.Number, report.Hash, blocks[i].NumberU64(), blocks[i].Hash())
+		}
+		if report.Txs != 1 || report.GasUsed != params.TxGas {
+			t.Errorf("report %d: content mismatch: have %d txs %d gas, want 1 txs %d gas", i, report.Txs, report.GasUsed, params.TxGas)
+		}
+		if phases := report.Senders + report.Execution + report.TrieHash + report.Snapshot + report.Commit; report.Total < phases {
+			t.Errorf("report %d: total %v below the sum of the phases %v", i, report.Total, phases)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DecodedLogField is a single decoded parameter of a log event.
+type DecodedLogField struct {
+	Name    string // Name of the parameter, argN if unnamed in the ABI
+	Indexed bool   // Whether the parameter was decoded from a topic
+	Value   any    // Decoded value, the topic hash for indexed dynamic types
+}
+
+// DecodedLog is a log emitted by a contract with a registered schema, carrying
+// the decoded event parameters along with the raw log.
+type DecodedLog struct {
+	Log    *types.Log
+	Event  string            // Signature of the event, e.g. Transfer(address,address,uint256)
+	Fields []DecodedLogField // Parameters in ABI declaration order
+}
+
+// logSchemaRegistry maintains the event ABIs of the contracts whose logs are
+// decoded before emission. The zero value is an empty registry.
+type logSchemaRegistry struct {
+	lock   sync.RWMutex
+	events map[common.Address]map[common.Hash]abi.Event
+}
+
+// RegisterLogSchema registers the events of the given JSON ABI fragment for the
+// contract at addr, so that its logs are emitted decoded to the subscribers of
+// SubscribeDecodedLogsEvent. Registering a contract again replaces its schema.
+// Anonymous events cannot be identified by topic and are ignored.
+func (bc *BlockChain) RegisterLogSchema(addr common.Address, abiJSON string) error {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return err
+	}
+	events := make(map[common.Hash]abi.Event)
+	for _, event := range parsed.Events {
+		if !event.Anonymous {
+			events[event.ID] = event
+		}
+	}
+	if len(events) == 0 {
+		return errors.New("no non-anonymous events in schema")
+	}
+	r := &bc.logSchemas
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.events == nil {
+		r.events = make(map[common.Address]map[common.Hash]abi.Event)
+	}
+	r.events[addr] = events
+	return nil
+}
+
+// UnregisterLogSchema drops the registered schema of the contract at addr.
+func (bc *BlockChain) UnregisterLogSchema(addr common.Address) {
+	r := &bc.logSchemas
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.events, addr)
+}
+
+// SubscribeDecodedLogsEvent registers a subscription of the logs emitted by
+// contracts with a registered schema, in their decoded form.
+func (bc *BlockChain) SubscribeDecodedLogsEvent(ch chan<- []*DecodedLog) event.Subscription {
+	return bc.scope.Track(bc.decodedLogsFeed.Subscribe(ch))
+}
+
+// sendLogs emits the given logs to the raw log subscribers, and the decodable
+// subset of them to the decoded log subscribers.
+func (bc *BlockChain) sendLogs(logs []*types.Log) {
+	bc.logsFeed.Send(logs)
+
+	if decoded := bc.logSchemas.decode(logs); len(decoded) > 0 {
+		bc.decodedLogsFeed.Send(decoded)
+	}
+}
+
+// decode decodes the logs emitted by contracts with a registered schema. Logs
+// failing to decode are skipped.
+func (r *logSchemaRegistry) decode(logs []*types.Log) []*DecodedLog {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if len(r.events) == 0 {
+		return nil
+	}
+	var decoded []*DecodedLog
+	for _, l := range logs {
+		if len(l.Topics) == 0 {
+			continue
+		}
+		event, ok := r.events[l.Address][l.Topics[0]]
+		if !ok {
+			continue
+		}
+		fields, err := decodeLogFields(event, l)
+		if err != nil {
+			log.Debug("Failed to decode log", "address", l.Address, "event", event.Sig, "tx", l.TxHash, "err", err)
+			continue
+		}
+		decoded = append(decoded, &DecodedLog{Log: l, Event: event.Sig, Fields: fields})
+	}
+	return decoded
+}
+
+// decodeLogFields decodes the parameters of a log according to its event ABI.
+func decodeLogFields(event abi.Event, l *types.Log) ([]DecodedLogField, error) {
+	var (
+		inputs  = make(abi.Arguments, len(event.Inputs))
+		indexed abi.Arguments
+	)
+	// Name the unnamed parameters to keep them apart in the decoded maps
+	for i, arg := range event.Inputs {
+		if arg.Name == "" {
+			arg.Name = fmt.Sprintf("arg%d", i)
+		}
+		inputs[i] = arg
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	values := make(map[string]any)
+	if err := abi.ParseTopicsIntoMap(values, indexed, l.Topics[1:]); err != nil {
+		return nil, err
+	}
+	if err := inputs.UnpackIntoMap(values, l.Data); err != nil {
+		return nil, err
+	}
+	fields := make([]DecodedLogField, len(inputs))
+	for i, arg := range inputs {
+		fields[i] = DecodedLogField{Name: arg.Name, Indexed: arg.Indexed, Value: values[arg.Name]}
+	}
+	return fields, nil
+}
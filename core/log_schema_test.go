@@ -0,0 +1,79 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const transferABI = `[{"type":"event","name":"Transfer","inputs":[
+	{"name":"from","type":"address","indexed":true},
+	{"name":"to","type":"address","indexed":true},
+	{"name":"","type":"uint256","indexed":false}]}]`
+
+// Tests that logs of contracts with a registered schema are decoded and the
+// others are skipped.
+func TestLogSchemaDecoding(t *testing.T) {
+	var (
+		bc       = new(BlockChain)
+		token    = common.Address{0x01}
+		other    = common.Address{0x02}
+		from     = common.Address{0xaa}
+		to       = common.Address{0xbb}
+		transfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	)
+	if err := bc.RegisterLogSchema(token, transferABI); err != nil {
+		t.Fatalf("failed to register schema: %v", err)
+	}
+	raw := &types.Log{
+		Address: token,
+		Topics:  []common.Hash{transfer, common.BytesToHash(from[:]), common.BytesToHash(to[:])},
+		Data:    common.BigToHash(big.NewInt(1000)).Bytes(),
+	}
+	foreign := &types.Log{Address: other, Topics: raw.Topics, Data: raw.Data}
+	malformed := &types.Log{Address: token, Topics: raw.Topics[:2], Data: raw.Data}
+
+	decoded := bc.logSchemas.decode([]*types.Log{foreign, raw, malformed})
+	if len(decoded) != 1 {
+		t.Fatalf("decoded log count mismatch: have %d, want 1", len(decoded))
+	}
+	if decoded[0].Log != raw || decoded[0].Event != "Transfer(address,address,uint256)" {
+		t.Errorf("decoded log mismatch: %+v", decoded[0])
+	}
+	fields := decoded[0].Fields
+	if len(fields) != 3 {
+		t.Fatalf("decoded field count mismatch: have %d, want 3", len(fields))
+	}
+	if fields[0].Name != "from" || !fields[0].Indexed || fields[0].Value != from {
+		t.Errorf("from field mismatch: %+v", fields[0])
+	}
+	if fields[1].Name != "to" || !fields[1].Indexed || fields[1].Value != to {
+		t.Errorf("to field mismatch: %+v", fields[1])
+	}
+	if value, ok := fields[2].Value.(*big.Int); fields[2].Name != "arg2" || fields[2].Indexed || !ok || value.Int64() != 1000 {
+		t.Errorf("value field mismatch: %+v", fields[2])
+	}
+	bc.UnregisterLogSchema(token)
+	if decoded := bc.logSchemas.decode([]*types.Log{raw}); len(decoded) != 0 {
+		t.Errorf("logs decoded after unregistering: %d", len(decoded))
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// parallelMinTxs is the minimum number of transactions in a block for it to
+	// be worth executing them in parallel.
+	parallelMinTxs = 4
+
+	// parallelContentionSample is the number of transactions committed before
+	// the conflict rate is considered for falling back to serial execution.
+	parallelContentionSample = 8
+)
+
+var (
+	parallelSpeculatedMeter = metrics.NewRegisteredMeter("chain/parallel/speculated", nil)
+	parallelConflictMeter   = metrics.NewRegisteredMeter("chain/parallel/conflicts", nil)
+	parallelFallbackMeter   = metrics.NewRegisteredMeter("chain/parallel/fallbacks", nil)
+)
+
+// speculation is the outcome of executing a transaction on the state as of
+// the beginning of the block.
+type speculation struct {
+	result  *ExecutionResult
+	err     error
+	reads   map[state.StateKey]struct{}
+	writes  map[state.StateKey]struct{}
+	effects *state.StateEffects // Nil if the effects cannot be replayed
+	logs    []*types.Log
+	stats   vm.ExecutionStats // Execution statistics of the speculation alone
+}
+
+// parallelExecutor executes the transactions of a block Block-STM style: all
+// of them are speculatively executed concurrently against the state as of the
+// beginning of the block, and then committed in order. A speculation is only
+// committed if nothing it read was written by the transactions before it,
+// otherwise the transaction is executed again on the actual state. If too many
+// transactions conflict, the remaining ones are executed serially.
+//
+// The results are identical to serial execution.
+type parallelExecutor struct {
+	results []chan *speculation // Speculation results, indexed by transaction index
+	abort   atomic.Bool         // Flag stopping the speculating workers
+
+	written  map[state.StateKey]struct{} // State written by the committed transactions
+	deleted  map[common.Address]struct{} // Accounts deleted by the committed transactions
+	recorder *state.AccessRecorder       // Access recorder over the block state
+	evm      *vm.EVM                     // EVM re-executing conflicting transactions
+	specs    vm.ExecutionStats           // Execution statistics of the committed speculations
+
+	committed int  // Number of transactions committed
+	conflicts int  // Number of transactions re-executed due to conflicts
+	serial    bool // Whether speculation was abandoned for the block
+}
+
+// newParallelExecutor starts speculatively executing the transactions of the
+// block on top of the given state. The pre-transaction system calls must have
+// been applied to the state already.
+func newParallelExecutor(p *StateProcessor, block *types.Block, statedb *state.StateDB, evm *vm.EVM, cfg vm.Config) *parallelExecutor {
+	var (
+		header = block.Header()
+		signer = types.MakeSigner(p.config, header.Number, header.Time)
+		txs    = block.Transactions()
+		tasks  = make(chan int, len(txs))
+	)
+	e := &parallelExecutor{
+		results:  make([]chan *speculation, len(txs)),
+		written:  make(map[state.StateKey]struct{}),
+		deleted:  make(map[common.Address]struct{}),
+		recorder: state.NewAccessRecorder(statedb),
+	}
+	e.evm = vm.NewEVM(evm.Context, e.recorder, p.config, cfg)
+
+	posa, isPoSA := p.chain.engine.(consensus.PoSA)
+	for i, tx := range txs {
+		if isPoSA {
+			if isSystemTx, err := posa.IsSystemTransaction(tx, header); err != nil || isSystemTx {
+				continue
+			}
+		}
+		e.results[i] = make(chan *speculation, 1)
+		tasks <- i
+	}
+	close(tasks)
+
+	for i := 0; i < cfg.ParallelWorkers; i++ {
+		var (
+			specdb   = statedb.Copy()
+			recorder = state.NewAccessRecorder(specdb)
+			specEVM  = vm.NewEVM(NewEVMBlockContext(header, p.chain, nil), recorder, p.config, cfg)
+		)
+		go func() {
+			for index := range tasks {
+				if e.abort.Load() {
+					return
+				}
+				e.results[index] <- e.speculate(txs[index], index, block, signer, specdb, recorder, specEVM)
+			}
+		}()
+	}
+	return e
+}
+
+// speculate executes the transaction on the given state, reverting the state
+// afterwards so it can be reused for the next speculation.
+func (e *parallelExecutor) speculate(tx *types.Transaction, index int, block *types.Block, signer types.Signer, statedb *state.StateDB, recorder *state.AccessRecorder, evm *vm.EVM) *speculation {
+	msg, err := TransactionToMessage(tx, signer, block.BaseFee())
+	if err != nil {
+		return &speculation{err: err}
+	}
+	statedb.SetTxContext(tx.Hash(), index)
+	recorder.Reset()
+
+	snapshot := statedb.Snapshot()
+	defer statedb.RevertToSnapshot(snapshot)
+
+	evm.ResetStats()
+	result, err := ApplyMessage(evm, msg, new(GasPool).AddGas(block.GasLimit()))
+	if err != nil {
+		return &speculation{err: err}
+	}
+	spec := &speculation{
+		result: result,
+		reads:  recorder.Reads(),
+		writes: recorder.Writes(),
+		logs:   statedb.GetLogs(tx.Hash(), block.NumberU64(), block.Hash()),
+		stats:  evm.Stats(),
+	}
+	spec.effects, _ = recorder.Effects()
+	return spec
+}
+
+// apply executes the transaction on the block state, committing its
+// speculation if still valid, or executing it again otherwise.
+func (e *parallelExecutor) apply(index int, msg *Message, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM, receiptProcessors ...ReceiptProcessor) (*types.Receipt, *ExecutionResult, error) {
+	if e.serial {
+		return applyTransactionWithEVM(msg, gp, statedb, blockNumber, blockHash, tx, usedGas, evm, receiptProcessors...)
+	}
+	spec := <-e.results[index]
+	if e.valid(spec) && gp.Gas() >= msg.GasLimit {
+		spec.effects.Apply(statedb)
+		if statedb.CompleteAccesses() {
+			statedb.TouchState(spec.reads)
+		}
+		for _, log := range spec.logs {
+			cpy := *log
+			statedb.AddLog(&cpy)
+		}
+		statedb.Finalise(true)
+		gp.SubGas(spec.result.UsedGas)
+		*usedGas += spec.result.UsedGas
+
+		evm.SetTxContext(NewEVMTxContext(msg))
+		receipt := MakeReceipt(evm, spec.result, statedb, blockNumber, blockHash, tx, *usedGas, nil, receiptProcessors...)
+		e.commit(spec.writes, nil)
+		e.specs.Merge(spec.stats)
+		parallelSpeculatedMeter.Mark(1)
+		return receipt, spec.result, nil
+	}
+	// The speculation is stale, or the transaction failed in a way that needs
+	// to be reproduced on the actual state, so execute it again.
+	e.recorder.Reset()
+	receipt, result, err := applyTransactionWithEVM(msg, gp, statedb, blockNumber, blockHash, tx, usedGas, e.evm, receiptProcessors...)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.conflicts++
+	e.commit(e.recorder.Writes(), e.recorder.Deleted())
+	parallelConflictMeter.Mark(1)
+
+	if e.committed >= parallelContentionSample && 2*e.conflicts > e.committed {
+		e.serial = true
+		e.close()
+		parallelFallbackMeter.Mark(1)
+	}
+	return receipt, result, nil
+}
+
+// valid reports whether the speculation can be committed, that is none of the
+// state it read was modified by the transactions committed before it.
+func (e *parallelExecutor) valid(spec *speculation) bool {
+	if spec.err != nil || spec.effects == nil {
+		return false
+	}
+	for key := range spec.reads {
+		if _, ok := e.written[key]; ok {
+			return false
+		}
+		if _, ok := e.deleted[key.Address]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// commit records the state written by a committed transaction.
+func (e *parallelExecutor) commit(writes map[state.StateKey]struct{}, deleted []common.Address) {
+	for key := range writes {
+		e.written[key] = struct{}{}
+	}
+	for _, addr := range deleted {
+		e.deleted[addr] = struct{}{}
+	}
+	e.committed++
+}
+
+// close stops the speculating workers.
+func (e *parallelExecutor) close() {
+	e.abort.Store(true)
+}
+
+// stats returns the execution statistics of the transactions executed by the
+// executor, either committed speculations or re-executions. Discarded
+// speculations are not accounted, as their transactions were executed again.
+func (e *parallelExecutor) stats() vm.ExecutionStats {
+	stats := e.evm.Stats()
+	stats.Merge(e.specs)
+	return stats
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that executing the transactions of blocks in parallel yields the same
+// state and receipts as executing them serially, both for independent and for
+// conflicting transactions.
+func TestParallelProcessing(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		keys   = make([]*ecdsa.PrivateKey, 4)
+		addrs  = make([]common.Address, 4)
+
+		// Counter incrementing slot 0, logging and returning the new value
+		counter = common.Address{0xcc}
+		alloc   = types.GenesisAlloc{counter: {Code: common.FromHex("0x6000546001018060005560005260206000a060206000f3")}}
+	)
+	racerKey, _ := crypto.GenerateKey()
+	racer := crypto.PubkeyToAddress(racerKey.PublicKey)
+	alloc[racer] = types.Account{Balance: big.NewInt(params.Ether)}
+
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	gspec := &Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, func(i int, b *BlockGen) {
+		price := new(big.Int).Mul(b.header.BaseFee, big.NewInt(2))
+		for j, key := range keys {
+			var tx *types.Transaction
+			switch (i + j) % 4 {
+			case 0:
+				tx = types.NewTransaction(b.TxNonce(addrs[j]), common.Address{byte(i), byte(j)}, big.NewInt(1), params.TxGas, price, nil)
+			case 1:
+				tx = types.NewTransaction(b.TxNonce(addrs[j]), counter, nil, 50000, price, nil)
+			case 2:
+				tx = types.NewTransaction(b.TxNonce(addrs[j]), addrs[(j+1)%len(addrs)], big.NewInt(1), params.TxGas, price, nil)
+			case 3:
+				tx = types.NewContractCreation(b.TxNonce(addrs[j]), nil, 100000, price, common.FromHex("0x600160005500"))
+			}
+			tx, _ = types.SignTx(tx, signer, key)
+			b.AddTx(tx)
+		}
+		// Chain a dependent transaction of the same sender
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addrs[0]), counter, nil, 50000, price, nil), signer, keys[0])
+		b.AddTx(tx)
+		// Race the counter transaction from an independent sender
+		tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(racer), counter, nil, 50000, price, nil), signer, racerKey)
+		b.AddTx(tx)
+	})
+	var serial vm.ExecutionStats
+	for _, workers := range []int{0, 4} {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{ParallelWorkers: workers}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("workers %d: block %d: failed to insert into chain: %v", workers, n, err)
+		}
+		statedb, err := chain.State()
+		if err != nil {
+			t.Fatalf("workers %d: failed to open head state: %v", workers, err)
+		}
+		if have := statedb.GetState(counter, common.Hash{}); have != common.BigToHash(big.NewInt(12)) {
+			t.Errorf("workers %d: counter mismatch: have %x, want 12", workers, have)
+		}
+		receipts := chain.GetReceiptsByHash(blocks[len(blocks)-1].Hash())
+		var logs int
+		for _, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				if log.Index != uint(logs) {
+					t.Errorf("workers %d: log index mismatch: have %d, want %d", workers, log.Index, logs)
+				}
+				logs++
+			}
+		}
+		if logs != 3 {
+			t.Errorf("workers %d: log count mismatch: have %d, want 3", workers, logs)
+		}
+		// The execution statistics cover the speculating EVMs, but count every
+		// transaction once
+		parent := chain.GetHeaderByHash(blocks[len(blocks)-1].ParentHash())
+		statedb, err = chain.StateAt(parent.Root)
+		if err != nil {
+			t.Fatalf("workers %d: failed to open parent state: %v", workers, err)
+		}
+		res, err := chain.Processor().Process(blocks[len(blocks)-1], statedb, vm.Config{ParallelWorkers: workers})
+		if err != nil {
+			t.Fatalf("workers %d: failed to process block: %v", workers, err)
+		}
+		if res.Stats.MaxCallDepth != 1 || res.Stats.ReturnData == 0 {
+			t.Errorf("workers %d: stats not tracked: %+v", workers, res.Stats)
+		}
+		if workers == 0 {
+			serial = res.Stats
+		} else if res.Stats != serial {
+			t.Errorf("workers %d: stats mismatch: have %+v, want %+v", workers, res.Stats, serial)
+		}
+		chain.Stop()
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"cmp"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/holiman/uint256"
+)
+
+// pendingTxChanSize is the size of the channel listening to new pool
+// transactions.
+const pendingTxChanSize = 4096
+
+var (
+	pendingRebuildMeter = metrics.NewRegisteredMeter("chain/pending/rebuild", nil)
+	pendingAdvanceMeter = metrics.NewRegisteredMeter("chain/pending/advance", nil)
+	pendingAppendMeter  = metrics.NewRegisteredMeter("chain/pending/append", nil)
+	pendingRebuildTimer = metrics.NewRegisteredTimer("chain/pending/rebuildtime", nil)
+)
+
+// PendingTxSource is the transaction pool the pending state is built from.
+type PendingTxSource interface {
+	// Executable returns the executable transactions of the pool in inclusion
+	// order, priced against the given base fee.
+	Executable(baseFee *big.Int) []*types.Transaction
+
+	// SubscribeTransactions subscribes to new transactions entering the pool.
+	SubscribeTransactions(ch chan<- NewTxsEvent, reorgs bool) event.Subscription
+}
+
+// PendingStateEvent is posted when the pending state changes. Reset is set if
+// the state was rebuilt from the chain head, in which case Txs contains all the
+// applied transactions instead of only the appended ones.
+type PendingStateEvent struct {
+	Header *types.Header
+	Txs    []*types.Transaction
+	Reset  bool
+}
+
+// PendingState maintains the speculative state of the block following the
+// chain head, with the executable pool transactions applied on top. On a new
+// head extending the previous one, only the applied transactions not included
+// in its block are carried over and re-applied; the whole pool is executed again
+// only on reorgs and transaction replacements. In between heads, the state is
+// extended in place with new transactions as long as they continue the nonce
+// sequence of their senders. Blob transactions are not applied, and the pool
+// transactions not fitting the gas limit are only picked up on a rebuild.
+type PendingState struct {
+	chain  *BlockChain
+	source PendingTxSource
+	signer types.Signer
+
+	lock     sync.Mutex           // Also guards reads, as the state caches accessed objects
+	header   *types.Header        // Speculative header of the pending block
+	state    *state.StateDB       // State with the pending transactions applied
+	txs      []*types.Transaction // Transactions applied to the pending state
+	included map[common.Hash]bool // Hashes of the applied transactions
+	gasPool  *GasPool             // Gas left in the pending block
+
+	feed event.Feed
+}
+
+// NewPendingState creates a pending state on top of the current chain head and
+// keeps it updated until the chain is stopped.
+func NewPendingState(chain *BlockChain, source PendingTxSource) *PendingState {
+	p := &PendingState{
+		chain:  chain,
+		source: source,
+		signer: types.LatestSigner(chain.Config()),
+	}
+	p.rebuild(chain.CurrentBlock())
+	chain.tasks.spawn("pendingstate", TaskHigh, RestartOnPanic, p.loop)
+	return p
+}
+
+// loop updates the pending state on chain head and pool events.
+func (p *PendingState) loop(quit <-chan struct{}) {
+	var (
+		headCh = make(chan ChainHeadEvent, 10)
+		txsCh  = make(chan NewTxsEvent, pendingTxChanSize)
+	)
+	headSub := p.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+	txsSub := p.source.SubscribeTransactions(txsCh, false)
+	defer txsSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			p.advance(ev.Header)
+		case ev := <-txsCh:
+			p.append(ev.Txs)
+		case <-headSub.Err():
+			return
+		case <-txsSub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// rebuild recreates the pending state on top of the given head.
+func (p *PendingState) rebuild(head *types.Header) {
+	start := time.Now()
+
+	statedb, err := p.chain.StateAt(head.Root)
+	if err != nil {
+		log.Warn("Failed to rebuild pending state", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	header := p.pendingHeader(head)
+
+	p.lock.Lock()
+	p.header, p.state = header, statedb
+	p.txs, p.included = nil, make(map[common.Hash]bool)
+	p.gasPool = new(GasPool).AddGas(header.GasLimit)
+	p.applyLocked(p.source.Executable(header.BaseFee))
+	ev := PendingStateEvent{Header: types.CopyHeader(header), Txs: slices.Clone(p.txs), Reset: true}
+	p.lock.Unlock()
+
+	pendingRebuildMeter.Mark(1)
+	pendingRebuildTimer.UpdateSince(start)
+	p.feed.Send(ev)
+}
+
+// advance moves the pending state on top of a new head. If the head extends the
+// previous one, the applied transactions not included in its block are carried
+// over, otherwise the pending state is rebuilt from the pool.
+func (p *PendingState) advance(head *types.Header) {
+	p.lock.Lock()
+	if p.header == nil || head.ParentHash != p.header.ParentHash {
+		p.lock.Unlock()
+		p.rebuild(head)
+		return
+	}
+	carried := p.txs
+	p.lock.Unlock()
+
+	start := time.Now()
+
+	block := p.chain.GetBlock(head.Hash(), head.Number.Uint64())
+	if block == nil {
+		p.rebuild(head)
+		return
+	}
+	statedb, err := p.chain.StateAt(head.Root)
+	if err != nil {
+		log.Warn("Failed to advance pending state", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	// Drop the transactions included in the block, the ones invalidated by it
+	// are skipped by their nonce when applied
+	included := make(map[common.Hash]bool, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		included[tx.Hash()] = true
+	}
+	carried = slices.DeleteFunc(slices.Clone(carried), func(tx *types.Transaction) bool {
+		return included[tx.Hash()]
+	})
+	header := p.pendingHeader(head)
+
+	p.lock.Lock()
+	p.header, p.state = header, statedb
+	p.txs, p.included = nil, make(map[common.Hash]bool)
+	p.gasPool = new(GasPool).AddGas(header.GasLimit)
+	p.applyLocked(carried)
+	ev := PendingStateEvent{Header: types.CopyHeader(header), Txs: slices.Clone(p.txs), Reset: true}
+	p.lock.Unlock()
+
+	pendingAdvanceMeter.Mark(1)
+	pendingRebuildTimer.UpdateSince(start)
+	p.feed.Send(ev)
+}
+
+// append applies new pool transactions on top of the pending state. If any of
+// them replaces an already applied transaction, the state is rebuilt instead.
+func (p *PendingState) append(txs []*types.Transaction) {
+	p.lock.Lock()
+	if p.state == nil {
+		p.lock.Unlock()
+		return
+	}
+	// Order the transactions by sender and nonce, checking for replacements
+	var (
+		bySender = make(map[common.Address][]*types.Transaction)
+		senders  []common.Address
+	)
+	for _, tx := range txs {
+		if tx.Type() == types.BlobTxType || p.included[tx.Hash()] {
+			continue
+		}
+		from, err := types.Sender(p.signer, tx)
+		if err != nil {
+			continue
+		}
+		if tx.Nonce() < p.state.GetNonce(from) {
+			head := p.chain.CurrentBlock()
+			p.lock.Unlock()
+			p.rebuild(head)
+			return
+		}
+		if _, ok := bySender[from]; !ok {
+			senders = append(senders, from)
+		}
+		bySender[from] = append(bySender[from], tx)
+	}
+	ordered := make([]*types.Transaction, 0, len(txs))
+	for _, from := range senders {
+		list := bySender[from]
+		slices.SortFunc(list, func(a, b *types.Transaction) int {
+			return cmp.Compare(a.Nonce(), b.Nonce())
+		})
+		ordered = append(ordered, list...)
+	}
+	applied := p.applyLocked(ordered)
+	if len(applied) == 0 {
+		p.lock.Unlock()
+		return
+	}
+	ev := PendingStateEvent{Header: types.CopyHeader(p.header), Txs: applied}
+	p.lock.Unlock()
+
+	pendingAppendMeter.Mark(int64(len(applied)))
+	p.feed.Send(ev)
+}
+
+// applyLocked executes the transactions on the pending state, skipping those
+// which are not executable, and returns the applied ones. Transactions not
+// matching the pending nonce of their sender are skipped, so once a transaction
+// fails, the later ones of the same sender are skipped too. The lock is assumed
+// to be held.
+func (p *PendingState) applyLocked(txs []*types.Transaction) []*types.Transaction {
+	var (
+		applied []*types.Transaction
+		evm     = vm.NewEVM(NewEVMBlockContext(p.header, p.chain, &p.header.Coinbase), p.state, p.chain.Config(), vm.Config{})
+	)
+	for _, tx := range txs {
+		if tx.Type() == types.BlobTxType || p.gasPool.Gas() < tx.Gas() {
+			continue
+		}
+		from, err := types.Sender(p.signer, tx)
+		if err != nil || tx.Nonce() != p.state.GetNonce(from) {
+			continue
+		}
+		var (
+			snap = p.state.Snapshot()
+			gas  = p.gasPool.Gas()
+		)
+		p.state.SetTxContext(tx.Hash(), len(p.txs))
+		if _, err := ApplyTransaction(evm, p.gasPool, p.state, p.header, tx, &p.header.GasUsed); err != nil {
+			log.Trace("Skipping pending transaction", "hash", tx.Hash(), "sender", from, "err", err)
+			p.state.RevertToSnapshot(snap)
+			p.gasPool.SetGas(gas)
+			continue
+		}
+		p.txs = append(p.txs, tx)
+		p.included[tx.Hash()] = true
+		applied = append(applied, tx)
+	}
+	return applied
+}
+
+// pendingHeader assembles the speculative header of the block following the
+// given head.
+func (p *PendingState) pendingHeader(parent *types.Header) *types.Header {
+	config := p.chain.Config()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       max(parent.Time+1, uint64(time.Now().Unix())),
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent)
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		var excessBlobGas uint64
+		if config.IsCancun(parent.Number, parent.Time) {
+			excessBlobGas = eip4844.CalcExcessBlobGas(config, parent, header.Time)
+		}
+		header.BlobGasUsed = new(uint64)
+		header.ExcessBlobGas = &excessBlobGas
+	}
+	return header
+}
+
+// Header returns the speculative header of the pending block.
+func (p *PendingState) Header() *types.Header {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.header == nil {
+		return nil
+	}
+	return types.CopyHeader(p.header)
+}
+
+// Transactions returns the transactions applied to the pending state.
+func (p *PendingState) Transactions() []*types.Transaction {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return slices.Clone(p.txs)
+}
+
+// Nonce returns the nonce of an account in the pending state.
+func (p *PendingState) Nonce(addr common.Address) uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.state == nil {
+		return 0
+	}
+	return p.state.GetNonce(addr)
+}
+
+// Balance returns the balance of an account in the pending state.
+func (p *PendingState) Balance(addr common.Address) *uint256.Int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.state == nil {
+		return new(uint256.Int)
+	}
+	return new(uint256.Int).Set(p.state.GetBalance(addr))
+}
+
+// State returns a copy of the pending state along with its header.
+func (p *PendingState) State() (*state.StateDB, *types.Header) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.state == nil {
+		return nil, nil
+	}
+	return p.state.Copy(), types.CopyHeader(p.header)
+}
+
+// SubscribePendingStateEvent registers a subscription of PendingStateEvent.
+func (p *PendingState) SubscribePendingStateEvent(ch chan<- PendingStateEvent) event.Subscription {
+	return p.chain.scope.Track(p.feed.Subscribe(ch))
+}
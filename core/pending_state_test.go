@@ -0,0 +1,149 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testPendingSource is a transaction pool serving a fixed set of transactions.
+type testPendingSource struct {
+	lock sync.Mutex
+	txs  []*types.Transaction
+	feed event.Feed
+}
+
+func (s *testPendingSource) Executable(baseFee *big.Int) []*types.Transaction {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.txs
+}
+
+func (s *testPendingSource) SubscribeTransactions(ch chan<- NewTxsEvent, reorgs bool) event.Subscription {
+	return s.feed.Subscribe(ch)
+}
+
+func (s *testPendingSource) set(txs ...*types.Transaction) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.txs = txs
+}
+
+// announce delivers new transactions once the pending state subscribed.
+func (s *testPendingSource) announce(txs ...*types.Transaction) {
+	for s.feed.Send(NewTxsEvent{Txs: txs}) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitPendingStateEvent(t *testing.T, events chan PendingStateEvent) PendingStateEvent {
+	t.Helper()
+
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("pending state event not announced")
+	}
+	return PendingStateEvent{}
+}
+
+// Tests that the pending state applies the pool transactions on top of the
+// head, extends them incrementally and carries them over to new heads.
+func TestPendingState(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.Address{0xaa}
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		txs    = make([]*types.Transaction, 3)
+	)
+	for i := range txs {
+		txs[i], _ = types.SignTx(types.NewTransaction(uint64(i), to, big.NewInt(1), params.TxGas, big.NewInt(2*params.InitialBaseFee), nil), signer, key)
+	}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	source := new(testPendingSource)
+	source.set(txs[0], txs[1])
+	pending := NewPendingState(chain, source)
+
+	if nonce := pending.Nonce(sender); nonce != 2 {
+		t.Fatalf("pending nonce mismatch: have %d, want 2", nonce)
+	}
+	if balance := pending.Balance(to); balance.Uint64() != 2 {
+		t.Fatalf("pending balance mismatch: have %v, want 2", balance)
+	}
+	if header := pending.Header(); header.Number.Uint64() != 1 {
+		t.Fatalf("pending number mismatch: have %d, want 1", header.Number)
+	}
+	events := make(chan PendingStateEvent, 1)
+	sub := pending.SubscribePendingStateEvent(events)
+	defer sub.Unsubscribe()
+
+	// Announce a new transaction and check it's appended
+	source.set(txs...)
+	source.announce(txs[2])
+
+	ev := waitPendingStateEvent(t, events)
+	if ev.Reset || len(ev.Txs) != 1 || ev.Txs[0].Hash() != txs[2].Hash() {
+		t.Fatalf("append event mismatch: reset %v, txs %d", ev.Reset, len(ev.Txs))
+	}
+	if nonce := pending.Nonce(sender); nonce != 3 {
+		t.Fatalf("pending nonce mismatch: have %d, want 3", nonce)
+	}
+	// Include the first transaction in a block and check the rest is carried over
+	// to the new head without executing the pool again
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		b.AddTx(txs[0])
+	})
+	source.set()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	ev = waitPendingStateEvent(t, events)
+	if !ev.Reset || len(ev.Txs) != 2 || ev.Header.Number.Uint64() != 2 {
+		t.Fatalf("rebuild event mismatch: reset %v, txs %d, number %d", ev.Reset, len(ev.Txs), ev.Header.Number)
+	}
+	if nonce := pending.Nonce(sender); nonce != 3 {
+		t.Fatalf("pending nonce mismatch: have %d, want 3", nonce)
+	}
+	if balance := pending.Balance(to); balance.Uint64() != 3 {
+		t.Fatalf("pending balance mismatch: have %v, want 3", balance)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that PreCommitBlockEvent is sent for every valid imported block, in
+// import order, and never for the blocks failing validation.
+func TestPreCommitBlockEvent(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan PreCommitBlockEvent, len(blocks))
+	sub := chain.SubscribePreCommitBlockEvent(events)
+	defer sub.Unsubscribe()
+
+	// Import all but the last block, and the last one with a bad state root
+	if n, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	header := blocks[3].Header()
+	header.Root = common.Hash{0xff}
+	bad := types.NewBlockWithHeader(header).WithBody(*blocks[3].Body())
+	if _, err := chain.InsertChain(types.Blocks{bad}); err == nil {
+		t.Fatal("block with bad state root imported")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case ev := <-events:
+			if ev.Block.Hash() != blocks[i].Hash() {
+				t.Fatalf("event %d: block mismatch: have %x, want %x", i, ev.Block.Hash(), blocks[i].Hash())
+			}
+		default:
+			t.Fatalf("event %d: missing", i)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event for block %d %x", ev.Block.NumberU64(), ev.Block.Hash())
+	default:
+	}
+}
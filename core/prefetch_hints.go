@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	prefetchHintSlotMeter = metrics.NewRegisteredMeter("chain/prefetch/hints/slots", nil)
+	prefetchHintHitMeter  = metrics.NewRegisteredMeter("chain/prefetch/hints/hits", nil)
+)
+
+// ERC-20 function selectors whose calldata reveals the accessed balances and
+// allowances.
+var (
+	erc20Transfer     = [4]byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	erc20TransferFrom = [4]byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	erc20Approve      = [4]byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+)
+
+// erc20StorageLocation is the ERC-7201 namespaced storage of the OpenZeppelin
+// v5 ERC-20 implementation, holding the balances and the allowances in its
+// first two slots.
+var erc20StorageLocation = common.HexToHash("0x52c63247e1f47db19d5ce0460030c497f067ca4cebf71ba98eeadabe20bace00")
+
+// Candidate storage positions of the balance and allowance mappings: slots 0
+// and 1 of the classic OpenZeppelin layout, 1 and 2 of the BEP-20 template
+// inheriting Ownable, and the ERC-7201 namespaced layout.
+var (
+	erc20BalanceBases   = []common.Hash{common.BigToHash(common.Big0), common.BigToHash(common.Big1), erc20StorageLocation}
+	erc20AllowanceBases = []common.Hash{common.BigToHash(common.Big1), common.BigToHash(common.Big2), incrementHash(erc20StorageLocation)}
+)
+
+// prefetchHints is the state a transaction is statically predicted to access,
+// beyond what its access list already declares.
+type prefetchHints struct {
+	accounts []common.Address                 // Accounts whose data and code are likely read
+	slots    map[common.Address][]common.Hash // Storage slots likely accessed, per contract
+}
+
+// staticPrefetchHints predicts the state accessed by a transaction from its
+// calldata, matching the calls of common token ABIs, and its EIP-7702
+// authorizations, without executing it.
+func staticPrefetchHints(tx *types.Transaction, from common.Address) *prefetchHints {
+	hints := &prefetchHints{slots: make(map[common.Address][]common.Hash)}
+
+	// Warm the delegation targets and authorities of set-code transactions
+	for _, auth := range tx.SetCodeAuthorizations() {
+		hints.accounts = append(hints.accounts, auth.Address)
+		if authority, err := auth.Authority(); err == nil {
+			hints.accounts = append(hints.accounts, authority)
+		}
+	}
+	to, data := tx.To(), tx.Data()
+	if to == nil || len(data) < 4 {
+		return hints
+	}
+	hints.accounts = append(hints.accounts, *to)
+
+	var (
+		args     = data[4:]
+		arg      = func(i int) common.Hash { return common.BytesToHash(args[i*32 : (i+1)*32]) }
+		sender   = common.BytesToHash(from.Bytes())
+		balances = func(holders ...common.Hash) {
+			for _, base := range erc20BalanceBases {
+				for _, holder := range holders {
+					hints.slots[*to] = append(hints.slots[*to], mappingSlot(holder, base))
+				}
+			}
+		}
+		allowance = func(owner, spender common.Hash) {
+			for _, base := range erc20AllowanceBases {
+				hints.slots[*to] = append(hints.slots[*to], mappingSlot(spender, mappingSlot(owner, base)))
+			}
+		}
+	)
+	switch [4]byte(data[:4]) {
+	case erc20Transfer:
+		if len(args) >= 64 {
+			balances(sender, arg(0))
+		}
+	case erc20TransferFrom:
+		if len(args) >= 96 {
+			balances(arg(0), arg(1))
+			allowance(arg(0), sender)
+		}
+	case erc20Approve:
+		if len(args) >= 64 {
+			allowance(sender, arg(0))
+		}
+	}
+	// Drop the slots declared in the access list, they are warmed already
+	for _, tuple := range tx.AccessList() {
+		if slots, ok := hints.slots[tuple.Address]; ok {
+			hints.slots[tuple.Address] = slices.DeleteFunc(slots, func(slot common.Hash) bool {
+				return slices.Contains(tuple.StorageKeys, slot)
+			})
+		}
+	}
+	return hints
+}
+
+// warm loads the predicted state into the given state database, following the
+// delegations of the predicted accounts.
+func (h *prefetchHints) warm(statedb *state.StateDB) {
+	for _, addr := range h.accounts {
+		if target, ok := types.ParseDelegation(statedb.GetCode(addr)); ok {
+			statedb.GetCode(target)
+		}
+	}
+	for addr, slots := range h.slots {
+		for _, slot := range slots {
+			statedb.GetState(addr, slot)
+		}
+	}
+}
+
+// measure reports the accuracy of the predicted slots against the access list
+// of the executed transaction.
+func (h *prefetchHints) measure(statedb *state.StateDB) {
+	var predicted, hits int64
+	for addr, slots := range h.slots {
+		for _, slot := range slots {
+			predicted++
+			if _, ok := statedb.SlotInAccessList(addr, slot); ok {
+				hits++
+			}
+		}
+	}
+	prefetchHintSlotMeter.Mark(predicted)
+	prefetchHintHitMeter.Mark(hits)
+}
+
+// mappingSlot returns the storage slot of the given key in a Solidity mapping
+// stored at the given position.
+func mappingSlot(key common.Hash, position common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key.Bytes(), position.Bytes())
+}
+
+// incrementHash returns the hash interpreted as a big endian number plus one.
+func incrementHash(h common.Hash) common.Hash {
+	binary.BigEndian.PutUint64(h[24:], binary.BigEndian.Uint64(h[24:])+1)
+	return h
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the balance and allowance slots of token calls are predicted from
+// the calldata, skipping those declared in the access list.
+func TestStaticPrefetchHints(t *testing.T) {
+	var (
+		token     = common.HexToAddress("0x1000")
+		sender    = common.HexToAddress("0x2000")
+		recipient = common.HexToAddress("0x3000")
+
+		senderKey    = common.BytesToHash(sender.Bytes())
+		recipientKey = common.BytesToHash(recipient.Bytes())
+		amount       = common.BigToHash(common.Big1)
+	)
+	calldata := func(selector [4]byte, args ...common.Hash) []byte {
+		data := selector[:]
+		for _, arg := range args {
+			data = append(data, arg.Bytes()...)
+		}
+		return data
+	}
+	// A transfer touches the balances of both parties
+	transfer := types.NewTx(&types.AccessListTx{
+		To:   &token,
+		Data: calldata(erc20Transfer, recipientKey, amount),
+		AccessList: types.AccessList{{
+			Address:     token,
+			StorageKeys: []common.Hash{mappingSlot(recipientKey, common.Hash{})},
+		}},
+	})
+	hints := staticPrefetchHints(transfer, sender)
+	if !slices.Contains(hints.accounts, token) {
+		t.Errorf("token account not predicted")
+	}
+	slots := hints.slots[token]
+	if want := 2*len(erc20BalanceBases) - 1; len(slots) != want {
+		t.Fatalf("predicted slot count mismatch: have %d, want %d", len(slots), want)
+	}
+	if !slices.Contains(slots, mappingSlot(senderKey, common.Hash{})) {
+		t.Errorf("sender balance not predicted")
+	}
+	if !slices.Contains(slots, mappingSlot(recipientKey, erc20StorageLocation)) {
+		t.Errorf("namespaced recipient balance not predicted")
+	}
+	if slices.Contains(slots, mappingSlot(recipientKey, common.Hash{})) {
+		t.Errorf("access list slot predicted")
+	}
+	// An approval touches the allowance of the sender only
+	approve := types.NewTx(&types.LegacyTx{To: &token, Data: calldata(erc20Approve, recipientKey, amount)})
+	slots = staticPrefetchHints(approve, sender).slots[token]
+	if want := mappingSlot(recipientKey, mappingSlot(senderKey, common.BigToHash(common.Big1))); !slices.Contains(slots, want) {
+		t.Errorf("allowance not predicted")
+	}
+	// Truncated calldata and unknown selectors predict no slots
+	truncated := types.NewTx(&types.LegacyTx{To: &token, Data: calldata(erc20TransferFrom, senderKey)})
+	if slots := staticPrefetchHints(truncated, sender).slots[token]; len(slots) != 0 {
+		t.Errorf("truncated call predicted %d slots", len(slots))
+	}
+	// Set-code transactions predict their delegation targets and authorities
+	key, _ := crypto.GenerateKey()
+	auth, err := types.SignSetCode(key, types.SetCodeAuthorization{ChainID: *uint256.NewInt(1), Address: token})
+	if err != nil {
+		t.Fatalf("failed to sign authorization: %v", err)
+	}
+	setcode := types.NewTx(&types.SetCodeTx{To: recipient, AuthList: []types.SetCodeAuthorization{auth}})
+	hints = staticPrefetchHints(setcode, sender)
+	if !slices.Contains(hints.accounts, token) || !slices.Contains(hints.accounts, crypto.PubkeyToAddress(key.PublicKey)) {
+		t.Errorf("set-code accounts not predicted: %v", hints.accounts)
+	}
+}
@@ -232,6 +232,22 @@ func WriteFinalizedBlockHash(db ethdb.KeyValueWriter, hash common.Hash) {
 	}
 }
 
+// ReadSafeBlockHash retrieves the hash of the safe block.
+func ReadSafeBlockHash(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(headSafeBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteSafeBlockHash stores the hash of the safe block.
+func WriteSafeBlockHash(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(headSafeBlockKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last safe block's hash", "err", err)
+	}
+}
+
 // ReadLastPivotNumber retrieves the number of the last pivot block. If the node
 // full synced, the last pivot will always be nil.
 func ReadLastPivotNumber(db ethdb.KeyValueReader) *uint64 {
@@ -277,6 +293,44 @@ func WriteTxIndexTail(db ethdb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadReceiptTail retrieves the number of oldest block whose receipts and
+// log index are retained, nil if receipts were never pruned.
+func ReadReceiptTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(receiptTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteReceiptTail stores the number of oldest block whose receipts are
+// retained into database.
+func WriteReceiptTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(receiptTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the receipt tail", "err", err)
+	}
+}
+
+// ReadBlobSidecarTail retrieves the number of oldest block whose blob sidecars
+// are retained. If the sidecars of all blocks are retained, nil is returned.
+func ReadBlobSidecarTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(blobSidecarTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteBlobSidecarTail stores the number of oldest block whose blob sidecars
+// are retained into database.
+func WriteBlobSidecarTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(blobSidecarTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the blob sidecar tail", "err", err)
+	}
+}
+
 // ReadHeaderRange returns the rlp-encoded headers, starting at 'number', and going
 // backwards towards genesis. This method assumes that the caller already has
 // placed a cap on count, to prevent DoS issues.
@@ -682,6 +736,159 @@ func DeleteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// RevertReason is the revert data returned by a failed transaction, stored
+// alongside the receipts of its block.
+type RevertReason struct {
+	TxIndex uint64 // Position of the transaction in the block
+	Reason  []byte // Revert data, possibly truncated
+}
+
+// ReadRevertReasons retrieves the revert reasons of the failed transactions of
+// a block. Nil is returned if they were not recorded for the block.
+func ReadRevertReasons(db ethdb.KeyValueReader, hash common.Hash, number uint64) []*RevertReason {
+	data, _ := db.Get(revertReasonsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var reasons []*RevertReason
+	if err := rlp.DecodeBytes(data, &reasons); err != nil {
+		log.Error("Invalid revert reasons RLP", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return reasons
+}
+
+// ReadRevertReason retrieves the revert reason of a single transaction of a
+// block, or nil if the transaction didn't revert or the reasons of the block
+// were not recorded.
+func ReadRevertReason(db ethdb.KeyValueReader, hash common.Hash, number uint64, txIndex uint64) []byte {
+	for _, reason := range ReadRevertReasons(db, hash, number) {
+		if reason.TxIndex == txIndex {
+			return reason.Reason
+		}
+	}
+	return nil
+}
+
+// WriteRevertReasons stores the revert reasons of the failed transactions of
+// a block.
+func WriteRevertReasons(db ethdb.KeyValueWriter, hash common.Hash, number uint64, reasons []*RevertReason) {
+	bytes, err := rlp.EncodeToBytes(reasons)
+	if err != nil {
+		log.Crit("Failed to encode revert reasons", "err", err)
+	}
+	if err := db.Put(revertReasonsKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store revert reasons", "err", err)
+	}
+}
+
+// DeleteRevertReasons removes the revert reasons associated with a block hash.
+func DeleteRevertReasons(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(revertReasonsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete revert reasons", "err", err)
+	}
+}
+
+// ReadAddressFilter retrieves the address filter list read from the state of
+// the given block, and false if it was not recorded.
+func ReadAddressFilter(db ethdb.KeyValueReader, hash common.Hash) ([]common.Address, bool) {
+	data, _ := db.Get(addressFilterKey(hash))
+	if len(data) == 0 {
+		return nil, false
+	}
+	var addrs []common.Address
+	if err := rlp.DecodeBytes(data, &addrs); err != nil {
+		log.Error("Invalid address filter RLP", "hash", hash, "err", err)
+		return nil, false
+	}
+	return addrs, true
+}
+
+// WriteAddressFilter stores the address filter list read from the state of the
+// given block.
+func WriteAddressFilter(db ethdb.KeyValueWriter, hash common.Hash, addrs []common.Address) {
+	bytes, err := rlp.EncodeToBytes(addrs)
+	if err != nil {
+		log.Crit("Failed to encode address filter", "err", err)
+	}
+	if err := db.Put(addressFilterKey(hash), bytes); err != nil {
+		log.Crit("Failed to store address filter", "err", err)
+	}
+}
+
+// ReadTotalSupply retrieves the total native supply after the given block, or
+// nil if it was not tracked.
+func ReadTotalSupply(db ethdb.KeyValueReader, hash common.Hash, number uint64) *big.Int {
+	data, _ := db.Get(totalSupplyKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	supply := new(big.Int)
+	if err := rlp.DecodeBytes(data, supply); err != nil {
+		log.Error("Invalid total supply RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return supply
+}
+
+// WriteTotalSupply stores the total native supply after the given block.
+func WriteTotalSupply(db ethdb.KeyValueWriter, hash common.Hash, number uint64, supply *big.Int) {
+	data, err := rlp.EncodeToBytes(supply)
+	if err != nil {
+		log.Crit("Failed to RLP encode total supply", "err", err)
+	}
+	if err := db.Put(totalSupplyKey(number, hash), data); err != nil {
+		log.Crit("Failed to store total supply", "err", err)
+	}
+}
+
+// DeleteTotalSupply removes the total native supply associated with a block hash.
+func DeleteTotalSupply(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(totalSupplyKey(number, hash)); err != nil {
+		log.Crit("Failed to delete total supply", "err", err)
+	}
+}
+
+// feeTotals is the storage encoding of the cumulative fees up to a block.
+type feeTotals struct {
+	Burnt *big.Int
+	Blob  *big.Int
+}
+
+// ReadFeeTotals retrieves the base fees burnt and the blob fees paid by all
+// blocks up to and including the given one, or nils if they were not tracked.
+func ReadFeeTotals(db ethdb.KeyValueReader, hash common.Hash, number uint64) (burnt *big.Int, blob *big.Int) {
+	data, _ := db.Get(feeTotalsKey(number, hash))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var totals feeTotals
+	if err := rlp.DecodeBytes(data, &totals); err != nil {
+		log.Error("Invalid fee totals RLP", "hash", hash, "err", err)
+		return nil, nil
+	}
+	return totals.Burnt, totals.Blob
+}
+
+// WriteFeeTotals stores the cumulative burnt base fees and blob fees up to and
+// including the given block.
+func WriteFeeTotals(db ethdb.KeyValueWriter, hash common.Hash, number uint64, burnt *big.Int, blob *big.Int) {
+	data, err := rlp.EncodeToBytes(&feeTotals{Burnt: burnt, Blob: blob})
+	if err != nil {
+		log.Crit("Failed to RLP encode fee totals", "err", err)
+	}
+	if err := db.Put(feeTotalsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store fee totals", "err", err)
+	}
+}
+
+// DeleteFeeTotals removes the cumulative fees associated with a block hash.
+func DeleteFeeTotals(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(feeTotalsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete fee totals", "err", err)
+	}
+}
+
 // storedReceiptRLP is the storage encoding of a receipt.
 // Re-definition in core/types/receipt.go.
 // TODO: Re-use the existing definition.
@@ -871,6 +1078,48 @@ func DeleteBlobSidecars(db ethdb.KeyValueWriter, hash common.Hash, number uint64
 	}
 }
 
+// PruneBlobSidecars deletes the blob sidecars of the canonical and side blocks
+// in the range [from, to) from the key-value store, returning the size of the
+// deleted data. Only the stored sidecars are visited, the blocks without any
+// are skipped over.
+func PruneBlobSidecars(db ethdb.KeyValueStore, from, to uint64) uint64 {
+	var (
+		it     = db.NewIterator(BlockBlobSidecarsPrefix, encodeBlockNumber(from))
+		batch  = db.NewBatch()
+		blocks int64
+		bytes  uint64
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(BlockBlobSidecarsPrefix)+8+common.HashLength {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(BlockBlobSidecarsPrefix):]) >= to {
+			break
+		}
+		if err := batch.Delete(key); err != nil {
+			log.Crit("Failed to delete blob sidecars", "err", err)
+		}
+		blocks++
+		bytes += uint64(len(it.Value()))
+
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to prune blob sidecars", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to prune blob sidecars", "err", err)
+	}
+	blobPruneMeter.Mark(blocks)
+	blobPruneBytesMeter.Mark(int64(bytes))
+	return bytes
+}
+
 func writeAncientBlock(op ethdb.AncientWriteOp, block *types.Block, header *types.Header, receipts []*types.ReceiptForStorage, td *big.Int) error {
 	num := block.NumberU64()
 	if err := op.AppendRaw(ChainFreezerHashTable, num, block.Hash().Bytes()); err != nil {
@@ -899,9 +1148,15 @@ func writeAncientBlock(op ethdb.AncientWriteOp, block *types.Block, header *type
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteRevertReasons(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
+	DeleteTotalSupply(db, hash, number)
+	DeleteFeeTotals(db, hash, number)
+	DeleteContractChanges(db, hash, number)
+	DeleteHusks(db, hash, number)
+	DeleteBlockAccessList(db, hash, number)
 	DeleteBlobSidecars(db, hash, number) // it is safe to delete non-exist blob
 }
 
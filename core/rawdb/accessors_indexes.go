@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +180,381 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// UncleIndexEntry is the reward attribution of an uncle included in a canonical
+// block, indexed by the miner of the uncle.
+type UncleIndexEntry struct {
+	Miner           common.Address // Coinbase of the uncle
+	BlockNumber     uint64         // Number of the block including the uncle
+	BlockHash       common.Hash    // Hash of the block including the uncle
+	Index           uint64         // Position of the uncle in the including block
+	UncleNumber     uint64         // Number of the uncle
+	UncleHash       common.Hash    // Hash of the uncle
+	Reward          *big.Int       // Reward credited to the miner of the uncle
+	Includer        common.Address // Coinbase of the block including the uncle
+	InclusionReward *big.Int       // Reward credited to the includer for the uncle
+}
+
+// WriteUncleIndexEntry stores the reward attribution of an uncle.
+func WriteUncleIndexEntry(db ethdb.KeyValueWriter, entry *UncleIndexEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode uncle index entry", "err", err)
+	}
+	if err := db.Put(uncleIndexKey(entry.Miner, entry.BlockNumber, entry.BlockHash, int(entry.Index)), data); err != nil {
+		log.Crit("Failed to store uncle index entry", "err", err)
+	}
+}
+
+// DeleteUncleIndexEntry removes the reward attribution of an uncle.
+func DeleteUncleIndexEntry(db ethdb.KeyValueWriter, miner common.Address, number uint64, hash common.Hash, index int) {
+	if err := db.Delete(uncleIndexKey(miner, number, hash, index)); err != nil {
+		log.Crit("Failed to delete uncle index entry", "err", err)
+	}
+}
+
+// ReadUncleIndexEntries retrieves the reward attributions of all the uncles mined
+// by the given miner and included in blocks within the given inclusive range.
+// Entries of blocks which are no longer canonical are not filtered out.
+func ReadUncleIndexEntries(db ethdb.Iteratee, miner common.Address, from uint64, to uint64) []*UncleIndexEntry {
+	prefix := append(append([]byte{}, uncleIndexPrefix...), miner.Bytes()...)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var entries []*UncleIndexEntry
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+8+common.HashLength+1 {
+			continue
+		}
+		if binary.BigEndian.Uint64(it.Key()[len(prefix):]) > to {
+			break
+		}
+		entry := new(UncleIndexEntry)
+		if err := rlp.DecodeBytes(it.Value(), entry); err != nil {
+			log.Error("Invalid uncle index entry RLP", "key", it.Key(), "err", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ContractChange is the change of the storage and code of a contract caused by
+// the execution of a block.
+type ContractChange struct {
+	Address      common.Address // Address of the contract
+	SlotsCreated uint64         // Number of storage slots set from empty
+	SlotsDeleted uint64         // Number of storage slots cleared, including wiped ones
+	CodeChanged  bool           // Whether the code was deployed or destructed
+	CodeSize     uint64         // Size of the code after the block
+	PrevCodeSize uint64         // Size of the code before the block
+}
+
+// ReadContractChanges retrieves the contract changes caused by the given block.
+func ReadContractChanges(db ethdb.KeyValueReader, hash common.Hash, number uint64) []*ContractChange {
+	data, _ := db.Get(contractChangesKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var changes []*ContractChange
+	if err := rlp.DecodeBytes(data, &changes); err != nil {
+		log.Error("Invalid contract changes RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return changes
+}
+
+// WriteContractChanges stores the contract changes caused by the given block.
+func WriteContractChanges(db ethdb.KeyValueWriter, hash common.Hash, number uint64, changes []*ContractChange) {
+	data, err := rlp.EncodeToBytes(changes)
+	if err != nil {
+		log.Crit("Failed to RLP encode contract changes", "err", err)
+	}
+	if err := db.Put(contractChangesKey(number, hash), data); err != nil {
+		log.Crit("Failed to store contract changes", "err", err)
+	}
+}
+
+// DeleteContractChanges removes the contract changes associated with a block hash.
+func DeleteContractChanges(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(contractChangesKey(number, hash)); err != nil {
+		log.Crit("Failed to delete contract changes", "err", err)
+	}
+}
+
+// ReadHusks retrieves the contracts which survived a SELFDESTRUCT without any
+// balance left in the given block.
+func ReadHusks(db ethdb.KeyValueReader, hash common.Hash, number uint64) []common.Address {
+	data, _ := db.Get(husksKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var husks []common.Address
+	if err := rlp.DecodeBytes(data, &husks); err != nil {
+		log.Error("Invalid selfdestruct husks RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return husks
+}
+
+// WriteHusks stores the contracts which survived a SELFDESTRUCT without any
+// balance left in the given block.
+func WriteHusks(db ethdb.KeyValueWriter, hash common.Hash, number uint64, husks []common.Address) {
+	data, err := rlp.EncodeToBytes(husks)
+	if err != nil {
+		log.Crit("Failed to RLP encode selfdestruct husks", "err", err)
+	}
+	if err := db.Put(husksKey(number, hash), data); err != nil {
+		log.Crit("Failed to store selfdestruct husks", "err", err)
+	}
+}
+
+// DeleteHusks removes the selfdestruct husks associated with a block hash.
+func DeleteHusks(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(husksKey(number, hash)); err != nil {
+		log.Crit("Failed to delete selfdestruct husks", "err", err)
+	}
+}
+
+// ReadBlockAccessList retrieves the accounts and storage slots accessed by the
+// execution of the given block.
+func ReadBlockAccessList(db ethdb.KeyValueReader, hash common.Hash, number uint64) types.BlockAccessList {
+	data, _ := db.Get(blockAccessListKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var list types.BlockAccessList
+	if err := rlp.DecodeBytes(data, &list); err != nil {
+		log.Error("Invalid block access list RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return list
+}
+
+// WriteBlockAccessList stores the accounts and storage slots accessed by the
+// execution of the given block.
+func WriteBlockAccessList(db ethdb.KeyValueWriter, hash common.Hash, number uint64, list types.BlockAccessList) {
+	data, err := rlp.EncodeToBytes(list)
+	if err != nil {
+		log.Crit("Failed to RLP encode block access list", "err", err)
+	}
+	if err := db.Put(blockAccessListKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block access list", "err", err)
+	}
+}
+
+// DeleteBlockAccessList removes the access list associated with a block hash.
+func DeleteBlockAccessList(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockAccessListKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block access list", "err", err)
+	}
+}
+
+// ContractStats are the storage and code statistics of a contract, accumulated
+// from the changes of the canonical blocks since the tracking was enabled.
+type ContractStats struct {
+	Address      common.Address // Address of the contract
+	SlotsCreated uint64         // Number of storage slots set from empty
+	SlotsDeleted uint64         // Number of storage slots cleared
+	CodeSize     uint64         // Current size of the code
+	FirstBlock   uint64         // Number of the first block changing the contract
+	LastBlock    uint64         // Number of the last block changing the contract
+}
+
+// Slots returns the net number of storage slots created by the tracked blocks.
+// It is only the full slot count of the contract if it was deployed after the
+// tracking was enabled, and may be negative otherwise.
+func (s *ContractStats) Slots() int64 {
+	return int64(s.SlotsCreated) - int64(s.SlotsDeleted)
+}
+
+// GrowthRate returns the average number of storage slots created per block
+// between the first and the last block changing the contract.
+func (s *ContractStats) GrowthRate() float64 {
+	return float64(s.Slots()) / float64(s.LastBlock-s.FirstBlock+1)
+}
+
+// ReadContractStats retrieves the statistics of a contract, or nil if none of
+// the tracked blocks changed it.
+func ReadContractStats(db ethdb.KeyValueReader, addr common.Address) *ContractStats {
+	data, _ := db.Get(contractStatsKey(addr))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(ContractStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid contract statistics RLP", "addr", addr, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteContractStats stores the statistics of a contract.
+func WriteContractStats(db ethdb.KeyValueWriter, stats *ContractStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to RLP encode contract statistics", "err", err)
+	}
+	if err := db.Put(contractStatsKey(stats.Address), data); err != nil {
+		log.Crit("Failed to store contract statistics", "err", err)
+	}
+}
+
+// DeleteContractStats removes the statistics of a contract.
+func DeleteContractStats(db ethdb.KeyValueWriter, addr common.Address) {
+	if err := db.Delete(contractStatsKey(addr)); err != nil {
+		log.Crit("Failed to delete contract statistics", "err", err)
+	}
+}
+
+// HasContractChangesApplied reports whether the contract changes of the given
+// block are accumulated into the contract statistics.
+func HasContractChangesApplied(db ethdb.KeyValueReader, hash common.Hash, number uint64) bool {
+	has, _ := db.Has(contractAppliedKey(number, hash))
+	return has
+}
+
+// WriteContractChangesApplied marks the contract changes of the given block as
+// accumulated into the contract statistics.
+func WriteContractChangesApplied(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Put(contractAppliedKey(number, hash), []byte{0x01}); err != nil {
+		log.Crit("Failed to store contract changes marker", "err", err)
+	}
+}
+
+// DeleteContractChangesApplied removes the marker of the contract changes of
+// the given block being accumulated into the contract statistics.
+func DeleteContractChangesApplied(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(contractAppliedKey(number, hash)); err != nil {
+		log.Crit("Failed to delete contract changes marker", "err", err)
+	}
+}
+
+// IterateContractStats calls fn with the statistics of all the tracked contracts.
+func IterateContractStats(db ethdb.Iteratee, fn func(*ContractStats)) {
+	it := db.NewIterator(contractStatsPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(contractStatsPrefix)+common.AddressLength {
+			continue
+		}
+		stats := new(ContractStats)
+		if err := rlp.DecodeBytes(it.Value(), stats); err != nil {
+			log.Error("Invalid contract statistics RLP", "key", it.Key(), "err", err)
+			continue
+		}
+		fn(stats)
+	}
+}
+
+// ReadAddressActivity retrieves the bitmap of the blocks within the given chunk
+// the address was active in, or nil if it wasn't active in any of them.
+func ReadAddressActivity(db ethdb.KeyValueReader, addr common.Address, chunk uint64) *ActivityBitmap {
+	data, _ := db.Get(addressActivityKey(addr, chunk))
+	if len(data) == 0 {
+		return nil
+	}
+	bitmap, err := decodeActivityBitmap(data)
+	if err != nil {
+		log.Error("Invalid address activity bitmap", "addr", addr, "chunk", chunk, "err", err)
+		return nil
+	}
+	return bitmap
+}
+
+// WriteAddressActivity stores the activity bitmap of an address within the
+// given chunk, deleting it if empty.
+func WriteAddressActivity(db ethdb.KeyValueWriter, addr common.Address, chunk uint64, bitmap *ActivityBitmap) {
+	if bitmap == nil || bitmap.Len() == 0 {
+		if err := db.Delete(addressActivityKey(addr, chunk)); err != nil {
+			log.Crit("Failed to delete address activity", "err", err)
+		}
+		return
+	}
+	if err := db.Put(addressActivityKey(addr, chunk), bitmap.encode()); err != nil {
+		log.Crit("Failed to store address activity", "err", err)
+	}
+}
+
+// IterateAddressActivity calls fn with the numbers of the blocks the address
+// was active in, in ascending order starting from the given block, until fn
+// returns false.
+func IterateAddressActivity(db ethdb.Iteratee, addr common.Address, from uint64, fn func(number uint64) bool) {
+	prefix := append(append([]byte{}, addressActivityPrefix...), addr.Bytes()...)
+	it := db.NewIterator(prefix, encodeBlockNumber(from>>ActivityChunkBits))
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+8 {
+			continue
+		}
+		chunk := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		bitmap, err := decodeActivityBitmap(it.Value())
+		if err != nil {
+			log.Error("Invalid address activity bitmap", "addr", addr, "chunk", chunk, "err", err)
+			continue
+		}
+		for _, offset := range bitmap.Offsets() {
+			number := chunk<<ActivityChunkBits | uint64(offset)
+			if number < from {
+				continue
+			}
+			if !fn(number) {
+				return
+			}
+		}
+	}
+}
+
+// ReadEquivocation retrieves the evidence of the given signer sealing two
+// different headers at the given height.
+func ReadEquivocation(db ethdb.KeyValueReader, number uint64, signer common.Address) *types.Equivocation {
+	data, _ := db.Get(equivocationKey(number, signer))
+	if len(data) == 0 {
+		return nil
+	}
+	evidence := new(types.Equivocation)
+	if err := rlp.DecodeBytes(data, evidence); err != nil {
+		log.Error("Invalid equivocation evidence RLP", "number", number, "signer", signer, "err", err)
+		return nil
+	}
+	return evidence
+}
+
+// ReadEquivocations retrieves all the equivocation evidence recorded for the
+// heights within the given range, ordered by height.
+func ReadEquivocations(db ethdb.Iteratee, first, last uint64) []*types.Equivocation {
+	it := db.NewIterator(equivocationPrefix, encodeBlockNumber(first))
+	defer it.Release()
+
+	var evidences []*types.Equivocation
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(equivocationPrefix)+8+common.AddressLength {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(equivocationPrefix):]) > last {
+			break
+		}
+		evidence := new(types.Equivocation)
+		if err := rlp.DecodeBytes(it.Value(), evidence); err != nil {
+			log.Error("Invalid equivocation evidence RLP", "key", key, "err", err)
+			continue
+		}
+		evidences = append(evidences, evidence)
+	}
+	return evidences
+}
+
+// WriteEquivocation stores the evidence of a signer sealing two different
+// headers at the same height.
+func WriteEquivocation(db ethdb.KeyValueWriter, evidence *types.Equivocation) {
+	data, err := rlp.EncodeToBytes(evidence)
+	if err != nil {
+		log.Crit("Failed to RLP encode equivocation evidence", "err", err)
+	}
+	if err := db.Put(equivocationKey(evidence.Header1.Number.Uint64(), evidence.Signer), data); err != nil {
+		log.Crit("Failed to store equivocation evidence", "err", err)
+	}
+}
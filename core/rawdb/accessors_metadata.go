@@ -60,6 +60,70 @@ func WriteDatabaseVersion(db ethdb.KeyValueWriter, version uint64) {
 	}
 }
 
+// ReadSchemaVersion retrieves the number of format migrations applied to the
+// database, nil if the database predates the migrations.
+func ReadSchemaVersion(db ethdb.KeyValueReader) *uint64 {
+	enc, _ := db.Get(schemaVersionKey)
+	if len(enc) == 0 {
+		return nil
+	}
+	var version uint64
+	if err := rlp.DecodeBytes(enc, &version); err != nil {
+		return nil
+	}
+	return &version
+}
+
+// WriteSchemaVersion stores the number of format migrations applied to the database.
+func WriteSchemaVersion(db ethdb.KeyValueWriter, version uint64) {
+	enc, err := rlp.EncodeToBytes(version)
+	if err != nil {
+		log.Crit("Failed to encode schema version", "err", err)
+	}
+	if err = db.Put(schemaVersionKey, enc); err != nil {
+		log.Crit("Failed to store the schema version", "err", err)
+	}
+}
+
+// schemaMigration is the progress of an interrupted format migration.
+type schemaMigration struct {
+	Version uint64 // Schema version the migration upgrades to
+	Marker  []byte // Migration specific position to resume from
+}
+
+// readSchemaMigration retrieves the progress of an interrupted format
+// migration, nil if none is in progress.
+func readSchemaMigration(db ethdb.KeyValueReader) *schemaMigration {
+	enc, _ := db.Get(schemaMigrationKey)
+	if len(enc) == 0 {
+		return nil
+	}
+	var progress schemaMigration
+	if err := rlp.DecodeBytes(enc, &progress); err != nil {
+		log.Warn("Failed to decode schema migration progress", "err", err)
+		return nil
+	}
+	return &progress
+}
+
+// writeSchemaMigration stores the progress of a format migration.
+func writeSchemaMigration(db ethdb.KeyValueWriter, progress *schemaMigration) {
+	enc, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode schema migration progress", "err", err)
+	}
+	if err = db.Put(schemaMigrationKey, enc); err != nil {
+		log.Crit("Failed to store the schema migration progress", "err", err)
+	}
+}
+
+// deleteSchemaMigration deletes the progress of a format migration.
+func deleteSchemaMigration(db ethdb.KeyValueWriter) {
+	if err := db.Delete(schemaMigrationKey); err != nil {
+		log.Crit("Failed to delete the schema migration progress", "err", err)
+	}
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 // ReadPreimage retrieves a single preimage of the provided hash.
@@ -284,3 +285,71 @@ func WriteStateHistory(db ethdb.AncientWriter, id uint64, meta []byte, accountIn
 		return nil
 	})
 }
+
+// ReadStatePruningMarker retrieves the last key processed by an interrupted live
+// state pruning, nil if no pruning is in progress.
+func ReadStatePruningMarker(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(statePruningMarkerKey)
+	return data
+}
+
+// WriteStatePruningMarker stores the last key processed by the live state pruning.
+func WriteStatePruningMarker(db ethdb.KeyValueWriter, marker []byte) {
+	if err := db.Put(statePruningMarkerKey, marker); err != nil {
+		log.Crit("Failed to store the state pruning marker", "err", err)
+	}
+}
+
+// DeleteStatePruningMarker deletes the progress marker of the live state pruning.
+func DeleteStatePruningMarker(db ethdb.KeyValueWriter) {
+	if err := db.Delete(statePruningMarkerKey); err != nil {
+		log.Crit("Failed to delete the state pruning marker", "err", err)
+	}
+}
+
+// VerkleTransitionState is the progress of the conversion of the merkle state
+// into the verkle tree, as of a particular state root. The cursors point to the
+// first leaves of the frozen merkle state which are not converted yet.
+type VerkleTransitionState struct {
+	BaseRoot    common.Hash // Root of the frozen merkle state being converted
+	NextAccount common.Hash // Hash of the next account to convert
+	NextSlot    common.Hash // Hash of the next storage slot of NextAccount to convert
+	Accounts    uint64      // Number of accounts converted so far
+	Slots       uint64      // Number of storage slots converted so far
+	Ended       bool        // Whether the whole merkle state is converted
+}
+
+// ReadVerkleTransitionState retrieves the progress of the verkle transition as
+// of the given state root, or nil if the state isn't part of the transition.
+func ReadVerkleTransitionState(db ethdb.KeyValueReader, root common.Hash) *VerkleTransitionState {
+	data, _ := db.Get(transitionStateKey(root))
+	if len(data) == 0 {
+		return nil
+	}
+	state := new(VerkleTransitionState)
+	if err := rlp.DecodeBytes(data, state); err != nil {
+		log.Error("Invalid verkle transition state RLP", "root", root, "err", err)
+		return nil
+	}
+	return state
+}
+
+// WriteVerkleTransitionState stores the progress of the verkle transition as of
+// the given state root.
+func WriteVerkleTransitionState(db ethdb.KeyValueWriter, root common.Hash, state *VerkleTransitionState) {
+	data, err := rlp.EncodeToBytes(state)
+	if err != nil {
+		log.Crit("Failed to encode verkle transition state", "err", err)
+	}
+	if err := db.Put(transitionStateKey(root), data); err != nil {
+		log.Crit("Failed to store verkle transition state", "err", err)
+	}
+}
+
+// DeleteVerkleTransitionState removes the progress of the verkle transition as
+// of the given state root.
+func DeleteVerkleTransitionState(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Delete(transitionStateKey(root)); err != nil {
+		log.Crit("Failed to delete verkle transition state", "err", err)
+	}
+}
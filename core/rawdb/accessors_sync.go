@@ -17,8 +17,12 @@
 package rawdb
 
 import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const (
@@ -42,3 +46,131 @@ func WriteSnapSyncStatusFlag(db ethdb.KeyValueWriter, flag uint8) {
 		log.Crit("Failed to store sync status flag", "err", err)
 	}
 }
+
+// ReceiptRange is an inclusive range of block numbers whose bodies and receipts
+// are fully present in the database.
+type ReceiptRange struct {
+	First uint64
+	Last  uint64
+}
+
+// ReadFilledReceiptRanges retrieves the receipt segments which were inserted
+// out of order and are not yet connected to the snap sync head.
+func ReadFilledReceiptRanges(db ethdb.KeyValueReader) []ReceiptRange {
+	blob, err := db.Get(filledReceiptRangesKey)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var ranges []ReceiptRange
+	if err := rlp.DecodeBytes(blob, &ranges); err != nil {
+		log.Error("Failed to decode filled receipt ranges", "err", err)
+		return nil
+	}
+	return ranges
+}
+
+// WriteFilledReceiptRanges stores the receipt segments which were inserted
+// out of order, deleting the entry altogether if there are none.
+func WriteFilledReceiptRanges(db ethdb.KeyValueWriter, ranges []ReceiptRange) {
+	if len(ranges) == 0 {
+		if err := db.Delete(filledReceiptRangesKey); err != nil {
+			log.Crit("Failed to remove filled receipt ranges", "err", err)
+		}
+		return
+	}
+	blob, err := rlp.EncodeToBytes(ranges)
+	if err != nil {
+		log.Crit("Failed to encode filled receipt ranges", "err", err)
+	}
+	if err := db.Put(filledReceiptRangesKey, blob); err != nil {
+		log.Crit("Failed to store filled receipt ranges", "err", err)
+	}
+}
+
+// SealCheckRange is an inclusive range of block numbers whose headers were
+// imported with only every Frequency-th seal (and the batch boundaries) verified.
+type SealCheckRange struct {
+	First     uint64
+	Last      uint64
+	Frequency uint64
+}
+
+// ReadSealCheckRanges retrieves the header ranges imported with spot checked
+// seals, allowing audits to know which seals were never verified.
+func ReadSealCheckRanges(db ethdb.KeyValueReader) []SealCheckRange {
+	blob, err := db.Get(sealCheckRangesKey)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var ranges []SealCheckRange
+	if err := rlp.DecodeBytes(blob, &ranges); err != nil {
+		log.Error("Failed to decode seal check ranges", "err", err)
+		return nil
+	}
+	return ranges
+}
+
+// WriteSealCheckRanges stores the header ranges imported with spot checked seals.
+func WriteSealCheckRanges(db ethdb.KeyValueWriter, ranges []SealCheckRange) {
+	blob, err := rlp.EncodeToBytes(ranges)
+	if err != nil {
+		log.Crit("Failed to encode seal check ranges", "err", err)
+	}
+	if err := db.Put(sealCheckRangesKey, blob); err != nil {
+		log.Crit("Failed to store seal check ranges", "err", err)
+	}
+}
+
+// ReadSkeletonSyncStatus retrieves the serialized sync status saved at shutdown.
+func ReadSkeletonSyncStatus(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(skeletonSyncStatusKey)
+	return data
+}
+
+// WriteSkeletonSyncStatus stores the skeleton sync status to save at shutdown.
+func WriteSkeletonSyncStatus(db ethdb.KeyValueWriter, status []byte) {
+	if err := db.Put(skeletonSyncStatusKey, status); err != nil {
+		log.Crit("Failed to store skeleton sync status", "err", err)
+	}
+}
+
+// DeleteSkeletonSyncStatus deletes the serialized sync status saved at the last
+// shutdown.
+func DeleteSkeletonSyncStatus(db ethdb.KeyValueWriter) {
+	if err := db.Delete(skeletonSyncStatusKey); err != nil {
+		log.Crit("Failed to remove skeleton sync status", "err", err)
+	}
+}
+
+// ReadSkeletonHeader retrieves a block header from the skeleton sync store.
+func ReadSkeletonHeader(db ethdb.KeyValueReader, number uint64) *types.Header {
+	data, _ := db.Get(skeletonHeaderKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	header := new(types.Header)
+	if err := rlp.Decode(bytes.NewReader(data), header); err != nil {
+		log.Error("Invalid skeleton header RLP", "number", number, "err", err)
+		return nil
+	}
+	return header
+}
+
+// WriteSkeletonHeader stores a block header into the skeleton sync store.
+func WriteSkeletonHeader(db ethdb.KeyValueWriter, header *types.Header) {
+	data, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		log.Crit("Failed to RLP encode header", "err", err)
+	}
+	key := skeletonHeaderKey(header.Number.Uint64())
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store skeleton header", "err", err)
+	}
+}
+
+// DeleteSkeletonHeader removes all block header data associated with a hash.
+func DeleteSkeletonHeader(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(skeletonHeaderKey(number)); err != nil {
+		log.Crit("Failed to delete skeleton header", "err", err)
+	}
+}
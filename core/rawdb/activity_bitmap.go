@@ -0,0 +1,184 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// ActivityChunkBits is the number of low block number bits addressed within
+	// a single activity bitmap chunk.
+	ActivityChunkBits = 16
+
+	// ActivityChunkSize is the number of blocks covered by an activity bitmap.
+	ActivityChunkSize = 1 << ActivityChunkBits
+
+	// activityArrayLimit is the cardinality above which a bitmap is stored as
+	// a dense bitset instead of a sorted array, the point where the latter
+	// stops being the smaller one.
+	activityArrayLimit = ActivityChunkSize / 16
+
+	activityArrayTag  = 0 // Sorted array of big endian uint16 offsets
+	activityBitsetTag = 1 // Little endian uint64 words of the offset bitset
+)
+
+var errInvalidActivityBitmap = errors.New("invalid activity bitmap")
+
+// ActivityBitmap is a roaring style container of the block offsets within an
+// activity chunk. Sparse containers are kept as a sorted array of offsets and
+// converted into a fixed size bitset once they grow dense.
+type ActivityBitmap struct {
+	array []uint16 // Sorted offsets, if the bitmap is sparse
+	words []uint64 // Bitset of the offsets, if the bitmap is dense
+	count int      // Number of offsets in the bitmap
+}
+
+// Len returns the number of offsets in the bitmap.
+func (b *ActivityBitmap) Len() int {
+	return b.count
+}
+
+// Contains reports whether the offset is in the bitmap.
+func (b *ActivityBitmap) Contains(offset uint16) bool {
+	if b.words != nil {
+		return b.words[offset/64]&(1<<(offset%64)) != 0
+	}
+	_, found := slices.BinarySearch(b.array, offset)
+	return found
+}
+
+// Add inserts the offset into the bitmap, returning false if it was present.
+func (b *ActivityBitmap) Add(offset uint16) bool {
+	if b.words != nil {
+		if b.words[offset/64]&(1<<(offset%64)) != 0 {
+			return false
+		}
+		b.words[offset/64] |= 1 << (offset % 64)
+		b.count++
+		return true
+	}
+	pos, found := slices.BinarySearch(b.array, offset)
+	if found {
+		return false
+	}
+	b.array = slices.Insert(b.array, pos, offset)
+	b.count++
+
+	if b.count > activityArrayLimit {
+		b.words = make([]uint64, ActivityChunkSize/64)
+		for _, offset := range b.array {
+			b.words[offset/64] |= 1 << (offset % 64)
+		}
+		b.array = nil
+	}
+	return true
+}
+
+// Remove deletes the offset from the bitmap, returning false if it was absent.
+func (b *ActivityBitmap) Remove(offset uint16) bool {
+	if b.words != nil {
+		if b.words[offset/64]&(1<<(offset%64)) == 0 {
+			return false
+		}
+		b.words[offset/64] &^= 1 << (offset % 64)
+		b.count--
+
+		if b.count <= activityArrayLimit {
+			b.array = b.Offsets()
+			b.words = nil
+		}
+		return true
+	}
+	pos, found := slices.BinarySearch(b.array, offset)
+	if !found {
+		return false
+	}
+	b.array = slices.Delete(b.array, pos, pos+1)
+	b.count--
+	return true
+}
+
+// Offsets returns all the offsets in the bitmap in ascending order.
+func (b *ActivityBitmap) Offsets() []uint16 {
+	if b.words == nil {
+		return slices.Clone(b.array)
+	}
+	offsets := make([]uint16, 0, b.count)
+	for i, word := range b.words {
+		for word != 0 {
+			offsets = append(offsets, uint16(i*64+bits.TrailingZeros64(word)))
+			word &= word - 1
+		}
+	}
+	return offsets
+}
+
+// encode serializes the bitmap into its database representation.
+func (b *ActivityBitmap) encode() []byte {
+	if b.words != nil {
+		blob := make([]byte, 1+8*len(b.words))
+		blob[0] = activityBitsetTag
+		for i, word := range b.words {
+			binary.LittleEndian.PutUint64(blob[1+8*i:], word)
+		}
+		return blob
+	}
+	blob := make([]byte, 1+2*len(b.array))
+	blob[0] = activityArrayTag
+	for i, offset := range b.array {
+		binary.BigEndian.PutUint16(blob[1+2*i:], offset)
+	}
+	return blob
+}
+
+// decodeActivityBitmap parses the database representation of a bitmap.
+func decodeActivityBitmap(blob []byte) (*ActivityBitmap, error) {
+	if len(blob) == 0 {
+		return nil, errInvalidActivityBitmap
+	}
+	b := new(ActivityBitmap)
+	switch blob[0] {
+	case activityArrayTag:
+		if (len(blob)-1)%2 != 0 {
+			return nil, errInvalidActivityBitmap
+		}
+		b.array = make([]uint16, (len(blob)-1)/2)
+		for i := range b.array {
+			b.array[i] = binary.BigEndian.Uint16(blob[1+2*i:])
+			if i > 0 && b.array[i] <= b.array[i-1] {
+				return nil, errInvalidActivityBitmap
+			}
+		}
+		b.count = len(b.array)
+	case activityBitsetTag:
+		if len(blob) != 1+ActivityChunkSize/8 {
+			return nil, errInvalidActivityBitmap
+		}
+		b.words = make([]uint64, ActivityChunkSize/64)
+		for i := range b.words {
+			b.words[i] = binary.LittleEndian.Uint64(blob[1+8*i:])
+			b.count += bits.OnesCount64(b.words[i])
+		}
+	default:
+		return nil, errInvalidActivityBitmap
+	}
+	return b, nil
+}
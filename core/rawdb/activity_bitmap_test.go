@@ -0,0 +1,113 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that activity bitmaps convert between their sparse and dense forms and
+// survive a database roundtrip in both.
+func TestActivityBitmap(t *testing.T) {
+	var (
+		bitmap = new(ActivityBitmap)
+		want   []uint16
+	)
+	check := func(dense bool) {
+		t.Helper()
+
+		if have := bitmap.words != nil; have != dense {
+			t.Fatalf("dense mismatch: have %v, want %v", have, dense)
+		}
+		if have := bitmap.Offsets(); !slices.Equal(have, want) {
+			t.Fatalf("offsets mismatch: have %d, want %d", len(have), len(want))
+		}
+		dec, err := decodeActivityBitmap(bitmap.encode())
+		if err != nil {
+			t.Fatalf("failed to decode bitmap: %v", err)
+		}
+		if dec.Len() != len(want) || !slices.Equal(dec.Offsets(), want) {
+			t.Fatalf("decoded offsets mismatch: have %d, want %d", dec.Len(), len(want))
+		}
+	}
+	// Fill the bitmap up to the conversion limit in reverse order
+	for i := activityArrayLimit - 1; i >= 0; i-- {
+		if !bitmap.Add(uint16(i * 3)) {
+			t.Fatalf("offset %d reported present", i*3)
+		}
+		want = append([]uint16{uint16(i * 3)}, want...)
+	}
+	if bitmap.Add(0) {
+		t.Fatalf("duplicate offset added")
+	}
+	check(false)
+
+	// Cross the limit and drop back below it
+	bitmap.Add(ActivityChunkSize - 1)
+	want = append(want, ActivityChunkSize-1)
+	check(true)
+
+	if !bitmap.Contains(ActivityChunkSize-1) || bitmap.Contains(1) {
+		t.Fatalf("dense membership mismatch")
+	}
+	bitmap.Remove(3)
+	want = slices.DeleteFunc(want, func(offset uint16) bool { return offset == 3 })
+	check(false)
+
+	if bitmap.Remove(3) {
+		t.Fatalf("absent offset removed")
+	}
+}
+
+// Tests that the activity of an address is iterated across chunks in order.
+func TestIterateAddressActivity(t *testing.T) {
+	var (
+		db   = NewMemoryDatabase()
+		addr = common.Address{0x01}
+		want = []uint64{5, ActivityChunkSize + 1, 3*ActivityChunkSize + 7}
+	)
+	for _, number := range want {
+		chunk := number >> ActivityChunkBits
+		bitmap := ReadAddressActivity(db, addr, chunk)
+		if bitmap == nil {
+			bitmap = new(ActivityBitmap)
+		}
+		bitmap.Add(uint16(number))
+		WriteAddressActivity(db, addr, chunk, bitmap)
+	}
+	// Activity of other addresses must not leak into the iteration
+	other := new(ActivityBitmap)
+	other.Add(9)
+	WriteAddressActivity(db, common.Address{0x02}, 0, other)
+
+	var have []uint64
+	IterateAddressActivity(db, addr, 6, func(number uint64) bool {
+		have = append(have, number)
+		return true
+	})
+	if !slices.Equal(have, want[1:]) {
+		t.Fatalf("activity mismatch: have %v, want %v", have, want[1:])
+	}
+	// Emptied bitmaps must be deleted
+	WriteAddressActivity(db, addr, 0, new(ActivityBitmap))
+	if ReadAddressActivity(db, addr, 0) != nil {
+		t.Fatalf("empty bitmap not deleted")
+	}
+}
@@ -56,6 +56,10 @@ var chainFreezerNoSnappy = map[string]bool{
 
 var additionTables = []string{ChainFreezerBlobSidecarTable}
 
+// prunableTables share the head with the other chain tables, but their tail can
+// be truncated independently to drop the history beyond a retention window.
+var prunableTables = []string{ChainFreezerReceiptTable}
+
 const (
 	// stateHistoryTableSize defines the maximum size of freezer data files.
 	stateHistoryTableSize = 2 * 1000 * 1000 * 1000
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -47,6 +48,9 @@ const (
 
 var (
 	missFreezerEnvErr = errors.New("missing freezer env error")
+
+	blobPruneMeter      = metrics.NewRegisteredMeter("chain/sidecars/pruned", nil)
+	blobPruneBytesMeter = metrics.NewRegisteredMeter("chain/sidecars/prunedbytes", nil)
 )
 
 // chainFreezer is a wrapper of chain ancient store with additional chain freezing
@@ -59,6 +63,10 @@ type chainFreezer struct {
 	wg      sync.WaitGroup
 	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
 
+	freezing bool               // Whether the background freezing runs at all
+	pause    chan chan struct{} // Pause requests, acknowledged in between the freeze cycles
+	resume   chan struct{}      // Resume requests of a paused freezing
+
 	threshold atomic.Uint64 // Number of recent blocks not to freeze (params.FullImmutabilityThreshold apart from tests)
 
 	freezeEnv    atomic.Value
@@ -74,7 +82,7 @@ type chainFreezer struct {
 //     state freezer (e.g. dev mode).
 //   - if non-empty directory is given, initializes the regular file-based
 //     state freezer.
-func newChainFreezer(datadir string, namespace string, readonly bool, multiDatabase bool) (*chainFreezer, error) {
+func newChainFreezer(datadir string, namespace string, readonly bool, multiDatabase bool, remote *RemoteFreezerConfig) (*chainFreezer, error) {
 	var (
 		err     error
 		freezer ethdb.AncientStore
@@ -82,7 +90,7 @@ func newChainFreezer(datadir string, namespace string, readonly bool, multiDatab
 	if datadir == "" {
 		freezer = NewMemoryFreezer(readonly, chainFreezerNoSnappy)
 	} else {
-		freezer, err = NewFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy)
+		freezer, err = NewRemoteFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy, remote)
 	}
 	if err != nil {
 		return nil, err
@@ -91,6 +99,8 @@ func newChainFreezer(datadir string, namespace string, readonly bool, multiDatab
 		AncientStore: freezer,
 		quit:         make(chan struct{}),
 		trigger:      make(chan chan struct{}),
+		pause:        make(chan chan struct{}),
+		resume:       make(chan struct{}),
 		// After enabling pruneAncient, the ancient data is not retained. In some specific scenarios where it is
 		// necessary to roll back to blocks prior to the finalized block, it is mandatory to keep the most recent 90,000 blocks in the database to ensure proper functionality and rollback capability.
 		multiDatabase: false,
@@ -191,6 +201,10 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore, continueFreeze bool) {
 		case <-f.quit:
 			log.Info("Freezer shutting down")
 			return
+		case ack := <-f.pause:
+			if !f.suspend(ack) {
+				return
+			}
 		default:
 		}
 		if backoff {
@@ -205,6 +219,11 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore, continueFreeze bool) {
 				timer.Reset(freezerRecheckInterval)
 			case triggered = <-f.trigger:
 				backoff = false
+			case ack := <-f.pause:
+				if !f.suspend(ack) {
+					return
+				}
+				continue
 			case <-f.quit:
 				return
 			}
@@ -407,7 +426,7 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore, continueFreeze bool) {
 		env, _ := f.freezeEnv.Load().(*ethdb.FreezerEnv)
 		// try prune blob data after cancun fork
 		if isCancun(env, head.Number, head.Time) {
-			f.tryPruneBlobAncientTable(env, *number)
+			f.tryPruneBlobAncientTable(db, env, *number)
 		}
 		f.tryPruneHistoryBlock(*number)
 
@@ -424,7 +443,44 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore, continueFreeze bool) {
 	}
 }
 
-func (f *chainFreezer) tryPruneBlobAncientTable(env *ethdb.FreezerEnv, num uint64) {
+// suspend acknowledges a pause request and blocks until the freezing is resumed,
+// returning false if the freezer is closed in the meantime.
+func (f *chainFreezer) suspend(ack chan struct{}) bool {
+	close(ack)
+	select {
+	case <-f.resume:
+		return true
+	case <-f.quit:
+		return false
+	}
+}
+
+// PauseFreezing suspends the background freezing, waiting for a running freeze
+// cycle to finish.
+func (f *chainFreezer) PauseFreezing() {
+	if !f.freezing {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case f.pause <- ack:
+		<-ack
+	case <-f.quit:
+	}
+}
+
+// ResumeFreezing lifts a pause of the background freezing.
+func (f *chainFreezer) ResumeFreezing() {
+	if !f.freezing {
+		return
+	}
+	select {
+	case f.resume <- struct{}{}:
+	case <-f.quit:
+	}
+}
+
+func (f *chainFreezer) tryPruneBlobAncientTable(db ethdb.KeyValueStore, env *ethdb.FreezerEnv, num uint64) {
 	extraReserve := getBlobExtraReserveFromEnv(env)
 	// It means that there is no need for pruning
 	if extraReserve == 0 {
@@ -448,10 +504,19 @@ func (f *chainFreezer) tryPruneBlobAncientTable(env *ethdb.FreezerEnv, num uint6
 	}
 
 	start := time.Now()
-	if _, err := f.TruncateTableTail(ChainFreezerBlobSidecarTable, expectTail); err != nil {
+	before, _ := f.AncientSize(ChainFreezerBlobSidecarTable)
+	old, err := f.TruncateTableTail(ChainFreezerBlobSidecarTable, expectTail)
+	if err != nil {
 		log.Error("Cannot prune blob ancient", "block", num, "expectTail", expectTail, "err", err)
 		return
 	}
+	if old < expectTail {
+		WriteBlobSidecarTail(db, expectTail)
+		blobPruneMeter.Mark(int64(expectTail - old))
+	}
+	if after, err := f.AncientSize(ChainFreezerBlobSidecarTable); err == nil && after < before {
+		blobPruneBytesMeter.Mark(int64(before - after))
+	}
 	log.Debug("Chain freezer prune useless blobs, now ancient data is", "from", expectTail, "to", num, "cost", common.PrettyDuration(time.Since(start)))
 }
 
@@ -0,0 +1,138 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// HeaderNumberReport summarizes the outcome of a hash->number index check.
+type HeaderNumberReport struct {
+	Checked    uint64 // Number of canonical heights verified
+	Gaps       uint64 // Canonical heights without a number->hash entry
+	Missing    uint64 // Canonical hashes without a hash->number entry
+	Mismatched uint64 // Canonical hashes mapped to the wrong number
+	Dangling   uint64 // Hash->number entries without a matching header
+	Repaired   bool   // Whether the detected issues were fixed
+}
+
+// Healthy reports whether no inconsistencies were detected.
+func (r *HeaderNumberReport) Healthy() bool {
+	return r.Gaps == 0 && r.Missing == 0 && r.Mismatched == 0 && r.Dangling == 0
+}
+
+// RepairHeaderNumbers verifies that every canonical header up to and including
+// head has a correct hash->number entry, and that every hash->number entry in
+// the key-value store points to an existing header. Entries below the freezer
+// tail belong to pruned history and are left alone, as their headers are gone
+// by design. If repair is set, missing or wrong entries are rewritten and
+// dangling ones are deleted.
+func RepairHeaderNumbers(db ethdb.Database, head uint64, repair bool) (*HeaderNumberReport, error) {
+	var (
+		report = &HeaderNumberReport{Repaired: repair}
+		tail   = db.AncientOffSet()
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = start
+	)
+	flush := func(force bool) error {
+		if !repair || (!force && batch.ValueSize() < ethdb.IdealBatchSize) {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	// Canonical chain -> hash->number direction
+	for number := tail; number <= head; number++ {
+		report.Checked++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying hash to number mappings", "number", number, "head", head, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+
+		hash := ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			report.Gaps++
+			log.Warn("Missing canonical hash", "number", number)
+			continue
+		}
+		stored := ReadHeaderNumber(db, hash)
+		switch {
+		case stored == nil:
+			report.Missing++
+			log.Debug("Missing hash to number mapping", "number", number, "hash", hash)
+		case *stored != number:
+			report.Mismatched++
+			log.Warn("Mismatched hash to number mapping", "number", number, "hash", hash, "stored", *stored)
+		default:
+			continue
+		}
+		if repair {
+			WriteHeaderNumber(batch, hash, number)
+			if err := flush(false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Hash->number entries -> headers direction. Flush the fixes of the first
+	// pass beforehand, lest rewritten entries are deemed dangling.
+	if err := flush(true); err != nil {
+		return nil, err
+	}
+	it := db.NewIterator(headerNumberPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if len(key) != len(headerNumberPrefix)+common.HashLength || len(value) != 8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(value)
+		if number < tail {
+			continue
+		}
+		hash := common.BytesToHash(key[len(headerNumberPrefix):])
+		if HasHeader(db, hash, number) {
+			continue
+		}
+		report.Dangling++
+		if repair {
+			DeleteHeaderNumber(batch, hash)
+			if err := flush(false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if err := flush(true); err != nil {
+		return nil, err
+	}
+	log.Info("Verified hash to number mappings", "checked", report.Checked, "gaps", report.Gaps,
+		"missing", report.Missing, "mismatched", report.Mismatched, "dangling", report.Dangling,
+		"repaired", repair, "elapsed", common.PrettyDuration(time.Since(start)))
+	return report, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// prunedDatabase is a database whose history below the offset was pruned.
+type prunedDatabase struct {
+	ethdb.Database
+	offset uint64
+}
+
+func (db *prunedDatabase) AncientOffSet() uint64 { return db.offset }
+
+// Tests that inconsistencies in the hash->number index are detected and fixed.
+func TestRepairHeaderNumbers(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var hashes []common.Hash
+	for i := 0; i < 10; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Extra: []byte("test")}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), uint64(i))
+		hashes = append(hashes, header.Hash())
+	}
+	report, err := RepairHeaderNumbers(db, 9, false)
+	if err != nil {
+		t.Fatalf("failed to verify pristine database: %v", err)
+	}
+	if !report.Healthy() || report.Checked != 10 {
+		t.Fatalf("pristine database reported unhealthy: %+v", report)
+	}
+	// Corrupt the index in all possible ways
+	DeleteHeaderNumber(db, hashes[3])
+	WriteHeaderNumber(db, hashes[5], 7)
+	WriteHeaderNumber(db, common.Hash{0xde, 0xad}, 4)
+
+	report, err = RepairHeaderNumbers(db, 9, false)
+	if err != nil {
+		t.Fatalf("failed to verify corrupted database: %v", err)
+	}
+	if report.Missing != 1 || report.Mismatched != 1 || report.Dangling != 2 {
+		t.Fatalf("corruption report mismatch: %+v", report)
+	}
+	if n := ReadHeaderNumber(db, hashes[3]); n != nil {
+		t.Fatalf("verification without repair modified the database")
+	}
+	if _, err := RepairHeaderNumbers(db, 9, true); err != nil {
+		t.Fatalf("failed to repair database: %v", err)
+	}
+	report, err = RepairHeaderNumbers(db, 9, false)
+	if err != nil {
+		t.Fatalf("failed to verify repaired database: %v", err)
+	}
+	if !report.Healthy() {
+		t.Fatalf("repaired database reported unhealthy: %+v", report)
+	}
+	for i, hash := range hashes {
+		if n := ReadHeaderNumber(db, hash); n == nil || *n != uint64(i) {
+			t.Errorf("header %d: mapping not repaired", i)
+		}
+	}
+}
+
+// Tests that the hash->number entries of pruned history are not deemed dangling.
+func TestRepairHeaderNumbersPruned(t *testing.T) {
+	db := &prunedDatabase{Database: NewMemoryDatabase(), offset: 5}
+
+	var hashes []common.Hash
+	for i := 0; i < 10; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Extra: []byte("test")}
+		if i < 5 {
+			// Pruned history only retains the hash->number entries
+			WriteHeaderNumber(db, header.Hash(), uint64(i))
+		} else {
+			WriteHeader(db, header)
+			WriteCanonicalHash(db, header.Hash(), uint64(i))
+		}
+		hashes = append(hashes, header.Hash())
+	}
+	WriteHeaderNumber(db, common.Hash{0xde, 0xad}, 7)
+
+	report, err := RepairHeaderNumbers(db, 9, true)
+	if err != nil {
+		t.Fatalf("failed to repair database: %v", err)
+	}
+	if report.Checked != 5 || report.Dangling != 1 {
+		t.Fatalf("report mismatch: %+v", report)
+	}
+	for i, hash := range hashes[:5] {
+		if n := ReadHeaderNumber(db, hash); n == nil || *n != uint64(i) {
+			t.Errorf("pruned header %d: mapping deleted", i)
+		}
+	}
+	if n := ReadHeaderNumber(db, common.Hash{0xde, 0xad}); n != nil {
+		t.Errorf("dangling mapping not deleted")
+	}
+}
@@ -118,6 +118,17 @@ func (frdb *freezerdb) SetupFreezerEnv(env *ethdb.FreezerEnv, blockHistory uint6
 	return frdb.AncientFreezer.SetupFreezerEnv(env, blockHistory)
 }
 
+// PauseFreezing suspends the background freezing, waiting for a running freeze
+// cycle to finish.
+func (frdb *freezerdb) PauseFreezing() {
+	frdb.AncientFreezer.PauseFreezing()
+}
+
+// ResumeFreezing lifts a pause of the background freezing.
+func (frdb *freezerdb) ResumeFreezing() {
+	frdb.AncientFreezer.ResumeFreezing()
+}
+
 // nofreezedb is a database wrapper that disables freezer data retrievals.
 type nofreezedb struct {
 	ethdb.KeyValueStore
@@ -189,6 +200,21 @@ func (db *nofreezedb) SyncAncient() error {
 	return errNotSupported
 }
 
+// Close implements io.Closer, closing the key-value store along with the
+// separate state store if there is one.
+func (db *nofreezedb) Close() error {
+	var errs []error
+	if err := db.KeyValueStore.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if db.HasSeparateStateStore() {
+		if err := db.stateStore.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (db *nofreezedb) SetStateStore(state ethdb.Database) {
 	db.stateStore = state
 }
@@ -240,6 +266,10 @@ func (db *nofreezedb) SetupFreezerEnv(env *ethdb.FreezerEnv, blockHistory uint64
 	return nil
 }
 
+func (db *nofreezedb) PauseFreezing() {}
+
+func (db *nofreezedb) ResumeFreezing() {}
+
 // NewDatabase creates a high level database on top of a given key-value data
 // store without a freezer moving immutable chain segments into cold storage.
 func NewDatabase(db ethdb.KeyValueStore) ethdb.Database {
@@ -332,6 +362,10 @@ func (db *emptyfreezedb) SetupFreezerEnv(env *ethdb.FreezerEnv, blockHistory uin
 	return nil
 }
 
+func (db *emptyfreezedb) PauseFreezing() {}
+
+func (db *emptyfreezedb) ResumeFreezing() {}
+
 // NewEmptyFreezeDB is used for CLI such as `geth db inspect` in pruned db that we don't
 // have a backing chain freezer.
 // WARNING: it must be only used in the above case.
@@ -380,6 +414,15 @@ func resolveChainFreezerDir(ancient string) string {
 // storage. The passed ancient indicates the path of root ancient directory
 // where the chain freezer can be opened.
 func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace string, readonly, disableFreeze, multiDatabase bool) (ethdb.Database, error) {
+	return NewDatabaseWithRemoteFreezer(db, ancient, namespace, readonly, disableFreeze, multiDatabase, nil)
+}
+
+// NewDatabaseWithRemoteFreezer creates a high level database like
+// NewDatabaseWithFreezer, with the sealed segments of the chain freezer
+// offloaded into the object store given in the remote config. The ancient
+// directory still holds the indices, the segments being written and a local
+// read-through cache of the offloaded ones.
+func NewDatabaseWithRemoteFreezer(db ethdb.KeyValueStore, ancient string, namespace string, readonly, disableFreeze, multiDatabase bool, remote *RemoteFreezerConfig) (ethdb.Database, error) {
 	// Create the idle freezer instance. If the given ancient directory is empty,
 	// in-memory chain freezer is used (e.g. dev mode); otherwise the regular
 	// file-based freezer is created.
@@ -398,7 +441,7 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace st
 	}
 
 	// Create the idle freezer instance
-	frdb, err := newChainFreezer(chainFreezerDir, namespace, readonly, multiDatabase)
+	frdb, err := newChainFreezer(chainFreezerDir, namespace, readonly, multiDatabase, remote)
 
 	// We are creating the freezerdb here because the validation logic for db and freezer below requires certain interfaces
 	// that need a database type. Therefore, we are pre-creating it for subsequent use.
@@ -500,6 +543,7 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace st
 
 	// Freezer is consistent with the key-value database, permit combining the two
 	if !disableFreeze && !readonly {
+		frdb.freezing = true
 		frdb.wg.Add(1)
 		go func() {
 			frdb.freeze(db, false)
@@ -510,9 +554,94 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace st
 }
 
 // NewMemoryDatabase creates an ephemeral in-memory key-value database without a
-// freezer moving immutable chain segments into cold storage.
-func NewMemoryDatabase() ethdb.Database {
-	return NewDatabase(memorydb.New())
+// freezer moving immutable chain segments into cold storage. The options allow
+// injecting faults into the database operations.
+func NewMemoryDatabase(opts ...memorydb.Option) ethdb.Database {
+	return NewDatabase(memorydb.New(opts...))
+}
+
+// memoryStore returns the key-value store backing a database created by
+// NewMemoryDatabase.
+func memoryStore(db ethdb.Database) (*memorydb.Database, error) {
+	if frdb, ok := db.(*nofreezedb); ok {
+		if store, ok := frdb.KeyValueStore.(*memorydb.Database); ok {
+			return store, nil
+		}
+	}
+	return nil, errors.New("not a memory database")
+}
+
+// SnapshotMemoryDatabase captures the content of a database created by
+// NewMemoryDatabase, which it can be rolled back to with RestoreMemoryDatabase.
+// The content is copied on the next write, so snapshots are cheap to take.
+func SnapshotMemoryDatabase(db ethdb.Database) (*memorydb.Snapshot, error) {
+	store, err := memoryStore(db)
+	if err != nil {
+		return nil, err
+	}
+	return store.Snapshot()
+}
+
+// RestoreMemoryDatabase rolls a database created by NewMemoryDatabase back to
+// the given snapshot.
+func RestoreMemoryDatabase(db ethdb.Database, snap *memorydb.Snapshot) error {
+	store, err := memoryStore(db)
+	if err != nil {
+		return err
+	}
+	return store.Restore(snap)
+}
+
+// SetMemoryDatabaseFaults changes the ratios of reads and writes failed in a
+// database created by NewMemoryDatabase.
+func SetMemoryDatabaseFaults(db ethdb.Database, readRate, writeRate float64) error {
+	store, err := memoryStore(db)
+	if err != nil {
+		return err
+	}
+	store.SetFaults(readRate, writeRate)
+	return nil
+}
+
+var (
+	// errStateStoreAttached is returned if a database already has a different
+	// state store attached.
+	errStateStoreAttached = errors.New("another state database already attached")
+
+	// errStateInChainStore is returned if a state store is attached to a chain
+	// database holding the state itself.
+	errStateInChainStore = errors.New("state held by the chain database")
+)
+
+// AttachStateStore makes db keep the state trie nodes in the separate state
+// database, leaving the chain data, contract codes and snapshots in db. It
+// refuses to attach an empty state database to a chain database which holds
+// the state already, as the node would silently lose access to it.
+func AttachStateStore(db ethdb.Database, state ethdb.Database) error {
+	if state == db {
+		return errors.New("state database is the chain database")
+	}
+	if db.HasSeparateStateStore() {
+		if db.GetStateStore() == state {
+			return nil
+		}
+		return errStateStoreAttached
+	}
+	if hasState(db, db) && !hasState(db, state) {
+		return errStateInChainStore
+	}
+	db.SetStateStore(state)
+	return nil
+}
+
+// hasState reports whether store holds the trie nodes of the state of any
+// scheme, looking up the genesis state root in db for the hash scheme.
+func hasState(db ethdb.Reader, store ethdb.KeyValueReader) bool {
+	if HasAccountTrieNode(store, nil) || ReadPersistentStateID(store) != 0 {
+		return true
+	}
+	header := ReadHeader(db, ReadCanonicalHash(db, 0), 0)
+	return header != nil && HasLegacyTrieNode(store, header.Root)
 }
 
 const (
@@ -660,22 +789,35 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		headers         stat
 		bodies          stat
 		receipts        stat
+		revertReasons   stat
 		tds             stat
 		numHashPairings stat
 		blobSidecars    stat
 		hashNumPairings stat
+		skeletonHeaders stat
 		legacyTries     stat
 		stateLookups    stat
 		accountTries    stat
 		storageTries    stat
 		codes           stat
 		txLookups       stat
+		uncleIndex      stat
 		accountSnaps    stat
 		storageSnaps    stat
 		preimages       stat
 		bloomBits       stat
 		cliqueSnaps     stat
 		parliaSnaps     stat
+		addressFilters  stat
+		totalSupplies   stat
+		feeTotals       stat
+		contractChanges stat
+		contractStats   stat
+		husks           stat
+		accessLists     stat
+		equivocations   stat
+		addressActivity stat
+		transitions     stat
 
 		// Verkle statistics
 		verkleTries        stat
@@ -706,6 +848,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bodies.Add(size)
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
 			receipts.Add(size)
+		case bytes.HasPrefix(key, revertReasonsPrefix) && len(key) == (len(revertReasonsPrefix)+8+common.HashLength):
+			revertReasons.Add(size)
 		case IsLegacyTrieNode(key, it.Value()):
 			legacyTries.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
@@ -716,6 +860,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			numHashPairings.Add(size)
 		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
 			hashNumPairings.Add(size)
+		case bytes.HasPrefix(key, skeletonHeaderPrefix) && len(key) == (len(skeletonHeaderPrefix)+8):
+			skeletonHeaders.Add(size)
 		case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
 			stateLookups.Add(size)
 		case IsAccountTrieNode(key):
@@ -726,6 +872,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			codes.Add(size)
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txLookups.Add(size)
+		case bytes.HasPrefix(key, uncleIndexPrefix) && len(key) == (len(uncleIndexPrefix)+common.AddressLength+8+common.HashLength+1):
+			uncleIndex.Add(size)
 		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
 			accountSnaps.Add(size)
 		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
@@ -744,6 +892,28 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			cliqueSnaps.Add(size)
 		case bytes.HasPrefix(key, ParliaSnapshotPrefix) && len(key) == 7+common.HashLength:
 			parliaSnaps.Add(size)
+		case bytes.HasPrefix(key, addressFilterPrefix) && len(key) == len(addressFilterPrefix)+common.HashLength:
+			addressFilters.Add(size)
+		case bytes.HasPrefix(key, totalSupplyPrefix) && len(key) == len(totalSupplyPrefix)+8+common.HashLength:
+			totalSupplies.Add(size)
+		case bytes.HasPrefix(key, feeTotalsPrefix) && len(key) == len(feeTotalsPrefix)+8+common.HashLength:
+			feeTotals.Add(size)
+		case bytes.HasPrefix(key, contractChangesPrefix) && len(key) == len(contractChangesPrefix)+8+common.HashLength:
+			contractChanges.Add(size)
+		case bytes.HasPrefix(key, contractStatsPrefix) && len(key) == len(contractStatsPrefix)+common.AddressLength:
+			contractStats.Add(size)
+		case bytes.HasPrefix(key, contractAppliedPrefix) && len(key) == len(contractAppliedPrefix)+8+common.HashLength:
+			contractStats.Add(size)
+		case bytes.HasPrefix(key, husksPrefix) && len(key) == len(husksPrefix)+8+common.HashLength:
+			husks.Add(size)
+		case bytes.HasPrefix(key, blockAccessListPrefix) && len(key) == len(blockAccessListPrefix)+8+common.HashLength:
+			accessLists.Add(size)
+		case bytes.HasPrefix(key, equivocationPrefix) && len(key) == len(equivocationPrefix)+8+common.AddressLength:
+			equivocations.Add(size)
+		case bytes.HasPrefix(key, addressActivityPrefix) && len(key) == len(addressActivityPrefix)+common.AddressLength+8:
+			addressActivity.Add(size)
+		case bytes.HasPrefix(key, transitionStatePrefix) && len(key) == len(transitionStatePrefix)+common.HashLength:
+			transitions.Add(size)
 		case bytes.HasPrefix(key, ChtTablePrefix) ||
 			bytes.HasPrefix(key, ChtIndexTablePrefix) ||
 			bytes.HasPrefix(key, ChtPrefix): // Canonical hash trie
@@ -778,6 +948,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
 				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
 				persistentStateIDKey, trieJournalKey, snapshotSyncStatusKey, snapSyncStatusFlagKey,
+				filledReceiptRangesKey, sealCheckRangesKey, receiptTailKey, blobSidecarTailKey, keyspacesKey, statePruningMarkerKey,
+				schemaVersionKey, schemaMigrationKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -843,11 +1015,14 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
 		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
 		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
+		{"Key-Value store", "Revert reasons", revertReasons.Size(), revertReasons.Count()},
 		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
 		{"Key-Value store", "BlobSidecars", blobSidecars.Size(), blobSidecars.Count()},
 		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
+		{"Key-Value store", "Skeleton headers", skeletonHeaders.Size(), skeletonHeaders.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
+		{"Key-Value store", "Uncle index", uncleIndex.Size(), uncleIndex.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Hash trie nodes", legacyTries.Size(), legacyTries.Count()},
@@ -861,6 +1036,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
 		{"Key-Value store", "Parlia snapshots", parliaSnaps.Size(), parliaSnaps.Count()},
+		{"Key-Value store", "Address filters", addressFilters.Size(), addressFilters.Count()},
+		{"Key-Value store", "Total supplies", totalSupplies.Size(), totalSupplies.Count()},
+		{"Key-Value store", "Fee totals", feeTotals.Size(), feeTotals.Count()},
+		{"Key-Value store", "Contract changes", contractChanges.Size(), contractChanges.Count()},
+		{"Key-Value store", "Contract statistics", contractStats.Size(), contractStats.Count()},
+		{"Key-Value store", "Selfdestruct husks", husks.Size(), husks.Count()},
+		{"Key-Value store", "Block access lists", accessLists.Size(), accessLists.Count()},
+		{"Key-Value store", "Equivocation evidence", equivocations.Size(), equivocations.Count()},
+		{"Key-Value store", "Address activity", addressActivity.Size(), addressActivity.Count()},
+		{"Key-Value store", "Verkle transition", transitions.Size(), transitions.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
 		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
@@ -909,6 +1094,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 	table.AppendBulk(stats)
 	table.Render()
 
+	if tail := ReadReceiptTail(db); tail != nil {
+		log.Info("Receipts and log index pruned", "tail", *tail)
+	}
 	if unaccounted.size > 0 {
 		log.Error("Database contains unaccounted data", "size", unaccounted.size, "count", unaccounted.count)
 	}
@@ -992,6 +1180,7 @@ func ReadChainMetadata(db ethdb.Reader) [][]string {
 	}
 	data := [][]string{
 		{"databaseVersion", pp(ReadDatabaseVersion(db))},
+		{"schemaVersion", pp(ReadSchemaVersion(db))},
 		{"headBlockHash", fmt.Sprintf("%v", ReadHeadBlockHash(db))},
 		{"headFastBlockHash", fmt.Sprintf("%v", ReadHeadFastBlockHash(db))},
 		{"headHeaderHash", fmt.Sprintf("%v", ReadHeadHeaderHash(db))},
@@ -17,9 +17,15 @@
 package rawdb
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 )
 
 const (
@@ -92,3 +98,93 @@ func Test_resolveChainFreezerDir(t *testing.T) {
 		})
 	}
 }
+
+// Tests that memory databases can be rolled back to snapshots and made faulty.
+func TestMemoryDatabaseSnapshot(t *testing.T) {
+	db := NewMemoryDatabase()
+	WriteCanonicalHash(db, common.Hash{0x01}, 1)
+
+	snap, err := SnapshotMemoryDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	WriteCanonicalHash(db, common.Hash{0x02}, 1)
+	WriteCanonicalHash(db, common.Hash{0x03}, 2)
+
+	if err := RestoreMemoryDatabase(db, snap); err != nil {
+		t.Fatalf("failed to restore snapshot: %v", err)
+	}
+	if hash := ReadCanonicalHash(db, 1); hash != (common.Hash{0x01}) {
+		t.Errorf("canonical hash mismatch: have %x, want %x", hash, common.Hash{0x01})
+	}
+	if hash := ReadCanonicalHash(db, 2); hash != (common.Hash{}) {
+		t.Errorf("canonical hash written after snapshot present: %x", hash)
+	}
+	if err := SetMemoryDatabaseFaults(db, 0, 1); err != nil {
+		t.Fatalf("failed to enable faults: %v", err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); !errors.Is(err, memorydb.ErrInjectedFault) {
+		t.Errorf("write fault not injected: %v", err)
+	}
+	if _, err := SnapshotMemoryDatabase(NewDatabase(NewMemoryDatabase())); err == nil {
+		t.Error("snapshot taken of a wrapped database")
+	}
+}
+
+// Tests that a paused freezer holds off the freeze cycles until resumed.
+func TestFreezerPause(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false, false, false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	frdb := db.(*freezerdb).AncientFreezer.(*chainFreezer)
+	db.PauseFreezing()
+
+	trigger := make(chan struct{})
+	select {
+	case frdb.trigger <- trigger:
+		t.Fatal("freeze cycle started while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	db.ResumeFreezing()
+
+	select {
+	case frdb.trigger <- trigger:
+		<-trigger
+	case <-time.After(time.Second):
+		t.Fatal("freeze cycle not started after resume")
+	}
+}
+
+func TestAttachStateStore(t *testing.T) {
+	db, state := NewMemoryDatabase(), NewMemoryDatabase()
+	if err := AttachStateStore(db, state); err != nil {
+		t.Fatalf("failed to attach state store: %v", err)
+	}
+	if err := AttachStateStore(db, state); err != nil {
+		t.Fatalf("failed to reattach state store: %v", err)
+	}
+	if err := AttachStateStore(db, NewMemoryDatabase()); !errors.Is(err, errStateStoreAttached) {
+		t.Fatalf("second state store attached: %v", err)
+	}
+	if db.GetStateStore() != state {
+		t.Fatal("state store not attached")
+	}
+	// A chain database holding the state must not be split from it
+	db = NewMemoryDatabase()
+	header := &types.Header{Number: common.Big0, Root: common.Hash{0x02}}
+	WriteHeader(db, header)
+	WriteCanonicalHash(db, header.Hash(), 0)
+	WriteLegacyTrieNode(db, header.Root, []byte{0x02})
+
+	if err := AttachStateStore(db, NewMemoryDatabase()); !errors.Is(err, errStateInChainStore) {
+		t.Fatalf("empty state store attached to populated chain database: %v", err)
+	}
+	state = NewMemoryDatabase()
+	WriteLegacyTrieNode(state, header.Root, []byte{0x02})
+	if err := AttachStateStore(db, state); err != nil {
+		t.Fatalf("failed to attach migrated state store: %v", err)
+	}
+}
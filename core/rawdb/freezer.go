@@ -81,6 +81,13 @@ type Freezer struct {
 // entry is true, snappy compression is disabled for the table.
 // additionTables indicates the new add tables for freezerDB, it has some special rules.
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return NewRemoteFreezer(datadir, namespace, readonly, maxTableSize, tables, nil)
+}
+
+// NewRemoteFreezer creates a freezer instance like NewFreezer, which offloads
+// the sealed data files of its tables into the configured object store. The
+// addition tables are always kept locally.
+func NewRemoteFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool, remote *RemoteFreezerConfig) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -131,7 +138,7 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 		if slices.Contains(additionTables, name) {
 			table, err = openAdditionTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly)
 		} else {
-			table, err = newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly)
+			table, err = openTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly, remote)
 		}
 		if err != nil {
 			for _, table := range freezer.tables {
@@ -338,7 +345,8 @@ func (f *Freezer) TruncateHead(items uint64) (uint64, error) {
 			// This often happens in chain rewinds, but the blob table is special.
 			// It has the same head, but a different tail from other tables (like bodies, receipts).
 			// So if the chain is rewound to head below the blob's tail, it needs to reset again.
-			if kind != ChainFreezerBlobSidecarTable {
+			// The same applies to pruned tables.
+			if kind != ChainFreezerBlobSidecarTable && !slices.Contains(prunableTables, kind) {
 				return 0, err
 			}
 			nt, err := table.resetItems(items)
@@ -404,8 +412,8 @@ func (f *Freezer) validate() error {
 	)
 	// Hack to get boundary of any table
 	for kind, table := range f.tables {
-		// addition tables is special cases
-		if slices.Contains(additionTables, kind) {
+		// addition and prunable tables are special cases
+		if slices.Contains(additionTables, kind) || slices.Contains(prunableTables, kind) {
 			continue
 		}
 		head = table.items.Load()
@@ -430,6 +438,16 @@ func (f *Freezer) validate() error {
 			}
 			continue
 		}
+		// prunable tables align head, but may be pruned beyond the tail
+		if slices.Contains(prunableTables, kind) {
+			if head != table.items.Load() {
+				return fmt.Errorf("freezer tables %s and %s have differing head: %d != %d", kind, name, table.items.Load(), head)
+			}
+			if tail > table.itemHidden.Load() {
+				return fmt.Errorf("freezer tables %s and %s have differing tail: %d != %d", kind, name, table.itemHidden.Load(), tail)
+			}
+			continue
+		}
 		if head != table.items.Load() {
 			return fmt.Errorf("freezer tables %s and %s have differing head: %d != %d", kind, name, table.items.Load(), head)
 		}
@@ -464,6 +482,10 @@ func (f *Freezer) repair() error {
 		if head > items {
 			head = items
 		}
+		// prunable tables don't restrict the common tail
+		if slices.Contains(prunableTables, kind) {
+			continue
+		}
 		hidden := table.itemHidden.Load()
 		if hidden > tail {
 			tail = hidden
@@ -479,7 +501,8 @@ func (f *Freezer) repair() error {
 			// This often happens in chain rewinds, but the blob table is special.
 			// It has the same head, but a different tail from other tables (like bodies, receipts).
 			// So if the chain is rewound to head below the blob's tail, it needs to reset again.
-			if kind != ChainFreezerBlobSidecarTable {
+			// The same applies to pruned tables.
+			if kind != ChainFreezerBlobSidecarTable && !slices.Contains(prunableTables, kind) {
 				return err
 			}
 			nt, err := table.resetItems(head)
@@ -510,8 +533,8 @@ func (f *Freezer) TruncateTableTail(kind string, tail uint64) (uint64, error) {
 	f.writeLock.Lock()
 	defer f.writeLock.Unlock()
 
-	if !slices.Contains(additionTables, kind) {
-		return 0, errors.New("only new added table could be truncated independently")
+	if !slices.Contains(additionTables, kind) && !slices.Contains(prunableTables, kind) {
+		return 0, errors.New("only new added or prunable table could be truncated independently")
 	}
 	t, exist := f.tables[kind]
 	if !exist {
@@ -120,6 +120,16 @@ func (t *memoryTable) truncateTail(items uint64) error {
 	return nil
 }
 
+// resetItems drops all the data in the table and restarts it at the given
+// number, used if a table pruned beyond the tail is rewound below it.
+func (t *memoryTable) resetItems(items uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.data, t.size = nil, 0
+	t.items, t.offset = items, items
+}
+
 // commit merges the given item batch into table. It's presumed that the
 // batch is ordered and continuous with table.
 func (t *memoryTable) commit(batch [][]byte) error {
@@ -380,7 +390,11 @@ func (f *MemoryFreezer) TruncateHead(items uint64) (uint64, error) {
 	if old <= items {
 		return old, nil
 	}
-	for _, table := range f.tables {
+	for kind, table := range f.tables {
+		if slices.Contains(prunableTables, kind) && items < table.offset {
+			table.resetItems(items)
+			continue
+		}
 		if err := table.truncateHead(items); err != nil {
 			return 0, err
 		}
@@ -441,9 +455,27 @@ func (f *MemoryFreezer) Reset() error {
 	return nil
 }
 
+// TruncateTableTail discards the data below the provided threshold number in
+// a single table. Only prunable tables can be truncated independently.
 func (f *MemoryFreezer) TruncateTableTail(kind string, tail uint64) (uint64, error) {
-	//TODO implement me
-	panic("implement me")
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	if !slices.Contains(prunableTables, kind) {
+		return 0, errors.New("only prunable table could be truncated independently")
+	}
+	table, exist := f.tables[kind]
+	if !exist {
+		return 0, errUnknownTable
+	}
+	old := table.offset
+	if err := table.truncateTail(tail); err != nil {
+		return 0, err
+	}
+	return old, nil
 }
 
 func (f *MemoryFreezer) ResetTable(kind string, startAt uint64, onlyEmpty bool) error {
@@ -0,0 +1,381 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// errRemoteCorrupted is returned if an offloaded data file retrieved from the
+// object store doesn't match the checksum recorded when it was uploaded.
+var errRemoteCorrupted = errors.New("offloaded freezer file corrupted")
+
+var (
+	remoteUploadMeter   = metrics.NewRegisteredMeter("freezer/remote/upload", nil)
+	remoteDownloadMeter = metrics.NewRegisteredMeter("freezer/remote/download", nil)
+	remoteCacheHitMeter = metrics.NewRegisteredMeter("freezer/remote/hit", nil)
+)
+
+// ObjectStore is a flat blob storage, such as an S3 or GCS bucket, which the
+// sealed data files of the freezer can be offloaded to. Objects are streamed,
+// as data files are up to 2GB large.
+type ObjectStore interface {
+	// Get retrieves the object stored under the given key. The caller must
+	// close the returned reader.
+	Get(key string) (io.ReadCloser, error)
+
+	// Put stores size bytes read from data under the given key, replacing any
+	// existing object.
+	Put(key string, data io.Reader, size int64) error
+
+	// Delete removes the object stored under the given key. Deleting a missing
+	// object is not an error.
+	Delete(key string) error
+}
+
+// RemoteFreezerConfig configures offloading the sealed data files of freezer
+// tables into an object store. Index and metadata files, as well as the data
+// file currently written, always stay on the local disk.
+type RemoteFreezerConfig struct {
+	Store     ObjectStore // Object store to offload the sealed data files into
+	Prefix    string      // Key prefix of the offloaded files within the store
+	CacheSize uint64      // Size of offloaded files retained locally, in bytes
+}
+
+// remoteSegments tracks the offloaded data files of a freezer table, serving
+// reads through a size limited local cache. The checksum of every offloaded
+// file is kept on the local disk to validate the retrieved content.
+type remoteSegments struct {
+	config        *RemoteFreezerConfig
+	path          string // Directory of the freezer table
+	name          string // Name of the freezer table
+	noCompression bool
+
+	lock    sync.Mutex
+	files   map[uint32]*os.File      // Locally cached offloaded files
+	sizes   map[uint32]uint64        // Sizes of the cached files
+	used    map[uint32]uint64        // Access clock of the cached files for eviction
+	refs    map[uint32]int           // Number of reads in progress, pinning the cached files
+	pending map[uint32]chan struct{} // Downloads in progress, closed when done
+	clock   uint64
+	size    uint64 // Total size of the cached files
+
+	uploadLock sync.Mutex     // Serializes the uploads and their completion
+	uploads    sync.WaitGroup // Uploads in progress
+}
+
+// newRemoteSegments creates the offloading tracker of a table, or returns nil
+// if offloading is disabled.
+func newRemoteSegments(config *RemoteFreezerConfig, path, name string, noCompression bool) *remoteSegments {
+	if config == nil {
+		return nil
+	}
+	return &remoteSegments{
+		config:        config,
+		path:          path,
+		name:          name,
+		noCompression: noCompression,
+		files:         make(map[uint32]*os.File),
+		sizes:         make(map[uint32]uint64),
+		used:          make(map[uint32]uint64),
+		refs:          make(map[uint32]int),
+		pending:       make(map[uint32]chan struct{}),
+	}
+}
+
+// filePath returns the local path of a data file.
+func (r *remoteSegments) filePath(num uint32) string {
+	return filepath.Join(r.path, dataFileName(r.name, num, r.noCompression))
+}
+
+// sumPath returns the local path of the checksum of an offloaded data file.
+func (r *remoteSegments) sumPath(num uint32) string {
+	return r.filePath(num) + ".sum"
+}
+
+// key returns the object store key of a data file.
+func (r *remoteSegments) key(num uint32) string {
+	return path.Join(r.config.Prefix, dataFileName(r.name, num, r.noCompression))
+}
+
+// offloaded reports whether the data file was moved into the object store.
+func (r *remoteSegments) offloaded(num uint32) bool {
+	_, err := os.Stat(r.sumPath(num))
+	return err == nil
+}
+
+// upload streams a sealed data file into the object store, returning its size
+// and checksum. The caller must hold the upload lock.
+func (r *remoteSegments) upload(num uint32, file *os.File) (uint64, []byte, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	hasher := sha256.New()
+	data := io.TeeReader(io.NewSectionReader(file, 0, stat.Size()), hasher)
+	if err := r.config.Store.Put(r.key(num), data, stat.Size()); err != nil {
+		return 0, nil, err
+	}
+	remoteUploadMeter.Mark(stat.Size())
+	return uint64(stat.Size()), hasher.Sum(nil), nil
+}
+
+// adoptUploaded records the checksum of an uploaded data file and takes over
+// the ownership of its open file descriptor.
+func (r *remoteSegments) adoptUploaded(num uint32, file *os.File, size uint64, sum []byte) error {
+	if err := os.WriteFile(r.sumPath(num), sum, 0644); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.adopt(num, file, size)
+	return nil
+}
+
+// readAt reads from an offloaded data file, retrieving it from the object
+// store if it's not cached locally. Reads of different files proceed in
+// parallel, the cached file is pinned against eviction while in use.
+func (r *remoteSegments) readAt(num uint32, p []byte, off int64) error {
+	file, err := r.acquire(num)
+	if err != nil {
+		return err
+	}
+	defer r.release(num)
+
+	if _, err := file.ReadAt(p, off); err != nil {
+		return fmt.Errorf("%w, fileid: %d, start: %d, length: %d", err, num, off, len(p))
+	}
+	return nil
+}
+
+// acquire returns the pinned local copy of an offloaded data file, downloading
+// it if necessary. Concurrent requests for the same file share the download.
+func (r *remoteSegments) acquire(num uint32) (*os.File, error) {
+	r.lock.Lock()
+	for {
+		if file, ok := r.files[num]; ok {
+			r.clock++
+			r.used[num] = r.clock
+			r.refs[num]++
+			r.lock.Unlock()
+
+			remoteCacheHitMeter.Mark(1)
+			return file, nil
+		}
+		done, ok := r.pending[num]
+		if !ok {
+			break
+		}
+		r.lock.Unlock()
+		<-done
+		r.lock.Lock()
+	}
+	done := make(chan struct{})
+	r.pending[num] = done
+	r.lock.Unlock()
+
+	file, size, err := r.fetch(num)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.pending, num)
+	close(done)
+	if err != nil {
+		return nil, err
+	}
+	r.adopt(num, file, size)
+	r.refs[num]++
+	return file, nil
+}
+
+// release unpins a local copy acquired before.
+func (r *remoteSegments) release(num uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.refs[num]--; r.refs[num] <= 0 {
+		delete(r.refs, num)
+	}
+}
+
+// fetch opens the local copy of an offloaded data file, streaming it from the
+// object store and validating it if necessary.
+func (r *remoteSegments) fetch(num uint32) (*os.File, uint64, error) {
+	sum, err := os.ReadFile(r.sumPath(num))
+	if err != nil {
+		return nil, 0, fmt.Errorf("missing data file %d", num)
+	}
+	// The file might still be on disk from a previous run
+	if file, err := openFreezerFileForReadOnly(r.filePath(num)); err == nil {
+		if stat, err := file.Stat(); err == nil {
+			return file, uint64(stat.Size()), nil
+		}
+		file.Close()
+	}
+	data, err := r.config.Store.Get(r.key(num))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve data file %d: %w", num, err)
+	}
+	defer data.Close()
+
+	tmp := r.filePath(num) + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return nil, 0, err
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hasher), data)
+	if err == nil {
+		err = out.Sync()
+	}
+	out.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return nil, 0, fmt.Errorf("failed to retrieve data file %d: %w", num, err)
+	}
+	if have := hasher.Sum(nil); !bytes.Equal(have, sum) {
+		os.Remove(tmp)
+		return nil, 0, fmt.Errorf("%w: file %d, checksum %x, want %x", errRemoteCorrupted, num, have, sum)
+	}
+	remoteDownloadMeter.Mark(size)
+
+	if err := os.Rename(tmp, r.filePath(num)); err != nil {
+		return nil, 0, err
+	}
+	file, err := openFreezerFileForReadOnly(r.filePath(num))
+	if err != nil {
+		return nil, 0, err
+	}
+	return file, uint64(size), nil
+}
+
+// adopt inserts a local copy into the cache, evicting the least recently used
+// unpinned ones above the size limit. The inserted copy itself is never evicted,
+// so it can be read right away. The caller must hold the lock.
+func (r *remoteSegments) adopt(num uint32, file *os.File, size uint64) {
+	r.clock++
+	r.files[num], r.sizes[num], r.used[num] = file, size, r.clock
+	r.size += size
+
+	for r.size > r.config.CacheSize {
+		var (
+			oldest uint32
+			clock  = r.clock + 1
+		)
+		for id, used := range r.used {
+			if used < clock && id != num && r.refs[id] == 0 {
+				oldest, clock = id, used
+			}
+		}
+		if clock > r.clock {
+			return // everything else is in use
+		}
+		r.evict(oldest)
+	}
+}
+
+// evict closes and removes the local copy of an offloaded data file. The caller
+// must hold the lock.
+func (r *remoteSegments) evict(num uint32) {
+	file, ok := r.files[num]
+	if !ok {
+		return
+	}
+	file.Close()
+	if err := os.Remove(file.Name()); err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to remove cached freezer file", "file", file.Name(), "err", err)
+	}
+	r.size -= r.sizes[num]
+	delete(r.files, num)
+	delete(r.sizes, num)
+	delete(r.used, num)
+}
+
+// restore brings an offloaded data file back onto the local disk and stops
+// tracking it, making it writable again. The caller must ensure no reads are
+// in progress.
+func (r *remoteSegments) restore(num uint32) error {
+	if !r.offloaded(num) {
+		return nil
+	}
+	if _, err := r.acquire(num); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Release the descriptor without deleting the local copy
+	r.files[num].Close()
+	r.size -= r.sizes[num]
+	delete(r.files, num)
+	delete(r.sizes, num)
+	delete(r.used, num)
+	delete(r.refs, num)
+
+	if err := r.config.Store.Delete(r.key(num)); err != nil {
+		log.Warn("Failed to delete offloaded freezer file", "key", r.key(num), "err", err)
+	}
+	return os.Remove(r.sumPath(num))
+}
+
+// drop deletes the offloaded data files in the range [from, to), both locally
+// and from the object store. The caller must ensure no reads are in progress.
+func (r *remoteSegments) drop(from, to uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for num := from; num < to; num++ {
+		if !r.offloaded(num) {
+			continue
+		}
+		r.evict(num)
+		if err := r.config.Store.Delete(r.key(num)); err != nil {
+			log.Warn("Failed to delete offloaded freezer file", "key", r.key(num), "err", err)
+		}
+		os.Remove(r.filePath(num))
+		os.Remove(r.sumPath(num))
+	}
+}
+
+// close releases the descriptors of the cached files, keeping the local copies
+// around for the next run. The caller must wait for the uploads beforehand.
+func (r *remoteSegments) close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for num, file := range r.files {
+		file.Close()
+		delete(r.files, num)
+	}
+	clear(r.sizes)
+	clear(r.used)
+	clear(r.refs)
+	r.size = 0
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config is the configuration of an S3 compatible object store. Google Cloud
+// Storage is supported through its XML API with HMAC keys, using the endpoint
+// https://storage.googleapis.com and the region "auto".
+type S3Config struct {
+	Endpoint  string // Base URL of the service, e.g. https://s3.us-east-1.amazonaws.com
+	Region    string // Region used for request signing
+	Bucket    string // Bucket holding the objects
+	AccessKey string
+	SecretKey string
+	Timeout   time.Duration // Timeout of a single request, no timeout if zero
+}
+
+// S3Store is an ObjectStore backed by an S3 compatible bucket, addressed in
+// path style and authenticated with AWS signature version 4.
+type S3Store struct {
+	config S3Config
+	base   *url.URL
+	client *http.Client
+}
+
+// NewS3Store creates an object store on top of an S3 compatible bucket.
+func NewS3Store(config S3Config) (*S3Store, error) {
+	base, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("missing bucket")
+	}
+	return &S3Store{
+		config: config,
+		base:   base,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Get retrieves the object stored under the given key, streaming its content.
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Put streams the object under the given key, replacing any existing one.
+func (s *S3Store) Put(key string, data io.Reader, size int64) error {
+	res, err := s.do(http.MethodPut, key, data, size)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Delete removes the object stored under the given key.
+func (s *S3Store) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, 0)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// do sends a signed request for the given object. The response body is only
+// returned on success and must be closed by the caller.
+func (s *S3Store) do(method, key string, body io.Reader, size int64) (*http.Response, error) {
+	target := *s.base
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + s.config.Bucket + "/" + strings.TrimPrefix(key, "/")
+
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	s.sign(req, time.Now().UTC())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 == 2 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	// Missing objects are fine to delete
+	if method == http.MethodDelete && res.StatusCode == http.StatusNotFound {
+		res.Body = io.NopCloser(bytes.NewReader(nil))
+		return res, nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	return nil, fmt.Errorf("%s %s: status %d: %s", method, key, res.StatusCode, bytes.TrimSpace(data))
+}
+
+// sign authenticates the request with AWS signature version 4. The payload is
+// left unsigned, so it can be streamed; it's protected by TLS and validated by
+// the checksum of the freezer on retrieval.
+func (s *S3Store) sign(req *http.Request, now time.Time) {
+	var (
+		date      = now.Format("20060102")
+		stamp     = now.Format("20060102T150405Z")
+		scope     = date + "/" + s.config.Region + "/s3/aws4_request"
+		signed    = "host;x-amz-content-sha256;x-amz-date"
+		bodyHash  = "UNSIGNED-PAYLOAD"
+		canonical = strings.Join([]string{
+			req.Method,
+			req.URL.EscapedPath(),
+			req.URL.RawQuery,
+			"host:" + req.URL.Host + "\n" + "x-amz-content-sha256:" + bodyHash + "\n" + "x-amz-date:" + stamp + "\n",
+			signed,
+			bodyHash,
+		}, "\n")
+	)
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", bodyHash)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
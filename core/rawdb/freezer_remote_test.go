@@ -0,0 +1,244 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// memoryObjectStore is an in-memory object store for testing.
+type memoryObjectStore struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryObjectStore() *memoryObjectStore {
+	return &memoryObjectStore{objects: make(map[string][]byte)}
+}
+
+func (s *memoryObjectStore) Get(key string) (io.ReadCloser, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(bytes.Clone(data))), nil
+}
+
+func (s *memoryObjectStore) Put(key string, data io.Reader, size int64) error {
+	blob, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	if int64(len(blob)) != size {
+		return fmt.Errorf("size mismatch: have %d, want %d", len(blob), size)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.objects[key] = blob
+	return nil
+}
+
+func (s *memoryObjectStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryObjectStore) has(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, ok := s.objects[key]
+	return ok
+}
+
+// Tests that sealed data files are offloaded into the object store and served
+// through the local cache, surviving restarts and truncations.
+func TestRemoteFreezerTable(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		store  = newMemoryObjectStore()
+		config = &RemoteFreezerConfig{Store: store, Prefix: "chain"}
+	)
+	open := func() *freezerTable {
+		f, err := openTable(dir, "remote", metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true, false, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	f := open()
+
+	// Write 15 bytes 255 times, results in 85 files, 84 of them sealed
+	writeChunks(t, f, 255, 15)
+	f.remote.uploads.Wait()
+	for num := uint32(0); num < 84; num++ {
+		if !store.has("chain/" + dataFileName("remote", num, true)) {
+			t.Fatalf("file %d not offloaded", num)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "remote.*.rdat")); len(files) > 2 {
+		t.Fatalf("offloaded files retained locally: %d", len(files))
+	}
+	items := make(map[uint64][]byte)
+	for i := 0; i < 255; i++ {
+		items[uint64(i)] = getChunk(15, i)
+	}
+	checkRetrieve(t, f, items)
+
+	// Reopen the table and check the offloaded files are still accessible
+	f.Close()
+	f = open()
+	checkRetrieve(t, f, items)
+
+	// Read concurrently, sharing downloads and pinning the files in use
+	var (
+		wg   sync.WaitGroup
+		errc = make(chan error, 8)
+	)
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 255; i++ {
+				item := uint64((i*7 + r*31) % 255)
+				if have, err := f.Retrieve(item); err != nil || !bytes.Equal(have, items[item]) {
+					errc <- fmt.Errorf("item %d: have %x, want %x, err %v", item, have, items[item], err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	if err := <-errc; err != nil {
+		t.Fatalf("concurrent read failed: %v", err)
+	}
+
+	// Corrupt an evicted file and check it's rejected
+	if _, err := f.Retrieve(200); err != nil {
+		t.Fatal(err)
+	}
+	store.Put("chain/"+dataFileName("remote", 0, true), bytes.NewReader(getChunk(50, 0xff)), 50)
+	if _, err := f.Retrieve(0); !errors.Is(err, errRemoteCorrupted) {
+		t.Fatalf("corrupted file accepted: %v", err)
+	}
+	// Truncate the tail and check the dropped files are deleted remotely
+	if err := f.truncateTail(150); err != nil {
+		t.Fatal(err)
+	}
+	if store.has("chain/" + dataFileName("remote", 49, true)) {
+		t.Fatal("truncated file retained in the object store")
+	}
+	// Truncate the head into an offloaded file and check it's writable again
+	if err := f.truncateHead(200); err != nil {
+		t.Fatal(err)
+	}
+	if f.remote.offloaded(66) || store.has("chain/"+dataFileName("remote", 67, true)) {
+		t.Fatal("rewound files still offloaded")
+	}
+	batch := f.newBatch()
+	if err := batch.AppendRaw(200, getChunk(15, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 150; i++ {
+		delete(items, i)
+	}
+	for i := uint64(201); i < 255; i++ {
+		delete(items, i)
+	}
+	checkRetrieve(t, f, items)
+	f.Close()
+}
+
+// Tests that the S3 store issues signed path style requests.
+func TestS3Store(t *testing.T) {
+	var (
+		objects = make(map[string][]byte)
+		lock    sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{Endpoint: server.URL, Region: "auto", Bucket: "ancient", AccessKey: "key", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("chain/headers.0000.cdat", bytes.NewReader([]byte{1, 2, 3}), 3); err != nil {
+		t.Fatalf("failed to put object: %v", err)
+	}
+	if _, ok := objects["/ancient/chain/headers.0000.cdat"]; !ok {
+		t.Fatalf("object stored under unexpected path: %v", objects)
+	}
+	body, err := store.Get("chain/headers.0000.cdat")
+	if err != nil {
+		t.Fatalf("failed to get object: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Fatalf("object mismatch: %x %v", data, err)
+	}
+	if err := store.Delete("chain/headers.0000.cdat"); err != nil {
+		t.Fatalf("failed to delete object: %v", err)
+	}
+	if _, err := store.Get("chain/headers.0000.cdat"); err == nil {
+		t.Fatal("deleted object retrieved")
+	}
+}
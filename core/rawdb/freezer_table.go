@@ -113,6 +113,7 @@ type freezerTable struct {
 	files  map[uint32]*os.File // open files
 	headId uint32              // number of the currently active head file
 	tailId uint32              // number of the earliest file
+	remote *remoteSegments     // object store the sealed files are offloaded to, nil if disabled
 
 	metadata *freezerTableMeta // metadata of the table
 	lastSync time.Time         // Timestamp when the last sync was performed
@@ -135,6 +136,12 @@ func newFreezerTable(path, name string, disableSnappy, readonly bool) (*freezerT
 // non-existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
 func newTable(path string, name string, readMeter, writeMeter *metrics.Meter, sizeGauge *metrics.Gauge, maxFilesize uint32, noCompression, readonly bool) (*freezerTable, error) {
+	return openTable(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, noCompression, readonly, nil)
+}
+
+// openTable opens a freezer table like newTable, offloading the sealed data
+// files into the configured object store if remote is non-nil.
+func openTable(path string, name string, readMeter, writeMeter *metrics.Meter, sizeGauge *metrics.Gauge, maxFilesize uint32, noCompression, readonly bool, remote *RemoteFreezerConfig) (*freezerTable, error) {
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -190,6 +197,7 @@ func newTable(path string, name string, readMeter, writeMeter *metrics.Meter, si
 		metadata:      metadata,
 		lastSync:      time.Now(),
 		files:         make(map[uint32]*os.File),
+		remote:        newRemoteSegments(remote, path, name, noCompression),
 		readMeter:     readMeter,
 		writeMeter:    writeMeter,
 		sizeGauge:     sizeGauge,
@@ -593,6 +601,10 @@ func (t *freezerTable) preopen() (err error) {
 
 	// Open all except head in RDONLY
 	for i := t.tailId; i < t.headId; i++ {
+		// Offloaded files are opened on demand
+		if t.remote != nil && t.remote.offloaded(i) {
+			continue
+		}
 		if _, err = t.openFile(i, openFreezerFileForReadOnly); err != nil {
 			return err
 		}
@@ -664,6 +676,13 @@ func (t *freezerTable) truncateHead(items uint64) error {
 	}
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
+		// Bring back the offloaded files which become writable again
+		if t.remote != nil {
+			if err := t.remote.restore(expected.filenum); err != nil {
+				return err
+			}
+			t.remote.drop(expected.filenum+1, t.headId)
+		}
 		// If already open for reading, force-reopen for writing
 		t.releaseFile(expected.filenum)
 		newHead, err := t.openFile(expected.filenum, openFreezerFileForAppend)
@@ -817,6 +836,9 @@ func (t *freezerTable) truncateTail(items uint64) error {
 		return err
 	}
 	// Release any files before the current tail
+	if t.remote != nil {
+		t.remote.drop(t.tailId, newTailId)
+	}
 	t.tailId = newTailId
 	t.itemOffset.Store(newDeleted)
 	t.releaseFilesBefore(t.tailId, true)
@@ -849,6 +871,9 @@ func (t *freezerTable) truncateTail(items uint64) error {
 // This operation must be completed before shutdown to prevent the loss of
 // recent writes.
 func (t *freezerTable) Close() error {
+	if t.remote != nil {
+		t.remote.uploads.Wait()
+	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
@@ -866,6 +891,9 @@ func (t *freezerTable) Close() error {
 	for _, f := range t.files {
 		doClose(f)
 	}
+	if t.remote != nil {
+		t.remote.close()
+	}
 	t.index = nil
 	t.head = nil
 	t.metadata.file = nil
@@ -880,13 +908,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(filepath.Join(t.path, dataFileName(t.name, num, t.noCompression)))
 		if err != nil {
 			return nil, err
 		}
@@ -895,6 +917,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// dataFileName returns the name of a data file of a freezer table.
+func dataFileName(table string, num uint32, noCompression bool) string {
+	if noCompression {
+		return fmt.Sprintf("%s.%04d.rdat", table, num)
+	}
+	return fmt.Sprintf("%s.%04d.cdat", table, num)
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -1052,6 +1082,9 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	readData := func(fileId, start uint32, length int) error {
 		output = grow(output, length)
 		dataFile, exist := t.files[fileId]
+		if !exist && t.remote != nil {
+			return t.remote.readAt(fileId, output[len(output)-length:], int64(start))
+		}
 		if !exist {
 			return fmt.Errorf("missing data file %d", fileId)
 		}
@@ -1168,7 +1201,14 @@ func (t *freezerTable) advanceHead() error {
 		return err
 	}
 	t.releaseFile(t.headId)
-	t.openFile(t.headId, openFreezerFileForReadOnly)
+	sealed, _ := t.openFile(t.headId, openFreezerFileForReadOnly)
+
+	// Move the sealed file into the object store in the background, it's
+	// served locally until the upload completes.
+	if t.remote != nil && sealed != nil {
+		t.remote.uploads.Add(1)
+		go t.offload(t.headId, sealed)
+	}
 
 	// Swap out the current head.
 	t.head = newHead
@@ -1177,6 +1217,40 @@ func (t *freezerTable) advanceHead() error {
 	return nil
 }
 
+// offload uploads a sealed data file into the object store without holding the
+// table lock, handing it over to the remote tracker afterwards. The upload is
+// discarded if the file was truncated or closed in the meantime. The file is
+// kept locally on failure, it's still fully functional.
+func (t *freezerTable) offload(num uint32, file *os.File) {
+	defer t.remote.uploads.Done()
+
+	t.remote.uploadLock.Lock()
+	defer t.remote.uploadLock.Unlock()
+
+	size, sum, err := t.remote.upload(num, file)
+	if err != nil {
+		t.logger.Warn("Failed to offload freezer file", "file", num, "err", err)
+		return
+	}
+	t.lock.Lock()
+	current := t.files[num] == file
+	if current {
+		if err = t.remote.adoptUploaded(num, file, size, sum); err == nil {
+			delete(t.files, num)
+		}
+	}
+	t.lock.Unlock()
+
+	if err != nil {
+		t.logger.Warn("Failed to offload freezer file", "file", num, "err", err)
+	}
+	if !current || err != nil {
+		if err := t.remote.config.Store.Delete(t.remote.key(num)); err != nil {
+			t.logger.Warn("Failed to delete offloaded freezer file", "file", num, "err", err)
+		}
+	}
+}
+
 // Sync pushes any pending data from memory out to disk. This is an expensive
 // operation, so use it with care.
 func (t *freezerTable) Sync() error {
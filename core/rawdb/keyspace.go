@@ -0,0 +1,450 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Keyspaces the chain data is partitioned into, if enabled on a backing store
+// supporting them. Everything else stays in the default keyspace.
+const (
+	KeyspaceTries    = "tries"    // Trie nodes and state lookups
+	KeyspaceHeaders  = "headers"  // Headers, total difficulties and hash/number mappings
+	KeyspaceReceipts = "receipts" // Block receipts
+	KeyspaceIndexes  = "indexes"  // Transaction lookups and bloombits
+)
+
+// keyspaces is the list of keyspaces the data is partitioned into.
+var keyspaces = [...]string{KeyspaceTries, KeyspaceHeaders, KeyspaceReceipts, KeyspaceIndexes}
+
+// KeyspaceCount is the number of keyspaces besides the default one.
+const KeyspaceCount = len(keyspaces)
+
+// relocatedKeys maps the keys moved into another keyspace after keyspaces were
+// introduced to their keyspace. They are migrated when the store is opened.
+var relocatedKeys = map[string]string{
+	string(persistentStateIDKey): KeyspaceTries,
+}
+
+// errKeyspacesUnsupported is returned if keyspaces are requested on a
+// populated database which was created without them.
+var errKeyspacesUnsupported = errors.New("database was created without keyspaces")
+
+// keyspaceOf returns the keyspace a database key belongs to, or an empty
+// string for the default one.
+func keyspaceOf(key []byte) string {
+	switch {
+	case len(key) == common.HashLength, IsAccountTrieNode(key), IsStorageTrieNode(key):
+		return KeyspaceTries
+	case bytes.Equal(key, persistentStateIDKey):
+		// The path scheme state ID must be written atomically with the nodes
+		return KeyspaceTries
+	case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
+		return KeyspaceTries
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength:
+		return KeyspaceHeaders
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength+len(headerTDSuffix) && bytes.HasSuffix(key, headerTDSuffix):
+		return KeyspaceHeaders
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+len(headerHashSuffix) && bytes.HasSuffix(key, headerHashSuffix):
+		return KeyspaceHeaders
+	case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == len(headerNumberPrefix)+common.HashLength:
+		return KeyspaceHeaders
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == len(blockReceiptsPrefix)+8+common.HashLength:
+		return KeyspaceReceipts
+	case bytes.HasPrefix(key, txLookupPrefix) && len(key) == len(txLookupPrefix)+common.HashLength:
+		return KeyspaceIndexes
+	case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == len(bloomBitsPrefix)+10+common.HashLength:
+		return KeyspaceIndexes
+	default:
+		return ""
+	}
+}
+
+// keyspacedStore is a key-value store routing the chain data into separately
+// managed keyspaces of the backing store, based on the key schema.
+type keyspacedStore struct {
+	ethdb.KeyValueStore                                // Default keyspace
+	spaces              map[string]ethdb.KeyValueStore // Named keyspaces
+}
+
+// NewKeyspacedStore wraps a key-value store, partitioning tries, headers,
+// receipts and indexes into separate keyspaces if the store supports them.
+// Whether keyspaces are used is decided when the database is created: a
+// database created with keyspaces keeps using them, while populated ones
+// created without are left as is, failing if keyspaces were requested.
+func NewKeyspacedStore(db ethdb.KeyValueStore, enable bool) (ethdb.KeyValueStore, error) {
+	keyspacer, ok := db.(ethdb.Keyspacer)
+	if !ok {
+		if enable {
+			return nil, errors.New("database doesn't support keyspaces")
+		}
+		return db, nil
+	}
+	marked, _ := db.Has(keyspacesKey)
+	if !marked {
+		if !enable {
+			return db, nil
+		}
+		if populated, _ := db.Has(databaseVersionKey); populated {
+			return nil, errKeyspacesUnsupported
+		}
+		if err := db.Put(keyspacesKey, []byte{1}); err != nil {
+			return nil, err
+		}
+	}
+	store := &keyspacedStore{
+		KeyValueStore: db,
+		spaces:        make(map[string]ethdb.KeyValueStore),
+	}
+	for _, name := range keyspaces {
+		space, err := keyspacer.Keyspace(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open keyspace %s: %w", name, err)
+		}
+		store.spaces[name] = space
+	}
+	if err := store.relocate(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// relocate moves the relocated keys still in the default keyspace into their
+// new keyspace.
+func (s *keyspacedStore) relocate() error {
+	for key, name := range relocatedKeys {
+		value, err := s.KeyValueStore.Get([]byte(key))
+		if err != nil {
+			continue // Not present in the default keyspace
+		}
+		space := s.spaces[name]
+		if has, _ := space.Has([]byte(key)); !has {
+			if err := space.Put([]byte(key), value); err != nil {
+				return err
+			}
+			if err := space.SyncKeyValue(); err != nil {
+				return err
+			}
+		}
+		if err := s.KeyValueStore.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isChainMarker reports whether a key of the default keyspace points to chain
+// data stored in the other keyspaces, which must be durable before the marker.
+func isChainMarker(key []byte) bool {
+	for _, marker := range [][]byte{headHeaderKey, headBlockKey, headFastBlockKey, headFinalizedBlockKey, headSafeBlockKey, lastPivotKey} {
+		if bytes.Equal(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the keyspace a key is stored in.
+func (s *keyspacedStore) route(key []byte) ethdb.KeyValueStore {
+	if name := keyspaceOf(key); name != "" {
+		return s.spaces[name]
+	}
+	return s.KeyValueStore
+}
+
+// stores returns all the keyspaces, the default one being the last.
+func (s *keyspacedStore) stores() []ethdb.KeyValueStore {
+	stores := make([]ethdb.KeyValueStore, 0, len(keyspaces)+1)
+	for _, name := range keyspaces {
+		stores = append(stores, s.spaces[name])
+	}
+	return append(stores, s.KeyValueStore)
+}
+
+// Has retrieves if a key is present in the key-value data store.
+func (s *keyspacedStore) Has(key []byte) (bool, error) {
+	return s.route(key).Has(key)
+}
+
+// Get retrieves the given key if it's present in the key-value data store.
+func (s *keyspacedStore) Get(key []byte) ([]byte, error) {
+	return s.route(key).Get(key)
+}
+
+// Put inserts the given value into the key-value data store. Chain markers are
+// only written once the data in the other keyspaces is durable.
+func (s *keyspacedStore) Put(key []byte, value []byte) error {
+	if isChainMarker(key) {
+		for _, name := range keyspaces {
+			if err := s.spaces[name].SyncKeyValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.route(key).Put(key, value)
+}
+
+// Delete removes the key from the key-value data store.
+func (s *keyspacedStore) Delete(key []byte) error {
+	return s.route(key).Delete(key)
+}
+
+// DeleteRange deletes all of the keys (and values) in the range [start,end)
+// from every keyspace.
+func (s *keyspacedStore) DeleteRange(start, end []byte) error {
+	for _, store := range s.stores() {
+		if err := store.DeleteRange(start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stat returns the statistic data of all the keyspaces.
+func (s *keyspacedStore) Stat() (string, error) {
+	var stats []string
+	for i, store := range s.stores() {
+		stat, err := store.Stat()
+		if err != nil {
+			return "", err
+		}
+		name := "default"
+		if i < len(keyspaces) {
+			name = keyspaces[i]
+		}
+		stats = append(stats, fmt.Sprintf("Keyspace %s:\n%s", name, stat))
+	}
+	return strings.Join(stats, "\n"), nil
+}
+
+// SyncKeyValue flushes all keyspaces to disk.
+func (s *keyspacedStore) SyncKeyValue() error {
+	for _, store := range s.stores() {
+		if err := store.SyncKeyValue(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact flattens the given key range in all keyspaces.
+func (s *keyspacedStore) Compact(start []byte, limit []byte) error {
+	for _, store := range s.stores() {
+		if err := store.Compact(start, limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keyspace returns the named keyspace of the store, allowing targeted
+// compaction.
+func (s *keyspacedStore) Keyspace(name string) (ethdb.KeyValueStore, error) {
+	if space, ok := s.spaces[name]; ok {
+		return space, nil
+	}
+	return nil, fmt.Errorf("unknown keyspace %s", name)
+}
+
+// NewIterator creates an iterator merging the content of all keyspaces.
+func (s *keyspacedStore) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	stores := s.stores()
+	iters := make([]ethdb.Iterator, len(stores))
+	for i, store := range stores {
+		iters[i] = store.NewIterator(prefix, start)
+	}
+	return &mergedIterator{iters: iters, cur: -1}
+}
+
+// NewBatch creates a write-only batch routing writes into the keyspaces.
+func (s *keyspacedStore) NewBatch() ethdb.Batch {
+	return &keyspacedBatch{store: s, batches: make(map[ethdb.KeyValueStore]ethdb.Batch)}
+}
+
+// NewBatchWithSize creates a write-only batch routing writes into the
+// keyspaces. The size hint is applied to the default keyspace only.
+func (s *keyspacedStore) NewBatchWithSize(size int) ethdb.Batch {
+	batch := s.NewBatch().(*keyspacedBatch)
+	batch.batches[s.KeyValueStore] = s.KeyValueStore.NewBatchWithSize(size)
+	return batch
+}
+
+// keyspacedBatch is a batch spanning multiple keyspaces.
+type keyspacedBatch struct {
+	store   *keyspacedStore
+	batches map[ethdb.KeyValueStore]ethdb.Batch
+	markers bool // Whether the batch updates chain markers
+}
+
+// batch returns the batch of the keyspace a key is stored in.
+func (b *keyspacedBatch) batch(key []byte) ethdb.Batch {
+	store := b.store.route(key)
+	batch, ok := b.batches[store]
+	if !ok {
+		batch = store.NewBatch()
+		b.batches[store] = batch
+	}
+	return batch
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *keyspacedBatch) Put(key, value []byte) error {
+	if isChainMarker(key) {
+		b.markers = true
+	}
+	return b.batch(key).Put(key, value)
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *keyspacedBatch) Delete(key []byte) error {
+	return b.batch(key).Delete(key)
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *keyspacedBatch) ValueSize() int {
+	var size int
+	for _, batch := range b.batches {
+		size += batch.ValueSize()
+	}
+	return size
+}
+
+// Write flushes the batches of all keyspaces. The default keyspace is written
+// last: if the batch updates the chain markers, the other keyspaces are synced
+// to disk before, so the markers never point to data lost in a crash between
+// the writes. Other batches need no ordering and aren't synced.
+func (b *keyspacedBatch) Write() error {
+	markers, ok := b.batches[b.store.KeyValueStore]
+	var written []ethdb.KeyValueStore
+	for _, store := range b.store.stores() {
+		if store == b.store.KeyValueStore {
+			break
+		}
+		if batch, ok := b.batches[store]; ok {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			written = append(written, store)
+		}
+	}
+	if !ok {
+		return nil
+	}
+	if b.markers {
+		for _, store := range written {
+			if err := store.SyncKeyValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return markers.Write()
+}
+
+// Reset resets the batch for reuse.
+func (b *keyspacedBatch) Reset() {
+	for _, batch := range b.batches {
+		batch.Reset()
+	}
+	b.markers = false
+}
+
+// Replay replays the batch contents, keyspace by keyspace.
+func (b *keyspacedBatch) Replay(w ethdb.KeyValueWriter) error {
+	for _, store := range b.store.stores() {
+		if batch, ok := b.batches[store]; ok {
+			if err := batch.Replay(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergedIterator iterates over the union of multiple iterators with disjoint
+// key sets in ascending key order.
+type mergedIterator struct {
+	iters   []ethdb.Iterator
+	valid   []bool
+	cur     int // Index of the iterator positioned at the current key, -1 if none
+	started bool
+	err     error
+}
+
+// Next moves the iterator to the next key/value pair.
+func (it *mergedIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		it.valid = make([]bool, len(it.iters))
+		for i, iter := range it.iters {
+			it.valid[i] = iter.Next()
+		}
+	} else if it.cur >= 0 {
+		it.valid[it.cur] = it.iters[it.cur].Next()
+	}
+	it.cur = -1
+	for i, iter := range it.iters {
+		if !it.valid[i] {
+			if err := iter.Error(); err != nil {
+				it.err = err
+				return false
+			}
+			continue
+		}
+		if it.cur < 0 || bytes.Compare(iter.Key(), it.iters[it.cur].Key()) < 0 {
+			it.cur = i
+		}
+	}
+	return it.cur >= 0
+}
+
+// Error returns any accumulated error.
+func (it *mergedIterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *mergedIterator) Key() []byte {
+	if it.cur < 0 {
+		return nil
+	}
+	return it.iters[it.cur].Key()
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *mergedIterator) Value() []byte {
+	if it.cur < 0 {
+		return nil
+	}
+	return it.iters[it.cur].Value()
+}
+
+// Release releases associated resources.
+func (it *mergedIterator) Release() {
+	for _, iter := range it.iters {
+		iter.Release()
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// keyspacedMemoryDatabase is an in-memory store supporting keyspaces.
+type keyspacedMemoryDatabase struct {
+	*memorydb.Database
+	spaces map[string]*keyspaceMemoryDatabase
+	syncs  []int // Size of the default keyspace at each keyspace sync
+}
+
+func newKeyspacedMemoryDatabase() *keyspacedMemoryDatabase {
+	return &keyspacedMemoryDatabase{Database: memorydb.New(), spaces: make(map[string]*keyspaceMemoryDatabase)}
+}
+
+func (db *keyspacedMemoryDatabase) Keyspace(name string) (ethdb.KeyValueStore, error) {
+	if _, ok := db.spaces[name]; !ok {
+		db.spaces[name] = &keyspaceMemoryDatabase{Database: memorydb.New(), parent: db}
+	}
+	return db.spaces[name], nil
+}
+
+// keyspaceMemoryDatabase is a keyspace of a keyspacedMemoryDatabase, recording
+// its syncs in the parent.
+type keyspaceMemoryDatabase struct {
+	*memorydb.Database
+	parent *keyspacedMemoryDatabase
+}
+
+func (db *keyspaceMemoryDatabase) SyncKeyValue() error {
+	db.parent.syncs = append(db.parent.syncs, db.parent.Len())
+	return db.Database.SyncKeyValue()
+}
+
+// Tests that chain data is routed into the keyspaces and is accessible through
+// the high level accessors and iterators.
+func TestKeyspacedStore(t *testing.T) {
+	backing := newKeyspacedMemoryDatabase()
+	store, err := NewKeyspacedStore(backing, true)
+	if err != nil {
+		t.Fatalf("failed to create keyspaced store: %v", err)
+	}
+	db := NewDatabase(store)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	hash := header.Hash()
+
+	batch := db.NewBatch()
+	WriteHeader(batch, header)
+	WriteCanonicalHash(batch, hash, 1)
+	WriteTxLookupEntries(batch, 1, []common.Hash{{0x01}})
+	WriteDatabaseVersion(batch, 1)
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if have := ReadHeader(db, hash, 1); have == nil || have.Hash() != hash {
+		t.Fatal("header not retrievable")
+	}
+	if number := ReadTxLookupEntry(db, common.Hash{0x01}); number == nil || *number != 1 {
+		t.Fatal("transaction lookup not retrievable")
+	}
+	if backing.spaces[KeyspaceHeaders].Len() != 3 {
+		t.Errorf("headers keyspace size mismatch: have %d, want 3", backing.spaces[KeyspaceHeaders].Len())
+	}
+	if backing.spaces[KeyspaceIndexes].Len() != 1 {
+		t.Errorf("indexes keyspace size mismatch: have %d, want 1", backing.spaces[KeyspaceIndexes].Len())
+	}
+	// Iterate over everything and check the keys are merged in order
+	var (
+		it   = db.NewIterator(nil, nil)
+		keys [][]byte
+	)
+	for it.Next() {
+		keys = append(keys, common.CopyBytes(it.Key()))
+	}
+	it.Release()
+	if len(keys) != backing.Len()+3+1 {
+		t.Fatalf("iterated key count mismatch: have %d, want %d", len(keys), backing.Len()+4)
+	}
+	for i := 1; i < len(keys); i++ {
+		if bytes.Compare(keys[i-1], keys[i]) >= 0 {
+			t.Fatalf("keys out of order: %x >= %x", keys[i-1], keys[i])
+		}
+	}
+	// Reopening keeps using the keyspaces even if not requested
+	if reopened, _ := NewKeyspacedStore(backing, false); reopened == ethdb.KeyValueStore(backing) {
+		t.Error("keyspaces disabled on reopen")
+	}
+	// Populated databases without keyspaces are rejected
+	plain := newKeyspacedMemoryDatabase()
+	WriteDatabaseVersion(plain, 1)
+	if _, err := NewKeyspacedStore(plain, true); !errors.Is(err, errKeyspacesUnsupported) {
+		t.Errorf("keyspaces enabled on populated database: %v", err)
+	}
+}
+
+// Tests that batches updating the chain markers sync the other keyspaces before
+// writing the default one, so the markers never outlive the data they point to,
+// while other batches aren't synced.
+func TestKeyspacedBatchSync(t *testing.T) {
+	backing := newKeyspacedMemoryDatabase()
+	store, err := NewKeyspacedStore(backing, true)
+	if err != nil {
+		t.Fatalf("failed to create keyspaced store: %v", err)
+	}
+	size := backing.Len()
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+
+	// Batches without chain markers need no sync
+	batch := store.NewBatch()
+	WriteHeader(batch, header)
+	WriteDatabaseVersion(batch, 1)
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if len(backing.syncs) != 0 {
+		t.Fatalf("keyspaces synced without markers: %v", backing.syncs)
+	}
+	batch.Reset()
+	WriteHeader(batch, header)
+	WriteHeadHeaderHash(batch, header.Hash())
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if len(backing.syncs) != 1 || backing.syncs[0] != size+1 {
+		t.Fatalf("keyspace syncs mismatch: have %v, want [%d]", backing.syncs, size+1)
+	}
+	if backing.Len() != size+2 {
+		t.Fatalf("default keyspace size mismatch: have %d, want %d", backing.Len(), size+2)
+	}
+	// Markers written directly sync the keyspaces too
+	WriteHeadBlockHash(store, header.Hash())
+	if len(backing.syncs) != 1+KeyspaceCount {
+		t.Errorf("keyspace syncs mismatch: have %d, want %d", len(backing.syncs), 1+KeyspaceCount)
+	}
+}
+
+// Tests that the path scheme state ID is stored along the trie nodes, and moved
+// there from the default keyspace of databases created before.
+func TestKeyspacedStateID(t *testing.T) {
+	backing := newKeyspacedMemoryDatabase()
+	store, err := NewKeyspacedStore(backing, true)
+	if err != nil {
+		t.Fatalf("failed to create keyspaced store: %v", err)
+	}
+	WritePersistentStateID(store, 1)
+	if has, _ := backing.spaces[KeyspaceTries].Has(persistentStateIDKey); !has {
+		t.Fatal("state ID not stored in the tries keyspace")
+	}
+	// Relocate an ID written into the default keyspace by an earlier version
+	backing.spaces[KeyspaceTries].Delete(persistentStateIDKey)
+	WritePersistentStateID(backing, 2)
+
+	if store, err = NewKeyspacedStore(backing, true); err != nil {
+		t.Fatalf("failed to reopen keyspaced store: %v", err)
+	}
+	if id := ReadPersistentStateID(store); id != 2 {
+		t.Fatalf("state ID mismatch: have %d, want 2", id)
+	}
+	if has, _ := backing.Has(persistentStateIDKey); has {
+		t.Fatal("state ID not moved out of the default keyspace")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// migrationLogInterval is the minimum time between two progress reports of a
+// running migration.
+const migrationLogInterval = 8 * time.Second
+
+// Migration is a change of the database format, applied in place on startup
+// instead of requiring a resync.
+type Migration struct {
+	Name string // Human readable description of the change
+
+	// Run converts the data from the given marker onwards, nil meaning from
+	// the start. The migration calls checkpoint with the position reached as
+	// it progresses; if interrupted, it's restarted from the last checkpoint,
+	// so the work done since must be safe to be repeated.
+	Run func(db ethdb.Database, marker []byte, checkpoint func(marker []byte)) error
+}
+
+// migrations is the ordered list of the format migrations, the schema version
+// of a database being the number of them applied. Migrations are only ever
+// appended, their position identifying them across releases.
+var migrations []Migration
+
+// MigrateDatabase applies the format migrations the database is missing.
+func MigrateDatabase(db ethdb.Database) error {
+	return migrate(db, migrations)
+}
+
+// migrate applies the migrations not yet applied to the database in order,
+// resuming an interrupted one from its last checkpoint. Fresh databases are
+// created in the latest format, so they're marked as migrated outright.
+func migrate(db ethdb.Database, migrations []Migration) error {
+	var (
+		latest  = uint64(len(migrations))
+		version uint64
+	)
+	if stored := ReadSchemaVersion(db); stored != nil {
+		version = *stored
+	} else if ReadHeadHeaderHash(db) == (common.Hash{}) {
+		WriteSchemaVersion(db, latest)
+		return nil
+	}
+	if version > latest {
+		return fmt.Errorf("database schema version is v%d, only v%d is supported", version, latest)
+	}
+	for ; version < latest; version++ {
+		var (
+			migration = migrations[version]
+			start     = time.Now()
+			logged    = time.Now()
+			marker    []byte
+		)
+		if progress := readSchemaMigration(db); progress != nil && progress.Version == version+1 {
+			marker = progress.Marker
+		}
+		log.Info("Migrating database", "version", version+1, "name", migration.Name, "resumed", marker != nil)
+
+		checkpoint := func(marker []byte) {
+			writeSchemaMigration(db, &schemaMigration{Version: version + 1, Marker: marker})
+			if time.Since(logged) > migrationLogInterval {
+				log.Info("Migrating database in progress", "version", version+1, "name", migration.Name, "marker", fmt.Sprintf("%x", marker), "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		if err := migration.Run(db, marker, checkpoint); err != nil {
+			return fmt.Errorf("database migration %d (%s) failed: %w", version+1, migration.Name, err)
+		}
+		batch := db.NewBatch()
+		WriteSchemaVersion(batch, version+1)
+		deleteSchemaMigration(batch)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		log.Info("Migrated database", "version", version+1, "name", migration.Name, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that fresh databases are marked as migrated without running anything.
+func TestMigrateFreshDatabase(t *testing.T) {
+	db := NewMemoryDatabase()
+	migrations := []Migration{{Name: "fail", Run: func(ethdb.Database, []byte, func([]byte)) error {
+		return errors.New("migrated fresh database")
+	}}}
+	if err := migrate(db, migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if version := ReadSchemaVersion(db); version == nil || *version != 1 {
+		t.Fatalf("schema version mismatch: have %v, want 1", version)
+	}
+}
+
+// Tests that migrations are applied in order, resumed from their checkpoint if
+// interrupted, and refused on databases of a newer schema.
+func TestMigrateDatabase(t *testing.T) {
+	db := NewMemoryDatabase()
+	WriteHeadHeaderHash(db, common.Hash{0x1})
+
+	var (
+		applied []string
+		markers [][]byte
+		fail    = true
+	)
+	migrations := []Migration{
+		{Name: "first", Run: func(db ethdb.Database, marker []byte, checkpoint func([]byte)) error {
+			applied = append(applied, "first")
+			return nil
+		}},
+		{Name: "second", Run: func(db ethdb.Database, marker []byte, checkpoint func([]byte)) error {
+			applied = append(applied, "second")
+			markers = append(markers, marker)
+			if marker == nil {
+				checkpoint([]byte{0x1})
+			}
+			if fail {
+				return errors.New("interrupted")
+			}
+			return nil
+		}},
+	}
+	if err := migrate(db, migrations); err == nil {
+		t.Fatal("interrupted migration succeeded")
+	}
+	if version := ReadSchemaVersion(db); version == nil || *version != 1 {
+		t.Fatalf("schema version mismatch: have %v, want 1", version)
+	}
+	fail = false
+	if err := migrate(db, migrations); err != nil {
+		t.Fatalf("failed to resume migration: %v", err)
+	}
+	if want := []string{"first", "second", "second"}; !reflect.DeepEqual(applied, want) {
+		t.Fatalf("applied migrations mismatch: have %v, want %v", applied, want)
+	}
+	if want := [][]byte{nil, {0x1}}; !reflect.DeepEqual(markers, want) {
+		t.Fatalf("resume markers mismatch: have %x, want %x", markers, want)
+	}
+	if version := ReadSchemaVersion(db); version == nil || *version != 2 {
+		t.Fatalf("schema version mismatch: have %v, want 2", version)
+	}
+	if progress := readSchemaMigration(db); progress != nil {
+		t.Fatalf("migration progress left behind: %v", progress)
+	}
+	if err := migrate(db, migrations[:1]); err == nil {
+		t.Fatal("migrated database of a newer schema")
+	}
+}
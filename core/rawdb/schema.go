@@ -43,6 +43,9 @@ var (
 	// headFinalizedBlockKey tracks the latest known finalized block hash.
 	headFinalizedBlockKey = []byte("LastFinalized")
 
+	// headSafeBlockKey tracks the latest known safe block hash.
+	headSafeBlockKey = []byte("LastSafe")
+
 	// persistentStateIDKey tracks the id of latest stored state(for path-based only).
 	persistentStateIDKey = []byte("LastStateID")
 
@@ -73,12 +76,36 @@ var (
 	// skeletonSyncStatusKey tracks the skeleton sync status across restarts.
 	skeletonSyncStatusKey = []byte("SkeletonSyncStatus")
 
+	// filledReceiptRangesKey tracks the receipt segments inserted out of order.
+	filledReceiptRangesKey = []byte("FilledReceiptRanges")
+
+	// sealCheckRangesKey tracks the header ranges imported with seals spot checked.
+	sealCheckRangesKey = []byte("SealCheckRanges")
+
 	// trieJournalKey tracks the in-memory trie node layers across restarts.
 	trieJournalKey = []byte("TrieJournal")
 
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// receiptTailKey tracks the oldest block whose receipts are retained.
+	receiptTailKey = []byte("ReceiptTail")
+
+	// blobSidecarTailKey tracks the oldest block whose blob sidecars are retained.
+	blobSidecarTailKey = []byte("BlobSidecarTail")
+
+	// keyspacesKey flags that the database partitions its data into keyspaces.
+	keyspacesKey = []byte("Keyspaces")
+
+	// statePruningMarkerKey tracks the progress of an interrupted live state pruning.
+	statePruningMarkerKey = []byte("StatePruningMarker")
+
+	// schemaVersionKey tracks the number of format migrations applied to the database.
+	schemaVersionKey = []byte("SchemaVersion")
+
+	// schemaMigrationKey tracks the progress of an interrupted format migration.
+	schemaMigrationKey = []byte("SchemaMigration")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	// This flag is deprecated, it's kept to avoid reporting errors when inspect
 	// database.
@@ -105,10 +132,14 @@ var (
 	headerHashSuffix   = []byte("n") // headerPrefix + num (uint64 big endian) + headerHashSuffix -> hash
 	headerNumberPrefix = []byte("H") // headerNumberPrefix + hash -> num (uint64 big endian)
 
+	skeletonHeaderPrefix = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
+
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	revertReasonsPrefix = []byte("R") // revertReasonsPrefix + num (uint64 big endian) + hash -> revert reasons of failed transactions
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
+	uncleIndexPrefix      = []byte("U") // uncleIndexPrefix + miner + num (uint64 big endian) + hash + index -> uncle reward attribution
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
@@ -144,6 +175,24 @@ var (
 	CliqueSnapshotPrefix = []byte("clique-")
 	ParliaSnapshotPrefix = []byte("parlia-")
 
+	addressFilterPrefix = []byte("address-filter-") // addressFilterPrefix + hash -> filtered addresses read at the block
+	totalSupplyPrefix   = []byte("supply-")         // totalSupplyPrefix + num (uint64 big endian) + hash -> total native supply
+	feeTotalsPrefix     = []byte("fees-")           // feeTotalsPrefix + num (uint64 big endian) + hash -> cumulative burnt and blob fees
+
+	contractChangesPrefix = []byte("contract-changes-") // contractChangesPrefix + num (uint64 big endian) + hash -> contract storage and code changes of the block
+	contractStatsPrefix   = []byte("contract-stats-")   // contractStatsPrefix + address -> accumulated contract storage and code statistics
+	contractAppliedPrefix = []byte("contract-applied-") // contractAppliedPrefix + num (uint64 big endian) + hash -> flag that the block's changes are in the statistics
+
+	husksPrefix = []byte("husks-") // husksPrefix + num (uint64 big endian) + hash -> contracts left without balance by a SELFDESTRUCT in the block
+
+	blockAccessListPrefix = []byte("access-list-") // blockAccessListPrefix + num (uint64 big endian) + hash -> accounts and storage slots accessed by the block
+
+	equivocationPrefix = []byte("equivocation-") // equivocationPrefix + num (uint64 big endian) + signer -> evidence of two headers sealed by the signer at the height
+
+	addressActivityPrefix = []byte("address-activity-") // addressActivityPrefix + address + chunk (uint64 big endian) -> bitmap of the canonical blocks the address was active in
+
+	transitionStatePrefix = []byte("transition-") // transitionStatePrefix + state root -> progress of the verkle transition at the state
+
 	BlockBlobSidecarsPrefix = []byte("blobs")
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
@@ -165,6 +214,11 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// skeletonHeaderKey = skeletonHeaderPrefix + num (uint64 big endian)
+func skeletonHeaderKey(number uint64) []byte {
+	return append(skeletonHeaderPrefix, encodeBlockNumber(number)...)
+}
+
 // headerKeyPrefix = headerPrefix + num (uint64 big endian)
 func headerKeyPrefix(number uint64) []byte {
 	return append(headerPrefix, encodeBlockNumber(number)...)
@@ -200,6 +254,56 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// revertReasonsKey = revertReasonsPrefix + num (uint64 big endian) + hash
+func revertReasonsKey(number uint64, hash common.Hash) []byte {
+	return append(append(revertReasonsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// addressFilterKey = addressFilterPrefix + hash
+func addressFilterKey(hash common.Hash) []byte {
+	return append(addressFilterPrefix, hash.Bytes()...)
+}
+
+// totalSupplyKey = totalSupplyPrefix + num (uint64 big endian) + hash
+func totalSupplyKey(number uint64, hash common.Hash) []byte {
+	return append(append(totalSupplyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// feeTotalsKey = feeTotalsPrefix + num (uint64 big endian) + hash
+func feeTotalsKey(number uint64, hash common.Hash) []byte {
+	return append(append(feeTotalsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// contractChangesKey = contractChangesPrefix + num (uint64 big endian) + hash
+func contractChangesKey(number uint64, hash common.Hash) []byte {
+	return append(append(contractChangesPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// contractStatsKey = contractStatsPrefix + address
+func contractStatsKey(addr common.Address) []byte {
+	return append(contractStatsPrefix, addr.Bytes()...)
+}
+
+// contractAppliedKey = contractAppliedPrefix + num (uint64 big endian) + hash
+func contractAppliedKey(number uint64, hash common.Hash) []byte {
+	return append(append(contractAppliedPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// husksKey = husksPrefix + num (uint64 big endian) + hash
+func husksKey(number uint64, hash common.Hash) []byte {
+	return append(append(husksPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// blockAccessListKey = blockAccessListPrefix + num (uint64 big endian) + hash
+func blockAccessListKey(number uint64, hash common.Hash) []byte {
+	return append(append(blockAccessListPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// equivocationKey = equivocationPrefix + num (uint64 big endian) + signer
+func equivocationKey(number uint64, signer common.Address) []byte {
+	return append(append(equivocationPrefix, encodeBlockNumber(number)...), signer.Bytes()...)
+}
+
 // blockBlobSidecarsKey = BlockBlobSidecarsPrefix + blockNumber (uint64 big endian) + blockHash
 func blockBlobSidecarsKey(number uint64, hash common.Hash) []byte {
 	return append(append(BlockBlobSidecarsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
@@ -210,6 +314,29 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// transitionStateKey = transitionStatePrefix + state root
+func transitionStateKey(root common.Hash) []byte {
+	return append(transitionStatePrefix, root.Bytes()...)
+}
+
+// addressActivityKey = addressActivityPrefix + address + chunk (uint64 big endian)
+func addressActivityKey(addr common.Address, chunk uint64) []byte {
+	key := make([]byte, 0, len(addressActivityPrefix)+common.AddressLength+8)
+	key = append(key, addressActivityPrefix...)
+	key = append(key, addr.Bytes()...)
+	return append(key, encodeBlockNumber(chunk)...)
+}
+
+// uncleIndexKey = uncleIndexPrefix + miner + num (uint64 big endian) + hash + index (uint8)
+func uncleIndexKey(miner common.Address, number uint64, hash common.Hash, index int) []byte {
+	key := make([]byte, 0, len(uncleIndexPrefix)+common.AddressLength+8+common.HashLength+1)
+	key = append(key, uncleIndexPrefix...)
+	key = append(key, miner.Bytes()...)
+	key = append(key, encodeBlockNumber(number)...)
+	key = append(key, hash.Bytes()...)
+	return append(key, byte(index))
+}
+
 // accountSnapshotKey = SnapshotAccountPrefix + hash
 func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
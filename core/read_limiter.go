@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/time/rate"
+)
+
+// defaultReadLimitCallers is the default number of callers whose limits are
+// tracked, the least recently seen ones are forgotten beyond.
+const defaultReadLimitCallers = 1024
+
+// ErrReadLimited is returned if an expensive chain read exceeds the allowance
+// of the caller.
+var ErrReadLimited = errors.New("chain read rate limited")
+
+var readLimitRejectMeter = metrics.NewRegisteredMeter("chain/readlimit/rejected", nil)
+
+// CallerIdentity extracts the identity of the caller of a chain read from the
+// context of the request, e.g. the remote address of an RPC client.
+type CallerIdentity func(ctx context.Context) string
+
+// ReadLimitConfig configures the limits of expensive chain reads. Every caller
+// is allowed to spend Rate cost units per second, with bursts up to Burst.
+type ReadLimitConfig struct {
+	Rate     float64        // Cost units replenished per second for every caller
+	Burst    int            // Maximum cost units spent at once by a caller
+	Identity CallerIdentity // Extracts the caller from the context, all callers share a limit if nil
+	Callers  int            // Maximum number of callers tracked
+
+	AncientReceiptCost int // Cost of reading the receipts of a frozen block
+	DeepStateCost      int // Cost of opening a state not covered by the snapshot
+}
+
+// readLimiter is a per-caller token bucket for expensive chain reads.
+type readLimiter struct {
+	config  ReadLimitConfig
+	callers *lru.Cache[string, *rate.Limiter]
+}
+
+// EnableReadLimiter returns a BlockChainOption which limits the rate of the
+// expensive reads served through the context aware getters, so that serving
+// public RPC doesn't stall block imports.
+func EnableReadLimiter(config ReadLimitConfig) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if config.Rate <= 0 || config.Burst <= 0 {
+			return nil, fmt.Errorf("invalid read limit: rate %v, burst %d", config.Rate, config.Burst)
+		}
+		if config.Callers <= 0 {
+			config.Callers = defaultReadLimitCallers
+		}
+		if config.AncientReceiptCost <= 0 {
+			config.AncientReceiptCost = 1
+		}
+		if config.DeepStateCost <= 0 {
+			config.DeepStateCost = 1
+		}
+		bc.readLimiter = &readLimiter{
+			config:  config,
+			callers: lru.NewCache[string, *rate.Limiter](config.Callers),
+		}
+		return bc, nil
+	}
+}
+
+// allow charges the given cost to the caller of the request, returning an
+// error if its allowance is exhausted.
+func (l *readLimiter) allow(ctx context.Context, cost int) error {
+	var caller string
+	if l.config.Identity != nil {
+		caller = l.config.Identity(ctx)
+	}
+	limiter, ok := l.callers.Get(caller)
+	if !ok {
+		// Racing callers might both create a limiter, losing one allowance at
+		// most, which is not worth serializing all reads for
+		limiter = rate.NewLimiter(rate.Limit(l.config.Rate), l.config.Burst)
+		l.callers.Add(caller, limiter)
+	}
+	if !limiter.AllowN(time.Now(), cost) {
+		readLimitRejectMeter.Mark(1)
+		return fmt.Errorf("%w: caller %q", ErrReadLimited, caller)
+	}
+	return nil
+}
+
+// GetReceiptsByHashContext is similar to GetReceiptsByHash, but charges the
+// read to the caller of the request if the receipts need to be loaded from the
+// freezer.
+func (bc *BlockChain) GetReceiptsByHashContext(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if bc.readLimiter != nil {
+		if _, ok := bc.receiptsCache.Get(hash); !ok {
+			if number := rawdb.ReadHeaderNumber(bc.db, hash); number != nil && bc.isFrozen(*number) {
+				if err := bc.readLimiter.allow(ctx, bc.readLimiter.config.AncientReceiptCost); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return bc.GetReceiptsByHash(hash), nil
+}
+
+// StateAtContext is similar to StateAt, but charges the read to the caller of
+// the request if the state is not covered by the snapshot, as every access to
+// it needs to resolve the trie.
+func (bc *BlockChain) StateAtContext(ctx context.Context, root common.Hash) (*state.StateDB, error) {
+	if bc.readLimiter != nil && (bc.snaps == nil || bc.snaps.Snapshot(root) == nil) {
+		if err := bc.readLimiter.allow(ctx, bc.readLimiter.config.DeepStateCost); err != nil {
+			return nil, err
+		}
+	}
+	return bc.StateAt(root)
+}
+
+// isFrozen reports whether the block with the given number was moved into the
+// ancient store.
+func (bc *BlockChain) isFrozen(number uint64) bool {
+	frozen, err := bc.db.Ancients()
+	return err == nil && number < frozen
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type readLimitCallerKey struct{}
+
+// Tests that expensive reads are limited per caller, leaving cheap ones alone.
+func TestReadLimiter(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+		config  = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		limits  = ReadLimitConfig{
+			Rate:  0.001,
+			Burst: 2,
+			Identity: func(ctx context.Context) string {
+				caller, _ := ctx.Value(readLimitCallerKey{}).(string)
+				return caller
+			},
+		}
+	)
+	// Without snapshots every state access resolves the trie
+	config.SnapshotLimit = 0
+
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2, nil)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, engine, vm.Config{}, nil, nil, EnableReadLimiter(limits))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	var (
+		alice = context.WithValue(context.Background(), readLimitCallerKey{}, "alice")
+		bob   = context.WithValue(context.Background(), readLimitCallerKey{}, "bob")
+		root  = blocks[1].Root()
+	)
+	for i := 0; i < limits.Burst; i++ {
+		if _, err := chain.StateAtContext(alice, root); err != nil {
+			t.Fatalf("read %d: failed to open state: %v", i, err)
+		}
+	}
+	if _, err := chain.StateAtContext(alice, root); !errors.Is(err, ErrReadLimited) {
+		t.Fatalf("read beyond burst allowed: %v", err)
+	}
+	if _, err := chain.StateAtContext(bob, root); err != nil {
+		t.Fatalf("caller limited by another one: %v", err)
+	}
+	// Receipts of blocks not yet frozen are cheap and never limited
+	if _, err := chain.GetReceiptsByHashContext(alice, blocks[1].Hash()); err != nil {
+		t.Fatalf("cheap read limited: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// logsCacheLimit is the number of blocks whose logs are cached when retrieved
+// without their receipts.
+const logsCacheLimit = 256
+
+var (
+	receiptsCacheHitMeter  = metrics.NewRegisteredMeter("chain/receipts/cache/hit", nil)
+	receiptsCacheMissMeter = metrics.NewRegisteredMeter("chain/receipts/cache/miss", nil)
+	logsCacheHitMeter      = metrics.NewRegisteredMeter("chain/logs/cache/hit", nil)
+	logsCacheMissMeter     = metrics.NewRegisteredMeter("chain/logs/cache/miss", nil)
+)
+
+// GetLogsByHash retrieves the logs of all transactions in a block. If the
+// receipts of the block are cached, the logs are taken from them, otherwise
+// only the logs are decoded from the stored receipts, skipping the rest. Many
+// filters usually target the same new block, so the result is cached.
+//
+// The returned logs are copies which the caller is free to modify, but their
+// fields not stored in the database are only derived if the receipts were
+// cached. Nil is returned if the receipts of the block are not found.
+func (bc *BlockChain) GetLogsByHash(hash common.Hash, number uint64) [][]*types.Log {
+	if receipts, ok := bc.receiptsCache.Get(hash); ok {
+		receiptsCacheHitMeter.Mark(1)
+		logs := make([][]*types.Log, len(receipts))
+		for i, receipt := range receipts {
+			logs[i] = copyLogs(receipt.Logs)
+		}
+		return logs
+	}
+	logs, ok := bc.logsCache.Get(hash)
+	if ok {
+		logsCacheHitMeter.Mark(1)
+	} else {
+		logsCacheMissMeter.Mark(1)
+		if logs = rawdb.ReadLogs(bc.db, hash, number); logs == nil {
+			return nil
+		}
+		bc.logsCache.Add(hash, logs)
+	}
+	copied := make([][]*types.Log, len(logs))
+	for i, txLogs := range logs {
+		copied[i] = copyLogs(txLogs)
+	}
+	return copied
+}
+
+// copyLogs returns shallow copies of the given logs, the data and topics of
+// the logs are never modified so they may be shared.
+func copyLogs(logs []*types.Log) []*types.Log {
+	copied := make([]*types.Log, len(logs))
+	for i, log := range logs {
+		cpy := *log
+		copied[i] = &cpy
+	}
+	return copied
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that logs are served from the cached receipts or decoded on their own,
+// and that callers can't corrupt the cached copies.
+func TestGetLogsByHash(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		emitter = common.HexToAddress("0xe1")
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// PUSH1 0, PUSH1 0, LOG0, STOP
+				emitter: {Code: []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.LOG0), byte(vm.STOP)}},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), emitter, new(big.Int), 100000, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[0]
+
+	// Decode the logs alone if the receipts are not cached
+	chain.receiptsCache.Purge()
+	logs := chain.GetLogsByHash(block.Hash(), block.NumberU64())
+	if len(logs) != 1 || len(logs[0]) != 1 || logs[0][0].Address != emitter {
+		t.Fatalf("decoded logs mismatch: %v", logs)
+	}
+	if !chain.logsCache.Contains(block.Hash()) {
+		t.Fatal("decoded logs not cached")
+	}
+	logs[0][0].Index = 99
+	if logs = chain.GetLogsByHash(block.Hash(), block.NumberU64()); logs[0][0].Index != 0 {
+		t.Fatal("cached logs modified through a returned copy")
+	}
+	// Share the derived logs of cached receipts
+	if receipts := chain.GetReceiptsByHash(block.Hash()); len(receipts) != 1 {
+		t.Fatalf("receipt count mismatch: have %d, want 1", len(receipts))
+	}
+	logs = chain.GetLogsByHash(block.Hash(), block.NumberU64())
+	if len(logs) != 1 || len(logs[0]) != 1 || logs[0][0].BlockHash != block.Hash() {
+		t.Fatalf("derived logs mismatch: %v", logs)
+	}
+	if logs := chain.GetLogsByHash(common.Hash{0x01}, 1); logs != nil {
+		t.Fatalf("logs returned for unknown block: %v", logs)
+	}
+}
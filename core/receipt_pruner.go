@@ -0,0 +1,128 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// receiptPruneBatch is the maximum number of blocks pruned in one step, so
+// that shutdown isn't delayed by a large backlog.
+const receiptPruneBatch = 10000
+
+var receiptPruneMeter = metrics.NewRegisteredMeter("chain/receipts/pruned", nil)
+
+// receiptPruneLoop deletes the receipts and log index of the blocks falling
+// out of the retention window whenever the head advances.
+func (bc *BlockChain) receiptPruneLoop(quit <-chan struct{}) {
+	heads := make(chan ChainHeadEvent, 16)
+	sub := bc.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	bc.pruneReceipts(bc.CurrentBlock().Number.Uint64(), quit)
+	for {
+		select {
+		case ev := <-heads:
+			// Only act on the latest head if several are queued up
+			head := ev.Header
+			for len(heads) > 0 {
+				head = (<-heads).Header
+			}
+			bc.pruneReceipts(head.Number.Uint64(), quit)
+		case <-sub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// pruneReceipts deletes the receipts and log index entries of the blocks older
+// than the retention window below the given head, advancing the receipt tail.
+//
+// Receipts of blocks not yet moved into the ancient store are left untouched
+// if one is present, since the freezer still needs them. They are pruned from
+// the freezer after being frozen.
+func (bc *BlockChain) pruneReceipts(head uint64, quit <-chan struct{}) {
+	retention := bc.cacheConfig.ReceiptRetention
+	if retention == 0 || head < retention {
+		return
+	}
+	limit := head - retention + 1
+
+	var tail uint64
+	if stored := rawdb.ReadReceiptTail(bc.db); stored != nil {
+		tail = *stored
+	}
+	frozen, err := bc.db.Ancients()
+	freezer := err == nil
+	if freezer && limit > frozen {
+		limit = frozen
+	}
+	for tail < limit {
+		next := min(tail+receiptPruneBatch, limit)
+		if freezer {
+			if _, err := bc.db.TruncateTableTail(rawdb.ChainFreezerReceiptTable, next); err != nil {
+				log.Error("Failed to prune ancient receipts", "tail", tail, "limit", next, "err", err)
+				return
+			}
+		} else {
+			batch := bc.db.NewBatch()
+			for number := tail; number < next; number++ {
+				rawdb.DeleteReceipts(batch, rawdb.ReadCanonicalHash(bc.db, number), number)
+				if batch.ValueSize() > ethdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						log.Crit("Failed to prune receipts", "err", err)
+					}
+					batch.Reset()
+				}
+			}
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to prune receipts", "err", err)
+			}
+		}
+		bc.pruneLogIndex(tail, next)
+		rawdb.WriteReceiptTail(bc.db, next)
+
+		receiptPruneMeter.Mark(int64(next - tail))
+		log.Debug("Pruned receipts", "from", tail, "to", next)
+		tail = next
+
+		select {
+		case <-quit:
+			return
+		default:
+		}
+	}
+}
+
+// pruneLogIndex deletes the bloombits sections which end up entirely below the
+// receipt tail when it is moved from the old to the new position.
+func (bc *BlockChain) pruneLogIndex(oldTail, newTail uint64) {
+	from, to := oldTail/params.BloomBitsBlocks, newTail/params.BloomBitsBlocks
+	if from >= to {
+		return
+	}
+	for bit := uint(0); bit < types.BloomBitLength; bit++ {
+		rawdb.DeleteBloombits(bc.db, bit, from, to)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that receipts beyond the retention window are pruned from both the
+// key-value store and the ancient store, leaving the recent ones intact.
+func TestReceiptPruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	check := func(db ethdb.Database, tail uint64) {
+		t.Helper()
+
+		if stored := rawdb.ReadReceiptTail(db); stored == nil || *stored != tail {
+			t.Fatalf("receipt tail mismatch: have %v, want %d", stored, tail)
+		}
+		for _, block := range blocks {
+			have := rawdb.ReadRawReceipts(db, block.Hash(), block.NumberU64()) != nil
+			if want := block.NumberU64() >= tail; have != want {
+				t.Errorf("block %d: receipts retained %v, want %v", block.NumberU64(), have, want)
+			}
+		}
+	}
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.ReceiptRetention = 3
+
+	// Prune receipts of a full chain from the key-value store
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.pruneReceipts(8, nil)
+	check(db, 6)
+	chain.Stop()
+
+	// Prune receipts of a snap synced chain from the ancient store
+	ancientDb, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false, false, false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer ancientDb.Close()
+
+	chain, err = NewBlockChain(ancientDb, config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if _, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	if _, err := chain.InsertReceiptChain(blocks, receipts, 4); err != nil {
+		t.Fatalf("failed to insert receipts: %v", err)
+	}
+	frozen, _ := ancientDb.Ancients()
+	if frozen == 0 || frozen >= 6 {
+		t.Fatalf("unexpected ancient count %d", frozen)
+	}
+	// Unfrozen receipts are still needed by the freezer, leave them
+	chain.pruneReceipts(8, nil)
+	check(ancientDb, frozen)
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errNonCanonicalSegment is returned if a receipt segment contains a block
+// which is not part of the canonical header chain.
+var errNonCanonicalSegment = errors.New("receipt segment not canonical")
+
+// InsertReceiptSegment completes an arbitrary, contiguous segment of the
+// canonical header chain with transaction and receipt data. Contrary to
+// InsertReceiptChain, the segment doesn't need to connect to the current snap
+// sync head, which allows filling the history from multiple positions in
+// parallel. The filled ranges are tracked persistently and stitched onto the
+// snap sync head as soon as the gaps between them are closed.
+//
+// Segments are always written into the key-value store, since the freezer
+// only supports append-only insertion.
+func (bc *BlockChain) InsertReceiptSegment(blockChain types.Blocks, receiptChain []types.Receipts) (int, error) {
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	if len(blockChain) == 0 {
+		return 0, nil
+	}
+	if len(blockChain) != len(receiptChain) {
+		return 0, fmt.Errorf("receipt segment length mismatch: %d blocks, %d receipts", len(blockChain), len(receiptChain))
+	}
+	for i, block := range blockChain {
+		if i != 0 {
+			prev := blockChain[i-1]
+			if block.NumberU64() != prev.NumberU64()+1 || block.ParentHash() != prev.Hash() {
+				return i, fmt.Errorf("non contiguous insert: item %d is #%d [%x..], item %d is #%d [%x..] (parent [%x..])",
+					i-1, prev.NumberU64(), prev.Hash().Bytes()[:4],
+					i, block.NumberU64(), block.Hash().Bytes()[:4], block.ParentHash().Bytes()[:4])
+			}
+		}
+		if rawdb.ReadCanonicalHash(bc.db, block.NumberU64()) != block.Hash() {
+			return i, fmt.Errorf("%w: #%d [%x..]", errNonCanonicalSegment, block.NumberU64(), block.Hash().Bytes()[:4])
+		}
+		for txIndex, tx := range block.Transactions() {
+			if tx.Type() == types.BlobTxType && tx.BlobTxSidecar() != nil {
+				return i, fmt.Errorf("block #%d contains unexpected blob sidecar in tx at index %d", block.NumberU64(), txIndex)
+			}
+		}
+	}
+	batch := bc.db.NewBatch()
+	for i, block := range blockChain {
+		if bc.insertStopped() {
+			return i, errInsertionInterrupted
+		}
+		if bc.HasBlock(block.Hash(), block.NumberU64()) && rawdb.HasReceipts(bc.db, block.Hash(), block.NumberU64()) {
+			continue
+		}
+		rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
+		rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receiptChain[i])
+		if bc.chainConfig.IsCancun(block.Number(), block.Time()) {
+			rawdb.WriteBlobSidecars(batch, block.Hash(), block.NumberU64(), block.Sidecars())
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return i, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	if !bc.chainmu.TryLock() {
+		return 0, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	ranges := mergeReceiptRange(rawdb.ReadFilledReceiptRanges(bc.db), rawdb.ReceiptRange{
+		First: blockChain[0].NumberU64(),
+		Last:  blockChain[len(blockChain)-1].NumberU64(),
+	})
+	rawdb.WriteFilledReceiptRanges(bc.db, ranges)
+	bc.stitchReceiptRanges()
+
+	log.Debug("Imported receipt segment", "first", blockChain[0].NumberU64(), "last", blockChain[len(blockChain)-1].NumberU64(), "ranges", len(ranges))
+	return 0, nil
+}
+
+// FilledReceiptRanges returns the receipt segments which were inserted out of
+// order and are not yet connected to the snap sync head.
+func (bc *BlockChain) FilledReceiptRanges() []rawdb.ReceiptRange {
+	return rawdb.ReadFilledReceiptRanges(bc.db)
+}
+
+// stitchReceiptRanges advances the snap sync head over all the filled receipt
+// ranges connecting to it, dropping the ranges which became redundant.
+//
+// This function expects the chain mutex to be held.
+func (bc *BlockChain) stitchReceiptRanges() {
+	ranges := rawdb.ReadFilledReceiptRanges(bc.db)
+	if len(ranges) == 0 {
+		return
+	}
+	var (
+		head = bc.CurrentSnapBlock().Number.Uint64()
+		keep = ranges[:0]
+	)
+	for _, r := range ranges {
+		switch {
+		case r.Last <= head:
+			// Range was overtaken by the snap sync head, drop it
+		case r.First <= head+1:
+			header := bc.GetHeaderByNumber(r.Last)
+			if header == nil || header.Number.Cmp(bc.CurrentHeader().Number) > 0 {
+				keep = append(keep, r)
+				continue
+			}
+			rawdb.WriteHeadFastBlockHash(bc.db, header.Hash())
+			bc.currentSnapBlock.Store(header)
+			headFastBlockGauge.Update(int64(r.Last))
+
+			log.Info("Stitched receipt segment onto snap head", "first", r.First, "last", r.Last, "prev", head)
+			head = r.Last
+		default:
+			keep = append(keep, r)
+		}
+	}
+	rawdb.WriteFilledReceiptRanges(bc.db, keep)
+}
+
+// mergeReceiptRange inserts a new range into a sorted list of disjoint ranges,
+// coalescing overlapping and adjacent ones.
+func mergeReceiptRange(ranges []rawdb.ReceiptRange, r rawdb.ReceiptRange) []rawdb.ReceiptRange {
+	ranges = append(ranges, r)
+	slices.SortFunc(ranges, func(a, b rawdb.ReceiptRange) int {
+		return cmp.Compare(a.First, b.First)
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.First <= last.Last+1 {
+			last.Last = max(last.Last, r.Last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
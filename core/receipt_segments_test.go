@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that receipt segments can be inserted out of order, are tracked as
+// filled ranges and get stitched onto the snap head once connected.
+func TestInsertReceiptSegments(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, engine, 10, nil)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	insert := func(first, last int) {
+		t.Helper()
+		if n, err := chain.InsertReceiptSegment(blocks[first-1:last], receipts[first-1:last]); err != nil {
+			t.Fatalf("failed to insert segment [%d, %d] at %d: %v", first, last, n, err)
+		}
+	}
+	check := func(head uint64, ranges []rawdb.ReceiptRange) {
+		t.Helper()
+		if have := chain.CurrentSnapBlock().Number.Uint64(); have != head {
+			t.Errorf("snap head mismatch: have %d, want %d", have, head)
+		}
+		if have := chain.FilledReceiptRanges(); !reflect.DeepEqual(have, ranges) {
+			t.Errorf("filled ranges mismatch: have %v, want %v", have, ranges)
+		}
+	}
+	insert(7, 9)
+	check(0, []rawdb.ReceiptRange{{First: 7, Last: 9}})
+
+	insert(3, 4)
+	check(0, []rawdb.ReceiptRange{{First: 3, Last: 4}, {First: 7, Last: 9}})
+
+	insert(5, 6) // Joins the two ranges, but not to the head
+	check(0, []rawdb.ReceiptRange{{First: 3, Last: 9}})
+
+	insert(1, 2) // Connects everything to the head
+	check(9, nil)
+
+	for i, block := range blocks[:9] {
+		if !chain.HasFastBlock(block.Hash(), block.NumberU64()) {
+			t.Errorf("block %d: body or receipts missing", i+1)
+		}
+	}
+	// Segments off the canonical header chain must be rejected
+	_, side, sideReceipts := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		b.SetExtra([]byte("side"))
+	})
+	if _, err := chain.InsertReceiptSegment(side, sideReceipts); !errors.Is(err, errNonCanonicalSegment) {
+		t.Errorf("side segment error mismatch: have %v, want %v", err, errNonCanonicalSegment)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// reorgDumper writes a self-contained post-mortem artifact to disk for every
+// chain reorganisation that drops at least threshold canonical blocks.
+type reorgDumper struct {
+	dir       string // Directory to place the artifacts into
+	threshold uint64 // Minimum number of dropped blocks to trigger a dump
+}
+
+// ReorgArtifact is the on-disk representation of a reorg post-mortem. It
+// contains everything needed to analyse the reorg without access to the
+// database of the node which experienced it.
+type ReorgArtifact struct {
+	Time         time.Time       `json:"time"`
+	CommonNumber uint64          `json:"commonNumber"`
+	CommonHash   common.Hash     `json:"commonHash"`
+	OldTd        *big.Int        `json:"oldTd"`
+	NewTd        *big.Int        `json:"newTd"`
+	OldChain     []*types.Header `json:"oldChain"` // Dropped headers, newest first
+	NewChain     []*types.Header `json:"newChain"` // Added headers, newest first
+	DroppedTxs   []common.Hash   `json:"droppedTxs"`
+	RemovedLogs  []*types.Log    `json:"removedLogs"`
+}
+
+// EnableReorgDumper returns a BlockChainOption which dumps a post-mortem
+// artifact into dir for every reorg dropping at least threshold blocks. A
+// ReorgDumpEvent carrying the artifact path is posted after each dump.
+func EnableReorgDumper(dir string, threshold uint64) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create reorg dump directory: %w", err)
+		}
+		if threshold == 0 {
+			threshold = 1
+		}
+		bc.reorgDumper = &reorgDumper{dir: dir, threshold: threshold}
+		return bc, nil
+	}
+}
+
+// shouldDump reports whether a reorg dropping the given number of blocks
+// needs to be dumped.
+func (d *reorgDumper) shouldDump(dropped int) bool {
+	return d != nil && uint64(dropped) >= d.threshold
+}
+
+// dump serializes the artifact into a new file and returns its path.
+func (d *reorgDumper) dump(artifact *ReorgArtifact) (string, error) {
+	blob, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("reorg-%d-%x-%d.json", artifact.CommonNumber, artifact.CommonHash[:4], artifact.Time.UnixNano())
+	path := filepath.Join(d.dir, name)
+	if err := os.WriteFile(path, blob, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// dumpReorg assembles the post-mortem artifact of a reorg and writes it to
+// disk in the background, announcing the resulting file on success.
+func (bc *BlockChain) dumpReorg(ancestor *types.Header, oldChain, newChain []*types.Header, droppedTxs []common.Hash, removedLogs []*types.Log) {
+	artifact := &ReorgArtifact{
+		Time:         time.Now(),
+		CommonNumber: ancestor.Number.Uint64(),
+		CommonHash:   ancestor.Hash(),
+		OldTd:        bc.GetTd(oldChain[0].Hash(), oldChain[0].Number.Uint64()),
+		NewTd:        bc.GetTd(newChain[0].Hash(), newChain[0].Number.Uint64()),
+		OldChain:     oldChain,
+		NewChain:     newChain,
+		DroppedTxs:   droppedTxs,
+		RemovedLogs:  removedLogs,
+	}
+	bc.tasks.spawn("reorgdump", TaskLow, RestartNever, func(quit <-chan struct{}) {
+		path, err := bc.reorgDumper.dump(artifact)
+		if err != nil {
+			log.Error("Failed to dump reorg artifact", "number", artifact.CommonNumber, "hash", artifact.CommonHash, "err", err)
+			return
+		}
+		log.Info("Dumped reorg artifact", "number", artifact.CommonNumber, "hash", artifact.CommonHash,
+			"drop", len(oldChain), "add", len(newChain), "path", path)
+		// Announce the dump outside of the task, a slow subscriber must not
+		// hold up the blockchain shutdown waiting for the tasks to finish.
+		sent := make(chan struct{})
+		go func() {
+			bc.reorgDumpFeed.Send(ReorgDumpEvent{Path: path, Artifact: artifact})
+			close(sent)
+		}()
+		select {
+		case <-sent:
+		case <-quit:
+		}
+	})
+}
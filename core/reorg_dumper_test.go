@@ -0,0 +1,138 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a reorg dropping enough blocks produces a post-mortem artifact
+// and announces it, while shallower reorgs are ignored.
+func TestReorgDumper(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableReorgDumper(dir, 2))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan ReorgDumpEvent, 1)
+	sub := chain.SubscribeReorgDumpEvent(events)
+	defer sub.Unsubscribe()
+
+	_, easy, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	_, heavy, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	if _, err := chain.InsertChain(heavy); err != nil {
+		t.Fatalf("failed to insert heavy chain: %v", err)
+	}
+	select {
+	case ev := <-events:
+		blob, err := os.ReadFile(ev.Path)
+		if err != nil {
+			t.Fatalf("failed to read artifact: %v", err)
+		}
+		var artifact ReorgArtifact
+		if err := json.Unmarshal(blob, &artifact); err != nil {
+			t.Fatalf("failed to decode artifact: %v", err)
+		}
+		if artifact.CommonNumber != 0 {
+			t.Errorf("common ancestor mismatch: have %d, want 0", artifact.CommonNumber)
+		}
+		if len(artifact.OldChain) != 2 {
+			t.Errorf("dropped header count mismatch: have %d, want 2", len(artifact.OldChain))
+		}
+		if artifact.OldChain[0].Hash() != easy[1].Hash() {
+			t.Errorf("dropped head mismatch: have %x, want %x", artifact.OldChain[0].Hash(), easy[1].Hash())
+		}
+		if artifact.OldTd == nil || artifact.NewTd == nil {
+			t.Errorf("missing total difficulties: old %v, new %v", artifact.OldTd, artifact.NewTd)
+		} else if artifact.OldTd.Cmp(artifact.NewTd) > 0 {
+			t.Errorf("old td %v higher than new td %v", artifact.OldTd, artifact.NewTd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reorg artifact not announced")
+	}
+}
+
+// Tests that a subscriber never draining the reorg dump events does not hold
+// up the shutdown of the blockchain.
+func TestReorgDumperStalledSubscriber(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableReorgDumper(dir, 2))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	sub := chain.SubscribeReorgDumpEvent(make(chan ReorgDumpEvent))
+	defer sub.Unsubscribe()
+
+	_, easy, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	_, heavy, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+		b.OffsetTime(-9) // Higher block difficulty
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	if _, err := chain.InsertChain(heavy); err != nil {
+		t.Fatalf("failed to insert heavy chain: %v", err)
+	}
+	// Wait for the artifact to be written, the announcement is then pending
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("reorg artifact not dumped")
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		chain.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("blockchain shutdown blocked by the reorg dump subscriber")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	reorgDepthHist      = metrics.NewRegisteredHistogram("chain/reorg/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
+	reorgWidthHist      = metrics.NewRegisteredHistogram("chain/reorg/width", nil, metrics.NewExpDecaySample(1028, 0.015))
+	reorgDroppedTxHist  = metrics.NewRegisteredHistogram("chain/reorg/txs/dropped", nil, metrics.NewExpDecaySample(1028, 0.015))
+	reorgReinjectTxHist = metrics.NewRegisteredHistogram("chain/reorg/txs/reinjected", nil, metrics.NewExpDecaySample(1028, 0.015))
+	reorgTimer          = metrics.NewRegisteredTimer("chain/reorg/time", nil)
+)
+
+// ReorgStats summarises a chain reorganisation.
+type ReorgStats struct {
+	CommonNumber  uint64        // Number of the common ancestor
+	CommonHash    common.Hash   // Hash of the common ancestor
+	Depth         int           // Number of canonical blocks dropped
+	Width         int           // Number of blocks added to the canonical chain
+	DroppedTxs    int           // Transactions contained in the dropped blocks
+	ReinjectedTxs int           // Dropped transactions missing from the new chain, returned to the pool
+	Elapsed       time.Duration // Time spent switching the canonical chain
+}
+
+// ReorgHook is a callback invoked after every chain reorganisation. It's run
+// synchronously on the import path, so any slow work should be handed off.
+type ReorgHook func(stats *ReorgStats)
+
+// WithReorgHook returns a BlockChainOption which invokes hook after every chain
+// reorganisation dropping at least minDepth canonical blocks, e.g. to forward
+// deep reorgs to an alerting integration.
+func WithReorgHook(hook ReorgHook, minDepth int) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.reorgHooks = append(bc.reorgHooks, reorgHook{fn: hook, minDepth: minDepth})
+		return bc, nil
+	}
+}
+
+// reorgHook is a registered reorg callback along with its trigger threshold.
+type reorgHook struct {
+	fn       ReorgHook
+	minDepth int
+}
+
+// reportReorg records the metrics of a finished reorg and invokes the hooks.
+func (bc *BlockChain) reportReorg(stats *ReorgStats) {
+	reorgDepthHist.Update(int64(stats.Depth))
+	reorgWidthHist.Update(int64(stats.Width))
+	reorgDroppedTxHist.Update(int64(stats.DroppedTxs))
+	reorgReinjectTxHist.Update(int64(stats.ReinjectedTxs))
+	reorgTimer.Update(stats.Elapsed)
+
+	for _, hook := range bc.reorgHooks {
+		if stats.Depth >= hook.minDepth {
+			hook.fn(stats)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that reorg statistics are reported to the hooks whose depth threshold
+// is reached.
+func TestReorgHook(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+
+		shallow []*ReorgStats
+		deep    []*ReorgStats
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil,
+		WithReorgHook(func(stats *ReorgStats) { shallow = append(shallow, stats) }, 1),
+		WithReorgHook(func(stats *ReorgStats) { deep = append(deep, stats) }, 3),
+	)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	_, easy, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		if i == 1 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	// The heavy chain outweighs the easy one at every height, so it deterministically
+	// takes over at its second block and the third one extends it
+	_, heavy, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+		b.OffsetTime(-9) // Higher block difficulty
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	if _, err := chain.InsertChain(heavy); err != nil {
+		t.Fatalf("failed to insert heavy chain: %v", err)
+	}
+	if len(deep) != 0 {
+		t.Errorf("deep reorg hook invoked for shallow reorg")
+	}
+	if len(shallow) != 1 {
+		t.Fatalf("reorg hook invocation mismatch: have %d, want 1", len(shallow))
+	}
+	stats := shallow[0]
+	if stats.CommonNumber != 0 || stats.CommonHash != chain.Genesis().Hash() {
+		t.Errorf("common ancestor mismatch: have %d %x", stats.CommonNumber, stats.CommonHash)
+	}
+	if stats.Depth != 2 || stats.Width != 2 {
+		t.Errorf("reorg shape mismatch: depth %d, width %d", stats.Depth, stats.Width)
+	}
+	if stats.DroppedTxs != 1 || stats.ReinjectedTxs != 1 {
+		t.Errorf("reorg transaction count mismatch: dropped %d, reinjected %d", stats.DroppedTxs, stats.ReinjectedTxs)
+	}
+}
+
+// Tests that reorgs are announced with the dropped and added blocks and
+// transactions, ordered from the common ancestor upwards.
+func TestReorgEvent(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan ReorgEvent, 1)
+	sub := chain.SubscribeReorgEvent(events)
+	defer sub.Unsubscribe()
+
+	transfer := func(b *BlockGen, to common.Address) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	}
+	_, easy, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		transfer(b, common.Address{0xaa})
+	})
+	// The heavy chain outweighs the easy one at every height, so it deterministically
+	// takes over at its second block and the third one extends it
+	_, heavy, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+		b.OffsetTime(-9) // Higher block difficulty
+		if i == 1 {
+			transfer(b, common.Address{0xbb})
+		}
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("reorg announced for chain extension: %+v", ev)
+	default:
+	}
+	if _, err := chain.InsertChain(heavy); err != nil {
+		t.Fatalf("failed to insert heavy chain: %v", err)
+	}
+	var ev ReorgEvent
+	select {
+	case ev = <-events:
+	default:
+		t.Fatal("reorg not announced")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("reorg announced for chain extension: %+v", ev)
+	default:
+	}
+	if ev.CommonAncestor.Hash() != chain.Genesis().Hash() {
+		t.Errorf("common ancestor mismatch: have %x", ev.CommonAncestor.Hash())
+	}
+	if len(ev.OldChain) != len(easy) || len(ev.NewChain) != 2 {
+		t.Fatalf("reorg shape mismatch: dropped %d, added %d", len(ev.OldChain), len(ev.NewChain))
+	}
+	for i, header := range ev.OldChain {
+		if header.Hash() != easy[i].Hash() {
+			t.Errorf("dropped block %d mismatch: have %x, want %x", i, header.Hash(), easy[i].Hash())
+		}
+	}
+	for i, header := range ev.NewChain {
+		if header.Hash() != heavy[i].Hash() {
+			t.Errorf("added block %d mismatch: have %x, want %x", i, header.Hash(), heavy[i].Hash())
+		}
+	}
+	if len(ev.RemovedTxs) != 2 || ev.RemovedTxs[0].Hash() != easy[0].Transactions()[0].Hash() || ev.RemovedTxs[1].Hash() != easy[1].Transactions()[0].Hash() {
+		t.Errorf("removed transactions mismatch: %v", ev.RemovedTxs)
+	}
+	if len(ev.AddedTxs) != 1 || ev.AddedTxs[0].Hash() != heavy[1].Transactions()[0].Hash() {
+		t.Errorf("added transactions mismatch: %v", ev.AddedTxs)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultRevertReasonLimit is the maximum number of bytes of revert data stored
+// per failed transaction if no explicit limit is configured.
+const DefaultRevertReasonLimit = 1024
+
+// EnableRevertReasons returns a BlockChainOption which persists the revert data
+// of the failed transactions of every processed block alongside its receipts.
+// Revert data exceeding limit bytes is truncated, a non-positive limit selects
+// DefaultRevertReasonLimit. Blocks imported without execution, e.g. via snap
+// sync, have no revert reasons recorded.
+func EnableRevertReasons(limit int) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if limit <= 0 {
+			limit = DefaultRevertReasonLimit
+		}
+		bc.revertReasonLimit = limit
+		return bc, nil
+	}
+}
+
+// GetRevertReason retrieves the stored revert data of the transaction at the
+// given position in the block, or nil if it didn't revert or wasn't recorded.
+func (bc *BlockChain) GetRevertReason(hash common.Hash, number uint64, txIndex uint64) []byte {
+	return rawdb.ReadRevertReason(bc.db, hash, number, txIndex)
+}
+
+// writeRevertReasons stores the revert data of the failed transactions of a
+// processed block, truncated to the configured limit.
+func (bc *BlockChain) writeRevertReasons(block *types.Block, reverts []*rawdb.RevertReason) {
+	if bc.revertReasonLimit == 0 || len(reverts) == 0 {
+		return
+	}
+	for _, revert := range reverts {
+		if len(revert.Reason) > bc.revertReasonLimit {
+			revert.Reason = revert.Reason[:bc.revertReasonLimit]
+		}
+	}
+	rawdb.WriteRevertReasons(bc.db, block.Hash(), block.NumberU64(), reverts)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the revert data of failed transactions is persisted, truncated
+// to the configured limit, when revert reason recording is enabled.
+func TestRevertReasons(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		reverter = common.Address{0xbb}
+		engine   = ethash.NewFaker()
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// Reverts with the 32 byte word 0xaa left padded
+				reverter: {Code: hexutil.MustDecode("0x60aa60005260206000fd")},
+			},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		for _, to := range []common.Address{{0xaa}, reverter} {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(0), 100000, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableRevertReasons(16))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	hash, number := blocks[0].Hash(), blocks[0].NumberU64()
+	if reason := chain.GetRevertReason(hash, number, 0); reason != nil {
+		t.Errorf("successful transaction has revert reason: %x", reason)
+	}
+	if reason := chain.GetRevertReason(hash, number, 1); !bytes.Equal(reason, make([]byte, 16)) {
+		t.Errorf("revert reason mismatch: have %x, want %x", reason, make([]byte, 16))
+	}
+	if reasons := rawdb.ReadRevertReasons(db, hash, number); len(reasons) != 1 {
+		t.Errorf("revert reason count mismatch: have %d, want 1", len(reasons))
+	}
+	// Freezing a block must retain its revert reasons, while rewinding the chain
+	// must drop them
+	frozen := rawdb.NewMemoryDatabase()
+	rawdb.WriteRevertReasons(frozen, hash, number, rawdb.ReadRevertReasons(db, hash, number))
+	rawdb.DeleteBlockWithoutNumber(frozen, hash, number)
+	if reasons := rawdb.ReadRevertReasons(frozen, hash, number); len(reasons) != 1 {
+		t.Errorf("revert reasons not retained on freezing: have %d, want 1", len(reasons))
+	}
+	if err := chain.SetHead(0); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if reasons := rawdb.ReadRevertReasons(db, hash, number); reasons != nil {
+		t.Errorf("revert reasons not deleted on rewind: %v", reasons)
+	}
+}
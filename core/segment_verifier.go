@@ -0,0 +1,240 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// errNoAttestationKey is returned if a segment is verified without a key
+	// to sign the attestation with.
+	errNoAttestationKey = errors.New("segment attestation disabled")
+
+	// errInvalidSegment is returned if the verified segment is malformed.
+	errInvalidSegment = errors.New("invalid segment")
+
+	// errSegmentStateUnavailable is returned if a segment passing all the other
+	// checks can't be executed, as the state of its parent is not available.
+	errSegmentStateUnavailable = errors.New("segment parent state unavailable")
+)
+
+// SegmentAttestation is the signed outcome of the verification of a chain
+// segment.
+type SegmentAttestation struct {
+	ChainID   *big.Int
+	First     uint64      // Number of the first block in the segment
+	FirstHash common.Hash // Hash of the first block in the segment
+	Last      uint64      // Number of the last block in the segment
+	LastHash  common.Hash // Hash of the last block in the segment
+	Executed  bool        // Whether the state transitions were re-executed
+	Valid     bool        // Whether the segment passed the verification
+	Reason    string      // Reason of a failed verification
+	Time      uint64      // Unix timestamp of the verification
+	Signature []byte      // Signature of the attester over SigHash
+}
+
+// SigHash returns the hash of the attestation signed by the attester.
+func (a *SegmentAttestation) SigHash() common.Hash {
+	blob, _ := rlp.EncodeToBytes([]interface{}{
+		a.ChainID, a.First, a.FirstHash, a.Last, a.LastHash, a.Executed, a.Valid, a.Reason, a.Time,
+	})
+	return crypto.Keccak256Hash(blob)
+}
+
+// Attester recovers the address of the account which signed the attestation.
+func (a *SegmentAttestation) Attester() (common.Address, error) {
+	pubkey, err := crypto.SigToPub(a.SigHash().Bytes(), a.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// EnableSegmentAttestation returns a BlockChainOption which allows the chain to
+// verify segments on behalf of external auditors, signing the results with
+// the given key.
+func EnableSegmentAttestation(key *ecdsa.PrivateKey) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.attestKey = key
+		return bc, nil
+	}
+}
+
+// VerifySegment fully validates a contiguous chain segment against the rules of
+// the consensus engine, without writing anything into the database, and returns
+// a signed attestation of the result. The parent of the segment must be known
+// locally, it doesn't need to be canonical.
+//
+// Besides the headers, the bodies and the receipts are checked against the
+// roots in the headers. The blocks are then re-executed on top of the state of
+// the parent and the resulting states and receipts checked too. The headers of
+// the segment are served to the execution, so the blocks can access each other
+// through the BLOCKHASH opcode.
+//
+// An error is returned if the segment is malformed, or if it can't be fully
+// verified as the state of its parent is not available. A segment failing the
+// verification results in an attestation of it.
+func (bc *BlockChain) VerifySegment(headers []*types.Header, bodies []*types.Body, receipts []types.Receipts) (*SegmentAttestation, error) {
+	if bc.attestKey == nil {
+		return nil, errNoAttestationKey
+	}
+	if len(headers) == 0 || len(bodies) != len(headers) || len(receipts) != len(headers) {
+		return nil, fmt.Errorf("%w: %d headers, %d bodies, %d receipt sets", errInvalidSegment, len(headers), len(bodies), len(receipts))
+	}
+	for i := range headers {
+		if headers[i] == nil || headers[i].Number == nil || bodies[i] == nil {
+			return nil, fmt.Errorf("%w: missing header or body at index %d", errInvalidSegment, i)
+		}
+	}
+	if headers[0].Number.Sign() == 0 {
+		return nil, fmt.Errorf("%w: segment starting at genesis", errInvalidSegment)
+	}
+	for i := 1; i < len(headers); i++ {
+		if headers[i].Number.Uint64() != headers[i-1].Number.Uint64()+1 || headers[i].ParentHash != headers[i-1].Hash() {
+			return nil, fmt.Errorf("%w: non contiguous headers #%d and #%d", errInvalidSegment, headers[i-1].Number, headers[i].Number)
+		}
+	}
+	var (
+		first       = headers[0]
+		last        = headers[len(headers)-1]
+		attestation = &SegmentAttestation{
+			ChainID:   bc.chainConfig.ChainID,
+			First:     first.Number.Uint64(),
+			FirstHash: first.Hash(),
+			Last:      last.Number.Uint64(),
+			LastHash:  last.Hash(),
+			Time:      uint64(time.Now().Unix()),
+		}
+	)
+	executed, err := bc.verifySegment(headers, bodies, receipts)
+	if errors.Is(err, errSegmentStateUnavailable) {
+		return nil, err
+	}
+	attestation.Executed = executed
+	attestation.Valid = err == nil
+	if err != nil {
+		attestation.Reason = err.Error()
+	}
+	sig, err := crypto.Sign(attestation.SigHash().Bytes(), bc.attestKey)
+	if err != nil {
+		return nil, err
+	}
+	attestation.Signature = sig
+	return attestation, nil
+}
+
+// verifySegment checks a contiguous segment, returning whether its blocks were
+// executed and the first violation found.
+func (bc *BlockChain) verifySegment(headers []*types.Header, bodies []*types.Body, receipts []types.Receipts) (bool, error) {
+	parent := bc.GetHeader(headers[0].ParentHash, headers[0].Number.Uint64()-1)
+	if parent == nil {
+		return false, consensus.ErrUnknownAncestor
+	}
+	// Check the headers against the consensus rules
+	abort, results := bc.engine.VerifyHeaders(bc, headers)
+	abort, results = bc.hc.validateExtra(headers, abort, results)
+	defer close(abort)
+
+	for i := range headers {
+		if err := <-results; err != nil {
+			return false, fmt.Errorf("header #%d: %w", headers[i].Number, err)
+		}
+	}
+	// Check the bodies and receipts against the roots in the headers
+	blocks := make([]*types.Block, len(headers))
+	for i, header := range headers {
+		body := bodies[i]
+		if hash := types.DeriveSha(types.Transactions(body.Transactions), trie.NewRootHasher(bc.chainConfig)); hash != header.TxHash {
+			return false, fmt.Errorf("block #%d: transaction root hash mismatch (header value %x, calculated %x)", header.Number, header.TxHash, hash)
+		}
+		if hash := types.CalcUncleHash(body.Uncles); hash != header.UncleHash {
+			return false, fmt.Errorf("block #%d: uncle root hash mismatch (header value %x, calculated %x)", header.Number, header.UncleHash, hash)
+		}
+		if header.WithdrawalsHash != nil {
+			if body.Withdrawals == nil {
+				return false, fmt.Errorf("block #%d: missing withdrawals", header.Number)
+			}
+			if hash := types.DeriveSha(types.Withdrawals(body.Withdrawals), trie.NewRootHasher(bc.chainConfig)); hash != *header.WithdrawalsHash {
+				return false, fmt.Errorf("block #%d: withdrawals root hash mismatch (header value %x, calculated %x)", header.Number, *header.WithdrawalsHash, hash)
+			}
+		}
+		if len(receipts[i]) != len(body.Transactions) {
+			return false, fmt.Errorf("block #%d: %d receipts for %d transactions", header.Number, len(receipts[i]), len(body.Transactions))
+		}
+		if hash := types.DeriveSha(receipts[i], trie.NewRootHasher(bc.chainConfig)); hash != header.ReceiptHash {
+			return false, fmt.Errorf("block #%d: receipt root hash mismatch (header value %x, calculated %x)", header.Number, header.ReceiptHash, hash)
+		}
+		if bloom := types.CreateBloom(receipts[i]); bloom != header.Bloom {
+			return false, fmt.Errorf("block #%d: bloom mismatch", header.Number)
+		}
+		blocks[i] = types.NewBlockWithHeader(header).WithBody(*body)
+	}
+	// Re-execute the state transitions on top of the parent state. The blocks
+	// are processed against a header chain which also serves the headers of the
+	// segment, since they are not in the database.
+	statedb, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errSegmentStateUnavailable, err)
+	}
+	processor := NewStateProcessor(bc.chainConfig, bc.newSegmentHeaderChain(headers))
+	for _, block := range blocks {
+		res, err := processor.Process(block, statedb, vm.Config{})
+		if err != nil {
+			return true, fmt.Errorf("block #%d: %w", block.Number(), err)
+		}
+		if err := bc.validator.ValidateState(block, statedb, res, false); err != nil {
+			return true, fmt.Errorf("block #%d: %w", block.Number(), err)
+		}
+	}
+	return true, nil
+}
+
+// newSegmentHeaderChain creates an idle header chain on top of the database of
+// the chain, which serves the headers of the given segment too.
+func (bc *BlockChain) newSegmentHeaderChain(headers []*types.Header) *HeaderChain {
+	hc := &HeaderChain{
+		config:        bc.chainConfig,
+		chainDb:       bc.db,
+		headerCache:   lru.NewCache[common.Hash, *types.Header](headerCacheLimit + len(headers)),
+		tdCache:       lru.NewCache[common.Hash, *big.Int](tdCacheLimit),
+		numberCache:   lru.NewCache[common.Hash, uint64](numberCacheLimit + len(headers)),
+		procInterrupt: bc.insertStopped,
+		engine:        bc.engine,
+	}
+	hc.genesisHeader = bc.hc.genesisHeader
+	hc.currentHeader.Store(bc.hc.CurrentHeader())
+	for _, header := range headers {
+		hash := header.Hash()
+		hc.headerCache.Add(hash, header)
+		hc.numberCache.Add(hash, header.Number.Uint64())
+	}
+	return hc
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that chain segments are verified without being imported and that the
+// outcome is attested.
+func TestVerifySegment(t *testing.T) {
+	var (
+		key, _       = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender       = crypto.PubkeyToAddress(key.PublicKey)
+		attestKey, _ = crypto.GenerateKey()
+		attester     = crypto.PubkeyToAddress(attestKey.PublicKey)
+		engine       = ethash.NewFaker()
+		genesis      = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableSegmentAttestation(attestKey))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	segment := func(receipts []types.Receipts) ([]*types.Header, []*types.Body, []types.Receipts) {
+		var (
+			headers []*types.Header
+			bodies  []*types.Body
+		)
+		for _, block := range blocks[1:] {
+			headers = append(headers, block.Header())
+			bodies = append(bodies, block.Body())
+		}
+		return headers, bodies, receipts[1:]
+	}
+	// Verify a segment on top of the local head without importing it
+	attestation, err := chain.VerifySegment(segment(receipts))
+	if err != nil {
+		t.Fatalf("failed to verify segment: %v", err)
+	}
+	if !attestation.Valid || !attestation.Executed || attestation.First != 2 || attestation.Last != 4 || attestation.LastHash != blocks[3].Hash() {
+		t.Fatalf("attestation mismatch: %+v", attestation)
+	}
+	if have, err := attestation.Attester(); err != nil || have != attester {
+		t.Fatalf("attester mismatch: have %x, want %x, err %v", have, attester, err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 1 {
+		t.Fatalf("segment imported, head %d", head)
+	}
+	// Tampered receipts must be attested as invalid
+	tampered := make([]types.Receipts, len(receipts))
+	copy(tampered, receipts)
+	forged := *receipts[2][0]
+	forged.CumulativeGasUsed++
+	tampered[2] = types.Receipts{&forged}
+
+	attestation, err = chain.VerifySegment(segment(tampered))
+	if err != nil {
+		t.Fatalf("failed to verify segment: %v", err)
+	}
+	if attestation.Valid || attestation.Reason == "" {
+		t.Fatalf("tampered segment attested valid: %+v", attestation)
+	}
+	// Malformed segments are rejected
+	headers, bodies, rs := segment(receipts)
+	headers[0], headers[1] = headers[1], headers[0]
+	if _, err := chain.VerifySegment(headers, bodies, rs); !errors.Is(err, errInvalidSegment) {
+		t.Fatalf("non contiguous segment accepted: %v", err)
+	}
+	headers, bodies, rs = segment(receipts)
+	bodies[1] = nil
+	if _, err := chain.VerifySegment(headers, bodies, rs); !errors.Is(err, errInvalidSegment) {
+		t.Fatalf("segment with missing body accepted: %v", err)
+	}
+	// Segments whose parent state is unavailable can't be attested
+	headersOnly, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableSegmentAttestation(attestKey))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer headersOnly.Stop()
+
+	if _, err := headersOnly.InsertHeaderChain([]*types.Header{blocks[0].Header()}); err != nil {
+		t.Fatalf("failed to insert header: %v", err)
+	}
+	if attestation, err := headersOnly.VerifySegment(segment(receipts)); !errors.Is(err, errSegmentStateUnavailable) {
+		t.Fatalf("segment without parent state attested: %+v, %v", attestation, err)
+	}
+}
@@ -67,11 +67,12 @@ func newTxSenderCacher(threads int) *txSenderCacher {
 }
 
 // cache is an infinite loop, caching transaction senders from various forms of
-// data structures.
+// data structures. Senders already recovered by the transaction pool are taken
+// from the shared sender cache.
 func (cacher *txSenderCacher) cache() {
 	for task := range cacher.tasks {
 		for i := 0; i < len(task.txs); i += task.inc {
-			types.Sender(task.signer, task.txs[i])
+			types.SharedSender(task.signer, task.txs[i])
 		}
 	}
 }
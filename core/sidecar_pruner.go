@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// sidecarPruneBatch is the maximum number of blocks pruned in one step, so
+// that shutdown isn't delayed by a large backlog.
+const sidecarPruneBatch = 10000
+
+// SidecarRetention returns the number of blocks from the head whose blob
+// sidecars are retained, 0 if all of them are.
+func (bc *BlockChain) SidecarRetention() uint64 {
+	return bc.cacheConfig.SidecarRetention
+}
+
+// SidecarTail returns the number of the oldest block whose blob sidecars are
+// retained, 0 if none were pruned yet.
+func (bc *BlockChain) SidecarTail() uint64 {
+	if tail := rawdb.ReadBlobSidecarTail(bc.db); tail != nil {
+		return *tail
+	}
+	return 0
+}
+
+// sidecarPruneLoop deletes the blob sidecars of the blocks falling out of the
+// retention window whenever the head advances.
+func (bc *BlockChain) sidecarPruneLoop(quit <-chan struct{}) {
+	heads := make(chan ChainHeadEvent, 16)
+	sub := bc.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	bc.pruneSidecars(bc.CurrentBlock().Number.Uint64(), quit)
+	for {
+		select {
+		case ev := <-heads:
+			// Only act on the latest head if several are queued up
+			head := ev.Header
+			for len(heads) > 0 {
+				head = (<-heads).Header
+			}
+			bc.pruneSidecars(head.Number.Uint64(), quit)
+		case <-sub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// pruneSidecars deletes the blob sidecars of the blocks older than the
+// retention window below the given head from the key-value store, advancing
+// the sidecar tail.
+//
+// The frozen sidecars are truncated from the freezer table by the chain freezer
+// itself, along the same retention window (the blob extra reserve on top of the
+// availability window), so only the blocks above the frozen ones are visited.
+func (bc *BlockChain) pruneSidecars(head uint64, quit <-chan struct{}) {
+	retention := bc.cacheConfig.SidecarRetention
+	if retention == 0 || head < retention {
+		return
+	}
+	limit := head - retention + 1
+
+	tail := bc.SidecarTail()
+	if frozen, err := bc.db.Ancients(); err == nil && frozen > tail {
+		tail = frozen
+	}
+	for tail < limit {
+		next := min(tail+sidecarPruneBatch, limit)
+		bytes := rawdb.PruneBlobSidecars(bc.db, tail, next)
+		rawdb.WriteBlobSidecarTail(bc.db, next)
+
+		log.Debug("Pruned blob sidecars", "from", tail, "to", next, "bytes", bytes)
+		tail = next
+
+		select {
+		case <-quit:
+			return
+		default:
+		}
+	}
+}
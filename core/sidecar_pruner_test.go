@@ -0,0 +1,150 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newSidecarTestChain creates a chain with sidecar retention disabled, so that
+// nothing is pruned in the background, and generates n blocks each carrying a
+// blob transaction with its sidecar.
+func newSidecarTestChain(t *testing.T, db ethdb.Database, n int) (*BlockChain, []*types.Block, []types.Receipts) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		config  = params.ParliaTestChainConfig
+		engine  = &mockParlia{}
+		signer  = types.LatestSigner(config)
+		genesis = &Genesis{
+			Config: config,
+			Alloc:  types.GenesisAlloc{addr: {Balance: new(big.Int).SetUint64(10 * params.Ether)}},
+		}
+	)
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, engine, n, func(i int, gen *BlockGen) {
+		tx, sidecar := makeMockTx(config, signer, key, gen.TxNonce(addr), gen.BaseFee().Uint64(), eip4844.CalcBlobFee(config, gen.HeadBlock()).Uint64(), true)
+		gen.AddTxWithChain(chain, tx)
+		gen.AddBlobSidecar(&types.BlobSidecar{
+			BlobTxSidecar: *sidecar,
+			TxIndex:       0,
+			TxHash:        tx.Hash(),
+		})
+	})
+	return chain, blocks, receipts
+}
+
+// checkSidecarsRetained checks that the sidecars of the blocks from tail on are
+// stored and the older ones are gone.
+func checkSidecarsRetained(t *testing.T, chain *BlockChain, blocks []*types.Block, tail uint64) {
+	t.Helper()
+
+	for _, block := range blocks {
+		have := len(rawdb.ReadBlobSidecars(chain.db, block.Hash(), block.NumberU64())) > 0
+		if want := block.NumberU64() >= tail; have != want {
+			t.Errorf("block %d: sidecars retained %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+}
+
+// Tests that blob sidecars beyond the retention window are pruned, leaving the
+// recent ones intact.
+func TestSidecarPruning(t *testing.T) {
+	chain, blocks, _ := newSidecarTestChain(t, rawdb.NewMemoryDatabase(), 8)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	checkSidecarsRetained(t, chain, blocks, 0)
+
+	chain.cacheConfig.SidecarRetention = 3
+	if retention := chain.SidecarRetention(); retention != 3 {
+		t.Fatalf("retention mismatch: have %d, want 3", retention)
+	}
+	chain.pruneSidecars(8, nil)
+	if tail := chain.SidecarTail(); tail != 6 {
+		t.Fatalf("sidecar tail mismatch: have %d, want 6", tail)
+	}
+	checkSidecarsRetained(t, chain, blocks, 6)
+}
+
+// Tests that the sidecars of the blocks above the frozen ones are pruned from
+// the key-value store, leaving the frozen ones to the chain freezer.
+func TestSidecarPruningFreezer(t *testing.T) {
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false, false, false)
+	if err != nil {
+		t.Fatalf("failed to create database with freezer: %v", err)
+	}
+	defer db.Close()
+
+	chain, blocks, receipts := newSidecarTestChain(t, db, 8)
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	// Freeze the blocks up to #5, leaving the rest in the key-value store
+	if n, err := chain.InsertReceiptChain(blocks, receipts, 5); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 6 {
+		t.Fatalf("frozen blocks mismatch: have %d, want 6", frozen)
+	}
+	checkSidecarsRetained(t, chain, blocks, 0)
+
+	chain.cacheConfig.SidecarRetention = 2
+	chain.pruneSidecars(8, nil)
+	if tail := chain.SidecarTail(); tail != 7 {
+		t.Fatalf("sidecar tail mismatch: have %d, want 7", tail)
+	}
+	for _, block := range blocks {
+		have := len(rawdb.ReadBlobSidecars(chain.db, block.Hash(), block.NumberU64())) > 0
+		if want := block.NumberU64() != 6; have != want {
+			t.Errorf("block %d: sidecars retained %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+}
+
+// Tests that a sidecar retention below the availability window is rejected.
+func TestSidecarRetentionBelowWindow(t *testing.T) {
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.SidecarRetention = params.MinBlocksForBlobRequests - 1
+
+	_, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, &Genesis{Config: params.TestChainConfig}, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if !errors.Is(err, errSidecarRetention) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errSidecarRetention)
+	}
+}
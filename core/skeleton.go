@@ -0,0 +1,319 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// errSkeletonEmpty is returned if the skeleton has no subchains to operate on.
+	errSkeletonEmpty = errors.New("skeleton is empty")
+
+	// errSkeletonReorgDenied is returned if a new head doesn't extend the leading
+	// subchain and the caller didn't permit starting a new one.
+	errSkeletonReorgDenied = errors.New("non-forced head reorg denied")
+
+	// errSkeletonUnlinked is returned if a backfilled header doesn't connect to
+	// the tail of the leading subchain.
+	errSkeletonUnlinked = errors.New("header doesn't link to skeleton tail")
+)
+
+// SkeletonSubchain is a contiguous header chain segment that is backed by the
+// database, but may not be linked to the live chain. The skeleton store may
+// contain multiple subchains, which get merged as the gaps between them are
+// backfilled.
+type SkeletonSubchain struct {
+	Head uint64      // Block number of the newest header in the subchain
+	Tail uint64      // Block number of the oldest header in the subchain
+	Next common.Hash // Block hash of the next oldest header in the subchain
+}
+
+// skeletonProgress is a database entry to allow suspending and resuming a chain
+// sync. As the skeleton header chain is downloaded backwards, restarts can and
+// will produce temporarily disjoint subchains.
+type skeletonProgress struct {
+	Subchains []*SkeletonSubchain // Disjoint subchains downloaded until now, newest first
+}
+
+// SkeletonStore is a persistent, reverse ordered header store used by sync
+// drivers that learn about the chain head first and backfill the headers down
+// to the local chain afterwards (e.g. beacon or portal based syncing). It only
+// stores and links headers, the actual header verification and chain import
+// is left to the driver.
+type SkeletonStore struct {
+	db       ethdb.Database
+	progress *skeletonProgress
+	lock     sync.RWMutex
+}
+
+// NewSkeletonStore creates a skeleton store on top of the given database,
+// resuming any previously persisted progress.
+func NewSkeletonStore(db ethdb.Database) *SkeletonStore {
+	s := &SkeletonStore{db: db, progress: new(skeletonProgress)}
+	if status := rawdb.ReadSkeletonSyncStatus(db); len(status) > 0 {
+		if err := json.Unmarshal(status, s.progress); err != nil {
+			log.Error("Failed to decode skeleton sync status", "err", err)
+			s.progress = new(skeletonProgress)
+		}
+	}
+	return s
+}
+
+// Subchains returns a copy of the subchains currently tracked, newest first.
+func (s *SkeletonStore) Subchains() []SkeletonSubchain {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	subchains := make([]SkeletonSubchain, len(s.progress.Subchains))
+	for i, sub := range s.progress.Subchains {
+		subchains[i] = *sub
+	}
+	return subchains
+}
+
+// Header retrieves a header from the skeleton store.
+func (s *SkeletonStore) Header(number uint64) *types.Header {
+	return rawdb.ReadSkeletonHeader(s.db, number)
+}
+
+// Bounds retrieves the head and tail headers of the leading subchain, along
+// with the hash of the next header to backfill.
+func (s *SkeletonStore) Bounds() (head *types.Header, tail *types.Header, next common.Hash, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.progress.Subchains) == 0 {
+		return nil, nil, common.Hash{}, errSkeletonEmpty
+	}
+	lead := s.progress.Subchains[0]
+	if head = rawdb.ReadSkeletonHeader(s.db, lead.Head); head == nil {
+		return nil, nil, common.Hash{}, fmt.Errorf("head skeleton header %d is missing", lead.Head)
+	}
+	if tail = rawdb.ReadSkeletonHeader(s.db, lead.Tail); tail == nil {
+		return nil, nil, common.Hash{}, fmt.Errorf("tail skeleton header %d is missing", lead.Tail)
+	}
+	return head, tail, lead.Next, nil
+}
+
+// Gaps returns the inclusive block ranges still missing between the tracked
+// subchains, newest first.
+func (s *SkeletonStore) Gaps() [][2]uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var gaps [][2]uint64
+	for i := 1; i < len(s.progress.Subchains); i++ {
+		newer, older := s.progress.Subchains[i-1], s.progress.Subchains[i]
+		if older.Head+1 <= newer.Tail-1 {
+			gaps = append(gaps, [2]uint64{older.Head + 1, newer.Tail - 1})
+		}
+	}
+	return gaps
+}
+
+// Linked reports whether the oldest subchain connects to a header known by
+// the given chain, meaning the skeleton can be imported in full.
+func (s *SkeletonStore) Linked(chain interface {
+	HasHeader(hash common.Hash, number uint64) bool
+}) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.progress.Subchains) != 1 {
+		return false
+	}
+	lead := s.progress.Subchains[0]
+	if lead.Tail == 0 {
+		return true
+	}
+	return chain.HasHeader(lead.Next, lead.Tail-1)
+}
+
+// Extend announces a new chain head to the skeleton. If the header extends the
+// leading subchain it is appended to it, otherwise a new subchain is started
+// if force is set (i.e. the head was reorged) or an error returned if not.
+func (s *SkeletonStore) Extend(head *types.Header, force bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var (
+		number = head.Number.Uint64()
+		batch  = s.db.NewBatch()
+	)
+	if len(s.progress.Subchains) > 0 {
+		lead := s.progress.Subchains[0]
+		if lead.Head+1 == number {
+			if parent := rawdb.ReadSkeletonHeader(s.db, lead.Head); parent != nil && parent.Hash() == head.ParentHash {
+				lead.Head = number
+				rawdb.WriteSkeletonHeader(batch, head)
+				s.saveProgress(batch)
+				return batch.Write()
+			}
+		}
+		if !force {
+			return fmt.Errorf("%w: head #%d [%x..], leading subchain %d->%d", errSkeletonReorgDenied, number, head.Hash().Bytes()[:4], lead.Tail, lead.Head)
+		}
+		// A new head is forced, drop everything from the old subchains above the
+		// new head since they're on a different fork now.
+		var keep []*SkeletonSubchain
+		for _, sub := range s.progress.Subchains {
+			if sub.Tail >= number {
+				for n := sub.Tail; n <= sub.Head; n++ {
+					rawdb.DeleteSkeletonHeader(batch, n)
+				}
+				continue
+			}
+			if sub.Head >= number {
+				for n := number; n <= sub.Head; n++ {
+					rawdb.DeleteSkeletonHeader(batch, n)
+				}
+				sub.Head = number - 1
+			}
+			keep = append(keep, sub)
+		}
+		s.progress.Subchains = keep
+	}
+	s.progress.Subchains = append([]*SkeletonSubchain{{
+		Head: number,
+		Tail: number,
+		Next: head.ParentHash,
+	}}, s.progress.Subchains...)
+
+	rawdb.WriteSkeletonHeader(batch, head)
+	s.saveProgress(batch)
+	return batch.Write()
+}
+
+// Backfill inserts a batch of headers, ordered newest first, below the tail of
+// the leading subchain. If the leading subchain reaches the next one and the
+// two link up, they are merged. The method returns whether a merge happened.
+func (s *SkeletonStore) Backfill(headers []*types.Header) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.progress.Subchains) == 0 {
+		return false, errSkeletonEmpty
+	}
+	var (
+		lead   = s.progress.Subchains[0]
+		batch  = s.db.NewBatch()
+		merged bool
+	)
+	for _, header := range headers {
+		number := header.Number.Uint64()
+		if lead.Tail == 0 || number != lead.Tail-1 || header.Hash() != lead.Next {
+			// Persist whatever was linked so far before bailing out
+			s.saveProgress(batch)
+			if err := batch.Write(); err != nil {
+				return merged, err
+			}
+			return merged, fmt.Errorf("%w: have #%d [%x..], want #%d [%x..]", errSkeletonUnlinked, number, header.Hash().Bytes()[:4], lead.Tail-1, lead.Next.Bytes()[:4])
+		}
+		// If the header reaches into the next subchain, either link them up or
+		// trim the next subchain since it's on a different fork
+		if len(s.progress.Subchains) > 1 && s.progress.Subchains[1].Head >= number {
+			next := s.progress.Subchains[1]
+			if existing := rawdb.ReadSkeletonHeader(s.db, number); existing != nil && existing.Hash() == header.Hash() {
+				lead.Tail, lead.Next = next.Tail, next.Next
+				s.progress.Subchains = append(s.progress.Subchains[:1], s.progress.Subchains[2:]...)
+
+				log.Debug("Merged skeleton subchains", "head", lead.Head, "tail", lead.Tail)
+				merged = true
+				break
+			}
+			if next.Tail >= number {
+				s.progress.Subchains = append(s.progress.Subchains[:1], s.progress.Subchains[2:]...)
+			} else {
+				next.Head = number - 1
+			}
+		}
+		rawdb.WriteSkeletonHeader(batch, header)
+		lead.Tail, lead.Next = number, header.ParentHash
+	}
+	s.saveProgress(batch)
+	return merged, batch.Write()
+}
+
+// Trim removes all skeleton headers at or below the given number, which the
+// sync driver already imported into the live chain.
+func (s *SkeletonStore) Trim(number uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var (
+		batch = s.db.NewBatch()
+		keep  []*SkeletonSubchain
+	)
+	for _, sub := range s.progress.Subchains {
+		if sub.Tail > number {
+			keep = append(keep, sub)
+			continue
+		}
+		end := min(sub.Head, number)
+		for n := sub.Tail; n <= end; n++ {
+			rawdb.DeleteSkeletonHeader(batch, n)
+		}
+		if sub.Head > number {
+			header := rawdb.ReadSkeletonHeader(s.db, number+1)
+			if header == nil {
+				return fmt.Errorf("skeleton header %d is missing", number+1)
+			}
+			sub.Tail, sub.Next = number+1, header.ParentHash
+			keep = append(keep, sub)
+		}
+	}
+	s.progress.Subchains = keep
+	s.saveProgress(batch)
+	return batch.Write()
+}
+
+// Reset drops all skeleton headers and the tracked progress.
+func (s *SkeletonStore) Reset() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	batch := s.db.NewBatch()
+	for _, sub := range s.progress.Subchains {
+		for n := sub.Tail; n <= sub.Head; n++ {
+			rawdb.DeleteSkeletonHeader(batch, n)
+		}
+	}
+	s.progress = new(skeletonProgress)
+	rawdb.DeleteSkeletonSyncStatus(batch)
+	return batch.Write()
+}
+
+// saveProgress writes the sync progress into the batch.
+//
+// This function expects the lock to be held.
+func (s *SkeletonStore) saveProgress(batch ethdb.KeyValueWriter) {
+	status, err := json.Marshal(s.progress)
+	if err != nil {
+		panic(err) // This can only fail during implementation
+	}
+	rawdb.WriteSkeletonSyncStatus(batch, status)
+}
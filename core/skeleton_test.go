@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests the basic lifecycle of the skeleton store: extending, backfilling,
+// gap tracking, merging, linking and persistence across restarts.
+func TestSkeletonStore(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 20, nil)
+	headers := make([]*types.Header, len(blocks)+1)
+	for _, block := range blocks {
+		headers[block.NumberU64()] = block.Header()
+	}
+	backfill := func(s *SkeletonStore, from, to uint64) bool {
+		t.Helper()
+		var batch []*types.Header
+		for n := from; n >= to; n-- {
+			batch = append(batch, headers[n])
+		}
+		merged, err := s.Backfill(batch)
+		if err != nil {
+			t.Fatalf("failed to backfill %d->%d: %v", from, to, err)
+		}
+		return merged
+	}
+	store := NewSkeletonStore(db)
+	if err := store.Extend(headers[10], false); err != nil {
+		t.Fatalf("failed to start skeleton: %v", err)
+	}
+	if err := store.Extend(headers[11], false); err != nil {
+		t.Fatalf("failed to extend skeleton: %v", err)
+	}
+	backfill(store, 9, 8)
+
+	if err := store.Extend(headers[20], false); !errors.Is(err, errSkeletonReorgDenied) {
+		t.Fatalf("unforced gapped head error mismatch: have %v, want %v", err, errSkeletonReorgDenied)
+	}
+	if err := store.Extend(headers[20], true); err != nil {
+		t.Fatalf("failed to force new head: %v", err)
+	}
+	if have, want := store.Gaps(), [][2]uint64{{12, 19}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("gaps mismatch: have %v, want %v", have, want)
+	}
+	// Reopen the store and ensure progress is retained
+	store = NewSkeletonStore(db)
+	if have := len(store.Subchains()); have != 2 {
+		t.Fatalf("subchain count mismatch after restart: have %d, want 2", have)
+	}
+	if _, err := store.Backfill([]*types.Header{headers[18]}); !errors.Is(err, errSkeletonUnlinked) {
+		t.Fatalf("unlinked backfill error mismatch: have %v, want %v", err, errSkeletonUnlinked)
+	}
+	if merged := backfill(store, 19, 11); !merged {
+		t.Fatal("subchains not merged")
+	}
+	head, tail, _, err := store.Bounds()
+	if err != nil {
+		t.Fatalf("failed to retrieve bounds: %v", err)
+	}
+	if head.Number.Uint64() != 20 || tail.Number.Uint64() != 8 {
+		t.Fatalf("bounds mismatch: have %d->%d, want 8->20", tail.Number, head.Number)
+	}
+	// Link the skeleton up to a local chain and trim the imported part
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if store.Linked(chain) {
+		t.Fatal("skeleton linked to chain without ancestors")
+	}
+	if _, err := chain.InsertHeaderChain(headers[1:8]); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	if !store.Linked(chain) {
+		t.Fatal("skeleton not linked to chain with ancestors")
+	}
+	if err := store.Trim(15); err != nil {
+		t.Fatalf("failed to trim skeleton: %v", err)
+	}
+	if have := store.Subchains(); len(have) != 1 || have[0].Tail != 16 || have[0].Next != headers[15].Hash() {
+		t.Fatalf("trimmed subchains mismatch: %+v", have)
+	}
+	if store.Header(12) != nil {
+		t.Fatal("trimmed header still present")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// snapshotRebuildPoll is the interval at which a running snapshot rebuild is
+// checked for completion.
+const snapshotRebuildPoll = 5 * time.Second
+
+var snapshotCorruptionMeter = metrics.NewRegisteredMeter("chain/snapshot/corruption", nil)
+
+// SnapshotHealth is the health status of the state snapshot.
+type SnapshotHealth uint8
+
+const (
+	// SnapshotCorrupted is reported when the snapshot tree diverged from the
+	// chain state and was disabled.
+	SnapshotCorrupted SnapshotHealth = iota
+
+	// SnapshotRebuilding is reported when a snapshot rebuild was scheduled.
+	SnapshotRebuilding
+
+	// SnapshotRebuilt is reported when a scheduled rebuild finished.
+	SnapshotRebuilt
+)
+
+// String implements fmt.Stringer.
+func (h SnapshotHealth) String() string {
+	switch h {
+	case SnapshotCorrupted:
+		return "corrupted"
+	case SnapshotRebuilding:
+		return "rebuilding"
+	case SnapshotRebuilt:
+		return "rebuilt"
+	default:
+		return "unknown"
+	}
+}
+
+// SnapshotHealthEvent is posted when the health of the state snapshot changes.
+type SnapshotHealthEvent struct {
+	Status SnapshotHealth
+	Number uint64      // Block at which the status change happened
+	Root   common.Hash // State root of the block
+}
+
+// SubscribeSnapshotHealthEvent registers a subscription of SnapshotHealthEvent.
+func (bc *BlockChain) SubscribeSnapshotHealthEvent(ch chan<- SnapshotHealthEvent) event.Subscription {
+	return bc.scope.Track(bc.snapHealthFeed.Subscribe(ch))
+}
+
+// snapshotCovered reports whether the snapshot tree maintains a layer for the
+// given state root.
+func (bc *BlockChain) snapshotCovered(root common.Hash) bool {
+	return bc.snaps != nil && bc.snaps.Snapshot(root) != nil
+}
+
+// checkSnapshot verifies that the snapshot tree was extended with the state of
+// a freshly written block whose parent state was covered. If the layer is
+// missing, the update was rejected due to an inconsistency between the layers,
+// so the snapshot is disabled and rebuilt from the trie of the current head.
+// The caller must hold the chain mutex.
+func (bc *BlockChain) checkSnapshot(block *types.Block, parentCovered bool) {
+	if !parentCovered || bc.snapshotCovered(block.Root()) {
+		return
+	}
+	snapshotCorruptionMeter.Mark(1)
+	log.Error("State snapshot diverged from the chain", "number", block.Number(), "hash", block.Hash(), "root", block.Root())
+
+	bc.snaps.Disable()
+	bc.snapHealthFeed.Send(SnapshotHealthEvent{Status: SnapshotCorrupted, Number: block.NumberU64(), Root: block.Root()})
+
+	if bc.cacheConfig.SnapshotNoBuild {
+		log.Warn("State snapshot left disabled, automatic rebuild is turned off")
+		return
+	}
+	// Regenerate from the head, the chain mutex guarantees it doesn't move
+	// until the generator is started, so new blocks extend the rebuilt tree.
+	head := bc.CurrentBlock()
+	bc.snaps.Rebuild(head.Root)
+	bc.snapHealthFeed.Send(SnapshotHealthEvent{Status: SnapshotRebuilding, Number: head.Number.Uint64(), Root: head.Root})
+
+	if bc.snapRecovery.CompareAndSwap(false, true) {
+		bc.tasks.spawn("snaprebuild", TaskNormal, RestartNever, bc.watchSnapshotRebuild)
+	}
+}
+
+// watchSnapshotRebuild waits for a scheduled snapshot rebuild to finish and
+// reports the recovery.
+func (bc *BlockChain) watchSnapshotRebuild(quit <-chan struct{}) {
+	defer bc.snapRecovery.Store(false)
+
+	ticker := time.NewTicker(snapshotRebuildPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if bc.snaps.Generating() {
+				continue
+			}
+			root := bc.snaps.DiskRoot()
+			if root == (common.Hash{}) {
+				continue // Disabled again, wait for the next rebuild
+			}
+			log.Info("State snapshot rebuilt", "root", root)
+			bc.snapHealthFeed.Send(SnapshotHealthEvent{Status: SnapshotRebuilt, Number: bc.CurrentBlock().Number.Uint64(), Root: root})
+			return
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a block whose state is missing from the snapshot tree, although
+// its parent was covered, triggers an automatic snapshot rebuild.
+func TestSnapshotCorruptionRebuild(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2, nil)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	head := chain.CurrentBlock()
+	if !chain.snapshotCovered(head.Root) {
+		t.Fatalf("head state not covered by snapshot")
+	}
+	events := make(chan SnapshotHealthEvent, 4)
+	sub := chain.SubscribeSnapshotHealthEvent(events)
+	defer sub.Unsubscribe()
+
+	// A healthy extension must not trigger anything
+	chain.checkSnapshot(blocks[1], true)
+	if len(events) != 0 {
+		t.Fatalf("health event emitted for consistent snapshot: %v", (<-events).Status)
+	}
+	// Simulate a block whose snapshot layer failed to be created
+	broken := types.NewBlockWithHeader(&types.Header{Number: common.Big3, Root: common.Hash{0xde, 0xad}})
+	chain.checkSnapshot(broken, true)
+
+	for _, want := range []SnapshotHealth{SnapshotCorrupted, SnapshotRebuilding} {
+		if event := <-events; event.Status != want {
+			t.Fatalf("health event mismatch: have %v, want %v", event.Status, want)
+		}
+	}
+	if !chain.snapshotCovered(head.Root) {
+		t.Errorf("snapshot rebuild not started from head")
+	}
+}
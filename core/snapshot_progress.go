@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// snapshotProgressPoll is the interval at which the progress of the snapshot
+// generation is checked for changes.
+const snapshotProgressPoll = 3 * time.Second
+
+// errSnapshotsDisabled is returned if the snapshot status is requested while
+// snapshots are not maintained.
+var errSnapshotsDisabled = errors.New("snapshots disabled")
+
+// SnapshotGenerationEvent is posted when the background snapshot generation
+// makes progress, and once more when it completes.
+type SnapshotGenerationEvent struct {
+	Status *snapshot.GenerationStatus
+}
+
+// SnapshotGenerationStatus returns the progress of the background generation of
+// the state snapshot. The status is updated whenever the generator flushes its
+// progress, so it might lag a few seconds behind.
+func (bc *BlockChain) SnapshotGenerationStatus() (*snapshot.GenerationStatus, error) {
+	if bc.snaps == nil {
+		return nil, errSnapshotsDisabled
+	}
+	return bc.snaps.GenerationStatus()
+}
+
+// SubscribeSnapshotGenerationEvent registers a subscription of SnapshotGenerationEvent.
+func (bc *BlockChain) SubscribeSnapshotGenerationEvent(ch chan<- SnapshotGenerationEvent) event.Subscription {
+	return bc.scope.Track(bc.snapGenFeed.Subscribe(ch))
+}
+
+// watchSnapshotGeneration periodically reports the progress of the snapshot
+// generation, covering both the initial build and any later rebuild.
+func (bc *BlockChain) watchSnapshotGeneration(quit <-chan struct{}) {
+	ticker := time.NewTicker(snapshotProgressPoll)
+	defer ticker.Stop()
+
+	var last *snapshot.GenerationStatus
+	for {
+		select {
+		case <-ticker.C:
+			last = bc.reportSnapshotGeneration(last)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// reportSnapshotGeneration posts the current generation progress if it changed
+// since the last reported one, returning the status to compare against next.
+// Completed generations are only reported if they were seen in progress.
+func (bc *BlockChain) reportSnapshotGeneration(last *snapshot.GenerationStatus) *snapshot.GenerationStatus {
+	status, err := bc.SnapshotGenerationStatus()
+	if err != nil {
+		return last // Disabled, wait for a rebuild
+	}
+	switch {
+	case status.Done && (last == nil || last.Done):
+		return status
+	case last != nil && last.Done == status.Done && bytes.Equal(last.Marker, status.Marker) && last.Accounts == status.Accounts && last.Slots == status.Slots:
+		return last
+	}
+	bc.snapGenFeed.Send(SnapshotGenerationEvent{Status: status})
+	return status
+}
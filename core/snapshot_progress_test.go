@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the snapshot generation progress is queryable and that progress
+// changes are reported through the event feed.
+func TestSnapshotGenerationStatus(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	for deadline := time.Now().Add(5 * time.Second); chain.snaps.Generating(); {
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot generation timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	status, err := chain.SnapshotGenerationStatus()
+	if err != nil {
+		t.Fatalf("failed to retrieve generation status: %v", err)
+	}
+	if !status.Done || status.Marker != nil {
+		t.Fatalf("generation not reported done: %+v", status)
+	}
+	events := make(chan SnapshotGenerationEvent, 4)
+	sub := chain.SubscribeSnapshotGenerationEvent(events)
+	defer sub.Unsubscribe()
+
+	// A completed generation is only reported if it was seen in progress
+	if last := chain.reportSnapshotGeneration(nil); !last.Done {
+		t.Fatalf("unexpected status returned: %+v", last)
+	}
+	if len(events) != 0 {
+		t.Fatalf("event emitted for idle generator: %+v", (<-events).Status)
+	}
+	chain.reportSnapshotGeneration(&snapshot.GenerationStatus{Marker: []byte{0x80}})
+	select {
+	case event := <-events:
+		if !event.Status.Done {
+			t.Fatalf("completion not reported: %+v", event.Status)
+		}
+	default:
+		t.Fatal("no event emitted on completion")
+	}
+	// Chains without snapshots report an error
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.SnapshotLimit = 0
+	nosnap, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer nosnap.Stop()
+
+	if _, err := nosnap.SnapshotGenerationStatus(); !errors.Is(err, errSnapshotsDisabled) {
+		t.Errorf("status reported without snapshots: %v", err)
+	}
+}
@@ -0,0 +1,475 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/holiman/uint256"
+)
+
+// errAccountDeleted is returned if the effects of a transaction cannot be
+// replayed, because it deleted or recreated an account.
+var errAccountDeleted = errors.New("transaction deleted an account")
+
+// StateKeyKind is the part of the state a StateKey refers to.
+type StateKeyKind uint8
+
+const (
+	AccountKey     StateKeyKind = iota // Existence of the account
+	BalanceKey                         // Balance of the account
+	NonceKey                           // Nonce of the account
+	CodeKey                            // Code of the account
+	StorageRootKey                     // Entire storage of the account
+	StorageKey                         // Single storage slot of the account
+)
+
+// StateKey identifies a piece of the state read or written by a transaction.
+type StateKey struct {
+	Kind    StateKeyKind
+	Address common.Address
+	Slot    common.Hash // Only set for StorageKey
+}
+
+// accountOrigin is the state of an account before the recorded transaction
+// first modified it.
+type accountOrigin struct {
+	exists   bool
+	balance  *uint256.Int
+	nonce    uint64
+	codeHash common.Hash
+	storage  map[common.Hash]common.Hash
+}
+
+// AccessRecorder is a statedb which records the state keys read and written
+// through it, along with the original values of everything modified. It is
+// used to detect conflicts between transactions executed concurrently and to
+// replay the effects of a transaction onto another state.
+//
+// Balance changes are treated as deltas: adding to or subtracting from a
+// balance does not count as reading it, so that for example transaction fees
+// accumulating in the same account do not conflict.
+type AccessRecorder struct {
+	inner   *StateDB
+	reads   map[StateKey]struct{}
+	writes  map[StateKey]struct{}
+	origins map[common.Address]*accountOrigin
+	deleted map[common.Address]struct{}
+}
+
+// NewAccessRecorder wraps the given stateDb with an access recorder.
+func NewAccessRecorder(stateDb *StateDB) *AccessRecorder {
+	r := &AccessRecorder{inner: stateDb}
+	r.Reset()
+	return r
+}
+
+// Reset discards the recorded accesses, to start recording a new transaction.
+func (r *AccessRecorder) Reset() {
+	r.reads = make(map[StateKey]struct{})
+	r.writes = make(map[StateKey]struct{})
+	r.origins = make(map[common.Address]*accountOrigin)
+	r.deleted = make(map[common.Address]struct{})
+}
+
+// Reads returns the state keys read since the last reset.
+func (r *AccessRecorder) Reads() map[StateKey]struct{} {
+	return r.reads
+}
+
+// Writes returns the state keys written since the last reset. Accounts whose
+// existence changed are reported as written AccountKeys.
+func (r *AccessRecorder) Writes() map[StateKey]struct{} {
+	writes := make(map[StateKey]struct{}, len(r.writes))
+	for key := range r.writes {
+		writes[key] = struct{}{}
+	}
+	for addr, origin := range r.origins {
+		if origin.exists != r.alive(addr) {
+			writes[StateKey{Kind: AccountKey, Address: addr}] = struct{}{}
+		}
+	}
+	for addr := range r.deleted {
+		writes[StateKey{Kind: AccountKey, Address: addr}] = struct{}{}
+	}
+	return writes
+}
+
+// Deleted returns the accounts destructed, recreated or deleted as empty since
+// the last reset. Their entire storage must be considered overwritten.
+func (r *AccessRecorder) Deleted() []common.Address {
+	var deleted []common.Address
+	for addr := range r.deleted {
+		deleted = append(deleted, addr)
+	}
+	for addr, origin := range r.origins {
+		if _, ok := r.deleted[addr]; !ok && origin.exists && !r.alive(addr) {
+			deleted = append(deleted, addr)
+		}
+	}
+	return deleted
+}
+
+// alive reports whether the account survives the end of the transaction, that
+// is it exists, and will not be removed as destructed or empty by Finalise.
+func (r *AccessRecorder) alive(addr common.Address) bool {
+	return r.inner.Exist(addr) && !r.inner.Empty(addr) && !r.inner.HasSelfDestructed(addr)
+}
+
+func (r *AccessRecorder) read(kind StateKeyKind, addr common.Address) {
+	r.reads[StateKey{Kind: kind, Address: addr}] = struct{}{}
+}
+
+func (r *AccessRecorder) write(kind StateKeyKind, addr common.Address) {
+	r.writes[StateKey{Kind: kind, Address: addr}] = struct{}{}
+	r.touch(addr)
+}
+
+// touch tracks the original state of the account the first time it is
+// modified.
+func (r *AccessRecorder) touch(addr common.Address) {
+	if _, ok := r.origins[addr]; !ok {
+		r.origins[addr] = &accountOrigin{
+			exists:   r.inner.Exist(addr),
+			balance:  r.inner.GetBalance(addr).Clone(),
+			nonce:    r.inner.GetNonce(addr),
+			codeHash: r.inner.GetCodeHash(addr),
+			storage:  make(map[common.Hash]common.Hash),
+		}
+	}
+}
+
+// Effects returns the net state changes made since the last reset. They can
+// only be replayed onto a state whose accounts match what was read, so only
+// after validating the reads against the transactions executed in between.
+func (r *AccessRecorder) Effects() (*StateEffects, error) {
+	if len(r.Deleted()) > 0 {
+		return nil, errAccountDeleted
+	}
+	effects := &StateEffects{accounts: make([]accountEffect, 0, len(r.origins))}
+	for addr, origin := range r.origins {
+		if !origin.exists && !r.alive(addr) {
+			continue // Account touched, but not created
+		}
+		effect := accountEffect{address: addr}
+		if balance := r.inner.GetBalance(addr); balance.Lt(origin.balance) {
+			effect.balance = new(uint256.Int).Sub(origin.balance, balance)
+			effect.negative = true
+		} else if balance.Gt(origin.balance) {
+			effect.balance = new(uint256.Int).Sub(balance, origin.balance)
+		}
+		if nonce := r.inner.GetNonce(addr); nonce != origin.nonce {
+			effect.nonce = &nonce
+		}
+		if r.inner.GetCodeHash(addr) != origin.codeHash {
+			effect.code = r.inner.GetCode(addr)
+			effect.setCode = true
+		}
+		for slot, value := range origin.storage {
+			if current := r.inner.GetState(addr, slot); current != value {
+				if effect.storage == nil {
+					effect.storage = make(map[common.Hash]common.Hash)
+				}
+				effect.storage[slot] = current
+			}
+		}
+		effects.accounts = append(effects.accounts, effect)
+	}
+	slices.SortFunc(effects.accounts, func(a, b accountEffect) int {
+		return bytes.Compare(a.address[:], b.address[:])
+	})
+	return effects, nil
+}
+
+// StateEffects is the net change a transaction made to the state.
+type StateEffects struct {
+	accounts []accountEffect
+}
+
+// accountEffect is the net change a transaction made to an account.
+type accountEffect struct {
+	address  common.Address
+	balance  *uint256.Int // Absolute balance change, nil if unchanged
+	negative bool         // Whether the balance decreased
+	nonce    *uint64
+	code     []byte
+	setCode  bool
+	storage  map[common.Hash]common.Hash
+}
+
+// Apply replays the effects onto the given state. The caller is responsible
+// for finalising the state afterwards.
+func (e *StateEffects) Apply(s *StateDB) {
+	for _, effect := range e.accounts {
+		// Created accounts are not empty, so setting their fields creates them
+		if effect.balance != nil && effect.negative {
+			s.SubBalance(effect.address, effect.balance, tracing.BalanceChangeUnspecified)
+		} else if effect.balance != nil {
+			s.AddBalance(effect.address, effect.balance, tracing.BalanceChangeUnspecified)
+		}
+		if effect.nonce != nil {
+			s.SetNonce(effect.address, *effect.nonce, tracing.NonceChangeUnspecified)
+		}
+		if effect.setCode {
+			s.SetCode(effect.address, effect.code)
+		}
+		for slot, value := range effect.storage {
+			s.SetState(effect.address, slot, value)
+		}
+	}
+}
+
+func (r *AccessRecorder) CreateAccount(addr common.Address) {
+	if r.inner.Exist(addr) {
+		r.deleted[addr] = struct{}{}
+	}
+	r.write(AccountKey, addr)
+	r.inner.CreateAccount(addr)
+}
+
+func (r *AccessRecorder) CreateContract(addr common.Address) {
+	r.write(AccountKey, addr)
+	r.inner.CreateContract(addr)
+}
+
+func (r *AccessRecorder) GetBalance(addr common.Address) *uint256.Int {
+	r.read(BalanceKey, addr)
+	return r.inner.GetBalance(addr)
+}
+
+func (r *AccessRecorder) SetBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) {
+	r.read(BalanceKey, addr)
+	r.write(BalanceKey, addr)
+	r.inner.SetBalance(addr, amount, reason)
+}
+
+func (r *AccessRecorder) AccountAbsent(addr common.Address) bool {
+	r.read(AccountKey, addr)
+	return r.inner.AccountAbsent(addr)
+}
+
+func (r *AccessRecorder) GetNonce(addr common.Address) uint64 {
+	r.read(NonceKey, addr)
+	return r.inner.GetNonce(addr)
+}
+
+func (r *AccessRecorder) GetCodeHash(addr common.Address) common.Hash {
+	// The code hash of missing accounts differs from that of empty ones
+	r.read(AccountKey, addr)
+	r.read(CodeKey, addr)
+	return r.inner.GetCodeHash(addr)
+}
+
+func (r *AccessRecorder) GetCode(addr common.Address) []byte {
+	r.read(CodeKey, addr)
+	return r.inner.GetCode(addr)
+}
+
+func (r *AccessRecorder) GetCodeSize(addr common.Address) int {
+	r.read(CodeKey, addr)
+	return r.inner.GetCodeSize(addr)
+}
+
+func (r *AccessRecorder) AddRefund(gas uint64) {
+	r.inner.AddRefund(gas)
+}
+
+func (r *AccessRecorder) SubRefund(gas uint64) {
+	r.inner.SubRefund(gas)
+}
+
+func (r *AccessRecorder) GetRefund() uint64 {
+	return r.inner.GetRefund()
+}
+
+func (r *AccessRecorder) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	r.reads[StateKey{Kind: StorageKey, Address: addr, Slot: hash}] = struct{}{}
+	return r.inner.GetCommittedState(addr, hash)
+}
+
+func (r *AccessRecorder) GetState(addr common.Address, hash common.Hash) common.Hash {
+	r.reads[StateKey{Kind: StorageKey, Address: addr, Slot: hash}] = struct{}{}
+	return r.inner.GetState(addr, hash)
+}
+
+func (r *AccessRecorder) GetStorageRoot(addr common.Address) common.Hash {
+	r.read(StorageRootKey, addr)
+	return r.inner.GetStorageRoot(addr)
+}
+
+func (r *AccessRecorder) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	return r.inner.GetTransientState(addr, key)
+}
+
+func (r *AccessRecorder) SetTransientState(addr common.Address, key, value common.Hash) {
+	r.inner.SetTransientState(addr, key, value)
+}
+
+func (r *AccessRecorder) HasSelfDestructed(addr common.Address) bool {
+	r.read(AccountKey, addr)
+	return r.inner.HasSelfDestructed(addr)
+}
+
+func (r *AccessRecorder) Exist(addr common.Address) bool {
+	r.read(AccountKey, addr)
+	return r.inner.Exist(addr)
+}
+
+func (r *AccessRecorder) Empty(addr common.Address) bool {
+	r.read(AccountKey, addr)
+	r.read(BalanceKey, addr)
+	r.read(NonceKey, addr)
+	r.read(CodeKey, addr)
+	return r.inner.Empty(addr)
+}
+
+func (r *AccessRecorder) AddressInAccessList(addr common.Address) bool {
+	return r.inner.AddressInAccessList(addr)
+}
+
+func (r *AccessRecorder) SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool) {
+	return r.inner.SlotInAccessList(addr, slot)
+}
+
+func (r *AccessRecorder) AddAddressToAccessList(addr common.Address) {
+	r.inner.AddAddressToAccessList(addr)
+}
+
+func (r *AccessRecorder) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	r.inner.AddSlotToAccessList(addr, slot)
+}
+
+func (r *AccessRecorder) ClearAccessList() {
+	r.inner.ClearAccessList()
+}
+
+func (r *AccessRecorder) PointCache() *utils.PointCache {
+	return r.inner.PointCache()
+}
+
+func (r *AccessRecorder) Prepare(rules params.Rules, sender, coinbase common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	r.inner.Prepare(rules, sender, coinbase, dest, precompiles, txAccesses)
+}
+
+func (r *AccessRecorder) SetTxContext(thash common.Hash, ti int) {
+	r.inner.SetTxContext(thash, ti)
+}
+
+func (r *AccessRecorder) TxIndex() int {
+	return r.inner.TxIndex()
+}
+
+func (r *AccessRecorder) RevertToSnapshot(i int) {
+	r.inner.RevertToSnapshot(i)
+}
+
+func (r *AccessRecorder) Snapshot() int {
+	return r.inner.Snapshot()
+}
+
+func (r *AccessRecorder) AddPreimage(hash common.Hash, preimage []byte) {
+	r.inner.AddPreimage(hash, preimage)
+}
+
+func (r *AccessRecorder) Witness() *stateless.Witness {
+	return r.inner.Witness()
+}
+
+func (r *AccessRecorder) AccessEvents() *AccessEvents {
+	return r.inner.AccessEvents()
+}
+
+func (r *AccessRecorder) SubBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) uint256.Int {
+	r.write(BalanceKey, addr)
+	return r.inner.SubBalance(addr, amount, reason)
+}
+
+func (r *AccessRecorder) AddBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) uint256.Int {
+	r.write(BalanceKey, addr)
+	return r.inner.AddBalance(addr, amount, reason)
+}
+
+func (r *AccessRecorder) SetNonce(addr common.Address, nonce uint64, reason tracing.NonceChangeReason) {
+	r.write(NonceKey, addr)
+	r.inner.SetNonce(addr, nonce, reason)
+}
+
+func (r *AccessRecorder) SetCode(addr common.Address, code []byte) []byte {
+	r.write(CodeKey, addr)
+	return r.inner.SetCode(addr, code)
+}
+
+func (r *AccessRecorder) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	r.writes[StateKey{Kind: StorageKey, Address: addr, Slot: key}] = struct{}{}
+	r.write(StorageRootKey, addr)
+
+	prev := r.inner.SetState(addr, key, value)
+	if origin := r.origins[addr]; origin != nil {
+		if _, ok := origin.storage[key]; !ok {
+			origin.storage[key] = prev
+		}
+	}
+	return prev
+}
+
+func (r *AccessRecorder) SelfDestruct(addr common.Address) uint256.Int {
+	r.write(BalanceKey, addr)
+	r.deleted[addr] = struct{}{}
+	return r.inner.SelfDestruct(addr)
+}
+
+func (r *AccessRecorder) SelfDestruct6780(addr common.Address) (uint256.Int, bool) {
+	r.read(BalanceKey, addr)
+	r.write(BalanceKey, addr)
+	prev, destructed := r.inner.SelfDestruct6780(addr)
+	if destructed {
+		r.deleted[addr] = struct{}{}
+	}
+	return prev, destructed
+}
+
+func (r *AccessRecorder) NoTrie() bool {
+	return r.inner.NoTrie()
+}
+
+func (r *AccessRecorder) AddLog(log *types.Log) {
+	r.inner.AddLog(log)
+}
+
+func (r *AccessRecorder) GetLogs(hash common.Hash, blockNumber uint64, blockHash common.Hash) []*types.Log {
+	return r.inner.GetLogs(hash, blockNumber, blockHash)
+}
+
+func (r *AccessRecorder) Finalise(deleteEmptyObjects bool) {
+	r.inner.Finalise(deleteEmptyObjects)
+}
+
+func (r *AccessRecorder) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
+	return r.inner.IntermediateRoot(deleteEmptyObjects)
+}
+
+func (r *AccessRecorder) IsAddressInMutations(addr common.Address) bool {
+	return r.inner.IsAddressInMutations(addr)
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// Tests that recorded effects replayed onto a state modified in between give
+// the same result as executing the operations on it directly.
+func TestAccessRecorderEffects(t *testing.T) {
+	var (
+		a    = common.Address{0xa}
+		b    = common.Address{0xb}
+		slot = common.Hash{0x1}
+	)
+	base, _ := New(types.EmptyRootHash, NewDatabaseForTesting())
+	base.SetBalance(a, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	base.SetState(a, slot, common.Hash{0x1})
+	base.Finalise(true)
+
+	execute := func(s interface {
+		SubBalance(common.Address, *uint256.Int, tracing.BalanceChangeReason) uint256.Int
+		AddBalance(common.Address, *uint256.Int, tracing.BalanceChangeReason) uint256.Int
+		SetNonce(common.Address, uint64, tracing.NonceChangeReason)
+		GetState(common.Address, common.Hash) common.Hash
+		SetState(common.Address, common.Hash, common.Hash) common.Hash
+	}) {
+		s.SubBalance(a, uint256.NewInt(10), tracing.BalanceChangeUnspecified)
+		s.AddBalance(b, uint256.NewInt(10), tracing.BalanceChangeUnspecified)
+		s.SetNonce(a, 1, tracing.NonceChangeUnspecified)
+		s.SetState(a, common.Hash{0x2}, s.GetState(a, slot))
+	}
+	recorder := NewAccessRecorder(base.Copy())
+	execute(recorder)
+
+	if _, ok := recorder.Reads()[StateKey{Kind: StorageKey, Address: a, Slot: slot}]; !ok {
+		t.Errorf("storage read not recorded")
+	}
+	if _, ok := recorder.Reads()[StateKey{Kind: BalanceKey, Address: a}]; ok {
+		t.Errorf("balance change recorded as read")
+	}
+	writes := recorder.Writes()
+	if _, ok := writes[StateKey{Kind: AccountKey, Address: b}]; !ok {
+		t.Errorf("account creation not recorded")
+	}
+	if _, ok := writes[StateKey{Kind: AccountKey, Address: a}]; ok {
+		t.Errorf("account modification recorded as creation")
+	}
+	effects, err := recorder.Effects()
+	if err != nil {
+		t.Fatalf("failed to collect effects: %v", err)
+	}
+	// Credit the account by an unrelated transaction, which the recorded one
+	// does not conflict with.
+	serial := base.Copy()
+	serial.AddBalance(a, uint256.NewInt(5), tracing.BalanceChangeUnspecified)
+	serial.Finalise(true)
+
+	replayed := serial.Copy()
+	effects.Apply(replayed)
+	execute(serial)
+
+	if have, want := replayed.IntermediateRoot(true), serial.IntermediateRoot(true); have != want {
+		t.Errorf("replayed root mismatch: have %x, want %x", have, want)
+	}
+	if have := replayed.GetBalance(a); have.Uint64() != 95 {
+		t.Errorf("replayed balance mismatch: have %v, want 95", have)
+	}
+	// Destructing an account cannot be replayed
+	recorder.Reset()
+	recorder.SelfDestruct(a)
+	if _, err := recorder.Effects(); err == nil {
+		t.Errorf("effects of destructed account replayable")
+	}
+	if deleted := recorder.Deleted(); len(deleted) != 1 || deleted[0] != a {
+		t.Errorf("deleted accounts mismatch: have %v, want [%x]", deleted, a)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ContractChanges returns the changes of the contract storages and codes made
+// by the last commit, sorted by address.
+func (s *StateDB) ContractChanges() []*rawdb.ContractChange {
+	return s.contractChanges
+}
+
+// collectContractChanges derives the contract storage and code changes from
+// the account deletions and updates of a commit. Deletions are aggregated first,
+// as accounts might be destroyed and recreated within the same block.
+func (s *StateDB) collectContractChanges(deletes map[common.Hash]*accountDelete, updates map[common.Hash]*accountUpdate) []*rawdb.ContractChange {
+	changes := make(map[common.Address]*rawdb.ContractChange)
+	get := func(addr common.Address) *rawdb.ContractChange {
+		change, ok := changes[addr]
+		if !ok {
+			change = &rawdb.ContractChange{Address: addr}
+			changes[addr] = change
+		}
+		return change
+	}
+	for _, op := range deletes {
+		size := s.originCodeSize(op.address, op.origin)
+		if size == 0 && len(op.storagesOrigin) == 0 {
+			continue
+		}
+		change := get(op.address)
+		change.SlotsDeleted += uint64(len(op.storagesOrigin))
+		if size > 0 {
+			change.CodeChanged, change.PrevCodeSize = true, size
+		}
+	}
+	for _, op := range updates {
+		var created, deleted uint64
+		for hash, value := range op.storages {
+			origin := op.storagesOriginByHash[hash]
+			switch {
+			case len(origin) == 0 && len(value) > 0:
+				created++
+			case len(origin) > 0 && len(value) == 0:
+				deleted++
+			}
+		}
+		if op.code == nil && created == 0 && deleted == 0 {
+			continue
+		}
+		change := get(op.address)
+		change.SlotsCreated += created
+		change.SlotsDeleted += deleted
+		if op.code != nil {
+			if !change.CodeChanged {
+				change.PrevCodeSize = s.originCodeSize(op.address, op.origin)
+			}
+			change.CodeChanged, change.CodeSize = true, uint64(len(op.code.blob))
+		}
+	}
+	list := make([]*rawdb.ContractChange, 0, len(changes))
+	for _, change := range changes {
+		list = append(list, change)
+	}
+	slices.SortFunc(list, func(a, b *rawdb.ContractChange) int { return a.Address.Cmp(b.Address) })
+	return list
+}
+
+// originCodeSize returns the code size of an account given in its original
+// slim-RLP encoding, which is empty if the account did not exist.
+func (s *StateDB) originCodeSize(addr common.Address, origin []byte) uint64 {
+	if len(origin) == 0 {
+		return 0
+	}
+	account, err := types.FullAccount(origin)
+	if err != nil || common.BytesToHash(account.CodeHash) == types.EmptyCodeHash {
+		return 0
+	}
+	size, err := s.reader.CodeSize(addr, common.BytesToHash(account.CodeHash))
+	if err != nil {
+		return 0
+	}
+	return uint64(size)
+}
@@ -18,7 +18,9 @@ package state
 
 import (
 	"fmt"
+	"sync"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -150,11 +152,18 @@ type Trie interface {
 type CachingDB struct {
 	disk          ethdb.KeyValueStore
 	triedb        *triedb.Database
+	base          *triedb.Database // Trie database of the frozen merkle state, if transitioning into verkle
 	noTries       bool
 	snap          *snapshot.Tree
 	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
 	codeSizeCache *lru.Cache[common.Hash, int]
+	codeStore     *fastcache.Cache // Persistent code cache surviving restarts, nil if disabled
 	pointCache    *utils.PointCache
+
+	wipeLock  sync.RWMutex    // Held by the storage wipes while reading, exclusively by the commits while writing
+	wipeMu    sync.Mutex      // Orders the wipes and commits acquiring the wipe lock
+	wipeAbort chan struct{}   // Closed when a commit starts, aborting the running storage wipes
+	quit      <-chan struct{} // Closed when the owner shuts down, aborting the storage wipes
 }
 
 // NewDatabase creates a state database with the provided data sources.
@@ -172,6 +181,42 @@ func NewDatabase(triedb *triedb.Database, snap *snapshot.Tree) *CachingDB {
 	}
 }
 
+// CodeCache is a cache of contract codes and their sizes. As codes are keyed
+// by their hash, a single cache can be shared by the state databases of any
+// number of independent chains.
+type CodeCache struct {
+	codes *lru.SizeConstrainedCache[common.Hash, []byte]
+	sizes *lru.Cache[common.Hash, int]
+}
+
+// NewCodeCache creates a code cache of the default size.
+func NewCodeCache() *CodeCache {
+	return &CodeCache{
+		codes: lru.NewSizeConstrainedCache[common.Hash, []byte](codeCacheSize),
+		sizes: lru.NewCache[common.Hash, int](codeSizeCacheSize),
+	}
+}
+
+// SetCodeCache replaces the code caches of the database with the given shared
+// one. It must be called before the database is used by any reader.
+func (db *CachingDB) SetCodeCache(cache *CodeCache) {
+	db.codeCache, db.codeSizeCache = cache.codes, cache.sizes
+}
+
+// SetCodeStore attaches a persistent store of contract codes behind the code
+// cache, consulted before the database. It must be called before the database
+// is used by any reader.
+func (db *CachingDB) SetCodeStore(store *fastcache.Cache) {
+	db.codeStore = store
+}
+
+// SetInterrupt attaches the quit channel of the database owner, closing which
+// aborts the storage wipes running in the background. It must be called before
+// the database is used by any reader.
+func (db *CachingDB) SetInterrupt(quit <-chan struct{}) {
+	db.quit = quit
+}
+
 // NewDatabaseForTesting is similar to NewDatabase, but it initializes the caching
 // db by using an ephemeral memory db with default config for testing.
 func NewDatabaseForTesting() *CachingDB {
@@ -182,6 +227,15 @@ func NewDatabaseForTesting() *CachingDB {
 func (db *CachingDB) Reader(stateRoot common.Hash) (Reader, error) {
 	var readers []StateReader
 
+	// States in the verkle transition are split across two tries, which the
+	// flat state layers know nothing about.
+	if ts := db.TransitionState(stateRoot); ts != nil && !ts.Ended {
+		tr, err := newTransitionReader(db, stateRoot, ts)
+		if err != nil {
+			return nil, err
+		}
+		return newReader(newCachingCodeReader(db.disk, db.codeCache, db.codeSizeCache, db.codeStore), tr), nil
+	}
 	// Set up the state snapshot reader if available. This feature
 	// is optional and may be partially useful if it's not fully
 	// generated.
@@ -192,9 +246,10 @@ func (db *CachingDB) Reader(stateRoot common.Hash) (Reader, error) {
 		if snap != nil {
 			readers = append(readers, newFlatReader(snap))
 		}
-	} else {
+	} else if db.base == nil {
 		// If standalone state snapshot is not available, try to construct
-		// the state reader with database.
+		// the state reader with database. The leaves converted by the verkle
+		// transition are missing from it, so transition databases skip it.
 		reader, err := db.triedb.StateReader(stateRoot)
 		if err == nil {
 			readers = append(readers, newFlatReader(reader)) // state reader is optional
@@ -214,7 +269,7 @@ func (db *CachingDB) Reader(stateRoot common.Hash) (Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newReader(newCachingCodeReader(db.disk, db.codeCache, db.codeSizeCache), combined), nil
+	return newReader(newCachingCodeReader(db.disk, db.codeCache, db.codeSizeCache, db.codeStore), combined), nil
 }
 
 // OpenTrie opens the main account trie at a specific root hash.
@@ -223,6 +278,13 @@ func (db *CachingDB) OpenTrie(root common.Hash) (Trie, error) {
 		return trie.NewEmptyTrie(), nil
 	}
 	if db.triedb.IsVerkle() {
+		if ts := db.TransitionState(root); ts != nil && !ts.Ended {
+			tr, err := db.openTransitionTrie(root, ts)
+			if err != nil {
+				return nil, err
+			}
+			return tr, nil
+		}
 		return trie.NewVerkleTrie(root, db.triedb, db.pointCache)
 	}
 	tr, err := trie.NewStateTrie(trie.StateTrieID(root), db.triedb)
@@ -242,6 +304,13 @@ func (db *CachingDB) OpenStorageTrie(stateRoot common.Hash, address common.Addre
 	// is hardcoded in the codebase. So we need to return the same trie in this
 	// case.
 	if db.triedb.IsVerkle() {
+		if tr, ok := self.(*trie.TransitionTrie); ok {
+			st, err := db.openTransitionStorageTrie(address, tr)
+			if err != nil {
+				return nil, err
+			}
+			return st, nil
+		}
 		return self, nil
 	}
 	tr, err := trie.NewStateTrie(trie.StorageTrieID(stateRoot, crypto.Keccak256Hash(address.Bytes()), root), db.triedb)
@@ -298,6 +367,8 @@ func mustCopyTrie(t Trie) Trie {
 		return t.Copy()
 	case *trie.VerkleTrie:
 		return t.Copy()
+	case *trie.TransitionTrie:
+		return t.Copy()
 	default:
 		panic(fmt.Errorf("unknown trie type %T", t))
 	}
@@ -0,0 +1,58 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Husks returns the contracts which survived a SELFDESTRUCT and were left
+// without balance by the last commit, sorted by address.
+//
+// Since EIP-6780, SELFDESTRUCT only destroys the contracts created in the same
+// transaction. Older contracts merely hand their balance over to the beneficiary
+// and keep their code, nonce and storage, with nobody left to clean them up.
+func (s *StateDB) Husks() []common.Address {
+	return s.husks
+}
+
+// markSurvivor records that a contract survived a SELFDESTRUCT.
+func (s *StateDB) markSurvivor(addr common.Address) {
+	if _, ok := s.survivors[addr]; ok {
+		return
+	}
+	if s.survivors == nil {
+		s.survivors = make(map[common.Address]struct{})
+	}
+	s.journal.survive(addr)
+	s.survivors[addr] = struct{}{}
+}
+
+// collectHusks returns the contracts which survived a SELFDESTRUCT since the
+// last commit and are still alive without balance.
+func (s *StateDB) collectHusks() []common.Address {
+	var husks []common.Address
+	for addr := range s.survivors {
+		if obj := s.stateObjects[addr]; obj != nil && obj.Balance().IsZero() {
+			husks = append(husks, addr)
+		}
+	}
+	slices.SortFunc(husks, common.Address.Cmp)
+	return husks
+}
@@ -187,6 +187,10 @@ func (j *journal) destruct(addr common.Address) {
 	j.append(selfDestructChange{account: addr})
 }
 
+func (j *journal) survive(addr common.Address) {
+	j.append(surviveChange{account: addr})
+}
+
 func (j *journal) storageChange(addr common.Address, key, prev, origin common.Hash) {
 	entry := storageChangePool.Get().(*storageChange)
 	entry.account = addr
@@ -265,6 +269,12 @@ type (
 	selfDestructChange struct {
 		account common.Address
 	}
+	// surviveChange represents a contract surviving a SELFDESTRUCT under the
+	// EIP-6780 rules. The journal-event manages the tracking of the husks left
+	// behind by such contracts.
+	surviveChange struct {
+		account common.Address
+	}
 
 	// Changes to individual accounts.
 	balanceChange struct {
@@ -358,6 +368,20 @@ func (ch selfDestructChange) copy() journalEntry {
 	}
 }
 
+func (ch surviveChange) revert(s *StateDB) {
+	delete(s.survivors, ch.account)
+}
+
+func (ch surviveChange) dirtied() *common.Address {
+	return nil
+}
+
+func (ch surviveChange) copy() journalEntry {
+	return surviveChange{
+		account: ch.account,
+	}
+}
+
 var ripemd = common.HexToAddress("0000000000000000000000000000000000000003")
 
 func (ch touchChange) revert(s *StateDB) {
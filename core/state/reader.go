@@ -19,6 +19,7 @@ package state
 import (
 	"errors"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -75,6 +76,15 @@ type Reader interface {
 	StateReader
 }
 
+// flatAccountReader is implemented by the state readers able to resolve accounts
+// from the flat state alone, without falling back to the tries.
+type flatAccountReader interface {
+	// flatAccount retrieves the account from the flat state, which is nil if it
+	// doesn't exist. False is returned if the flat state is unavailable or
+	// doesn't cover the account yet.
+	flatAccount(addr common.Address) (*types.StateAccount, bool)
+}
+
 // cachingCodeReader implements ContractCodeReader, accessing contract code either in
 // local key-value store or the shared code cache.
 type cachingCodeReader struct {
@@ -84,14 +94,18 @@ type cachingCodeReader struct {
 	// they are natively thread-safe.
 	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
 	codeSizeCache *lru.Cache[common.Hash, int]
+
+	// The optional persistent store backing the caches, surviving restarts.
+	codeStore *fastcache.Cache
 }
 
 // newCachingCodeReader constructs the code reader.
-func newCachingCodeReader(db ethdb.KeyValueReader, codeCache *lru.SizeConstrainedCache[common.Hash, []byte], codeSizeCache *lru.Cache[common.Hash, int]) *cachingCodeReader {
+func newCachingCodeReader(db ethdb.KeyValueReader, codeCache *lru.SizeConstrainedCache[common.Hash, []byte], codeSizeCache *lru.Cache[common.Hash, int], codeStore *fastcache.Cache) *cachingCodeReader {
 	return &cachingCodeReader{
 		db:            db,
 		codeCache:     codeCache,
 		codeSizeCache: codeSizeCache,
+		codeStore:     codeStore,
 	}
 }
 
@@ -102,10 +116,24 @@ func (r *cachingCodeReader) Code(addr common.Address, codeHash common.Hash) ([]b
 	if len(code) > 0 {
 		return code, nil
 	}
+	if r.codeStore != nil {
+		if code, ok := r.codeStore.HasGet(nil, codeHash[:]); ok && len(code) > 0 {
+			// The store is loaded from disk, drop any entry not matching its hash
+			if crypto.Keccak256Hash(code) == codeHash {
+				r.codeCache.Add(codeHash, code)
+				r.codeSizeCache.Add(codeHash, len(code))
+				return code, nil
+			}
+			r.codeStore.Del(codeHash[:])
+		}
+	}
 	code = rawdb.ReadCode(r.db, codeHash)
 	if len(code) > 0 {
 		r.codeCache.Add(codeHash, code)
 		r.codeSizeCache.Add(codeHash, len(code))
+		if r.codeStore != nil {
+			r.codeStore.Set(codeHash[:], code)
+		}
 	}
 	return code, nil
 }
@@ -166,6 +194,12 @@ func (r *flatReader) Account(addr common.Address) (*types.StateAccount, error) {
 	return acct, nil
 }
 
+// flatAccount implements flatAccountReader.
+func (r *flatReader) flatAccount(addr common.Address) (*types.StateAccount, bool) {
+	account, err := r.Account(addr)
+	return account, err == nil
+}
+
 // Storage implements StateReader, retrieving the storage slot specified by the
 // address and slot key.
 //
@@ -327,6 +361,17 @@ func (r *multiStateReader) Account(addr common.Address) (*types.StateAccount, er
 	return nil, errors.Join(errs...)
 }
 
+// flatAccount implements flatAccountReader, consulting the first reader backed
+// by the flat state.
+func (r *multiStateReader) flatAccount(addr common.Address) (*types.StateAccount, bool) {
+	for _, reader := range r.readers {
+		if flat, ok := reader.(*flatReader); ok {
+			return flat.flatAccount(addr)
+		}
+	}
+	return nil, false
+}
+
 // Storage implementing StateReader interface, retrieving the storage slot
 // associated with a particular account address and slot key.
 //
@@ -358,3 +403,11 @@ func newReader(codeReader ContractCodeReader, stateReader StateReader) *reader {
 		StateReader:        stateReader,
 	}
 }
+
+// flatAccount implements flatAccountReader if the wrapped state reader does.
+func (r *reader) flatAccount(addr common.Address) (*types.StateAccount, bool) {
+	if flat, ok := r.StateReader.(flatAccountReader); ok {
+		return flat.flatAccount(addr)
+	}
+	return nil, false
+}
@@ -72,19 +72,58 @@ func (gs *generatorStats) Log(msg string, root common.Hash, marker []byte) {
 		"elapsed", common.PrettyDuration(time.Since(gs.start)),
 	}...)
 	// Calculate the estimated indexing time based on current stats
-	if len(marker) > 0 {
-		if done := binary.BigEndian.Uint64(marker[:8]) - gs.origin; done > 0 {
-			left := math.MaxUint64 - binary.BigEndian.Uint64(marker[:8])
-
-			speed := done/uint64(time.Since(gs.start)/time.Millisecond+1) + 1 // +1s to avoid division by zero
-			ctx = append(ctx, []interface{}{
-				"eta", common.PrettyDuration(time.Duration(left/speed) * time.Millisecond),
-			}...)
-		}
+	if eta, ok := gs.eta(marker); ok {
+		ctx = append(ctx, []interface{}{"eta", common.PrettyDuration(eta)}...)
 	}
 	log.Info(msg, ctx...)
 }
 
+// eta estimates the time left to finish the generation from the given marker,
+// based on the speed the key space was covered since the generation started.
+func (gs *generatorStats) eta(marker []byte) (time.Duration, bool) {
+	if len(marker) < 8 {
+		return 0, false
+	}
+	done := binary.BigEndian.Uint64(marker[:8]) - gs.origin
+	if done == 0 {
+		return 0, false
+	}
+	left := math.MaxUint64 - binary.BigEndian.Uint64(marker[:8])
+
+	speed := done/uint64(time.Since(gs.start)/time.Millisecond+1) + 1 // +1s to avoid division by zero
+	return time.Duration(left/speed) * time.Millisecond, true
+}
+
+// status assembles the externally visible generation progress at the given
+// marker. It must be called from the generator or while it's suspended.
+func (gs *generatorStats) status(marker []byte) *GenerationStatus {
+	status := &GenerationStatus{
+		Done:     marker == nil,
+		Accounts: gs.accounts,
+		Slots:    gs.slots,
+		Storage:  gs.storage,
+		Dangling: gs.dangling,
+		Marker:   common.CopyBytes(marker),
+		Started:  gs.start,
+	}
+	if !status.Done {
+		status.ETA, _ = gs.eta(marker)
+	}
+	return status
+}
+
+// GenerationStatus is the progress of the background snapshot generation.
+type GenerationStatus struct {
+	Done     bool               // Whether the snapshot is fully generated
+	Accounts uint64             // Number of accounts indexed
+	Slots    uint64             // Number of storage slots indexed
+	Storage  common.StorageSize // Total size of the indexed accounts and slots
+	Dangling uint64             // Number of dangling storage slots removed
+	Marker   []byte             // Position of the generator, account hash optionally followed by slot hash
+	Started  time.Time          // Time the current generation run started
+	ETA      time.Duration      // Estimated time left, zero if unknown
+}
+
 // generatorContext carries a few global values to be shared by all generation functions.
 type generatorContext struct {
 	stats   *generatorStats     // Generation statistic collection
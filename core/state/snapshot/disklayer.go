@@ -41,6 +41,8 @@ type diskLayer struct {
 	genMarker  []byte                    // Marker for the state that's indexed during initial layer generation
 	genPending chan struct{}             // Notification channel when generation is done (test synchronicity)
 	genAbort   chan chan *generatorStats // Notification channel to abort generating the snapshot in this layer
+	genPaused  *generatorStats           // Progress of a paused generation, nil if not paused
+	genStatus  *GenerationStatus         // Generation progress as of the last flush, nil if unknown
 
 	lock sync.RWMutex
 }
@@ -75,6 +75,7 @@ func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, cache
 		genMarker:  genMarker,
 		genPending: make(chan struct{}),
 		genAbort:   make(chan chan *generatorStats),
+		genStatus:  stats.status(genMarker),
 	}
 	go base.generate(stats)
 	log.Debug("Start snapshot generation", "root", root)
@@ -488,6 +489,7 @@ func (dl *diskLayer) checkAndFlush(ctx *generatorContext, current []byte) error
 
 		dl.lock.Lock()
 		dl.genMarker = current
+		dl.genStatus = ctx.stats.status(current)
 		dl.lock.Unlock()
 
 		if abort != nil {
@@ -698,6 +700,7 @@ func (dl *diskLayer) generate(stats *generatorStats) {
 
 	dl.lock.Lock()
 	dl.genMarker = nil
+	dl.genStatus = stats.status(nil)
 	close(dl.genPending)
 	dl.lock.Unlock()
 
@@ -85,6 +85,86 @@ func testGeneration(t *testing.T, scheme string) {
 	<-stop
 }
 
+// Tests that the generation status reports the completed generation along with
+// the number of accounts and slots generated.
+func TestGenerationStatus(t *testing.T) {
+	testGenerationStatus(t, rawdb.HashScheme)
+	testGenerationStatus(t, rawdb.PathScheme)
+}
+
+func testGenerationStatus(t *testing.T, scheme string) {
+	var helper = newHelper(scheme)
+	stRoot := helper.makeStorageTrie("", []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, false)
+
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: uint256.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: uint256.NewInt(2), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-3", &types.StateAccount{Balance: uint256.NewInt(3), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+
+	helper.makeStorageTrie("acc-1", []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+	helper.makeStorageTrie("acc-3", []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Snapshot generation failed")
+	}
+	checkSnapRoot(t, snap, root)
+
+	snap.lock.RLock()
+	status := snap.genStatus
+	snap.lock.RUnlock()
+	if status == nil || !status.Done || status.Marker != nil {
+		t.Errorf("generation status not completed: %+v", status)
+	} else if status.Accounts != 3 || status.Slots != 6 {
+		t.Errorf("generation status mismatch: have %d accounts %d slots, want 3 accounts 6 slots", status.Accounts, status.Slots)
+	}
+	// Signal abortion to the generator and wait for it to tear down
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+}
+
+// Tests that a paused generation is resumed from its progress and completes.
+func TestGenerationPause(t *testing.T) {
+	testGenerationPause(t, rawdb.HashScheme)
+	testGenerationPause(t, rawdb.PathScheme)
+}
+
+func testGenerationPause(t *testing.T, scheme string) {
+	var helper = newHelper(scheme)
+	for i := 0; i < 1000; i++ {
+		helper.addTrieAccount(fmt.Sprintf("acc-%d", i), &types.StateAccount{Balance: uint256.NewInt(uint64(i)), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	}
+	root, snap := helper.CommitAndGenerate()
+	snaps := &Tree{layers: map[common.Hash]snapshot{root: snap}}
+
+	snaps.PauseGeneration()
+	snap.lock.RLock()
+	abort := snap.genAbort
+	snap.lock.RUnlock()
+	if abort != nil {
+		t.Fatal("generator still running after pause")
+	}
+	snaps.ResumeGeneration()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Snapshot generation failed")
+	}
+	checkSnapRoot(t, snap, root)
+
+	// Repeated pausing of a completed generation tears the generator down
+	snaps.PauseGeneration()
+	snaps.ResumeGeneration()
+	snap.lock.RLock()
+	abort = snap.genAbort
+	snap.lock.RUnlock()
+	if abort != nil {
+		t.Fatal("completed generation restarted")
+	}
+}
+
 // Tests that snapshot generation with existent flat state.
 func TestGenerateExistentState(t *testing.T) {
 	testGenerateExistentState(t, rawdb.HashScheme)
@@ -198,23 +198,25 @@ func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, root comm
 			base.genMarker = []byte{}
 		}
 	}
+	var origin uint64
+	if len(generator.Marker) >= 8 {
+		origin = binary.BigEndian.Uint64(generator.Marker)
+	}
+	stats := &generatorStats{
+		origin:   origin,
+		start:    time.Now(),
+		accounts: generator.Accounts,
+		slots:    generator.Slots,
+		storage:  common.StorageSize(generator.Storage),
+	}
+	base.genStatus = stats.status(base.genMarker)
+
 	// Everything loaded correctly, resume any suspended operations
 	// if the background generation is allowed
 	if !generator.Done && !noBuild {
 		base.genPending = make(chan struct{})
 		base.genAbort = make(chan chan *generatorStats)
-
-		var origin uint64
-		if len(generator.Marker) >= 8 {
-			origin = binary.BigEndian.Uint64(generator.Marker)
-		}
-		go base.generate(&generatorStats{
-			origin:   origin,
-			start:    time.Now(),
-			accounts: generator.Accounts,
-			slots:    generator.Slots,
-			storage:  common.StorageSize(generator.Storage),
-		})
+		go base.generate(stats)
 	}
 	return snapshot, false, nil
 }
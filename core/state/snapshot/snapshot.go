@@ -22,7 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -635,6 +637,10 @@ func diffToDisk(bottom *diffLayer) *diskLayer {
 		triedb:     base.triedb,
 		genMarker:  base.genMarker,
 		genPending: base.genPending,
+		genStatus:  base.genStatus,
+	}
+	if stats != nil {
+		res.genStatus = stats.status(base.genMarker)
 	}
 	// If snapshot generation hasn't finished yet, port over all the starts and
 	// continue where the previous round left off.
@@ -649,6 +655,68 @@ func diffToDisk(bottom *diffLayer) *diskLayer {
 	return res
 }
 
+// PauseGeneration suspends the background generation of the snapshot, if it's
+// running, until ResumeGeneration is called.
+func (t *Tree) PauseGeneration() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	dl := t.disklayer()
+	if dl == nil || dl.genAbort == nil {
+		return
+	}
+	abort := make(chan *generatorStats)
+	dl.genAbort <- abort
+	stats := <-abort
+
+	dl.lock.Lock()
+	dl.genAbort, dl.genPaused = nil, stats
+	dl.lock.Unlock()
+}
+
+// ResumeGeneration continues a paused background generation of the snapshot.
+func (t *Tree) ResumeGeneration() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	dl := t.disklayer()
+	if dl == nil {
+		return
+	}
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if dl.genAbort != nil || dl.genMarker == nil {
+		return
+	}
+	stats := dl.genPaused
+	if stats == nil {
+		stats = &generatorStats{start: time.Now()}
+	}
+	dl.genAbort, dl.genPaused = make(chan chan *generatorStats), nil
+	go dl.generate(stats)
+}
+
+// ResizeCache replaces the read cache of the disk layer with an empty one of the
+// given size in megabytes, which must be positive.
+func (t *Tree) ResizeCache(size int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.config.CacheSize = size
+
+	dl := t.disklayer()
+	if dl == nil {
+		return
+	}
+	dl.lock.Lock()
+	old := dl.cache
+	dl.cache = fastcache.New(size * 1024 * 1024)
+	dl.lock.Unlock()
+
+	old.Reset()
+}
+
 // Release releases resources
 func (t *Tree) Release() {
 	t.lock.RLock()
@@ -845,6 +913,34 @@ func (t *Tree) generating() (bool, error) {
 	return layer.genMarker != nil, nil
 }
 
+// Generating reports whether the snapshot is still under construction. False
+// is returned if there's no disk layer at all, e.g. when snapshots are disabled.
+func (t *Tree) Generating() bool {
+	generating, err := t.generating()
+	return err == nil && generating
+}
+
+// GenerationStatus returns the progress of the snapshot generation in the disk
+// layer, as of the last batch flushed by the generator. An error is returned if
+// there's no disk layer at all, e.g. when snapshots are disabled.
+func (t *Tree) GenerationStatus() (*GenerationStatus, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	layer := t.disklayer()
+	if layer == nil {
+		return nil, errors.New("disk layer is missing")
+	}
+	layer.lock.RLock()
+	defer layer.lock.RUnlock()
+
+	if layer.genStatus == nil {
+		return &GenerationStatus{Done: layer.genMarker == nil, Marker: common.CopyBytes(layer.genMarker)}, nil
+	}
+	status := *layer.genStatus
+	return &status, nil
+}
+
 // DiskRoot is an external helper function to return the disk layer root.
 func (t *Tree) DiskRoot() common.Hash {
 	t.lock.RLock()
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
 	"github.com/holiman/uint256"
 )
@@ -535,6 +536,14 @@ func (s *stateObject) SetBalance(amount *uint256.Int) uint256.Int {
 }
 
 func (s *stateObject) setBalance(amount *uint256.Int) {
+	// Account for the supply change, journal reverts included. A nil amount
+	// (e.g. an allocation without balance) counts as zero.
+	if amount != nil {
+		s.db.supplyDelta.Add(&s.db.supplyDelta, amount)
+	}
+	if s.data.Balance != nil {
+		s.db.supplyDelta.Sub(&s.db.supplyDelta, s.data.Balance)
+	}
 	s.data.Balance = amount
 }
 
@@ -556,6 +565,14 @@ func (s *stateObject) deepCopy(db *StateDB) *stateObject {
 	}
 	if s.trie != nil {
 		obj.trie = mustCopyTrie(s.trie)
+
+		// Storage tries of a transitioning state write into the overlay of the
+		// account trie, which must be the one of the copied state.
+		if tr, ok := s.trie.(*trie.TransitionTrie); ok {
+			if main, ok := db.trie.(*trie.TransitionTrie); ok {
+				obj.trie = main.StorageTrie(tr.Base().Copy())
+			}
+		}
 	}
 	return obj
 }
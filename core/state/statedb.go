@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"math/big"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -93,6 +94,8 @@ type StateDB struct {
 	originalRoot common.Hash
 	expectedRoot common.Hash // The state root in the block header
 
+	transition *rawdb.VerkleTransitionState // Progress of the verkle transition, nil if not transitioning
+
 	fullProcessed bool
 
 	// This map holds 'live' objects, which will get modified while
@@ -106,12 +109,21 @@ type StateDB struct {
 	// boundaries.
 	stateObjectsDestruct map[common.Address]*stateObject
 
+	// This map holds the accounts known to be missing from the state reader,
+	// so that the lookups of fresh accounts, e.g. the nonce and balance checks
+	// of a transaction followed by its execution, hit the database only once.
+	stateObjectsAbsent map[common.Address]struct{}
+
 	// This map tracks the account mutations that occurred during the
 	// transition. Uncommitted mutations belonging to the same account
 	// can be merged into a single one which is equivalent from database's
 	// perspective. This map is populated at the transaction boundaries.
 	mutations map[common.Address]*mutation
 
+	// Wipes of the original storage of destructed accounts, gathered in the
+	// background from the moment the destruction is finalised.
+	storageWipes map[common.Address]*storageWipe
+
 	// if needBadSharedStorage = true, try read from sharedPool firstly, compatible with old erroneous data(https://forum.bnbchain.org/t/about-the-hertzfix/2400).
 	// else read from sharedPool which is not in stateObjectsDestruct.
 	needBadSharedStorage bool
@@ -130,6 +142,18 @@ type StateDB struct {
 	// The refund counter, also used by state transitioning.
 	refund uint64
 
+	// The net change of the native supply caused by the balance mutations, in
+	// two's complement to stay in 256 bit arithmetic on the balance hot path.
+	supplyDelta uint256.Int
+
+	// The contract storage and code changes made by the last commit.
+	contractChanges []*rawdb.ContractChange
+
+	// The contracts surviving a SELFDESTRUCT since the last commit, and the
+	// ones left without balance by the last commit.
+	survivors map[common.Address]struct{}
+	husks     []common.Address
+
 	// The tx context and all occurred logs in the scope of transaction.
 	thash   common.Hash
 	txIndex int
@@ -153,6 +177,9 @@ type StateDB struct {
 	// State witness if cross validation is needed
 	witness *stateless.Witness // TODO(Nathan): more define the relation with `noTrie`
 
+	// Whether the accessed state must cover the reads of speculative executions
+	completeAccesses bool
+
 	// Measurements gathered during execution for debugging purposes
 	// MetricsMux should be used in more places, but will affect on performance, so following meteration is not accurate
 	MetricsMux      sync.Mutex
@@ -203,7 +230,9 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 		reader:               reader,
 		stateObjects:         make(map[common.Address]*stateObject, defaultNumOfSlots),
 		stateObjectsDestruct: make(map[common.Address]*stateObject, defaultNumOfSlots),
+		stateObjectsAbsent:   make(map[common.Address]struct{}),
 		mutations:            make(map[common.Address]*mutation, defaultNumOfSlots),
+		storageWipes:         make(map[common.Address]*storageWipe),
 		logs:                 make(map[common.Hash][]*types.Log),
 		preimages:            make(map[common.Hash][]byte),
 		journal:              newJournal(),
@@ -213,6 +242,9 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	if db.TrieDB().IsVerkle() {
 		sdb.accessEvents = NewAccessEvents(db.PointCache())
 	}
+	if cdb, ok := db.(*CachingDB); ok {
+		sdb.transition = cdb.TransitionState(root)
+	}
 	return sdb, nil
 }
 
@@ -250,7 +282,9 @@ func (s *StateDB) TransferPrefetcher(prev *StateDB) {
 // state trie concurrently while the state is mutated so that when we reach the
 // commit phase, most of the needed data is already hot.
 func (s *StateDB) StartPrefetcher(namespace string, witness *stateless.Witness) {
-	if s.noTrie {
+	// The prefetched tries would replace the transition trie holding the leaves
+	// converted into verkle, don't prefetch while converting.
+	if s.noTrie || s.converting() {
 		return
 	}
 
@@ -407,6 +441,44 @@ func (s *StateDB) Exist(addr common.Address) bool {
 	return s.getStateObject(addr) != nil
 }
 
+// AccountAbsent reports whether the account is known not to exist, as a cheap
+// pre-check before looking up the nonce, balance or code of brand new accounts.
+// Only the flat state is consulted: false is returned if it's unavailable or
+// doesn't cover the account, in which case the regular lookups must be used.
+// An existing account found in the flat state is loaded for the lookups.
+func (s *StateDB) AccountAbsent(addr common.Address) bool {
+	if s.stateObjects[addr] != nil {
+		return false
+	}
+	if _, ok := s.stateObjectsDestruct[addr]; ok {
+		return true
+	}
+	if _, ok := s.stateObjectsAbsent[addr]; ok {
+		return true
+	}
+	flat, ok := s.reader.(flatAccountReader)
+	if !ok {
+		return false
+	}
+	s.AccountLoaded++
+
+	start := time.Now()
+	acct, ok := flat.flatAccount(addr)
+	if metrics.EnabledExpensive() {
+		s.AccountReads += time.Since(start)
+	}
+	switch {
+	case !ok:
+		return false
+	case acct == nil:
+		s.stateObjectsAbsent[addr] = struct{}{}
+		return true
+	default:
+		s.loadStateObject(addr, acct)
+		return false
+	}
+}
+
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (s *StateDB) Empty(addr common.Address) bool {
@@ -635,6 +707,7 @@ func (s *StateDB) SelfDestruct6780(addr common.Address) (uint256.Int, bool) {
 	if stateObject.newContract {
 		return s.SelfDestruct(addr), true
 	}
+	s.markSurvivor(addr)
 	return *(stateObject.Balance()), false
 }
 
@@ -704,6 +777,10 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 	if _, ok := s.stateObjectsDestruct[addr]; ok {
 		return nil
 	}
+	// Short circuit if the account is already known to be missing
+	if _, ok := s.stateObjectsAbsent[addr]; ok {
+		return nil
+	}
 	s.AccountLoaded++
 
 	start := time.Now()
@@ -718,15 +795,20 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 
 	// Short circuit if the account is not found
 	if acct == nil {
+		s.stateObjectsAbsent[addr] = struct{}{}
 		return nil
 	}
-	// Schedule the resolved account for prefetching if it's enabled.
+	return s.loadStateObject(addr, acct)
+}
+
+// loadStateObject inserts an account resolved from the database into the live
+// set, scheduling it for prefetching if it's enabled.
+func (s *StateDB) loadStateObject(addr common.Address, acct *types.StateAccount) *stateObject {
 	if s.prefetcher != nil {
-		if err = s.prefetcher.prefetch(common.Hash{}, s.originalRoot, common.Address{}, []common.Address{addr}, nil, true); err != nil {
+		if err := s.prefetcher.prefetch(common.Hash{}, s.originalRoot, common.Address{}, []common.Address{addr}, nil, true); err != nil {
 			log.Error("Failed to prefetch account", "addr", addr, "err", err)
 		}
 	}
-	// Insert into the live set
 	obj := newObject(s, addr, acct)
 	s.setStateObject(obj)
 	return obj
@@ -801,17 +883,20 @@ func (s *StateDB) copyInternal(doPrefetch bool) *StateDB {
 		// fullProcessed:        s.fullProcessed,
 		stateObjects:         make(map[common.Address]*stateObject, len(s.journal.dirties)),
 		stateObjectsDestruct: make(map[common.Address]*stateObject, len(s.stateObjectsDestruct)),
+		stateObjectsAbsent:   maps.Clone(s.stateObjectsAbsent),
 		mutations:            make(map[common.Address]*mutation, len(s.mutations)),
 		dbErr:                s.dbErr,
 		needBadSharedStorage: s.needBadSharedStorage,
 		writeOnSharedStorage: s.writeOnSharedStorage,
 		storagePool:          s.storagePool,
 		refund:               s.refund,
+		supplyDelta:          s.supplyDelta,
 		thash:                s.thash,
 		txIndex:              s.txIndex,
 		logs:                 make(map[common.Hash][]*types.Log, len(s.logs)),
 		logSize:              s.logSize,
 		preimages:            maps.Clone(s.preimages),
+		survivors:            maps.Clone(s.survivors),
 
 		transientStorage: s.transientStorage.Copy(),
 		journal:          s.journal.copy(),
@@ -819,6 +904,10 @@ func (s *StateDB) copyInternal(doPrefetch bool) *StateDB {
 	if s.witness != nil {
 		state.witness = s.witness.Copy()
 	}
+	if s.transition != nil {
+		transition := *s.transition
+		state.transition = &transition
+	}
 	// Do we need to copy the access list and transient storage?
 	// In practice: No. At the start of a transaction, these two lists are empty.
 	// In practice, we only ever copy state _between_ transactions/blocks, never
@@ -843,6 +932,8 @@ func (s *StateDB) copyInternal(doPrefetch bool) *StateDB {
 	for addr, op := range s.mutations {
 		state.mutations[addr] = op.copy()
 	}
+	// The storage wipes in progress are not shared, as their results are handed
+	// over to the commit. The copy gathers its own wipes if it commits.
 	// Deep copy the logs occurred in the scope of block
 	for hash, logs := range s.logs {
 		cpy := make([]*types.Log, len(logs))
@@ -878,6 +969,16 @@ func (s *StateDB) GetRefund() uint64 {
 	return s.refund
 }
 
+// SupplyDelta returns the net change of the native supply caused by the balance
+// mutations applied to the state. Reverted mutations cancel out, so after the
+// execution of a block this is the supply issued (or burnt, if negative) by it.
+func (s *StateDB) SupplyDelta() *big.Int {
+	if s.supplyDelta.Sign() < 0 {
+		return new(big.Int).Neg(new(uint256.Int).Neg(&s.supplyDelta).ToBig())
+	}
+	return s.supplyDelta.ToBig()
+}
+
 // Finalise finalises the state by removing the destructed objects and clears
 // the journal as well as the refunds. Finalise, however, will not push any updates
 // into the tries just yet. Only IntermediateRoot or Commit will do that.
@@ -902,6 +1003,7 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 			// event is tracked.
 			if _, ok := s.stateObjectsDestruct[obj.address]; !ok {
 				s.stateObjectsDestruct[obj.address] = obj
+				s.wipeStorage(obj.address, obj.origin)
 			}
 		} else {
 			obj.finalise()
@@ -1117,7 +1219,7 @@ func (s *StateDB) clearJournalAndRefund() {
 // of a specific account. It leverages the associated state snapshot for fast
 // storage iteration and constructs trie node deletion markers by creating
 // stack trie with iterated slots.
-func (s *StateDB) fastDeleteStorage(snaps *snapshot.Tree, addrHash common.Hash, root common.Hash) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
+func (s *StateDB) fastDeleteStorage(snaps *snapshot.Tree, addrHash common.Hash, root common.Hash, interrupt wipeInterrupt) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
 	iter, err := snaps.StorageIterator(s.originalRoot, addrHash, common.Hash{})
 	if err != nil {
 		return nil, nil, nil, err
@@ -1133,6 +1235,9 @@ func (s *StateDB) fastDeleteStorage(snaps *snapshot.Tree, addrHash common.Hash,
 		nodes.AddNode(path, trienode.NewDeleted())
 	})
 	for iter.Next() {
+		if interrupt.aborted() {
+			return nil, nil, nil, errStorageWipeAborted
+		}
 		slot := common.CopyBytes(iter.Slot())
 		if err := iter.Error(); err != nil { // error might occur after Slot function
 			return nil, nil, nil, err
@@ -1157,7 +1262,7 @@ func (s *StateDB) fastDeleteStorage(snaps *snapshot.Tree, addrHash common.Hash,
 // slowDeleteStorage serves as a less-efficient alternative to "fastDeleteStorage,"
 // employed when the associated state snapshot is not available. It iterates the
 // storage slots along with all internal trie nodes via trie directly.
-func (s *StateDB) slowDeleteStorage(addr common.Address, addrHash common.Hash, root common.Hash) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
+func (s *StateDB) slowDeleteStorage(addr common.Address, addrHash common.Hash, root common.Hash, interrupt wipeInterrupt) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
 	tr, err := s.db.OpenStorageTrie(s.originalRoot, addr, root, s.trie)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to open storage trie, err: %w", err)
@@ -1176,6 +1281,9 @@ func (s *StateDB) slowDeleteStorage(addr common.Address, addrHash common.Hash, r
 		storageOrigins = make(map[common.Hash][]byte)  // the set for tracking the original value of slot
 	)
 	for it.Next(true) {
+		if interrupt.aborted() {
+			return nil, nil, nil, errStorageWipeAborted
+		}
 		if it.Leaf() {
 			key := common.BytesToHash(it.LeafKey())
 			storages[key] = nil
@@ -1196,8 +1304,8 @@ func (s *StateDB) slowDeleteStorage(addr common.Address, addrHash common.Hash, r
 // deleteStorage is designed to delete the storage trie of a designated account.
 // The function will make an attempt to utilize an efficient strategy if the
 // associated state snapshot is reachable; otherwise, it will resort to a less
-// efficient approach.
-func (s *StateDB) deleteStorage(addr common.Address, addrHash common.Hash, root common.Hash) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
+// efficient approach. The deletion is abandoned once the interrupt is signalled.
+func (s *StateDB) deleteStorage(addr common.Address, addrHash common.Hash, root common.Hash, interrupt wipeInterrupt) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
 	var (
 		err            error
 		nodes          *trienode.NodeSet      // the set for trie node mutations (value is nil)
@@ -1209,10 +1317,10 @@ func (s *StateDB) deleteStorage(addr common.Address, addrHash common.Hash, root
 	// one just in case.
 	snaps := s.db.Snapshot()
 	if snaps != nil {
-		storages, storageOrigins, nodes, err = s.fastDeleteStorage(snaps, addrHash, root)
+		storages, storageOrigins, nodes, err = s.fastDeleteStorage(snaps, addrHash, root, interrupt)
 	}
-	if snaps == nil || err != nil {
-		storages, storageOrigins, nodes, err = s.slowDeleteStorage(addr, addrHash, root)
+	if snaps == nil || (err != nil && !errors.Is(err, errStorageWipeAborted)) {
+		storages, storageOrigins, nodes, err = s.slowDeleteStorage(addr, addrHash, root, interrupt)
 	}
 	if err != nil {
 		return nil, nil, nil, err
@@ -1271,7 +1379,7 @@ func (s *StateDB) handleDestruction(noStorageWiping bool) (map[common.Hash]*acco
 			return nil, nil, fmt.Errorf("unexpected storage wiping, %x", addr)
 		}
 		// Remove storage slots belonging to the account.
-		storages, storagesOrigin, set, err := s.deleteStorage(addr, addrHash, prev.Root)
+		storages, storagesOrigin, set, err := s.waitStorageWipe(addr, addrHash, prev.Root)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to delete storage, err: %w", err)
 		}
@@ -1452,8 +1560,16 @@ func (s *StateDB) commit(deleteEmptyObjects bool, noStorageWiping bool) (*stateU
 	// Clear all internal flags and update state root at the end.
 	s.mutations = make(map[common.Address]*mutation)
 	s.stateObjectsDestruct = make(map[common.Address]*stateObject)
+	s.storageWipes = make(map[common.Address]*storageWipe)
+	s.contractChanges = s.collectContractChanges(deletes, updates)
+	s.husks, s.survivors = s.collectHusks(), nil
 
+	// The overlay of a transitioning state descends from the overlay of the
+	// parent in the trie database, not from the parent state itself.
 	origin := s.originalRoot
+	if tr, ok := s.trie.(*trie.TransitionTrie); ok {
+		origin = tr.OverlayRoot()
+	}
 	s.originalRoot = root
 
 	return newStateUpdate(noStorageWiping, origin, root, deletes, updates, nodes), nil
@@ -1479,6 +1595,10 @@ func (s *StateDB) commitAndFlush(block uint64, deleteEmptyObjects bool, noStorag
 		}
 	}
 	if !ret.empty() {
+		// Hold off the storage wipes of other states over the same database
+		if db, ok := s.db.(*CachingDB); ok {
+			defer db.startCommit()()
+		}
 		// If snapshotting is enabled, update the snapshot tree with this new version
 		if snap := s.db.Snapshot(); snap != nil && snap.Snapshot(ret.originRoot) != nil {
 			start := time.Now()
@@ -1492,9 +1612,7 @@ func (s *StateDB) commitAndFlush(block uint64, deleteEmptyObjects bool, noStorag
 			if err := snap.Cap(ret.root, snap.CapLimit()); err != nil {
 				log.Warn("Failed to cap snapshot tree", "root", ret.root, "layers", TriesInMemory, "err", err)
 			}
-			if metrics.EnabledExpensive() {
-				s.SnapshotCommits += time.Since(start)
-			}
+			s.SnapshotCommits += time.Since(start)
 		}
 		// If trie database is enabled, commit the state update as a new layer
 		if db := s.db.TrieDB(); db != nil && !s.noTrie {
@@ -1507,7 +1625,12 @@ func (s *StateDB) commitAndFlush(block uint64, deleteEmptyObjects bool, noStorag
 			}
 		}
 	}
+	// Carry the progress of the verkle transition over to the new state
+	if s.transition != nil {
+		rawdb.WriteVerkleTransitionState(s.db.TrieDB().Disk(), ret.root, s.transition)
+	}
 	s.reader, _ = s.db.Reader(s.originalRoot)
+	s.stateObjectsAbsent = make(map[common.Address]struct{})
 	return ret, err
 }
 
@@ -1662,6 +1785,49 @@ func (s *StateDB) Witness() *stateless.Witness {
 	return s.witness
 }
 
+// AccessedState returns the accounts loaded into the state since its creation,
+// including the destructed ones, along with the storage slots read from each.
+func (s *StateDB) AccessedState() map[common.Address][]common.Hash {
+	accessed := make(map[common.Address][]common.Hash, len(s.stateObjects)+len(s.stateObjectsDestruct))
+	collect := func(obj *stateObject) {
+		slots := accessed[obj.address]
+		for key := range obj.originStorage {
+			slots = append(slots, key)
+		}
+		accessed[obj.address] = slots
+	}
+	for _, obj := range s.stateObjectsDestruct {
+		collect(obj)
+	}
+	for _, obj := range s.stateObjects {
+		collect(obj)
+	}
+	return accessed
+}
+
+// RequireCompleteAccesses requests the accessed state to also cover the state
+// read by the transactions speculatively executed on copies of the statedb, not
+// only what's loaded by replaying their effects. Copies don't inherit it.
+func (s *StateDB) RequireCompleteAccesses() {
+	s.completeAccesses = true
+}
+
+// CompleteAccesses reports whether the accessed state must cover the reads of
+// the speculative executions, which is always the case if collecting a witness.
+func (s *StateDB) CompleteAccesses() bool {
+	return s.completeAccesses || s.witness != nil
+}
+
+// TouchState loads the given state keys into the statedb, so they are part of
+// the accessed state and witness as if read through it.
+func (s *StateDB) TouchState(keys map[StateKey]struct{}) {
+	for key := range keys {
+		if obj := s.getStateObject(key.Address); obj != nil && key.Kind == StorageKey {
+			obj.GetCommittedState(key.Slot)
+		}
+	}
+}
+
 func (s *StateDB) AccessEvents() *AccessEvents {
 	return s.accessEvents
 }
@@ -58,7 +58,15 @@ func (s *hookedStateDB) GetBalance(addr common.Address) *uint256.Int {
 }
 
 func (s *hookedStateDB) SetBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) {
+	prev := s.inner.GetBalance(addr).Clone()
 	s.inner.SetBalance(addr, amount, reason)
+	if s.hooks.OnBalanceChange != nil && !prev.Eq(amount) {
+		s.hooks.OnBalanceChange(addr, prev.ToBig(), amount.ToBig(), reason)
+	}
+}
+
+func (s *hookedStateDB) AccountAbsent(addr common.Address) bool {
+	return s.inner.AccountAbsent(addr)
 }
 
 func (s *hookedStateDB) GetNonce(addr common.Address) uint64 {
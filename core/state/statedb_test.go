@@ -19,9 +19,11 @@ package state
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"maps"
 	"math"
+	"math/big"
 	"math/rand"
 	"reflect"
 	"slices"
@@ -1296,12 +1298,12 @@ func TestDeleteStorage(t *testing.T) {
 	obj := fastState.getOrNewStateObject(addr)
 	storageRoot := obj.data.Root
 
-	_, _, fastNodes, err := fastState.deleteStorage(addr, crypto.Keccak256Hash(addr[:]), storageRoot)
+	_, _, fastNodes, err := fastState.deleteStorage(addr, crypto.Keccak256Hash(addr[:]), storageRoot, wipeInterrupt{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	_, _, slowNodes, err := slowState.deleteStorage(addr, crypto.Keccak256Hash(addr[:]), storageRoot)
+	_, _, slowNodes, err := slowState.deleteStorage(addr, crypto.Keccak256Hash(addr[:]), storageRoot, wipeInterrupt{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1325,6 +1327,103 @@ func TestDeleteStorage(t *testing.T) {
 	}
 }
 
+// Tests that the storage of a destructed account is wiped in the background
+// and the resurrected account commits to the same root as a fresh one.
+func TestBackgroundStorageWipe(t *testing.T) {
+	var (
+		tdb      = triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+		state, _ = New(types.EmptyRootHash, NewDatabase(tdb, nil))
+		addr     = common.HexToAddress("0x1")
+		slot     = common.HexToHash("0x01")
+	)
+	state.SetNonce(addr, 1, tracing.NonceChangeUnspecified)
+	for i := 0; i < 1000; i++ {
+		state.SetState(addr, common.Hash(uint256.NewInt(uint64(i)).Bytes32()), common.Hash(uint256.NewInt(uint64(i+1)).Bytes32()))
+	}
+	root, _ := state.Commit(0, true, false)
+
+	state, _ = New(root, NewDatabase(tdb, nil))
+	state.SelfDestruct(addr)
+	state.Finalise(true)
+
+	wipe, ok := state.storageWipes[addr]
+	if !ok {
+		t.Fatalf("storage wipe not started")
+	}
+	<-wipe.done
+	if wipe.err != nil || len(wipe.storages) != 1000 || len(wipe.origins) != 1000 {
+		t.Fatalf("storage wipe mismatch: %d slots, %d origins, err %v", len(wipe.storages), len(wipe.origins), wipe.err)
+	}
+	state.CreateAccount(addr)
+	state.SetNonce(addr, 1, tracing.NonceChangeUnspecified)
+	state.SetState(addr, slot, slot)
+	have, err := state.Commit(1, true, false)
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	fresh, _ := New(types.EmptyRootHash, NewDatabase(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil), nil))
+	fresh.SetNonce(addr, 1, tracing.NonceChangeUnspecified)
+	fresh.SetState(addr, slot, slot)
+	if want := fresh.IntermediateRoot(true); have != want {
+		t.Fatalf("root mismatch: have %x, want %x", have, want)
+	}
+	if len(state.storageWipes) != 0 {
+		t.Fatalf("storage wipes not reset after commit")
+	}
+}
+
+// Tests that the storage wipes aborted by a foreign commit are redone inline,
+// while the ones interrupted by the shutdown fail the commit.
+func TestStorageWipeAbort(t *testing.T) {
+	var (
+		tdb      = triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+		db       = NewDatabase(tdb, nil)
+		state, _ = New(types.EmptyRootHash, db)
+		addr     = common.HexToAddress("0x1")
+	)
+	state.SetNonce(addr, 1, tracing.NonceChangeUnspecified)
+	for i := 0; i < 1000; i++ {
+		state.SetState(addr, common.Hash(uint256.NewInt(uint64(i)).Bytes32()), common.Hash(uint256.NewInt(uint64(i+1)).Bytes32()))
+	}
+	root, _ := state.Commit(0, true, false)
+
+	// A commit over the same database aborts the running wipes
+	state, _ = New(root, db)
+	interrupt := db.startWipe()
+	go func() { db.startCommit()() }()
+
+	<-interrupt.abort
+	db.wipeLock.RUnlock()
+	storageRoot := state.GetStorageRoot(addr)
+	if _, _, _, err := state.deleteStorage(addr, crypto.Keccak256Hash(addr[:]), storageRoot, interrupt); !errors.Is(err, errStorageWipeAborted) {
+		t.Fatalf("wipe abortion error mismatch: have %v, want %v", err, errStorageWipeAborted)
+	}
+	// An aborted background wipe is redone inline at commit
+	state.SelfDestruct(addr)
+	state.Finalise(true)
+
+	wipe := state.storageWipes[addr]
+	<-wipe.done
+	wipe.storages, wipe.origins, wipe.nodes, wipe.err = nil, nil, nil, errStorageWipeAborted
+
+	if have, err := state.Commit(1, true, false); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	} else if have != types.EmptyRootHash {
+		t.Fatalf("root mismatch: have %x, want %x", have, types.EmptyRootHash)
+	}
+	// The shutdown of the database owner fails the wipe
+	quit := make(chan struct{})
+	close(quit)
+	db = NewDatabase(tdb, nil)
+	db.SetInterrupt(quit)
+
+	state, _ = New(root, db)
+	state.SelfDestruct(addr)
+	if _, err := state.Commit(1, true, false); !errors.Is(err, errStorageWipeAborted) {
+		t.Fatalf("interrupted commit error mismatch: have %v, want %v", err, errStorageWipeAborted)
+	}
+}
+
 func TestStorageDirtiness(t *testing.T) {
 	var (
 		disk       = rawdb.NewMemoryDatabase()
@@ -1368,3 +1467,126 @@ func TestStorageDirtiness(t *testing.T) {
 	state.RevertToSnapshot(snap)
 	checkDirty(common.Hash{0x1}, common.Hash{0x1}, true)
 }
+
+// Tests that the supply delta follows the balance mutations, reverts included.
+func TestSupplyDelta(t *testing.T) {
+	var (
+		a = common.Address{0xa}
+		b = common.Address{0xb}
+	)
+	db := NewDatabaseForTesting()
+	state, _ := New(types.EmptyRootHash, db)
+	state.AddBalance(a, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	state.SubBalance(a, uint256.NewInt(40), tracing.BalanceChangeUnspecified)
+
+	snap := state.Snapshot()
+	state.AddBalance(b, uint256.NewInt(1000), tracing.BalanceChangeUnspecified)
+	state.SelfDestruct(a)
+	state.RevertToSnapshot(snap)
+
+	if delta := state.SupplyDelta(); delta.Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("supply delta mismatch: have %v, want 60", delta)
+	}
+	state.SelfDestruct(a)
+	if delta := state.Copy().SupplyDelta(); delta.Sign() != 0 {
+		t.Fatalf("supply delta of copy mismatch: have %v, want 0", delta)
+	}
+	// Burning pre-existing balance yields a negative delta
+	state, _ = New(types.EmptyRootHash, db)
+	state.AddBalance(a, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	root, _ := state.Commit(0, true, false)
+
+	state, _ = New(root, db)
+	state.SubBalance(a, uint256.NewInt(30), tracing.BalanceChangeUnspecified)
+	if delta := state.SupplyDelta(); delta.Cmp(big.NewInt(-30)) != 0 {
+		t.Fatalf("burnt supply delta mismatch: have %v, want -30", delta)
+	}
+}
+
+// countingReader is a state reader counting the account lookups.
+type countingReader struct {
+	Reader
+	accounts int
+}
+
+func (r *countingReader) Account(addr common.Address) (*types.StateAccount, error) {
+	r.accounts++
+	return r.Reader.Account(addr)
+}
+
+// Tests that the accounts missing from the state are looked up only once, and
+// that creating them afterwards is unaffected.
+func TestAbsentAccountLookups(t *testing.T) {
+	state, _ := New(types.EmptyRootHash, NewDatabaseForTesting())
+	reader := &countingReader{Reader: state.reader}
+	state.reader = reader
+
+	addr := common.Address{0x01}
+	if nonce, balance := state.GetNonce(addr), state.GetBalance(addr); nonce != 0 || !balance.IsZero() {
+		t.Fatalf("absent account mismatch: nonce %d, balance %v", nonce, balance)
+	}
+	if state.Exist(addr) {
+		t.Fatal("absent account exists")
+	}
+	if reader.accounts != 1 {
+		t.Fatalf("account lookups mismatch: have %d, want 1", reader.accounts)
+	}
+	// Create the account and revert the creation
+	snapshot := state.Snapshot()
+	state.AddBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	if balance := state.GetBalance(addr); balance.Uint64() != 1 {
+		t.Fatalf("created account balance mismatch: have %v, want 1", balance)
+	}
+	state.RevertToSnapshot(snapshot)
+	if state.Exist(addr) {
+		t.Fatal("reverted account exists")
+	}
+	if reader.accounts != 1 {
+		t.Fatalf("account lookups mismatch: have %d, want 1", reader.accounts)
+	}
+}
+
+// Tests that the absence of accounts is pre-checked against the snapshot only,
+// loading the existing accounts it resolves for the subsequent lookups.
+func TestAccountAbsent(t *testing.T) {
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		tdb      = triedb.NewDatabase(disk, nil)
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, types.EmptyRootHash, 128, false)
+		state, _ = New(types.EmptyRootHash, NewDatabase(tdb, snaps))
+		existing = common.Address{0x01}
+		missing  = common.Address{0x02}
+	)
+	state.SetNonce(existing, 1, tracing.NonceChangeUnspecified)
+	root, _ := state.Commit(0, true, false)
+
+	state, _ = New(root, NewDatabase(tdb, snaps))
+	reader := &countingReader{Reader: state.reader}
+	if !state.AccountAbsent(missing) {
+		t.Fatal("missing account not reported absent")
+	}
+	if state.AccountAbsent(existing) {
+		t.Fatal("existing account reported absent")
+	}
+	// Both accounts are resolved, the regular lookups don't hit the reader
+	state.reader = reader
+	if nonce := state.GetNonce(existing); nonce != 1 {
+		t.Fatalf("existing account nonce mismatch: have %d, want 1", nonce)
+	}
+	if state.Exist(missing) {
+		t.Fatal("absent account exists")
+	}
+	if reader.accounts != 0 {
+		t.Fatalf("account lookups mismatch: have %d, want 0", reader.accounts)
+	}
+	// Without a snapshot the absence is unknown
+	state, _ = New(root, NewDatabase(tdb, nil))
+	if state.AccountAbsent(missing) {
+		t.Fatal("absence reported without snapshot")
+	}
+	// Accounts created in the state are never absent
+	state.AddBalance(missing, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	if state.AccountAbsent(missing) {
+		t.Fatal("created account reported absent")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// largeStorageWipe is the number of slots above which a storage wipe is
+// reported, as it would have noticeably stalled the block import if done
+// inline at commit.
+const largeStorageWipe = 100_000
+
+// errStorageWipeAborted is returned by a storage wipe interrupted by a commit
+// over the same database or by the shutdown of the database owner.
+var errStorageWipeAborted = errors.New("storage wipe aborted")
+
+// storageWipe is the deletion of the original storage of a destructed account,
+// gathered in the background. The account acts as a tombstone meanwhile: the
+// original storage is never read again in the scope of the block, so the wipe
+// can proceed concurrently with the remaining execution.
+//
+// The wipes never overlap with the database writes of a commit: a commit aborts
+// the wipes running over the same database and waits for them to back off, the
+// aborted ones are then redone inline when their own state is committed.
+type storageWipe struct {
+	done chan struct{} // Closed when the wipe is gathered
+
+	storages map[common.Hash][]byte // The set for storage mutations (value is nil)
+	origins  map[common.Hash][]byte // The set for tracking the original value of slot
+	nodes    *trienode.NodeSet      // The set for trie node mutations (value is nil)
+	err      error
+}
+
+// wipeStorage starts gathering the deletion of the original storage of the
+// given destructed account in the background, unless the storage is empty or
+// its wipe is already in progress.
+func (s *StateDB) wipeStorage(addr common.Address, origin *types.StateAccount) {
+	if origin == nil || origin.Root == types.EmptyRootHash || s.db.TrieDB().IsVerkle() {
+		return
+	}
+	if _, ok := s.storageWipes[addr]; ok {
+		return
+	}
+	if s.storageWipes == nil {
+		s.storageWipes = make(map[common.Address]*storageWipe)
+	}
+	var (
+		wipe     = &storageWipe{done: make(chan struct{})}
+		addrHash = crypto.Keccak256Hash(addr.Bytes())
+		root     = origin.Root
+	)
+	s.storageWipes[addr] = wipe
+
+	go func() {
+		defer close(wipe.done)
+
+		db, ok := s.db.(*CachingDB)
+		if !ok {
+			wipe.storages, wipe.origins, wipe.nodes, wipe.err = s.deleteStorage(addr, addrHash, root, wipeInterrupt{})
+			return
+		}
+		interrupt := db.startWipe()
+		defer db.wipeLock.RUnlock()
+
+		start := time.Now()
+		wipe.storages, wipe.origins, wipe.nodes, wipe.err = s.deleteStorage(addr, addrHash, root, interrupt)
+		if wipe.err != nil {
+			return
+		}
+		storageWipeTimer.UpdateSince(start)
+
+		if len(wipe.storages) >= largeStorageWipe {
+			log.Info("Wiped large contract storage", "address", addr, "slots", len(wipe.storages), "elapsed", common.PrettyDuration(time.Since(start)))
+		}
+	}()
+}
+
+// waitStorageWipe returns the deletion of the original storage of the given
+// destructed account, waiting for the background wipe if one was started, or
+// gathering it inline otherwise. A wipe aborted by a foreign commit is redone
+// inline too.
+func (s *StateDB) waitStorageWipe(addr common.Address, addrHash common.Hash, root common.Hash) (map[common.Hash][]byte, map[common.Hash][]byte, *trienode.NodeSet, error) {
+	var interrupt wipeInterrupt
+	if db, ok := s.db.(*CachingDB); ok {
+		interrupt.quit = db.quit
+	}
+	wipe, ok := s.storageWipes[addr]
+	if !ok {
+		return s.deleteStorage(addr, addrHash, root, interrupt)
+	}
+	<-wipe.done
+	if errors.Is(wipe.err, errStorageWipeAborted) && !interrupt.aborted() {
+		return s.deleteStorage(addr, addrHash, root, interrupt)
+	}
+	return wipe.storages, wipe.origins, wipe.nodes, wipe.err
+}
+
+// wipeInterrupt signals a storage wipe to back off, either as a commit is
+// waiting or the database owner is shutting down. The zero value is never
+// signalled.
+type wipeInterrupt struct {
+	abort <-chan struct{} // Closed when a commit over the database starts
+	quit  <-chan struct{} // Closed when the database owner shuts down
+}
+
+// aborted reports whether the wipe should back off.
+func (w wipeInterrupt) aborted() bool {
+	select {
+	case <-w.abort:
+		return true
+	case <-w.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// startWipe registers a storage wipe running over the database, holding off the
+// commits until it finishes. The caller must release the read lock of the wipes
+// when done.
+func (db *CachingDB) startWipe() wipeInterrupt {
+	db.wipeMu.Lock()
+	defer db.wipeMu.Unlock()
+
+	db.wipeLock.RLock()
+
+	if db.wipeAbort == nil {
+		db.wipeAbort = make(chan struct{})
+	}
+	return wipeInterrupt{abort: db.wipeAbort, quit: db.quit}
+}
+
+// startCommit aborts the running storage wipes and blocks new ones until the
+// returned function is called, serializing the wipes with the database writes.
+func (db *CachingDB) startCommit() func() {
+	db.wipeMu.Lock()
+	defer db.wipeMu.Unlock()
+
+	if db.wipeAbort != nil {
+		close(db.wipeAbort)
+		db.wipeAbort = nil
+	}
+	db.wipeLock.Lock()
+	return db.wipeLock.Unlock
+}
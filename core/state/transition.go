@@ -0,0 +1,315 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// The verkle transition converts the merkle state of a chain into a verkle tree
+// while the chain keeps progressing. The merkle state at the fork is frozen as
+// the base of the transition, and every later state is an overlay verkle tree
+// holding all the leaves written since the fork, on top of that base. Each block
+// moves a fixed number of base leaves into its overlay, until the whole base is
+// converted and the overlay becomes a standalone verkle tree.
+//
+// The progress of the conversion is tracked for every state root of the chain
+// from the fork onwards. Converted leaves are written straight into the trie,
+// so the states of a transitioning chain are always read through the trie and
+// never from the flat state layers.
+
+// NewTransitionDatabase creates a state database for a chain transitioning into
+// verkle. The overlay trie database holds the verkle trees, the base one holds
+// the frozen merkle state. States not part of the transition are served as plain
+// verkle trees.
+func NewTransitionDatabase(overlay *triedb.Database, base *triedb.Database) *CachingDB {
+	db := NewDatabase(overlay, nil)
+	db.base = base
+	return db
+}
+
+// TransitionState returns the progress of the verkle transition as of the given
+// state root, or nil if the state isn't part of a transition.
+func (db *CachingDB) TransitionState(root common.Hash) *rawdb.VerkleTransitionState {
+	if db.base == nil {
+		return nil
+	}
+	return rawdb.ReadVerkleTransitionState(db.disk, root)
+}
+
+// StartTransition marks the given merkle state as the base of the verkle
+// transition, converted by the states built on top of it. Nothing is done if
+// the state is already part of the transition.
+func (db *CachingDB) StartTransition(root common.Hash) error {
+	if db.base == nil {
+		return errors.New("not a transition database")
+	}
+	if db.TransitionState(root) != nil {
+		return nil
+	}
+	rawdb.WriteVerkleTransitionState(db.disk, root, &rawdb.VerkleTransitionState{BaseRoot: root})
+	log.Info("Started verkle transition", "base", root)
+	return nil
+}
+
+// openTransitionTrie opens the account trie of a state in the verkle transition.
+func (db *CachingDB) openTransitionTrie(root common.Hash, ts *rawdb.VerkleTransitionState) (*trie.TransitionTrie, error) {
+	base, err := trie.NewStateTrie(trie.StateTrieID(ts.BaseRoot), db.base)
+	if err != nil {
+		return nil, err
+	}
+	// The base itself is the parent of the first overlay, which starts empty
+	overlayRoot := root
+	if root == ts.BaseRoot {
+		overlayRoot = types.EmptyVerkleHash
+	}
+	overlay, err := trie.NewVerkleTrie(overlayRoot, db.triedb, db.pointCache)
+	if err != nil {
+		return nil, err
+	}
+	return trie.NewTransitionTrie(base, overlay), nil
+}
+
+// openTransitionStorageTrie opens the storage trie of an account in a state of
+// the verkle transition, sharing the overlay of the given account trie.
+func (db *CachingDB) openTransitionStorageTrie(address common.Address, self *trie.TransitionTrie) (*trie.TransitionTrie, error) {
+	// Overlay accounts carry no storage root, resolve the one of the base
+	root := types.EmptyRootHash
+	acc, err := self.Base().GetAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc != nil {
+		root = acc.Root
+	}
+	base, err := trie.NewStateTrie(trie.StorageTrieID(self.Base().Hash(), crypto.Keccak256Hash(address.Bytes()), root), db.base)
+	if err != nil {
+		return nil, err
+	}
+	return self.StorageTrie(base), nil
+}
+
+// transitionReader implements the StateReader interface, serving the state of
+// the verkle transition through its transition tries.
+type transitionReader struct {
+	db       *CachingDB
+	mainTrie *trie.TransitionTrie
+	subTries map[common.Address]*trie.TransitionTrie
+}
+
+// newTransitionReader constructs a reader of a state in the verkle transition.
+func newTransitionReader(db *CachingDB, root common.Hash, ts *rawdb.VerkleTransitionState) (*transitionReader, error) {
+	tr, err := db.openTransitionTrie(root, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &transitionReader{
+		db:       db,
+		mainTrie: tr,
+		subTries: make(map[common.Address]*trie.TransitionTrie),
+	}, nil
+}
+
+// Account implements StateReader, retrieving the account specified by the address.
+func (r *transitionReader) Account(addr common.Address) (*types.StateAccount, error) {
+	return r.mainTrie.GetAccount(addr)
+}
+
+// Storage implements StateReader, retrieving the storage slot specified by the
+// address and slot key.
+func (r *transitionReader) Storage(addr common.Address, key common.Hash) (common.Hash, error) {
+	tr, ok := r.subTries[addr]
+	if !ok {
+		var err error
+		if tr, err = r.db.openTransitionStorageTrie(addr, r.mainTrie); err != nil {
+			return common.Hash{}, err
+		}
+		r.subTries[addr] = tr
+	}
+	ret, err := tr.GetStorage(addr, key.Bytes())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var value common.Hash
+	value.SetBytes(ret)
+	return value, nil
+}
+
+// VerkleTransition returns the progress of the verkle transition of the state,
+// or nil if the state isn't part of a transition.
+func (s *StateDB) VerkleTransition() *rawdb.VerkleTransitionState {
+	if s.transition == nil {
+		return nil
+	}
+	cpy := *s.transition
+	return &cpy
+}
+
+// converting reports whether the state is in the middle of the verkle transition.
+func (s *StateDB) converting() bool {
+	return s.transition != nil && !s.transition.Ended
+}
+
+// ConvertToVerkle moves up to stride leaves of the frozen merkle state into the
+// verkle overlay, advancing the progress of the transition. Leaves already held
+// by the overlay are newer than the base ones and are skipped, but still count
+// towards the stride, so the converted set doesn't depend on the order of the
+// writes within the block. It's a noop for states not being converted.
+//
+// The conversion maps the hashed merkle keys back to addresses and slots, so it
+// fails if their preimages are not available.
+func (s *StateDB) ConvertToVerkle(stride uint64) error {
+	if !s.converting() {
+		return nil
+	}
+	tr, ok := s.trie.(*trie.TransitionTrie)
+	if !ok {
+		return fmt.Errorf("unexpected trie %T in verkle transition", s.trie)
+	}
+	db, ok := s.db.(*CachingDB)
+	if !ok {
+		return fmt.Errorf("unexpected database %T in verkle transition", s.db)
+	}
+	var (
+		ts    = s.transition
+		disk  = db.disk
+		moved uint64
+	)
+	nodeIt, err := tr.Base().NodeIterator(ts.NextAccount.Bytes())
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		accHash := common.BytesToHash(it.Key)
+		if moved >= stride {
+			ts.NextAccount, ts.NextSlot = accHash, common.Hash{}
+			return nil
+		}
+		preimage := rawdb.ReadPreimage(disk, accHash)
+		if len(preimage) != common.AddressLength {
+			return fmt.Errorf("missing preimage of account %x", accHash)
+		}
+		addr := common.BytesToAddress(preimage)
+
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			return fmt.Errorf("invalid account %x: %w", accHash, err)
+		}
+		// Convert the storage first, so an interrupted account is resumed from
+		// its next slot
+		if acc.Root != types.EmptyRootHash {
+			var origin common.Hash
+			if accHash == ts.NextAccount {
+				origin = ts.NextSlot
+			}
+			next, n, err := s.convertStorage(db, tr, addr, accHash, acc.Root, origin, stride-moved)
+			moved += n
+			if err != nil {
+				return err
+			}
+			if next != nil {
+				ts.NextAccount, ts.NextSlot = accHash, *next
+				return nil
+			}
+		}
+		exists, err := tr.HasOverlayAccount(addr)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			var code []byte
+			if codeHash := common.BytesToHash(acc.CodeHash); !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+				code = rawdb.ReadCode(disk, codeHash)
+				if len(code) == 0 {
+					return fmt.Errorf("missing code %x of account %x", codeHash, addr)
+				}
+				if err := tr.UpdateContractCode(addr, codeHash, code); err != nil {
+					return err
+				}
+			}
+			if err := tr.UpdateAccount(addr, &acc, len(code)); err != nil {
+				return err
+			}
+		}
+		moved++
+		ts.Accounts++
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	ts.NextAccount, ts.NextSlot = common.Hash{}, common.Hash{}
+	ts.Ended = true
+
+	log.Info("Finished verkle transition", "base", ts.BaseRoot, "accounts", ts.Accounts, "slots", ts.Slots)
+	return nil
+}
+
+// convertStorage moves up to budget storage slots of an account into the verkle
+// overlay, starting at the given slot. It returns the hash of the first slot not
+// converted, or nil if the whole storage is converted.
+func (s *StateDB) convertStorage(db *CachingDB, tr *trie.TransitionTrie, addr common.Address, accHash common.Hash, root common.Hash, origin common.Hash, budget uint64) (*common.Hash, uint64, error) {
+	base, err := trie.NewStateTrie(trie.StorageTrieID(tr.Base().Hash(), accHash, root), db.base)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodeIt, err := base.NodeIterator(origin.Bytes())
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		it    = trie.NewIterator(nodeIt)
+		moved uint64
+	)
+	for it.Next() {
+		slotHash := common.BytesToHash(it.Key)
+		if moved >= budget {
+			return &slotHash, moved, nil
+		}
+		key := rawdb.ReadPreimage(db.disk, slotHash)
+		if len(key) != common.HashLength {
+			return nil, moved, fmt.Errorf("missing preimage of slot %x of account %x", slotHash, addr)
+		}
+		exists, err := tr.HasOverlayStorage(addr, key)
+		if err != nil {
+			return nil, moved, err
+		}
+		if !exists {
+			_, value, _, err := rlp.Split(it.Value)
+			if err != nil {
+				return nil, moved, fmt.Errorf("invalid slot %x of account %x: %w", slotHash, addr, err)
+			}
+			if err := tr.UpdateStorage(addr, key, value); err != nil {
+				return nil, moved, err
+			}
+		}
+		moved++
+		s.transition.Slots++
+	}
+	return nil, moved, it.Err
+}
@@ -0,0 +1,435 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// checkpointManifest is the name of the file describing a state checkpoint.
+	checkpointManifest = "manifest.json"
+
+	// defaultCheckpointChunkSize is the default maximum number of accounts
+	// stored in a single checkpoint chunk.
+	defaultCheckpointChunkSize = 16384
+)
+
+var (
+	// errCheckpointNotReady is returned if a checkpoint is requested while the
+	// state snapshot is still being generated.
+	errCheckpointNotReady = errors.New("state snapshot not yet generated")
+
+	// errCheckpointMismatch is returned if the content of a checkpoint doesn't
+	// match its manifest or the header it is bootstrapped for.
+	errCheckpointMismatch = errors.New("checkpoint mismatch")
+)
+
+// CheckpointConfig configures the periodic export of state checkpoints.
+type CheckpointConfig struct {
+	Dir       string // Directory to export the checkpoints into
+	Interval  uint64 // Number of blocks between two checkpoints, e.g. the epoch length
+	ChunkSize int    // Maximum number of accounts per chunk
+}
+
+// StateCheckpoint is the manifest of a state checkpoint, the complete state of
+// a block split into chunks ordered by account hash.
+type StateCheckpoint struct {
+	Number     uint64            `json:"number"`
+	Hash       common.Hash       `json:"hash"`
+	Root       common.Hash       `json:"root"`
+	Validators []common.Address  `json:"validators,omitempty"` // Validator set after the block, if known by the engine
+	Chunks     []CheckpointChunk `json:"chunks"`
+}
+
+// CheckpointChunk describes a single chunk file of a state checkpoint.
+type CheckpointChunk struct {
+	File     string      `json:"file"`
+	First    common.Hash `json:"first"`    // Hash of the first account in the chunk
+	Last     common.Hash `json:"last"`     // Hash of the last account in the chunk
+	Accounts int         `json:"accounts"` // Number of accounts in the chunk
+	Checksum common.Hash `json:"checksum"` // Keccak256 hash of the chunk file
+}
+
+// checkpointAccount is the chunk representation of an account, along with its
+// code and storage.
+type checkpointAccount struct {
+	Hash    common.Hash
+	Account []byte // Slim RLP encoded account
+	Code    []byte
+	Storage []checkpointSlot
+}
+
+// checkpointSlot is the chunk representation of a storage slot.
+type checkpointSlot struct {
+	Hash  common.Hash
+	Value []byte // RLP encoded slot value, as stored in the trie
+}
+
+// EnableStateCheckpoints returns a BlockChainOption which exports a checkpoint
+// of the state into the configured directory every time the head reaches a
+// multiple of the checkpoint interval. It requires state snapshots.
+func EnableStateCheckpoints(config CheckpointConfig) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if bc.snaps == nil {
+			return nil, errSnapshotsDisabled
+		}
+		if config.Interval == 0 {
+			return nil, errors.New("zero checkpoint interval")
+		}
+		if config.ChunkSize <= 0 {
+			config.ChunkSize = defaultCheckpointChunkSize
+		}
+		if err := os.MkdirAll(config.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+		bc.checkpointConfig = &config
+		return bc, nil
+	}
+}
+
+// checkpointLoop exports a state checkpoint whenever a new head lands on a
+// checkpoint interval.
+func (bc *BlockChain) checkpointLoop(quit <-chan struct{}) {
+	heads := make(chan ChainHeadEvent, 16)
+	sub := bc.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	config := bc.checkpointConfig
+	for {
+		select {
+		case ev := <-heads:
+			number := ev.Header.Number.Uint64()
+			if number == 0 || number%config.Interval != 0 {
+				continue
+			}
+			dir := filepath.Join(config.Dir, fmt.Sprintf("checkpoint-%d", number))
+			if _, err := bc.ExportCheckpoint(ev.Header, dir, config.ChunkSize); err != nil {
+				log.Error("Failed to export state checkpoint", "number", number, "hash", ev.Header.Hash(), "err", err)
+			}
+		case <-sub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// ExportCheckpoint writes a checkpoint of the state of the given header into
+// dir, which must not exist yet. The state is read from the snapshot, so the
+// header must be recent enough to be covered by it.
+func (bc *BlockChain) ExportCheckpoint(header *types.Header, dir string, chunkSize int) (*StateCheckpoint, error) {
+	if bc.snaps == nil {
+		return nil, errSnapshotsDisabled
+	}
+	if status, err := bc.snaps.GenerationStatus(); err != nil || !status.Done {
+		return nil, errCheckpointNotReady
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("checkpoint directory %s already exists", dir)
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultCheckpointChunkSize
+	}
+	var (
+		start = time.Now()
+		cp    = &StateCheckpoint{Number: header.Number.Uint64(), Hash: header.Hash(), Root: header.Root}
+	)
+	if reader, ok := bc.engine.(consensus.ValidatorSetReader); ok {
+		validators, err := reader.ValidatorsAt(bc, header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve validators: %w", err)
+		}
+		cp.Validators = validators
+	}
+	// Assemble the checkpoint in a temporary directory, so a crash never leaves
+	// an incomplete one behind.
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	it, err := bc.snaps.AccountIterator(header.Root, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var (
+		chunk    []checkpointAccount
+		accounts int
+	)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		blob, err := rlp.EncodeToBytes(chunk)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("chunk-%05d.rlp", len(cp.Chunks))
+		if err := os.WriteFile(filepath.Join(tmp, name), blob, 0644); err != nil {
+			return err
+		}
+		cp.Chunks = append(cp.Chunks, CheckpointChunk{
+			File:     name,
+			First:    chunk[0].Hash,
+			Last:     chunk[len(chunk)-1].Hash,
+			Accounts: len(chunk),
+			Checksum: crypto.Keccak256Hash(blob),
+		})
+		chunk = chunk[:0]
+		return nil
+	}
+	for it.Next() {
+		entry, err := bc.checkpointAccount(header.Root, it.Hash(), common.CopyBytes(it.Account()))
+		if err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, *entry)
+		accounts++
+
+		if len(chunk) >= chunkSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	blob, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, checkpointManifest), blob, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	log.Info("Exported state checkpoint", "number", cp.Number, "hash", cp.Hash, "root", cp.Root,
+		"accounts", accounts, "chunks", len(cp.Chunks), "validators", len(cp.Validators),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return cp, nil
+}
+
+// checkpointAccount gathers the code and storage of an account from the
+// snapshot of the given state.
+func (bc *BlockChain) checkpointAccount(root common.Hash, hash common.Hash, slim []byte) (*checkpointAccount, error) {
+	account, err := types.FullAccount(slim)
+	if err != nil {
+		return nil, err
+	}
+	entry := &checkpointAccount{Hash: hash, Account: slim}
+	if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
+		entry.Code = rawdb.ReadCode(bc.db, common.BytesToHash(account.CodeHash))
+		if entry.Code == nil {
+			return nil, fmt.Errorf("missing code %x of account %x", account.CodeHash, hash)
+		}
+	}
+	if account.Root == types.EmptyRootHash {
+		return entry, nil
+	}
+	it, err := bc.snaps.StorageIterator(root, hash, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	for it.Next() {
+		entry.Storage = append(entry.Storage, checkpointSlot{Hash: it.Hash(), Value: common.CopyBytes(it.Slot())})
+	}
+	return entry, it.Error()
+}
+
+// VerifyCheckpoint checks the integrity of the state checkpoint in dir,
+// rebuilding its state trie and comparing the root against the manifest.
+// The returned manifest is only trustworthy to the extent its block hash
+// is, which is for the caller to check against a trusted source.
+func VerifyCheckpoint(dir string) (*StateCheckpoint, error) {
+	return importCheckpoint(dir, nil, "")
+}
+
+// BootstrapFromCheckpoint verifies the state checkpoint in dir and imports its
+// state, along with the state snapshot, into db using the given state scheme.
+// The checkpoint must belong to the given header, which is expected to come
+// from a trusted source, e.g. a verified header chain.
+func BootstrapFromCheckpoint(db ethdb.Database, scheme string, dir string, header *types.Header) (*StateCheckpoint, error) {
+	cp, err := readCheckpointManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Hash != header.Hash() || cp.Root != header.Root {
+		return nil, fmt.Errorf("%w: checkpoint of block %x, root %x, want block %x, root %x", errCheckpointMismatch, cp.Hash, cp.Root, header.Hash(), header.Root)
+	}
+	if rawdb.HasTrieNode(db, common.Hash{}, nil, header.Root, scheme) {
+		log.Info("Checkpoint state already present", "number", cp.Number, "root", cp.Root)
+		return cp, nil
+	}
+	start := time.Now()
+	if cp, err = importCheckpoint(dir, db, scheme); err != nil {
+		return nil, err
+	}
+	rawdb.WriteSnapshotRoot(db, cp.Root)
+	log.Info("Bootstrapped state from checkpoint", "number", cp.Number, "hash", cp.Hash, "root", cp.Root,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return cp, nil
+}
+
+// readCheckpointManifest loads the manifest of the state checkpoint in dir.
+func readCheckpointManifest(dir string) (*StateCheckpoint, error) {
+	blob, err := os.ReadFile(filepath.Join(dir, checkpointManifest))
+	if err != nil {
+		return nil, err
+	}
+	cp := new(StateCheckpoint)
+	if err := json.Unmarshal(blob, cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint manifest: %w", err)
+	}
+	return cp, nil
+}
+
+// importCheckpoint verifies the chunks of the state checkpoint in dir against
+// its manifest and regenerates the state trie, writing the trie nodes, codes
+// and snapshot entries into db if it's non-nil.
+func importCheckpoint(dir string, db ethdb.Database, scheme string) (*StateCheckpoint, error) {
+	cp, err := readCheckpointManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	var batch ethdb.Batch
+	if db != nil {
+		batch = db.NewBatch()
+	}
+	onTrieNode := func(owner common.Hash) trie.OnTrieNode {
+		if batch == nil {
+			return nil
+		}
+		return func(path []byte, hash common.Hash, blob []byte) {
+			rawdb.WriteTrieNode(batch, owner, path, hash, blob, scheme)
+		}
+	}
+	var (
+		accountTrie = trie.NewStackTrie(onTrieNode(common.Hash{}))
+		last        *common.Hash
+	)
+	for i, meta := range cp.Chunks {
+		blob, err := os.ReadFile(filepath.Join(dir, meta.File))
+		if err != nil {
+			return nil, err
+		}
+		if checksum := crypto.Keccak256Hash(blob); checksum != meta.Checksum {
+			return nil, fmt.Errorf("%w: chunk %d checksum %x, want %x", errCheckpointMismatch, i, checksum, meta.Checksum)
+		}
+		var chunk []checkpointAccount
+		if err := rlp.DecodeBytes(blob, &chunk); err != nil {
+			return nil, fmt.Errorf("invalid chunk %d: %w", i, err)
+		}
+		if len(chunk) == 0 || len(chunk) != meta.Accounts || chunk[0].Hash != meta.First || chunk[len(chunk)-1].Hash != meta.Last {
+			return nil, fmt.Errorf("%w: chunk %d content doesn't match the manifest", errCheckpointMismatch, i)
+		}
+		for _, entry := range chunk {
+			if last != nil && bytes.Compare(entry.Hash[:], last[:]) <= 0 {
+				return nil, fmt.Errorf("%w: account %x out of order", errCheckpointMismatch, entry.Hash)
+			}
+			last = &entry.Hash
+
+			full, err := importCheckpointAccount(&entry, batch, onTrieNode(entry.Hash))
+			if err != nil {
+				return nil, err
+			}
+			if err := accountTrie.Update(entry.Hash[:], full); err != nil {
+				return nil, err
+			}
+			if batch != nil && batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return nil, err
+				}
+				batch.Reset()
+			}
+		}
+	}
+	if root := accountTrie.Hash(); root != cp.Root {
+		return nil, fmt.Errorf("%w: state root %x, want %x", errCheckpointMismatch, root, cp.Root)
+	}
+	if batch != nil {
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// importCheckpointAccount verifies the code and storage of a checkpoint account
+// and returns its full RLP encoding, writing its data into batch if non-nil.
+func importCheckpointAccount(entry *checkpointAccount, batch ethdb.Batch, onTrieNode trie.OnTrieNode) ([]byte, error) {
+	account, err := types.FullAccount(entry.Account)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account %x: %w", entry.Hash, err)
+	}
+	if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
+		if hash := crypto.Keccak256(entry.Code); !bytes.Equal(hash, account.CodeHash) {
+			return nil, fmt.Errorf("%w: account %x code hash %x, want %x", errCheckpointMismatch, entry.Hash, hash, account.CodeHash)
+		}
+		if batch != nil {
+			rawdb.WriteCode(batch, common.BytesToHash(account.CodeHash), entry.Code)
+		}
+	}
+	storageTrie := trie.NewStackTrie(onTrieNode)
+	for i, slot := range entry.Storage {
+		if i > 0 && bytes.Compare(slot.Hash[:], entry.Storage[i-1].Hash[:]) <= 0 {
+			return nil, fmt.Errorf("%w: account %x slot %x out of order", errCheckpointMismatch, entry.Hash, slot.Hash)
+		}
+		if err := storageTrie.Update(slot.Hash[:], slot.Value); err != nil {
+			return nil, err
+		}
+		if batch != nil {
+			rawdb.WriteStorageSnapshot(batch, entry.Hash, slot.Hash, slot.Value)
+		}
+	}
+	if root := storageTrie.Hash(); root != account.Root {
+		return nil, fmt.Errorf("%w: account %x storage root %x, want %x", errCheckpointMismatch, entry.Hash, root, account.Root)
+	}
+	if batch != nil {
+		rawdb.WriteAccountSnapshot(batch, entry.Hash, entry.Account)
+	}
+	return types.FullAccountRLP(entry.Account)
+}
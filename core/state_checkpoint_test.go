@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Tests that state checkpoints are exported periodically, verified and can be
+// used to bootstrap the state of a fresh database.
+func TestStateCheckpoint(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		code     = []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.STOP)}
+		engine   = ethash.NewFaker()
+		genesis  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				contract: {
+					Balance: big.NewInt(1),
+					Code:    code,
+					Storage: map[common.Hash]common.Hash{{0x01}: {0x02}, {0x03}: {0x04}},
+				},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		dir    = t.TempDir()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.SnapshotWait = true
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, engine, vm.Config{}, nil, nil,
+		EnableStateCheckpoints(CheckpointConfig{Dir: dir, Interval: 2, ChunkSize: 2}))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Wait for the checkpoint of the last epoch to be exported
+	cpdir := filepath.Join(dir, "checkpoint-4")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(cpdir); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("checkpoint not exported")
+		}
+	}
+	cp, err := VerifyCheckpoint(cpdir)
+	if err != nil {
+		t.Fatalf("failed to verify checkpoint: %v", err)
+	}
+	head := blocks[3].Header()
+	if cp.Number != 4 || cp.Hash != head.Hash() || cp.Root != head.Root {
+		t.Fatalf("checkpoint mismatch: have #%d %x root %x, want #4 %x root %x", cp.Number, cp.Hash, cp.Root, head.Hash(), head.Root)
+	}
+	// At least 2 genesis accounts, 4 recipients and the coinbase, in chunks of 2
+	var accounts int
+	for i, chunk := range cp.Chunks {
+		if chunk.Accounts > 2 {
+			t.Errorf("chunk %d: %d accounts exceed the chunk size", i, chunk.Accounts)
+		}
+		accounts += chunk.Accounts
+	}
+	if accounts < 7 {
+		t.Errorf("account count mismatch: have %d, want at least 7", accounts)
+	}
+	// Bootstrapping requires the matching header
+	db := rawdb.NewMemoryDatabase()
+	if _, err := BootstrapFromCheckpoint(db, rawdb.HashScheme, cpdir, blocks[2].Header()); !errors.Is(err, errCheckpointMismatch) {
+		t.Fatalf("checkpoint bootstrapped for wrong header: %v", err)
+	}
+	if _, err := BootstrapFromCheckpoint(db, rawdb.HashScheme, cpdir, head); err != nil {
+		t.Fatalf("failed to bootstrap from checkpoint: %v", err)
+	}
+	statedb, err := state.New(head.Root, state.NewDatabase(triedb.NewDatabase(db, nil), nil))
+	if err != nil {
+		t.Fatalf("failed to open bootstrapped state: %v", err)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Errorf("code mismatch: have %x, want %x", have, code)
+	}
+	if have := statedb.GetState(contract, common.Hash{0x03}); have != (common.Hash{0x04}) {
+		t.Errorf("storage mismatch: have %x, want %x", have, common.Hash{0x04})
+	}
+	if have := statedb.GetBalance(common.Address{0x04}); have.Uint64() != 1 {
+		t.Errorf("balance mismatch: have %v, want 1", have)
+	}
+	if root := rawdb.ReadSnapshotRoot(db); root != head.Root {
+		t.Errorf("snapshot root mismatch: have %x, want %x", root, head.Root)
+	}
+	// Tampered chunks must be detected
+	chunk := filepath.Join(cpdir, cp.Chunks[1].File)
+	blob, _ := os.ReadFile(chunk)
+	blob[len(blob)-1] ^= 0xff
+	os.WriteFile(chunk, blob, 0644)
+
+	if _, err := VerifyCheckpoint(cpdir); !errors.Is(err, errCheckpointMismatch) {
+		t.Fatalf("tampered checkpoint verified: %v", err)
+	}
+}
@@ -20,25 +20,30 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 )
 
 const prefetchThread = 3
 const checkInterval = 10
 
+var prefetchNextTxMeter = metrics.NewRegisteredMeter("chain/prefetch/next/txs", nil)
+
 // statePrefetcher is a basic Prefetcher, which blindly executes a block on top
 // of an arbitrary state with the goal of prefetching potentially useful state
 // data from disk before the main block processor start executing.
 type statePrefetcher struct {
-	config *params.ChainConfig // Chain configuration options
-	chain  *HeaderChain        // Canonical block chain
+	config  *params.ChainConfig // Chain configuration options
+	chain   *HeaderChain        // Canonical block chain
+	threads int                 // Number of threads executing or warming transactions
 }
 
 // NewStatePrefetcher initialises a new statePrefetcher.
 func NewStatePrefetcher(config *params.ChainConfig, chain *HeaderChain) *statePrefetcher {
 	return &statePrefetcher{
-		config: config,
-		chain:  chain,
+		config:  config,
+		chain:   chain,
+		threads: prefetchThread,
 	}
 }
 
@@ -47,11 +52,16 @@ func NewStatePrefetcher(config *params.ChainConfig, chain *HeaderChain) *statePr
 // only goal is to warm the state caches.
 func (p *statePrefetcher) Prefetch(transactions types.Transactions, header *types.Header, gasLimit uint64, statedb *state.StateDB, cfg *vm.Config, interruptCh <-chan struct{}) {
 	var (
-		signer = types.MakeSigner(p.config, header.Number, header.Time)
+		signer  = types.MakeSigner(p.config, header.Number, header.Time)
+		measure = p.config.IsBerlin(header.Number)
 	)
-	txChan := make(chan int, prefetchThread)
+	txChan := make(chan int, p.threads)
+
+	// Warm the statically predicted state of all the transactions ahead of the
+	// executing threads, on top of the access lists warmed by the execution.
+	go p.prefetchHints(transactions, signer, statedb.CopyDoPrefetch(), interruptCh)
 
-	for i := 0; i < prefetchThread; i++ {
+	for i := 0; i < p.threads; i++ {
 		go func() {
 			newStatedb := statedb.CopyDoPrefetch()
 			gaspool := new(GasPool).AddGas(gasLimit)
@@ -72,7 +82,9 @@ func (p *statePrefetcher) Prefetch(transactions types.Transactions, header *type
 					newStatedb.SetTxContext(tx.Hash(), txIndex)
 					// We attempt to apply a transaction. The goal is not to execute
 					// the transaction successfully, rather to warm up touched data slots.
-					ApplyMessage(evm, msg, gaspool)
+					if _, err := ApplyMessage(evm, msg, gaspool); err == nil && measure {
+						staticPrefetchHints(tx, msg.From).measure(newStatedb)
+					}
 
 				case <-interruptCh:
 					// If block precaching was interrupted, abort
@@ -92,14 +104,71 @@ func (p *statePrefetcher) Prefetch(transactions types.Transactions, header *type
 	}
 }
 
+// prefetchHints loads the state the transactions are statically predicted to
+// access, without executing them.
+func (p *statePrefetcher) prefetchHints(transactions types.Transactions, signer types.Signer, statedb *state.StateDB, interruptCh <-chan struct{}) {
+	for _, tx := range transactions {
+		select {
+		case <-interruptCh:
+			return
+		default:
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return // Also invalid block, bail out
+		}
+		staticPrefetchHints(tx, from).warm(statedb)
+	}
+}
+
+// PrefetchNext warms the state the transactions of an upcoming block are
+// predicted to access, i.e. their senders, recipients, access lists and static
+// hints, without executing them. It runs while the parent block is executed,
+// so the given state is the one the parent is executed on top of.
+func (p *statePrefetcher) PrefetchNext(block *types.Block, statedb *state.StateDB, interruptCh <-chan struct{}) {
+	var (
+		signer = types.MakeSigner(p.config, block.Number(), block.Time())
+		txs    = block.Transactions()
+	)
+	for i := 0; i < p.threads && i < len(txs); i++ {
+		go func(start int) {
+			throwaway := statedb.CopyDoPrefetch()
+			for j := start; j < len(txs); j += p.threads {
+				select {
+				case <-interruptCh:
+					return
+				default:
+				}
+				tx := txs[j]
+				from, err := types.Sender(signer, tx)
+				if err != nil {
+					return // Also invalid block, bail out
+				}
+				throwaway.GetBalance(from)
+				if to := tx.To(); to != nil {
+					throwaway.GetCode(*to)
+				}
+				for _, tuple := range tx.AccessList() {
+					throwaway.GetCode(tuple.Address)
+					for _, slot := range tuple.StorageKeys {
+						throwaway.GetState(tuple.Address, slot)
+					}
+				}
+				staticPrefetchHints(tx, from).warm(throwaway)
+				prefetchNextTxMeter.Mark(1)
+			}
+		}(i)
+	}
+}
+
 // PrefetchMining processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb, but any changes are discarded. The
 // only goal is to warm the state caches. Only used for mining stage.
 func (p *statePrefetcher) PrefetchMining(txs TransactionsByPriceAndNonce, header *types.Header, gasLimit uint64, statedb *state.StateDB, cfg vm.Config, interruptCh <-chan struct{}, txCurr **types.Transaction) {
 	var signer = types.MakeSigner(p.config, header.Number, header.Time)
 
-	txCh := make(chan *types.Transaction, 2*prefetchThread)
-	for i := 0; i < prefetchThread; i++ {
+	txCh := make(chan *types.Transaction, 2*p.threads)
+	for i := 0; i < p.threads; i++ {
 		go func(startCh <-chan *types.Transaction, stopCh <-chan struct{}) {
 			newStatedb := statedb.CopyDoPrefetch()
 			evm := vm.NewEVM(NewEVMBlockContext(header, p.chain, nil), newStatedb, p.config, cfg)
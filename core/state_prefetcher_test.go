@@ -68,6 +68,64 @@ func TestPrefetchLeaking(t *testing.T) {
 	})
 }
 
+// Tests that warming the state of an upcoming block doesn't leak goroutines
+// once interrupted, and that the prefetch concurrency is configurable.
+func TestPrefetchNextLeaking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, block *BlockGen) {
+		for j := 0; j < 100; j++ {
+			tx, err := types.SignTx(types.NewTx(&types.AccessListTx{
+				ChainID:    gspec.Config.ChainID,
+				Nonce:      block.TxNonce(address),
+				To:         &common.Address{byte(j)},
+				Value:      big.NewInt(1000),
+				Gas:        params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas,
+				GasPrice:   block.header.BaseFee,
+				AccessList: types.AccessList{{Address: common.Address{byte(j)}, StorageKeys: []common.Hash{{byte(j)}}}},
+			}), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	config := *defaultCacheConfig
+	config.PrefetchThreads = 5
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if threads := chain.prefetcher.(*statePrefetcher).threads; threads != 5 {
+		t.Fatalf("prefetch threads mismatch: have %d, want 5", threads)
+	}
+	statedb, _ := state.NewWithSharedPool(chain.CurrentBlock().Root, chain.statedb)
+	inter := make(chan struct{})
+
+	Track(ctx, t, func(ctx context.Context) {
+		close(inter)
+		go chain.prefetcher.PrefetchNext(blocks[1], statedb, inter)
+		time.Sleep(1 * time.Second)
+	})
+	// Blocks import fine with the next ones prefetched meanwhile
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+}
+
 func Track(ctx context.Context, t *testing.T, fn func(context.Context)) {
 	label := t.Name()
 	pprof.Do(ctx, pprof.Labels("test", label), fn)
@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/tracing"
@@ -69,6 +70,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		blockHash   = block.Hash()
 		blockNumber = block.Number()
 		allLogs     []*types.Log
+		reverts     []*rawdb.RevertReason
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
 
@@ -117,6 +119,16 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	// usually do have two tx, one for validator set contract, another for system reward contract.
 	systemTxs := make([]*types.Transaction, 0, 2)
 
+	// Speculatively execute the transactions in parallel if enabled. Tracing,
+	// preimage recording and witness collection observe every state access, so
+	// they are only supported by serial execution.
+	var parallel *parallelExecutor
+	if cfg.ParallelWorkers > 1 && txNum >= parallelMinTxs && cfg.Tracer == nil && !cfg.EnablePreimageRecording &&
+		p.config.IsByzantium(blockNumber) && statedb.Witness() == nil && !statedb.GetTrie().IsVerkle() {
+		parallel = newParallelExecutor(p, block, statedb, evm, cfg)
+		defer parallel.close()
+	}
+
 	for i, tx := range block.Transactions() {
 		if isPoSA {
 			if isSystemTx, err := posa.IsSystemTransaction(tx, block.Header()); err != nil {
@@ -142,11 +154,22 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		}
 		statedb.SetTxContext(tx.Hash(), i)
 
-		receipt, err := ApplyTransactionWithEVM(msg, gp, statedb, blockNumber, blockHash, tx, usedGas, evm, bloomProcessors)
+		var (
+			receipt *types.Receipt
+			result  *ExecutionResult
+		)
+		if parallel != nil {
+			receipt, result, err = parallel.apply(i, msg, gp, statedb, blockNumber, blockHash, tx, usedGas, evm, bloomProcessors)
+		} else {
+			receipt, result, err = applyTransactionWithEVM(msg, gp, statedb, blockNumber, blockHash, tx, usedGas, evm, bloomProcessors)
+		}
 		if err != nil {
 			bloomProcessors.Close()
 			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
+		if reason := result.Revert(); len(reason) > 0 {
+			reverts = append(reverts, &rawdb.RevertReason{TxIndex: uint64(i), Reason: reason})
+		}
 		commonTxs = append(commonTxs, tx)
 		receipts = append(receipts, receipt)
 	}
@@ -170,6 +193,12 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		ProcessConsolidationQueue(&requests, evm)
 	}
 
+	// Move the next batch of merkle leaves into verkle during the transition
+	if p.config.IsVerkle(header.Number, header.Time) {
+		if err := statedb.ConvertToVerkle(params.VerkleConversionStride); err != nil {
+			return nil, err
+		}
+	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	err = p.chain.engine.Finalize(p.chain, header, tracingStateDB, &commonTxs, block.Uncles(), block.Withdrawals(), &receipts, &systemTxs, usedGas, cfg.Tracer)
 	if err != nil {
@@ -178,12 +207,17 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	for _, receipt := range receipts {
 		allLogs = append(allLogs, receipt.Logs...)
 	}
-
+	stats := evm.Stats()
+	if parallel != nil {
+		stats.Merge(parallel.stats())
+	}
 	return &ProcessResult{
 		Receipts: receipts,
 		Requests: requests,
 		Logs:     allLogs,
 		GasUsed:  *usedGas,
+		Stats:    stats,
+		Reverts:  reverts,
 	}, nil
 }
 
@@ -191,8 +225,14 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // and uses the input parameters for its environment similar to ApplyTransaction. However,
 // this method takes an already created EVM instance as input.
 func ApplyTransactionWithEVM(msg *Message, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM, receiptProcessors ...ReceiptProcessor) (receipt *types.Receipt, err error) {
+	receipt, _, err = applyTransactionWithEVM(msg, gp, statedb, blockNumber, blockHash, tx, usedGas, evm, receiptProcessors...)
+	return receipt, err
+}
+
+// applyTransactionWithEVM is the implementation of ApplyTransactionWithEVM,
+// additionally returning the raw execution result of the transaction.
+func applyTransactionWithEVM(msg *Message, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM, receiptProcessors ...ReceiptProcessor) (receipt *types.Receipt, result *ExecutionResult, err error) {
 	// Add timing measurement
-	var result *ExecutionResult
 	if tx.Gas() > largeTxGasLimit {
 		start := time.Now()
 		defer func() {
@@ -214,7 +254,7 @@ func ApplyTransactionWithEVM(msg *Message, gp *GasPool, statedb *state.StateDB,
 	// Apply the transaction to the current state (included in the env).
 	result, err = ApplyMessage(evm, msg, gp)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Update the state with pending changes.
 	var root []byte
@@ -231,7 +271,8 @@ func ApplyTransactionWithEVM(msg *Message, gp *GasPool, statedb *state.StateDB,
 		statedb.AccessEvents().Merge(evm.AccessEvents)
 	}
 
-	return MakeReceipt(evm, result, statedb, blockNumber, blockHash, tx, *usedGas, root, receiptProcessors...), nil
+	receipt = MakeReceipt(evm, result, statedb, blockNumber, blockHash, tx, *usedGas, root, receiptProcessors...)
+	return receipt, result, nil
 }
 
 // MakeReceipt generates the receipt object for a transaction given its execution result.
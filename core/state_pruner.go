@@ -0,0 +1,398 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+)
+
+const (
+	// statePruningBloomFile is the name of the bloom filter of the live state
+	// entries, persisted as the checkpoint of an interrupted pruning.
+	statePruningBloomFile = "statepruning.bloom"
+
+	// statePruningCompactionThreshold is the number of deleted entries above
+	// which the database is compacted after pruning.
+	statePruningCompactionThreshold = 1_000_000
+
+	// defaultStatePruningBloomSize is the bloom filter size in megabytes used if
+	// none is configured.
+	defaultStatePruningBloomSize = 256
+)
+
+// PrunerConfig is the configuration of the live state pruner.
+type PrunerConfig struct {
+	Datadir   string // Directory to store the checkpoint of the pruning in
+	Retain    uint64 // Number of recent canonical states to retain
+	BloomSize uint64 // Megabytes of memory allocated to the bloom filter of live entries
+}
+
+// Pruner deletes the stale state of a live hash scheme blockchain. It pauses the
+// chain for the duration of the pruning, marks the entries of the retained
+// states in a bloom filter and deletes every trie node and contract code not
+// contained, followed by compacting the database.
+//
+// Progress is checkpointed into the database, so an interrupted pruning resumes
+// where it left off on the next run. Before resuming, the states live at that
+// point are added to the persisted bloom filter, protecting the entries written
+// in the meantime.
+type Pruner struct {
+	chain  *BlockChain
+	config PrunerConfig
+}
+
+// NewPruner creates a live state pruner for the given blockchain.
+func NewPruner(chain *BlockChain, config PrunerConfig) (*Pruner, error) {
+	if chain.NoTries() || chain.TrieDB().Scheme() != rawdb.HashScheme {
+		return nil, errors.New("live pruning requires the hash state scheme")
+	}
+	if config.Retain == 0 {
+		return nil, errors.New("at least one state must be retained")
+	}
+	if config.BloomSize == 0 {
+		config.BloomSize = defaultStatePruningBloomSize
+	}
+	return &Pruner{chain: chain, config: config}, nil
+}
+
+// Prune deletes every state entry not belonging to the retained states. The
+// chain is paused for maintenance unless it's paused already. If the context is
+// cancelled, the pruning is interrupted at the last checkpoint.
+func (p *Pruner) Prune(ctx context.Context) error {
+	switch err := p.chain.Pause(ctx); {
+	case err == nil:
+		defer p.chain.Resume()
+	case errors.Is(err, errChainPaused):
+		// Already in maintenance, the chain mutex is held by the pause
+	default:
+		return err
+	}
+	var (
+		start  = time.Now()
+		path   = filepath.Join(p.config.Datadir, statePruningBloomFile)
+		db     = p.stateStore()
+		bloom  stateBloom
+		marker []byte
+	)
+	if _, err := os.Stat(path); err == nil {
+		filter, _, err := bloomfilter.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to load pruning checkpoint: %w", err)
+		}
+		bloom, marker = stateBloom{filter}, rawdb.ReadStatePruningMarker(db)
+		log.Info("Resuming state pruning", "marker", common.Bytes2Hex(marker))
+	} else {
+		filter, err := bloomfilter.New(p.config.BloomSize*1024*1024*8, 4)
+		if err != nil {
+			return err
+		}
+		bloom = stateBloom{filter}
+	}
+	if err := p.markLive(bloom); err != nil {
+		return err
+	}
+	if err := bloom.commit(path); err != nil {
+		return err
+	}
+	count, size, err := p.sweep(ctx, db, bloom, marker)
+	if err != nil {
+		return err
+	}
+	if count >= statePruningCompactionThreshold {
+		cstart := time.Now()
+		for b := 0x00; b <= 0xf0; b += 0x10 {
+			var (
+				start = []byte{byte(b)}
+				end   = []byte{byte(b + 0x10)}
+			)
+			if b == 0xf0 {
+				end = nil
+			}
+			log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
+			if err := db.Compact(start, end); err != nil {
+				return err
+			}
+		}
+	}
+	// Drop the checkpoint, the bloom filter first, so a crash in between starts
+	// a new pruning instead of resuming from a stale marker.
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	rawdb.DeleteStatePruningMarker(db)
+
+	log.Info("State pruning successful", "deleted", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// stateStore returns the database holding the state entries.
+func (p *Pruner) stateStore() ethdb.Database {
+	if p.chain.db.HasSeparateStateStore() {
+		return p.chain.db.GetStateStore()
+	}
+	return p.chain.db
+}
+
+// liveRoots returns the states to retain: the most recent canonical ones, the
+// archive checkpoints, the ones still referenced from memory and the genesis
+// state.
+func (p *Pruner) liveRoots() []common.Hash {
+	var (
+		roots []common.Hash
+		seen  = make(map[common.Hash]bool)
+	)
+	add := func(root common.Hash) {
+		if !seen[root] && p.chain.HasState(root) {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	head := p.chain.CurrentBlock().Number.Uint64()
+	for i := uint64(0); i < p.config.Retain && i <= head; i++ {
+		if header := p.chain.GetHeaderByNumber(head - i); header != nil {
+			add(header.Root)
+		}
+	}
+	// The archive checkpoints are persisted to be never pruned
+	if interval := p.chain.StateArchiveInterval(); interval > 0 {
+		for number := interval; number <= head; number += interval {
+			if header := p.chain.GetHeaderByNumber(number); header != nil {
+				add(header.Root)
+			}
+		}
+	}
+	// The tries held in memory might be flushed later on, retain the entries
+	// they reference on disk. The chain mutex is held, the queue is stable.
+	type gcEntry struct {
+		root   common.Hash
+		number int64
+	}
+	var held []gcEntry
+	for !p.chain.triegc.Empty() {
+		root, number := p.chain.triegc.Pop()
+		held = append(held, gcEntry{root, number})
+	}
+	for _, entry := range held {
+		p.chain.triegc.Push(entry.root, entry.number)
+		add(entry.root)
+	}
+	add(p.chain.Genesis().Root())
+	return roots
+}
+
+// markLive adds the entries of the retained states to the bloom filter. The
+// states are regenerated from the snapshot if available, otherwise their tries
+// are traversed.
+func (p *Pruner) markLive(bloom stateBloom) error {
+	for _, root := range p.liveRoots() {
+		if p.chain.snaps != nil && p.chain.snaps.Snapshot(root) != nil {
+			err := snapshot.GenerateTrie(p.chain.snaps, root, p.chain.db, bloom)
+			if err == nil {
+				continue
+			}
+			log.Debug("Failed to regenerate state from snapshot", "root", root, "err", err)
+		}
+		if err := p.traverse(root, bloom); err != nil {
+			return fmt.Errorf("failed to traverse state %x: %w", root, err)
+		}
+	}
+	return nil
+}
+
+// traverse adds all the trie nodes and contract codes of a state to the bloom.
+func (p *Pruner) traverse(root common.Hash, bloom stateBloom) error {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), p.chain.TrieDB())
+	if err != nil {
+		return err
+	}
+	accIter, err := t.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for accIter.Next(true) {
+		if hash := accIter.Hash(); hash != (common.Hash{}) {
+			bloom.add(hash.Bytes())
+		}
+		if !accIter.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		if acc.Root != types.EmptyRootHash {
+			id := trie.StorageTrieID(root, common.BytesToHash(accIter.LeafKey()), acc.Root)
+			storageTrie, err := trie.NewStateTrie(id, p.chain.TrieDB())
+			if err != nil {
+				return err
+			}
+			storageIter, err := storageTrie.NodeIterator(nil)
+			if err != nil {
+				return err
+			}
+			for storageIter.Next(true) {
+				if hash := storageIter.Hash(); hash != (common.Hash{}) {
+					bloom.add(hash.Bytes())
+				}
+			}
+			if err := storageIter.Error(); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			bloom.add(acc.CodeHash)
+		}
+	}
+	return accIter.Error()
+}
+
+// sweep deletes the trie nodes and contract codes not contained in the bloom
+// filter, starting at the marker. The progress is checkpointed with every batch
+// written, which is also when cancellation is checked.
+func (p *Pruner) sweep(ctx context.Context, db ethdb.Database, bloom stateBloom, marker []byte) (int, common.StorageSize, error) {
+	var (
+		count  int
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+		iter   = db.NewIterator(nil, marker)
+	)
+	defer func() { iter.Release() }()
+
+	for iter.Next() {
+		key := iter.Key()
+
+		isCode, codeKey := rawdb.IsCodeKey(key)
+		if len(key) != common.HashLength && !isCode {
+			continue
+		}
+		checkKey := key
+		if isCode {
+			checkKey = codeKey
+		}
+		if bloom.contains(checkKey) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(iter.Value()))
+		batch.Delete(key)
+
+		if batch.ValueSize() < ethdb.IdealBatchSize {
+			continue
+		}
+		// Checkpoint the progress along with the deletions, and recreate the
+		// iterator to let the compactor drop the deleted entries.
+		key = common.CopyBytes(key)
+		rawdb.WriteStatePruningMarker(batch, key)
+		if err := batch.Write(); err != nil {
+			return count, size, err
+		}
+		batch.Reset()
+
+		iter.Release()
+		iter = db.NewIterator(nil, key)
+
+		select {
+		case <-ctx.Done():
+			log.Info("State pruning interrupted", "deleted", count, "size", size)
+			return count, size, ctx.Err()
+		default:
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data", "deleted", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return count, size, err
+	}
+	if err := batch.Write(); err != nil {
+		return count, size, err
+	}
+	return count, size, nil
+}
+
+// stateBloom is a bloom filter of the live state entries, keyed by the hash of
+// the trie nodes and contract codes. It implements ethdb.KeyValueWriter to be
+// filled by the trie generator.
+type stateBloom struct {
+	filter *bloomfilter.Filter
+}
+
+// add inserts a trie node or code hash into the bloom.
+func (b stateBloom) add(hash []byte) {
+	b.filter.AddHash(binary.BigEndian.Uint64(hash))
+}
+
+// contains reports whether a trie node or code hash might be live.
+func (b stateBloom) contains(hash []byte) bool {
+	return b.filter.ContainsHash(binary.BigEndian.Uint64(hash))
+}
+
+// Put implements ethdb.KeyValueWriter, recording the key of a state entry.
+func (b stateBloom) Put(key []byte, value []byte) error {
+	if isCode, codeKey := rawdb.IsCodeKey(key); isCode {
+		b.add(codeKey)
+		return nil
+	}
+	if len(key) != common.HashLength {
+		return errors.New("invalid entry")
+	}
+	b.add(key)
+	return nil
+}
+
+// Delete implements ethdb.KeyValueWriter, entries are never removed.
+func (b stateBloom) Delete(key []byte) error {
+	return errors.New("not supported")
+}
+
+// commit persists the bloom filter atomically into the given file.
+func (b stateBloom) commit(path string) error {
+	tmp := path + ".tmp"
+	if _, err := b.filter.WriteFile(tmp); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(tmp, os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+)
+
+// Tests that the live pruner deletes the states beyond the retention, resumes
+// from checkpoints and leaves the chain able to progress.
+func TestLiveStatePruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer  = types.LatestSigner(params.TestChainConfig)
+		datadir = t.TempDir()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	// Run an archive node, so every state is persisted
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.TrieDirtyDisabled = true
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks[:8]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	pruner, err := NewPruner(chain, PrunerConfig{Datadir: datadir, Retain: 2, BloomSize: 1})
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	// Leave a checkpoint behind with all keys processed, nothing must be deleted
+	filter, _ := bloomfilter.New(8*1024*1024, 4)
+	if err := (stateBloom{filter}).commit(filepath.Join(datadir, statePruningBloomFile)); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	rawdb.WriteStatePruningMarker(db, bytes.Repeat([]byte{0xff}, 33))
+
+	if err := pruner.Prune(context.Background()); err != nil {
+		t.Fatalf("failed to resume pruning: %v", err)
+	}
+	if !rawdb.HasLegacyTrieNode(db, blocks[0].Root()) {
+		t.Fatal("state deleted before the checkpoint marker")
+	}
+	if _, err := os.Stat(filepath.Join(datadir, statePruningBloomFile)); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint retained after pruning: %v", err)
+	}
+	if marker := rawdb.ReadStatePruningMarker(db); marker != nil {
+		t.Fatalf("checkpoint marker retained after pruning: %x", marker)
+	}
+	// Prune for real and check only the recent states are retained
+	if err := pruner.Prune(context.Background()); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	if chain.Paused() {
+		t.Fatal("chain left paused after pruning")
+	}
+	for i, block := range blocks[:8] {
+		if have, want := rawdb.HasLegacyTrieNode(db, block.Root()), i >= 6; have != want {
+			t.Errorf("block %d: state present %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+	if !rawdb.HasLegacyTrieNode(db, chain.Genesis().Root()) {
+		t.Error("genesis state pruned")
+	}
+	// The chain must be able to progress on top of the retained state
+	if n, err := chain.InsertChain(blocks[8:]); err != nil {
+		t.Fatalf("failed to insert block %d after pruning: %v", n, err)
+	}
+}
+
+// Tests that the live pruner retains the archive checkpoints.
+func TestLiveStatePruningArchive(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.ArchiveInterval = 3
+	config.TriesInMemory = 2
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	pruner, err := NewPruner(chain, PrunerConfig{Datadir: t.TempDir(), Retain: 1, BloomSize: 1})
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.Prune(context.Background()); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	for _, block := range []*types.Block{blocks[2], blocks[5]} {
+		if !rawdb.HasLegacyTrieNode(db, block.Root()) {
+			t.Errorf("archive checkpoint %d pruned", block.NumberU64())
+		}
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+const (
+	// regenCacheLimit is the number of regenerated states kept around, so that
+	// repeated queries on the same historical block are served instantly.
+	regenCacheLimit = 16
+
+	// regenCacheMemory is the total memory the cached regenerated states may
+	// hold, the least recently used ones are dropped above it.
+	regenCacheMemory = 1024 * 1024 * 1024
+
+	// regenMemoryLimit is the memory a single regeneration may hold, counting
+	// both the dirty trie nodes and the ones flushed into its ephemeral
+	// database. Regenerations exceeding it are aborted.
+	regenMemoryLimit = 512 * 1024 * 1024
+
+	// regenFlushLimit is the amount of dirty trie nodes above which they are
+	// flushed into the ephemeral database of the regeneration, which stores
+	// them more compactly.
+	regenFlushLimit = 64 * 1024 * 1024
+)
+
+var (
+	stateRegenMeter      = metrics.NewRegisteredMeter("chain/state/regen/runs", nil)
+	stateRegenHitMeter   = metrics.NewRegisteredMeter("chain/state/regen/hits", nil)
+	stateRegenBlockMeter = metrics.NewRegisteredMeter("chain/state/regen/blocks", nil)
+	stateRegenTimer      = metrics.NewRegisteredTimer("chain/state/regen/time", nil)
+
+	// errRegenPathScheme is returned when regenerating a historical state in the
+	// path scheme, which only retains the state of the recent blocks.
+	errRegenPathScheme = errors.New("historical state not available in path scheme")
+
+	// errRegenUnavailable is returned if no state is available within the
+	// allowed number of blocks to re-execute.
+	errRegenUnavailable = errors.New("required historical state unavailable")
+
+	// errRegenMemory is returned if a regeneration exceeds its memory allowance.
+	errRegenMemory = errors.New("historical state regeneration exceeds memory allowance")
+)
+
+// regenTask is a state regeneration running in the background, which any
+// number of callers may wait for.
+type regenTask struct {
+	done   chan struct{} // Closed when the regeneration finishes
+	reexec uint64        // Blocks allowed to re-execute, raised by later callers
+	state  *state.StateDB
+	size   uint64 // Memory held by the regenerated state
+	err    error
+}
+
+// regenState is a cached regenerated state along with the memory it holds.
+type regenState struct {
+	state *state.StateDB
+	size  uint64
+}
+
+// stateRegenerator rebuilds pruned historical states by re-executing blocks on
+// top of the nearest state still available, deduplicating the concurrent
+// requests for the same block and caching the results.
+type stateRegenerator struct {
+	chain *BlockChain
+	cache lru.BasicLRU[common.Hash, *regenState] // Regenerated states by block hash
+	size  uint64                                 // Memory held by the cached states
+
+	tasks map[common.Hash]*regenTask // Regenerations in progress by block hash
+	lock  sync.Mutex
+}
+
+// newStateRegenerator creates the state regenerator of the chain.
+func newStateRegenerator(chain *BlockChain) *stateRegenerator {
+	return &stateRegenerator{
+		chain: chain,
+		cache: lru.NewBasicLRU[common.Hash, *regenState](regenCacheLimit),
+		tasks: make(map[common.Hash]*regenTask),
+	}
+}
+
+// StateAtBlock returns a mutable copy of the state of the given block, backed by
+// an ephemeral trie database isolated from the live one. If the state isn't on
+// disk, it's regenerated in the background by re-executing up to reexec blocks
+// on top of the nearest available ancestor state, always reaching back to the
+// nearest archive checkpoint if archiving by interval is enabled. Cancelling
+// the context abandons the wait, but not the regeneration, which still caches
+// its result for later queries.
+func (bc *BlockChain) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64) (*state.StateDB, error) {
+	if bc.triedb.Scheme() == rawdb.PathScheme {
+		return nil, errRegenPathScheme
+	}
+	if interval := bc.StateArchiveInterval(); interval > 0 {
+		reexec = max(reexec, block.NumberU64()%interval)
+	}
+	return bc.regen.stateAt(ctx, block, reexec)
+}
+
+// stateAt serves a state from the cache, or waits for its regeneration. Joining
+// a regeneration in progress raises its re-execution limit to the requested
+// one, a regeneration which gave up below it is retried.
+func (r *stateRegenerator) stateAt(ctx context.Context, block *types.Block, reexec uint64) (*state.StateDB, error) {
+	hash := block.Hash()
+	for {
+		r.lock.Lock()
+		if cached, ok := r.cache.Get(hash); ok {
+			r.lock.Unlock()
+			stateRegenHitMeter.Mark(1)
+			return cached.state.Copy(), nil
+		}
+		task, ok := r.tasks[hash]
+		if !ok {
+			task = &regenTask{done: make(chan struct{}), reexec: reexec}
+			r.tasks[hash] = task
+			go r.run(task, block)
+		}
+		task.reexec = max(task.reexec, reexec)
+		r.lock.Unlock()
+
+		select {
+		case <-task.done:
+			if errors.Is(task.err, errRegenUnavailable) && task.reexec < reexec {
+				continue
+			}
+			if task.err != nil {
+				return nil, task.err
+			}
+			return task.state.Copy(), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-r.chain.quit:
+			return nil, errChainStopped
+		}
+	}
+}
+
+// run regenerates the state of a block, publishing the result to the waiters
+// and the cache.
+func (r *stateRegenerator) run(task *regenTask, block *types.Block) {
+	start := time.Now()
+	task.state, task.size, task.err = r.regenerate(task, block)
+
+	r.lock.Lock()
+	if task.err == nil {
+		r.add(block.Hash(), &regenState{state: task.state, size: task.size})
+	}
+	delete(r.tasks, block.Hash())
+	r.lock.Unlock()
+	close(task.done)
+
+	stateRegenMeter.Mark(1)
+	stateRegenTimer.UpdateSince(start)
+}
+
+// add caches a regenerated state, dropping the least recently used ones above
+// the memory allowance. The caller must hold the lock.
+func (r *stateRegenerator) add(hash common.Hash, cached *regenState) {
+	if cached.size > regenCacheMemory {
+		return
+	}
+	for r.cache.Len() > 0 && (r.size+cached.size > regenCacheMemory || r.cache.Len() >= regenCacheLimit) {
+		_, evicted, _ := r.cache.RemoveOldest()
+		r.size -= evicted.size
+	}
+	r.cache.Add(hash, cached)
+	r.size += cached.size
+}
+
+// reexecLimit returns the number of blocks the task may re-execute.
+func (r *stateRegenerator) reexecLimit(task *regenTask) uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return task.reexec
+}
+
+// regenerate finds the nearest ancestor of the block whose state is available
+// on disk and re-executes the blocks on top of it. The states are rebuilt over
+// an ephemeral database, isolated from the live one, and only the latest one
+// is held in memory. The memory held by the resulting state is returned too.
+func (r *stateRegenerator) regenerate(task *regenTask, block *types.Block) (*state.StateDB, uint64, error) {
+	var (
+		bc       = r.chain
+		diskdb   = newRegenDatabase(bc.db)
+		tdb      = triedb.NewDatabase(diskdb, triedb.HashDefaults)
+		database = state.NewDatabase(tdb, nil)
+		current  = block
+		path     []common.Hash // Hashes of the blocks to re-execute, newest first
+		statedb  *state.StateDB
+		err      error
+	)
+	for {
+		if statedb, err = state.New(current.Root(), database); err == nil {
+			break
+		}
+		if reexec := r.reexecLimit(task); uint64(len(path)) >= reexec {
+			return nil, 0, fmt.Errorf("%w (reexec=%d)", errRegenUnavailable, reexec)
+		}
+		if current.NumberU64() == 0 {
+			return nil, 0, errors.New("genesis state is missing")
+		}
+		parent := bc.GetBlock(current.ParentHash(), current.NumberU64()-1)
+		if parent == nil {
+			return nil, 0, fmt.Errorf("missing block %x %d", current.ParentHash(), current.NumberU64()-1)
+		}
+		path = append(path, current.Hash())
+		current = parent
+	}
+	var (
+		start  = time.Now()
+		logged time.Time
+		parent common.Hash
+		size   uint64
+	)
+	for i := len(path) - 1; i >= 0; i-- {
+		select {
+		case <-bc.quit:
+			return nil, 0, errChainStopped
+		default:
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Regenerating historical state", "block", current.NumberU64()+1, "target", block.NumberU64(), "remaining", i+1, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		next := bc.GetBlock(path[i], current.NumberU64()+1)
+		if next == nil {
+			return nil, 0, fmt.Errorf("missing block %x %d", path[i], current.NumberU64()+1)
+		}
+		current = next
+		statedb.SetExpectedStateRoot(current.Root())
+		if _, err := bc.processor.Process(current, statedb, vm.Config{}); err != nil {
+			return nil, 0, fmt.Errorf("processing block %d failed: %v", current.NumberU64(), err)
+		}
+		root, err := statedb.Commit(current.NumberU64(), bc.chainConfig.IsEIP158(current.Number()), bc.chainConfig.IsCancun(current.Number(), current.Time()))
+		if err != nil {
+			return nil, 0, fmt.Errorf("state commit failed, number %d root %v: %w", current.NumberU64(), current.Root(), err)
+		}
+		if root != current.Root() {
+			return nil, 0, fmt.Errorf("regenerated root mismatch, number %d: have %x, want %x", current.NumberU64(), root, current.Root())
+		}
+		stateRegenBlockMeter.Mark(1)
+
+		// Only keep the latest state alive, flushing it into the ephemeral
+		// database if the dirty nodes grow large
+		tdb.Reference(root, common.Hash{})
+		if parent != (common.Hash{}) {
+			tdb.Dereference(parent)
+		}
+		parent = root
+
+		_, nodes, _, _ := tdb.Size()
+		if nodes > regenFlushLimit {
+			if err := tdb.Commit(root, false); err != nil {
+				return nil, 0, err
+			}
+			_, nodes, _, _ = tdb.Size()
+		}
+		if size = uint64(nodes) + diskdb.size.Load(); size > regenMemoryLimit {
+			return nil, 0, fmt.Errorf("%w, block %d", errRegenMemory, current.NumberU64())
+		}
+		if statedb, err = state.New(root, database); err != nil {
+			return nil, 0, fmt.Errorf("state reset after block %d failed: %v", current.NumberU64(), err)
+		}
+	}
+	if len(path) > 0 {
+		log.Info("Historical state regenerated", "block", block.NumberU64(), "reexec", len(path), "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return statedb, size, nil
+}
+
+// regenDatabase is an ephemeral database layered over the chain database. The
+// data written during a regeneration is kept in memory, while the reads fall
+// through to the chain database, which is never written to. Iterators only
+// cover the chain database, the trie database doesn't need them.
+type regenDatabase struct {
+	ethdb.Database                    // Chain database serving the reads
+	mem            *memorydb.Database // Data written during the regeneration
+	size           atomic.Uint64      // Size of the written data
+}
+
+// newRegenDatabase creates an ephemeral database over the chain database.
+func newRegenDatabase(db ethdb.Database) *regenDatabase {
+	return &regenDatabase{Database: db, mem: memorydb.New()}
+}
+
+// Has retrieves if a key is present in the ephemeral or the chain database.
+func (db *regenDatabase) Has(key []byte) (bool, error) {
+	if has, _ := db.mem.Has(key); has {
+		return true, nil
+	}
+	return db.Database.Has(key)
+}
+
+// Get retrieves the given key from the ephemeral or the chain database.
+func (db *regenDatabase) Get(key []byte) ([]byte, error) {
+	if blob, err := db.mem.Get(key); err == nil {
+		return blob, nil
+	}
+	return db.Database.Get(key)
+}
+
+// Put inserts the given value into the ephemeral database.
+func (db *regenDatabase) Put(key []byte, value []byte) error {
+	db.size.Add(uint64(len(key) + len(value)))
+	return db.mem.Put(key, value)
+}
+
+// Delete removes the key from the ephemeral database. Keys of the chain
+// database are left visible.
+func (db *regenDatabase) Delete(key []byte) error {
+	return db.mem.Delete(key)
+}
+
+// NewBatch creates a write-only batch into the ephemeral database.
+func (db *regenDatabase) NewBatch() ethdb.Batch {
+	return &regenBatch{Batch: db.mem.NewBatch(), db: db}
+}
+
+// NewBatchWithSize creates a write-only batch into the ephemeral database with
+// pre-allocated buffer.
+func (db *regenDatabase) NewBatchWithSize(size int) ethdb.Batch {
+	return &regenBatch{Batch: db.mem.NewBatchWithSize(size), db: db}
+}
+
+// regenBatch is a batch into the ephemeral database of a regeneration, tracking
+// the size of the written data.
+type regenBatch struct {
+	ethdb.Batch
+	db *regenDatabase
+}
+
+// Write flushes the batch into the ephemeral database.
+func (b *regenBatch) Write() error {
+	b.db.size.Add(uint64(b.ValueSize()))
+	return b.Batch.Write()
+}
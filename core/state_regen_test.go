@@ -0,0 +1,151 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// Tests that historical states missing from disk are regenerated on top of the
+// nearest persisted ancestor, and cached without leaking the callers' changes.
+func TestStateAtBlock(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Only the genesis state is on disk, so the regeneration must reach it
+	target := blocks[4]
+	if _, err := chain.StateAtBlock(context.Background(), target, 4); err == nil {
+		t.Fatal("state regenerated beyond the reexec limit")
+	}
+	statedb, err := chain.StateAtBlock(context.Background(), target, 5)
+	if err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != target.Root() {
+		t.Fatalf("regenerated root mismatch: have %x, want %x", root, target.Root())
+	}
+	if !chain.regen.cache.Contains(target.Hash()) {
+		t.Fatal("regenerated state not cached")
+	}
+	// Changes to the returned state must not leak into the cached one
+	statedb.AddBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+
+	statedb, err = chain.StateAtBlock(context.Background(), target, 0)
+	if err != nil {
+		t.Fatalf("failed to retrieve cached state: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != target.Root() {
+		t.Fatalf("cached root mismatch: have %x, want %x", root, target.Root())
+	}
+	if chain.regen.size == 0 {
+		t.Fatal("memory of the cached state not accounted")
+	}
+	// Joining a regeneration with a higher limit must succeed regardless of the
+	// limit it was started with
+	var (
+		wg   sync.WaitGroup
+		errs [2]error
+	)
+	for i, reexec := range []uint64{5, 6} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = chain.StateAtBlock(context.Background(), blocks[5], reexec)
+		}()
+	}
+	wg.Wait()
+	if !errors.Is(errs[0], errRegenUnavailable) && errs[0] != nil {
+		t.Fatalf("limited regeneration error mismatch: have %v", errs[0])
+	}
+	if errs[1] != nil {
+		t.Fatalf("failed to regenerate state with raised limit: %v", errs[1])
+	}
+	// Cancelled queries return without waiting for the regeneration
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := chain.StateAtBlock(ctx, blocks[6], 10); err != context.Canceled && err != nil {
+		t.Fatalf("cancelled query error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
+
+// Tests that the ephemeral database of a regeneration serves the chain data but
+// keeps all writes in memory.
+func TestRegenDatabase(t *testing.T) {
+	chaindb := rawdb.NewMemoryDatabase()
+	chaindb.Put([]byte("a"), []byte{1})
+
+	db := newRegenDatabase(chaindb)
+	if blob, err := db.Get([]byte("a")); err != nil || !bytes.Equal(blob, []byte{1}) {
+		t.Fatalf("chain data not served: %x %v", blob, err)
+	}
+	db.Put([]byte("a"), []byte{2})
+	batch := db.NewBatch()
+	batch.Put([]byte("b"), []byte{3})
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if blob, _ := db.Get([]byte("a")); !bytes.Equal(blob, []byte{2}) {
+		t.Fatalf("overwritten data mismatch: have %x, want 02", blob)
+	}
+	if has, _ := db.Has([]byte("b")); !has {
+		t.Fatal("batch not written")
+	}
+	if blob, _ := chaindb.Get([]byte("a")); !bytes.Equal(blob, []byte{1}) {
+		t.Fatalf("chain database written: have %x, want 01", blob)
+	}
+	if has, _ := chaindb.Has([]byte("b")); has {
+		t.Fatal("chain database written by batch")
+	}
+	if size := db.size.Load(); size != 4 {
+		t.Fatalf("written size mismatch: have %d, want 4", size)
+	}
+}
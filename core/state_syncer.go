@@ -0,0 +1,330 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+var errHealingStarted = errors.New("state healing already started")
+
+// StateSyncer ingests the state of a block directly into the flat state and
+// the trie database, allowing sync drivers other than the snap protocol (e.g.
+// state dumps or trusted peers) to reuse the machinery of snap sync.
+//
+// The state is delivered in two phases. First the account and storage ranges,
+// in the format served by the snap protocol, are verified against their proofs
+// and written to the flat state. Ranges may be served from a state root other
+// than the target one, e.g. if the sync pivot moved. Heal then generates the
+// tries from the flat state and schedules the retrieval of the trie nodes and
+// codes missing or inconsistent with the target root, delivered through
+// ProcessNode and ProcessCode until nothing is pending.
+//
+// As with snap sync, the flat state may retain entries deleted from the target
+// state, it has to be verified by the snapshot generator afterwards.
+//
+// StateSyncer is not safe for concurrent use.
+type StateSyncer struct {
+	db     ethdb.Database
+	scheme string
+	root   common.Hash
+
+	states ethdb.Batch // Batch of flat state and code writes
+	nodes  ethdb.Batch // Batch of trie node writes, maybe to the separate state store
+	healer *trie.Sync  // Scheduler of the trie nodes and codes to heal, nil until healing
+}
+
+// NewStateSyncer creates a state syncer filling the state of the given root.
+func NewStateSyncer(db ethdb.Database, scheme string, root common.Hash) *StateSyncer {
+	nodes := db.NewBatch()
+	if db.HasSeparateStateStore() {
+		nodes = db.GetStateStore().NewBatch()
+	}
+	return &StateSyncer{
+		db:     db,
+		scheme: scheme,
+		root:   root,
+		states: db.NewBatch(),
+		nodes:  nodes,
+	}
+}
+
+// Root returns the state root being synced.
+func (s *StateSyncer) Root() common.Hash {
+	return s.root
+}
+
+// ProcessAccountRange verifies a range of accounts, given as hashes and slim
+// RLP encoded accounts, against the state root it was served from and writes
+// it to the flat state. The proof may only be omitted if the range covers the
+// whole state. It reports whether more accounts follow the range.
+func (s *StateSyncer) ProcessAccountRange(root common.Hash, origin common.Hash, hashes []common.Hash, accounts [][]byte, proof [][]byte) (bool, error) {
+	if s.healer != nil {
+		return false, errHealingStarted
+	}
+	if len(hashes) != len(accounts) {
+		return false, fmt.Errorf("account range mismatch: %d hashes, %d accounts", len(hashes), len(accounts))
+	}
+	keys := make([][]byte, len(hashes))
+	values := make([][]byte, len(accounts))
+	for i, account := range accounts {
+		full, err := types.FullAccountRLP(account)
+		if err != nil {
+			return false, fmt.Errorf("invalid account %x: %v", hashes[i], err)
+		}
+		keys[i], values[i] = common.CopyBytes(hashes[i][:]), full
+	}
+	more, err := verifyRange(root, origin, keys, values, proof)
+	if err != nil {
+		return false, fmt.Errorf("account range failed proof: %w", err)
+	}
+	for i, hash := range hashes {
+		rawdb.WriteAccountSnapshot(s.states, hash, accounts[i])
+	}
+	return more, s.flush(false)
+}
+
+// ProcessStorageRange verifies a range of storage slots of an account, given
+// as hashes and RLP encoded values, against the storage root it was served
+// from and writes it to the flat state. The proof may only be omitted if the
+// range covers the whole storage. It reports whether more slots follow the
+// range.
+func (s *StateSyncer) ProcessStorageRange(root common.Hash, account common.Hash, origin common.Hash, hashes []common.Hash, slots [][]byte, proof [][]byte) (bool, error) {
+	if s.healer != nil {
+		return false, errHealingStarted
+	}
+	if len(hashes) != len(slots) {
+		return false, fmt.Errorf("storage range mismatch: %d hashes, %d slots", len(hashes), len(slots))
+	}
+	keys := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	more, err := verifyRange(root, origin, keys, slots, proof)
+	if err != nil {
+		return false, fmt.Errorf("storage range of %x failed proof: %w", account, err)
+	}
+	for i, hash := range hashes {
+		rawdb.WriteStorageSnapshot(s.states, account, hash, slots[i])
+	}
+	return more, s.flush(false)
+}
+
+// ProcessCodes writes contract codes, keyed by their hashes.
+func (s *StateSyncer) ProcessCodes(codes [][]byte) error {
+	for _, code := range codes {
+		rawdb.WriteCode(s.states, crypto.Keccak256Hash(code), code)
+	}
+	return s.flush(false)
+}
+
+// verifyRange checks a range of trie leaves against its proof, reporting
+// whether more leaves follow it.
+func verifyRange(root common.Hash, origin common.Hash, keys [][]byte, values [][]byte, proof [][]byte) (bool, error) {
+	if len(proof) == 0 {
+		// No proof, the range must hash to the root on its own
+		return trie.VerifyRangeProof(root, nil, keys, values, nil)
+	}
+	nodes := make(trienode.ProofList, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return trie.VerifyRangeProof(root, origin[:], keys, values, nodes.Set())
+}
+
+// Heal ends the ingestion of ranges: it generates the tries from the flat state
+// and schedules the healing of the state towards the target root. Accounts
+// whose storage or code is incomplete are left out of the generated account
+// trie, so that the healer descends into them.
+func (s *StateSyncer) Heal() error {
+	if s.healer != nil {
+		return errHealingStarted
+	}
+	// Flush the ingested ranges and codes, the tries are generated from the database
+	if err := s.flush(true); err != nil {
+		return err
+	}
+	var (
+		incomplete [][]byte // Sorted trie paths of the incomplete accounts
+		accounts   = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+			// Skip the nodes containing an incomplete account
+			n := sort.Search(len(incomplete), func(i int) bool { return bytes.Compare(incomplete[i], path) >= 0 })
+			if n < len(incomplete) && bytes.HasPrefix(incomplete[n], path) {
+				return
+			}
+			rawdb.WriteTrieNode(s.nodes, common.Hash{}, path, hash, blob, s.scheme)
+		})
+		it = rawdb.NewKeyLengthIterator(s.db.NewIterator(rawdb.SnapshotAccountPrefix, nil), len(rawdb.SnapshotAccountPrefix)+common.HashLength)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		hash := common.BytesToHash(it.Key()[len(rawdb.SnapshotAccountPrefix):])
+		account, err := types.FullAccount(it.Value())
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", hash, err)
+		}
+		root, err := s.generateStorage(hash)
+		if err != nil {
+			return err
+		}
+		code := common.BytesToHash(account.CodeHash)
+		if root != account.Root || (code != types.EmptyCodeHash && !rawdb.HasCode(s.db, code)) {
+			incomplete = append(incomplete, keyNibbles(hash))
+		}
+		full, _ := rlp.EncodeToBytes(account)
+		if err := accounts.Update(hash[:], full); err != nil {
+			return err
+		}
+		if err := s.flush(false); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	accounts.Hash()
+	if err := s.flush(true); err != nil {
+		return err
+	}
+	s.healer = state.NewStateSync(s.root, s.db, s.onHealState, s.scheme)
+	return nil
+}
+
+// generateStorage generates the storage trie of an account from the flat state,
+// returning its root.
+func (s *StateSyncer) generateStorage(account common.Hash) (common.Hash, error) {
+	storage := trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteTrieNode(s.nodes, account, path, hash, blob, s.scheme)
+	})
+	it := rawdb.IterateStorageSnapshots(s.db, account)
+	defer it.Release()
+
+	for it.Next() {
+		slot := it.Key()[len(rawdb.SnapshotStoragePrefix)+common.HashLength:]
+		if err := storage.Update(slot, common.CopyBytes(it.Value())); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return common.Hash{}, err
+	}
+	return storage.Hash(), nil
+}
+
+// keyNibbles returns the trie path of a key, without the terminator.
+func keyNibbles(key common.Hash) []byte {
+	nibbles := make([]byte, 2*len(key))
+	for i, b := range key {
+		nibbles[2*i], nibbles[2*i+1] = b/16, b%16
+	}
+	return nibbles
+}
+
+// onHealState writes the accounts and storage slots retrieved while healing to
+// the flat state.
+func (s *StateSyncer) onHealState(paths [][]byte, value []byte) error {
+	switch len(paths) {
+	case 1:
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return err
+		}
+		rawdb.WriteAccountSnapshot(s.states, common.BytesToHash(paths[0]), types.SlimAccountRLP(account))
+	case 2:
+		rawdb.WriteStorageSnapshot(s.states, common.BytesToHash(paths[0]), common.BytesToHash(paths[1]), value)
+	}
+	return nil
+}
+
+// Missing retrieves the trie nodes and codes to heal, up to max items if
+// non-zero. The trie nodes are identified by both their hashes and their
+// paths, to be converted with trie.NewSyncPath for retrieval by path.
+func (s *StateSyncer) Missing(max int) (paths []string, nodes []common.Hash, codes []common.Hash) {
+	if s.healer == nil {
+		return nil, nil, nil
+	}
+	return s.healer.Missing(max)
+}
+
+// ProcessNode delivers a trie node requested by Missing, identified by its path.
+func (s *StateSyncer) ProcessNode(path string, blob []byte) error {
+	if s.healer == nil {
+		return trie.ErrNotRequested
+	}
+	return s.healer.ProcessNode(trie.NodeSyncResult{Path: path, Data: blob})
+}
+
+// ProcessCode delivers a code requested by Missing.
+func (s *StateSyncer) ProcessCode(hash common.Hash, code []byte) error {
+	if s.healer == nil {
+		return trie.ErrNotRequested
+	}
+	return s.healer.ProcessCode(trie.CodeSyncResult{Hash: hash, Data: code})
+}
+
+// Pending returns the number of trie nodes and codes being healed. Once it
+// drops to zero after Heal, the state is complete.
+func (s *StateSyncer) Pending() int {
+	if s.healer == nil {
+		return 0
+	}
+	return s.healer.Pending()
+}
+
+// Commit flushes the healed trie nodes and codes, along with the pending flat
+// state, to the database.
+func (s *StateSyncer) Commit() error {
+	if s.healer != nil {
+		var nodes ethdb.Batch
+		if s.db.HasSeparateStateStore() {
+			nodes = s.nodes
+		}
+		if err := s.healer.Commit(s.states, nodes); err != nil {
+			return err
+		}
+	}
+	return s.flush(true)
+}
+
+// flush writes the pending batches to the database, if forced or if they grew
+// large enough.
+func (s *StateSyncer) flush(force bool) error {
+	for _, batch := range []ethdb.Batch{s.states, s.nodes} {
+		if batch.ValueSize() == 0 || (!force && batch.ValueSize() < ethdb.IdealBatchSize) {
+			continue
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	return nil
+}
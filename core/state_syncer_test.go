@@ -0,0 +1,217 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// stateSyncSource is a state to be synced, with its accounts and storages laid
+// out as served by the snap protocol.
+type stateSyncSource struct {
+	db       ethdb.Database
+	root     common.Hash
+	hashes   []common.Hash
+	accounts [][]byte                      // Slim RLP encoded accounts
+	roots    map[common.Hash]common.Hash   // Storage roots by account hash
+	slots    map[common.Hash][]common.Hash // Slot hashes by account hash
+	values   map[common.Hash][][]byte      // Slot values by account hash
+	codes    [][]byte
+}
+
+func newStateSyncSource(t *testing.T) *stateSyncSource {
+	db := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(db, nil)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(tdb, nil))
+	for i := byte(1); i <= 3; i++ {
+		addr := common.Address{i}
+		statedb.SetBalance(addr, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+		if i == 1 {
+			continue
+		}
+		statedb.SetCode(addr, []byte{i, 0x00})
+		for j := byte(1); j <= 4*i; j++ {
+			statedb.SetState(addr, common.Hash{j}, common.Hash{i, j})
+		}
+	}
+	root, err := statedb.Commit(0, true, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := tdb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit tries: %v", err)
+	}
+	src := &stateSyncSource{
+		db:     db,
+		root:   root,
+		roots:  make(map[common.Hash]common.Hash),
+		slots:  make(map[common.Hash][]common.Hash),
+		values: make(map[common.Hash][][]byte),
+	}
+	tr, _ := trie.New(trie.StateTrieID(root), tdb)
+	for it := trie.NewIterator(tr.MustNodeIterator(nil)); it.Next(); {
+		hash := common.BytesToHash(it.Key)
+		account, _ := types.FullAccount(it.Value)
+
+		src.hashes = append(src.hashes, hash)
+		src.accounts = append(src.accounts, types.SlimAccountRLP(*account))
+		if common.BytesToHash(account.CodeHash) != types.EmptyCodeHash {
+			src.codes = append(src.codes, rawdb.ReadCode(db, common.BytesToHash(account.CodeHash)))
+		}
+		src.roots[hash] = account.Root
+		st, _ := trie.New(trie.StorageTrieID(root, hash, account.Root), tdb)
+		for sit := trie.NewIterator(st.MustNodeIterator(nil)); sit.Next(); {
+			src.slots[hash] = append(src.slots[hash], common.BytesToHash(sit.Key))
+			src.values[hash] = append(src.values[hash], common.CopyBytes(sit.Value))
+		}
+	}
+	return src
+}
+
+// checkSyncedState checks that the synced state matches the source one.
+func checkSyncedState(t *testing.T, db ethdb.Database, root common.Hash) {
+	t.Helper()
+
+	statedb, err := state.New(root, state.NewDatabase(triedb.NewDatabase(db, nil), nil))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	for i := byte(1); i <= 3; i++ {
+		addr := common.Address{i}
+		if balance := statedb.GetBalance(addr); balance.Uint64() != uint64(i) {
+			t.Errorf("account %x: balance mismatch: have %v, want %d", addr, balance, i)
+		}
+		if i == 1 {
+			continue
+		}
+		if code := statedb.GetCode(addr); len(code) != 2 || code[0] != i {
+			t.Errorf("account %x: code mismatch: have %x", addr, code)
+		}
+		for j := byte(1); j <= 4*i; j++ {
+			if have, want := statedb.GetState(addr, common.Hash{j}), (common.Hash{i, j}); have != want {
+				t.Errorf("account %x slot %x: have %x, want %x", addr, j, have, want)
+			}
+		}
+	}
+	if err := statedb.Error(); err != nil {
+		t.Fatalf("failed to read synced state: %v", err)
+	}
+}
+
+// Tests that a state ingested as complete ranges needs no healing.
+func TestStateSyncerRanges(t *testing.T) {
+	src := newStateSyncSource(t)
+	db := rawdb.NewMemoryDatabase()
+	syncer := NewStateSyncer(db, rawdb.HashScheme, src.root)
+
+	if more, err := syncer.ProcessAccountRange(src.root, common.Hash{}, src.hashes, src.accounts, nil); err != nil || more {
+		t.Fatalf("failed to process accounts: more %v, err %v", more, err)
+	}
+	for _, hash := range src.hashes {
+		if more, err := syncer.ProcessStorageRange(src.roots[hash], hash, common.Hash{}, src.slots[hash], src.values[hash], nil); err != nil || more {
+			t.Fatalf("failed to process storage of %x: more %v, err %v", hash, more, err)
+		}
+	}
+	if err := syncer.ProcessCodes(src.codes); err != nil {
+		t.Fatalf("failed to process codes: %v", err)
+	}
+	if err := syncer.Heal(); err != nil {
+		t.Fatalf("failed to heal: %v", err)
+	}
+	if pending := syncer.Pending(); pending != 0 {
+		t.Fatalf("pending heals mismatch: have %d, want 0", pending)
+	}
+	checkSyncedState(t, db, src.root)
+}
+
+// Tests that the storage and code missing from the ranges are healed.
+func TestStateSyncerHeal(t *testing.T) {
+	src := newStateSyncSource(t)
+	db := rawdb.NewMemoryDatabase()
+	syncer := NewStateSyncer(db, rawdb.HashScheme, src.root)
+
+	if _, err := syncer.ProcessAccountRange(src.root, common.Hash{}, src.hashes, src.accounts, nil); err != nil {
+		t.Fatalf("failed to process accounts: %v", err)
+	}
+	// Deliver the storage of a single account, and no code
+	var delivered bool
+	for _, hash := range src.hashes {
+		if len(src.slots[hash]) == 0 || delivered {
+			continue
+		}
+		if _, err := syncer.ProcessStorageRange(src.roots[hash], hash, common.Hash{}, src.slots[hash], src.values[hash], nil); err != nil {
+			t.Fatalf("failed to process storage of %x: %v", hash, err)
+		}
+		delivered = true
+	}
+	if err := syncer.Heal(); err != nil {
+		t.Fatalf("failed to heal: %v", err)
+	}
+	if syncer.Pending() == 0 {
+		t.Fatal("nothing to heal")
+	}
+	for syncer.Pending() > 0 {
+		paths, nodes, codes := syncer.Missing(0)
+		for i, hash := range nodes {
+			if err := syncer.ProcessNode(paths[i], rawdb.ReadLegacyTrieNode(src.db, hash)); err != nil {
+				t.Fatalf("failed to process node %x: %v", hash, err)
+			}
+		}
+		for _, hash := range codes {
+			if err := syncer.ProcessCode(hash, rawdb.ReadCode(src.db, hash)); err != nil {
+				t.Fatalf("failed to process code %x: %v", hash, err)
+			}
+		}
+		if err := syncer.Commit(); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	checkSyncedState(t, db, src.root)
+}
+
+// Tests that ranges failing their proofs are rejected.
+func TestStateSyncerBadRange(t *testing.T) {
+	src := newStateSyncSource(t)
+	syncer := NewStateSyncer(rawdb.NewMemoryDatabase(), rawdb.HashScheme, src.root)
+
+	// Drop an account from a range claiming to be complete
+	if _, err := syncer.ProcessAccountRange(src.root, common.Hash{}, src.hashes[1:], src.accounts[1:], nil); err == nil {
+		t.Fatal("incomplete account range accepted")
+	}
+	// Tamper with a storage slot
+	for _, hash := range src.hashes {
+		if len(src.slots[hash]) == 0 {
+			continue
+		}
+		values := append([][]byte{crypto.Keccak256(src.values[hash][0])}, src.values[hash][1:]...)
+		if _, err := syncer.ProcessStorageRange(src.roots[hash], hash, common.Hash{}, src.slots[hash], values, nil); err == nil {
+			t.Fatalf("tampered storage range of %x accepted", hash)
+		}
+		break
+	}
+}
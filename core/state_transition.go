@@ -195,7 +195,7 @@ func TransactionToMessage(tx *types.Transaction, s types.Signer, baseFee *big.In
 		}
 	}
 	var err error
-	msg.From, err = types.Sender(s, tx)
+	msg.From, err = types.SharedSender(s, tx)
 	return msg, err
 }
 
@@ -307,9 +307,16 @@ func (st *stateTransition) buyGas() error {
 func (st *stateTransition) preCheck() error {
 	// Only check transactions that are not fake
 	msg := st.msg
+
+	// Brand new senders have neither a nonce nor code, skip looking them up if
+	// the flat state knows them to be missing
+	absent := st.state.AccountAbsent(msg.From)
 	if !msg.SkipNonceChecks {
 		// Make sure this transaction's nonce is correct.
-		stNonce := st.state.GetNonce(msg.From)
+		var stNonce uint64
+		if !absent {
+			stNonce = st.state.GetNonce(msg.From)
+		}
 		if msgNonce := msg.Nonce; stNonce < msgNonce {
 			return fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooHigh,
 				msg.From.Hex(), msgNonce, stNonce)
@@ -321,7 +328,7 @@ func (st *stateTransition) preCheck() error {
 				msg.From.Hex(), stNonce)
 		}
 	}
-	if !msg.SkipFromEOACheck {
+	if !msg.SkipFromEOACheck && !absent {
 		// Make sure the sender is an EOA
 		code := st.state.GetCode(msg.From)
 		_, delegated := types.ParseDelegation(code)
@@ -17,6 +17,10 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
@@ -31,6 +35,20 @@ import (
 	"github.com/ethereum/go-ethereum/triedb"
 )
 
+var (
+	// errWitnessNoHeaders is returned if a witness does not contain the parent
+	// header of the block to execute.
+	errWitnessNoHeaders = errors.New("witness missing parent header")
+
+	// errWitnessParentMismatch is returned if the first header of a witness is
+	// not the parent of the block to execute.
+	errWitnessParentMismatch = errors.New("witness parent mismatch")
+
+	// errWitnessBrokenChain is returned if the headers of a witness do not form
+	// a contiguous chain.
+	errWitnessBrokenChain = errors.New("witness headers not contiguous")
+)
+
 // ExecuteStateless runs a stateless execution based on a witness, verifies
 // everything it can locally and returns the state root and receipt root, that
 // need the other side to explicitly check.
@@ -49,6 +67,11 @@ func ExecuteStateless(config *params.ChainConfig, vmconfig vm.Config, block *typ
 	if block.ReceiptHash() != (common.Hash{}) {
 		log.Error("stateless runner received receipt root it's expected to calculate (faulty consensus client)", "block", block.Number())
 	}
+	// Ensure the witness is anchored to the parent of the block, otherwise the
+	// pre-state root and the block hashes it serves cannot be trusted
+	if err := verifyWitnessHeaders(block.Header(), witness); err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
 	// Create and populate the state database to serve as the stateless backend
 	memdb := witness.MakeHashDB()
 	db, err := state.New(witness.Root(), state.NewDatabase(triedb.NewDatabase(memdb, triedb.HashDefaults), nil))
@@ -57,10 +80,13 @@ func ExecuteStateless(config *params.ChainConfig, vmconfig vm.Config, block *typ
 	}
 	// Create a blockchain that is idle, but can be used to access headers through
 	chain := &HeaderChain{
-		config:      config,
-		chainDb:     memdb,
-		headerCache: lru.NewCache[common.Hash, *types.Header](256),
-		engine:      beacon.New(ethash.NewFaker()),
+		config:        config,
+		chainDb:       memdb,
+		headerCache:   lru.NewCache[common.Hash, *types.Header](headerCacheLimit),
+		tdCache:       lru.NewCache[common.Hash, *big.Int](tdCacheLimit),
+		numberCache:   lru.NewCache[common.Hash, uint64](numberCacheLimit),
+		procInterrupt: func() bool { return false },
+		engine:        beacon.New(ethash.NewFaker()),
 	}
 	processor := NewStateProcessor(config, chain)
 	validator := NewBlockValidator(config, nil) // No chain, we only validate the state, not the block
@@ -74,7 +100,25 @@ func ExecuteStateless(config *params.ChainConfig, vmconfig vm.Config, block *typ
 		return common.Hash{}, common.Hash{}, err
 	}
 	// Almost everything validated, but receipt and state root needs to be returned
-	receiptRoot := types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
+	receiptRoot := types.DeriveSha(res.Receipts, trie.NewRootHasher(config))
 	stateRoot := db.IntermediateRoot(config.IsEIP158(block.Number()))
 	return stateRoot, receiptRoot, nil
 }
+
+// verifyWitnessHeaders checks that the headers of the witness are a contiguous
+// chain of ancestors, starting with the parent of the given block.
+func verifyWitnessHeaders(header *types.Header, witness *stateless.Witness) error {
+	if len(witness.Headers) == 0 || witness.Headers[0] == nil {
+		return errWitnessNoHeaders
+	}
+	if parent := witness.Headers[0]; parent.Hash() != header.ParentHash || parent.Number.Uint64()+1 != header.Number.Uint64() {
+		return fmt.Errorf("%w: have #%d [%x], want #%d [%x]", errWitnessParentMismatch, parent.Number, parent.Hash(), header.Number.Uint64()-1, header.ParentHash)
+	}
+	for i := 1; i < len(witness.Headers); i++ {
+		child, ancestor := witness.Headers[i-1], witness.Headers[i]
+		if ancestor == nil || ancestor.Hash() != child.ParentHash || ancestor.Number.Uint64()+1 != child.Number.Uint64() {
+			return fmt.Errorf("%w: header %d does not link to #%d", errWitnessBrokenChain, i, child.Number)
+		}
+	}
+	return nil
+}
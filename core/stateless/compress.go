@@ -0,0 +1,220 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+var (
+	witnessRawSizeHist        = metrics.NewRegisteredHistogram("stateless/witness/size/raw", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessCompressedSizeHist = metrics.NewRegisteredHistogram("stateless/witness/size/compressed", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessCodeRefMeter       = metrics.NewRegisteredMeter("stateless/witness/coderefs", nil)
+	witnessChunkMeter         = metrics.NewRegisteredMeter("stateless/witness/chunks", nil)
+)
+
+// maxDecodedWitnessSize is the maximum size of a decompressed witness, bounding
+// the memory allocated for witnesses received from untrusted sources.
+const maxDecodedWitnessSize = 128 * 1024 * 1024
+
+var (
+	errMissingWitnessCode  = errors.New("referenced witness code unavailable")
+	errInvalidWitnessChunk = errors.New("invalid witness chunk")
+	errWitnessTooLarge     = errors.New("witness too large")
+)
+
+// compactWitness is the compressed witness encoding. Trie nodes and codes are
+// sorted, so equal witnesses always encode to the same bytes.
+type compactWitness struct {
+	Headers []*types.Header
+	Codes   []compactCode
+	State   [][]byte
+}
+
+// compactCode is a bytecode of the witness, either carried in full or only
+// referenced by hash if the receiver is known to have it.
+type compactCode struct {
+	Hash common.Hash
+	Code []byte
+}
+
+// EncodeCompressed serializes the witness into its compressed format. Codes for
+// which known returns true are only referenced by hash, their contents being
+// resolved by the receiver. A nil known function carries all codes in full.
+func (w *Witness) EncodeCompressed(known func(common.Hash) bool) ([]byte, error) {
+	w.lock.Lock()
+	cw := &compactWitness{
+		Headers: w.Headers,
+		Codes:   make([]compactCode, 0, len(w.Codes)),
+		State:   make([][]byte, 0, len(w.State)),
+	}
+	for code := range w.Codes {
+		entry := compactCode{Hash: crypto.Keccak256Hash([]byte(code))}
+		if known != nil && known(entry.Hash) {
+			witnessCodeRefMeter.Mark(1)
+		} else {
+			entry.Code = []byte(code)
+		}
+		cw.Codes = append(cw.Codes, entry)
+	}
+	for node := range w.State {
+		cw.State = append(cw.State, []byte(node))
+	}
+	w.lock.Unlock()
+
+	slices.SortFunc(cw.Codes, func(a, b compactCode) int { return a.Hash.Cmp(b.Hash) })
+	slices.SortFunc(cw.State, func(a, b []byte) int { return strings.Compare(string(a), string(b)) })
+
+	raw, err := rlp.EncodeToBytes(cw)
+	if err != nil {
+		return nil, err
+	}
+	blob := snappy.Encode(nil, raw)
+
+	witnessRawSizeHist.Update(int64(len(raw)))
+	witnessCompressedSizeHist.Update(int64(len(blob)))
+	return blob, nil
+}
+
+// DecodeCompressedWitness decodes a witness from its compressed format. Codes
+// referenced by hash are resolved through readCode, which may be nil if the
+// witness is known to carry all codes in full.
+func DecodeCompressedWitness(blob []byte, readCode func(common.Hash) []byte) (*Witness, error) {
+	size, err := snappy.DecodedLen(blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress witness: %w", err)
+	}
+	if size > maxDecodedWitnessSize {
+		return nil, fmt.Errorf("%w: %d bytes decompressed, limit %d", errWitnessTooLarge, size, maxDecodedWitnessSize)
+	}
+	raw, err := snappy.Decode(nil, blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress witness: %w", err)
+	}
+	var cw compactWitness
+	if err := rlp.DecodeBytes(raw, &cw); err != nil {
+		return nil, err
+	}
+	w := &Witness{
+		Headers: cw.Headers,
+		Codes:   make(map[string]struct{}, len(cw.Codes)),
+		State:   make(map[string]struct{}, len(cw.State)),
+	}
+	for _, entry := range cw.Codes {
+		code := entry.Code
+		if len(code) == 0 {
+			if readCode != nil {
+				code = readCode(entry.Hash)
+			}
+			if len(code) == 0 {
+				return nil, fmt.Errorf("%w: %x", errMissingWitnessCode, entry.Hash)
+			}
+		}
+		if hash := crypto.Keccak256Hash(code); hash != entry.Hash {
+			return nil, fmt.Errorf("witness code hash mismatch: have %x, want %x", hash, entry.Hash)
+		}
+		w.Codes[string(code)] = struct{}{}
+	}
+	for _, node := range cw.State {
+		w.State[string(node)] = struct{}{}
+	}
+	return w, nil
+}
+
+// WitnessChunk is a piece of a compressed witness, sized for gossiping. All the
+// chunks of a witness carry the hash of the full compressed witness, which the
+// reassembled payload is checked against.
+type WitnessChunk struct {
+	Hash  common.Hash // Keccak256 hash of the full compressed witness
+	Index uint16      // Position of the chunk in the witness
+	Total uint16      // Number of chunks of the witness
+	Data  []byte      // Chunk contents
+}
+
+// SplitWitness splits a compressed witness into chunks of at most the given
+// size.
+func SplitWitness(blob []byte, size int) ([]*WitnessChunk, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid witness chunk size %d", size)
+	}
+	total := (len(blob) + size - 1) / size
+	if total == 0 {
+		total = 1
+	}
+	if total > math.MaxUint16 {
+		return nil, fmt.Errorf("witness of %d bytes exceeds %d chunks of %d bytes", len(blob), math.MaxUint16, size)
+	}
+	var (
+		hash   = crypto.Keccak256Hash(blob)
+		chunks = make([]*WitnessChunk, 0, total)
+	)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*size, len(blob))
+		chunks = append(chunks, &WitnessChunk{
+			Hash:  hash,
+			Index: uint16(i),
+			Total: uint16(total),
+			Data:  blob[i*size : end],
+		})
+	}
+	witnessChunkMeter.Mark(int64(total))
+	return chunks, nil
+}
+
+// JoinWitness reassembles a compressed witness from its chunks, which may be
+// given in any order. All chunks must be present and belong to one witness.
+func JoinWitness(chunks []*WitnessChunk) ([]byte, error) {
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("%w: no chunks", errInvalidWitnessChunk)
+	}
+	var (
+		hash   = chunks[0].Hash
+		total  = int(chunks[0].Total)
+		sorted = make([]*WitnessChunk, total)
+	)
+	if len(chunks) != total {
+		return nil, fmt.Errorf("%w: have %d chunks, want %d", errInvalidWitnessChunk, len(chunks), total)
+	}
+	for _, chunk := range chunks {
+		if chunk.Hash != hash || int(chunk.Total) != total {
+			return nil, fmt.Errorf("%w: chunk %d of another witness", errInvalidWitnessChunk, chunk.Index)
+		}
+		if int(chunk.Index) >= total || sorted[chunk.Index] != nil {
+			return nil, fmt.Errorf("%w: duplicate or out of range chunk %d", errInvalidWitnessChunk, chunk.Index)
+		}
+		sorted[chunk.Index] = chunk
+	}
+	var blob []byte
+	for _, chunk := range sorted {
+		blob = append(blob, chunk.Data...)
+	}
+	if have := crypto.Keccak256Hash(blob); have != hash {
+		return nil, fmt.Errorf("%w: hash mismatch: have %x, want %x", errInvalidWitnessChunk, have, hash)
+	}
+	return blob, nil
+}
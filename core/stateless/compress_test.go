@@ -0,0 +1,122 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestWitness() *Witness {
+	w := &Witness{
+		Headers: []*types.Header{{Number: big.NewInt(1), Difficulty: big.NewInt(1)}},
+		Codes:   make(map[string]struct{}),
+		State:   make(map[string]struct{}),
+	}
+	for i := 0; i < 64; i++ {
+		w.State[string(bytes.Repeat([]byte{byte(i)}, 100))] = struct{}{}
+	}
+	w.Codes["\x60\x00\x60\x00\xf3"] = struct{}{}
+	w.Codes["\x60\x01\x60\x00\x55"] = struct{}{}
+	return w
+}
+
+// Tests that the compressed encoding round trips, resolving referenced codes.
+func TestCompressedWitness(t *testing.T) {
+	var (
+		w        = newTestWitness()
+		shared   = []byte("\x60\x00\x60\x00\xf3")
+		codeHash = crypto.Keccak256Hash(shared)
+		known    = func(hash common.Hash) bool { return hash == codeHash }
+	)
+	blob, err := w.EncodeCompressed(known)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	again, _ := w.EncodeCompressed(known)
+	if !bytes.Equal(blob, again) {
+		t.Fatalf("encoding not deterministic")
+	}
+	if full, _ := w.EncodeCompressed(nil); len(full) <= len(blob) {
+		t.Errorf("code reference not smaller: have %d, full %d", len(blob), len(full))
+	}
+	if _, err := DecodeCompressedWitness(blob, nil); !errors.Is(err, errMissingWitnessCode) {
+		t.Fatalf("missing code not detected: %v", err)
+	}
+	dec, err := DecodeCompressedWitness(blob, func(hash common.Hash) []byte {
+		if hash == codeHash {
+			return shared
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to decode witness: %v", err)
+	}
+	if !reflect.DeepEqual(dec.Codes, w.Codes) || !reflect.DeepEqual(dec.State, w.State) {
+		t.Errorf("decoded witness mismatch")
+	}
+	if len(dec.Headers) != 1 || dec.Headers[0].Hash() != w.Headers[0].Hash() {
+		t.Errorf("decoded headers mismatch")
+	}
+	// Witnesses claiming to decompress beyond the limit are rejected upfront
+	bomb := binary.AppendUvarint(nil, maxDecodedWitnessSize+1)
+	if _, err := DecodeCompressedWitness(bomb, nil); !errors.Is(err, errWitnessTooLarge) {
+		t.Errorf("oversized witness not rejected: %v", err)
+	}
+}
+
+// Tests that witnesses are split into chunks and reassembled in any order.
+func TestWitnessChunks(t *testing.T) {
+	blob, err := newTestWitness().EncodeCompressed(nil)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	chunks, err := SplitWitness(blob, 64)
+	if err != nil {
+		t.Fatalf("failed to split witness: %v", err)
+	}
+	if want := (len(blob) + 63) / 64; len(chunks) != want {
+		t.Fatalf("chunk count mismatch: have %d, want %d", len(chunks), want)
+	}
+	reversed := make([]*WitnessChunk, len(chunks))
+	for i, chunk := range chunks {
+		reversed[len(chunks)-1-i] = chunk
+	}
+	joined, err := JoinWitness(reversed)
+	if err != nil {
+		t.Fatalf("failed to join witness: %v", err)
+	}
+	if !bytes.Equal(joined, blob) {
+		t.Fatalf("joined witness mismatch")
+	}
+	if _, err := JoinWitness(chunks[1:]); !errors.Is(err, errInvalidWitnessChunk) {
+		t.Errorf("incomplete chunks accepted: %v", err)
+	}
+	tampered := *chunks[0]
+	tampered.Data = append([]byte{tampered.Data[0] ^ 0xff}, tampered.Data[1:]...)
+	if _, err := JoinWitness(append([]*WitnessChunk{&tampered}, chunks[1:]...)); !errors.Is(err, errInvalidWitnessChunk) {
+		t.Errorf("tampered chunk accepted: %v", err)
+	}
+}
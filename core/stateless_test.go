@@ -0,0 +1,94 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that stateless execution only accepts witnesses anchored to the parent
+// of the executed block.
+func TestExecuteStatelessWitnessHeaders(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil, WithWitnessRecording(1))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[1]
+	recorded := chain.GetBlockWitness(block.Hash())
+	if recorded == nil {
+		t.Fatal("witness missing")
+	}
+	context := block.Header()
+	context.Root, context.ReceiptHash = common.Hash{}, common.Hash{}
+	task := types.NewBlockWithHeader(context).WithBody(*block.Body())
+
+	tests := []struct {
+		name   string
+		tamper func(w *stateless.Witness)
+		err    error
+	}{
+		{"valid", func(w *stateless.Witness) {}, nil},
+		{"ancestors", func(w *stateless.Witness) { w.Headers = append(w.Headers, chain.Genesis().Header()) }, nil},
+		{"no headers", func(w *stateless.Witness) { w.Headers = nil }, errWitnessNoHeaders},
+		{"wrong parent", func(w *stateless.Witness) { w.Headers[0] = chain.Genesis().Header() }, errWitnessParentMismatch},
+		{"broken chain", func(w *stateless.Witness) { w.Headers = append(w.Headers, block.Header()) }, errWitnessBrokenChain},
+	}
+	for _, tt := range tests {
+		witness := recorded.Witness.Copy()
+		tt.tamper(witness)
+
+		root, receiptRoot, err := ExecuteStateless(params.TestChainConfig, vm.Config{}, task, witness)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && (root != block.Root() || receiptRoot != block.ReceiptHash()) {
+			t.Errorf("%s: roots mismatch: have %x %x, want %x %x", tt.name, root, receiptRoot, block.Root(), block.ReceiptHash())
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// errSupplyAuditNotReady is returned if the supply is audited before the
+	// snapshot is fully generated.
+	errSupplyAuditNotReady = errors.New("snapshot generation in progress")
+
+	// errSupplyAuditAborted is returned if an audit is interrupted.
+	errSupplyAuditAborted = errors.New("supply audit aborted")
+)
+
+var (
+	supplyAuditMeter          = metrics.NewRegisteredMeter("chain/supply/audits", nil)
+	supplyAuditDivergentMeter = metrics.NewRegisteredMeter("chain/supply/divergent", nil)
+	supplyAuditTimer          = metrics.NewRegisteredTimer("chain/supply/audittime", nil)
+)
+
+// SupplyAuditConfig configures the periodic audits of the native supply.
+type SupplyAuditConfig struct {
+	Interval uint64 // Number of blocks between audits
+	Dir      string // Directory to write the reports of divergent audits into, none if empty
+}
+
+// SupplyAudit is the outcome of reconciling the sum of all account balances in
+// the state of a block against the total supply tracked for it.
+type SupplyAudit struct {
+	Number   uint64        `json:"number"`
+	Hash     common.Hash   `json:"hash"`
+	Root     common.Hash   `json:"root"`
+	Accounts uint64        `json:"accounts"`          // Number of accounts in the state
+	Balances *big.Int      `json:"balances"`          // Sum of all account balances
+	Tracked  *big.Int      `json:"tracked,omitempty"` // Total supply tracked for the block, nil if untracked
+	Diff     *big.Int      `json:"diff,omitempty"`    // Balances minus the tracked supply, nil if untracked
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// Divergent reports whether the balances don't add up to the tracked supply.
+func (a *SupplyAudit) Divergent() bool {
+	return a.Diff != nil && a.Diff.Sign() != 0
+}
+
+// EnableSupplyAudits returns a BlockChainOption which periodically sums up the
+// account balances of the head state and reconciles them against the tracked
+// total supply, as a safety net against silent state corruption.
+func EnableSupplyAudits(config SupplyAuditConfig) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if bc.snaps == nil {
+			return nil, errSnapshotsDisabled
+		}
+		if config.Interval == 0 {
+			return nil, errors.New("zero supply audit interval")
+		}
+		if config.Dir != "" {
+			if err := os.MkdirAll(config.Dir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create supply audit directory: %w", err)
+			}
+		}
+		bc.supplyAuditConfig = &config
+		return bc, nil
+	}
+}
+
+// LastSupplyAudit returns the outcome of the last periodic supply audit, or nil
+// if none completed yet.
+func (bc *BlockChain) LastSupplyAudit() *SupplyAudit {
+	return bc.lastSupplyAudit.Load()
+}
+
+// supplyAuditLoop audits the supply whenever a new head lands on an audit
+// interval. Heads arriving while an audit runs are skipped.
+func (bc *BlockChain) supplyAuditLoop(quit <-chan struct{}) {
+	heads := make(chan ChainHeadEvent, 16)
+	sub := bc.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	var (
+		config = bc.supplyAuditConfig
+		done   chan struct{}
+		abort  = make(chan struct{})
+	)
+	defer func() {
+		close(abort)
+		if done != nil {
+			<-done
+		}
+	}()
+	for {
+		select {
+		case ev := <-heads:
+			number := ev.Header.Number.Uint64()
+			if number == 0 || number%config.Interval != 0 || done != nil {
+				continue
+			}
+			done = make(chan struct{})
+			go func(header *types.Header) {
+				defer close(done)
+				bc.reportSupplyAudit(header, abort)
+			}(ev.Header)
+		case <-done:
+			done = nil
+		case <-sub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// reportSupplyAudit audits the supply of the given header, reporting the
+// outcome through the logs, the metrics and the report directory.
+func (bc *BlockChain) reportSupplyAudit(header *types.Header, abort <-chan struct{}) {
+	audit, err := bc.auditSupply(header, abort)
+	if err != nil {
+		if !errors.Is(err, errSupplyAuditAborted) {
+			log.Warn("Failed to audit supply", "number", header.Number, "hash", header.Hash(), "err", err)
+		}
+		return
+	}
+	bc.lastSupplyAudit.Store(audit)
+	if !audit.Divergent() {
+		log.Info("Audited native supply", "number", audit.Number, "accounts", audit.Accounts, "supply", audit.Balances, "elapsed", common.PrettyDuration(audit.Elapsed))
+		return
+	}
+	supplyAuditDivergentMeter.Mark(1)
+	log.Error("Native supply diverged from state", "number", audit.Number, "hash", audit.Hash, "balances", audit.Balances, "tracked", audit.Tracked, "diff", audit.Diff)
+
+	if dir := bc.supplyAuditConfig.Dir; dir != "" {
+		blob, err := json.MarshalIndent(audit, "", "  ")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, fmt.Sprintf("supply-audit-%d.json", audit.Number)), blob, 0644)
+		}
+		if err != nil {
+			log.Error("Failed to write supply audit report", "number", audit.Number, "err", err)
+		}
+	}
+}
+
+// AuditSupply sums up the account balances of the state of the given header
+// and reconciles them against the total supply tracked for it. The state is
+// read from the snapshot, so the header must be recent enough to be covered.
+func (bc *BlockChain) AuditSupply(header *types.Header) (*SupplyAudit, error) {
+	return bc.auditSupply(header, nil)
+}
+
+// auditSupply is the interruptible version of AuditSupply.
+func (bc *BlockChain) auditSupply(header *types.Header, abort <-chan struct{}) (*SupplyAudit, error) {
+	if bc.snaps == nil {
+		return nil, errSnapshotsDisabled
+	}
+	if status, err := bc.snaps.GenerationStatus(); err != nil || !status.Done {
+		return nil, errSupplyAuditNotReady
+	}
+	it, err := bc.snaps.AccountIterator(header.Root, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var (
+		start = time.Now()
+		audit = &SupplyAudit{
+			Number:   header.Number.Uint64(),
+			Hash:     header.Hash(),
+			Root:     header.Root,
+			Balances: new(big.Int),
+		}
+	)
+	for it.Next() {
+		if audit.Accounts%10000 == 0 {
+			select {
+			case <-abort:
+				return nil, errSupplyAuditAborted
+			default:
+			}
+		}
+		account, err := types.FullAccount(it.Account())
+		if err != nil {
+			return nil, fmt.Errorf("invalid account %x: %w", it.Hash(), err)
+		}
+		audit.Balances.Add(audit.Balances, account.Balance.ToBig())
+		audit.Accounts++
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if tracked := rawdb.ReadTotalSupply(bc.db, audit.Hash, audit.Number); tracked != nil {
+		audit.Tracked = tracked
+		audit.Diff = new(big.Int).Sub(audit.Balances, tracked)
+	}
+	audit.Elapsed = time.Since(start)
+
+	supplyAuditMeter.Mark(1)
+	supplyAuditTimer.Update(audit.Elapsed)
+	return audit, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the supply audits reconcile the state balances with the tracked
+// supply, and that divergences are detected and reported.
+func TestSupplyAudit(t *testing.T) {
+	var (
+		engine    = ethash.NewFaker()
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		gspec     = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		dir    = t.TempDir()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), recipient, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, gspec, nil, engine, vm.Config{}, nil, nil, EnableSupplyAudits(SupplyAuditConfig{Interval: 4, Dir: dir}))
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		audit, err := chain.AuditSupply(block.Header())
+		if err != nil {
+			t.Fatalf("block %d: failed to audit supply: %v", block.NumberU64(), err)
+		}
+		if audit.Divergent() {
+			t.Errorf("block %d: supply diverged: balances %v, tracked %v", block.NumberU64(), audit.Balances, audit.Tracked)
+		}
+		if audit.Tracked == nil || audit.Accounts < 2 {
+			t.Errorf("block %d: incomplete audit: tracked %v, accounts %d", block.NumberU64(), audit.Tracked, audit.Accounts)
+		}
+	}
+	// Corrupt the tracked supply and ensure the divergence is reported
+	head := blocks[len(blocks)-1]
+	tracked := chain.TotalSupply(head.NumberU64())
+	rawdb.WriteTotalSupply(db, head.Hash(), head.NumberU64(), new(big.Int).Add(tracked, big.NewInt(1)))
+
+	audit, err := chain.AuditSupply(head.Header())
+	if err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if !audit.Divergent() || audit.Diff.Cmp(big.NewInt(-1)) != 0 {
+		t.Fatalf("divergence mismatch: have %v, want -1", audit.Diff)
+	}
+	chain.reportSupplyAudit(head.Header(), nil)
+	if last := chain.LastSupplyAudit(); last == nil || last.Number != head.NumberU64() || !last.Divergent() {
+		t.Fatalf("last audit mismatch: have %+v", last)
+	}
+	blob, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("supply-audit-%d.json", head.NumberU64())))
+	if err != nil {
+		t.Fatalf("divergence report missing: %v", err)
+	}
+	var report SupplyAudit
+	if err := json.Unmarshal(blob, &report); err != nil {
+		t.Fatalf("failed to decode divergence report: %v", err)
+	}
+	if report.Hash != head.Hash() || report.Diff.Cmp(audit.Diff) != 0 {
+		t.Fatalf("divergence report mismatch: have %+v, want %+v", report, audit)
+	}
+}
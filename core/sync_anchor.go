@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// anchorVerifyBatch is the number of headers verified at once when verifying
+// the headers below the sync anchor.
+const anchorVerifyBatch = 2048
+
+var (
+	errNoSyncAnchor       = errors.New("no sync anchor configured")
+	errSyncAnchorMismatch = errors.New("sync anchor mismatch")
+)
+
+// SyncAnchor is a trusted checkpoint of the chain, e.g. a recent finalized
+// block obtained out of band, used to bootstrap a node without verifying the
+// headers up to it.
+type SyncAnchor struct {
+	Number uint64
+	Hash   common.Hash
+	Root   common.Hash // State root of the anchor block, not checked if zero
+}
+
+// WithSyncAnchor returns a BlockChainOption which trusts the headers up to the
+// given anchor: the headers of a batch reaching the anchor are only checked to
+// be linked up to it, while the anchor header must match the anchor exactly.
+// As the headers are linked by their hashes, the anchor authenticates all the
+// ones below it in the batch. Batches not reaching the anchor are verified in
+// full, as nothing would vouch for them.
+//
+// The skipped seal and consensus verification can be run later with
+// VerifyAnchoredHeaders.
+func WithSyncAnchor(anchor SyncAnchor) BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		if anchor.Number == 0 || anchor.Hash == (common.Hash{}) {
+			return nil, fmt.Errorf("invalid sync anchor #%d [%x]", anchor.Number, anchor.Hash)
+		}
+		if hash := bc.GetCanonicalHash(anchor.Number); hash != (common.Hash{}) && hash != anchor.Hash {
+			return nil, fmt.Errorf("%w: canonical #%d is %x, anchor %x", errSyncAnchorMismatch, anchor.Number, hash, anchor.Hash)
+		}
+		bc.hc.anchor = &anchor
+		log.Info("Trusting headers up to sync anchor", "number", anchor.Number, "hash", anchor.Hash, "root", anchor.Root)
+		return bc, nil
+	}
+}
+
+// checkAnchored returns the number of leading headers of a contiguous batch at
+// or below the sync anchor which can be trusted without verification. These
+// are only the ones linked up to the anchor header within the batch, which must
+// match the anchor; if the batch doesn't reach the anchor, none are trusted. On
+// failure, the index of the offending header is returned.
+func (hc *HeaderChain) checkAnchored(chain []*types.Header) (int, error) {
+	anchor := hc.anchor
+	if anchor == nil || len(chain) == 0 {
+		return 0, nil
+	}
+	first, last := chain[0].Number.Uint64(), chain[len(chain)-1].Number.Uint64()
+	if first > anchor.Number || last < anchor.Number {
+		return 0, nil
+	}
+	end := int(anchor.Number - first)
+	for i := 1; i <= end; i++ {
+		if chain[i].ParentHash != chain[i-1].Hash() {
+			return i, fmt.Errorf("unlinked header #%d [%x], parent %x", chain[i].Number, chain[i].Hash(), chain[i].ParentHash)
+		}
+	}
+	header := chain[end]
+	if hash := header.Hash(); hash != anchor.Hash {
+		return end, fmt.Errorf("%w: header #%d hash %x, want %x", errSyncAnchorMismatch, anchor.Number, hash, anchor.Hash)
+	}
+	if anchor.Root != (common.Hash{}) && header.Root != anchor.Root {
+		return end, fmt.Errorf("%w: header #%d root %x, want %x", errSyncAnchorMismatch, anchor.Number, header.Root, anchor.Root)
+	}
+	return end + 1, nil
+}
+
+// skipAnchored reports the anchored headers leading a batch as verified. The
+// given results are those of the verification of the headers from the last
+// anchored one, which is only included as the parent of the following ones,
+// its own result being dropped.
+func skipAnchored(anchored int, total int, abort chan<- struct{}, results <-chan error) (chan<- struct{}, <-chan error) {
+	if anchored == 0 {
+		return abort, results
+	}
+	var (
+		quit    = make(chan struct{})
+		checked = make(chan error, total)
+	)
+	for i := 0; i < anchored; i++ {
+		checked <- nil
+	}
+	go func() {
+		defer close(abort)
+
+		for i := anchored - 1; i < total; i++ {
+			select {
+			case <-quit:
+				return
+			case err := <-results:
+				if i >= anchored {
+					checked <- err
+				}
+			}
+		}
+		<-quit
+	}()
+	return quit, checked
+}
+
+// VerifyAnchoredHeaders runs the header verification skipped for the canonical
+// headers up to the sync anchor. It returns the number of the first invalid
+// header along with the error, or the anchor number if all are valid. It's
+// meant to be run in the background once the node is synced, as a backfill
+// check of the trusted range.
+func (bc *BlockChain) VerifyAnchoredHeaders() (uint64, error) {
+	anchor := bc.hc.anchor
+	if anchor == nil {
+		return 0, errNoSyncAnchor
+	}
+	if hash := bc.GetCanonicalHash(anchor.Number); hash != anchor.Hash {
+		return anchor.Number, fmt.Errorf("%w: canonical #%d is %x, anchor %x", errSyncAnchorMismatch, anchor.Number, hash, anchor.Hash)
+	}
+	for first := uint64(1); first <= anchor.Number; first += anchorVerifyBatch {
+		last := min(first+anchorVerifyBatch-1, anchor.Number)
+
+		headers := make([]*types.Header, 0, last-first+1)
+		for number := first; number <= last; number++ {
+			header := bc.GetHeaderByNumber(number)
+			if header == nil {
+				return number, fmt.Errorf("missing header #%d", number)
+			}
+			headers = append(headers, header)
+		}
+		abort, results := bc.engine.VerifyHeaders(bc, headers)
+		abort, results = bc.hc.validateExtra(headers, abort, results)
+		for _, header := range headers {
+			if bc.insertStopped() {
+				close(abort)
+				return header.Number.Uint64(), errInsertionInterrupted
+			}
+			if err := <-results; err != nil {
+				close(abort)
+				return header.Number.Uint64(), err
+			}
+		}
+		close(abort)
+		log.Info("Verified anchored headers", "number", last, "anchor", anchor.Number)
+	}
+	return anchor.Number, nil
+}
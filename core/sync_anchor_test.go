@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the headers linked up to the sync anchor are imported without
+// being verified, that the anchor is enforced, and that the skipped verification can
+// be run afterwards.
+func TestSyncAnchor(t *testing.T) {
+	var (
+		genesis    = &Genesis{Config: params.TestChainConfig, BaseFee: common.Big1}
+		_, headers = makeHeaderChainWithGenesis(genesis, 12, ethash.NewFaker(), 1)
+		anchor     = SyncAnchor{Number: 8, Hash: headers[7].Hash(), Root: headers[7].Root}
+	)
+	// The engine rejects header #5, below the anchor
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFakeFailer(5), vm.Config{}, nil, nil, WithSyncAnchor(anchor))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Headers not linked up to the anchor within the batch are verified in full
+	if n, err := chain.InsertHeaderChain(headers[:6]); err == nil || n != 4 {
+		t.Fatalf("unanchored batch mismatch: have %d, %v, want 4 failing", n, err)
+	}
+	if _, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert anchored headers: %v", err)
+	}
+	if head := chain.CurrentHeader().Number.Uint64(); head != 12 {
+		t.Fatalf("head mismatch: have %d, want 12", head)
+	}
+	number, err := chain.VerifyAnchoredHeaders()
+	if err == nil || number != 5 {
+		t.Fatalf("backfill verification mismatch: have #%d, %v, want #5 failing", number, err)
+	}
+	// A chain not matching the anchor is rejected
+	_, fork := makeHeaderChainWithGenesis(genesis, 12, ethash.NewFaker(), 2)
+
+	chain, err = NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil, WithSyncAnchor(anchor))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertHeaderChain(fork); !errors.Is(err, errSyncAnchorMismatch) || n != 7 {
+		t.Fatalf("mismatching chain error: have %d, %v, want 7, %v", n, err, errSyncAnchorMismatch)
+	}
+	if _, err := chain.VerifyAnchoredHeaders(); !errors.Is(err, errSyncAnchorMismatch) {
+		t.Fatalf("backfill verification error mismatch: have %v, want %v", err, errSyncAnchorMismatch)
+	}
+}
+
+// Tests that blocks up to the sync anchor are imported without their headers
+// being verified, in batches reaching beyond the anchor.
+func TestSyncAnchorBlocks(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 6, nil)
+
+	// The engine rejects block #3, below the anchor
+	anchor := SyncAnchor{Number: 4, Hash: blocks[3].Hash(), Root: blocks[3].Root()}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), genesis, nil, ethash.NewFakeFailer(3), vm.Config{}, nil, nil, WithSyncAnchor(anchor))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 6 {
+		t.Fatalf("head mismatch: have %d, want 6", head)
+	}
+}
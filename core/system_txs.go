@@ -0,0 +1,130 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SystemTxGasLimit is the gas limit of the system transactions issued by the
+// block producer of a Parlia block.
+const SystemTxGasLimit = math.MaxUint64 / 2
+
+// systemTxABI contains the system contract methods invoked by the system
+// transactions of a Parlia block.
+var systemTxABI = mustParseABI(`[
+	{"type":"function","name":"deposit","inputs":[{"name":"valAddr","type":"address"}],"outputs":[],"stateMutability":"payable"},
+	{"type":"function","name":"distributeFinalityReward","inputs":[{"name":"valAddrs","type":"address[]"},{"name":"weights","type":"uint256[]"}],"outputs":[],"stateMutability":"nonpayable"},
+	{"type":"function","name":"updateValidatorSetV2","inputs":[{"name":"_consensusAddrs","type":"address[]"},{"name":"_votingPowers","type":"uint64[]"},{"name":"_voteAddrs","type":"bytes[]"}],"outputs":[],"stateMutability":"nonpayable"},
+	{"type":"function","name":"slash","inputs":[{"name":"validator","type":"address"}],"outputs":[],"stateMutability":"nonpayable"}
+]`)
+
+func mustParseABI(raw string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// NewSystemMessage returns the message of a system transaction sent by the
+// block producer.
+func NewSystemMessage(from, to common.Address, data []byte, value *big.Int) *Message {
+	return &Message{
+		From:     from,
+		GasLimit: SystemTxGasLimit,
+		GasPrice: big.NewInt(0),
+		Value:    value,
+		To:       &to,
+		Data:     data,
+	}
+}
+
+// NewSystemTransaction returns the unsigned transaction carrying the given
+// system message. The block producer signs it, whereas a validator compares
+// its signing hash against the transaction included in the block.
+func NewSystemTransaction(nonce uint64, msg *Message) *types.Transaction {
+	return types.NewTransaction(nonce, *msg.To, msg.Value, msg.GasLimit, msg.GasPrice, msg.Data)
+}
+
+// NewDepositMessage returns the system message depositing the collected fees
+// of the block to the reward of the given validator.
+func NewDepositMessage(coinbase, validator common.Address, amount *big.Int) (*Message, error) {
+	data, err := systemTxABI.Pack("deposit", validator)
+	if err != nil {
+		return nil, err
+	}
+	return NewSystemMessage(coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, amount), nil
+}
+
+// NewSystemRewardMessage returns the system message transferring the given
+// share of the collected fees to the system reward pool.
+func NewSystemRewardMessage(coinbase common.Address, amount *big.Int) *Message {
+	return NewSystemMessage(coinbase, common.HexToAddress(systemcontracts.SystemRewardContract), nil, amount)
+}
+
+// NewSlashMessage returns the system message slashing the in-turn validator
+// that failed to produce its block.
+func NewSlashMessage(coinbase, spoiled common.Address) (*Message, error) {
+	data, err := systemTxABI.Pack("slash", spoiled)
+	if err != nil {
+		return nil, err
+	}
+	return NewSystemMessage(coinbase, common.HexToAddress(systemcontracts.SlashContract), data, common.Big0), nil
+}
+
+// NewFinalityRewardMessage returns the system message distributing the
+// finality reward by the given vote weights. The validators are ordered by
+// address, so the message does not depend on the iteration order of the map.
+func NewFinalityRewardMessage(coinbase common.Address, weights map[common.Address]uint64) (*Message, error) {
+	validators := make([]common.Address, 0, len(weights))
+	for val := range weights {
+		validators = append(validators, val)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i][:], validators[j][:]) < 0
+	})
+	values := make([]*big.Int, len(validators))
+	for i, val := range validators {
+		values[i] = new(big.Int).SetUint64(weights[val])
+	}
+	data, err := systemTxABI.Pack("distributeFinalityReward", validators, values)
+	if err != nil {
+		return nil, err
+	}
+	return NewSystemMessage(coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, common.Big0), nil
+}
+
+// NewValidatorSetUpdateMessage returns the system message installing the
+// elected validator set at a breathe block. The slices must be ordered by the
+// election result and have the same length.
+func NewValidatorSetUpdateMessage(coinbase common.Address, validators []common.Address, votingPowers []uint64, voteAddrs [][]byte) (*Message, error) {
+	data, err := systemTxABI.Pack("updateValidatorSetV2", validators, votingPowers, voteAddrs)
+	if err != nil {
+		return nil, err
+	}
+	return NewSystemMessage(coinbase, common.HexToAddress(systemcontracts.ValidatorContract), data, common.Big0), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the system messages call the expected system contract methods.
+func TestSystemMessages(t *testing.T) {
+	var (
+		coinbase  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		validator = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	)
+	deposit, err := NewDepositMessage(coinbase, validator, big.NewInt(100))
+	if err != nil {
+		t.Fatalf("failed to build deposit: %v", err)
+	}
+	slash, err := NewSlashMessage(coinbase, validator)
+	if err != nil {
+		t.Fatalf("failed to build slash: %v", err)
+	}
+	reward, err := NewFinalityRewardMessage(coinbase, map[common.Address]uint64{validator: 1})
+	if err != nil {
+		t.Fatalf("failed to build finality reward: %v", err)
+	}
+	update, err := NewValidatorSetUpdateMessage(coinbase, []common.Address{validator}, []uint64{1}, [][]byte{make([]byte, 48)})
+	if err != nil {
+		t.Fatalf("failed to build validator set update: %v", err)
+	}
+	tests := []struct {
+		msg    *Message
+		to     string
+		method string
+	}{
+		{deposit, systemcontracts.ValidatorContract, "deposit(address)"},
+		{slash, systemcontracts.SlashContract, "slash(address)"},
+		{reward, systemcontracts.ValidatorContract, "distributeFinalityReward(address[],uint256[])"},
+		{update, systemcontracts.ValidatorContract, "updateValidatorSetV2(address[],uint64[],bytes[])"},
+	}
+	for i, tt := range tests {
+		if tt.msg.From != coinbase {
+			t.Errorf("test %d: sender mismatch: have %x, want %x", i, tt.msg.From, coinbase)
+		}
+		if *tt.msg.To != common.HexToAddress(tt.to) {
+			t.Errorf("test %d: recipient mismatch: have %x, want %s", i, *tt.msg.To, tt.to)
+		}
+		if id := crypto.Keccak256([]byte(tt.method))[:4]; !bytes.Equal(tt.msg.Data[:4], id) {
+			t.Errorf("test %d: selector mismatch: have %x, want %x", i, tt.msg.Data[:4], id)
+		}
+		if tt.msg.GasLimit != SystemTxGasLimit || tt.msg.GasPrice.Sign() != 0 {
+			t.Errorf("test %d: gas mismatch: have %d at %v", i, tt.msg.GasLimit, tt.msg.GasPrice)
+		}
+	}
+	if deposit.Value.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("deposit value mismatch: have %v, want 100", deposit.Value)
+	}
+	if msg := NewSystemRewardMessage(coinbase, big.NewInt(7)); *msg.To != common.HexToAddress(systemcontracts.SystemRewardContract) || len(msg.Data) != 0 {
+		t.Errorf("system reward message mismatch: to %x, data %x", *msg.To, msg.Data)
+	}
+}
+
+// Tests that the finality reward message is independent of the map order.
+func TestFinalityRewardMessageOrder(t *testing.T) {
+	weights := make(map[common.Address]uint64)
+	for i := 0; i < 32; i++ {
+		weights[common.BytesToAddress([]byte{byte(31 - i)})] = uint64(i)
+	}
+	want, err := NewFinalityRewardMessage(common.Address{}, weights)
+	if err != nil {
+		t.Fatalf("failed to build finality reward: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		have, err := NewFinalityRewardMessage(common.Address{}, weights)
+		if err != nil {
+			t.Fatalf("failed to build finality reward: %v", err)
+		}
+		if !bytes.Equal(have.Data, want.Data) {
+			t.Fatalf("run %d: calldata mismatch", i)
+		}
+	}
+	// The first validator is the lowest address, carrying the highest weight
+	args, err := systemTxABI.Methods["distributeFinalityReward"].Inputs.Unpack(want.Data[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack calldata: %v", err)
+	}
+	validators, values := args[0].([]common.Address), args[1].([]*big.Int)
+	if validators[0] != (common.Address{}) || values[0].Uint64() != 31 {
+		t.Errorf("first entry mismatch: have %x=%v, want %x=31", validators[0], values[0], common.Address{})
+	}
+}
+
+// Tests that the system transaction carries the fields of its message.
+func TestSystemTransaction(t *testing.T) {
+	msg := NewSystemRewardMessage(common.Address{0x01}, big.NewInt(5))
+	tx := NewSystemTransaction(3, msg)
+
+	want := types.NewTransaction(3, common.HexToAddress(systemcontracts.SystemRewardContract), big.NewInt(5), SystemTxGasLimit, big.NewInt(0), nil)
+	signer := types.HomesteadSigner{}
+	if signer.Hash(tx) != signer.Hash(want) {
+		t.Errorf("system transaction mismatch: have %v, want %v", signer.Hash(tx), signer.Hash(want))
+	}
+}
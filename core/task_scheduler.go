@@ -0,0 +1,274 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// defaultTaskSlots is the number of low and normal priority tasks running
+	// concurrently, further ones are queued.
+	defaultTaskSlots = 4
+
+	// taskRestartDelay is the backoff before restarting a task, multiplied by
+	// the number of restarts so far and capped at maxTaskRestartDelay.
+	taskRestartDelay    = time.Second
+	maxTaskRestartDelay = 30 * time.Second
+)
+
+var (
+	taskStartMeter   = metrics.NewRegisteredMeter("chain/tasks/start", nil)
+	taskPanicMeter   = metrics.NewRegisteredMeter("chain/tasks/panic", nil)
+	taskRestartMeter = metrics.NewRegisteredMeter("chain/tasks/restart", nil)
+)
+
+// TaskPriority is the scheduling priority of a background task.
+type TaskPriority int
+
+const (
+	TaskLow    TaskPriority = iota // Deferrable work, e.g. writing diagnostics
+	TaskNormal                     // Regular work, queued if all slots are busy
+	TaskHigh                       // Chain services, started immediately without a slot
+)
+
+func (p TaskPriority) String() string {
+	switch p {
+	case TaskLow:
+		return "low"
+	case TaskNormal:
+		return "normal"
+	case TaskHigh:
+		return "high"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// RestartPolicy determines whether a background task is restarted when it
+// terminates before the chain is stopped.
+type RestartPolicy int
+
+const (
+	RestartNever   RestartPolicy = iota // The task runs once
+	RestartOnPanic                      // The task is restarted if it panicked
+	RestartAlways                       // The task is restarted whenever it returns
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnPanic:
+		return "on-panic"
+	case RestartAlways:
+		return "always"
+	}
+	return fmt.Sprintf("policy(%d)", int(p))
+}
+
+// TaskInfo is a snapshot of the state of a background task.
+type TaskInfo struct {
+	ID        uint64
+	Name      string
+	Priority  TaskPriority
+	Restart   RestartPolicy
+	Running   bool      // Whether the task is running, otherwise it is queued
+	Queued    time.Time // Time the task was scheduled
+	Started   time.Time // Time the task was last (re)started, zero if queued
+	Restarts  int       // Number of times the task was restarted
+	Panics    int       // Number of times the task panicked
+	LastPanic string    // Value of the last panic, if any
+}
+
+// task is a background task of the scheduler.
+type task struct {
+	info TaskInfo
+	fn   func(quit <-chan struct{})
+}
+
+// taskScheduler supervises the background goroutines of the chain. High
+// priority tasks are started right away, the others share a limited number of
+// slots, queued by priority. Panics are recovered and reported, and tasks are
+// restarted according to their policy until the chain is stopped.
+type taskScheduler struct {
+	quit  <-chan struct{}
+	slots int
+
+	lock    sync.Mutex
+	tasks   map[uint64]*task
+	queue   *prque.Prque[int64, *task]
+	running int // Number of slots in use
+	nextID  uint64
+	wg      sync.WaitGroup
+}
+
+func newTaskScheduler(quit <-chan struct{}, slots int) *taskScheduler {
+	return &taskScheduler{
+		quit:  quit,
+		slots: slots,
+		tasks: make(map[uint64]*task),
+		queue: prque.New[int64, *task](nil),
+	}
+}
+
+// spawn schedules a background task. The function is expected to return once
+// the quit channel is closed. Tasks spawned during shutdown are still started,
+// so one-off work is not lost, but they are never restarted.
+func (s *taskScheduler) spawn(name string, priority TaskPriority, restart RestartPolicy, fn func(quit <-chan struct{})) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextID++
+	t := &task{
+		info: TaskInfo{
+			ID:       s.nextID,
+			Name:     name,
+			Priority: priority,
+			Restart:  restart,
+			Queued:   time.Now(),
+		},
+		fn: fn,
+	}
+	s.tasks[t.info.ID] = t
+	s.wg.Add(1)
+
+	if priority >= TaskHigh || s.running < s.slots {
+		s.start(t)
+		return
+	}
+	// Queue by priority first and by scheduling order second
+	s.queue.Push(t, int64(priority)<<48-int64(t.info.ID))
+}
+
+// start launches a task. The lock is assumed to be held.
+func (s *taskScheduler) start(t *task) {
+	if t.info.Priority < TaskHigh {
+		s.running++
+	}
+	t.info.Running = true
+	t.info.Started = time.Now()
+	taskStartMeter.Mark(1)
+
+	go s.run(t)
+}
+
+// run executes a task until it is done, restarting it as per its policy.
+func (s *taskScheduler) run(t *task) {
+	defer s.finish(t)
+
+	for {
+		panicked := s.invoke(t)
+
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+		if t.info.Restart == RestartNever || (t.info.Restart == RestartOnPanic && !panicked) {
+			return
+		}
+		s.lock.Lock()
+		t.info.Restarts++
+		delay := min(time.Duration(t.info.Restarts)*taskRestartDelay, maxTaskRestartDelay)
+		s.lock.Unlock()
+
+		log.Warn("Restarting background task", "task", t.info.Name, "restarts", t.info.Restarts, "delay", delay)
+		taskRestartMeter.Mark(1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.quit:
+			timer.Stop()
+			return
+		}
+		s.lock.Lock()
+		t.info.Started = time.Now()
+		s.lock.Unlock()
+	}
+}
+
+// invoke runs the task function once, recovering from panics.
+func (s *taskScheduler) invoke(t *task) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			log.Error("Background task panicked", "task", t.info.Name, "err", r, "stack", string(debug.Stack()))
+			taskPanicMeter.Mark(1)
+
+			s.lock.Lock()
+			t.info.Panics++
+			t.info.LastPanic = fmt.Sprint(r)
+			s.lock.Unlock()
+		}
+	}()
+	t.fn(s.quit)
+	return false
+}
+
+// finish releases the slot of a terminated task and starts the queued ones.
+func (s *taskScheduler) finish(t *task) {
+	s.lock.Lock()
+	delete(s.tasks, t.info.ID)
+	if t.info.Priority < TaskHigh {
+		s.running--
+	}
+	for s.running < s.slots && !s.queue.Empty() {
+		next, _ := s.queue.Pop()
+		s.start(next)
+	}
+	s.lock.Unlock()
+
+	s.wg.Done()
+}
+
+// wait blocks until all tasks, including the queued ones, terminated.
+func (s *taskScheduler) wait() {
+	s.wg.Wait()
+}
+
+// list returns the state of the running and queued tasks, ordered by name.
+func (s *taskScheduler) list() []TaskInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	infos := make([]TaskInfo, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		infos = append(infos, t.info)
+	}
+	slices.SortFunc(infos, func(a, b TaskInfo) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	return infos
+}
+
+// Tasks returns the state of the background tasks of the chain.
+func (bc *BlockChain) Tasks() []TaskInfo {
+	return bc.tasks.list()
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests that tasks beyond the slots are queued by priority, while high
+// priority ones start right away.
+func TestTaskSchedulerPriorities(t *testing.T) {
+	var (
+		quit      = make(chan struct{})
+		scheduler = newTaskScheduler(quit, 1)
+		release   = make(chan struct{})
+
+		lock  sync.Mutex
+		order []string
+	)
+	record := func(name string) func(<-chan struct{}) {
+		return func(<-chan struct{}) {
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+		}
+	}
+	scheduler.spawn("blocker", TaskNormal, RestartNever, func(<-chan struct{}) { <-release })
+	scheduler.spawn("low", TaskLow, RestartNever, record("low"))
+	scheduler.spawn("normal", TaskNormal, RestartNever, record("normal"))
+
+	started := make(chan struct{})
+	scheduler.spawn("service", TaskHigh, RestartNever, func(quit <-chan struct{}) {
+		close(started)
+		<-quit
+	})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("high priority task queued")
+	}
+	infos := scheduler.list()
+	if len(infos) != 4 {
+		t.Fatalf("task count mismatch: have %d, want 4", len(infos))
+	}
+	for _, info := range infos {
+		if running := info.Name == "blocker" || info.Name == "service"; info.Running != running {
+			t.Errorf("task %s running mismatch: have %v, want %v", info.Name, info.Running, running)
+		}
+	}
+	close(release)
+	close(quit)
+	scheduler.wait()
+
+	if !slices.Equal(order, []string{"normal", "low"}) {
+		t.Errorf("queued task order mismatch: have %v", order)
+	}
+	if infos := scheduler.list(); len(infos) != 0 {
+		t.Errorf("terminated tasks listed: %v", infos)
+	}
+}
+
+// Tests that panicking tasks are recovered and restarted as per their policy.
+func TestTaskSchedulerRestarts(t *testing.T) {
+	var (
+		quit      = make(chan struct{})
+		scheduler = newTaskScheduler(quit, defaultTaskSlots)
+		restarted = make(chan struct{})
+		runs      int
+	)
+	scheduler.spawn("oneshot", TaskNormal, RestartNever, func(<-chan struct{}) { panic("oneshot") })
+	scheduler.spawn("service", TaskHigh, RestartOnPanic, func(quit <-chan struct{}) {
+		if runs++; runs == 1 {
+			panic("service")
+		}
+		close(restarted)
+		<-quit
+	})
+	select {
+	case <-restarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("panicked task not restarted")
+	}
+	infos := scheduler.list()
+	if len(infos) != 1 {
+		t.Fatalf("task count mismatch: have %d, want 1", len(infos))
+	}
+	if info := infos[0]; info.Name != "service" || info.Restarts != 1 || info.Panics != 1 || info.LastPanic != "service" {
+		t.Errorf("task state mismatch: %+v", info)
+	}
+	close(quit)
+	scheduler.wait()
+}
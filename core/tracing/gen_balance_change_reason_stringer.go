@@ -26,23 +26,25 @@ func _() {
 	_ = x[BalanceChangeRevert-15]
 	_ = x[BalanceDecreaseBSCDistributeReward-210]
 	_ = x[BalanceIncreaseBSCDistributeReward-211]
+	_ = x[BalanceIncreaseNativeMint-212]
+	_ = x[BalanceDecreaseNativeBurn-213]
 }
 
 const (
 	_BalanceChangeReason_name_0 = "BalanceChangeUnspecifiedBalanceIncreaseRewardMineUncleBalanceIncreaseRewardMineBlockBalanceIncreaseWithdrawalBalanceIncreaseGenesisBalanceBalanceIncreaseRewardTransactionFeeBalanceDecreaseGasBuyBalanceIncreaseGasReturnBalanceIncreaseDaoContractBalanceDecreaseDaoAccountBalanceChangeTransferBalanceChangeTouchAccountBalanceIncreaseSelfdestructBalanceDecreaseSelfdestructBalanceDecreaseSelfdestructBurnBalanceChangeRevert"
-	_BalanceChangeReason_name_1 = "BalanceDecreaseBSCDistributeRewardBalanceIncreaseBSCDistributeReward"
+	_BalanceChangeReason_name_1 = "BalanceDecreaseBSCDistributeRewardBalanceIncreaseBSCDistributeRewardBalanceIncreaseNativeMintBalanceDecreaseNativeBurn"
 )
 
 var (
 	_BalanceChangeReason_index_0 = [...]uint16{0, 24, 54, 84, 109, 138, 173, 194, 218, 244, 269, 290, 315, 342, 369, 400, 419}
-	_BalanceChangeReason_index_1 = [...]uint8{0, 34, 68}
+	_BalanceChangeReason_index_1 = [...]uint8{0, 34, 68, 93, 118}
 )
 
 func (i BalanceChangeReason) String() string {
 	switch {
 	case i <= 15:
 		return _BalanceChangeReason_name_0[_BalanceChangeReason_index_0[i]:_BalanceChangeReason_index_0[i+1]]
-	case 210 <= i && i <= 211:
+	case 210 <= i && i <= 213:
 		i -= 210
 		return _BalanceChangeReason_name_1[_BalanceChangeReason_index_1[i]:_BalanceChangeReason_index_1[i+1]]
 	default:
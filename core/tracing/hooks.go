@@ -321,6 +321,13 @@ const (
 	// BalanceIncreaseBSCDistributeReward is a balance change that increases the block validator's balance and
 	// happens when BSC is distributing rewards to validator.
 	BalanceIncreaseBSCDistributeReward BalanceChangeReason = 211
+
+	// BalanceIncreaseNativeMint is native balance minted by a system contract
+	// through the native minter.
+	BalanceIncreaseNativeMint BalanceChangeReason = 212
+	// BalanceDecreaseNativeBurn is native balance burnt by a system contract
+	// through the native minter.
+	BalanceDecreaseNativeBurn BalanceChangeReason = 213
 )
 
 // GasChangeReason is used to indicate the reason for a gas change, useful
@@ -0,0 +1,256 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// errReplayTxNotFound is returned if the transaction to replay is not
+	// part of the indexed canonical chain.
+	errReplayTxNotFound = errors.New("transaction not found")
+
+	// errReplaySystemTx is returned if the transaction to replay is a system
+	// transaction, which is executed by the consensus engine and not by the
+	// state processor.
+	errReplaySystemTx = errors.New("system transactions cannot be replayed")
+)
+
+// ReplayAccount is a set of fields to override on an account before the
+// replayed transaction is executed. Nil fields are left untouched. State
+// replaces the entire storage of the account, whereas StateDiff only patches
+// the given slots; setting both is an error.
+type ReplayAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *uint256.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+// ReplayBlock is a set of fields to override in the block context the
+// replayed transaction is executed in. Nil fields are left untouched.
+type ReplayBlock struct {
+	Number      *big.Int
+	Time        *uint64
+	GasLimit    *uint64
+	Coinbase    *common.Address
+	Random      *common.Hash
+	BaseFee     *big.Int
+	BlobBaseFee *big.Int
+}
+
+// ReplayOverrides is the override ladder of a transaction replay. The rungs
+// are applied in order on top of the reconstructed pre-state of the original
+// transaction: first the block context, then the account state, finally the
+// tracer is attached. Overrides only affect the replayed transaction, the
+// preceding transactions of the block are always executed verbatim.
+type ReplayOverrides struct {
+	Block  *ReplayBlock                      // Block context overrides
+	State  map[common.Address]*ReplayAccount // Account overrides
+	Tracer *tracing.Hooks                    // Tracer to run the replayed transaction with
+}
+
+// ReplayResult is the outcome of a transaction replay.
+type ReplayResult struct {
+	Tx      *types.Transaction
+	Block   *types.Block
+	Receipt *types.Receipt   // Receipt of the replayed execution, not the original one
+	Result  *ExecutionResult // Raw execution result, including return data
+}
+
+// apply applies the block overrides to the given block context.
+func (o *ReplayBlock) apply(ctx *vm.BlockContext) {
+	if o == nil {
+		return
+	}
+	if o.Number != nil {
+		ctx.BlockNumber = new(big.Int).Set(o.Number)
+	}
+	if o.Time != nil {
+		ctx.Time = *o.Time
+	}
+	if o.GasLimit != nil {
+		ctx.GasLimit = *o.GasLimit
+	}
+	if o.Coinbase != nil {
+		ctx.Coinbase = *o.Coinbase
+	}
+	if o.Random != nil {
+		ctx.Random = o.Random
+	}
+	if o.BaseFee != nil {
+		ctx.BaseFee = new(big.Int).Set(o.BaseFee)
+	}
+	if o.BlobBaseFee != nil {
+		ctx.BlobBaseFee = new(big.Int).Set(o.BlobBaseFee)
+	}
+}
+
+// applyReplayState applies the account overrides to the given state.
+func applyReplayState(statedb *state.StateDB, overrides map[common.Address]*ReplayAccount) error {
+	for addr, account := range overrides {
+		if account == nil {
+			continue
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, *account.Nonce, tracing.NonceChangeUnspecified)
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance, tracing.BalanceChangeUnspecified)
+		}
+		if account.State != nil {
+			statedb.SetStorage(addr, account.State)
+		}
+		for key, value := range account.StateDiff {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	// Make the overrides visible to the replayed transaction as pre-state
+	statedb.Finalise(false)
+	return nil
+}
+
+// ReplayTransaction locates the canonical transaction with the given hash,
+// reconstructs its pre-state by re-executing the preceding transactions of
+// its block on top of the parent state, and executes it again with the given
+// overrides applied. The parent state must be available, historical states
+// are not regenerated.
+func (bc *BlockChain) ReplayTransaction(hash common.Hash, overrides *ReplayOverrides) (*ReplayResult, error) {
+	lookup, _, err := bc.GetTransactionLookup(hash)
+	if err != nil {
+		return nil, err
+	}
+	if lookup == nil {
+		return nil, errReplayTxNotFound
+	}
+	block := bc.GetBlock(lookup.BlockHash, lookup.BlockIndex)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", lookup.BlockHash)
+	}
+	if lookup.Index >= uint64(len(block.Transactions())) {
+		return nil, fmt.Errorf("transaction index %d out of range for block %#x", lookup.Index, block.Hash())
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	if overrides == nil {
+		overrides = new(ReplayOverrides)
+	}
+	var (
+		config  = bc.Config()
+		header  = block.Header()
+		signer  = types.MakeSigner(config, header.Number, header.Time)
+		posa, _ = bc.engine.(consensus.PoSA)
+		context = NewEVMBlockContext(header, bc, nil)
+		evm     = vm.NewEVM(context, statedb, config, vm.Config{})
+		usedGas uint64
+	)
+	// Mirror the pre-execution steps of the state processor
+	systemcontracts.TryUpdateBuildInSystemContract(config, header.Number, parent.Time, header.Time, statedb, true)
+	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
+		ProcessBeaconBlockRoot(*beaconRoot, evm)
+	}
+	if config.IsPrague(header.Number, header.Time) || config.IsVerkle(header.Number, header.Time) {
+		ProcessParentBlockHash(block.ParentHash(), evm)
+	}
+	for i, tx := range block.Transactions()[:lookup.Index+1] {
+		if posa != nil {
+			if isSystem, _ := posa.IsSystemTransaction(tx, header); isSystem {
+				if i == int(lookup.Index) {
+					return nil, errReplaySystemTx
+				}
+				// System transactions are always at the end of the block,
+				// so none of them may precede a replayable transaction.
+				continue
+			}
+		}
+		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+
+		if i < int(lookup.Index) {
+			if _, err := ApplyTransactionWithEVM(msg, new(GasPool).AddGas(tx.Gas()), statedb, header.Number, block.Hash(), tx, &usedGas, evm); err != nil {
+				return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			continue
+		}
+		// Reached the transaction to replay, climb the override ladder
+		overrides.Block.apply(&context)
+		if err := applyReplayState(statedb, overrides.State); err != nil {
+			return nil, err
+		}
+		var tracingStateDB = vm.StateDB(statedb)
+		if overrides.Tracer != nil {
+			tracingStateDB = state.NewHookedState(statedb, overrides.Tracer)
+		}
+		evm = vm.NewEVM(context, tracingStateDB, config, vm.Config{Tracer: overrides.Tracer})
+		return replayMessage(evm, msg, statedb, block, tx, usedGas)
+	}
+	return nil, errReplayTxNotFound
+}
+
+// replayMessage executes the message of the replayed transaction, invoking
+// the transaction level tracing hooks around it.
+func replayMessage(evm *vm.EVM, msg *Message, statedb *state.StateDB, block *types.Block, tx *types.Transaction, usedGas uint64) (res *ReplayResult, err error) {
+	var receipt *types.Receipt
+	if hooks := evm.Config.Tracer; hooks != nil {
+		if hooks.OnTxStart != nil {
+			hooks.OnTxStart(evm.GetVMContext(), tx, msg.From)
+		}
+		if hooks.OnTxEnd != nil {
+			defer func() { hooks.OnTxEnd(receipt, err) }()
+		}
+	}
+	result, err := ApplyMessage(evm, msg, new(GasPool).AddGas(tx.Gas()))
+	if err != nil {
+		return nil, err
+	}
+	var root []byte
+	if evm.ChainConfig().IsByzantium(evm.Context.BlockNumber) {
+		evm.StateDB.Finalise(true)
+	} else {
+		root = statedb.IntermediateRoot(evm.ChainConfig().IsEIP158(evm.Context.BlockNumber)).Bytes()
+	}
+	receipt = MakeReceipt(evm, result, statedb, evm.Context.BlockNumber, block.Hash(), tx, usedGas+result.UsedGas, root)
+	return &ReplayResult{Tx: tx, Block: block, Receipt: receipt, Result: result}, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// Tests that transactions can be replayed by hash, with the preceding
+// transactions of the block re-executed and the overrides applied.
+func TestReplayTransaction(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.Address{0xaa}
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	rawdb.WriteTxLookupEntriesByBlock(db, blocks[0])
+
+	// Replay the second transaction verbatim and check it matches the original
+	tx := blocks[0].Transactions()[1]
+	var started, ended bool
+	res, err := chain.ReplayTransaction(tx.Hash(), &ReplayOverrides{
+		Tracer: &tracing.Hooks{
+			OnTxStart: func(*tracing.VMContext, *types.Transaction, common.Address) { started = true },
+			OnTxEnd:   func(*types.Receipt, error) { ended = true },
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if !started || !ended {
+		t.Errorf("tracer not invoked: start %v, end %v", started, ended)
+	}
+	if res.Receipt.Status != types.ReceiptStatusSuccessful || res.Receipt.CumulativeGasUsed != 2*params.TxGas {
+		t.Errorf("replayed receipt mismatch: status %d, cumulative gas %d", res.Receipt.Status, res.Receipt.CumulativeGasUsed)
+	}
+	// Drain the sender through a state override and expect the replay to fail
+	overrides := &ReplayOverrides{
+		State: map[common.Address]*ReplayAccount{sender: {Balance: uint256.NewInt(0)}},
+	}
+	if _, err := chain.ReplayTransaction(tx.Hash(), overrides); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("overridden replay error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+	if _, err := chain.ReplayTransaction(common.Hash{0x01}, nil); err == nil {
+		t.Errorf("replayed unknown transaction")
+	}
+}
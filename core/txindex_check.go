@@ -0,0 +1,138 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// txIndexCheckLimit is the maximum number of transaction lookup entries
+// verified after a single reorg.
+const txIndexCheckLimit = 8192
+
+var (
+	txIndexCheckMeter    = metrics.NewRegisteredMeter("chain/txindex/checked", nil)
+	txIndexRepairMeter   = metrics.NewRegisteredMeter("chain/txindex/repaired", nil)
+	txIndexDeleteMeter   = metrics.NewRegisteredMeter("chain/txindex/deleted", nil)
+	txIndexTruncateMeter = metrics.NewRegisteredMeter("chain/txindex/truncated", nil)
+)
+
+// txIndexCheck is a verification of the transaction lookup entries touched by
+// a reorg.
+type txIndexCheck struct {
+	from, to uint64        // Range of block numbers rewritten by the reorg
+	dropped  []common.Hash // Transactions of the abandoned branch
+}
+
+// scheduleTxIndexCheck verifies in the background that the lookup entries of
+// the transactions in the reorged range point into the canonical chain,
+// repairing the ones still referencing the abandoned branch.
+func (bc *BlockChain) scheduleTxIndexCheck(check *txIndexCheck) {
+	bc.tasks.spawn("txindexcheck", TaskLow, RestartNever, func(quit <-chan struct{}) {
+		// Hold the chain mutex, the canonical chain must not move while the
+		// entries are compared against it
+		if !bc.chainmu.TryLock() {
+			return
+		}
+		defer bc.chainmu.Unlock()
+
+		select {
+		case <-quit:
+			return
+		default:
+		}
+		bc.checkTxIndex(check)
+	})
+}
+
+// checkTxIndex compares the lookup entries of the dropped transactions and of
+// the canonical ones in the reorged range against the canonical chain. Stale
+// entries are redirected to the canonical block including the transaction, or
+// deleted if there is none.
+func (bc *BlockChain) checkTxIndex(check *txIndexCheck) (repaired, deleted int) {
+	var (
+		canonical = make(map[common.Hash]uint64)              // Canonical transactions in the range
+		included  = make(map[uint64]map[common.Hash]struct{}) // Transactions of canonical blocks, by number
+		hashes    = make([]common.Hash, 0, len(check.dropped))
+	)
+	// Gather the transactions of the canonical blocks in a lazily populated
+	// cache, the dropped ones might point far outside the reorged range
+	contains := func(number uint64, hash common.Hash) bool {
+		txs, ok := included[number]
+		if !ok {
+			txs = make(map[common.Hash]struct{})
+			if block := bc.GetBlockByNumber(number); block != nil {
+				for _, tx := range block.Transactions() {
+					txs[tx.Hash()] = struct{}{}
+				}
+			}
+			included[number] = txs
+		}
+		_, ok = txs[hash]
+		return ok
+	}
+	for number := check.from; number <= check.to; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			canonical[tx.Hash()] = number
+			hashes = append(hashes, tx.Hash())
+		}
+	}
+	hashes = append(hashes, check.dropped...)
+	if len(hashes) > txIndexCheckLimit {
+		log.Warn("Truncated transaction index check", "from", check.from, "to", check.to, "txs", len(hashes), "limit", txIndexCheckLimit)
+		txIndexTruncateMeter.Mark(1)
+		hashes = hashes[:txIndexCheckLimit]
+	}
+	batch := bc.db.NewBatch()
+	for _, hash := range hashes {
+		entry := rawdb.ReadTxLookupEntry(bc.db, hash)
+		if entry == nil || contains(*entry, hash) {
+			continue
+		}
+		if number, ok := canonical[hash]; ok {
+			log.Warn("Repairing stale transaction index", "hash", hash, "stale", *entry, "number", number)
+			rawdb.WriteTxLookupEntries(batch, number, []common.Hash{hash})
+			repaired++
+		} else {
+			log.Warn("Deleting stale transaction index", "hash", hash, "stale", *entry)
+			rawdb.DeleteTxLookupEntry(batch, hash)
+			deleted++
+		}
+	}
+	txIndexCheckMeter.Mark(int64(len(hashes)))
+	if repaired+deleted == 0 {
+		return 0, 0
+	}
+	bc.txLookupLock.Lock()
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to repair transaction indexes", "err", err)
+	}
+	bc.txLookupCache.Purge()
+	bc.txLookupLock.Unlock()
+
+	txIndexRepairMeter.Mark(int64(repaired))
+	txIndexDeleteMeter.Mark(int64(deleted))
+	log.Info("Repaired transaction indexes", "from", check.from, "to", check.to, "checked", len(hashes), "repaired", repaired, "deleted", deleted)
+	return repaired, deleted
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that stale transaction lookup entries left behind by a reorg are
+// redirected to the canonical chain or deleted.
+func TestTxIndexCheck(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Point a canonical transaction at the wrong block and leave an entry of an
+	// abandoned transaction behind
+	var (
+		moved   = blocks[1].Transactions()[0].Hash()
+		dropped = common.Hash{0xde, 0xad}
+	)
+	rawdb.WriteTxLookupEntries(db, 3, []common.Hash{moved})
+	rawdb.WriteTxLookupEntries(db, 1, []common.Hash{dropped})
+
+	repaired, deleted := chain.checkTxIndex(&txIndexCheck{from: 2, to: 4, dropped: []common.Hash{dropped}})
+	if repaired != 1 || deleted != 1 {
+		t.Fatalf("repair mismatch: have %d repaired, %d deleted, want 1, 1", repaired, deleted)
+	}
+	if entry := rawdb.ReadTxLookupEntry(db, moved); entry == nil || *entry != 2 {
+		t.Errorf("stale entry not repaired: %v", entry)
+	}
+	if entry := rawdb.ReadTxLookupEntry(db, dropped); entry != nil {
+		t.Errorf("stale entry not deleted: %d", *entry)
+	}
+	// A consistent index must be left untouched
+	if repaired, deleted := chain.checkTxIndex(&txIndexCheck{from: 1, to: 4}); repaired != 0 || deleted != 0 {
+		t.Errorf("consistent index modified: %d repaired, %d deleted", repaired, deleted)
+	}
+}
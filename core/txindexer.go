@@ -47,6 +47,8 @@ type txIndexer struct {
 	limit    uint64
 	db       ethdb.Database
 	progress chan chan TxIndexProgress
+	pause    chan chan struct{} // Pause requests, acknowledged once the running task is stopped
+	resume   chan struct{}      // Resume requests of a paused indexer
 	term     chan chan struct{}
 	closed   chan struct{}
 }
@@ -57,10 +59,12 @@ func newTxIndexer(limit uint64, chain *BlockChain) *txIndexer {
 		limit:    limit,
 		db:       chain.db,
 		progress: make(chan chan TxIndexProgress),
+		pause:    make(chan chan struct{}),
+		resume:   make(chan struct{}),
 		term:     make(chan chan struct{}),
 		closed:   make(chan struct{}),
 	}
-	go indexer.loop(chain)
+	chain.tasks.spawn("txindexer", TaskHigh, RestartNever, func(<-chan struct{}) { indexer.loop(chain) })
 
 	var msg string
 	if limit == 0 {
@@ -126,6 +130,7 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 	var (
 		stop     chan struct{} // Non-nil if background routine is active.
 		done     chan struct{} // Non-nil if background routine is active.
+		paused   bool          // Whether new tasks are suspended
 		lastHead uint64        // The latest announced chain head (whose tx indexes are assumed created)
 		headCh   = make(chan ChainHeadEvent)
 		sub      = chain.SubscribeChainHeadEvent(headCh)
@@ -161,7 +166,7 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 	for {
 		select {
 		case head := <-headCh:
-			if done == nil {
+			if done == nil && !paused {
 				stop = make(chan struct{})
 				done = make(chan struct{})
 				go indexer.run(rawdb.ReadTxIndexTail(indexer.db), head.Header.Number.Uint64(), stop, done)
@@ -173,6 +178,24 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 			lastTail = rawdb.ReadTxIndexTail(indexer.db)
 		case ch := <-indexer.progress:
 			ch <- indexer.report(lastHead, lastTail)
+		case ch := <-indexer.pause:
+			// Interrupt the running task, it's picked up from the persisted
+			// tail on resumption
+			if stop != nil {
+				close(stop)
+				<-done
+				stop, done = nil, nil
+				lastTail = rawdb.ReadTxIndexTail(indexer.db)
+			}
+			paused = true
+			close(ch)
+		case <-indexer.resume:
+			paused = false
+			if done == nil && lastHead != 0 {
+				stop = make(chan struct{})
+				done = make(chan struct{})
+				go indexer.run(rawdb.ReadTxIndexTail(indexer.db), lastHead, stop, done)
+			}
 		case ch := <-indexer.term:
 			if stop != nil {
 				close(stop)
@@ -221,6 +244,24 @@ func (indexer *txIndexer) txIndexProgress() (TxIndexProgress, error) {
 	}
 }
 
+// suspend stops the running indexing task and holds off new ones until resumed.
+func (indexer *txIndexer) suspend() {
+	ch := make(chan struct{})
+	select {
+	case indexer.pause <- ch:
+		<-ch
+	case <-indexer.closed:
+	}
+}
+
+// unsuspend resumes the indexing after a suspension.
+func (indexer *txIndexer) unsuspend() {
+	select {
+	case indexer.resume <- struct{}{}:
+	case <-indexer.closed:
+	}
+}
+
 // close shutdown the indexer. Safe to be called for multiple times.
 func (indexer *txIndexer) close() {
 	ch := make(chan struct{})
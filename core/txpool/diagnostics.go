@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// StuckReason is a reason preventing a pooled transaction from being included
+// in the next block.
+type StuckReason string
+
+const (
+	// StuckNonceGap is reported if transactions with lower nonces are missing.
+	StuckNonceGap StuckReason = "nonce-gap"
+
+	// StuckBlocked is reported if a transaction with a lower nonce of the same
+	// sender is stuck itself.
+	StuckBlocked StuckReason = "blocked"
+
+	// StuckUnderpriced is reported if the tip is below the pool's minimum.
+	StuckUnderpriced StuckReason = "underpriced"
+
+	// StuckFeeCapTooLow is reported if the fee cap is below the base fee of
+	// the next block.
+	StuckFeeCapTooLow StuckReason = "fee-cap-too-low"
+
+	// StuckInsufficientFunds is reported if the balance of the sender does not
+	// cover the cost of the transaction along with the lower nonce ones.
+	StuckInsufficientFunds StuckReason = "insufficient-funds"
+)
+
+// NonceGap is a range of missing nonces, both ends inclusive.
+type NonceGap struct {
+	From uint64
+	To   uint64
+}
+
+// TxDiagnosis explains the state of a pooled transaction.
+type TxDiagnosis struct {
+	Tx      *types.Transaction
+	Pending bool          // Whether the pool considers the transaction executable
+	Cost    *big.Int      // Cost of the transaction along with the lower nonce ones
+	Reasons []StuckReason // Reasons preventing inclusion, empty if includable
+}
+
+// SenderDiagnosis explains why the pooled transactions of a sender are not
+// included, ordered by nonce.
+type SenderDiagnosis struct {
+	Address    common.Address
+	StateNonce uint64   // Nonce of the sender in the head state
+	Balance    *big.Int // Balance of the sender in the head state
+	BaseFee    *big.Int // Base fee of the next block, nil before London
+	MinTip     *big.Int // Minimum tip accepted by the pool
+	Gaps       []NonceGap
+	Txs        []*TxDiagnosis
+}
+
+// diagnoser is implemented by subpools able to explain stuck transactions.
+type diagnoser interface {
+	// Diagnose reports why the transactions of a sender are not executable,
+	// or nil if the subpool does not track the sender.
+	Diagnose(addr common.Address) *SenderDiagnosis
+}
+
+// Diagnose reports the nonce gaps of a sender and the reasons each of its
+// pooled transactions is not includable in the next block. Nil is returned if
+// the sender has no transactions in a subpool supporting diagnostics.
+func (p *TxPool) Diagnose(addr common.Address) *SenderDiagnosis {
+	for _, subpool := range p.subpools {
+		if d, ok := subpool.(diagnoser); ok {
+			if diag := d.Diagnose(addr); diag != nil {
+				return diag
+			}
+		}
+	}
+	return nil
+}
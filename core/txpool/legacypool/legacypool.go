@@ -18,6 +18,7 @@
 package legacypool
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"math"
@@ -249,6 +250,7 @@ type LegacyPool struct {
 	gasTip       atomic.Pointer[uint256.Int]
 	txFeed       event.Feed
 	reannoTxFeed event.Feed // Event feed for announcing transactions again
+	reinjectFeed event.Feed // Event feed for reporting transactions reinjected after reorgs
 	scope        event.SubscriptionScope
 	signer       types.Signer
 	mu           sync.RWMutex
@@ -466,6 +468,12 @@ func (pool *LegacyPool) SubscribeReannoTxsEvent(ch chan<- core.ReannoTxsEvent) e
 	return pool.scope.Track(pool.reannoTxFeed.Subscribe(ch))
 }
 
+// SubscribeReinjectTxsEvent registers a subscription of ReinjectTxsEvent and
+// starts sending event to the given channel.
+func (pool *LegacyPool) SubscribeReinjectTxsEvent(ch chan<- core.ReinjectTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.reinjectFeed.Subscribe(ch))
+}
+
 // SetGasTip updates the minimum gas tip required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *LegacyPool) SetGasTip(tip *big.Int) {
@@ -566,6 +574,73 @@ func (pool *LegacyPool) ContentFrom(addr common.Address) ([]*types.Transaction,
 	return pending, queued
 }
 
+// Diagnose reports the nonce gaps of an account and the reasons each of its
+// pooled transactions is not includable in the next block, or nil if the pool
+// holds no transactions of the account.
+func (pool *LegacyPool) Diagnose(addr common.Address) *txpool.SenderDiagnosis {
+	// The state is read as well, which is not safe for concurrent use
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var (
+		txs     types.Transactions
+		pending = make(map[common.Hash]bool)
+	)
+	if list, ok := pool.pending[addr]; ok {
+		for _, tx := range list.Flatten() {
+			pending[tx.Hash()] = true
+			txs = append(txs, tx)
+		}
+	}
+	if list, ok := pool.queue[addr]; ok {
+		txs = append(txs, list.Flatten()...)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	sort.Sort(types.TxByNonce(txs))
+
+	diag := &txpool.SenderDiagnosis{
+		Address:    addr,
+		StateNonce: pool.currentState.GetNonce(addr),
+		Balance:    pool.currentState.GetBalance(addr).ToBig(),
+		MinTip:     pool.gasTip.Load().ToBig(),
+	}
+	if baseFee := pool.priced.urgent.baseFee; baseFee != nil {
+		diag.BaseFee = new(big.Int).Set(baseFee)
+	}
+	var (
+		next  = diag.StateNonce
+		cost  = new(big.Int)
+		stuck bool
+	)
+	for _, tx := range txs {
+		entry := &txpool.TxDiagnosis{Tx: tx, Pending: pending[tx.Hash()]}
+		if tx.Nonce() > next {
+			diag.Gaps = append(diag.Gaps, txpool.NonceGap{From: next, To: tx.Nonce() - 1})
+			entry.Reasons = append(entry.Reasons, txpool.StuckNonceGap)
+		} else if stuck {
+			entry.Reasons = append(entry.Reasons, txpool.StuckBlocked)
+		}
+		next = tx.Nonce() + 1
+
+		if tx.GasTipCapIntCmp(diag.MinTip) < 0 {
+			entry.Reasons = append(entry.Reasons, txpool.StuckUnderpriced)
+		}
+		if diag.BaseFee != nil && tx.GasFeeCapIntCmp(diag.BaseFee) < 0 {
+			entry.Reasons = append(entry.Reasons, txpool.StuckFeeCapTooLow)
+		}
+		cost.Add(cost, tx.Cost())
+		entry.Cost = new(big.Int).Set(cost)
+		if cost.Cmp(diag.Balance) > 0 {
+			entry.Reasons = append(entry.Reasons, txpool.StuckInsufficientFunds)
+		}
+		stuck = stuck || len(entry.Reasons) > 0
+		diag.Txs = append(diag.Txs, entry)
+	}
+	return diag
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce.
 //
@@ -1319,9 +1394,10 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		promoteAddrs = dirtyAccounts.flatten()
 	}
 	pool.mu.Lock()
+	var reinjected *core.ReinjectTxsEvent
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
-		pool.reset(reset.oldHead, reset.newHead)
+		reinjected = pool.reset(reset.oldHead, reset.newHead)
 
 		// Nonces were reset, discard any events that became stale
 		for addr := range events {
@@ -1371,6 +1447,11 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	// Transfer transactions from OverflowPool to MainPool for new block import
 	pool.transferTransactions()
 
+	// Report the outcome of the reorg before announcing the transactions
+	if reinjected != nil {
+		pool.reinjectFeed.Send(*reinjected)
+	}
+
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
 		addr, _ := types.Sender(pool.signer, tx)
@@ -1388,11 +1469,35 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	}
 }
 
+// reorgedTx is a transaction dropped from the canonical chain by a reorg, along
+// with its position in the dropped chain.
+type reorgedTx struct {
+	tx     *types.Transaction
+	number uint64
+	index  int
+}
+
+// compareReorgedTxs orders reorged transactions by their position in the
+// dropped chain, oldest first. The position is unique, so the same reorg always
+// yields the same reinjection order and pool content.
+func compareReorgedTxs(a, b reorgedTx) int {
+	if c := cmp.Compare(a.number, b.number); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.index, b.index)
+}
+
 // reset retrieves the current state of the blockchain and ensures the content
-// of the transaction pool is valid with regard to the chain state.
-func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
+// of the transaction pool is valid with regard to the chain state. If the head
+// was reorged, the transactions dropped from the old chain are reinjected and
+// the outcome is returned.
+func (pool *LegacyPool) reset(oldHead, newHead *types.Header) *core.ReinjectTxsEvent {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
+	var (
+		reinject types.Transactions
+		lost     []*types.Transaction     // Reorged transactions not in the new chain, in reinjection order
+		rejected map[common.Hash]struct{} // Lost transactions not accepted for reinjection
+	)
 
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
@@ -1416,7 +1521,7 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 					// If we reorged to a same or higher number, then it's not a case of setHead
 					log.Warn("Transaction pool reset with missing old head",
 						"old", oldHead.Hash(), "oldnum", oldNum, "new", newHead.Hash(), "newnum", newNum)
-					return
+					return nil
 				}
 				// If the reorg ended up on a lower number, it's indicative of setHead being the cause
 				log.Debug("Skipping transaction reset caused by setHead",
@@ -1429,43 +1534,63 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 					// reorg caused by sync-reversion or explicit sethead back to an
 					// earlier block.
 					log.Warn("Transaction pool reset with missing new head", "number", newHead.Number, "hash", newHead.Hash())
-					return
+					return nil
+				}
+				var (
+					discarded []reorgedTx
+					included  types.Transactions
+				)
+				discard := func(block *types.Block) {
+					for i, tx := range block.Transactions() {
+						discarded = append(discarded, reorgedTx{tx: tx, number: block.NumberU64(), index: i})
+					}
 				}
-				var discarded, included types.Transactions
 				for rem.NumberU64() > add.NumberU64() {
-					discarded = append(discarded, rem.Transactions()...)
+					discard(rem)
 					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
 						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-						return
+						return nil
 					}
 				}
 				for add.NumberU64() > rem.NumberU64() {
 					included = append(included, add.Transactions()...)
 					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
 						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-						return
+						return nil
 					}
 				}
 				for rem.Hash() != add.Hash() {
-					discarded = append(discarded, rem.Transactions()...)
+					discard(rem)
 					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
 						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-						return
+						return nil
 					}
 					included = append(included, add.Transactions()...)
 					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
 						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-						return
+						return nil
 					}
 				}
-				lost := make([]*types.Transaction, 0, len(discarded))
+				keep := make(map[common.Hash]struct{}, len(included))
+				for _, tx := range included {
+					keep[tx.Hash()] = struct{}{}
+				}
+				slices.SortFunc(discarded, compareReorgedTxs)
+
+				lost = make([]*types.Transaction, 0, len(discarded))
+				rejected = make(map[common.Hash]struct{})
 				gasTip := pool.gasTip.Load().ToBig()
-				for _, tx := range types.TxDifference(discarded, included) {
-					if pool.Filter(tx) && tx.GasTipCapIntCmp(gasTip) >= 0 {
-						lost = append(lost, tx)
+				for _, d := range discarded {
+					if _, ok := keep[d.tx.Hash()]; ok {
+						continue
+					}
+					lost = append(lost, d.tx)
+					if pool.Filter(d.tx) && d.tx.GasTipCapIntCmp(gasTip) >= 0 {
+						reinject = append(reinject, d.tx)
+					} else {
+						rejected[d.tx.Hash()] = struct{}{}
 					}
 				}
-				reinject = lost
 			}
 		}
 	}
@@ -1476,7 +1601,7 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
-		return
+		return nil
 	}
 	pool.currentHead.Store(newHead)
 	pool.currentState = statedb
@@ -1485,7 +1610,25 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	core.SenderCacher().Recover(pool.signer, reinject)
-	pool.addTxsLocked(reinject)
+	errs, _ := pool.addTxsLocked(reinject)
+
+	if rejected == nil {
+		return nil // No reorg happened
+	}
+	for i, tx := range reinject {
+		if errs[i] != nil && !errors.Is(errs[i], txpool.ErrAlreadyKnown) {
+			rejected[tx.Hash()] = struct{}{}
+		}
+	}
+	event := &core.ReinjectTxsEvent{OldHead: oldHead, NewHead: newHead}
+	for _, tx := range lost {
+		if _, ok := rejected[tx.Hash()]; ok {
+			event.Dropped = append(event.Dropped, tx.Hash())
+		} else {
+			event.Reinjected = append(event.Reinjected, tx.Hash())
+		}
+	}
+	return event
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -2703,3 +2703,153 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that the reasons preventing the inclusion of the transactions of an
+// account are reported, along with its nonce gaps.
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000))
+
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(2), key),
+		pricedTransaction(1, 100000, big.NewInt(1), key),
+		pricedTransaction(3, 100000, big.NewInt(2), key),
+		pricedTransaction(4, 100000, big.NewInt(2), key),
+	}
+	for i, err := range pool.addRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// Raise the base fee and drain the account without resetting the pool
+	pool.mu.Lock()
+	pool.priced.SetBaseFee(big.NewInt(2))
+	pool.currentState.SetBalance(addr, uint256.NewInt(400000), tracing.BalanceChangeUnspecified)
+	pool.mu.Unlock()
+
+	diag := pool.Diagnose(addr)
+	if diag == nil {
+		t.Fatal("diagnosis missing")
+	}
+	if diag.StateNonce != 0 || diag.Balance.Uint64() != 400000 || diag.BaseFee.Uint64() != 2 {
+		t.Errorf("account state mismatch: nonce %d, balance %v, base fee %v", diag.StateNonce, diag.Balance, diag.BaseFee)
+	}
+	if len(diag.Gaps) != 1 || diag.Gaps[0] != (txpool.NonceGap{From: 2, To: 2}) {
+		t.Errorf("nonce gaps mismatch: have %v, want [{2 2}]", diag.Gaps)
+	}
+	want := []struct {
+		pending bool
+		reasons []txpool.StuckReason
+	}{
+		{true, nil},
+		{true, []txpool.StuckReason{txpool.StuckFeeCapTooLow}},
+		{false, []txpool.StuckReason{txpool.StuckNonceGap, txpool.StuckInsufficientFunds}},
+		{false, []txpool.StuckReason{txpool.StuckBlocked, txpool.StuckInsufficientFunds}},
+	}
+	if len(diag.Txs) != len(want) {
+		t.Fatalf("diagnosed transaction count mismatch: have %d, want %d", len(diag.Txs), len(want))
+	}
+	for i, entry := range diag.Txs {
+		if entry.Tx.Hash() != txs[i].Hash() {
+			t.Errorf("tx %d: hash mismatch", i)
+		}
+		if entry.Pending != want[i].pending {
+			t.Errorf("tx %d: pending mismatch: have %v, want %v", i, entry.Pending, want[i].pending)
+		}
+		if !slices.Equal(entry.Reasons, want[i].reasons) {
+			t.Errorf("tx %d: reasons mismatch: have %v, want %v", i, entry.Reasons, want[i].reasons)
+		}
+	}
+	if diag := pool.Diagnose(common.Address{0x01}); diag != nil {
+		t.Errorf("diagnosis returned for unknown account: %+v", diag)
+	}
+}
+
+// reorgTestChain is a test chain serving a fixed set of blocks.
+type reorgTestChain struct {
+	*testBlockChain
+	blocks map[common.Hash]*types.Block
+}
+
+func (bc *reorgTestChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.blocks[hash]
+}
+
+// Tests that transactions dropped by a reorg are reinjected in the order of
+// their position in the old chain, and that the outcome is reported.
+func TestReorgReinjection(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	chain := &reorgTestChain{
+		testBlockChain: newTestBlockChain(params.TestChainConfig, 10000000, statedb, new(event.Feed)),
+		blocks:         make(map[common.Hash]*types.Block),
+	}
+	var (
+		keyA, _ = crypto.GenerateKey()
+		keyB, _ = crypto.GenerateKey()
+		keyC, _ = crypto.GenerateKey()
+
+		txA0 = pricedTransaction(0, 100000, big.NewInt(1), keyA)
+		txA1 = pricedTransaction(1, 100000, big.NewInt(1), keyA)
+		txB0 = pricedTransaction(0, 100000, big.NewInt(1), keyB)
+		txC0 = pricedTransaction(0, 100000, big.NewInt(0), keyC) // Below the minimum tip
+	)
+	for _, key := range []*ecdsa.PrivateKey{keyA, keyB, keyC} {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), uint256.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+	}
+	block := func(parent *types.Block, extra byte, txs ...*types.Transaction) *types.Block {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			Difficulty: common.Big0,
+			GasLimit:   10000000,
+			Extra:      []byte{extra},
+		}
+		b := types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+		chain.blocks[b.Hash()] = b
+		return b
+	}
+	var (
+		root    = block(types.NewBlockWithHeader(chain.CurrentBlock()), 0)
+		oldMid  = block(root, 1, txA0, txB0, txC0)
+		oldHead = block(oldMid, 1, txA1)
+		newHead = block(root, 2, txB0)
+	)
+	pool := New(testTxPoolConfig, chain)
+	if err := pool.Init(testTxPoolConfig.PriceLimit, chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	<-pool.initDoneCh
+	defer pool.Close()
+
+	pool.mu.Lock()
+	ev := pool.reset(oldHead.Header(), newHead.Header())
+	pool.mu.Unlock()
+
+	if ev == nil {
+		t.Fatal("reinjection not reported")
+	}
+	if ev.OldHead.Hash() != oldHead.Hash() || ev.NewHead.Hash() != newHead.Hash() {
+		t.Errorf("reported heads mismatch")
+	}
+	if want := []common.Hash{txA0.Hash(), txA1.Hash()}; !slices.Equal(ev.Reinjected, want) {
+		t.Errorf("reinjected transactions mismatch: have %v, want %v", ev.Reinjected, want)
+	}
+	if want := []common.Hash{txC0.Hash()}; !slices.Equal(ev.Dropped, want) {
+		t.Errorf("dropped transactions mismatch: have %v, want %v", ev.Dropped, want)
+	}
+	for _, tx := range []*types.Transaction{txA0, txA1} {
+		if !pool.Has(tx.Hash()) {
+			t.Errorf("reinjected transaction %x missing from pool", tx.Hash())
+		}
+	}
+	if pool.Has(txB0.Hash()) || pool.Has(txC0.Hash()) {
+		t.Error("included or underpriced transaction reinjected")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"container/heap"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// SnapshotTx is an executable transaction of a pool snapshot, with everything
+// a block builder needs resolved upfront.
+type SnapshotTx struct {
+	Tx          *types.Transaction
+	Sender      common.Address
+	Tip         *big.Int             // Effective miner tip at the snapshot base fee
+	Sidecar     *types.BlobTxSidecar // Blob sidecar, nil for non-blob transactions
+	Authorities []common.Address     // Recovered 7702 authorities, invalid ones are zero
+}
+
+// Snapshot is an immutable view of the executable transactions of the pool,
+// priced against a given base fee and blob fee. Transactions are ordered by
+// effective tip, ties broken by hash, while keeping the nonce order of every
+// account, so the same pool content always yields the same snapshot.
+type Snapshot struct {
+	BaseFee *big.Int
+	BlobFee *big.Int
+	Txs     []*SnapshotTx
+}
+
+// Snapshot returns the executable transactions of the pool priced against the
+// given fees. Transactions not affording the base fee are omitted, together
+// with the higher nonces of the same account. Blob transactions are only
+// included if a blob fee is given.
+func (p *TxPool) Snapshot(baseFee, blobFee *big.Int) *Snapshot {
+	filter := PendingFilter{MinTip: new(uint256.Int)}
+	if baseFee != nil {
+		filter.BaseFee = uint256.MustFromBig(baseFee)
+	}
+	if blobFee != nil {
+		filter.BlobFee = uint256.MustFromBig(blobFee)
+	} else {
+		filter.OnlyPlainTxs = true
+	}
+	// Resolve the pending transactions, truncating every account at the first
+	// one that is gone or not executable with the requested fees.
+	var (
+		pending = p.Pending(filter)
+		heads   = make(snapshotHeap, 0, len(pending))
+	)
+	for addr, lazies := range pending {
+		var txs []*SnapshotTx
+		for _, lazy := range lazies {
+			tx := lazy.Resolve()
+			if tx == nil {
+				break
+			}
+			tip, err := tx.EffectiveGasTip(baseFee)
+			if err != nil {
+				break
+			}
+			if tx.Type() == types.BlobTxType && (blobFee == nil || tx.BlobGasFeeCapIntCmp(blobFee) < 0) {
+				break
+			}
+			stx := &SnapshotTx{
+				Tx:      tx,
+				Sender:  addr,
+				Tip:     tip,
+				Sidecar: tx.BlobTxSidecar(),
+			}
+			if auths := tx.SetCodeAuthorizations(); len(auths) > 0 {
+				stx.Authorities = make([]common.Address, len(auths))
+				for i := range auths {
+					if authority, err := auths[i].Authority(); err == nil {
+						stx.Authorities[i] = authority
+					}
+				}
+			}
+			txs = append(txs, stx)
+		}
+		if len(txs) > 0 {
+			heads = append(heads, txs)
+		}
+	}
+	// Merge the accounts by price, picking the account heads one by one
+	snapshot := &Snapshot{BaseFee: baseFee, BlobFee: blobFee}
+	heap.Init(&heads)
+	for len(heads) > 0 {
+		txs := heads[0]
+		snapshot.Txs = append(snapshot.Txs, txs[0])
+		if len(txs) > 1 {
+			heads[0] = txs[1:]
+			heap.Fix(&heads, 0)
+		} else {
+			heap.Pop(&heads)
+		}
+	}
+	return snapshot
+}
+
+// Executable returns the executable non-blob transactions of the pool in the
+// deterministic snapshot order, priced against the given base fee.
+func (p *TxPool) Executable(baseFee *big.Int) []*types.Transaction {
+	snapshot := p.Snapshot(baseFee, nil)
+
+	txs := make([]*types.Transaction, len(snapshot.Txs))
+	for i, stx := range snapshot.Txs {
+		txs[i] = stx.Tx
+	}
+	return txs
+}
+
+// snapshotHeap is a heap of per-account transaction lists, ordered by the
+// effective tip of their first transaction and then by its hash.
+type snapshotHeap [][]*SnapshotTx
+
+func (h snapshotHeap) Len() int { return len(h) }
+
+func (h snapshotHeap) Less(i, j int) bool {
+	if c := h[i][0].Tip.Cmp(h[j][0].Tip); c != 0 {
+		return c > 0
+	}
+	a, b := h[i][0].Tx.Hash(), h[j][0].Tx.Hash()
+	return a.Cmp(b) < 0
+}
+
+func (h snapshotHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *snapshotHeap) Push(x any) {
+	*h = append(*h, x.([]*SnapshotTx))
+}
+
+func (h *snapshotHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
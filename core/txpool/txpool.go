@@ -61,6 +61,14 @@ type BlockChain interface {
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
 
+// addressFilter is implemented by chains filtering the addresses transactions
+// may be sent from or to.
+type addressFilter interface {
+	// CheckPendingTransactionAddresses returns an error if the transaction is
+	// sent from or to an address filtered in the next block.
+	CheckPendingTransactionAddresses(tx *types.Transaction) error
+}
+
 // TxPool is an aggregator for various transaction specific pools, collectively
 // tracking all the transactions deemed interesting by the node. Transactions
 // enter the pool when they are received from the network or submitted locally.
@@ -69,6 +77,8 @@ type BlockChain interface {
 type TxPool struct {
 	subpools []SubPool // List of subpools for specialized transaction handling
 
+	filter addressFilter // Address filter of the chain, nil if unsupported
+
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
@@ -94,6 +104,7 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
 	}
+	pool.filter, _ = chain.(addressFilter)
 	for i, subpool := range subpools {
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
 			for j := i - 1; j >= 0; j-- {
@@ -344,11 +355,18 @@ func (p *TxPool) Add(txs []*types.Transaction, sync bool) []error {
 	// so we can piece back the returned errors into the original order.
 	txsets := make([][]*types.Transaction, len(p.subpools))
 	splits := make([]int, len(txs))
+	errs := make([]error, len(txs))
 
 	for i, tx := range txs {
 		// Mark this transaction belonging to no-subpool
 		splits[i] = -1
 
+		// Reject transactions from or to filtered addresses upfront
+		if p.filter != nil {
+			if errs[i] = p.filter.CheckPendingTransactionAddresses(tx); errs[i] != nil {
+				continue
+			}
+		}
 		// Try to find a subpool that accepts the transaction
 		for j, subpool := range p.subpools {
 			if subpool.Filter(tx) {
@@ -364,11 +382,12 @@ func (p *TxPool) Add(txs []*types.Transaction, sync bool) []error {
 	for i := 0; i < len(p.subpools); i++ {
 		errsets[i] = p.subpools[i].Add(txsets[i], sync)
 	}
-	errs := make([]error, len(txs))
 	for i, split := range splits {
 		// If the transaction was rejected by all subpools, mark it unsupported
 		if split == -1 {
-			errs[i] = fmt.Errorf("%w: received type %d", core.ErrTxTypeNotSupported, txs[i].Type())
+			if errs[i] == nil {
+				errs[i] = fmt.Errorf("%w: received type %d", core.ErrTxTypeNotSupported, txs[i].Type())
+			}
 			continue
 		}
 		// Find which subpool handled it and pull in the corresponding error
@@ -417,6 +436,25 @@ func (p *TxPool) SubscribeReannoTxsEvent(ch chan<- core.ReannoTxsEvent) event.Su
 	return p.subs.Track(event.JoinSubscriptions(subs...))
 }
 
+// reinjectNotifier is implemented by subpools reporting the transactions they
+// reinjected after reorgs.
+type reinjectNotifier interface {
+	SubscribeReinjectTxsEvent(ch chan<- core.ReinjectTxsEvent) event.Subscription
+}
+
+// SubscribeReinjectTxsEvent registers a subscription of ReinjectTxsEvent,
+// reporting the transactions reinjected and dropped by the subpools after
+// reorgs.
+func (p *TxPool) SubscribeReinjectTxsEvent(ch chan<- core.ReinjectTxsEvent) event.Subscription {
+	subs := make([]event.Subscription, 0, len(p.subpools))
+	for _, subpool := range p.subpools {
+		if notifier, ok := subpool.(reinjectNotifier); ok {
+			subs = append(subs, notifier.SubscribeReinjectTxsEvent(ch))
+		}
+	}
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *TxPool) Nonce(addr common.Address) uint64 {
@@ -114,8 +114,9 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	if tx.GasFeeCapIntCmp(tx.GasTipCap()) < 0 {
 		return core.ErrTipAboveFeeCap
 	}
-	// Make sure the transaction is signed properly
-	if _, err := types.Sender(signer, tx); err != nil {
+	// Make sure the transaction is signed properly, sharing the sender with the
+	// block import so it doesn't need to be recovered again
+	if _, err := types.ShareSender(signer, tx); err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidSender, err)
 	}
 	// Ensure the transaction has more gas than the bare minimum needed to cover
@@ -239,7 +240,15 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 		log.Error("Transaction sender recovery failed", "err", err)
 		return err
 	}
-	next := opts.State.GetNonce(from)
+	// Brand new senders have neither a nonce nor funds, skip looking them up if
+	// the flat state knows them to be missing
+	var (
+		absent = opts.State.AccountAbsent(from)
+		next   uint64
+	)
+	if !absent {
+		next = opts.State.GetNonce(from)
+	}
 	if next > tx.Nonce() {
 		return fmt.Errorf("%w: next nonce %v, tx nonce %v", core.ErrNonceTooLow, next, tx.Nonce())
 	}
@@ -252,9 +261,12 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 	}
 	// Ensure the transactor has enough funds to cover the transaction costs
 	var (
-		balance = opts.State.GetBalance(from).ToBig()
+		balance = new(big.Int)
 		cost    = tx.Cost()
 	)
+	if !absent {
+		balance = opts.State.GetBalance(from).ToBig()
+	}
 	if balance.Cmp(cost) < 0 {
 		return fmt.Errorf("%w: balance %v, tx cost %v, overshot %v", core.ErrInsufficientFunds, balance, cost, new(big.Int).Sub(cost, balance))
 	}
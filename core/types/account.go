@@ -39,16 +39,24 @@ type Account struct {
 	Balance *big.Int                    `json:"balance" gencodec:"required"`
 	Nonce   uint64                      `json:"nonce,omitempty"`
 
+	// Constructor is the contract creation code to run at genesis creation. If
+	// set, the returned runtime code and the storage written by it is committed
+	// to the account instead of Code.
+	Constructor     []byte `json:"constructor,omitempty"`
+	ConstructorArgs []byte `json:"constructorArgs,omitempty"` // ABI encoded arguments appended to the creation code
+
 	// used in tests
 	PrivateKey []byte `json:"secretKey,omitempty"`
 }
 
 type accountMarshaling struct {
-	Code       hexutil.Bytes
-	Balance    *math.HexOrDecimal256
-	Nonce      math.HexOrDecimal64
-	Storage    map[storageJSON]storageJSON
-	PrivateKey hexutil.Bytes
+	Code            hexutil.Bytes
+	Balance         *math.HexOrDecimal256
+	Nonce           math.HexOrDecimal64
+	Storage         map[storageJSON]storageJSON
+	Constructor     hexutil.Bytes
+	ConstructorArgs hexutil.Bytes
+	PrivateKey      hexutil.Bytes
 }
 
 // storageJSON represents a 256 bit byte array, but allows less than 256 bits when
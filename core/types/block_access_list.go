@@ -0,0 +1,44 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import "github.com/ethereum/go-ethereum/common"
+
+// AccountAccess is an account accessed by the execution of a block, along with
+// its storage slots accessed, sorted.
+type AccountAccess struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// BlockAccessList is the list of the accounts and storage slots accessed by the
+// execution of a block, including the system calls, sorted by address. Unlike
+// the access lists of the transactions, it's derived from the execution and is
+// not part of the block, but bound to it by its commitment.
+type BlockAccessList []AccountAccess
+
+// Hash returns the hash of the RLP encoding of the access list.
+func (l BlockAccessList) Hash() common.Hash {
+	return rlpHash(l)
+}
+
+// Commitment returns the hash binding the access list to the block producing
+// it. Anyone holding the block derives the same commitment by executing it, so
+// lists served by peers can be checked against it.
+func (l BlockAccessList) Commitment(blockHash common.Hash) common.Hash {
+	return rlpHash([]common.Hash{blockHash, l.Hash()})
+}
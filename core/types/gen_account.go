@@ -17,11 +17,13 @@ var _ = (*accountMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (a Account) MarshalJSON() ([]byte, error) {
 	type Account struct {
-		Code       hexutil.Bytes               `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
-		PrivateKey hexutil.Bytes               `json:"secretKey,omitempty"`
+		Code            hexutil.Bytes               `json:"code,omitempty"`
+		Storage         map[storageJSON]storageJSON `json:"storage,omitempty"`
+		Balance         *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
+		Nonce           math.HexOrDecimal64         `json:"nonce,omitempty"`
+		Constructor     hexutil.Bytes               `json:"constructor,omitempty"`
+		ConstructorArgs hexutil.Bytes               `json:"constructorArgs,omitempty"`
+		PrivateKey      hexutil.Bytes               `json:"secretKey,omitempty"`
 	}
 	var enc Account
 	enc.Code = a.Code
@@ -33,6 +35,8 @@ func (a Account) MarshalJSON() ([]byte, error) {
 	}
 	enc.Balance = (*math.HexOrDecimal256)(a.Balance)
 	enc.Nonce = math.HexOrDecimal64(a.Nonce)
+	enc.Constructor = a.Constructor
+	enc.ConstructorArgs = a.ConstructorArgs
 	enc.PrivateKey = a.PrivateKey
 	return json.Marshal(&enc)
 }
@@ -40,11 +44,13 @@ func (a Account) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (a *Account) UnmarshalJSON(input []byte) error {
 	type Account struct {
-		Code       *hexutil.Bytes              `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      *math.HexOrDecimal64        `json:"nonce,omitempty"`
-		PrivateKey *hexutil.Bytes              `json:"secretKey,omitempty"`
+		Code            *hexutil.Bytes              `json:"code,omitempty"`
+		Storage         map[storageJSON]storageJSON `json:"storage,omitempty"`
+		Balance         *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
+		Nonce           *math.HexOrDecimal64        `json:"nonce,omitempty"`
+		Constructor     *hexutil.Bytes              `json:"constructor,omitempty"`
+		ConstructorArgs *hexutil.Bytes              `json:"constructorArgs,omitempty"`
+		PrivateKey      *hexutil.Bytes              `json:"secretKey,omitempty"`
 	}
 	var dec Account
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -66,6 +72,12 @@ func (a *Account) UnmarshalJSON(input []byte) error {
 	if dec.Nonce != nil {
 		a.Nonce = uint64(*dec.Nonce)
 	}
+	if dec.Constructor != nil {
+		a.Constructor = *dec.Constructor
+	}
+	if dec.ConstructorArgs != nil {
+		a.ConstructorArgs = *dec.ConstructorArgs
+	}
 	if dec.PrivateKey != nil {
 		a.PrivateKey = *dec.PrivateKey
 	}
@@ -0,0 +1,61 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// sharedSendersLimit is the number of transaction senders shared across the
+// decoded copies of transactions, roughly a few full transaction pools.
+const sharedSendersLimit = 65536
+
+var (
+	sharedSenders = lru.NewCache[common.Hash, *sigCache](sharedSendersLimit)
+
+	sharedSenderHitMeter  = metrics.NewRegisteredMeter("chain/senders/hit", nil)
+	sharedSenderMissMeter = metrics.NewRegisteredMeter("chain/senders/miss", nil)
+)
+
+// ShareSender is similar to Sender, but additionally shares the sender through
+// a process wide cache, so that later decoded copies of the transaction, e.g.
+// the ones included in an imported block, don't need to recover it again.
+func ShareSender(signer Signer, tx *Transaction) (common.Address, error) {
+	addr, err := Sender(signer, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sharedSenders.Add(tx.Hash(), tx.from.Load())
+	return addr, nil
+}
+
+// SharedSender is similar to Sender, but looks up the senders shared through
+// ShareSender before recovering it from the signature.
+func SharedSender(signer Signer, tx *Transaction) (common.Address, error) {
+	if sc := tx.from.Load(); sc != nil && sc.signer.Equal(signer) {
+		return sc.from, nil
+	}
+	if sc, ok := sharedSenders.Get(tx.Hash()); ok && sc.signer.Equal(signer) {
+		sharedSenderHitMeter.Mark(1)
+		tx.from.Store(sc)
+		return sc.from, nil
+	}
+	sharedSenderMissMeter.Mark(1)
+	return Sender(signer, tx)
+}
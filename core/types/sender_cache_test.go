@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that senders shared by one copy of a transaction are reused by other
+// decoded copies, as long as they are derived with the same signer.
+func TestSharedSender(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewLondonSigner(big.NewInt(1))
+	tx, err := SignNewTx(key, signer, &DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, To: &common.Address{}, Gas: 21000})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	decode := func() *Transaction {
+		blob, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		cpy := new(Transaction)
+		if err := cpy.UnmarshalBinary(blob); err != nil {
+			t.Fatalf("failed to decode transaction: %v", err)
+		}
+		return cpy
+	}
+	// Senders not shared are recovered from the signature
+	if from, err := SharedSender(signer, decode()); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	if _, ok := sharedSenders.Get(tx.Hash()); ok {
+		t.Fatal("sender shared without being requested")
+	}
+	// Shared senders are reused by decoded copies
+	if from, err := ShareSender(signer, tx); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	cpy := decode()
+	if from, err := SharedSender(signer, cpy); err != nil || from != addr {
+		t.Fatalf("shared sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	if sc := cpy.from.Load(); sc == nil || sc.from != addr {
+		t.Fatal("shared sender not cached in the transaction")
+	}
+	// Shared senders are ignored for other signers
+	other := NewLondonSigner(big.NewInt(2))
+	if _, err := SharedSender(other, decode()); err == nil {
+		t.Fatal("sender shared across chain ids")
+	}
+}
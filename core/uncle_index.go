@@ -0,0 +1,85 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// EnableUncleIndex returns a BlockChainOption which maintains an index of the
+// uncles included in the canonical chain, keyed by the miner of the uncle and
+// attributed with the rewards paid for it. Only blocks becoming canonical after
+// enabling the index are covered, there is no backfilling of older blocks.
+func EnableUncleIndex() BlockChainOption {
+	return func(bc *BlockChain) (*BlockChain, error) {
+		bc.uncleIndex = true
+		return bc, nil
+	}
+}
+
+// UnclesByMiner retrieves the reward attributions of the canonical uncles mined
+// by the given address and included in blocks within the given inclusive range.
+func (bc *BlockChain) UnclesByMiner(miner common.Address, from uint64, to uint64) []*rawdb.UncleIndexEntry {
+	entries := rawdb.ReadUncleIndexEntries(bc.db, miner, from, to)
+
+	// Entries are dropped on reorgs, but not on head rewinds, filter out any
+	// left dangling on side chains.
+	canonical := entries[:0]
+	for _, entry := range entries {
+		if bc.GetCanonicalHash(entry.BlockNumber) == entry.BlockHash {
+			canonical = append(canonical, entry)
+		}
+	}
+	return canonical
+}
+
+// writeUncleIndex indexes the uncles of a block which became canonical.
+func (bc *BlockChain) writeUncleIndex(db ethdb.KeyValueWriter, block *types.Block) {
+	uncles := block.Uncles()
+	if !bc.uncleIndex || len(uncles) == 0 {
+		return
+	}
+	rewards, inclusion := ethash.UncleRewards(bc.chainConfig, block.Header(), uncles)
+	for i, uncle := range uncles {
+		rawdb.WriteUncleIndexEntry(db, &rawdb.UncleIndexEntry{
+			Miner:           uncle.Coinbase,
+			BlockNumber:     block.NumberU64(),
+			BlockHash:       block.Hash(),
+			Index:           uint64(i),
+			UncleNumber:     uncle.Number.Uint64(),
+			UncleHash:       uncle.Hash(),
+			Reward:          rewards[i].ToBig(),
+			Includer:        block.Coinbase(),
+			InclusionReward: inclusion.ToBig(),
+		})
+	}
+}
+
+// deleteUncleIndex drops the uncle index entries of a block which was reorged
+// out of the canonical chain.
+func (bc *BlockChain) deleteUncleIndex(db ethdb.KeyValueWriter, block *types.Block) {
+	if !bc.uncleIndex {
+		return
+	}
+	for i, uncle := range block.Uncles() {
+		rawdb.DeleteUncleIndexEntry(db, uncle.Coinbase, block.NumberU64(), block.Hash(), i)
+	}
+}
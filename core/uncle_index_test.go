@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that uncles are indexed by miner with their rewards when included in
+// the canonical chain, and dropped when reorged out.
+func TestUncleIndex(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		miner   = common.Address{0x11}
+		genesis = &Genesis{Config: params.TestChainConfig}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		if i == 2 {
+			uncle := b.PrevBlock(1).Header()
+			uncle.Extra = []byte("uncle")
+			uncle.Coinbase = miner
+			b.AddUncle(uncle)
+		}
+	})
+	_, fork, _ := GenerateChainWithGenesis(genesis, engine, 5, func(i int, b *BlockGen) {
+		b.SetExtra([]byte("fork"))
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil, EnableUncleIndex())
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	entries := chain.UnclesByMiner(miner, 0, 10)
+	if len(entries) != 1 {
+		t.Fatalf("uncle entry count mismatch: have %d, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.BlockNumber != 3 || entry.BlockHash != blocks[2].Hash() || entry.UncleNumber != 2 {
+		t.Errorf("uncle entry position mismatch: %+v", entry)
+	}
+	// Uncle one block deep gets 7/8 of the block reward, the includer 1/32
+	reward := ethash.ConstantinopleBlockReward.ToBig()
+	if want := reward.Uint64() * 7 / 8; entry.Reward.Uint64() != want {
+		t.Errorf("uncle reward mismatch: have %v, want %v", entry.Reward, want)
+	}
+	if want := reward.Uint64() / 32; entry.InclusionReward.Uint64() != want {
+		t.Errorf("inclusion reward mismatch: have %v, want %v", entry.InclusionReward, want)
+	}
+	if entries := chain.UnclesByMiner(miner, 4, 10); len(entries) != 0 {
+		t.Errorf("out of range uncles returned: %d", len(entries))
+	}
+	// Reorg the uncle including block out and check the index is cleaned up
+	if n, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork block %d: %v", n, err)
+	}
+	if chain.CurrentBlock().Hash() != fork[len(fork)-1].Hash() {
+		t.Fatalf("fork not canonical")
+	}
+	if entries := rawdb.ReadUncleIndexEntries(db, miner, 0, 10); len(entries) != 0 {
+		t.Errorf("uncle entries not dropped on reorg: %d", len(entries))
+	}
+}
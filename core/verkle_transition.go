@@ -0,0 +1,127 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// errVerkleTransitionScheme is returned if a chain scheduling the verkle fork
+// after genesis doesn't use the path state scheme, the only one verkle supports.
+var errVerkleTransitionScheme = errors.New("verkle transition requires the path state scheme")
+
+// errVerkleTransitionPreimages is returned if a chain scheduling the verkle fork
+// after genesis may lack the preimages of the state, which the conversion needs
+// to map the hashed merkle keys back to addresses and slots.
+var errVerkleTransitionPreimages = errors.New("verkle transition requires the preimages of the whole state")
+
+// setupVerkleTransition opens the verkle trie database of chains scheduling the
+// verkle fork after genesis. From the fork on, the merkle state of the chain is
+// frozen and converted into verkle by the blocks on top of it.
+//
+// Unless the conversion is already over, the preimages of all the accounts and
+// slots must be available: they have to be recorded since genesis and the state
+// can't have been snap synced, as neither the state sync nor the snapshot carry
+// the preimages.
+func (bc *BlockChain) setupVerkleTransition() error {
+	if bc.chainConfig.VerkleTime == nil || bc.chainConfig.IsVerkleGenesis() {
+		return nil
+	}
+	if bc.triedb.Scheme() != rawdb.PathScheme {
+		return errVerkleTransitionScheme
+	}
+	if ts := rawdb.ReadVerkleTransitionState(bc.db, bc.CurrentBlock().Root); ts == nil || !ts.Ended {
+		if !bc.cacheConfig.Preimages {
+			return fmt.Errorf("%w: preimage recording disabled", errVerkleTransitionPreimages)
+		}
+		if pivot := rawdb.ReadLastPivotNumber(bc.db); pivot != nil {
+			return fmt.Errorf("%w: state snap synced at block %d", errVerkleTransitionPreimages, *pivot)
+		}
+	}
+	bc.verkleTriedb = triedb.NewDatabase(bc.db, bc.cacheConfig.triedbConfig(true))
+	bc.verkleStatedb = state.NewTransitionDatabase(bc.verkleTriedb, bc.triedb)
+	return nil
+}
+
+// stateDatabase returns the state database serving the given state root.
+func (bc *BlockChain) stateDatabase(root common.Hash) *state.CachingDB {
+	if bc.verkleStatedb != nil && bc.verkleStatedb.TransitionState(root) != nil {
+		return bc.verkleStatedb
+	}
+	return bc.statedb
+}
+
+// startVerkleTransition marks the parent state as the base of the verkle
+// transition if the given block is the first one after the verkle fork.
+func (bc *BlockChain) startVerkleTransition(parent *types.Header, header *types.Header) error {
+	if bc.verkleStatedb == nil || !bc.chainConfig.IsVerkle(header.Number, header.Time) {
+		return nil
+	}
+	return bc.verkleStatedb.StartTransition(parent.Root)
+}
+
+// StateAtParent returns a new mutable state of the parent of the given header,
+// to build the block on top of. If the block is the first one after the verkle
+// fork, the parent state becomes the base of the verkle transition.
+func (bc *BlockChain) StateAtParent(parent *types.Header, header *types.Header) (*state.StateDB, error) {
+	if err := bc.startVerkleTransition(parent, header); err != nil {
+		return nil, err
+	}
+	return bc.StateAt(parent.Root)
+}
+
+// VerkleTransition returns the progress of the verkle transition as of the given
+// state root, or nil if the state isn't part of the transition.
+func (bc *BlockChain) VerkleTransition(root common.Hash) *rawdb.VerkleTransitionState {
+	if bc.verkleStatedb == nil {
+		return nil
+	}
+	return bc.verkleStatedb.TransitionState(root)
+}
+
+// merkleRoot returns the root of the merkle state underpinning the given state,
+// which is the base of the transition for the states converted into verkle.
+func (bc *BlockChain) merkleRoot(root common.Hash) common.Hash {
+	if ts := bc.VerkleTransition(root); ts != nil {
+		return ts.BaseRoot
+	}
+	return root
+}
+
+// stopVerkleTransition journals the in-memory verkle trie nodes of the head
+// state and closes the verkle trie database.
+func (bc *BlockChain) stopVerkleTransition() {
+	if bc.verkleTriedb == nil {
+		return
+	}
+	if root := bc.CurrentBlock().Root; bc.VerkleTransition(root) != nil {
+		if err := bc.verkleTriedb.Journal(root); err != nil {
+			log.Info("Failed to journal in-memory verkle nodes", "err", err)
+		}
+	}
+	if err := bc.verkleTriedb.Close(); err != nil {
+		log.Error("Failed to close verkle trie database", "err", err)
+	}
+}
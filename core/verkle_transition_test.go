@@ -0,0 +1,61 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the verkle transition is only configured if the preimages of the
+// whole state are available.
+func TestVerkleTransitionPreimages(t *testing.T) {
+	config := *params.MergedTestChainConfig
+	config.VerkleTime = new(uint64)
+	*config.VerkleTime = 1000
+
+	tests := []struct {
+		preimages bool
+		pivot     bool
+		err       error
+	}{
+		{preimages: false, err: errVerkleTransitionPreimages},
+		{preimages: true, pivot: true, err: errVerkleTransitionPreimages},
+		{preimages: true},
+	}
+	for i, tt := range tests {
+		db := rawdb.NewMemoryDatabase()
+		if tt.pivot {
+			rawdb.WriteLastPivotNumber(db, 1)
+		}
+		cacheConfig := DefaultCacheConfigWithScheme(rawdb.PathScheme)
+		cacheConfig.Preimages = tt.preimages
+
+		chain, err := NewBlockChain(db, cacheConfig, &Genesis{Config: &config}, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if err == nil {
+			chain.Stop()
+		}
+	}
+}
@@ -17,9 +17,11 @@
 package vm
 
 import (
+	"bytes"
 	"math/bits"
 	"testing"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -59,6 +61,33 @@ func TestJumpDestAnalysis(t *testing.T) {
 	}
 }
 
+// Tests that the analyses are persisted with a checksum, and that corrupted
+// entries of the persistent store are dropped.
+func TestCodeBitmapStore(t *testing.T) {
+	var (
+		store    = fastcache.New(1024 * 1024)
+		code     = []byte{byte(PUSH1), 0x01, byte(JUMPDEST), byte(PUSH2), 0x5b, 0x5b}
+		codeHash = crypto.Keccak256Hash(code)
+		analysis = codeBitmap(code)
+	)
+	storeCodeBitmap(store, codeHash, analysis)
+	codeBitmapCache.Remove(codeHash)
+	if have := loadCodeBitmap(store, codeHash); !bytes.Equal(have, analysis) {
+		t.Fatalf("stored analysis mismatch: have %x, want %x", have, analysis)
+	}
+	// Flip the analysis within the store, the entry must be rejected
+	codeBitmapCache.Remove(codeHash)
+	enc := store.Get(nil, codeHash[:])
+	enc[0] ^= 0xff
+	store.Set(codeHash[:], enc)
+	if have := loadCodeBitmap(store, codeHash); have != nil {
+		t.Fatalf("corrupted analysis loaded: %x", have)
+	}
+	if store.Has(codeHash[:]) {
+		t.Fatalf("corrupted analysis not dropped")
+	}
+}
+
 const analysisCodeSize = 1200 * 1024
 
 func BenchmarkJumpdestAnalysis_1200k(bench *testing.B) {
@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	callMemoHitMeter    = metrics.NewRegisteredMeter("vm/callmemo/hit", nil)
+	callMemoMissMeter   = metrics.NewRegisteredMeter("vm/callmemo/miss", nil)
+	callMemoImpureMeter = metrics.NewRegisteredMeter("vm/callmemo/impure", nil)
+)
+
+// pureOpcodes marks the opcodes whose result only depends on the code, the call
+// input and the block context. Static calls only executing such opcodes yield
+// the same result whenever they are repeated within a block. Anything reading
+// the state, the transaction context, the remaining gas or calling out makes
+// the frame impure.
+var pureOpcodes [256]bool
+
+func init() {
+	for op := STOP; op <= SIGNEXTEND; op++ {
+		pureOpcodes[op] = true
+	}
+	for op := LT; op <= SAR; op++ {
+		pureOpcodes[op] = true
+	}
+	for op := PUSH0; op <= SWAP16; op++ {
+		pureOpcodes[op] = true // pushes, dups and swaps are contiguous
+	}
+	for _, op := range []OpCode{
+		KECCAK256,
+		CALLVALUE, CALLDATALOAD, CALLDATASIZE, CALLDATACOPY, CODESIZE, CODECOPY, RETURNDATASIZE, RETURNDATACOPY,
+		COINBASE, TIMESTAMP, NUMBER, PREVRANDAO, GASLIMIT, CHAINID, BASEFEE, BLOBBASEFEE,
+		POP, MLOAD, MSTORE, MSTORE8, JUMP, JUMPI, PC, MSIZE, JUMPDEST, MCOPY,
+		RETURN, REVERT, INVALID,
+	} {
+		pureOpcodes[op] = true
+	}
+}
+
+// callMemoEntry is the memoized outcome of a pure static call.
+type callMemoEntry struct {
+	ret     []byte
+	gasUsed uint64
+	err     error // nil or ErrExecutionReverted
+}
+
+// callMemo memoizes the results of pure static calls within a block, keyed by
+// the code hash of the callee and the call input. The memo lives as long as the
+// EVM, which is created for the state transition of a single block.
+type callMemo struct {
+	limit   int
+	entries map[common.Hash]callMemoEntry
+	tainted bool // Whether an impure opcode ran since the last static call started
+}
+
+// newCallMemo creates a memo holding up to limit static call results.
+func newCallMemo(limit int) *callMemo {
+	return &callMemo{
+		limit:   limit,
+		entries: make(map[common.Hash]callMemoEntry),
+	}
+}
+
+// callMemoEnabled reports whether static call results may be memoized with the
+// given configuration. Tracers must observe every executed opcode, preimage
+// recording is a side effect of hashing, and the verkle code chunk costs depend
+// on the accessed state, so all of them disable the memo.
+func callMemoEnabled(config Config, rules params.Rules) bool {
+	return config.StaticCallMemo > 0 && config.Tracer == nil && !config.EnablePreimageRecording && !rules.IsEIP4762
+}
+
+// runStatic executes the code of a static call, serving the result from the
+// memo if the same code already ran purely with the same input in the block,
+// and there's enough gas to cover it.
+func (evm *EVM) runStatic(contract *Contract, input []byte) ([]byte, error) {
+	memo := evm.callMemo
+	key := crypto.Keccak256Hash(contract.CodeHash[:], input)
+	if entry, ok := memo.entries[key]; ok && contract.Gas >= entry.gasUsed {
+		callMemoHitMeter.Mark(1)
+		contract.Gas -= entry.gasUsed
+		return common.CopyBytes(entry.ret), entry.err
+	}
+	callMemoMissMeter.Mark(1)
+
+	// Track the purity of this frame on its own, the caller is impure anyway as
+	// it issued a call
+	tainted := memo.tainted
+	memo.tainted = false
+	defer func() { memo.tainted = memo.tainted || tainted }()
+
+	startGas := contract.Gas
+	ret, err := evm.interpreter.Run(contract, input, true)
+	if memo.tainted {
+		callMemoImpureMeter.Mark(1)
+		return ret, err
+	}
+	if (err == nil || err == ErrExecutionReverted) && len(memo.entries) < memo.limit {
+		memo.entries[key] = callMemoEntry{
+			ret:     common.CopyBytes(ret),
+			gasUsed: startGas - contract.Gas,
+			err:     err,
+		}
+	}
+	return ret, err
+}
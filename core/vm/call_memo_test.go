@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that pure static calls are memoized across callees sharing the code,
+// while calls reading the state are always executed.
+func TestStaticCallMemo(t *testing.T) {
+	var (
+		pure      = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		twin      = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		impure    = common.HexToAddress("0x000000000000000000000000000000000000cccc")
+		pureCode  = common.FromHex("0x60003560020260005260206000f3") // return 2 * calldata[0:32]
+		sloadCode = common.FromHex("0x60005460005260206000f3")       // return storage[0]
+		caller    = AccountRef(common.Address{})
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(pure, pureCode)
+	statedb.SetCode(twin, pureCode)
+	statedb.SetCode(impure, sloadCode)
+
+	evm := NewEVM(BlockContext{BlockNumber: big.NewInt(1)}, statedb, params.TestChainConfig, Config{StaticCallMemo: 16})
+	if evm.callMemo == nil {
+		t.Fatal("call memo not enabled")
+	}
+	input := common.LeftPadBytes([]byte{21}, 32)
+
+	ret, left, err := evm.StaticCall(caller, pure, input, 100000)
+	if err != nil || new(big.Int).SetBytes(ret).Uint64() != 42 {
+		t.Fatalf("pure call mismatch: have %x, %v", ret, err)
+	}
+	used := 100000 - left
+	if len(evm.callMemo.entries) != 1 {
+		t.Fatalf("pure call not memoized: %d entries", len(evm.callMemo.entries))
+	}
+	// Repeated calls to the same code are served from the memo, charging the same gas
+	ret, left, err = evm.StaticCall(caller, twin, input, 100000)
+	if err != nil || new(big.Int).SetBytes(ret).Uint64() != 42 || 100000-left != used {
+		t.Fatalf("memoized call mismatch: have %x, gas %d, %v", ret, 100000-left, err)
+	}
+	if len(evm.callMemo.entries) != 1 {
+		t.Fatalf("memo entries mismatch: have %d, want 1", len(evm.callMemo.entries))
+	}
+	// Calls without enough gas to cover the memoized usage still run out of gas
+	if _, _, err := evm.StaticCall(caller, pure, input, used-1); !errors.Is(err, ErrOutOfGas) {
+		t.Fatalf("underfunded call error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+	// State dependent calls are never memoized
+	for _, value := range []byte{1, 2} {
+		statedb.SetState(impure, common.Hash{}, common.BytesToHash([]byte{value}))
+		ret, _, err := evm.StaticCall(caller, impure, nil, 100000)
+		if err != nil || new(big.Int).SetBytes(ret).Uint64() != uint64(value) {
+			t.Fatalf("impure call mismatch: have %x, want %d, %v", ret, value, err)
+		}
+	}
+	if len(evm.callMemo.entries) != 1 {
+		t.Fatalf("impure call memoized: %d entries", len(evm.callMemo.entries))
+	}
+	// Tracing disables the memo altogether
+	traced := NewEVM(BlockContext{BlockNumber: big.NewInt(1)}, statedb, params.TestChainConfig, Config{StaticCallMemo: 16, Tracer: &tracing.Hooks{}})
+	if traced.callMemo != nil {
+		t.Fatal("call memo enabled while tracing")
+	}
+}
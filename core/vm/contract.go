@@ -17,14 +17,24 @@
 package vm
 
 import (
+	"bytes"
+
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/holiman/uint256"
 )
 
-const codeBitmapCacheSize = 2000
+const (
+	codeBitmapCacheSize = 2000
+
+	// codeBitmapChecksumSize is the size of the checksum appended to the code
+	// analyses in the persistent store, guarding against corrupted entries.
+	codeBitmapChecksumSize = 8
+)
 
 var (
 	codeBitmapCache = lru.NewCache[common.Hash, bitvec](codeBitmapCacheSize)
@@ -33,6 +43,47 @@ var (
 	contractCodeBitmapMissMeter = metrics.NewRegisteredMeter("vm/contract/code/bitmap/miss", nil)
 )
 
+// codeBitmapChecksum returns the checksum of the analysis of the code with the
+// given hash, binding the analysis to the code.
+func codeBitmapChecksum(codeHash common.Hash, analysis []byte) []byte {
+	return crypto.Keccak256(codeHash[:], analysis)[:codeBitmapChecksumSize]
+}
+
+// loadCodeBitmap retrieves the analysis of the code from the caches, or nil if
+// the code wasn't analysed yet. Entries of the persistent store failing their
+// checksum are dropped.
+func loadCodeBitmap(store *fastcache.Cache, codeHash common.Hash) bitvec {
+	if cached, ok := codeBitmapCache.Get(codeHash); ok {
+		return cached
+	}
+	if store == nil {
+		return nil
+	}
+	enc, ok := store.HasGet(nil, codeHash[:])
+	if !ok {
+		return nil
+	}
+	if len(enc) <= codeBitmapChecksumSize {
+		store.Del(codeHash[:])
+		return nil
+	}
+	analysis, checksum := enc[:len(enc)-codeBitmapChecksumSize], enc[len(enc)-codeBitmapChecksumSize:]
+	if !bytes.Equal(checksum, codeBitmapChecksum(codeHash, analysis)) {
+		store.Del(codeHash[:])
+		return nil
+	}
+	codeBitmapCache.Add(codeHash, analysis)
+	return analysis
+}
+
+// storeCodeBitmap caches the analysis of the code.
+func storeCodeBitmap(store *fastcache.Cache, codeHash common.Hash, analysis bitvec) {
+	codeBitmapCache.Add(codeHash, analysis)
+	if store != nil {
+		store.Set(codeHash[:], append(common.CopyBytes(analysis), codeBitmapChecksum(codeHash, analysis)...))
+	}
+}
+
 // ContractRef is a reference to the contract's backing object
 type ContractRef interface {
 	Address() common.Address
@@ -63,6 +114,8 @@ type Contract struct {
 	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
 	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
 
+	analysisStore *fastcache.Cache // Persistent store of JUMPDEST analyses of the executing EVM, if any
+
 	Code     []byte
 	CodeHash common.Hash
 	CodeAddr *common.Address
@@ -104,7 +157,7 @@ func (c *Contract) isCode(udest uint64) bool {
 		// Does parent context have the analysis?
 		analysis, exist := c.jumpdests[c.CodeHash]
 		if !exist {
-			if cached, ok := codeBitmapCache.Get(c.CodeHash); ok {
+			if cached := loadCodeBitmap(c.analysisStore, c.CodeHash); cached != nil {
 				contractCodeBitmapHitMeter.Mark(1)
 				analysis = cached
 			} else {
@@ -113,7 +166,7 @@ func (c *Contract) isCode(udest uint64) bool {
 				analysis = codeBitmap(c.Code)
 				c.jumpdests[c.CodeHash] = analysis
 				contractCodeBitmapMissMeter.Mark(1)
-				codeBitmapCache.Add(c.CodeHash, analysis)
+				storeCodeBitmap(c.analysisStore, c.CodeHash, analysis)
 			}
 		}
 		// Also stash it in current contract for faster access
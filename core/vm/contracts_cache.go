@@ -0,0 +1,73 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// precompileCacheSize is the total size of the precompile outputs cached.
+const precompileCacheSize = 4 * 1024 * 1024
+
+var (
+	precompileCacheHitMeter  = metrics.NewRegisteredMeter("vm/precompile/cache/hit", nil)
+	precompileCacheMissMeter = metrics.NewRegisteredMeter("vm/precompile/cache/miss", nil)
+
+	// precompileCache holds the outputs of the expensive precompiles, shared by
+	// all the EVMs of the process.
+	precompileCache = lru.NewSizeConstrainedCache[precompileCacheKey, []byte](precompileCacheSize)
+)
+
+// precompileCacheKey identifies a precompile run. The precompile is part of the
+// key as its variants may accept different inputs across forks.
+type precompileCacheKey struct {
+	contract PrecompiledContract
+	input    common.Hash
+}
+
+// isCachedPrecompile reports whether the outputs of the precompile are worth
+// caching, i.e. the precompile is expensive compared to hashing its input.
+func isCachedPrecompile(p PrecompiledContract) bool {
+	switch p.(type) {
+	case *bigModExp, *bn256PairingIstanbul, *bn256PairingByzantium, *bls12381Pairing, *kzgPointEvaluation:
+		return true
+	}
+	return false
+}
+
+// runPrecompile runs the precompile, serving the outputs of the expensive ones
+// from the cache. Only successful runs are cached, failures are cheap to detect.
+func runPrecompile(p PrecompiledContract, input []byte) ([]byte, error) {
+	if !isCachedPrecompile(p) {
+		return p.Run(input)
+	}
+	key := precompileCacheKey{contract: p, input: crypto.Keccak256Hash(input)}
+	if output, ok := precompileCache.Get(key); ok {
+		precompileCacheHitMeter.Mark(1)
+		return common.CopyBytes(output), nil
+	}
+	precompileCacheMissMeter.Mark(1)
+
+	output, err := p.Run(input)
+	if err == nil {
+		precompileCache.Add(key, common.CopyBytes(output))
+	}
+	return output, err
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the outputs of expensive precompiles are cached per precompile
+// variant, and that callers can't corrupt the cached outputs.
+func TestPrecompileCache(t *testing.T) {
+	var (
+		modexp = &bigModExp{eip2565: true}
+		legacy = &bigModExp{}
+		// 3 ** 5 % 7, with one byte long operands
+		input = common.FromHex("0x" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"030507")
+		key = precompileCacheKey{contract: modexp, input: crypto.Keccak256Hash(input)}
+	)
+	output, _, err := RunPrecompiledContract(modexp, input, 100000, nil)
+	if err != nil || !bytes.Equal(output, []byte{5}) {
+		t.Fatalf("modexp output mismatch: have %x, %v", output, err)
+	}
+	if _, ok := precompileCache.Get(key); !ok {
+		t.Fatal("modexp output not cached")
+	}
+	if _, ok := precompileCache.Get(precompileCacheKey{contract: legacy, input: key.input}); ok {
+		t.Fatal("output cached for another precompile variant")
+	}
+	output[0] = 0xff
+
+	output, _, err = RunPrecompiledContract(modexp, input, 100000, nil)
+	if err != nil || !bytes.Equal(output, []byte{5}) {
+		t.Fatalf("cached output mismatch: have %x, %v", output, err)
+	}
+	// Cheap precompiles are never cached
+	if isCachedPrecompile(&sha256hash{}) {
+		t.Fatal("cheap precompile cached")
+	}
+}
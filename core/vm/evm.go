@@ -122,6 +122,14 @@ type EVM struct {
 	callGasTemp uint64
 	// precompiles holds the precompiled contracts for the current epoch
 	precompiles map[common.Address]PrecompiledContract
+	// stats aggregates the resource usage of the executed call frames
+	stats ExecutionStats
+	// nativeMinter is set if the native minter is active in the current block
+	nativeMinter bool
+	// foreignState is set if the foreign state reader is active in the current block
+	foreignState bool
+	// callMemo holds the results of the pure static calls of the block, nil if disabled
+	callMemo *callMemo
 }
 
 // NewEVM constructs an EVM instance with the supplied block context, state
@@ -137,6 +145,11 @@ func NewEVM(blockCtx BlockContext, statedb StateDB, chainConfig *params.ChainCon
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time),
 	}
 	evm.precompiles = activePrecompiledContracts(evm.chainRules)
+	evm.nativeMinter = chainConfig.IsNativeMinter(blockCtx.BlockNumber)
+	evm.foreignState = chainConfig.IsForeignStateReader(blockCtx.BlockNumber)
+	if callMemoEnabled(config, evm.chainRules) {
+		evm.callMemo = newCallMemo(config.StaticCallMemo)
+	}
 	evm.interpreter = NewEVMInterpreter(evm)
 
 	return evm
@@ -198,6 +211,12 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if !value.IsZero() && !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, ErrInsufficientBalance
 	}
+	if evm.isNativeMinter(addr) {
+		return evm.callNativeMinter(caller.Address(), input, gas, value)
+	}
+	if evm.isForeignStateReader(addr) {
+		return evm.callForeignStateReader(input, gas, value)
+	}
 	snapshot := evm.StateDB.Snapshot()
 	p, isPrecompile := evm.precompile(addr)
 
@@ -279,6 +298,12 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	if evm.isNativeMinter(addr) {
+		return nil, 0, errNativeMinterCallType
+	}
+	if evm.isForeignStateReader(addr) {
+		return nil, 0, errForeignStateCallType
+	}
 	// Fail if we're trying to transfer more than the available balance
 	// Note although it's noop to transfer X ether to caller itself. But
 	// if caller doesn't have enough balance, it would be an error to allow
@@ -336,6 +361,12 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	if evm.isNativeMinter(addr) {
+		return nil, 0, errNativeMinterCallType
+	}
+	if evm.isForeignStateReader(addr) {
+		return nil, 0, errForeignStateCallType
+	}
 	var snapshot = evm.StateDB.Snapshot()
 
 	// It is allowed to call precompiles, even via delegatecall
@@ -379,6 +410,12 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	if evm.isNativeMinter(addr) {
+		return nil, 0, errNativeMinterCallType
+	}
+	if evm.isForeignStateReader(addr) {
+		return evm.callForeignStateReader(input, gas, new(uint256.Int))
+	}
 	// We take a snapshot here. This is a bit counter-intuitive, and could probably be skipped.
 	// However, even a staticcall is considered a 'touch'. On mainnet, static calls were introduced
 	// after all empty accounts were deleted, so this is not required. However, if we omit this,
@@ -408,7 +445,11 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 		// When an error was returned by the EVM or when setting the creation code
 		// above we revert to the snapshot and consume any gas remaining. Additionally
 		// when we're in Homestead this also counts for code storage gas errors.
-		ret, err = evm.interpreter.Run(contract, input, true)
+		if evm.callMemo != nil {
+			ret, err = evm.runStatic(contract, input)
+		} else {
+			ret, err = evm.interpreter.Run(contract, input, true)
+		}
 		gas = contract.Gas
 	}
 	if err != nil {
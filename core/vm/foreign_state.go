@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// maxForeignProofNodes is the maximum number of nodes of a single proof, well
+// above the depth of any realistic trie.
+const maxForeignProofNodes = 64
+
+var (
+	errForeignStateInput    = errors.New("invalid foreign state read input")
+	errForeignStateRoot     = errors.New("foreign state root not registered")
+	errForeignStateProof    = errors.New("invalid foreign state proof")
+	errForeignStateValue    = errors.New("foreign state reader does not accept value")
+	errForeignStateCallType = errors.New("foreign state reader only supports plain and static calls")
+)
+
+// ForeignStateRootSlot returns the storage slot of the registry holding the
+// state root of the given block of a foreign chain, roots[chainId][number].
+func ForeignStateRootSlot(chainID, number *uint256.Int) common.Hash {
+	inner := crypto.Keccak256(chainID.PaddedBytes(32), make([]byte, 32))
+	return crypto.Keccak256Hash(number.PaddedBytes(32), inner)
+}
+
+// isForeignStateReader reports whether the given address is the active foreign
+// state reader.
+func (evm *EVM) isForeignStateReader(addr common.Address) bool {
+	return evm.foreignState && addr == params.ForeignStateReaderAddress
+}
+
+// callForeignStateReader reads a storage slot of an account on a foreign chain.
+// The input is the ABI encoding of
+//
+//	(uint256 chainId, uint256 number, address account, bytes32 slot, bytes[] accountProof, bytes[] storageProof)
+//
+// with the proofs as returned by eth_getProof against the foreign chain. They
+// are verified against the state root registered for the block, and the 32
+// byte slot value is returned, zero if the account or the slot is absent. Like
+// precompiles, failing calls consume all gas.
+func (evm *EVM) callForeignStateReader(input []byte, gas uint64, value *uint256.Int) ([]byte, uint64, error) {
+	words := (uint64(len(input)) + 31) / 32
+	if words > (math.MaxUint64-params.ForeignStateReadGas)/params.ForeignStateReadWordGas {
+		return nil, 0, ErrOutOfGas
+	}
+	cost := params.ForeignStateReadGas + words*params.ForeignStateReadWordGas
+	if gas < cost {
+		return nil, 0, ErrOutOfGas
+	}
+	if evm.Config.Tracer != nil && evm.Config.Tracer.OnGasChange != nil {
+		evm.Config.Tracer.OnGasChange(gas, gas-cost, tracing.GasChangeCallPrecompiledContract)
+	}
+	gas -= cost
+
+	if !value.IsZero() {
+		return nil, 0, errForeignStateValue
+	}
+	if len(input) < 6*32 {
+		return nil, 0, errForeignStateInput
+	}
+	var (
+		chainID = new(uint256.Int).SetBytes(input[:32])
+		number  = new(uint256.Int).SetBytes(input[32:64])
+		account = common.BytesToAddress(input[64:96])
+		slot    = common.BytesToHash(input[96:128])
+	)
+	accountProof, err := decodeProofArg(input, input[128:160])
+	if err != nil {
+		return nil, 0, err
+	}
+	storageProof, err := decodeProofArg(input, input[160:192])
+	if err != nil {
+		return nil, 0, err
+	}
+	root := evm.StateDB.GetState(evm.chainConfig.ForeignState.Registry, ForeignStateRootSlot(chainID, number))
+	if root == (common.Hash{}) {
+		return nil, 0, errForeignStateRoot
+	}
+	blob, err := verifyForeignProof(root, account.Bytes(), accountProof)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(blob) == 0 {
+		return make([]byte, 32), gas, nil // Absent account, zero storage
+	}
+	var acc types.StateAccount
+	if err := rlp.DecodeBytes(blob, &acc); err != nil {
+		return nil, 0, errForeignStateProof
+	}
+	blob, err = verifyForeignProof(acc.Root, slot.Bytes(), storageProof)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(blob) == 0 {
+		return make([]byte, 32), gas, nil // Absent slot
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil || len(content) > 32 {
+		return nil, 0, errForeignStateProof
+	}
+	return common.LeftPadBytes(content, 32), gas, nil
+}
+
+// verifyForeignProof verifies a Merkle proof of the given key against the root,
+// returning the proven value, or nil if the proof shows the key is absent.
+func verifyForeignProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, err := trie.VerifyProof(root, crypto.Keccak256(key), db)
+	if err != nil {
+		return nil, errForeignStateProof
+	}
+	return value, nil
+}
+
+// decodeProofArg decodes a bytes[] argument of the ABI encoded input, given the
+// head word holding its offset.
+func decodeProofArg(input []byte, head []byte) ([][]byte, error) {
+	start, ok := abiOffset(input, head)
+	if !ok {
+		return nil, errForeignStateInput
+	}
+	count, ok := abiOffset(input, input[start:start+32])
+	if !ok || count > maxForeignProofNodes {
+		return nil, errForeignStateInput
+	}
+	var (
+		base  = start + 32 // Element offsets are relative to the array contents
+		nodes = make([][]byte, 0, count)
+	)
+	if base+count*32 > uint64(len(input)) {
+		return nil, errForeignStateInput
+	}
+	for i := uint64(0); i < count; i++ {
+		offset, ok := abiOffset(input, input[base+i*32:base+(i+1)*32])
+		if !ok || base+offset+32 > uint64(len(input)) {
+			return nil, errForeignStateInput
+		}
+		pos := base + offset
+		size, ok := abiOffset(input, input[pos:pos+32])
+		if !ok || pos+32+size > uint64(len(input)) {
+			return nil, errForeignStateInput
+		}
+		nodes = append(nodes, input[pos+32:pos+32+size])
+	}
+	return nodes, nil
+}
+
+// abiOffset decodes an ABI word used as an offset or a length, which must leave
+// room for a word to read within the input.
+func abiOffset(input []byte, word []byte) (uint64, bool) {
+	n := new(uint256.Int).SetBytes(word)
+	if !n.IsUint64() || n.Uint64() > uint64(len(input))-32 {
+		return 0, false
+	}
+	return n.Uint64(), true
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// proveForeign returns the proof of the hashed key in the given trie.
+func proveForeign(t *testing.T, tr *trie.Trie, key []byte) [][]byte {
+	db := memorydb.New()
+	if err := tr.Prove(crypto.Keccak256(key), db); err != nil {
+		t.Fatalf("failed to prove key: %v", err)
+	}
+	var proof [][]byte
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		proof = append(proof, common.CopyBytes(it.Value()))
+	}
+	return proof
+}
+
+// encodeBytesArray ABI encodes a bytes[] value.
+func encodeBytesArray(items [][]byte) []byte {
+	var (
+		head = common.LeftPadBytes(big.NewInt(int64(len(items))).Bytes(), 32)
+		body []byte
+	)
+	for _, item := range items {
+		head = append(head, common.LeftPadBytes(big.NewInt(int64(32*len(items)+len(body))).Bytes(), 32)...)
+		body = append(body, common.LeftPadBytes(big.NewInt(int64(len(item))).Bytes(), 32)...)
+		body = append(body, common.RightPadBytes(item, (len(item)+31)/32*32)...)
+	}
+	return append(head, body...)
+}
+
+// foreignStateInput ABI encodes a foreign state read.
+func foreignStateInput(chainID, number uint64, account common.Address, slot common.Hash, accountProof, storageProof [][]byte) []byte {
+	var (
+		accounts = encodeBytesArray(accountProof)
+		storages = encodeBytesArray(storageProof)
+		input    []byte
+	)
+	input = append(input, common.LeftPadBytes(new(big.Int).SetUint64(chainID).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(new(big.Int).SetUint64(number).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(account.Bytes(), 32)...)
+	input = append(input, slot.Bytes()...)
+	input = append(input, common.LeftPadBytes(big.NewInt(6*32).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(big.NewInt(int64(6*32+len(accounts))).Bytes(), 32)...)
+	input = append(input, accounts...)
+	return append(input, storages...)
+}
+
+func TestForeignStateReader(t *testing.T) {
+	var (
+		registry = common.HexToAddress("0x1000")
+		account  = common.HexToAddress("0x2000")
+		slot     = common.HexToHash("0x01")
+		value    = common.HexToHash("0xc0ffee")
+		config   = *params.AllEthashProtocolChanges
+	)
+	config.ForeignState = &params.ForeignStateConfig{Block: big.NewInt(0), Registry: registry}
+
+	// Assemble the foreign state holding a single slot
+	tdb := triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+	storage := trie.NewEmpty(tdb)
+	enc, _ := rlp.EncodeToBytes(common.TrimLeftZeroes(value.Bytes()))
+	storage.MustUpdate(crypto.Keccak256(slot.Bytes()), enc)
+
+	accounts := trie.NewEmpty(tdb)
+	enc, _ = rlp.EncodeToBytes(&types.StateAccount{Nonce: 1, Balance: new(uint256.Int), Root: storage.Hash(), CodeHash: types.EmptyCodeHash[:]})
+	accounts.MustUpdate(crypto.Keccak256(account.Bytes()), enc)
+
+	var (
+		accountProof = proveForeign(t, accounts, account.Bytes())
+		storageProof = proveForeign(t, storage, slot.Bytes())
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetState(registry, ForeignStateRootSlot(uint256.NewInt(56), uint256.NewInt(100)), accounts.Hash())
+
+	evm := NewEVM(BlockContext{BlockNumber: big.NewInt(1)}, statedb, &config, Config{})
+	read := func(number uint64, slot common.Hash, accountProof, storageProof [][]byte) ([]byte, error) {
+		ret, _, err := evm.StaticCall(AccountRef(common.Address{}), params.ForeignStateReaderAddress, foreignStateInput(56, number, account, slot, accountProof, storageProof), 1000000)
+		return ret, err
+	}
+	// Read the slot and an absent one
+	ret, err := read(100, slot, accountProof, storageProof)
+	if err != nil {
+		t.Fatalf("foreign read failed: %v", err)
+	}
+	if !bytes.Equal(ret, value.Bytes()) {
+		t.Errorf("foreign value mismatch: have %x, want %x", ret, value)
+	}
+	absent := common.HexToHash("0x02")
+	if ret, err := read(100, absent, accountProof, proveForeign(t, storage, absent.Bytes())); err != nil || !bytes.Equal(ret, make([]byte, 32)) {
+		t.Errorf("absent slot mismatch: have %x, %v", ret, err)
+	}
+	// Reject unregistered roots, mismatching proofs and other call types
+	if _, err := read(101, slot, accountProof, storageProof); !errors.Is(err, errForeignStateRoot) {
+		t.Errorf("unregistered root accepted: %v", err)
+	}
+	if _, err := read(100, slot, accountProof, accountProof); !errors.Is(err, errForeignStateProof) {
+		t.Errorf("invalid storage proof accepted: %v", err)
+	}
+	if _, _, err := evm.Call(AccountRef(common.Address{}), params.ForeignStateReaderAddress, []byte{1}, 1000000, new(uint256.Int)); !errors.Is(err, errForeignStateInput) {
+		t.Errorf("short input accepted: %v", err)
+	}
+	if _, _, err := evm.DelegateCall(AccountRef(common.Address{}), params.ForeignStateReaderAddress, nil, 1000000); !errors.Is(err, errForeignStateCallType) {
+		t.Errorf("delegate call accepted: %v", err)
+	}
+}
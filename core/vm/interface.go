@@ -39,6 +39,10 @@ type StateDB interface {
 	GetBalance(common.Address) *uint256.Int
 	SetBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason)
 
+	// AccountAbsent reports whether the account is known not to exist from the
+	// flat state alone, false if unknown.
+	AccountAbsent(common.Address) bool
+
 	GetNonce(common.Address) uint64
 	SetNonce(common.Address, uint64, tracing.NonceChangeReason)
 
@@ -19,6 +19,7 @@ package vm
 import (
 	"fmt"
 
+	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/tracing"
@@ -35,6 +36,11 @@ type Config struct {
 	ExtraEips               []int // Additional EIPS that are to be enabled
 
 	StatelessSelfValidation bool // Generate execution witnesses and self-check against them (testing purpose)
+
+	ParallelWorkers int // Number of workers speculatively executing block transactions in parallel (0 = serial)
+	StaticCallMemo  int // Number of pure static call results memoized per block (0 = disabled)
+
+	CodeAnalysisStore *fastcache.Cache // Optional persistent store of code analyses backing the in-memory cache
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -163,7 +169,11 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (ret []byte, err error) {
 	// Increment the call depth which is restricted to 1024
 	in.evm.depth++
-	defer func() { in.evm.depth-- }()
+	in.evm.stats.trackDepth(in.evm.depth)
+	defer func() {
+		in.evm.stats.trackReturn(len(ret))
+		in.evm.depth--
+	}()
 
 	// Make sure the readOnly is only set if we aren't in readOnly yet.
 	// This also makes sure that the readOnly flag isn't removed for child calls.
@@ -180,6 +190,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	contract.analysisStore = in.evm.Config.CodeAnalysisStore
 
 	var (
 		op          OpCode        // current opcode
@@ -245,6 +256,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
 		operation := in.table[op]
+		if memo := in.evm.callMemo; memo != nil && !pureOpcodes[op] {
+			memo.tainted = true
+		}
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := stack.len(); sLen < operation.minStack {
@@ -306,6 +320,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		}
 		if memorySize > 0 {
 			mem.Resize(memorySize)
+			in.evm.stats.trackMemory(memorySize)
 		}
 
 		// execute the operation
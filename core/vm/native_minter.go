@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// NativeMintTopic is the topic of the audit log emitted on minting, the
+	// event being NativeMint(address indexed minter, address indexed to, uint256 amount).
+	NativeMintTopic = crypto.Keccak256Hash([]byte("NativeMint(address,address,uint256)"))
+
+	// NativeBurnTopic is the topic of the audit log emitted on burning, the
+	// event being NativeBurn(address indexed minter, address indexed from, uint256 amount).
+	NativeBurnTopic = crypto.Keccak256Hash([]byte("NativeBurn(address,address,uint256)"))
+
+	// Storage slots of the native minter account tracking the supply changes.
+	nativeMintedSlot = common.Hash{}
+	nativeBurntSlot  = common.BigToHash(common.Big1)
+
+	nativeMintSelector = crypto.Keccak256([]byte("mint(address,uint256)"))[:4]
+	nativeBurnSelector = crypto.Keccak256([]byte("burn(address,uint256)"))[:4]
+
+	errNativeMinterCaller   = errors.New("caller not allowed to mint")
+	errNativeMinterInput    = errors.New("invalid native minter input")
+	errNativeMinterValue    = errors.New("native minter does not accept value")
+	errNativeMinterCallType = errors.New("native minter only supports plain calls")
+	errNativeMinterOverflow = errors.New("native supply counter overflow")
+)
+
+// NativeSupplyChange returns the total native balance minted and burnt through
+// the native minter in the given state.
+func NativeSupplyChange(db StateDB) (minted, burnt *uint256.Int) {
+	minted = new(uint256.Int).SetBytes(db.GetState(params.NativeMinterAddress, nativeMintedSlot).Bytes())
+	burnt = new(uint256.Int).SetBytes(db.GetState(params.NativeMinterAddress, nativeBurntSlot).Bytes())
+	return minted, burnt
+}
+
+// isNativeMinter reports whether the given address is the active native minter.
+func (evm *EVM) isNativeMinter(addr common.Address) bool {
+	return evm.nativeMinter && addr == params.NativeMinterAddress
+}
+
+// callNativeMinter executes a mint(address,uint256) or burn(address,uint256)
+// call of an allowed system contract, recording the change of the native supply
+// and emitting an audit log. Like precompiles, failing calls consume all gas.
+func (evm *EVM) callNativeMinter(caller common.Address, input []byte, gas uint64, value *uint256.Int) ([]byte, uint64, error) {
+	if gas < params.NativeMinterGas {
+		return nil, 0, ErrOutOfGas
+	}
+	if evm.Config.Tracer != nil && evm.Config.Tracer.OnGasChange != nil {
+		evm.Config.Tracer.OnGasChange(gas, gas-params.NativeMinterGas, tracing.GasChangeCallPrecompiledContract)
+	}
+	gas -= params.NativeMinterGas
+
+	// Minting and burning modify the state, which a static frame up the call
+	// stack forbids even for calls without value
+	if evm.interpreter.readOnly {
+		return nil, 0, ErrWriteProtection
+	}
+	if !evm.chainConfig.NativeMinter.IsAllowedMinter(caller) {
+		return nil, 0, errNativeMinterCaller
+	}
+	if !value.IsZero() {
+		return nil, 0, errNativeMinterValue
+	}
+	if len(input) != 4+2*32 {
+		return nil, 0, errNativeMinterInput
+	}
+	var (
+		account = common.BytesToAddress(input[4:36])
+		amount  = new(uint256.Int).SetBytes(input[36:68])
+		topic   common.Hash
+		slot    common.Hash
+	)
+	switch {
+	case bytes.Equal(input[:4], nativeMintSelector):
+		topic, slot = NativeMintTopic, nativeMintedSlot
+
+	case bytes.Equal(input[:4], nativeBurnSelector):
+		if evm.StateDB.GetBalance(account).Lt(amount) {
+			return nil, 0, ErrInsufficientBalance
+		}
+		topic, slot = NativeBurnTopic, nativeBurntSlot
+
+	default:
+		return nil, 0, errNativeMinterInput
+	}
+	total := new(uint256.Int).SetBytes(evm.StateDB.GetState(params.NativeMinterAddress, slot).Bytes())
+	if _, overflow := total.AddOverflow(total, amount); overflow {
+		return nil, 0, errNativeMinterOverflow
+	}
+	if topic == NativeMintTopic {
+		evm.StateDB.AddBalance(account, amount, tracing.BalanceIncreaseNativeMint)
+	} else {
+		evm.StateDB.SubBalance(account, amount, tracing.BalanceDecreaseNativeBurn)
+	}
+	// Track the supply change in the minter account, which is kept non-empty
+	// so that the counters survive the empty account removal of EIP-158.
+	if evm.StateDB.GetNonce(params.NativeMinterAddress) == 0 {
+		evm.StateDB.SetNonce(params.NativeMinterAddress, 1, tracing.NonceChangeUnspecified)
+	}
+	evm.StateDB.SetState(params.NativeMinterAddress, slot, total.Bytes32())
+
+	evm.StateDB.AddLog(&types.Log{
+		Address:     params.NativeMinterAddress,
+		Topics:      []common.Hash{topic, common.BytesToHash(caller.Bytes()), common.BytesToHash(account.Bytes())},
+		Data:        amount.PaddedBytes(32),
+		BlockNumber: evm.Context.BlockNumber.Uint64(),
+	})
+	return nil, gas, nil
+}
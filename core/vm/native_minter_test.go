@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func nativeMinterInput(selector []byte, account common.Address, amount uint64) []byte {
+	input := append(common.CopyBytes(selector), common.LeftPadBytes(account.Bytes(), 32)...)
+	return append(input, common.LeftPadBytes(new(big.Int).SetUint64(amount).Bytes(), 32)...)
+}
+
+func TestNativeMinter(t *testing.T) {
+	var (
+		minter  = common.HexToAddress("0x1000")
+		account = common.HexToAddress("0x2000")
+		config  = *params.AllEthashProtocolChanges
+	)
+	config.NativeMinter = &params.NativeMinterConfig{Block: big.NewInt(0), Minters: []common.Address{minter}}
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	vmctx := BlockContext{
+		BlockNumber: big.NewInt(1),
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+	}
+	evm := NewEVM(vmctx, statedb, &config, Config{})
+
+	// Mint and burn from the allowed minter
+	_, gas, err := evm.Call(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeMintSelector, account, 100), 50000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	if used := 50000 - gas; used != params.NativeMinterGas {
+		t.Errorf("gas used mismatch: have %d, want %d", used, params.NativeMinterGas)
+	}
+	if _, _, err := evm.Call(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeBurnSelector, account, 30), 50000, new(uint256.Int)); err != nil {
+		t.Fatalf("burn failed: %v", err)
+	}
+	if balance := statedb.GetBalance(account); balance.Uint64() != 70 {
+		t.Errorf("balance mismatch: have %v, want 70", balance)
+	}
+	if minted, burnt := NativeSupplyChange(statedb); minted.Uint64() != 100 || burnt.Uint64() != 30 {
+		t.Errorf("supply change mismatch: have +%v -%v, want +100 -30", minted, burnt)
+	}
+	logs := statedb.Logs()
+	if len(logs) != 2 || logs[0].Topics[0] != NativeMintTopic || logs[1].Topics[0] != NativeBurnTopic {
+		t.Fatalf("audit logs mismatch: have %v", logs)
+	}
+	if logs[0].Topics[1] != common.BytesToHash(minter.Bytes()) || logs[0].Topics[2] != common.BytesToHash(account.Bytes()) {
+		t.Errorf("mint log topics mismatch: have %v", logs[0].Topics)
+	}
+
+	// Reject burning more than the balance, unknown minters and other call types
+	if _, _, err := evm.Call(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeBurnSelector, account, 71), 50000, new(uint256.Int)); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("excessive burn not rejected: %v", err)
+	}
+	if _, _, err := evm.Call(AccountRef(account), params.NativeMinterAddress, nativeMinterInput(nativeMintSelector, account, 1), 50000, new(uint256.Int)); !errors.Is(err, errNativeMinterCaller) {
+		t.Errorf("unknown minter not rejected: %v", err)
+	}
+	if _, _, err := evm.StaticCall(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeMintSelector, account, 1), 50000); !errors.Is(err, errNativeMinterCallType) {
+		t.Errorf("static call not rejected: %v", err)
+	}
+	// Plain calls from within a static frame must not modify the state either
+	evm.interpreter.readOnly = true
+	if _, _, err := evm.Call(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeMintSelector, account, 1), 50000, new(uint256.Int)); !errors.Is(err, ErrWriteProtection) {
+		t.Errorf("call within static frame not rejected: %v", err)
+	}
+	evm.interpreter.readOnly = false
+
+	// Overflowing the supply counters is rejected
+	minted := statedb.GetState(params.NativeMinterAddress, nativeMintedSlot)
+	statedb.SetState(params.NativeMinterAddress, nativeMintedSlot, new(uint256.Int).SetAllOne().Bytes32())
+	if _, _, err := evm.Call(AccountRef(minter), params.NativeMinterAddress, nativeMinterInput(nativeMintSelector, account, 1), 50000, new(uint256.Int)); !errors.Is(err, errNativeMinterOverflow) {
+		t.Errorf("supply counter overflow not rejected: %v", err)
+	}
+	statedb.SetState(params.NativeMinterAddress, nativeMintedSlot, minted)
+
+	if balance := statedb.GetBalance(account); balance.Uint64() != 70 {
+		t.Errorf("balance changed by rejected calls: have %v, want 70", balance)
+	}
+}
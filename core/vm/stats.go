@@ -0,0 +1,69 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+// ExecutionStats aggregates the resource usage of all the call frames executed
+// by an EVM, allowing operators to spot memory or call stack pressure patterns.
+type ExecutionStats struct {
+	MaxCallDepth  int    // Deepest call stack reached
+	MaxMemory     uint64 // Largest memory expansion of a single call frame
+	MaxReturnData uint64 // Largest return data of a single call frame
+	ReturnData    uint64 // Cumulative return data size of all call frames
+}
+
+// Stats returns the execution statistics aggregated since the EVM was created
+// or its statistics were last reset.
+func (evm *EVM) Stats() ExecutionStats {
+	return evm.stats
+}
+
+// ResetStats clears the execution statistics aggregated so far, allowing those
+// of a single execution to be retrieved.
+func (evm *EVM) ResetStats() {
+	evm.stats = ExecutionStats{}
+}
+
+// Merge folds the statistics of another EVM into s, e.g. of the EVMs executing
+// the transactions of a block in parallel.
+func (s *ExecutionStats) Merge(other ExecutionStats) {
+	s.trackDepth(other.MaxCallDepth)
+	s.trackMemory(other.MaxMemory)
+	s.MaxReturnData = max(s.MaxReturnData, other.MaxReturnData)
+	s.ReturnData += other.ReturnData
+}
+
+// trackDepth updates the deepest call stack reached.
+func (s *ExecutionStats) trackDepth(depth int) {
+	if depth > s.MaxCallDepth {
+		s.MaxCallDepth = depth
+	}
+}
+
+// trackMemory updates the largest memory expansion of a call frame.
+func (s *ExecutionStats) trackMemory(size uint64) {
+	if size > s.MaxMemory {
+		s.MaxMemory = size
+	}
+}
+
+// trackReturn accounts the return data of a finished call frame.
+func (s *ExecutionStats) trackReturn(size int) {
+	s.ReturnData += uint64(size)
+	if uint64(size) > s.MaxReturnData {
+		s.MaxReturnData = uint64(size)
+	}
+}
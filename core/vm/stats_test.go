@@ -0,0 +1,47 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "testing"
+
+func TestExecutionStatsTracking(t *testing.T) {
+	var s ExecutionStats
+
+	s.trackDepth(1)
+	s.trackDepth(3)
+	s.trackDepth(2)
+	s.trackMemory(64)
+	s.trackMemory(32)
+	s.trackReturn(10)
+	s.trackReturn(0)
+	s.trackReturn(4)
+
+	want := ExecutionStats{MaxCallDepth: 3, MaxMemory: 64, MaxReturnData: 10, ReturnData: 14}
+	if s != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", s, want)
+	}
+}
+
+func TestExecutionStatsMerge(t *testing.T) {
+	s := ExecutionStats{MaxCallDepth: 3, MaxMemory: 32, MaxReturnData: 10, ReturnData: 14}
+	s.Merge(ExecutionStats{MaxCallDepth: 1, MaxMemory: 64, MaxReturnData: 4, ReturnData: 6})
+
+	want := ExecutionStats{MaxCallDepth: 3, MaxMemory: 64, MaxReturnData: 10, ReturnData: 20}
+	if s != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", s, want)
+	}
+}
@@ -131,6 +131,17 @@ func (api *DebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error)
 	return results, nil
 }
 
+// GetFutureBlocks returns the headers of the blocks queued until the local clock
+// catches up with them, ordered by number.
+func (api *DebugAPI) GetFutureBlocks() []map[string]interface{} {
+	blocks := api.eth.blockchain.FutureBlocks()
+	results := make([]map[string]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		results = append(results, ethapi.RPCMarshalHeader(block.Header()))
+	}
+	return results
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -359,6 +370,130 @@ func (api *DebugAPI) getModifiedAccounts(startBlock, endBlock *types.Block) ([]c
 	return dirty, nil
 }
 
+// HuskResult is a contract left without balance by a SELFDESTRUCT which didn't
+// destroy it, as returned by GetHusks.
+type HuskResult struct {
+	Address     common.Address `json:"address"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	CodeSize    hexutil.Uint64 `json:"codeSize"`
+}
+
+// GetHusks returns the contracts which survived a SELFDESTRUCT in the canonical
+// blocks between the given ones, and are still left without balance.
+func (api *DebugAPI) GetHusks(from, to rpc.BlockNumber) ([]*HuskResult, error) {
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			return api.eth.blockchain.CurrentBlock().Number.Uint64()
+		}
+		return uint64(number)
+	}
+	husks, err := api.eth.blockchain.Husks(resolve(from), resolve(to))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*HuskResult, 0, len(husks))
+	for _, husk := range husks {
+		results = append(results, &HuskResult{
+			Address:     husk.Address,
+			BlockNumber: hexutil.Uint64(husk.Number),
+			Nonce:       hexutil.Uint64(husk.Nonce),
+			CodeSize:    hexutil.Uint64(husk.CodeSize),
+		})
+	}
+	return results, nil
+}
+
+// BlockAccessListResult is the access list of a block along with its hash and
+// its commitment to the block, as returned by GetBlockAccessList.
+type BlockAccessListResult struct {
+	Hash       common.Hash           `json:"hash"`
+	Commitment common.Hash           `json:"commitment"`
+	Accounts   types.BlockAccessList `json:"accounts"`
+}
+
+// GetBlockAccessList returns the accounts and storage slots accessed by the
+// execution of the given block, if the node produced its access list.
+func (api *DebugAPI) GetBlockAccessList(blockHash common.Hash) (*BlockAccessListResult, error) {
+	list := api.eth.blockchain.GetBlockAccessList(blockHash)
+	if list == nil {
+		return nil, fmt.Errorf("access list of block %x not available", blockHash)
+	}
+	return &BlockAccessListResult{Hash: list.Hash(), Commitment: list.Commitment(blockHash), Accounts: list}, nil
+}
+
+// VerifyBlockAccessList checks an access list of the given block obtained
+// elsewhere against the one produced by the node executing it.
+func (api *DebugAPI) VerifyBlockAccessList(blockHash common.Hash, list types.BlockAccessList) error {
+	return api.eth.blockchain.VerifyBlockAccessList(blockHash, list)
+}
+
+// GetEquivocations returns the evidence of the validators found sealing two
+// different headers at the same height, for the heights between the given ones.
+func (api *DebugAPI) GetEquivocations(from, to rpc.BlockNumber) ([]*types.Equivocation, error) {
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			return api.eth.blockchain.CurrentBlock().Number.Uint64()
+		}
+		return uint64(number)
+	}
+	return api.eth.blockchain.GetEquivocations(resolve(from), resolve(to))
+}
+
+// ImportProgressResult is the rolling block import throughput, as returned by
+// ImportProgress.
+type ImportProgressResult struct {
+	Head            hexutil.Uint64 `json:"head"`
+	Target          hexutil.Uint64 `json:"target"`
+	BlocksPerSecond float64        `json:"blocksPerSecond"`
+	GasPerSecond    float64        `json:"gasPerSecond"`
+	MgasPerSecond   float64        `json:"mgasPerSecond"`
+	ETA             string         `json:"eta"`
+}
+
+// ImportProgress returns the throughput of the recent block imports and the
+// estimated time to import the target block, the best known head if omitted.
+func (api *DebugAPI) ImportProgress(target *hexutil.Uint64) *ImportProgressResult {
+	var number uint64
+	if target != nil {
+		number = uint64(*target)
+	}
+	progress := api.eth.blockchain.ImportProgress(number)
+	return &ImportProgressResult{
+		Head:            hexutil.Uint64(progress.Head),
+		Target:          hexutil.Uint64(progress.Target),
+		BlocksPerSecond: progress.BlocksPerSecond,
+		GasPerSecond:    progress.GasPerSecond,
+		MgasPerSecond:   progress.MgasPerSecond,
+		ETA:             common.PrettyDuration(progress.ETA).String(),
+	}
+}
+
+// ChainLimitsResult is the operational limits of the chain in effect, as
+// returned by ChainLimits.
+type ChainLimitsResult struct {
+	TriesInMemory      hexutil.Uint64 `json:"triesInMemory"`
+	BodyCacheLimit     int            `json:"bodyCacheLimit"`
+	BlockCacheLimit    int            `json:"blockCacheLimit"`
+	ReceiptsCacheLimit int            `json:"receiptsCacheLimit"`
+	FutureBlocksLimit  int            `json:"futureBlocksLimit"`
+	FutureBlocksSkew   string         `json:"futureBlocksSkew"`
+}
+
+// ChainLimits returns the operational limits of the chain in effect, such as
+// the number of tries kept in memory and the sizes of the block caches.
+func (api *DebugAPI) ChainLimits() *ChainLimitsResult {
+	limits := api.eth.blockchain.Limits()
+	return &ChainLimitsResult{
+		TriesInMemory:      hexutil.Uint64(limits.TriesInMemory),
+		BodyCacheLimit:     limits.BodyCacheLimit,
+		BlockCacheLimit:    limits.BlockCacheLimit,
+		ReceiptsCacheLimit: limits.ReceiptsCacheLimit,
+		FutureBlocksLimit:  limits.FutureBlocksLimit,
+		FutureBlocksSkew:   common.PrettyDuration(limits.FutureBlocksSkew).String(),
+	}
+}
+
 // GetAccessibleState returns the first number where the node has accessible
 // state on disk. Note this being the post-state of that block and the pre-state
 // of the next block.
@@ -102,6 +102,7 @@ type Ethereum struct {
 	// core protocol objects
 	config         *ethconfig.Config
 	txPool         *txpool.TxPool
+	pendingState   *core.PendingState
 	localTxTracker *locals.TxTracker
 	blockchain     *core.BlockChain
 
@@ -287,6 +288,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 		}
 	}
+	if err := rawdb.MigrateDatabase(chainDb); err != nil {
+		return nil, err
+	}
 	var (
 		journalFilePath string
 		path            string
@@ -300,6 +304,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
+			ParallelWorkers:         config.ParallelWorkers,
+			StaticCallMemo:          config.StaticCallMemo,
 		}
 		cacheConfig = &core.CacheConfig{
 			EnableSharedStorage: config.EnableSharedStorage,
@@ -311,7 +317,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			NoTries:             config.TriesVerifyMode != core.LocalVerify,
 			SnapshotLimit:       config.SnapshotCache,
 			TriesInMemory:       config.TriesInMemory,
+			BodyCacheLimit:      config.BodyCacheLimit,
+			BlockCacheLimit:     config.BlockCacheLimit,
+			ReceiptsCacheLimit:  config.ReceiptsCacheLimit,
 			Preimages:           config.Preimages,
+			HeaderCacheWarmup:   config.HeaderCacheWarmup,
 			StateHistory:        config.StateHistory,
 			StateScheme:         config.StateScheme,
 			PathSyncFlush:       config.PathSyncFlush,
@@ -319,6 +329,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			JournalFile:         config.JournalFileEnabled,
 		}
 	)
+	// The blob sidecars are retained along the reserve the chain freezer prunes
+	// the frozen ones with, a zero reserve keeping all of them
+	if config.BlobExtraReserve > 0 {
+		cacheConfig.SidecarRetention = params.MinBlocksForBlobRequests + config.BlobExtraReserve
+	}
+	if config.CodeCacheJournal {
+		cacheConfig.CodeCacheJournal = stack.ResolvePath(path) + "/codecache"
+	}
 	if config.VMTrace != "" {
 		traceConfig := json.RawMessage("{}")
 		if config.VMTraceJsonConfig != "" {
@@ -335,6 +353,12 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if stack.Config().EnableDoubleSignMonitor {
 		bcOps = append(bcOps, core.EnableDoubleSignChecker)
 	}
+	if config.BlockAccessLists {
+		bcOps = append(bcOps, core.EnableBlockAccessLists())
+	}
+	if config.ImportProgressInterval > 0 {
+		bcOps = append(bcOps, core.WithImportProgressEvents(config.ImportProgressInterval))
+	}
 
 	peers := newPeerSet()
 	// TODO (MariusVanDerWijden) get rid of shouldPreserve in a follow-up PR
@@ -366,6 +390,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	eth.pendingState = core.NewPendingState(eth.blockchain, eth.txPool)
 
 	if !config.TxPool.NoLocals {
 		rejournal := config.TxPool.Rejournal
@@ -385,6 +410,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		TxPool:                    eth.txPool,
 		Network:                   networkID,
 		Sync:                      config.SyncMode,
+		ImportBatchGas:            config.ImportBatchGas,
 		BloomCache:                uint64(cacheLimit),
 		EventMux:                  eth.eventMux,
 		RequiredBlocks:            config.RequiredBlocks,
@@ -736,6 +762,7 @@ func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *txpool.TxPool             { return s.txPool }
+func (s *Ethereum) PendingState() *core.PendingState   { return s.pendingState }
 func (s *Ethereum) VotePool() *vote.VotePool           { return s.votePool }
 func (s *Ethereum) EventMux() *event.TypeMux           { return s.eventMux }
 func (s *Ethereum) Engine() consensus.Engine           { return s.engine }
@@ -838,13 +865,18 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Close()
 	s.miner.Close()
-	s.blockchain.Stop()
+	err := stopBlockchain(s.blockchain, s.config.ShutdownTimeout)
 	s.engine.Close()
 
-	// Clean shutdown marker as the last thing before closing db
-	s.shutdownTracker.Stop()
-
-	s.chainDb.Close()
+	// Clean shutdown marker as the last thing before closing db. If the chain
+	// is still persisting its data, the database is left open for it instead,
+	// and the shutdown is recorded as unclean.
+	if err == nil {
+		s.shutdownTracker.Stop()
+		s.chainDb.Close()
+	} else {
+		log.Warn("Leaving the database open for the unfinished blockchain shutdown")
+	}
 	s.eventMux.Stop()
 
 	// stop report loop
@@ -852,6 +884,21 @@ func (s *Ethereum) Stop() error {
 	return nil
 }
 
+// stopBlockchain stops the blockchain, returning once the timeout elapses even
+// if the shutdown is still persisting data in the background. In that case the
+// error of the exceeded deadline is returned, and the database must not be
+// closed.
+func stopBlockchain(chain *core.BlockChain, timeout time.Duration) error {
+	if timeout == 0 {
+		chain.Stop()
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return chain.StopWithContext(ctx)
+}
+
 func (s *Ethereum) reportRecentBlocksLoop() {
 	reportCnt := uint64(2)
 	reportTicker := time.NewTicker(time.Second)
@@ -0,0 +1,68 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a blockchain shutdown exceeding its timeout is reported and not
+// waited for, the flushing continuing in the background.
+func TestStopBlockchainTimeout(t *testing.T) {
+	var (
+		flushing = make(chan struct{})
+		tracer   = &tracing.Hooks{OnClose: func() {
+			close(flushing)
+			time.Sleep(200 * time.Millisecond)
+		}}
+		genesis = &core.Genesis{Config: params.TestChainConfig}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{Tracer: tracer}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	start := time.Now()
+	err = stopBlockchain(chain, 50*time.Millisecond)
+	if !errors.Is(err, core.ErrShutdownDeadline) {
+		t.Fatalf("shutdown error mismatch: have %v, want %v", err, core.ErrShutdownDeadline)
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Fatalf("waited for the unfinished shutdown: %v", elapsed)
+	}
+	select {
+	case <-flushing:
+	default:
+		t.Fatal("shutdown did not reach the flushing phase")
+	}
+	if phase := chain.ShutdownPhase(); phase == "" {
+		t.Fatal("shutdown reported as finished")
+	}
+	// The shutdown keeps running in the background until done
+	chain.Stop()
+	if phase := chain.ShutdownPhase(); phase != "" {
+		t.Fatalf("shutdown not finished, phase %q", phase)
+	}
+}
@@ -118,6 +118,7 @@ type Downloader struct {
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	blockchain BlockChain
+	importGas  uint64 // Gas budget of a single chain insertion, zero to import the results at once
 
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
@@ -240,6 +241,13 @@ func New(stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, dropPeer
 	return dl
 }
 
+// SetImportBatchGas sets the gas budget of the batches the downloaded blocks
+// are imported in, splitting the results into batches of similar work instead
+// of similar length. Zero imports the results retrieved at once.
+func (d *Downloader) SetImportBatchGas(gas uint64) {
+	d.importGas = gas
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -1349,7 +1357,44 @@ func (d *Downloader) processFullSyncContent(ttd *big.Int, beaconMode bool) error
 	}
 }
 
+// importBlockResults imports the fetch results into the chain, split into
+// batches by the gas budget of the downloader.
 func (d *Downloader) importBlockResults(results []*fetchResult) error {
+	for _, batch := range splitByGas(results, d.importGas) {
+		if err := d.insertBlockResults(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitByGas splits the fetch results into consecutive batches, each using at
+// most the given amount of gas. Blocks exceeding the budget on their own are
+// placed in a batch alone. A zero budget disables the splitting.
+func splitByGas(results []*fetchResult, budget uint64) [][]*fetchResult {
+	if len(results) == 0 {
+		return nil
+	}
+	if budget == 0 {
+		return [][]*fetchResult{results}
+	}
+	var (
+		batches [][]*fetchResult
+		start   int
+		gas     uint64
+	)
+	for i, result := range results {
+		if used := result.Header.GasUsed; i > start && gas+used > budget {
+			batches = append(batches, results[start:i])
+			start, gas = i, used
+		} else {
+			gas += used
+		}
+	}
+	return append(batches, results[start:])
+}
+
+func (d *Downloader) insertBlockResults(results []*fetchResult) error {
 	// Check for any early termination requests
 	if len(results) == 0 {
 		return nil
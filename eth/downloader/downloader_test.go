@@ -1260,3 +1260,36 @@ func TestRemoteHeaderRequestSpan(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the fetch results are split into import batches by the gas used.
+func TestSplitByGas(t *testing.T) {
+	results := func(gas ...uint64) []*fetchResult {
+		res := make([]*fetchResult, len(gas))
+		for i, used := range gas {
+			res[i] = &fetchResult{Header: &types.Header{Number: big.NewInt(int64(i)), GasUsed: used}}
+		}
+		return res
+	}
+	testCases := []struct {
+		gas    []uint64
+		budget uint64
+		sizes  []int
+	}{
+		{nil, 100, nil},
+		{[]uint64{10, 20, 30}, 0, []int{3}},
+		{[]uint64{10, 20, 30}, 100, []int{3}},
+		{[]uint64{50, 50, 50, 50}, 100, []int{2, 2}},
+		{[]uint64{0, 0, 100, 0, 0}, 50, []int{2, 1, 2}},
+		{[]uint64{200, 200, 10, 10}, 100, []int{1, 1, 2}},
+		{[]uint64{0, 0, 0, 0}, 1, []int{4}},
+	}
+	for i, tt := range testCases {
+		var sizes []int
+		for _, batch := range splitByGas(results(tt.gas...), tt.budget) {
+			sizes = append(sizes, len(batch))
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tt.sizes) {
+			t.Errorf("test %d: batch sizes mismatch: have %v, want %v", i, sizes, tt.sizes)
+		}
+	}
+}
@@ -51,6 +51,7 @@ var FullNodeGPO = gasprice.Config{
 // Defaults contains default settings for use on the BSC main net.
 var Defaults = Config{
 	SyncMode:            SnapSync,
+	ImportBatchGas:      10_000_000_000,
 	NetworkId:           0, // enable auto configuration of networkID == chainID
 	TxLookupLimit:       2350000,
 	TransactionHistory:  2350000,
@@ -61,6 +62,7 @@ var Defaults = Config{
 	TrieCleanCache:      154,
 	TrieDirtyCache:      256,
 	TrieTimeout:         10 * time.Minute,
+	ShutdownTimeout:     time.Minute,
 	TriesInMemory:       128,
 	TriesVerifyMode:     core.LocalVerify,
 	SnapshotCache:       102,
@@ -88,6 +90,15 @@ type Config struct {
 	NetworkId uint64
 	SyncMode  SyncMode
 
+	// ImportBatchGas is the gas budget of the batches the downloaded blocks are
+	// imported in, keeping the batches uniform in work regardless of how full
+	// the blocks are. Zero imports the downloaded blocks in batches by count.
+	ImportBatchGas uint64
+
+	// ImportProgressInterval is the number of imported blocks between the import
+	// progress events, zero disables them.
+	ImportProgressInterval uint64
+
 	// DisablePeerTxBroadcast is an optional config and disabled by default, and usually you do not need it.
 	// When this flag is enabled, you are requesting remote peers to stop broadcasting new transactions to you, and
 	// it does not mean that your node will stop broadcasting transactions to remote peers.
@@ -125,6 +136,7 @@ type Config struct {
 	JournalFileEnabled bool   // Whether the TrieJournal is stored using journal file
 
 	DisableTxIndexer bool `toml:",omitempty"` // Whether to enable the transaction indexer
+	BlockAccessLists bool `toml:",omitempty"` // Whether to produce and store the access lists of the imported blocks
 
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
@@ -152,8 +164,14 @@ type Config struct {
 	TrieTimeout         time.Duration
 	SnapshotCache       int
 	TriesInMemory       uint64
+	BodyCacheLimit      int           `toml:",omitempty"` // Number of recent block bodies cached, 0 for the default
+	BlockCacheLimit     int           `toml:",omitempty"` // Number of recent blocks cached, 0 for the default
+	ReceiptsCacheLimit  int           `toml:",omitempty"` // Number of recent block receipts cached, 0 for the default
+	ShutdownTimeout     time.Duration `toml:",omitempty"` // Time after which an unfinished blockchain shutdown is reported, 0 for no deadline
 	TriesVerifyMode     core.VerifyMode
 	Preimages           bool
+	HeaderCacheWarmup   uint64 // Number of recent headers preloaded into the caches on startup
+	CodeCacheJournal    bool   `toml:",omitempty"` // Whether the hot contract codes and analyses are persisted across restarts
 
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
@@ -171,6 +189,12 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Number of workers speculatively executing block transactions in parallel
+	ParallelWorkers int
+
+	// Number of pure static call results memoized per block
+	StaticCallMemo int
+
 	// Enables VM tracing
 	VMTrace           string
 	VMTraceJsonConfig string
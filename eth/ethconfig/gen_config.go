@@ -20,6 +20,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                SyncMode
+		ImportBatchGas          uint64
+		ImportProgressInterval  uint64
 		DisablePeerTxBroadcast  bool
 		EVNNodeIDsToAdd         []enode.ID
 		EVNNodeIDsToRemove      []enode.ID
@@ -39,6 +41,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		PathSyncFlush           bool   `toml:",omitempty"`
 		JournalFileEnabled      bool
 		DisableTxIndexer        bool                   `toml:",omitempty"`
+		BlockAccessLists        bool                   `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		SkipBcVersionCheck      bool                   `toml:"-"`
 		DatabaseHandles         int                    `toml:"-"`
@@ -50,14 +53,22 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieTimeout             time.Duration
 		SnapshotCache           int
 		TriesInMemory           uint64
+		BodyCacheLimit          int           `toml:",omitempty"`
+		BlockCacheLimit         int           `toml:",omitempty"`
+		ReceiptsCacheLimit      int           `toml:",omitempty"`
+		ShutdownTimeout         time.Duration `toml:",omitempty"`
 		TriesVerifyMode         core.VerifyMode
 		Preimages               bool
+		HeaderCacheWarmup       uint64
+		CodeCacheJournal        bool `toml:",omitempty"`
 		FilterLogCacheSize      int
 		Miner                   minerconfig.Config
 		TxPool                  legacypool.Config
 		BlobPool                blobpool.Config
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
+		ParallelWorkers         int
+		StaticCallMemo          int
 		VMTrace                 string
 		VMTraceJsonConfig       string
 		RPCGasCap               uint64
@@ -74,6 +85,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.ImportBatchGas = c.ImportBatchGas
+	enc.ImportProgressInterval = c.ImportProgressInterval
 	enc.DisablePeerTxBroadcast = c.DisablePeerTxBroadcast
 	enc.EVNNodeIDsToAdd = c.EVNNodeIDsToAdd
 	enc.EVNNodeIDsToRemove = c.EVNNodeIDsToRemove
@@ -93,6 +106,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.PathSyncFlush = c.PathSyncFlush
 	enc.JournalFileEnabled = c.JournalFileEnabled
 	enc.DisableTxIndexer = c.DisableTxIndexer
+	enc.BlockAccessLists = c.BlockAccessLists
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
@@ -104,14 +118,22 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
 	enc.TriesInMemory = c.TriesInMemory
+	enc.BodyCacheLimit = c.BodyCacheLimit
+	enc.BlockCacheLimit = c.BlockCacheLimit
+	enc.ReceiptsCacheLimit = c.ReceiptsCacheLimit
+	enc.ShutdownTimeout = c.ShutdownTimeout
 	enc.TriesVerifyMode = c.TriesVerifyMode
 	enc.Preimages = c.Preimages
+	enc.HeaderCacheWarmup = c.HeaderCacheWarmup
+	enc.CodeCacheJournal = c.CodeCacheJournal
 	enc.FilterLogCacheSize = c.FilterLogCacheSize
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.ParallelWorkers = c.ParallelWorkers
+	enc.StaticCallMemo = c.StaticCallMemo
 	enc.VMTrace = c.VMTrace
 	enc.VMTraceJsonConfig = c.VMTraceJsonConfig
 	enc.RPCGasCap = c.RPCGasCap
@@ -132,6 +154,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *SyncMode
+		ImportBatchGas          *uint64
+		ImportProgressInterval  *uint64
 		DisablePeerTxBroadcast  *bool
 		EVNNodeIDsToAdd         []enode.ID
 		EVNNodeIDsToRemove      []enode.ID
@@ -151,6 +175,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		PathSyncFlush           *bool   `toml:",omitempty"`
 		JournalFileEnabled      *bool
 		DisableTxIndexer        *bool                  `toml:",omitempty"`
+		BlockAccessLists        *bool                  `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
 		SkipBcVersionCheck      *bool                  `toml:"-"`
 		DatabaseHandles         *int                   `toml:"-"`
@@ -162,14 +187,22 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieTimeout             *time.Duration
 		SnapshotCache           *int
 		TriesInMemory           *uint64
+		BodyCacheLimit          *int           `toml:",omitempty"`
+		BlockCacheLimit         *int           `toml:",omitempty"`
+		ReceiptsCacheLimit      *int           `toml:",omitempty"`
+		ShutdownTimeout         *time.Duration `toml:",omitempty"`
 		TriesVerifyMode         *core.VerifyMode
 		Preimages               *bool
+		HeaderCacheWarmup       *uint64
+		CodeCacheJournal        *bool `toml:",omitempty"`
 		FilterLogCacheSize      *int
 		Miner                   *minerconfig.Config
 		TxPool                  *legacypool.Config
 		BlobPool                *blobpool.Config
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
+		ParallelWorkers         *int
+		StaticCallMemo          *int
 		VMTrace                 *string
 		VMTraceJsonConfig       *string
 		RPCGasCap               *uint64
@@ -195,6 +228,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.ImportBatchGas != nil {
+		c.ImportBatchGas = *dec.ImportBatchGas
+	}
+	if dec.ImportProgressInterval != nil {
+		c.ImportProgressInterval = *dec.ImportProgressInterval
+	}
 	if dec.DisablePeerTxBroadcast != nil {
 		c.DisablePeerTxBroadcast = *dec.DisablePeerTxBroadcast
 	}
@@ -252,6 +291,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DisableTxIndexer != nil {
 		c.DisableTxIndexer = *dec.DisableTxIndexer
 	}
+	if dec.BlockAccessLists != nil {
+		c.BlockAccessLists = *dec.BlockAccessLists
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
@@ -285,12 +327,30 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TriesInMemory != nil {
 		c.TriesInMemory = *dec.TriesInMemory
 	}
+	if dec.BodyCacheLimit != nil {
+		c.BodyCacheLimit = *dec.BodyCacheLimit
+	}
+	if dec.BlockCacheLimit != nil {
+		c.BlockCacheLimit = *dec.BlockCacheLimit
+	}
+	if dec.ReceiptsCacheLimit != nil {
+		c.ReceiptsCacheLimit = *dec.ReceiptsCacheLimit
+	}
+	if dec.ShutdownTimeout != nil {
+		c.ShutdownTimeout = *dec.ShutdownTimeout
+	}
 	if dec.TriesVerifyMode != nil {
 		c.TriesVerifyMode = *dec.TriesVerifyMode
 	}
 	if dec.Preimages != nil {
 		c.Preimages = *dec.Preimages
 	}
+	if dec.HeaderCacheWarmup != nil {
+		c.HeaderCacheWarmup = *dec.HeaderCacheWarmup
+	}
+	if dec.CodeCacheJournal != nil {
+		c.CodeCacheJournal = *dec.CodeCacheJournal
+	}
 	if dec.FilterLogCacheSize != nil {
 		c.FilterLogCacheSize = *dec.FilterLogCacheSize
 	}
@@ -309,6 +369,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.ParallelWorkers != nil {
+		c.ParallelWorkers = *dec.ParallelWorkers
+	}
+	if dec.StaticCallMemo != nil {
+		c.StaticCallMemo = *dec.StaticCallMemo
+	}
 	if dec.VMTrace != nil {
 		c.VMTrace = *dec.VMTrace
 	}
@@ -104,7 +104,7 @@ func newTester() *fetcherTester {
 		blocks:  map[common.Hash]*types.Block{genesis.Hash(): genesis},
 		drops:   make(map[string]bool),
 	}
-	tester.fetcher = NewBlockFetcher(tester.getBlock, tester.verifyHeader, tester.broadcastBlock,
+	tester.fetcher = NewBlockFetcher(nil, tester.getBlock, tester.verifyHeader, tester.broadcastBlock,
 		tester.chainHeight, tester.chainFinalizedHeight, tester.insertChain, tester.dropPeer,
 		func(peer string, startHeight uint64, startHash common.Hash, count uint64) ([]*types.Block, error) {
 			return nil, errors.New("not implemented")
@@ -1038,6 +1038,7 @@ func TestBlockFetcherMultiplePeers(t *testing.T) {
 
 	// Create fetcher
 	fetcher := NewBlockFetcher(
+		nil,
 		// getBlock
 		func(hash common.Hash) *types.Block {
 			return blockStore[hash]
@@ -1266,6 +1267,7 @@ func TestQuickBlockFetching(t *testing.T) {
 
 	// Create fetcher with quick block fetching support
 	fetcher := NewBlockFetcher(
+		nil,
 		blockRetriever.getBlock,
 		func(header *types.Header) error { return nil },
 		func(block *types.Block, propagate bool) {},
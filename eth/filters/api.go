@@ -374,6 +374,40 @@ func (api *FilterAPI) NewFinalizedHeaders(ctx context.Context) (*rpc.Subscriptio
 	return rpcSub, nil
 }
 
+// NewBlobSidecars send a notification with the blob sidecars of each block
+// becoming canonical, the blobs being truncated to their leading 32 bytes
+// unless fullBlob is set.
+func (api *FilterAPI) NewBlobSidecars(ctx context.Context, fullBlob *bool) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	showBlob := fullBlob != nil && *fullBlob
+
+	rpcSub := notifier.CreateSubscription()
+
+	gopool.Submit(func() {
+		sidecars := make(chan types.BlobSidecars)
+		sidecarsSub := api.events.SubscribeBlobSidecars(sidecars)
+		defer sidecarsSub.Unsubscribe()
+
+		for {
+			select {
+			case s := <-sidecars:
+				result := make([]map[string]interface{}, len(s))
+				for i, sidecar := range s {
+					result[i] = ethapi.RPCMarshalBlobSidecar(sidecar, showBlob)
+				}
+				notifier.Notify(rpcSub.ID, result)
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	})
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
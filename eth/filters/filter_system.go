@@ -70,6 +70,7 @@ type Backend interface {
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribeNewVoteEvent(chan<- core.NewVoteEvent) event.Subscription
+	SubscribeBlobSidecarsEvent(ch chan<- core.BlobSidecarsEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -160,6 +161,8 @@ const (
 	VotesSubscription
 	// FinalizedHeadersSubscription queries hashes for finalized headers that are reached
 	FinalizedHeadersSubscription
+	// BlobSidecarsSubscription queries the blob sidecars of blocks becoming canonical
+	BlobSidecarsSubscription
 	// LastIndexSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -176,6 +179,8 @@ const (
 	chainEvChanSize = 10
 	// finalizedHeaderEvChanSize is the size of channel listening to FinalizedHeaderEvent.
 	finalizedHeaderEvChanSize = 10
+	// sidecarsChanSize is the size of channel listening to BlobSidecarsEvent.
+	sidecarsChanSize = 10
 	// voteChanSize is the size of channel listening to NewVoteEvent.
 	// The number is referenced from the size of vote pool.
 	voteChanSize = 256
@@ -190,6 +195,7 @@ type subscription struct {
 	txs       chan []*types.Transaction
 	headers   chan *types.Header
 	votes     chan *types.VoteEnvelope
+	sidecars  chan types.BlobSidecars
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -207,6 +213,7 @@ type EventSystem struct {
 	chainSub           event.Subscription // Subscription for new chain event
 	finalizedHeaderSub event.Subscription // Subscription for new finalized header
 	voteSub            event.Subscription // Subscription for new vote event
+	sidecarsSub        event.Subscription // Subscription for new blob sidecars event
 
 	// Channels
 	install           chan *subscription             // install filter for event notification
@@ -217,6 +224,7 @@ type EventSystem struct {
 	chainCh           chan core.ChainEvent           // Channel to receive new chain event
 	finalizedHeaderCh chan core.FinalizedHeaderEvent // Channel to receive new finalized header event
 	voteCh            chan core.NewVoteEvent         // Channel to receive new vote event
+	sidecarsCh        chan core.BlobSidecarsEvent    // Channel to receive new blob sidecars event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -237,6 +245,7 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 		chainCh:           make(chan core.ChainEvent, chainEvChanSize),
 		finalizedHeaderCh: make(chan core.FinalizedHeaderEvent, finalizedHeaderEvChanSize),
 		voteCh:            make(chan core.NewVoteEvent, voteChanSize),
+		sidecarsCh:        make(chan core.BlobSidecarsEvent, sidecarsChanSize),
 	}
 
 	// Subscribe events
@@ -246,9 +255,10 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
 	m.finalizedHeaderSub = m.backend.SubscribeFinalizedHeaderEvent(m.finalizedHeaderCh)
 	m.voteSub = m.backend.SubscribeNewVoteEvent(m.voteCh)
+	m.sidecarsSub = m.backend.SubscribeBlobSidecarsEvent(m.sidecarsCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.sidecarsSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 	if m.voteSub == nil || m.finalizedHeaderSub == nil {
@@ -288,6 +298,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.txs:
 			case <-sub.f.headers:
 			case <-sub.f.votes:
+			case <-sub.f.sidecars:
 			}
 		}
 
@@ -356,6 +367,7 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
 		votes:     make(chan *types.VoteEnvelope),
+		sidecars:  make(chan types.BlobSidecars),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -373,6 +385,7 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		txs:       make(chan []*types.Transaction),
 		headers:   headers,
 		votes:     make(chan *types.VoteEnvelope),
+		sidecars:  make(chan types.BlobSidecars),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -390,6 +403,7 @@ func (es *EventSystem) SubscribeNewFinalizedHeaders(headers chan *types.Header)
 		txs:       make(chan []*types.Transaction),
 		headers:   headers,
 		votes:     make(chan *types.VoteEnvelope),
+		sidecars:  make(chan types.BlobSidecars),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -407,6 +421,7 @@ func (es *EventSystem) SubscribePendingTxs(txs chan []*types.Transaction) *Subsc
 		txs:       txs,
 		headers:   make(chan *types.Header),
 		votes:     make(chan *types.VoteEnvelope),
+		sidecars:  make(chan types.BlobSidecars),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -424,6 +439,25 @@ func (es *EventSystem) SubscribeNewVotes(votes chan *types.VoteEnvelope) *Subscr
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
 		votes:     votes,
+		sidecars:  make(chan types.BlobSidecars),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeBlobSidecars creates a subscription that writes the blob sidecars of
+// the blocks becoming canonical.
+func (es *EventSystem) SubscribeBlobSidecars(sidecars chan types.BlobSidecars) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       BlobSidecarsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		txs:       make(chan []*types.Transaction),
+		headers:   make(chan *types.Header),
+		votes:     make(chan *types.VoteEnvelope),
+		sidecars:  sidecars,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -468,6 +502,12 @@ func (es *EventSystem) handleFinalizedHeaderEvent(filters filterIndex, ev core.F
 	}
 }
 
+func (es *EventSystem) handleBlobSidecarsEvent(filters filterIndex, ev core.BlobSidecarsEvent) {
+	for _, f := range filters[BlobSidecarsSubscription] {
+		f.sidecars <- ev.Sidecars
+	}
+}
+
 // eventLoop (un)installs filters and processes mux events.
 func (es *EventSystem) eventLoop() {
 	// Ensure all subscriptions get cleaned up
@@ -477,6 +517,7 @@ func (es *EventSystem) eventLoop() {
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
 		es.finalizedHeaderSub.Unsubscribe()
+		es.sidecarsSub.Unsubscribe()
 		if es.voteSub != nil {
 			es.voteSub.Unsubscribe()
 		}
@@ -505,6 +546,8 @@ func (es *EventSystem) eventLoop() {
 			es.handleFinalizedHeaderEvent(index, ev)
 		case ev := <-es.voteCh:
 			es.handleVoteEvent(index, ev)
+		case ev := <-es.sidecarsCh:
+			es.handleBlobSidecarsEvent(index, ev)
 
 		case f := <-es.install:
 			index[f.typ][f.id] = f
@@ -525,6 +568,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.finalizedHeaderSub.Err():
 			return
+		case <-es.sidecarsSub.Err():
+			return
 		case <-voteSubErr:
 			return
 		}
@@ -48,6 +48,7 @@ type testBackend struct {
 	chainFeed           event.Feed
 	finalizedHeaderFeed event.Feed
 	voteFeed            event.Feed
+	sidecarsFeed        event.Feed
 	pendingBlock        *types.Block
 	pendingReceipts     types.Receipts
 }
@@ -142,6 +143,10 @@ func (b *testBackend) SubscribeNewVoteEvent(ch chan<- core.NewVoteEvent) event.S
 	return b.voteFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeBlobSidecarsEvent(ch chan<- core.BlobSidecarsEvent) event.Subscription {
+	return b.sidecarsFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -706,3 +711,41 @@ func TestVoteSubscription(t *testing.T) {
 
 	<-sub0.Err()
 }
+
+// TestBlobSidecarsSubscription tests that the blob sidecars of the blocks
+// becoming canonical are delivered to the subscribers.
+func TestBlobSidecarsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+		events       []core.BlobSidecarsEvent
+	)
+	for i := 1; i <= 3; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		events = append(events, core.BlobSidecarsEvent{
+			Header:   header,
+			Sidecars: types.BlobSidecars{{BlockNumber: header.Number, BlockHash: header.Hash(), TxHash: common.Hash{byte(i)}}},
+		})
+	}
+	chan0 := make(chan types.BlobSidecars)
+	sub0 := api.events.SubscribeBlobSidecars(chan0)
+
+	go func() { // simulate client
+		for i := 0; i != len(events); i++ {
+			sidecars := <-chan0
+			if len(sidecars) != 1 || sidecars[0].TxHash != events[i].Sidecars[0].TxHash {
+				t.Errorf("sub received invalid sidecars on index %d, want %x, got %v", i, events[i].Sidecars[0].TxHash, sidecars)
+			}
+		}
+		sub0.Unsubscribe()
+	}()
+
+	time.Sleep(1 * time.Second)
+	for _, ev := range events {
+		backend.sidecarsFeed.Send(ev)
+	}
+	<-sub0.Err()
+}
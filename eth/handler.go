@@ -57,6 +57,9 @@ const (
 	// voteChanSize is the size of channel listening to NewVotesEvent.
 	voteChanSize = 256
 
+	// preCommitChanSize is the size of channel listening to PreCommitBlockEvent.
+	preCommitChanSize = 64
+
 	// deltaTdThreshold is the threshold of TD difference for peers to broadcast votes.
 	deltaTdThreshold = 20
 
@@ -64,10 +67,15 @@ const (
 	// All transactions with a higher size will be announced and need to be fetched
 	// by the peer.
 	txMaxBroadcastSize = 4096
+
+	// blockFetchPeers is the number of peers tried in turn to retrieve the bodies
+	// of the blocks only known by their headers.
+	blockFetchPeers = 3
 )
 
 var (
 	syncChallengeTimeout        = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
+	blockFetchTimeout           = 5 * time.Second  // Time allowance for a node to reply to a missing bodies request
 	accountBlacklistPeerCounter = metrics.NewRegisteredCounter("eth/count/blacklist", nil)
 )
 
@@ -120,6 +128,7 @@ type handlerConfig struct {
 	VotePool                  votePool
 	Network                   uint64                 // Network identifier to adfvertise
 	Sync                      ethconfig.SyncMode     // Whether to snap or full sync
+	ImportBatchGas            uint64                 // Gas budget of the downloaded block import batches
 	BloomCache                uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux                  *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks            map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
@@ -167,6 +176,8 @@ type handler struct {
 	reannoTxsCh    chan core.ReannoTxsEvent
 	reannoTxsSub   event.Subscription
 	minedBlockSub  *event.TypeMuxSubscription
+	preCommitCh    chan core.PreCommitBlockEvent
+	preCommitSub   event.Subscription
 	voteCh         chan core.NewVoteEvent
 	votesSub       event.Subscription
 	voteMonitorSub event.Subscription
@@ -255,6 +266,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	}
 	// Construct the downloader (long sync)
 	h.downloader = downloader.New(config.Database, h.eventMux, h.chain, h.removePeer, nil)
+	h.downloader.SetImportBatchGas(config.ImportBatchGas)
 
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
@@ -348,8 +360,9 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		fetchRangeBlocks = nil
 	}
 
-	h.blockFetcher = fetcher.NewBlockFetcher(h.chain.GetBlockByHash, validator, broadcastBlockWithCheck,
+	h.blockFetcher = fetcher.NewBlockFetcher(h.chain.Config(), h.chain.GetBlockByHash, validator, broadcastBlockWithCheck,
 		heighter, finalizeHeighter, inserter, h.removePeer, fetchRangeBlocks)
+	h.chain.RegisterBlockFetcher(h.fetchBlocks)
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -379,6 +392,58 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	return h, nil
 }
 
+// fetchBlocks retrieves the bodies of the given headers from a few connected
+// peers in turn, and assembles the blocks of the first non-empty response. It
+// completes the head switches to blocks only known by their headers.
+func (h *handler) fetchBlocks(headers []*types.Header) ([]*types.Block, error) {
+	hashes := make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash()
+	}
+	for _, peer := range h.peers.headPeers(blockFetchPeers) {
+		bodies, err := requestBodies(peer, hashes)
+		if err != nil {
+			peer.Log().Debug("Failed to fetch block bodies", "count", len(hashes), "err", err)
+			continue
+		}
+		if len(bodies) == 0 || len(bodies) > len(headers) {
+			continue
+		}
+		blocks := make([]*types.Block, len(bodies))
+		for i, body := range bodies {
+			blocks[i] = types.NewBlockWithHeader(headers[i]).WithBody(types.Body{
+				Transactions: body.Transactions,
+				Uncles:       body.Uncles,
+				Withdrawals:  body.Withdrawals,
+			}).WithSidecars(body.Sidecars)
+		}
+		return blocks, nil
+	}
+	return nil, errors.New("block bodies unavailable from peers")
+}
+
+// requestBodies is a blocking version of Peer.RequestBodies, giving up after
+// blockFetchTimeout.
+func requestBodies(peer *ethPeer, hashes []common.Hash) ([]*eth.BlockBody, error) {
+	resCh := make(chan *eth.Response)
+	req, err := peer.RequestBodies(hashes, resCh)
+	if err != nil {
+		return nil, err
+	}
+	defer req.Close()
+
+	timeout := time.NewTimer(blockFetchTimeout)
+	defer timeout.Stop()
+
+	select {
+	case res := <-resCh:
+		res.Done <- nil
+		return *res.Res.(*eth.BlockBodiesResponse), nil
+	case <-timeout.C:
+		return nil, errors.New("request timed out")
+	}
+}
+
 // protoTracker tracks the number of active protocol handlers.
 func (h *handler) protoTracker() {
 	defer h.wg.Done()
@@ -722,6 +787,12 @@ func (h *handler) Start(maxPeers int, maxPeersPerIP int) {
 	h.minedBlockSub = h.eventMux.Subscribe(core.NewMinedBlockEvent{}, core.NewSealedBlockEvent{})
 	go h.minedBroadcastLoop()
 
+	// announce imported blocks while they are being committed
+	h.wg.Add(1)
+	h.preCommitCh = make(chan core.PreCommitBlockEvent, preCommitChanSize)
+	h.preCommitSub = h.chain.SubscribePreCommitBlockEvent(h.preCommitCh)
+	go h.preCommitAnnounceLoop()
+
 	// start sync handlers
 	h.wg.Add(1)
 	go h.chainSync.loop()
@@ -752,6 +823,7 @@ func (h *handler) Stop() {
 	h.txsSub.Unsubscribe()        // quits txBroadcastLoop
 	h.reannoTxsSub.Unsubscribe()  // quits txReannounceLoop
 	h.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	h.preCommitSub.Unsubscribe()  // quits preCommitAnnounceLoop
 	if h.votepool != nil {
 		h.votesSub.Unsubscribe() // quits voteBroadcastLoop
 		if h.maliciousVoteMonitor != nil {
@@ -1024,6 +1096,33 @@ func (h *handler) minedBroadcastLoop() {
 	}
 }
 
+// preCommitAnnounceLoop announces the imported blocks to connected peers as
+// soon as they are validated, without waiting for them to be committed. Peers
+// only request the announced blocks after a delay, by which time the commit is
+// expected to be done, and if it failed they fetch the block elsewhere. Blocks
+// imported during sync are not announced.
+func (h *handler) preCommitAnnounceLoop() {
+	defer h.wg.Done()
+	for {
+		select {
+		case ev := <-h.preCommitCh:
+			if !h.synced.Load() {
+				continue
+			}
+			block := ev.Block
+			peers := h.peers.peersWithoutBlock(block.Hash())
+			for _, peer := range peers {
+				peer.AsyncSendNewBlockHash(block)
+			}
+			log.Debug("Announced block before commit", "hash", block.Hash(), "recipients", len(peers))
+		case <-h.preCommitSub.Err():
+			return
+		case <-h.stopCh:
+			return
+		}
+	}
+}
+
 // txBroadcastLoop announces new transactions to connected peers.
 func (h *handler) txBroadcastLoop() {
 	defer h.wg.Done()
@@ -327,7 +327,7 @@ func handleNewBlock(backend Backend, msg Decoder, peer *Peer) error {
 		log.Warn("Propagated block has invalid uncles", "have", hash, "exp", ann.Block.UncleHash())
 		return nil // TODO(karalabe): return error eventually, but wait a few releases
 	}
-	if hash := types.DeriveSha(ann.Block.Transactions(), trie.NewStackTrie(nil)); hash != ann.Block.TxHash() {
+	if hash := types.DeriveSha(ann.Block.Transactions(), trie.NewRootHasher(backend.Chain().Config())); hash != ann.Block.TxHash() {
 		log.Warn("Propagated block has invalid body", "have", hash, "exp", ann.Block.TxHash())
 		return nil // TODO(karalabe): return error eventually, but wait a few releases
 	}
@@ -372,7 +372,7 @@ func handleBlockBodies(backend Backend, msg Decoder, peer *Peer) error {
 			uncleHashes      = make([]common.Hash, len(res.BlockBodiesResponse))
 			withdrawalHashes = make([]common.Hash, len(res.BlockBodiesResponse))
 		)
-		hasher := trie.NewStackTrie(nil)
+		hasher := trie.NewRootHasher(backend.Chain().Config())
 		for i, body := range res.BlockBodiesResponse {
 			txsHashes[i] = types.DeriveSha(types.Transactions(body.Transactions), hasher)
 			uncleHashes[i] = types.CalcUncleHash(body.Uncles)
@@ -396,7 +396,7 @@ func handleReceipts(backend Backend, msg Decoder, peer *Peer) error {
 		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 	}
 	metadata := func() interface{} {
-		hasher := trie.NewStackTrie(nil)
+		hasher := trie.NewRootHasher(backend.Chain().Config())
 		hashes := make([]common.Hash, len(res.ReceiptsResponse))
 		for i, receipt := range res.ReceiptsResponse {
 			hashes[i] = types.DeriveSha(types.Receipts(receipt), hasher)
@@ -20,7 +20,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -33,7 +32,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/log"
-	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
 )
@@ -47,7 +45,6 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 		current  *types.Block
 		database state.Database
 		tdb      *triedb.Database
-		report   = true
 		origin   = block.NumberU64()
 	)
 	// The state is only for reading purposes, check the state presence in
@@ -80,75 +77,26 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 			}
 		}
 		// The optional base statedb is given, mark the start point as parent block
-		statedb, database, tdb, report = base, base.Database(), base.Database().TrieDB(), false
+		statedb, database, tdb = base, base.Database(), base.Database().TrieDB()
 		current = eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
 		if current == nil {
 			return nil, nil, fmt.Errorf("missing parent block %v %d", block.ParentHash(), block.NumberU64()-1)
 		}
 	} else {
-		// Otherwise, try to reexec blocks until we find a state or reach our limit
-		current = block
-
-		// Create an ephemeral trie.Database for isolating the live one. Otherwise
-		// the internal junks created by tracing will be persisted into the disk.
-		// TODO(rjl493456442), clean cache is disabled to prevent memory leak,
-		// please re-enable it for better performance.
-		tdb = triedb.NewDatabase(eth.chainDb, triedb.HashDefaults)
-		database = state.NewDatabase(tdb, nil)
-
-		// If we didn't check the live database, do check state over ephemeral database,
-		// otherwise we would rewind past a persisted block (specific corner case is
-		// chain tracing from the genesis).
-		if !readOnly {
-			statedb, err = state.New(current.Root(), database)
-			if err == nil {
-				return statedb, noopReleaser, nil
-			}
-		}
-		// Database does not have the state for the given block, try to regenerate
-		for i := uint64(0); i < reexec; i++ {
-			if err := ctx.Err(); err != nil {
-				return nil, nil, err
-			}
-			if current.NumberU64() == 0 {
-				return nil, nil, errors.New("genesis state is missing")
-			}
-			parent := eth.blockchain.GetBlock(current.ParentHash(), current.NumberU64()-1)
-			if parent == nil {
-				return nil, nil, fmt.Errorf("missing block %v %d", current.ParentHash(), current.NumberU64()-1)
-			}
-			current = parent
-
-			statedb, err = state.New(current.Root(), database)
-			if err == nil {
-				break
-			}
-		}
-		if err != nil {
-			switch err.(type) {
-			case *trie.MissingNodeError:
-				return nil, nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
-			default:
-				return nil, nil, err
-			}
+		// Otherwise, regenerate the state from the nearest available one over an
+		// ephemeral trie database, isolated from the live one
+		if statedb, err = eth.blockchain.StateAtBlock(ctx, block, reexec); err != nil {
+			return nil, nil, err
 		}
+		return statedb, noopReleaser, nil
 	}
-	// State is available at historical point, re-execute the blocks on top for
-	// the desired state.
-	var (
-		start  = time.Now()
-		logged time.Time
-		parent common.Hash
-	)
+	// State is available at the parent, re-execute the block on top for the
+	// desired state.
+	var parent common.Hash
 	for current.NumberU64() < origin {
 		if err := ctx.Err(); err != nil {
 			return nil, nil, err
 		}
-		// Print progress logs if long enough time elapsed
-		if time.Since(logged) > 8*time.Second && report {
-			log.Info("Regenerating historical state", "block", current.NumberU64()+1, "target", origin, "remaining", origin-current.NumberU64()-1, "elapsed", time.Since(start))
-			logged = time.Now()
-		}
 		// Retrieve the next block to regenerate and process it
 		next := current.NumberU64() + 1
 		if current = eth.blockchain.GetBlockByNumber(next); current == nil {
@@ -176,10 +124,6 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 		}
 		parent = root
 	}
-	if report {
-		diff, nodes, immutablenodes, imgs := tdb.Size() // all memory is contained within the nodes return in hashdb
-		log.Info("Historical state regenerated", "block", current.NumberU64(), "elapsed", time.Since(start), "layer", diff, "nodes", nodes, "immutablenodes", immutablenodes, "preimages", imgs)
-	}
 	return statedb, func() { tdb.Dereference(block.Root()) }, nil
 }
 
@@ -0,0 +1,329 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("invariant", newInvariantTracer)
+}
+
+// Invariants checked by the invariant tracer.
+const (
+	invariantBalance = "balance" // Balances never drop below zero and change consistently
+	invariantNonce   = "nonce"   // Nonces only ever increase, except when reverted
+	invariantStorage = "storage" // Storage slots change consistently
+	invariantSupply  = "supply"  // Balance changes of a block add up to its issuance minus its burn
+)
+
+// negativeBalance is the balance from which on balances are considered to have
+// wrapped around below zero. Balances are unsigned 256 bit integers, and no
+// legitimate balance gets anywhere close to 2^255.
+var negativeBalance = new(big.Int).Lsh(big.NewInt(1), 255)
+
+// invariantViolation is a broken invariant.
+type invariantViolation struct {
+	Check   string          `json:"check"`
+	Address *common.Address `json:"address,omitempty"`
+	Slot    *common.Hash    `json:"slot,omitempty"`
+	TxHash  *common.Hash    `json:"txHash,omitempty"`
+	Have    string          `json:"have"`
+	Want    string          `json:"want"`
+	Detail  string          `json:"detail,omitempty"`
+}
+
+// invariantReport lists the invariants broken by a block.
+type invariantReport struct {
+	Number     uint64               `json:"blockNumber"`
+	Hash       common.Hash          `json:"hash"`
+	ParentHash common.Hash          `json:"parentHash"`
+	Violations []invariantViolation `json:"violations"`
+}
+
+type invariantTracerConfig struct {
+	Path   string   `json:"path"`   // Directory to write violation reports into, none are written if empty
+	Halt   *bool    `json:"halt"`   // Whether to halt the node on violations, defaults to true
+	Checks []string `json:"checks"` // Invariants to check, defaults to all of them
+}
+
+// invariantTracer asserts consensus invariants on every processed block. It
+// relies on the journaling wrapper to observe reverted state changes, so the
+// state changes it sees always continue from the previous ones.
+type invariantTracer struct {
+	config      invariantTracerConfig
+	checks      map[string]bool
+	chainConfig *params.ChainConfig
+	halt        func(report *invariantReport, path string) // Called on violations, to stop the node
+
+	block      *types.Block
+	tx         *common.Hash
+	balances   map[common.Address]*big.Int
+	nonces     map[common.Address]uint64
+	storage    map[common.Address]map[common.Hash]common.Hash
+	delta      *big.Int   // Sum of all balance changes of the block
+	issuance   *big.Int   // Ether created by the block
+	burn       *big.Int   // Ether explicitly destroyed by the block
+	frames     []*big.Int // Self-destruct balance changes of the open call frames
+	violations []invariantViolation
+}
+
+func newInvariantTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	t, err := newInvariantTracerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.WrapWithJournal(&tracing.Hooks{
+		OnBlockchainInit: t.onBlockchainInit,
+		OnBlockStart:     t.onBlockStart,
+		OnBlockEnd:       t.onBlockEnd,
+		OnTxStart:        t.onTxStart,
+		OnTxEnd:          t.onTxEnd,
+		OnEnter:          t.onEnter,
+		OnExit:           t.onExit,
+		OnBalanceChange:  t.onBalanceChange,
+		OnNonceChangeV2:  t.onNonceChange,
+		OnCodeChange:     t.onCodeChange,
+		OnStorageChange:  t.onStorageChange,
+	})
+}
+
+func newInvariantTracerFromConfig(cfg json.RawMessage) (*invariantTracer, error) {
+	var config invariantTracerConfig
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %v", err)
+		}
+	}
+	checks := make(map[string]bool)
+	if len(config.Checks) == 0 {
+		config.Checks = []string{invariantBalance, invariantNonce, invariantStorage, invariantSupply}
+	}
+	for _, check := range config.Checks {
+		switch check {
+		case invariantBalance, invariantNonce, invariantStorage, invariantSupply:
+			checks[check] = true
+		default:
+			return nil, fmt.Errorf("unknown invariant %q", check)
+		}
+	}
+	if config.Path != "" {
+		if err := os.MkdirAll(config.Path, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &invariantTracer{
+		config: config,
+		checks: checks,
+		halt: func(report *invariantReport, path string) {
+			log.Crit("Consensus invariant violated", "number", report.Number, "hash", report.Hash,
+				"violations", len(report.Violations), "first", report.Violations[0], "report", path)
+		},
+	}, nil
+}
+
+func (t *invariantTracer) onBlockchainInit(chainConfig *params.ChainConfig) {
+	t.chainConfig = chainConfig
+}
+
+func (t *invariantTracer) onBlockStart(ev tracing.BlockEvent) {
+	t.block = ev.Block
+	t.tx = nil
+	t.balances = make(map[common.Address]*big.Int)
+	t.nonces = make(map[common.Address]uint64)
+	t.storage = make(map[common.Address]map[common.Hash]common.Hash)
+	t.delta = new(big.Int)
+	t.issuance = new(big.Int)
+	t.burn = new(big.Int)
+	t.frames = nil
+	t.violations = nil
+}
+
+func (t *invariantTracer) onTxStart(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	hash := tx.Hash()
+	t.tx = &hash
+}
+
+func (t *invariantTracer) onTxEnd(receipt *types.Receipt, err error) {
+	t.tx = nil
+}
+
+func (t *invariantTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, new(big.Int))
+}
+
+// onExit accounts the ether burnt by the self-destructs of the call frame, if
+// it was not reverted. Self-destructs transferring to another account leave
+// the total unchanged, while those crediting the destructed account itself
+// burn the balance.
+func (t *invariantTracer) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if reverted {
+		return
+	}
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].Add(t.frames[len(t.frames)-1], frame)
+	} else {
+		t.burn.Sub(t.burn, frame)
+	}
+}
+
+// violate records a broken invariant.
+func (t *invariantTracer) violate(check string, addr common.Address, slot *common.Hash, have, want any, detail string) {
+	t.violations = append(t.violations, invariantViolation{
+		Check:   check,
+		Address: &addr,
+		Slot:    slot,
+		TxHash:  t.tx,
+		Have:    fmt.Sprint(have),
+		Want:    fmt.Sprint(want),
+		Detail:  detail,
+	})
+}
+
+func (t *invariantTracer) onBalanceChange(addr common.Address, prevBalance, newBalance *big.Int, reason tracing.BalanceChangeReason) {
+	if t.block == nil {
+		return // Genesis allocation
+	}
+	if t.checks[invariantBalance] {
+		if last, ok := t.balances[addr]; ok && last.Cmp(prevBalance) != 0 {
+			t.violate(invariantBalance, addr, nil, prevBalance, last, fmt.Sprintf("inconsistent previous balance (%v)", reason))
+		}
+		if newBalance.Sign() < 0 || newBalance.Cmp(negativeBalance) >= 0 {
+			t.violate(invariantBalance, addr, nil, newBalance, "non-negative", fmt.Sprintf("negative balance (%v)", reason))
+		}
+		t.balances[addr] = new(big.Int).Set(newBalance)
+	}
+	diff := new(big.Int).Sub(newBalance, prevBalance)
+	t.delta.Add(t.delta, diff)
+
+	switch reason {
+	case tracing.BalanceIncreaseRewardMineUncle, tracing.BalanceIncreaseRewardMineBlock,
+		tracing.BalanceIncreaseWithdrawal, tracing.BalanceIncreaseNativeMint:
+		t.issuance.Add(t.issuance, diff)
+	case tracing.BalanceDecreaseSelfdestructBurn, tracing.BalanceDecreaseNativeBurn:
+		t.burn.Sub(t.burn, diff)
+	case tracing.BalanceIncreaseSelfdestruct, tracing.BalanceDecreaseSelfdestruct:
+		if len(t.frames) > 0 {
+			t.frames[len(t.frames)-1].Add(t.frames[len(t.frames)-1], diff)
+		}
+	}
+}
+
+func (t *invariantTracer) onNonceChange(addr common.Address, prevNonce, newNonce uint64, reason tracing.NonceChangeReason) {
+	if t.block == nil || !t.checks[invariantNonce] {
+		return
+	}
+	if last, ok := t.nonces[addr]; ok && last != prevNonce {
+		t.violate(invariantNonce, addr, nil, prevNonce, last, fmt.Sprintf("inconsistent previous nonce (%v)", reason))
+	}
+	if newNonce <= prevNonce && reason != tracing.NonceChangeRevert {
+		t.violate(invariantNonce, addr, nil, newNonce, fmt.Sprintf("> %d", prevNonce), fmt.Sprintf("nonce not increasing (%v)", reason))
+	}
+	t.nonces[addr] = newNonce
+}
+
+func (t *invariantTracer) onCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	// A self-destruct clears the nonce and storage of the account without
+	// reporting them, so forget about them.
+	if t.block != nil && len(prevCode) > 0 && len(code) == 0 {
+		delete(t.nonces, addr)
+		delete(t.storage, addr)
+	}
+}
+
+func (t *invariantTracer) onStorageChange(addr common.Address, slot common.Hash, prevValue, newValue common.Hash) {
+	if t.block == nil || !t.checks[invariantStorage] {
+		return
+	}
+	slots := t.storage[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]common.Hash)
+		t.storage[addr] = slots
+	}
+	if last, ok := slots[slot]; ok && last != prevValue {
+		t.violate(invariantStorage, addr, &slot, prevValue.Hex(), last.Hex(), "inconsistent previous value")
+	}
+	slots[slot] = newValue
+}
+
+func (t *invariantTracer) onBlockEnd(err error) {
+	block := t.block
+	t.block = nil
+	if block == nil || err != nil {
+		return // Block rejected, its state is discarded
+	}
+	if t.checks[invariantSupply] {
+		// Fees are paid out of the senders' balances without a matching
+		// increase, which is the implicit burn
+		burnt, blob := core.BlockFees(t.chainConfig, block.Header())
+		if t.chainConfig.Parlia == nil {
+			burnt.Add(burnt, blob)
+		}
+		want := new(big.Int).Sub(t.issuance, t.burn)
+		want.Sub(want, burnt)
+		if t.delta.Cmp(want) != 0 {
+			t.violations = append(t.violations, invariantViolation{
+				Check:  invariantSupply,
+				Have:   t.delta.String(),
+				Want:   want.String(),
+				Detail: fmt.Sprintf("issuance %v, burn %v, fees burnt %v", t.issuance, t.burn, burnt),
+			})
+		}
+	}
+	if len(t.violations) == 0 {
+		return
+	}
+	report := &invariantReport{
+		Number:     block.NumberU64(),
+		Hash:       block.Hash(),
+		ParentHash: block.ParentHash(),
+		Violations: t.violations,
+	}
+	var path string
+	if t.config.Path != "" {
+		path = filepath.Join(t.config.Path, fmt.Sprintf("invariant-%d-%x.json", report.Number, report.Hash[:4]))
+		blob, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(path, blob, 0644); err != nil {
+			log.Error("Failed to write invariant report", "path", path, "err", err)
+		}
+	}
+	if t.config.Halt == nil || *t.config.Halt {
+		t.halt(report, path)
+	} else {
+		for _, violation := range report.Violations {
+			log.Error("Consensus invariant violated", "number", report.Number, "hash", report.Hash, "violation", violation)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestInvariantTracer creates an invariant tracer collecting the reports
+// instead of halting.
+func newTestInvariantTracer(t *testing.T, config string) (*invariantTracer, *[]*invariantReport) {
+	t.Helper()
+
+	tracer, err := newInvariantTracerFromConfig(json.RawMessage(config))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	reports := new([]*invariantReport)
+	tracer.halt = func(report *invariantReport, path string) {
+		*reports = append(*reports, report)
+	}
+	tracer.onBlockchainInit(params.TestChainConfig)
+	return tracer, reports
+}
+
+func testInvariantBlock(number int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), BaseFee: big.NewInt(0)})
+}
+
+// Tests that consistent blocks pass all checks, including blocks with
+// reverted state changes.
+func TestInvariantTracerConsistent(t *testing.T) {
+	tracer, reports := newTestInvariantTracer(t, "")
+	var (
+		alice = common.Address{0xaa}
+		bob   = common.Address{0xbb}
+		slot  = common.Hash{0x01}
+	)
+	tracer.onBlockStart(tracing.BlockEvent{Block: testInvariantBlock(1)})
+	tracer.onNonceChange(alice, 0, 1, tracing.NonceChangeEoACall)
+	tracer.onEnter(0, byte(0xf1), alice, bob, nil, 21000, big.NewInt(10))
+	tracer.onBalanceChange(alice, big.NewInt(100), big.NewInt(90), tracing.BalanceChangeTransfer)
+	tracer.onBalanceChange(bob, big.NewInt(0), big.NewInt(10), tracing.BalanceChangeTransfer)
+	tracer.onStorageChange(bob, slot, common.Hash{}, common.Hash{0x1})
+	tracer.onStorageChange(bob, slot, common.Hash{0x1}, common.Hash{})
+	tracer.onBalanceChange(bob, big.NewInt(10), big.NewInt(0), tracing.BalanceChangeRevert)
+	tracer.onBalanceChange(alice, big.NewInt(90), big.NewInt(100), tracing.BalanceChangeRevert)
+	tracer.onExit(0, nil, 21000, nil, true)
+	tracer.onBalanceChange(bob, big.NewInt(0), big.NewInt(5), tracing.BalanceIncreaseRewardMineBlock)
+	tracer.onBlockEnd(nil)
+
+	if len(*reports) != 0 {
+		t.Fatalf("unexpected violations: %+v", (*reports)[0].Violations)
+	}
+}
+
+// Tests that self-destructs crediting the destructed account itself are
+// accounted as burn, unless reverted.
+func TestInvariantTracerSelfdestructBurn(t *testing.T) {
+	tracer, reports := newTestInvariantTracer(t, `{"checks": ["supply"]}`)
+	contract := common.Address{0xcc}
+
+	tracer.onBlockStart(tracing.BlockEvent{Block: testInvariantBlock(1)})
+	for _, reverted := range []bool{true, false} {
+		tracer.onEnter(0, byte(0xf1), contract, contract, nil, 21000, big.NewInt(0))
+		tracer.onEnter(1, byte(0xff), contract, contract, nil, 0, big.NewInt(50))
+		tracer.onBalanceChange(contract, big.NewInt(50), big.NewInt(100), tracing.BalanceIncreaseSelfdestruct)
+		tracer.onBalanceChange(contract, big.NewInt(100), big.NewInt(0), tracing.BalanceDecreaseSelfdestruct)
+		tracer.onExit(1, nil, 0, nil, false)
+		if reverted {
+			tracer.onBalanceChange(contract, big.NewInt(0), big.NewInt(100), tracing.BalanceChangeRevert)
+			tracer.onBalanceChange(contract, big.NewInt(100), big.NewInt(50), tracing.BalanceChangeRevert)
+		}
+		tracer.onExit(0, nil, 21000, nil, reverted)
+	}
+	tracer.onBlockEnd(nil)
+
+	if len(*reports) != 0 {
+		t.Fatalf("unexpected violations: %+v", (*reports)[0].Violations)
+	}
+}
+
+// Tests that violations of every invariant are reported, and written to disk.
+func TestInvariantTracerViolations(t *testing.T) {
+	dir := t.TempDir()
+	tracer, reports := newTestInvariantTracer(t, `{"path": "`+dir+`"}`)
+	var (
+		alice = common.Address{0xaa}
+		slot  = common.Hash{0x01}
+		block = testInvariantBlock(7)
+	)
+	tracer.onBlockStart(tracing.BlockEvent{Block: block})
+	tracer.onBalanceChange(alice, big.NewInt(100), big.NewInt(90), tracing.BalanceChangeTransfer)
+	tracer.onBalanceChange(alice, big.NewInt(80), big.NewInt(70), tracing.BalanceChangeTransfer)
+	tracer.onNonceChange(alice, 5, 4, tracing.NonceChangeEoACall)
+	tracer.onStorageChange(alice, slot, common.Hash{}, common.Hash{0x1})
+	tracer.onStorageChange(alice, slot, common.Hash{0x2}, common.Hash{0x3})
+	tracer.onBlockEnd(nil)
+
+	if len(*reports) != 1 {
+		t.Fatalf("report count mismatch: have %d, want 1", len(*reports))
+	}
+	report := (*reports)[0]
+	if report.Number != 7 || report.Hash != block.Hash() {
+		t.Errorf("report block mismatch: have %d %x", report.Number, report.Hash)
+	}
+	var checks []string
+	for _, violation := range report.Violations {
+		checks = append(checks, violation.Check)
+	}
+	want := []string{invariantBalance, invariantNonce, invariantStorage, invariantSupply}
+	if len(checks) != len(want) {
+		t.Fatalf("violations mismatch: have %v, want %v", checks, want)
+	}
+	for i := range want {
+		if checks[i] != want[i] {
+			t.Errorf("violation %d mismatch: have %s, want %s", i, checks[i], want[i])
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "invariant-7-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("report file missing")
+	}
+	blob, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var stored invariantReport
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if len(stored.Violations) != len(want) {
+		t.Errorf("stored violations mismatch: have %d, want %d", len(stored.Violations), len(want))
+	}
+}
+
+// Tests that invalid configurations are rejected.
+func TestInvariantTracerConfig(t *testing.T) {
+	if _, err := newInvariantTracerFromConfig(json.RawMessage(`{"checks": ["gravity"]}`)); err == nil {
+		t.Error("unknown check accepted")
+	}
+	if _, err := newInvariantTracer(json.RawMessage(`{"checks": ["nonce", "supply"], "halt": false}`)); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+}
@@ -73,6 +73,17 @@ type Compacter interface {
 	Compact(start []byte, limit []byte) error
 }
 
+// Keyspacer wraps the Keyspace method of a backing data store able to manage
+// named partitions of its data independently, e.g. with separate compaction
+// and caching.
+type Keyspacer interface {
+	// Keyspace returns the named partition of the data store, creating it if it
+	// doesn't exist yet. Keyspaces are closed along with the data store.
+	//
+	// Note, writes spanning multiple keyspaces are not atomic.
+	Keyspace(name string) (KeyValueStore, error)
+}
+
 // KeyValueStore contains all the methods required to allow handling different
 // key-value data stores backing the high level database.
 type KeyValueStore interface {
@@ -167,6 +178,13 @@ type FreezerEnv struct {
 type AncientFreezer interface {
 	// SetupFreezerEnv provides params.ChainConfig for checking hark forks, like isCancun.
 	SetupFreezerEnv(env *FreezerEnv, blockHistory uint64) error
+
+	// PauseFreezing suspends moving chain segments into the ancient store in the
+	// background, waiting for a running freeze cycle to finish.
+	PauseFreezing()
+
+	// ResumeFreezing lifts a pause of the background freezing.
+	ResumeFreezing()
 }
 
 // AncientWriteOp is given to the function argument of ModifyAncients.
@@ -19,9 +19,12 @@ package memorydb
 
 import (
 	"errors"
+	"maps"
+	"math/rand"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -35,18 +38,41 @@ var (
 	// errMemorydbNotFound is returned if a key is requested that is not found in
 	// the provided memory database.
 	errMemorydbNotFound = errors.New("not found")
+
+	// ErrInjectedFault is returned by the operations failed by fault injection.
+	ErrInjectedFault = errors.New("injected fault")
 )
 
 // Database is an ephemeral key-value store. Apart from basic data storage
 // functionality it also supports batch writes and iterating over the keyspace in
 // binary-alphabetical order.
 type Database struct {
-	db   map[string][]byte
-	lock sync.RWMutex
+	db     map[string][]byte
+	shared atomic.Bool // Whether db is referenced by a snapshot, copied before the next write
+	lock   sync.RWMutex
+
+	faulty    atomic.Bool // Whether fault injection is enabled
+	readRate  float64     // Ratio of reads to fail
+	writeRate float64     // Ratio of writes to fail
+	faultRand rand.Source // Deterministic source of the injected faults
+	faultLock sync.Mutex
 
 	stateStore ethdb.Database
 }
 
+// Option is a configuration option of a memory database.
+type Option func(*Database)
+
+// WithFaults makes the database fail the given ratios of reads and writes with
+// ErrInjectedFault. The failed operations are picked by a random source seeded
+// with seed, so the faults are reproducible.
+func WithFaults(readRate, writeRate float64, seed int64) Option {
+	return func(db *Database) {
+		db.faultRand = rand.NewSource(seed)
+		db.SetFaults(readRate, writeRate)
+	}
+}
+
 func (db *Database) ModifyAncients(f func(ethdb.AncientWriteOp) error) (int64, error) {
 	//TODO implement me
 	panic("implement me")
@@ -124,10 +150,14 @@ func (db *Database) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err erro
 
 // New returns a wrapped map with all the required database interface methods
 // implemented.
-func New() *Database {
-	return &Database{
+func New(opts ...Option) *Database {
+	db := &Database{
 		db: make(map[string][]byte),
 	}
+	for _, opt := range opts {
+		opt(db)
+	}
+	return db
 }
 
 // NewWithCap returns a wrapped map pre-allocated to the provided capacity with
@@ -156,6 +186,9 @@ func (db *Database) Has(key []byte) (bool, error) {
 	if db.db == nil {
 		return false, errMemorydbClosed
 	}
+	if db.fault(false) {
+		return false, ErrInjectedFault
+	}
 	_, ok := db.db[string(key)]
 	return ok, nil
 }
@@ -168,6 +201,9 @@ func (db *Database) Get(key []byte) ([]byte, error) {
 	if db.db == nil {
 		return nil, errMemorydbClosed
 	}
+	if db.fault(false) {
+		return nil, ErrInjectedFault
+	}
 	if entry, ok := db.db[string(key)]; ok {
 		return common.CopyBytes(entry), nil
 	}
@@ -182,6 +218,10 @@ func (db *Database) Put(key []byte, value []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
+	if db.fault(true) {
+		return ErrInjectedFault
+	}
+	db.writable()
 	db.db[string(key)] = common.CopyBytes(value)
 	return nil
 }
@@ -194,6 +234,10 @@ func (db *Database) Delete(key []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
+	if db.fault(true) {
+		return ErrInjectedFault
+	}
+	db.writable()
 	delete(db.db, string(key))
 	return nil
 }
@@ -206,6 +250,10 @@ func (db *Database) DeleteRange(start, end []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
+	if db.fault(true) {
+		return ErrInjectedFault
+	}
+	db.writable()
 
 	for key := range db.db {
 		if key >= string(start) && key < string(end) {
@@ -237,6 +285,9 @@ func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.fault(false) {
+		return &iterator{index: -1, err: ErrInjectedFault}
+	}
 	var (
 		pr     = string(prefix)
 		st     = string(append(prefix, start...))
@@ -300,6 +351,77 @@ func (db *Database) StateStoreReader() ethdb.Reader {
 	return db.stateStore
 }
 
+// SetFaults changes the ratios of reads and writes failed with ErrInjectedFault,
+// zero ratios disabling fault injection.
+func (db *Database) SetFaults(readRate, writeRate float64) {
+	db.faultLock.Lock()
+	defer db.faultLock.Unlock()
+
+	if db.faultRand == nil {
+		db.faultRand = rand.NewSource(0)
+	}
+	db.readRate, db.writeRate = readRate, writeRate
+	db.faulty.Store(readRate > 0 || writeRate > 0)
+}
+
+// fault reports whether the next read or write operation should fail.
+func (db *Database) fault(write bool) bool {
+	if !db.faulty.Load() {
+		return false
+	}
+	db.faultLock.Lock()
+	defer db.faultLock.Unlock()
+
+	rate := db.readRate
+	if write {
+		rate = db.writeRate
+	}
+	return float64(db.faultRand.Int63())/(1<<63) < rate
+}
+
+// Snapshot is a frozen copy of the database content, which the database can be
+// rolled back to.
+type Snapshot struct {
+	db map[string][]byte
+}
+
+// Snapshot captures the current content of the database. The content is shared
+// with the database until its next write, which copies it first, so taking a
+// snapshot is cheap.
+func (db *Database) Snapshot() (*Snapshot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return nil, errMemorydbClosed
+	}
+	db.shared.Store(true)
+	return &Snapshot{db: db.db}, nil
+}
+
+// Restore rolls the database content back to the given snapshot. The snapshot
+// is left intact and can be restored again later.
+func (db *Database) Restore(snap *Snapshot) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return errMemorydbClosed
+	}
+	db.db = snap.db
+	db.shared.Store(true)
+	return nil
+}
+
+// writable copies the content of the database if it's shared with a snapshot,
+// so it can be modified. The caller must hold the write lock.
+func (db *Database) writable() {
+	if db.shared.Load() {
+		db.db = maps.Clone(db.db)
+		db.shared.Store(false)
+	}
+}
+
 // keyvalue is a key-value tuple tagged with a deletion field to allow creating
 // memory-database write batches.
 type keyvalue struct {
@@ -343,6 +465,10 @@ func (b *batch) Write() error {
 	if b.db.db == nil {
 		return errMemorydbClosed
 	}
+	if b.db.fault(true) {
+		return ErrInjectedFault
+	}
+	b.db.writable()
 	for _, keyvalue := range b.writes {
 		if keyvalue.delete {
 			delete(b.db.db, keyvalue.key)
@@ -382,6 +508,7 @@ type iterator struct {
 	index  int
 	keys   []string
 	values [][]byte
+	err    error
 }
 
 // Next moves the iterator to the next key/value pair. It returns whether the
@@ -396,9 +523,10 @@ func (it *iterator) Next() bool {
 }
 
 // Error returns any accumulated error. Exhausting all the key/value pairs
-// is not considered to be an error. A memory iterator cannot encounter errors.
+// is not considered to be an error. A memory iterator only fails if faults
+// are injected.
 func (it *iterator) Error() error {
-	return nil
+	return it.err
 }
 
 // Key returns the key of the current key/value pair, or nil if done. The caller
@@ -17,7 +17,9 @@
 package memorydb
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -32,6 +34,74 @@ func TestMemoryDB(t *testing.T) {
 	})
 }
 
+// Tests that snapshots are isolated from later writes and can be restored
+// multiple times.
+func TestSnapshotRestore(t *testing.T) {
+	db := New()
+	db.Put([]byte("a"), []byte{1})
+
+	snap, err := db.Snapshot()
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	db.Put([]byte("a"), []byte{2})
+	db.Put([]byte("b"), []byte{3})
+
+	batch := db.NewBatch()
+	batch.Delete([]byte("a"))
+	batch.Write()
+
+	for i := 0; i < 2; i++ {
+		if err := db.Restore(snap); err != nil {
+			t.Fatalf("failed to restore snapshot: %v", err)
+		}
+		if have, _ := db.Get([]byte("a")); !bytes.Equal(have, []byte{1}) {
+			t.Fatalf("restore %d: value mismatch: have %x, want 01", i, have)
+		}
+		if ok, _ := db.Has([]byte("b")); ok {
+			t.Fatalf("restore %d: key written after snapshot present", i)
+		}
+		db.Put([]byte("a"), []byte{4})
+		db.DeleteRange([]byte("a"), []byte("c"))
+	}
+}
+
+// Tests that faults are injected at the configured ratios, deterministically.
+func TestFaultInjection(t *testing.T) {
+	count := func(db *Database) (reads, writes int) {
+		for i := 0; i < 1000; i++ {
+			if _, err := db.Has([]byte("a")); errors.Is(err, ErrInjectedFault) {
+				reads++
+			}
+			if err := db.Put([]byte("a"), nil); errors.Is(err, ErrInjectedFault) {
+				writes++
+			}
+		}
+		return reads, writes
+	}
+	reads, writes := count(New(WithFaults(0.1, 0.5, 1)))
+	if reads < 50 || reads > 150 {
+		t.Errorf("read fault count out of range: %d", reads)
+	}
+	if writes < 400 || writes > 600 {
+		t.Errorf("write fault count out of range: %d", writes)
+	}
+	if r, w := count(New(WithFaults(0.1, 0.5, 1))); r != reads || w != writes {
+		t.Errorf("faults not reproducible: have %d/%d, want %d/%d", r, w, reads, writes)
+	}
+	db := New(WithFaults(1, 0, 1))
+	it := db.NewIterator(nil, nil)
+	if it.Next() || !errors.Is(it.Error(), ErrInjectedFault) {
+		t.Errorf("iterator fault not injected: %v", it.Error())
+	}
+	it.Release()
+
+	db.SetFaults(0, 0)
+	if reads, writes := count(db); reads != 0 || writes != 0 {
+		t.Errorf("faults injected after disabling: %d/%d", reads, writes)
+	}
+}
+
 // BenchmarkBatchAllocs measures the time/allocs for storing 120 kB of data
 func BenchmarkBatchAllocs(b *testing.B) {
 	b.ReportAllocs()
@@ -20,6 +20,8 @@ package pebble
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -52,6 +54,9 @@ const (
 	// degradationWarnInterval specifies how often warning should be printed if the
 	// leveldb database cannot keep up with requested writes.
 	degradationWarnInterval = time.Minute
+
+	// keyspacesDir is the subdirectory of the database holding its keyspaces.
+	keyspacesDir = "keyspaces"
 )
 
 // Database is a persistent key-value store based on the pebble storage engine.
@@ -61,6 +66,11 @@ type Database struct {
 	fn        string     // filename for reporting
 	db        *pebble.DB // Underlying pebble storage engine
 	namespace string     // Namespace for metrics
+	cache     int        // Cache allowance in megabytes, used to size keyspaces
+	handles   int        // File handles allowance, used to size keyspaces
+	readonly  bool
+
+	keyspaces map[string]*Database // Separately managed partitions, opened on demand
 
 	compTimeMeter          *metrics.Meter   // Meter for measuring the total time spent in database compaction
 	compReadMeter          *metrics.Meter   // Meter for measuring the data read during compaction
@@ -220,9 +230,14 @@ func New(file string, cache int, handles int, namespace string, readonly bool) (
 		"handles", handles, "memory table", common.StorageSize(memTableSize))
 
 	db := &Database{
-		fn:       file,
-		log:      logger,
-		quitChan: make(chan chan error),
+		fn:        file,
+		namespace: namespace,
+		cache:     cache,
+		handles:   handles,
+		readonly:  readonly,
+		keyspaces: make(map[string]*Database),
+		log:       logger,
+		quitChan:  make(chan chan error),
 
 		// Use asynchronous write mode by default. Otherwise, the overhead of frequent fsync
 		// operations can be significant, especially on platforms with slow fsync performance
@@ -369,9 +384,45 @@ func (d *Database) Close() error {
 		}
 		d.quitChan = nil
 	}
+	for name, keyspace := range d.keyspaces {
+		if err := keyspace.Close(); err != nil {
+			d.log.Error("Failed to close keyspace", "keyspace", name, "err", err)
+		}
+	}
 	return d.db.Close()
 }
 
+// HasKeyspaces reports whether the database in the given directory has any
+// keyspace, allowing its cache and file handles budget to be split before the
+// database is opened.
+func HasKeyspaces(file string) bool {
+	_, err := os.Stat(filepath.Join(file, keyspacesDir))
+	return err == nil
+}
+
+// Keyspace returns the named partition of the database, backed by a separate
+// pebble instance in a subdirectory, compacted and cached independently. Each
+// keyspace is allowed the same cache and file handles as the parent, which is
+// thus expected to be opened with its share of the total budget only.
+func (d *Database) Keyspace(name string) (ethdb.KeyValueStore, error) {
+	d.quitLock.Lock()
+	defer d.quitLock.Unlock()
+
+	if d.closed {
+		return nil, pebble.ErrClosed
+	}
+	if keyspace, ok := d.keyspaces[name]; ok {
+		return keyspace, nil
+	}
+	file := filepath.Join(d.fn, keyspacesDir, name)
+	keyspace, err := New(file, d.cache, d.handles, d.namespace+"keyspace/"+name+"/", d.readonly)
+	if err != nil {
+		return nil, err
+	}
+	d.keyspaces[name] = keyspace
+	return keyspace, nil
+}
+
 // Has retrieves if a key is present in the key-value store.
 func (d *Database) Has(key []byte) (bool, error) {
 	d.quitLock.RLock()
@@ -189,6 +189,10 @@ func (db *Database) SetupFreezerEnv(env *ethdb.FreezerEnv, blockHistory uint64)
 	panic("not supported")
 }
 
+func (db *Database) PauseFreezing() {}
+
+func (db *Database) ResumeFreezing() {}
+
 func New(client *rpc.Client) ethdb.Database {
 	return &Database{
 		remote: client,
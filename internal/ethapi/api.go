@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -239,6 +240,123 @@ func (api *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RP
 	return content
 }
 
+// RPCSnapshotTransaction is an executable transaction of a pool snapshot.
+type RPCSnapshotTransaction struct {
+	*RPCTransaction
+	EffectiveTip *hexutil.Big         `json:"effectiveTip"`
+	Authorities  []common.Address     `json:"authorities,omitempty"`
+	Sidecar      *types.BlobTxSidecar `json:"sidecar,omitempty"`
+}
+
+// RPCSnapshot is a deterministic snapshot of the executable pool transactions.
+type RPCSnapshot struct {
+	BaseFee      *hexutil.Big              `json:"baseFee"`
+	BlobFee      *hexutil.Big              `json:"blobFee,omitempty"`
+	Transactions []*RPCSnapshotTransaction `json:"transactions"`
+}
+
+// Snapshot returns the executable transactions of the pool priced against the
+// given base fee and blob fee, in a reproducible block building order. If the
+// base fee is omitted, the one of the next block is used. Blob transactions
+// are only included if a blob fee is given.
+func (api *TxPoolAPI) Snapshot(baseFee *hexutil.Big, blobFee *hexutil.Big) (*RPCSnapshot, error) {
+	var (
+		curHeader = api.b.CurrentHeader()
+		config    = api.b.ChainConfig()
+		base      *big.Int
+		blob      *big.Int
+	)
+	if baseFee != nil {
+		base = baseFee.ToInt()
+	} else if config.IsLondon(new(big.Int).Add(curHeader.Number, common.Big1)) {
+		base = eip1559.CalcBaseFee(config, curHeader)
+	}
+	if blobFee != nil {
+		blob = blobFee.ToInt()
+	}
+	if (base != nil && base.Sign() < 0) || (blob != nil && blob.Sign() < 0) {
+		return nil, errors.New("negative fee")
+	}
+	snapshot := api.b.TxPoolSnapshot(base, blob)
+
+	result := &RPCSnapshot{
+		BaseFee:      (*hexutil.Big)(snapshot.BaseFee),
+		BlobFee:      (*hexutil.Big)(snapshot.BlobFee),
+		Transactions: make([]*RPCSnapshotTransaction, 0, len(snapshot.Txs)),
+	}
+	for _, stx := range snapshot.Txs {
+		result.Transactions = append(result.Transactions, &RPCSnapshotTransaction{
+			RPCTransaction: NewRPCPendingTransaction(stx.Tx, curHeader, config),
+			EffectiveTip:   (*hexutil.Big)(stx.Tip),
+			Authorities:    stx.Authorities,
+			Sidecar:        stx.Sidecar,
+		})
+	}
+	return result, nil
+}
+
+// RPCStuckTransaction explains why a pooled transaction is not included.
+type RPCStuckTransaction struct {
+	Hash    common.Hash          `json:"hash"`
+	Nonce   hexutil.Uint64       `json:"nonce"`
+	Pending bool                 `json:"pending"`
+	Cost    *hexutil.Big         `json:"cumulativeCost"`
+	Reasons []txpool.StuckReason `json:"reasons"`
+}
+
+// RPCNonceGap is a range of nonces missing from the pool, both ends inclusive.
+type RPCNonceGap struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// RPCSenderDiagnosis explains why the pooled transactions of a sender are stuck.
+type RPCSenderDiagnosis struct {
+	Address      common.Address         `json:"address"`
+	StateNonce   hexutil.Uint64         `json:"stateNonce"`
+	Balance      *hexutil.Big           `json:"balance"`
+	BaseFee      *hexutil.Big           `json:"baseFee,omitempty"`
+	MinTip       *hexutil.Big           `json:"minTip"`
+	Gaps         []RPCNonceGap          `json:"gaps"`
+	Transactions []*RPCStuckTransaction `json:"transactions"`
+}
+
+// Diagnose reports the nonce gaps of an account and the reasons each of its
+// pooled transactions is not includable in the next block. Nil is returned if
+// the pool holds no transactions of the account.
+func (api *TxPoolAPI) Diagnose(addr common.Address) *RPCSenderDiagnosis {
+	diag := api.b.TxPoolDiagnose(addr)
+	if diag == nil {
+		return nil
+	}
+	result := &RPCSenderDiagnosis{
+		Address:      diag.Address,
+		StateNonce:   hexutil.Uint64(diag.StateNonce),
+		Balance:      (*hexutil.Big)(diag.Balance),
+		BaseFee:      (*hexutil.Big)(diag.BaseFee),
+		MinTip:       (*hexutil.Big)(diag.MinTip),
+		Gaps:         make([]RPCNonceGap, 0, len(diag.Gaps)),
+		Transactions: make([]*RPCStuckTransaction, 0, len(diag.Txs)),
+	}
+	for _, gap := range diag.Gaps {
+		result.Gaps = append(result.Gaps, RPCNonceGap{From: hexutil.Uint64(gap.From), To: hexutil.Uint64(gap.To)})
+	}
+	for _, entry := range diag.Txs {
+		reasons := entry.Reasons
+		if reasons == nil {
+			reasons = []txpool.StuckReason{}
+		}
+		result.Transactions = append(result.Transactions, &RPCStuckTransaction{
+			Hash:    entry.Tx.Hash(),
+			Nonce:   hexutil.Uint64(entry.Tx.Nonce()),
+			Pending: entry.Pending,
+			Cost:    (*hexutil.Big)(entry.Cost),
+			Reasons: reasons,
+		})
+	}
+	return result
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (api *TxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := api.b.Stats()
@@ -733,7 +851,7 @@ func (api *BlockChainAPI) GetBlobSidecars(ctx context.Context, blockNrOrHash rpc
 	}
 	result := make([]map[string]interface{}, len(blobSidecars))
 	for i, sidecar := range blobSidecars {
-		result[i] = marshalBlobSidecar(sidecar, showBlob)
+		result[i] = RPCMarshalBlobSidecar(sidecar, showBlob)
 	}
 	return result, nil
 }
@@ -759,7 +877,7 @@ func (api *BlockChainAPI) GetBlobSidecarByTxHash(ctx context.Context, hash commo
 	}
 	for _, sidecar := range blobSidecars {
 		if sidecar.TxIndex == Index {
-			return marshalBlobSidecar(sidecar, showBlob), nil
+			return RPCMarshalBlobSidecar(sidecar, showBlob), nil
 		}
 	}
 
@@ -1895,7 +2013,9 @@ func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber u
 	return fields
 }
 
-func marshalBlobSidecar(sidecar *types.BlobSidecar, fullBlob bool) map[string]interface{} {
+// RPCMarshalBlobSidecar converts the given blob sidecar to the RPC output,
+// the blobs being replaced by their leading 32 bytes unless fullBlob is set.
+func RPCMarshalBlobSidecar(sidecar *types.BlobSidecar, fullBlob bool) map[string]interface{} {
 	fields := map[string]interface{}{
 		"blockHash":   sidecar.BlockHash,
 		"blockNumber": hexutil.EncodeUint64(sidecar.BlockNumber.Uint64()),
@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol field types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftEncoder serializes the parquet metadata structures using the thrift
+// compact protocol. Only the subset of the protocol needed by the writer is
+// implemented.
+type thriftEncoder struct {
+	buf    []byte
+	last   int16   // Id of the last field written in the current struct
+	parent []int16 // Ids of the last fields written in the enclosing structs
+}
+
+func (e *thriftEncoder) varint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *thriftEncoder) zigzag(v int64) {
+	e.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *thriftEncoder) field(id int16, kind byte) {
+	if delta := id - e.last; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|kind)
+	} else {
+		e.buf = append(e.buf, kind)
+		e.zigzag(int64(id))
+	}
+	e.last = id
+}
+
+func (e *thriftEncoder) i32(id int16, v int32) {
+	e.field(id, thriftI32)
+	e.zigzag(int64(v))
+}
+
+func (e *thriftEncoder) i64(id int16, v int64) {
+	e.field(id, thriftI64)
+	e.zigzag(v)
+}
+
+func (e *thriftEncoder) binary(id int16, v []byte) {
+	e.field(id, thriftBinary)
+	e.varint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *thriftEncoder) string(id int16, v string) {
+	e.binary(id, []byte(v))
+}
+
+// list starts a list field of the given size and element type. The elements
+// are expected to follow directly.
+func (e *thriftEncoder) list(id int16, kind byte, size int) {
+	e.field(id, thriftList)
+	if size < 15 {
+		e.buf = append(e.buf, byte(size)<<4|kind)
+	} else {
+		e.buf = append(e.buf, 0xf0|kind)
+		e.varint(uint64(size))
+	}
+}
+
+// listI32 writes a list element of type i32.
+func (e *thriftEncoder) listI32(v int32) {
+	e.zigzag(int64(v))
+}
+
+// listString writes a list element of type string.
+func (e *thriftEncoder) listString(v string) {
+	e.varint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// structField starts a struct field, to be ended by end.
+func (e *thriftEncoder) structField(id int16) {
+	e.field(id, thriftStruct)
+	e.begin()
+}
+
+// begin starts a struct, either a list element or a field.
+func (e *thriftEncoder) begin() {
+	e.parent = append(e.parent, e.last)
+	e.last = 0
+}
+
+// end terminates the current struct.
+func (e *thriftEncoder) end() {
+	e.buf = append(e.buf, 0)
+	e.last = e.parent[len(e.parent)-1]
+	e.parent = e.parent[:len(e.parent)-1]
+}
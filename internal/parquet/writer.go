@@ -0,0 +1,409 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package parquet implements a minimal writer of Apache Parquet files.
+//
+// Only flat schemas are supported. Every column chunk is written as a single
+// plain encoded, snappy compressed data page. The format is described at
+// https://parquet.apache.org/docs/file-format/.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/golang/snappy"
+)
+
+const (
+	magic = "PAR1"
+
+	// DefaultRowGroupSize is the number of rows buffered into a row group before
+	// it is written out.
+	DefaultRowGroupSize = 65536
+
+	encodingPlain = 0
+	encodingRLE   = 3
+	codecSnappy   = 1
+	pageTypeData  = 0
+	convertedUTF8 = 0
+	repRequired   = 0
+	repOptional   = 1
+)
+
+// Type is the physical type of a column.
+type Type int32
+
+const (
+	Boolean           Type = 0
+	Int32             Type = 1
+	Int64             Type = 2
+	ByteArray         Type = 6
+	FixedLenByteArray Type = 7
+)
+
+// Column describes a column of the schema.
+type Column struct {
+	Name     string
+	Type     Type
+	Length   int  // Size of FixedLenByteArray values
+	Optional bool // Whether the column accepts nil values
+	UTF8     bool // Whether the ByteArray values are strings
+}
+
+// columnChunk is the position and size of a written column chunk.
+type columnChunk struct {
+	offset       int64
+	values       int64
+	uncompressed int64
+	compressed   int64
+}
+
+// rowGroup is a written row group.
+type rowGroup struct {
+	rows   int64
+	size   int64
+	chunks []columnChunk
+}
+
+// Writer writes rows into a parquet file.
+type Writer struct {
+	out      io.Writer
+	offset   int64
+	columns  []Column
+	metadata map[string]string
+
+	values   [][]any // Buffered values, per column
+	buffered int     // Number of buffered rows
+	groups   []rowGroup
+	rows     int64
+	closed   bool
+}
+
+// NewWriter creates a parquet writer with the given schema, storing the given
+// key-value metadata in the file footer.
+func NewWriter(out io.Writer, columns []Column, metadata map[string]string) (*Writer, error) {
+	names := make(map[string]bool)
+	for _, column := range columns {
+		if names[column.Name] {
+			return nil, fmt.Errorf("duplicate column %q", column.Name)
+		}
+		names[column.Name] = true
+		if column.Type == FixedLenByteArray && column.Length <= 0 {
+			return nil, fmt.Errorf("column %q: invalid length %d", column.Name, column.Length)
+		}
+	}
+	w := &Writer{
+		out:      out,
+		columns:  columns,
+		metadata: metadata,
+		values:   make([][]any, len(columns)),
+	}
+	if err := w.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Rows returns the number of rows written.
+func (w *Writer) Rows() int64 {
+	return w.rows + int64(w.buffered)
+}
+
+// Write appends a row, with one value per column. Accepted values are bool
+// for Boolean, int32 for Int32, int64 or uint64 for Int64 and []byte or string
+// for byte array columns. Optional columns also accept nil.
+func (w *Writer) Write(row ...any) error {
+	if w.closed {
+		return errors.New("parquet writer closed")
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values, schema has %d columns", len(row), len(w.columns))
+	}
+	for i, value := range row {
+		if err := w.columns[i].check(value); err != nil {
+			return err
+		}
+	}
+	for i, value := range row {
+		w.values[i] = append(w.values[i], value)
+	}
+	w.buffered++
+	if w.buffered >= DefaultRowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// check verifies that the value can be stored in the column.
+func (c *Column) check(value any) error {
+	if value == nil {
+		if !c.Optional {
+			return fmt.Errorf("column %q: nil value for required column", c.Name)
+		}
+		return nil
+	}
+	var ok bool
+	switch c.Type {
+	case Boolean:
+		_, ok = value.(bool)
+	case Int32:
+		_, ok = value.(int32)
+	case Int64:
+		switch value.(type) {
+		case int64, uint64:
+			ok = true
+		}
+	case ByteArray:
+		switch value.(type) {
+		case []byte, string:
+			ok = true
+		}
+	case FixedLenByteArray:
+		if v, isBytes := value.([]byte); isBytes {
+			if len(v) != c.Length {
+				return fmt.Errorf("column %q: value length %d, want %d", c.Name, len(v), c.Length)
+			}
+			ok = true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("column %q: invalid value type %T", c.Name, value)
+	}
+	return nil
+}
+
+// Flush writes the buffered rows out as a row group.
+func (w *Writer) Flush() error {
+	if w.buffered == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(w.buffered)}
+	for i := range w.columns {
+		chunk, err := w.writeChunk(&w.columns[i], w.values[i])
+		if err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.uncompressed
+		w.values[i] = w.values[i][:0]
+	}
+	w.groups = append(w.groups, group)
+	w.rows += group.rows
+	w.buffered = 0
+	return nil
+}
+
+// writeChunk writes the values of a column as a single data page.
+func (w *Writer) writeChunk(column *Column, values []any) (columnChunk, error) {
+	var page []byte
+	if column.Optional {
+		levels := encodeLevels(values)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+	page = column.encode(page, values)
+	compressed := snappy.Encode(nil, page)
+
+	var header thriftEncoder
+	header.i32(1, pageTypeData)
+	header.i32(2, int32(len(page)))
+	header.i32(3, int32(len(compressed)))
+	header.structField(5)
+	header.i32(1, int32(len(values)))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.end()
+	header.buf = append(header.buf, 0)
+
+	chunk := columnChunk{
+		offset:       w.offset,
+		values:       int64(len(values)),
+		uncompressed: int64(len(header.buf) + len(page)),
+		compressed:   int64(len(header.buf) + len(compressed)),
+	}
+	if err := w.write(header.buf); err != nil {
+		return columnChunk{}, err
+	}
+	if err := w.write(compressed); err != nil {
+		return columnChunk{}, err
+	}
+	return chunk, nil
+}
+
+// encode appends the plain encoding of the non-nil values to buf.
+func (c *Column) encode(buf []byte, values []any) []byte {
+	var bits, nbits int
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case bool:
+			// Booleans are bit-packed, least significant bit first
+			if v {
+				bits |= 1 << nbits
+			}
+			if nbits++; nbits == 8 {
+				buf = append(buf, byte(bits))
+				bits, nbits = 0, 0
+			}
+		case int32:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(v))
+		case int64:
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+		case uint64:
+			buf = binary.LittleEndian.AppendUint64(buf, v)
+		case string:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(v)))
+			buf = append(buf, v...)
+		case []byte:
+			if c.Type == ByteArray {
+				buf = binary.LittleEndian.AppendUint32(buf, uint32(len(v)))
+			}
+			buf = append(buf, v...)
+		}
+	}
+	if nbits > 0 {
+		buf = append(buf, byte(bits))
+	}
+	return buf
+}
+
+// encodeLevels encodes the definition levels of an optional column, using the
+// run-length encoded flavour of the RLE/bit-packing hybrid with a bit width
+// of one.
+func encodeLevels(values []any) []byte {
+	var buf []byte
+	for i := 0; i < len(values); {
+		defined := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == defined {
+			run++
+		}
+		buf = binary.AppendUvarint(buf, uint64(run)<<1)
+		if defined {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		i += run
+	}
+	return buf
+}
+
+// Close flushes the buffered rows and writes the file footer. The underlying
+// writer is not closed.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return w.write(footer)
+}
+
+// footer encodes the file metadata.
+func (w *Writer) footer() []byte {
+	var e thriftEncoder
+	e.i32(1, 1)
+
+	// The schema is a flattened tree, with the root element followed by the
+	// columns as its children
+	e.list(2, thriftStruct, len(w.columns)+1)
+	e.begin()
+	e.string(4, "schema")
+	e.i32(5, int32(len(w.columns)))
+	e.end()
+	for _, column := range w.columns {
+		e.begin()
+		e.i32(1, int32(column.Type))
+		if column.Type == FixedLenByteArray {
+			e.i32(2, int32(column.Length))
+		}
+		if column.Optional {
+			e.i32(3, repOptional)
+		} else {
+			e.i32(3, repRequired)
+		}
+		e.string(4, column.Name)
+		if column.UTF8 {
+			e.i32(6, convertedUTF8)
+		}
+		e.end()
+	}
+	e.i64(3, w.rows)
+
+	e.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		e.begin()
+		e.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			e.begin()
+			e.i64(2, chunk.offset)
+			e.structField(3)
+			e.i32(1, int32(w.columns[i].Type))
+			e.list(2, thriftI32, 2)
+			e.listI32(encodingPlain)
+			e.listI32(encodingRLE)
+			e.list(3, thriftBinary, 1)
+			e.listString(w.columns[i].Name)
+			e.i32(4, codecSnappy)
+			e.i64(5, chunk.values)
+			e.i64(6, chunk.uncompressed)
+			e.i64(7, chunk.compressed)
+			e.i64(9, chunk.offset)
+			e.end()
+			e.end()
+		}
+		e.i64(2, group.size)
+		e.i64(3, group.rows)
+		e.end()
+	}
+	if len(w.metadata) > 0 {
+		keys := make([]string, 0, len(w.metadata))
+		for key := range w.metadata {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		e.list(5, thriftStruct, len(keys))
+		for _, key := range keys {
+			e.begin()
+			e.string(1, key)
+			e.string(2, w.metadata[key])
+			e.end()
+		}
+	}
+	e.string(6, "go-ethereum")
+	e.buf = append(e.buf, 0)
+	return e.buf
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	return err
+}
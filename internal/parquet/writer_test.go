@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/snappy"
+)
+
+// thriftDecoder decodes thrift compact protocol structs into maps keyed by
+// field id, to inspect the written metadata.
+type thriftDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *thriftDecoder) varint() uint64 {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	d.pos += n
+	return v
+}
+
+func (d *thriftDecoder) zigzag() int64 {
+	v := d.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *thriftDecoder) value(kind byte) any {
+	switch kind {
+	case thriftI32, thriftI64:
+		return d.zigzag()
+	case thriftBinary:
+		size := int(d.varint())
+		d.pos += size
+		return string(d.buf[d.pos-size : d.pos])
+	case thriftList:
+		header := d.buf[d.pos]
+		d.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.varint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = d.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return d.decodeStruct()
+	}
+	panic("unsupported thrift type")
+}
+
+func (d *thriftDecoder) decodeStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := d.buf[d.pos]
+		d.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(d.zigzag())
+		}
+		fields[id] = d.value(header & 0x0f)
+		last = id
+	}
+}
+
+// Tests that the written file is laid out as specified, with the footer and
+// pages decoding back to the written values.
+func TestWriter(t *testing.T) {
+	var (
+		buf     bytes.Buffer
+		columns = []Column{
+			{Name: "number", Type: Int64},
+			{Name: "hash", Type: FixedLenByteArray, Length: 2},
+			{Name: "name", Type: ByteArray, UTF8: true},
+			{Name: "flag", Type: Boolean},
+			{Name: "extra", Type: Int32, Optional: true},
+		}
+	)
+	w, err := NewWriter(&buf, columns, map[string]string{"version": "1"})
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	rows := [][]any{
+		{int64(1), []byte{0x1, 0x2}, "one", true, int32(7)},
+		{uint64(2), []byte{0x3, 0x4}, []byte("two"), false, nil},
+		{int64(3), []byte{0x5, 0x6}, "three", true, int32(9)},
+	}
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			t.Fatalf("failed to write row: %v", err)
+		}
+	}
+	for _, row := range [][]any{
+		{int64(4)},
+		{int64(4), []byte{0x1}, "four", true, nil},
+		{int64(4), []byte{0x1, 0x2}, nil, true, nil},
+		{int32(4), []byte{0x1, 0x2}, "four", true, nil},
+	} {
+		if err := w.Write(row...); err == nil {
+			t.Errorf("invalid row accepted: %v", row)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, []byte(magic)) || !bytes.HasSuffix(file, []byte(magic)) {
+		t.Fatalf("magic missing")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := (&thriftDecoder{buf: file[len(file)-8-size : len(file)-8]}).decodeStruct()
+
+	if footer[3] != int64(len(rows)) {
+		t.Errorf("row count mismatch: have %v, want %d", footer[3], len(rows))
+	}
+	schema := footer[2].([]any)
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("schema size mismatch: have %d, want %d", len(schema), len(columns)+1)
+	}
+	for i, column := range columns {
+		if name := schema[i+1].(map[int16]any)[4]; name != column.Name {
+			t.Errorf("column %d name mismatch: have %v, want %s", i, name, column.Name)
+		}
+	}
+	if kv := footer[5].([]any)[0].(map[int16]any); kv[1] != "version" || kv[2] != "1" {
+		t.Errorf("metadata mismatch: %v", kv)
+	}
+	// Decode the page of the optional column
+	group := footer[4].([]any)[0].(map[int16]any)
+	meta := group[1].([]any)[4].(map[int16]any)[3].(map[int16]any)
+
+	d := &thriftDecoder{buf: file, pos: int(meta[9].(int64))}
+	header := d.decodeStruct()
+	page, err := snappy.Decode(nil, file[d.pos:d.pos+int(header[3].(int64))])
+	if err != nil {
+		t.Fatalf("failed to decompress page: %v", err)
+	}
+	levels := binary.LittleEndian.Uint32(page)
+	if want := []byte{2, 1, 2, 0, 2, 1}; !bytes.Equal(page[4:4+levels], want) {
+		t.Errorf("definition levels mismatch: have %x, want %x", page[4:4+levels], want)
+	}
+	if want := []byte{7, 0, 0, 0, 9, 0, 0, 0}; !bytes.Equal(page[4+levels:], want) {
+		t.Errorf("values mismatch: have %x, want %x", page[4+levels:], want)
+	}
+}
@@ -406,6 +406,11 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getFutureBlocks',
+			call: 'debug_getFutureBlocks',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -423,6 +428,38 @@ web3._extend({
 			params: 2,
 			inputFormatter:[null, null],
 		}),
+		new web3._extend.Method({
+			name: 'getHusks',
+			call: 'debug_getHusks',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
+		new web3._extend.Method({
+			name: 'getBlockAccessList',
+			call: 'debug_getBlockAccessList',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'verifyBlockAccessList',
+			call: 'debug_verifyBlockAccessList',
+			params: 2,
+		}),
+		new web3._extend.Method({
+			name: 'getEquivocations',
+			call: 'debug_getEquivocations',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
+		new web3._extend.Method({
+			name: 'importProgress',
+			call: 'debug_importProgress',
+			params: 1,
+			inputFormatter: [null],
+		}),
+		new web3._extend.Method({
+			name: 'chainLimits',
+			call: 'debug_chainLimits',
+		}),
 		new web3._extend.Method({
 			name: 'freezeClient',
 			call: 'debug_freezeClient',
@@ -736,6 +773,17 @@ web3._extend({
 			call: 'txpool_contentFrom',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'snapshot',
+			call: 'txpool_snapshot',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal],
+		}),
+		new web3._extend.Method({
+			name: 'diagnose',
+			call: 'txpool_diagnose',
+			params: 1,
+		}),
 	]
 });
 `
@@ -691,7 +691,7 @@ func (w *worker) makeEnv(parent *types.Header, header *types.Header, coinbase co
 	prevEnv *environment, witness bool) (*environment, error) {
 	// Retrieve the parent state to execute on top and start a prefetcher for
 	// the miner to speed block sealing up a bit
-	state, err := w.chain.StateAt(parent.Root)
+	state, err := w.chain.StateAtParent(parent, header)
 	if err != nil {
 		return nil, err
 	}
@@ -736,7 +736,7 @@ func (w *worker) updateSnapshot(env *environment) {
 		env.header,
 		&body,
 		env.receipts,
-		trie.NewStackTrie(nil),
+		trie.NewRootHasher(w.chainConfig),
 	)
 	w.snapshotReceipts = copyReceipts(env.receipts)
 	w.snapshotState = env.state.Copy()
@@ -934,6 +934,12 @@ LOOP:
 			txs.Pop()
 			continue
 		}
+		// Skip transactions of addresses filtered since their pool admission
+		if err := w.chain.CheckTransactionAddresses(env.header, tx); err != nil {
+			log.Trace("Ignoring filtered transaction", "hash", ltx.Hash, "err", err)
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 
@@ -1037,6 +1043,10 @@ func (w *worker) prepareWork(genParams *generateParams, witness bool) (*environm
 			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
 		}
 	}
+	// The gas limit schedule overrides the configured gas ceiling
+	if limit, ok := w.chainConfig.ScheduledGasLimit(header.Number); ok {
+		header.GasLimit = limit
+	}
 	// Run the consensus preparation with the default or customized consensus engine.
 	// Note that the `header.Time` may be changed.
 	if err := w.engine.Prepare(w.chain, header); err != nil {
@@ -1167,14 +1177,14 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 }
 
 // generateWork generates a sealing block based on the given parameters.
-func (w *worker) generateWork(params *generateParams, witness bool) *newPayloadResult {
-	work, err := w.prepareWork(params, witness)
+func (w *worker) generateWork(genParams *generateParams, witness bool) *newPayloadResult {
+	work, err := w.prepareWork(genParams, witness)
 	if err != nil {
 		return &newPayloadResult{err: err}
 	}
 	defer work.discard()
 
-	if !params.noTxs {
+	if !genParams.noTxs {
 		interrupt := new(atomic.Int32)
 		timer := time.AfterFunc(*w.config.Recommit, func() {
 			interrupt.Store(commitInterruptTimeout)
@@ -1186,7 +1196,7 @@ func (w *worker) generateWork(params *generateParams, witness bool) *newPayloadR
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.recommit))
 		}
 	}
-	body := types.Body{Transactions: work.txs, Withdrawals: params.withdrawals}
+	body := types.Body{Transactions: work.txs, Withdrawals: genParams.withdrawals}
 	allLogs := make([]*types.Log, 0)
 	for _, r := range work.receipts {
 		allLogs = append(allLogs, r.Logs...)
@@ -1209,6 +1219,11 @@ func (w *worker) generateWork(params *generateParams, witness bool) *newPayloadR
 		work.header.RequestsHash = &reqHash
 	}
 
+	if w.chainConfig.IsVerkle(work.header.Number, work.header.Time) {
+		if err := work.state.ConvertToVerkle(params.VerkleConversionStride); err != nil {
+			return &newPayloadResult{err: err}
+		}
+	}
 	fees := work.state.GetBalance(consensus.SystemAddress)
 	block, receipts, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, &body, work.receipts, nil)
 	if err != nil {
@@ -1486,6 +1501,11 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		if env.header.EmptyWithdrawalsHash() {
 			body.Withdrawals = make([]*types.Withdrawal, 0)
 		}
+		if w.chainConfig.IsVerkle(env.header.Number, env.header.Time) {
+			if err := env.state.ConvertToVerkle(params.VerkleConversionStride); err != nil {
+				return err
+			}
+		}
 		block, receipts, err := w.engine.FinalizeAndAssemble(w.chain, types.CopyHeader(env.header), env.state, &body, env.receipts, nil)
 		if err != nil {
 			return err
@@ -24,8 +24,10 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -64,6 +66,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DataDirLockTimeout is the time to wait for another process to release the
+	// lock of the data directory before failing. Zero fails immediately.
+	DataDirLockTimeout time.Duration `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -252,6 +258,14 @@ type Config struct {
 
 	DBEngine string `toml:",omitempty"`
 
+	// DBKeyspaces partitions the data of newly created pebble databases into
+	// separately compacted and cached keyspaces.
+	DBKeyspaces bool `toml:",omitempty"`
+
+	// DBRemoteAncient offloads the sealed data files of the chain freezer into
+	// an object store, nil keeps them on the local disk.
+	DBRemoteAncient *rawdb.RemoteFreezerConfig `toml:"-"`
+
 	Instance int `toml:",omitempty"`
 }
 
@@ -36,6 +36,8 @@ type openOptions struct {
 	Cache             int    // the capacity(in megabytes) of the data caching
 	Handles           int    // number of files to be open simultaneously
 	ReadOnly          bool
+	Keyspaces         bool                       // whether to partition the data of a new database into keyspaces
+	Remote            *rawdb.RemoteFreezerConfig // object store to offload the sealed ancient files into, if any
 
 	DisableFreeze bool
 	MultiDataBase bool
@@ -54,7 +56,7 @@ func openDatabase(o openOptions) (ethdb.Database, error) {
 	if len(o.AncientsDirectory) == 0 {
 		return kvdb, nil
 	}
-	frdb, err := rawdb.NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly, o.DisableFreeze, o.MultiDataBase)
+	frdb, err := rawdb.NewDatabaseWithRemoteFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly, o.DisableFreeze, o.MultiDataBase, o.Remote)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -81,7 +83,7 @@ func openKeyValueDatabase(o openOptions) (ethdb.Database, error) {
 	}
 	if o.Type == rawdb.DBPebble || existingDb == rawdb.DBPebble {
 		log.Info("Using pebble as the backing database")
-		return newPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+		return newPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Keyspaces)
 	}
 	if o.Type == rawdb.DBLeveldb || existingDb == rawdb.DBLeveldb {
 		log.Info("Using leveldb as the backing database")
@@ -89,7 +91,7 @@ func openKeyValueDatabase(o openOptions) (ethdb.Database, error) {
 	}
 	// No pre-existing database, no user-requested one either. Default to Pebble.
 	log.Info("Defaulting to pebble as the backing database")
-	return newPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+	return newPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Keyspaces)
 }
 
 // newLevelDBDatabase creates a persistent key-value database without a freezer
@@ -97,7 +99,7 @@ func openKeyValueDatabase(o openOptions) (ethdb.Database, error) {
 func newLevelDBDatabase(file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
 	db, err := leveldb.New(file, cache, handles, namespace, readonly)
 	if err != nil {
-		return nil, err
+		return nil, convertDatabaseLockError(file, err)
 	}
 	log.Info("Using LevelDB as the backing database")
 	return rawdb.NewDatabase(db), nil
@@ -105,10 +107,19 @@ func newLevelDBDatabase(file string, cache int, handles int, namespace string, r
 
 // newPebbleDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
-func newPebbleDBDatabase(file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
+func newPebbleDBDatabase(file string, cache int, handles int, namespace string, readonly bool, keyspaces bool) (ethdb.Database, error) {
+	// Split the budget evenly between the default keyspace and the others
+	if keyspaces || pebble.HasKeyspaces(file) {
+		cache, handles = cache/(rawdb.KeyspaceCount+1), handles/(rawdb.KeyspaceCount+1)
+	}
 	db, err := pebble.New(file, cache, handles, namespace, readonly)
 	if err != nil {
+		return nil, convertDatabaseLockError(file, err)
+	}
+	store, err := rawdb.NewKeyspacedStore(db, keyspaces)
+	if err != nil {
+		db.Close()
 		return nil, err
 	}
-	return rawdb.NewDatabase(db), nil
+	return rawdb.NewDatabase(store), nil
 }
@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gofrs/flock"
+)
+
+const (
+	datadirLock      = "LOCK"       // Path within the instance directory to the lock file
+	datadirLockOwner = "LOCK.owner" // Path within the instance directory to the lock holder record
+
+	dirLockRetry = 500 * time.Millisecond // Interval between lock attempts when waiting
+)
+
+// DatadirLockError is returned if the instance directory is locked by another
+// process. It unwraps to ErrDatadirUsed.
+type DatadirLockError struct {
+	Path  string    // Path of the lock file
+	PID   int       // Process holding the lock, 0 if unknown
+	Host  string    // Host of the process holding the lock
+	Since time.Time // Time the lock was acquired
+}
+
+// Error implements error.
+func (e *DatadirLockError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%v: %s is locked by an unknown process", ErrDatadirUsed, e.Path)
+	}
+	return fmt.Sprintf("%v: %s is locked by process %d on %s since %v", ErrDatadirUsed, e.Path, e.PID, e.Host, e.Since.Format(time.RFC3339))
+}
+
+// Unwrap returns ErrDatadirUsed, so the error matches it with errors.Is.
+func (e *DatadirLockError) Unwrap() error {
+	return ErrDatadirUsed
+}
+
+// dirLockOwner is the record of the process holding the instance directory
+// lock, kept next to the lock file.
+type dirLockOwner struct {
+	PID   int       `json:"pid"`
+	Host  string    `json:"host"`
+	Since time.Time `json:"since"`
+}
+
+// stale reports whether the recorded owner is known to be gone: it ran on this
+// host, but its process does not exist anymore. Owners on other hosts, e.g. of
+// a datadir on a network share, are never considered stale.
+func (o *dirLockOwner) stale() bool {
+	host, err := os.Hostname()
+	if err != nil || host != o.Host || o.PID == os.Getpid() {
+		return false
+	}
+	return !processAlive(o.PID)
+}
+
+// lockDataDir acquires the lock of the instance directory, waiting at most the
+// given time for another process to release it.
+//
+// Advisory locks are released by the kernel when their holder exits, but stay
+// held as long as any inherited descriptor of the lock file is open. A held lock
+// is thus never broken, even if its recorded owner is dead, only reported.
+func lockDataDir(dir string, timeout time.Duration, logger log.Logger) (*flock.Flock, error) {
+	path := filepath.Join(dir, datadirLock)
+	lock := flock.New(path)
+
+	locked, err := tryLockDataDir(lock, timeout, logger)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	owner := readDirLockOwner(dir)
+	if !locked {
+		lock.Close()
+		if owner != nil && owner.stale() {
+			logger.Warn("Datadir lock held after its recorded owner exited, check for processes inheriting it", "path", path, "pid", owner.PID, "since", owner.Since)
+		}
+		return nil, newDatadirLockError(path, owner)
+	}
+	if owner != nil {
+		logger.Info("Previous instance did not release the datadir lock", "pid", owner.PID, "host", owner.Host, "since", owner.Since)
+	}
+	if err := writeDirLockOwner(dir); err != nil {
+		logger.Warn("Failed to record datadir lock owner", "err", err)
+	}
+	return lock, nil
+}
+
+// tryLockDataDir attempts to take the lock, retrying until the timeout if it
+// is held by another process.
+func tryLockDataDir(lock *flock.Flock, timeout time.Duration, logger log.Logger) (bool, error) {
+	if locked, err := lock.TryLock(); err != nil || locked || timeout <= 0 {
+		return locked, err
+	}
+	logger.Info("Waiting for datadir lock", "path", lock.Path(), "timeout", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	locked, err := lock.TryLockContext(ctx, dirLockRetry)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, nil
+	}
+	return locked, err
+}
+
+// unlockDataDir releases the lock of the instance directory, dropping the
+// owner record first so it is never left behind for a live lock.
+func unlockDataDir(dir string, lock *flock.Flock) {
+	os.Remove(filepath.Join(dir, datadirLockOwner))
+	lock.Unlock()
+}
+
+// newDatadirLockError creates the error reporting the holder of a lock.
+func newDatadirLockError(path string, owner *dirLockOwner) error {
+	err := &DatadirLockError{Path: path}
+	if owner != nil {
+		err.PID, err.Host, err.Since = owner.PID, owner.Host, owner.Since
+	}
+	return err
+}
+
+// readDirLockOwner loads the recorded holder of the instance directory lock,
+// or nil if there is no valid record.
+func readDirLockOwner(dir string) *dirLockOwner {
+	blob, err := os.ReadFile(filepath.Join(dir, datadirLockOwner))
+	if err != nil {
+		return nil
+	}
+	owner := new(dirLockOwner)
+	if err := json.Unmarshal(blob, owner); err != nil || owner.PID <= 0 {
+		return nil
+	}
+	return owner
+}
+
+// writeDirLockOwner records the current process as the holder of the instance
+// directory lock.
+func writeDirLockOwner(dir string) error {
+	host, _ := os.Hostname()
+	blob, err := json.Marshal(&dirLockOwner{PID: os.Getpid(), Host: host, Since: time.Now()})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, datadirLockOwner), blob, 0600)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gofrs/flock"
+)
+
+// Tests that a held lock is never broken, even if its recorded owner is dead,
+// as the lock may have been inherited by another process.
+func TestStaleDataDirLock(t *testing.T) {
+	dir := t.TempDir()
+	held := flock.New(filepath.Join(dir, datadirLock))
+	if locked, err := held.TryLock(); err != nil || !locked {
+		t.Fatalf("failed to take lock: %v", err)
+	}
+	defer held.Unlock()
+
+	host, _ := os.Hostname()
+	record := func(pid int, host string) {
+		blob, _ := json.Marshal(&dirLockOwner{PID: pid, Host: host, Since: time.Now()})
+		if err := os.WriteFile(filepath.Join(dir, datadirLockOwner), blob, 0600); err != nil {
+			t.Fatalf("failed to write lock owner: %v", err)
+		}
+	}
+	// A dead owner on another host may be alive from its own point of view
+	record(math.MaxInt32, "elsewhere")
+	var lockErr *DatadirLockError
+	if _, err := lockDataDir(dir, 0, log.Root()); !errors.As(err, &lockErr) || lockErr.Host != "elsewhere" {
+		t.Fatalf("remote lock not reported: %v", err)
+	}
+	// A dead owner on this host may have passed the lock on to a child
+	record(math.MaxInt32, host)
+	if _, err := lockDataDir(dir, 0, log.Root()); !errors.As(err, &lockErr) || lockErr.PID != math.MaxInt32 {
+		t.Fatalf("held lock not reported: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, datadirLock)); err != nil {
+		t.Fatalf("held lock removed: %v", err)
+	}
+	// Once released, the lock is taken over and the record replaced
+	held.Unlock()
+	lock, err := lockDataDir(dir, 0, log.Root())
+	if err != nil {
+		t.Fatalf("released lock not acquired: %v", err)
+	}
+	if owner := readDirLockOwner(dir); owner == nil || owner.PID != os.Getpid() {
+		t.Errorf("lock owner not recorded: %v", owner)
+	}
+	unlockDataDir(dir, lock)
+	if owner := readDirLockOwner(dir); owner != nil {
+		t.Errorf("lock owner left after unlock: %v", owner)
+	}
+}
+
+// Tests that the lock is acquired once released within the wait timeout.
+func TestDataDirLockTimeout(t *testing.T) {
+	dir := t.TempDir()
+	held, err := lockDataDir(dir, 0, log.Root())
+	if err != nil {
+		t.Fatalf("failed to take lock: %v", err)
+	}
+	if _, err := lockDataDir(dir, 100*time.Millisecond, log.Root()); !errors.Is(err, ErrDatadirUsed) {
+		t.Fatalf("held lock acquired: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { unlockDataDir(dir, held) })
+
+	lock, err := lockDataDir(dir, 5*time.Second, log.Root())
+	if err != nil {
+		t.Fatalf("released lock not acquired: %v", err)
+	}
+	unlockDataDir(dir, lock)
+}
@@ -40,6 +40,16 @@ func convertFileLockError(err error) error {
 	return err
 }
 
+// convertDatabaseLockError replaces the lock failures of the database engines,
+// which only carry the errno, with an error naming the database in use.
+func convertDatabaseLockError(path string, err error) error {
+	var errno syscall.Errno
+	if errors.As(err, &errno) && datadirInUseErrnos[uint(errno)] {
+		return fmt.Errorf("%w: database %s is locked: %v", ErrDatadirUsed, path, err)
+	}
+	return err
+}
+
 // StopError is returned if a Node fails to stop either any of its registered
 // services or itself.
 type StopError struct {
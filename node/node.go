@@ -355,20 +355,18 @@ func (n *Node) openDataDir() error {
 	}
 	// Lock the instance directory to prevent concurrent use by another instance as well as
 	// accidental use of the instance directory as a database.
-	n.dirLock = flock.New(filepath.Join(instdir, "LOCK"))
-
-	if locked, err := n.dirLock.TryLock(); err != nil {
+	lock, err := lockDataDir(instdir, n.config.DataDirLockTimeout, n.log)
+	if err != nil {
 		return err
-	} else if !locked {
-		return ErrDatadirUsed
 	}
+	n.dirLock = lock
 	return nil
 }
 
 func (n *Node) closeDataDir() {
 	// Release instance directory lock.
 	if n.dirLock != nil && n.dirLock.Locked() {
-		n.dirLock.Unlock()
+		unlockDataDir(filepath.Dir(n.dirLock.Path()), n.dirLock)
 		n.dirLock = nil
 	}
 }
@@ -761,6 +759,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string, r
 			Cache:         cache,
 			Handles:       handles,
 			ReadOnly:      readonly,
+			Keyspaces:     n.config.DBKeyspaces,
 			MultiDataBase: n.CheckIfMultiDataBase(),
 		})
 	}
@@ -827,6 +826,13 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient,
 	if n.config.DataDir == "" {
 		db, err = rawdb.NewDatabaseWithFreezer(memorydb.New(), "", namespace, readonly, disableFreeze, false)
 	} else {
+		// Keep the offloaded files of the different databases apart
+		var remote *rawdb.RemoteFreezerConfig
+		if n.config.DBRemoteAncient != nil {
+			config := *n.config.DBRemoteAncient
+			config.Prefix = path.Join(config.Prefix, name)
+			remote = &config
+		}
 		db, err = openDatabase(openOptions{
 			Type:              n.config.DBEngine,
 			Directory:         n.ResolvePath(name),
@@ -835,6 +841,8 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient,
 			Cache:             cache,
 			Handles:           handles,
 			ReadOnly:          readonly,
+			Keyspaces:         n.config.DBKeyspaces,
+			Remote:            remote,
 			DisableFreeze:     disableFreeze,
 		})
 	}
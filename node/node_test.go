@@ -22,6 +22,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"reflect"
 	"slices"
 	"strings"
@@ -101,9 +102,13 @@ func TestNodeUsedDataDir(t *testing.T) {
 
 	// Create a second node based on the same data directory and ensure failure
 	_, err = New(&Config{DataDir: dir})
-	if err != ErrDatadirUsed {
+	if !errors.Is(err, ErrDatadirUsed) {
 		t.Fatalf("duplicate datadir failure mismatch: have %v, want %v", err, ErrDatadirUsed)
 	}
+	var lockErr *DatadirLockError
+	if !errors.As(err, &lockErr) || lockErr.PID != os.Getpid() {
+		t.Fatalf("lock holder not reported: %v", err)
+	}
 }
 
 // Tests whether a Lifecycle can be registered.
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params/forks"
@@ -650,6 +651,90 @@ type ChainConfig struct {
 	Clique             *CliqueConfig       `json:"clique,omitempty"`
 	Parlia             *ParliaConfig       `json:"parlia,omitempty"`
 	BlobScheduleConfig *BlobScheduleConfig `json:"blobSchedule,omitempty"`
+
+	// BlockRewards is the block reward schedule consumed by the reward paying
+	// consensus engines. If nil, the engine's built-in rewards apply.
+	BlockRewards []*BlockRewardConfig `json:"blockRewards,omitempty"`
+
+	// GasLimitSchedule is the EIP-7783 style gas limit ramp. If nil, the gas
+	// limit is adjusted by the block producers within the usual bounds.
+	GasLimitSchedule *GasLimitScheduleConfig `json:"gasLimitSchedule,omitempty"`
+
+	// AddressFilter enables rejecting transactions from and to the addresses
+	// listed by a system contract, for permissioned chains. If nil, no address
+	// is filtered.
+	AddressFilter *AddressFilterConfig `json:"addressFilter,omitempty"`
+
+	// NativeMinter enables the native minter, allowing the listed system
+	// contracts to mint and burn native balance. If nil, contracts can't alter
+	// the native supply.
+	NativeMinter *NativeMinterConfig `json:"nativeMinter,omitempty"`
+
+	// ForeignState enables the foreign state reader, verifying reads of the
+	// state of other chains against the roots registered in a system contract.
+	ForeignState *ForeignStateConfig `json:"foreignState,omitempty"`
+
+	// RootHasher selects the strategy deriving the transaction, receipt and
+	// withdrawal roots of blocks, e.g. RootHasherBinary for experimental
+	// networks. If empty, the roots are merkle patricia trie roots.
+	RootHasher string `json:"rootHasher,omitempty"`
+}
+
+// Root hashing strategies of the chain configuration.
+const (
+	RootHasherMPT    = "mpt"    // Merkle patricia trie roots, as on mainnet
+	RootHasherBinary = "binary" // Binary merkle tree roots mixed in with the list length
+)
+
+// RootHasherName returns the name of the strategy deriving the list roots of
+// blocks, defaulting to RootHasherMPT.
+func (c *ChainConfig) RootHasherName() string {
+	if c == nil || c.RootHasher == "" {
+		return RootHasherMPT
+	}
+	return c.RootHasher
+}
+
+// NativeMinterConfig defines the system contracts allowed to call the native
+// minter at NativeMinterAddress.
+type NativeMinterConfig struct {
+	Block   *big.Int         `json:"block"`   // Block number the minter is activated at
+	Minters []common.Address `json:"minters"` // System contracts allowed to mint and burn
+}
+
+// ForeignStateConfig defines the system contract registering the state roots
+// of foreign chains, which the foreign state reader at ForeignStateReaderAddress
+// verifies proofs against. The registry keeps the roots in the mapping
+// roots[chainId][blockNumber] at storage slot 0.
+type ForeignStateConfig struct {
+	Block    *big.Int       `json:"block"`    // Block number the reader is activated at
+	Registry common.Address `json:"registry"` // System contract registering the foreign state roots
+}
+
+// AddressFilterConfig defines the system contract administering the filtered
+// addresses. The list is read once per epoch, from the state of the last epoch
+// boundary preceding a block, so all nodes enforce the same list.
+type AddressFilterConfig struct {
+	Block    *big.Int       `json:"block"`    // Block number the filter is enforced from
+	Contract common.Address `json:"contract"` // Contract exposing filteredAddresses() returns (address[])
+	Epoch    uint64         `json:"epoch"`    // Number of blocks between list refreshes
+}
+
+// GasLimitScheduleConfig defines a gas limit growing linearly by a fixed amount
+// per block from the given block onwards, until reaching the cap.
+type GasLimitScheduleConfig struct {
+	Block           *big.Int `json:"block"`           // Block number the schedule applies from
+	InitialGasLimit uint64   `json:"initialGasLimit"` // Gas limit of the first scheduled block
+	IncreaseRate    uint64   `json:"increaseRate"`    // Gas limit increase per block
+	GasLimitCap     uint64   `json:"gasLimitCap"`     // Gas limit the schedule stops growing at
+}
+
+// BlockRewardConfig is a step of the block reward schedule, defining the reward
+// paid from the given block onwards until the next step.
+type BlockRewardConfig struct {
+	Block           *big.Int `json:"block"`                     // Block number the reward applies from
+	Reward          *big.Int `json:"reward"`                    // Block reward in wei
+	HalvingInterval uint64   `json:"halvingInterval,omitempty"` // Number of blocks after which the reward halves (0 = never)
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -841,6 +926,77 @@ type BlobConfig struct {
 	UpdateFraction uint64 `json:"baseFeeUpdateFraction"`
 }
 
+// BlockReward returns the block reward defined by the reward schedule for the
+// given block number, or nil if the schedule doesn't cover the block and the
+// consensus engine's built-in rewards apply.
+func (c *ChainConfig) BlockReward(num *big.Int) *big.Int {
+	var step *BlockRewardConfig
+	for _, cur := range c.BlockRewards {
+		if isBlockForked(cur.Block, num) {
+			step = cur
+		}
+	}
+	if step == nil {
+		return nil
+	}
+	reward := new(big.Int).Set(step.Reward)
+	if step.HalvingInterval > 0 {
+		halvings := new(big.Int).Sub(num, step.Block)
+		halvings.Div(halvings, new(big.Int).SetUint64(step.HalvingInterval))
+		if !halvings.IsUint64() || halvings.Uint64() >= uint64(reward.BitLen()) {
+			return new(big.Int)
+		}
+		reward.Rsh(reward, uint(halvings.Uint64()))
+	}
+	return reward
+}
+
+// ScheduledGasLimit returns the gas limit defined by the gas limit schedule for
+// the given block number, and false if the schedule doesn't cover the block.
+func (c *ChainConfig) ScheduledGasLimit(num *big.Int) (uint64, bool) {
+	s := c.GasLimitSchedule
+	if s == nil || !isBlockForked(s.Block, num) {
+		return 0, false
+	}
+	if s.IncreaseRate == 0 {
+		return s.InitialGasLimit, true
+	}
+	elapsed := new(big.Int).Sub(num, s.Block)
+	if !elapsed.IsUint64() || elapsed.Uint64() > (s.GasLimitCap-s.InitialGasLimit)/s.IncreaseRate {
+		return s.GasLimitCap, true
+	}
+	return s.InitialGasLimit + elapsed.Uint64()*s.IncreaseRate, true
+}
+
+// IsAddressFilterEnforced returns whether the address filter is enforced on
+// the given block number.
+func (c *ChainConfig) IsAddressFilterEnforced(num *big.Int) bool {
+	return c.AddressFilter != nil && isBlockForked(c.AddressFilter.Block, num)
+}
+
+// IsNativeMinter returns whether the native minter is active at the given
+// block number.
+func (c *ChainConfig) IsNativeMinter(num *big.Int) bool {
+	return c.NativeMinter != nil && isBlockForked(c.NativeMinter.Block, num)
+}
+
+// IsForeignStateReader returns whether the foreign state reader is active at
+// the given block number.
+func (c *ChainConfig) IsForeignStateReader(num *big.Int) bool {
+	return c.ForeignState != nil && isBlockForked(c.ForeignState.Block, num)
+}
+
+// IsAllowedMinter returns whether the given address may mint and burn native
+// balance through the native minter.
+func (c *NativeMinterConfig) IsAllowedMinter(addr common.Address) bool {
+	for _, minter := range c.Minters {
+		if minter == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // BlobScheduleConfig determines target and max number of blobs allow per fork.
 type BlobScheduleConfig struct {
 	Cancun *BlobConfig `json:"cancun,omitempty"`
@@ -1287,6 +1443,21 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64, time u
 // CheckConfigForkOrder checks that we don't "skip" any forks, geth isn't pluggable enough
 // to guarantee that forks can be implemented in a different order than on official networks
 func (c *ChainConfig) CheckConfigForkOrder() error {
+	if err := c.checkBlockRewards(); err != nil {
+		return err
+	}
+	if err := c.checkGasLimitSchedule(); err != nil {
+		return err
+	}
+	if f := c.AddressFilter; f != nil && (f.Block == nil || f.Epoch == 0) {
+		return errors.New("invalid chain configuration: address filter needs a block and a non-zero epoch")
+	}
+	if m := c.NativeMinter; m != nil && m.Block == nil {
+		return errors.New("invalid chain configuration: missing native minter block")
+	}
+	if f := c.ForeignState; f != nil && (f.Block == nil || f.Registry == (common.Address{})) {
+		return errors.New("invalid chain configuration: foreign state reader needs a block and a registry")
+	}
 	// skip checking for non-Parlia egine
 	if c.Parlia == nil {
 		return nil
@@ -1386,6 +1557,48 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 	return nil
 }
 
+// checkBlockRewards checks that the block reward schedule is fully specified and
+// its steps are strictly ordered by activation block.
+func (c *ChainConfig) checkBlockRewards() error {
+	for i, cur := range c.BlockRewards {
+		if cur == nil || cur.Block == nil || cur.Reward == nil {
+			return fmt.Errorf("invalid chain configuration: incomplete block reward step %d", i)
+		}
+		if cur.Reward.Sign() < 0 {
+			return fmt.Errorf("invalid chain configuration: negative block reward at block %v", cur.Block)
+		}
+		if cur.Reward.BitLen() > 256 {
+			return fmt.Errorf("invalid chain configuration: block reward at block %v exceeds 256 bits", cur.Block)
+		}
+		if i > 0 && c.BlockRewards[i-1].Block.Cmp(cur.Block) >= 0 {
+			return fmt.Errorf("unsupported block reward ordering: step at block %v follows step at block %v", cur.Block, c.BlockRewards[i-1].Block)
+		}
+	}
+	return nil
+}
+
+// checkGasLimitSchedule checks that the gas limit schedule is fully specified
+// and stays within the allowed gas limit range.
+func (c *ChainConfig) checkGasLimitSchedule() error {
+	s := c.GasLimitSchedule
+	if s == nil {
+		return nil
+	}
+	if s.Block == nil {
+		return errors.New("invalid chain configuration: missing gas limit schedule block")
+	}
+	if s.InitialGasLimit < MinGasLimit {
+		return fmt.Errorf("invalid chain configuration: initial scheduled gas limit %d below %d", s.InitialGasLimit, MinGasLimit)
+	}
+	if s.GasLimitCap < s.InitialGasLimit {
+		return fmt.Errorf("invalid chain configuration: gas limit cap %d below initial gas limit %d", s.GasLimitCap, s.InitialGasLimit)
+	}
+	if s.GasLimitCap > MaxGasLimit {
+		return fmt.Errorf("invalid chain configuration: gas limit cap %d above %d", s.GasLimitCap, MaxGasLimit)
+	}
+	return nil
+}
+
 func (bc *BlobConfig) validate() error {
 	if bc.Max < 0 {
 		return errors.New("max < 0")
@@ -1536,9 +1749,138 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.VerkleTime, newcfg.VerkleTime, headTimestamp) {
 		return newTimestampCompatError("Verkle fork timestamp", c.VerkleTime, newcfg.VerkleTime)
 	}
+	if stored, next, ok := blockRewardsIncompatible(c.BlockRewards, newcfg.BlockRewards, headNumber); !ok {
+		return newBlockCompatError("block reward schedule", stored, next)
+	}
+	if stored, next, ok := gasLimitScheduleIncompatible(c.GasLimitSchedule, newcfg.GasLimitSchedule, headNumber); !ok {
+		return newBlockCompatError("gas limit schedule", stored, next)
+	}
+	if stored, next, ok := addressFilterIncompatible(c.AddressFilter, newcfg.AddressFilter, headNumber); !ok {
+		return newBlockCompatError("address filter", stored, next)
+	}
+	if stored, next, ok := nativeMinterIncompatible(c.NativeMinter, newcfg.NativeMinter, headNumber); !ok {
+		return newBlockCompatError("native minter", stored, next)
+	}
+	if stored, next, ok := foreignStateIncompatible(c.ForeignState, newcfg.ForeignState, headNumber); !ok {
+		return newBlockCompatError("foreign state reader", stored, next)
+	}
+	if c.RootHasherName() != newcfg.RootHasherName() {
+		return newBlockCompatError("root hasher", common.Big0, common.Big0)
+	}
 	return nil
 }
 
+// foreignStateIncompatible returns the activation blocks of the foreign state
+// readers and false if a reader change would alter the execution of blocks at
+// or below head.
+func foreignStateIncompatible(f1, f2 *ForeignStateConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	if f1 != nil && f2 != nil && configBlockEqual(f1.Block, f2.Block) && f1.Registry == f2.Registry {
+		return nil, nil, true
+	}
+	var stored, next *big.Int
+	if f1 != nil {
+		stored = f1.Block
+	}
+	if f2 != nil {
+		next = f2.Block
+	}
+	if isBlockForked(stored, head) || isBlockForked(next, head) {
+		return stored, next, false
+	}
+	return nil, nil, true
+}
+
+// nativeMinterIncompatible returns the activation blocks of the minters and
+// false if a native minter change would alter the execution of blocks at or
+// below head.
+func nativeMinterIncompatible(m1, m2 *NativeMinterConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	if m1 != nil && m2 != nil && configBlockEqual(m1.Block, m2.Block) && slices.Equal(m1.Minters, m2.Minters) {
+		return nil, nil, true
+	}
+	var stored, next *big.Int
+	if m1 != nil {
+		stored = m1.Block
+	}
+	if m2 != nil {
+		next = m2.Block
+	}
+	if isBlockForked(stored, head) || isBlockForked(next, head) {
+		return stored, next, false
+	}
+	return nil, nil, true
+}
+
+// addressFilterIncompatible returns the activation blocks of the filters and
+// false if an address filter change would alter the validity of blocks at or
+// below head.
+func addressFilterIncompatible(f1, f2 *AddressFilterConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	if f1 != nil && f2 != nil && configBlockEqual(f1.Block, f2.Block) && f1.Contract == f2.Contract && f1.Epoch == f2.Epoch {
+		return nil, nil, true
+	}
+	var stored, next *big.Int
+	if f1 != nil {
+		stored = f1.Block
+	}
+	if f2 != nil {
+		next = f2.Block
+	}
+	if isBlockForked(stored, head) || isBlockForked(next, head) {
+		return stored, next, false
+	}
+	return nil, nil, true
+}
+
+// gasLimitScheduleIncompatible returns the activation blocks of the schedules
+// and false if a gas limit schedule change would alter the gas limits of blocks
+// at or below head.
+func gasLimitScheduleIncompatible(s1, s2 *GasLimitScheduleConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	if s1 != nil && s2 != nil && configBlockEqual(s1.Block, s2.Block) && s1.InitialGasLimit == s2.InitialGasLimit &&
+		s1.IncreaseRate == s2.IncreaseRate && s1.GasLimitCap == s2.GasLimitCap {
+		return nil, nil, true
+	}
+	var stored, next *big.Int
+	if s1 != nil {
+		stored = s1.Block
+	}
+	if s2 != nil {
+		next = s2.Block
+	}
+	if isBlockForked(stored, head) || isBlockForked(next, head) {
+		return stored, next, false
+	}
+	return nil, nil, true
+}
+
+// blockRewardsIncompatible returns the activation blocks of the first differing
+// reward steps and false if a reward schedule change would alter the rewards of
+// blocks at or below head.
+func blockRewardsIncompatible(s1, s2 []*BlockRewardConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	for i := 0; i < max(len(s1), len(s2)); i++ {
+		var x, y *BlockRewardConfig
+		if i < len(s1) {
+			x = s1[i]
+		}
+		if i < len(s2) {
+			y = s2[i]
+		}
+		if x != nil && y != nil && configBlockEqual(x.Block, y.Block) && configBlockEqual(x.Reward, y.Reward) && x.HalvingInterval == y.HalvingInterval {
+			continue
+		}
+		var stored, next *big.Int
+		if x != nil {
+			stored = x.Block
+		}
+		if y != nil {
+			next = y.Block
+		}
+		if isBlockForked(stored, head) || isBlockForked(next, head) {
+			return stored, next, false
+		}
+		return nil, nil, true
+	}
+	return nil, nil, true
+}
+
 // BaseFeeChangeDenominator bounds the amount the base fee can change between blocks.
 func (c *ChainConfig) BaseFeeChangeDenominator() uint64 {
 	return DefaultBaseFeeChangeDenominator
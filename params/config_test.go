@@ -155,3 +155,100 @@ func TestTimestampCompatError(t *testing.T) {
 	require.Equal(t, newTimestampCompatError(errWhat, newUint64(0), newUint64(1681338455)).Error(),
 		"mismatching Shanghai fork timestamp in database (have timestamp 0, want timestamp 1681338455, rewindto timestamp 0)")
 }
+
+func TestBlockRewardSchedule(t *testing.T) {
+	config := &ChainConfig{
+		BlockRewards: []*BlockRewardConfig{
+			{Block: big.NewInt(10), Reward: big.NewInt(1000)},
+			{Block: big.NewInt(20), Reward: big.NewInt(800), HalvingInterval: 5},
+		},
+	}
+	require.NoError(t, config.checkBlockRewards())
+
+	for _, tt := range []struct {
+		number uint64
+		reward *big.Int
+	}{
+		{9, nil},
+		{10, big.NewInt(1000)},
+		{19, big.NewInt(1000)},
+		{20, big.NewInt(800)},
+		{24, big.NewInt(800)},
+		{25, big.NewInt(400)},
+		{35, big.NewInt(100)},
+		{1000, big.NewInt(0)},
+	} {
+		require.Equal(t, tt.reward, config.BlockReward(new(big.Int).SetUint64(tt.number)), "block %d", tt.number)
+	}
+	// Rescheduling rewards is only allowed for future blocks
+	changed := &ChainConfig{
+		BlockRewards: []*BlockRewardConfig{
+			{Block: big.NewInt(10), Reward: big.NewInt(1000)},
+			{Block: big.NewInt(30), Reward: big.NewInt(800)},
+		},
+	}
+	require.Nil(t, config.CheckCompatible(changed, 19, 0))
+	require.Equal(t, &ConfigCompatError{
+		What:          "block reward schedule",
+		StoredBlock:   big.NewInt(20),
+		NewBlock:      big.NewInt(30),
+		RewindToBlock: 19,
+	}, config.CheckCompatible(changed, 25, 0))
+
+	// Unordered schedules must be rejected
+	config.BlockRewards[1].Block = big.NewInt(10)
+	require.Error(t, config.checkBlockRewards())
+
+	// Rewards beyond 256 bits must be rejected
+	config.BlockRewards = []*BlockRewardConfig{{Block: big.NewInt(0), Reward: new(big.Int).Lsh(big.NewInt(1), 256)}}
+	require.Error(t, config.checkBlockRewards())
+}
+
+func TestGasLimitSchedule(t *testing.T) {
+	config := &ChainConfig{
+		GasLimitSchedule: &GasLimitScheduleConfig{
+			Block:           big.NewInt(10),
+			InitialGasLimit: 30_000_000,
+			IncreaseRate:    1_000_000,
+			GasLimitCap:     32_500_000,
+		},
+	}
+	require.NoError(t, config.checkGasLimitSchedule())
+
+	for _, tt := range []struct {
+		number    uint64
+		limit     uint64
+		scheduled bool
+	}{
+		{9, 0, false},
+		{10, 30_000_000, true},
+		{11, 31_000_000, true},
+		{12, 32_000_000, true},
+		{13, 32_500_000, true},
+		{1000, 32_500_000, true},
+	} {
+		limit, scheduled := config.ScheduledGasLimit(new(big.Int).SetUint64(tt.number))
+		require.Equal(t, tt.scheduled, scheduled, "block %d", tt.number)
+		require.Equal(t, tt.limit, limit, "block %d", tt.number)
+	}
+	// Rescheduling is only allowed for future blocks
+	changed := &ChainConfig{
+		GasLimitSchedule: &GasLimitScheduleConfig{
+			Block:           big.NewInt(20),
+			InitialGasLimit: 30_000_000,
+			IncreaseRate:    2_000_000,
+			GasLimitCap:     40_000_000,
+		},
+	}
+	require.Nil(t, config.CheckCompatible(changed, 9, 0))
+	require.Equal(t, &ConfigCompatError{
+		What:          "gas limit schedule",
+		StoredBlock:   big.NewInt(10),
+		NewBlock:      big.NewInt(20),
+		RewindToBlock: 9,
+	}, config.CheckCompatible(changed, 15, 0))
+
+	// Caps below the initial gas limit must be rejected
+	config.GasLimitSchedule.GasLimitCap = 20_000_000
+	require.Error(t, config.checkGasLimitSchedule())
+}
@@ -177,6 +177,11 @@ const (
 
 	P256VerifyGas uint64 = 3450 // secp256r1 elliptic curve signature verifier gas price
 
+	NativeMinterGas uint64 = 20000 // Gas cost of minting or burning native balance through the native minter
+
+	ForeignStateReadGas     uint64 = 10000 // Base gas cost of a verified foreign state read
+	ForeignStateReadWordGas uint64 = 30    // Gas cost per word of the foreign state read input, covering the proof hashing
+
 	// The Refund Quotient is the cap on how much of the used gas can be refunded. Before EIP-3529,
 	// up to half the consumed gas could be refunded. Redefined as 1/5th in EIP-3529
 	RefundQuotient        uint64 = 2
@@ -189,6 +194,8 @@ const (
 	BlobTxPointEvaluationPrecompileGas = 50000   // Gas price for the point evaluation precompile.
 
 	HistoryServeWindow = 8192 // Number of blocks to serve historical block hashes for, EIP-2935.
+
+	VerkleConversionStride = 10000 // Number of merkle leaves converted into the verkle tree per block during the transition
 )
 
 var (
@@ -239,4 +246,12 @@ var (
 	// EIP-7251 - Increase the MAX_EFFECTIVE_BALANCE
 	ConsolidationQueueAddress = common.HexToAddress("0x0000BBdDc7CE488642fb579F8B00f3a590007251")
 	ConsolidationQueueCode    = common.FromHex("3373fffffffffffffffffffffffffffffffffffffffe1460d35760115f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff1461019a57600182026001905f5b5f82111560685781019083028483029004916001019190604d565b9093900492505050366060146088573661019a573461019a575f5260205ff35b341061019a57600154600101600155600354806004026004013381556001015f358155600101602035815560010160403590553360601b5f5260605f60143760745fa0600101600355005b6003546002548082038060021160e7575060025b5f5b8181146101295782810160040260040181607402815460601b815260140181600101548152602001816002015481526020019060030154905260010160e9565b910180921461013b5790600255610146565b90505f6002555f6003555b5f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff141561017357505f5b6001546001828201116101885750505f61018e565b01600190035b5f555f6001556074025ff35b5f5ffd")
+
+	// NativeMinterAddress is where the native minter is called by the system
+	// contracts allowed to mint and burn native balance.
+	NativeMinterAddress = common.HexToAddress("0x0200000000000000000000000000000000000001")
+
+	// ForeignStateReaderAddress is where the state of foreign chains is read,
+	// verified against the state roots registered for them.
+	ForeignStateReaderAddress = common.HexToAddress("0x0200000000000000000000000000000000000002")
 )
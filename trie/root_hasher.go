@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RootHasherFactory creates hashers deriving the roots of the ordered lists of
+// blocks, i.e. the transactions, receipts and withdrawals.
+type RootHasherFactory func() types.TrieHasher
+
+var (
+	rootHashersLock sync.RWMutex
+	rootHashers     = map[string]RootHasherFactory{
+		params.RootHasherMPT:    func() types.TrieHasher { return NewStackTrie(nil) },
+		params.RootHasherBinary: func() types.TrieHasher { return NewBinaryHasher() },
+	}
+)
+
+// RegisterRootHasher makes a root hashing strategy selectable by name in the
+// chain configuration. It panics if the name is already taken, as strategies
+// are meant to be registered once at startup.
+func RegisterRootHasher(name string, factory RootHasherFactory) {
+	rootHashersLock.Lock()
+	defer rootHashersLock.Unlock()
+
+	if _, ok := rootHashers[name]; ok {
+		panic(fmt.Sprintf("root hasher %q already registered", name))
+	}
+	rootHashers[name] = factory
+}
+
+// ValidateRootHasher returns an error if the root hashing strategy selected in
+// the chain configuration is unknown.
+func ValidateRootHasher(config *params.ChainConfig) error {
+	rootHashersLock.RLock()
+	defer rootHashersLock.RUnlock()
+
+	if _, ok := rootHashers[config.RootHasherName()]; !ok {
+		return fmt.Errorf("unknown root hasher %q", config.RootHasherName())
+	}
+	return nil
+}
+
+// NewRootHasher creates a hasher deriving list roots with the strategy selected
+// in the chain configuration, or a stack trie if config is nil. It panics if the
+// strategy is unknown, configurations are checked by ValidateRootHasher when
+// the chain is set up.
+func NewRootHasher(config *params.ChainConfig) types.TrieHasher {
+	rootHashersLock.RLock()
+	factory, ok := rootHashers[config.RootHasherName()]
+	rootHashersLock.RUnlock()
+
+	if !ok {
+		panic(fmt.Sprintf("unknown root hasher %q", config.RootHasherName()))
+	}
+	return factory()
+}
+
+// BinaryHasher derives the root of an ordered list as a binary merkle tree over
+// the hashes of the items, padded with zero hashes to a power of two and mixed
+// in with the length of the list, similarly to SSZ list roots. The root of an
+// empty list is the empty trie root, matching the roots blocks default to.
+type BinaryHasher struct {
+	leaves []common.Hash
+}
+
+// NewBinaryHasher creates a binary merkle tree hasher.
+func NewBinaryHasher() *BinaryHasher {
+	return new(BinaryHasher)
+}
+
+// Reset implements types.TrieHasher, clearing the items of the list.
+func (h *BinaryHasher) Reset() {
+	h.leaves = h.leaves[:0]
+}
+
+// Update implements types.TrieHasher, setting the item at the RLP encoded index
+// in key. Items may be set in any order, but the indices must be contiguous
+// by the time the root is derived.
+func (h *BinaryHasher) Update(key []byte, value []byte) error {
+	var index uint64
+	if err := rlp.DecodeBytes(key, &index); err != nil {
+		return fmt.Errorf("invalid list index %x: %v", key, err)
+	}
+	for uint64(len(h.leaves)) <= index {
+		h.leaves = append(h.leaves, common.Hash{})
+	}
+	h.leaves[index] = crypto.Keccak256Hash(value)
+	return nil
+}
+
+// Hash implements types.TrieHasher, returning the root of the list.
+func (h *BinaryHasher) Hash() common.Hash {
+	if len(h.leaves) == 0 {
+		return types.EmptyRootHash
+	}
+	width := 1
+	for width < len(h.leaves) {
+		width <<= 1
+	}
+	level := make([]common.Hash, width)
+	copy(level, h.leaves)
+	for ; width > 1; width >>= 1 {
+		for i := 0; i < width/2; i++ {
+			level[i] = crypto.Keccak256Hash(level[2*i][:], level[2*i+1][:])
+		}
+	}
+	var length common.Hash
+	binary.BigEndian.PutUint64(length[common.HashLength-8:], uint64(len(h.leaves)))
+	return crypto.Keccak256Hash(level[0][:], length[:])
+}
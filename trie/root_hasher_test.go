@@ -0,0 +1,103 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests the roots derived by the binary hasher.
+func TestBinaryHasher(t *testing.T) {
+	withdrawals := func(n int) types.Withdrawals {
+		list := make(types.Withdrawals, n)
+		for i := range list {
+			list[i] = &types.Withdrawal{Index: uint64(i), Amount: uint64(i + 1)}
+		}
+		return list
+	}
+	// Empty lists hash to the default empty roots of blocks
+	if root := types.DeriveSha(withdrawals(0), NewBinaryHasher()); root != types.EmptyRootHash {
+		t.Fatalf("empty root mismatch: have %x, want %x", root, types.EmptyRootHash)
+	}
+	// Three items are padded to four leaves and mixed in with the length
+	list := withdrawals(3)
+	leaves := make([]common.Hash, 4)
+	for i, w := range list {
+		blob, _ := rlp.EncodeToBytes(w)
+		leaves[i] = crypto.Keccak256Hash(blob)
+	}
+	var length common.Hash
+	binary.BigEndian.PutUint64(length[24:], 3)
+	want := crypto.Keccak256Hash(
+		crypto.Keccak256(crypto.Keccak256(leaves[0][:], leaves[1][:]), crypto.Keccak256(leaves[2][:], leaves[3][:])),
+		length[:],
+	)
+	hasher := NewBinaryHasher()
+	if root := types.DeriveSha(list, hasher); root != want {
+		t.Fatalf("root mismatch: have %x, want %x", root, want)
+	}
+	// The hasher must be reusable after deriving a root
+	if root := types.DeriveSha(list, hasher); root != want {
+		t.Fatalf("reused root mismatch: have %x, want %x", root, want)
+	}
+	// Long lists are inserted out of order by DeriveSha
+	long := withdrawals(300)
+	if a, b := types.DeriveSha(long, NewBinaryHasher()), types.DeriveSha(long[:299], NewBinaryHasher()); a == b {
+		t.Fatalf("roots of different lists collide: %x", a)
+	}
+}
+
+// Tests that root hashers are selected by the chain configuration.
+func TestRootHasherSelection(t *testing.T) {
+	var (
+		config = *params.TestChainConfig
+		list   = types.Withdrawals{{Index: 1}, {Index: 2}}
+	)
+	mpt := types.DeriveSha(list, NewStackTrie(nil))
+	if root := types.DeriveSha(list, NewRootHasher(nil)); root != mpt {
+		t.Fatalf("nil config root mismatch: have %x, want %x", root, mpt)
+	}
+	if root := types.DeriveSha(list, NewRootHasher(&config)); root != mpt {
+		t.Fatalf("default root mismatch: have %x, want %x", root, mpt)
+	}
+	config.RootHasher = params.RootHasherBinary
+	if root, want := types.DeriveSha(list, NewRootHasher(&config)), types.DeriveSha(list, NewBinaryHasher()); root != want {
+		t.Fatalf("binary root mismatch: have %x, want %x", root, want)
+	}
+	// Unknown hashers are rejected until registered
+	config.RootHasher = "test-root-hasher"
+	if err := ValidateRootHasher(&config); err == nil {
+		t.Fatal("unknown root hasher accepted")
+	}
+	RegisterRootHasher(config.RootHasher, func() types.TrieHasher { return NewBinaryHasher() })
+	if err := ValidateRootHasher(&config); err != nil {
+		t.Fatalf("registered root hasher rejected: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate root hasher registered")
+		}
+	}()
+	RegisterRootHasher(params.RootHasherMPT, func() types.TrieHasher { return NewStackTrie(nil) })
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-verkle"
+)
+
+// errTransitionUnsupported is returned for the trie operations which can't be
+// served while the state is split across the merkle and verkle tries.
+var errTransitionUnsupported = errors.New("not supported during the verkle transition")
+
+// TransitionTrie is the trie of a state being converted from a merkle trie to a
+// verkle tree. Reads are served from the verkle overlay, falling back to the
+// frozen merkle base for leaves not converted yet. Writes only hit the overlay.
+//
+// Leaves deleted from the overlay are kept as tombstones, so that they are not
+// resurrected from the base: cleared slots hold zero values, and deleted
+// accounts hold zero basic data and code hash.
+type TransitionTrie struct {
+	base        *StateTrie  // Frozen merkle trie, either the account or a storage trie
+	overlay     *VerkleTrie // Verkle tree collecting all the writes
+	overlayRoot common.Hash // Root of the overlay the trie was opened at
+	storage     bool        // Whether the base is a storage trie
+}
+
+// NewTransitionTrie creates a transition account trie on top of the given
+// merkle base, writing into the given verkle overlay.
+func NewTransitionTrie(base *StateTrie, overlay *VerkleTrie) *TransitionTrie {
+	return &TransitionTrie{
+		base:        base,
+		overlay:     overlay,
+		overlayRoot: overlay.Hash(),
+	}
+}
+
+// StorageTrie creates the transition storage trie of an account on top of its
+// merkle storage trie. All the transition tries of a state share the overlay.
+func (t *TransitionTrie) StorageTrie(base *StateTrie) *TransitionTrie {
+	return &TransitionTrie{
+		base:        base,
+		overlay:     t.overlay,
+		overlayRoot: t.overlayRoot,
+		storage:     true,
+	}
+}
+
+// Base returns the frozen merkle trie of the transition.
+func (t *TransitionTrie) Base() *StateTrie {
+	return t.base
+}
+
+// Overlay returns the verkle tree collecting the writes of the transition.
+func (t *TransitionTrie) Overlay() *VerkleTrie {
+	return t.overlay
+}
+
+// OverlayRoot returns the root of the overlay the trie was opened at, i.e. the
+// parent of the overlay in the trie database.
+func (t *TransitionTrie) OverlayRoot() common.Hash {
+	return t.overlayRoot
+}
+
+// GetKey returns the sha3 preimage of a hashed key of the base.
+func (t *TransitionTrie) GetKey(key []byte) []byte {
+	return t.base.GetKey(key)
+}
+
+// HasOverlayAccount reports whether the overlay holds the account, either
+// converted, written or deleted.
+func (t *TransitionTrie) HasOverlayAccount(addr common.Address) (bool, error) {
+	key := utils.BasicDataKeyWithEvaluatedAddress(t.overlay.cache.Get(addr.Bytes()))
+	val, err := t.overlay.root.Get(key, t.overlay.nodeResolver)
+	if err != nil {
+		return false, err
+	}
+	return val != nil, nil
+}
+
+// HasOverlayStorage reports whether the overlay holds the storage slot, either
+// converted, written or cleared.
+func (t *TransitionTrie) HasOverlayStorage(addr common.Address, key []byte) (bool, error) {
+	val, err := t.overlayStorage(addr, key)
+	if err != nil {
+		return false, err
+	}
+	return val != nil, nil
+}
+
+// overlayStorage retrieves the raw storage slot from the overlay, nil if the
+// slot was never written into it.
+func (t *TransitionTrie) overlayStorage(addr common.Address, key []byte) ([]byte, error) {
+	k := utils.StorageSlotKeyWithEvaluatedAddress(t.overlay.cache.Get(addr.Bytes()), key)
+	return t.overlay.root.Get(k, t.overlay.nodeResolver)
+}
+
+// GetAccount implements state.Trie, retrieving the account from the overlay if
+// it holds it, or from the base otherwise.
+func (t *TransitionTrie) GetAccount(addr common.Address) (*types.StateAccount, error) {
+	exists, err := t.HasOverlayAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return t.base.GetAccount(addr)
+	}
+	acc, err := t.overlay.GetAccount(addr)
+	if err != nil || acc == nil {
+		return acc, err
+	}
+	if bytes.Equal(acc.CodeHash, common.Hash{}.Bytes()) {
+		return nil, nil // tombstone of a deleted account
+	}
+	return acc, nil
+}
+
+// GetStorage implements state.Trie, retrieving the storage slot from the overlay
+// if it holds it, or from the base otherwise.
+func (t *TransitionTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	val, err := t.overlayStorage(addr, key)
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		return common.TrimLeftZeroes(val), nil
+	}
+	if !t.storage {
+		return nil, nil
+	}
+	return t.base.GetStorage(addr, key)
+}
+
+// UpdateAccount implements state.Trie, writing the account into the overlay.
+func (t *TransitionTrie) UpdateAccount(addr common.Address, account *types.StateAccount, codeLen int) error {
+	return t.overlay.UpdateAccount(addr, account, codeLen)
+}
+
+// UpdateStorage implements state.Trie, writing the storage slot into the overlay.
+func (t *TransitionTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return t.overlay.UpdateStorage(addr, key, value)
+}
+
+// DeleteAccount implements state.Trie, leaving a tombstone of the account in
+// the overlay.
+func (t *TransitionTrie) DeleteAccount(addr common.Address) error {
+	var (
+		zero   [32]byte
+		values = make([][]byte, verkle.NodeWidth)
+	)
+	values[utils.BasicDataLeafKey] = zero[:]
+	values[utils.CodeHashLeafKey] = zero[:]
+
+	root, ok := t.overlay.root.(*verkle.InternalNode)
+	if !ok {
+		return errInvalidRootType
+	}
+	if err := root.InsertValuesAtStem(t.overlay.cache.GetStem(addr[:]), values, t.overlay.nodeResolver); err != nil {
+		return fmt.Errorf("DeleteAccount (%x) error: %v", addr, err)
+	}
+	return nil
+}
+
+// DeleteStorage implements state.Trie, clearing the storage slot in the overlay.
+func (t *TransitionTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return t.overlay.DeleteStorage(addr, key)
+}
+
+// UpdateContractCode implements state.Trie, writing the code into the overlay.
+func (t *TransitionTrie) UpdateContractCode(addr common.Address, codeHash common.Hash, code []byte) error {
+	return t.overlay.UpdateContractCode(addr, codeHash, code)
+}
+
+// Hash returns the root hash of the overlay, which is the state root during the
+// transition.
+func (t *TransitionTrie) Hash() common.Hash {
+	return t.overlay.Hash()
+}
+
+// Commit collects the dirty nodes of the overlay, the base is never modified.
+func (t *TransitionTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet) {
+	return t.overlay.Commit(collectLeaf)
+}
+
+// Witness returns the nodes of the overlay which have been accessed.
+func (t *TransitionTrie) Witness() map[string]struct{} {
+	return t.overlay.Witness()
+}
+
+// NodeIterator implements state.Trie, but iterating a state split across two
+// tries is not supported.
+func (t *TransitionTrie) NodeIterator(startKey []byte) (NodeIterator, error) {
+	return nil, errTransitionUnsupported
+}
+
+// Prove implements state.Trie, but proving a state split across two tries is
+// not supported.
+func (t *TransitionTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errTransitionUnsupported
+}
+
+// IsVerkle indicates that the transition trie hashes as a verkle tree.
+func (t *TransitionTrie) IsVerkle() bool {
+	return true
+}
+
+// Copy returns a deep-copied transition trie.
+func (t *TransitionTrie) Copy() *TransitionTrie {
+	return &TransitionTrie{
+		base:        t.base.Copy(),
+		overlay:     t.overlay.Copy(),
+		overlayRoot: t.overlayRoot,
+		storage:     t.storage,
+	}
+}
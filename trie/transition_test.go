@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/holiman/uint256"
+)
+
+// Tests that transition tries read through the overlay into the merkle base,
+// and that overlay deletions shadow the base instead of resurrecting it.
+func TestTransitionTrieReadWrite(t *testing.T) {
+	var (
+		db       = newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.PathScheme)
+		base, _  = NewStateTrie(TrieID(types.EmptyRootHash), db)
+		slots, _ = NewStateTrie(TrieID(types.EmptyRootHash), db)
+	)
+	for addr, acct := range accounts {
+		if err := base.UpdateAccount(addr, acct, 0); err != nil {
+			t.Fatalf("Failed to update base account, %v", err)
+		}
+	}
+	for key, val := range storages[common.Address{1}] {
+		if err := slots.UpdateStorage(common.Address{1}, key.Bytes(), val); err != nil {
+			t.Fatalf("Failed to update base storage, %v", err)
+		}
+	}
+	overlay, _ := NewVerkleTrie(types.EmptyVerkleHash, db, utils.NewPointCache(100))
+	tr := NewTransitionTrie(base, overlay)
+	st := tr.StorageTrie(slots)
+
+	// Unconverted leaves are served from the base
+	for addr, acct := range accounts {
+		stored, err := tr.GetAccount(addr)
+		if err != nil {
+			t.Fatalf("Failed to get account, %v", err)
+		}
+		if stored == nil || stored.Nonce != acct.Nonce {
+			t.Fatalf("base account %x mismatch: have %v, want %v", addr, stored, acct)
+		}
+	}
+	if val, _ := st.GetStorage(common.Address{1}, common.Hash{10}.Bytes()); !bytes.Equal(val, []byte{10}) {
+		t.Fatalf("base slot mismatch: have %x, want 0a", val)
+	}
+	// Writes land in the overlay and shadow the base
+	updated := &types.StateAccount{Nonce: 101, Balance: uint256.NewInt(1), CodeHash: types.EmptyCodeHash.Bytes()}
+	if err := tr.UpdateAccount(common.Address{1}, updated, 0); err != nil {
+		t.Fatalf("Failed to update account, %v", err)
+	}
+	if err := st.UpdateStorage(common.Address{1}, common.Hash{10}.Bytes(), []byte{0x42}); err != nil {
+		t.Fatalf("Failed to update storage, %v", err)
+	}
+	if stored, _ := tr.GetAccount(common.Address{1}); stored == nil || stored.Nonce != 101 {
+		t.Fatalf("overlay account mismatch: have %v", stored)
+	}
+	if val, _ := st.GetStorage(common.Address{1}, common.Hash{10}.Bytes()); !bytes.Equal(val, []byte{0x42}) {
+		t.Fatalf("overlay slot mismatch: have %x, want 42", val)
+	}
+	if exists, _ := tr.HasOverlayAccount(common.Address{2}); exists {
+		t.Fatalf("unconverted account reported in overlay")
+	}
+	// Deletions leave tombstones hiding the base leaves
+	if err := tr.DeleteAccount(common.Address{2}); err != nil {
+		t.Fatalf("Failed to delete account, %v", err)
+	}
+	if err := st.DeleteStorage(common.Address{1}, common.Hash{11}.Bytes()); err != nil {
+		t.Fatalf("Failed to delete storage, %v", err)
+	}
+	if stored, err := tr.GetAccount(common.Address{2}); err != nil || stored != nil {
+		t.Fatalf("deleted account resurrected: %v, %v", stored, err)
+	}
+	if val, _ := st.GetStorage(common.Address{1}, common.Hash{11}.Bytes()); len(val) != 0 {
+		t.Fatalf("cleared slot resurrected: %x", val)
+	}
+	// The base is never modified and the state hashes as the overlay
+	if stored, _ := base.GetAccount(common.Address{2}); stored == nil {
+		t.Fatalf("base account deleted")
+	}
+	if tr.Hash() != overlay.Hash() || tr.OverlayRoot() != types.EmptyVerkleHash {
+		t.Fatalf("transition roots mismatch")
+	}
+}
@@ -276,6 +276,20 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	return hdb.Cap(limit)
 }
 
+// ResizeCleanCache replaces the clean node cache with an empty one of the given
+// size in bytes, disabling it if zero.
+func (db *Database) ResizeCleanCache(size int) error {
+	switch backend := db.backend.(type) {
+	case *hashdb.Database:
+		backend.ResizeCleanCache(size)
+	case *pathdb.Database:
+		backend.ResizeCleanCache(size)
+	default:
+		return errors.New("not supported")
+	}
+	return nil
+}
+
 // Reference adds a new reference from a parent node to a child node. This function
 // is used to add reference between internal trie node and external node(e.g. storage
 // trie root), all internal trie nodes are referenced together by database itself.
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VictoriaMetrics/fastcache"
@@ -78,11 +79,11 @@ var Defaults = &Config{
 // the disk database. The aim is to accumulate trie writes in-memory and only
 // periodically flush a couple tries to disk, garbage collecting the remainder.
 type Database struct {
-	diskdb  ethdb.Database              // Persistent storage for matured trie nodes
-	cleans  *fastcache.Cache            // GC friendly memory cache of clean node RLPs
-	dirties map[common.Hash]*cachedNode // Data and references relationships of dirty trie nodes
-	oldest  common.Hash                 // Oldest tracked node, flush-list head
-	newest  common.Hash                 // Newest tracked node, flush-list tail
+	diskdb  ethdb.Database                  // Persistent storage for matured trie nodes
+	cleans  atomic.Pointer[fastcache.Cache] // GC friendly memory cache of clean node RLPs, replaced on resize
+	dirties map[common.Hash]*cachedNode     // Data and references relationships of dirty trie nodes
+	oldest  common.Hash                     // Oldest tracked node, flush-list head
+	newest  common.Hash                     // Newest tracked node, flush-list tail
 
 	gctime  time.Duration      // Time spent on garbage collection since last commit
 	gcnodes uint64             // Nodes garbage collected since last commit
@@ -128,15 +129,26 @@ func New(diskdb ethdb.Database, config *Config) *Database {
 	if config == nil {
 		config = Defaults
 	}
-	var cleans *fastcache.Cache
-	if config.CleanCacheSize > 0 {
-		cleans = fastcache.New(config.CleanCacheSize)
-	}
-	return &Database{
+	db := &Database{
 		diskdb:  diskdb,
-		cleans:  cleans,
 		dirties: make(map[common.Hash]*cachedNode),
 	}
+	if config.CleanCacheSize > 0 {
+		db.cleans.Store(fastcache.New(config.CleanCacheSize))
+	}
+	return db
+}
+
+// ResizeCleanCache replaces the clean node cache with an empty one of the given
+// size in bytes, disabling it if zero.
+func (db *Database) ResizeCleanCache(size int) {
+	var cleans *fastcache.Cache
+	if size > 0 {
+		cleans = fastcache.New(size)
+	}
+	if old := db.cleans.Swap(cleans); old != nil {
+		old.Reset()
+	}
 }
 
 // insert inserts a trie node into the memory database. All nodes inserted by
@@ -178,8 +190,9 @@ func (db *Database) node(hash common.Hash) ([]byte, error) {
 		return nil, errors.New("not found")
 	}
 	// Retrieve the node from the clean cache if available
-	if db.cleans != nil {
-		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
+	cleans := db.cleans.Load()
+	if cleans != nil {
+		if enc := cleans.Get(nil, hash[:]); enc != nil {
 			memcacheCleanHitMeter.Mark(1)
 			memcacheCleanReadMeter.Mark(int64(len(enc)))
 			return enc, nil
@@ -203,8 +216,8 @@ func (db *Database) node(hash common.Hash) ([]byte, error) {
 	// Content unavailable in memory, attempt to retrieve from disk
 	enc := rawdb.ReadLegacyTrieNode(db.diskdb, hash)
 	if len(enc) != 0 {
-		if db.cleans != nil {
-			db.cleans.Set(hash[:], enc)
+		if cleans != nil {
+			cleans.Set(hash[:], enc)
 			memcacheCleanMissMeter.Mark(1)
 			memcacheCleanWriteMeter.Mark(int64(len(enc)))
 		}
@@ -523,8 +536,8 @@ func (c *cleaner) Put(key []byte, rlp []byte) error {
 		c.db.childrenSize -= common.StorageSize(len(node.external) * common.HashLength)
 	}
 	// Move the flushed node into the clean cache to prevent insta-reloads
-	if c.db.cleans != nil {
-		c.db.cleans.Set(hash[:], rlp)
+	if cleans := c.db.cleans.Load(); cleans != nil {
+		cleans.Set(hash[:], rlp)
 		memcacheCleanWriteMeter.Mark(int64(len(rlp)))
 	}
 	return nil
@@ -605,8 +618,8 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize, common.Stora
 
 // Close closes the trie database and releases all held resources.
 func (db *Database) Close() error {
-	if db.cleans != nil {
-		db.cleans.Reset()
+	if cleans := db.cleans.Load(); cleans != nil {
+		cleans.Reset()
 	}
 	return nil
 }
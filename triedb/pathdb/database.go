@@ -558,6 +558,16 @@ func (db *Database) Size() (diffs common.StorageSize, nodes common.StorageSize,
 	return diffs, nodes, immutableNodes
 }
 
+// ResizeCleanCache replaces the clean node cache with an empty one of the given
+// size in bytes, disabling it if zero.
+func (db *Database) ResizeCleanCache(size int) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.config.CleanCacheSize = size
+	db.tree.bottom().resizeCache(size)
+}
+
 // Scheme returns the node scheme used in the database.
 func (db *Database) Scheme() string {
 	return rawdb.PathScheme
@@ -404,6 +404,21 @@ func (dl *diskLayer) size() (common.StorageSize, common.StorageSize) {
 	return common.StorageSize(dirtyNodes), common.StorageSize(dirtyimmutableNodes)
 }
 
+// resizeCache replaces the clean node cache with an empty one of the given size
+// in bytes, disabling it if zero.
+func (dl *diskLayer) resizeCache(size int) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if dl.nodes != nil {
+		dl.nodes.Reset()
+	}
+	dl.nodes = nil
+	if size > 0 {
+		dl.nodes = fastcache.New(size)
+	}
+}
+
 // resetCache releases the memory held by clean cache to prevent memory leak.
 func (dl *diskLayer) resetCache() {
 	dl.lock.RLock()